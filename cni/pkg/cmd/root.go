@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
@@ -30,6 +31,7 @@ import (
 	"istio.io/istio/cni/pkg/install"
 	udsLog "istio.io/istio/cni/pkg/log"
 	"istio.io/istio/cni/pkg/monitoring"
+	"istio.io/istio/cni/pkg/reconcile"
 	"istio.io/istio/cni/pkg/repair"
 	iptables "istio.io/istio/tools/istio-iptables/pkg/constants"
 	"istio.io/pkg/collateral"
@@ -63,6 +65,7 @@ var rootCmd = &cobra.Command{
 		}
 		log.Infof("CNI install configuration: \n%+v", cfg.InstallConfig)
 		log.Infof("CNI race repair configuration: \n%+v", cfg.RepairConfig)
+		log.Infof("CNI iptables reconcile configuration: \n%+v", cfg.ReconcileConfig)
 
 		// Start metrics server
 		monitoring.SetupMonitoring(cfg.InstallConfig.MonitoringPort, "/metrics", ctx.Done())
@@ -80,6 +83,8 @@ var rootCmd = &cobra.Command{
 
 		repair.StartRepair(ctx, &cfg.RepairConfig)
 
+		reconcile.StartReconcile(ctx, &cfg.ReconcileConfig)
+
 		if err = installer.Run(ctx); err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				log.Infof("Installer exits with %v", err)
@@ -165,6 +170,11 @@ func init() {
 		"A set of label selectors in label=value format that will be added to the pod list filters")
 	registerStringParameter(constants.RepairFieldSelectors, "",
 		"A set of field selectors in label=value format that will be added to the pod list filters")
+
+	// Reconcile
+	registerDurationParameter(constants.ReconcileInterval, 0,
+		"If set, the iptables rules of the pods programmed by the CNI plugin are checked at this interval, and repaired "+
+			"if they drifted. Requires the privileges to enter the network namespaces of the pods")
 }
 
 func registerStringParameter(name, value, usage string) {
@@ -203,6 +213,15 @@ func registerBooleanParameter(name string, value bool, usage string) {
 	bindViper(name)
 }
 
+func registerDurationParameter(name string, value time.Duration, usage string) {
+	envName := strings.Replace(strings.ToUpper(name), "-", "_", -1)
+	rootCmd.Flags().Duration(name, value, usage)
+	// Note: we do not rely on istio env package to retrieve configuration. We relies on viper.
+	// This is just to make sure the reference doc tool can generate doc with these vars as env variable at istio.io.
+	env.RegisterDurationVar(envName, value, usage)
+	bindViper(name)
+}
+
 func bindViper(name string) {
 	if err := viper.BindPFlag(name, rootCmd.Flags().Lookup(name)); err != nil {
 		log.Error(err)
@@ -263,5 +282,10 @@ func constructConfig() (*config.Config, error) {
 		FieldSelectors:     viper.GetString(constants.RepairFieldSelectors),
 	}
 
-	return &config.Config{InstallConfig: installCfg, RepairConfig: repairCfg}, nil
+	reconcileCfg := config.ReconcileConfig{
+		Interval: viper.GetDuration(constants.ReconcileInterval),
+		PodsDir:  constants.ReconcilePodsDir,
+	}
+
+	return &config.Config{InstallConfig: installCfg, RepairConfig: repairCfg, ReconcileConfig: reconcileCfg}, nil
 }
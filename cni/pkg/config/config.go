@@ -17,11 +17,13 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	InstallConfig InstallConfig
-	RepairConfig  RepairConfig
+	InstallConfig   InstallConfig
+	RepairConfig    RepairConfig
+	ReconcileConfig ReconcileConfig
 }
 
 // InstallConfig struct defines the Istio CNI installation options
@@ -113,6 +115,16 @@ type RepairConfig struct {
 	FieldSelectors string
 }
 
+// ReconcileConfig struct defines the Istio CNI iptables reconcile configuration
+type ReconcileConfig struct {
+	// The interval at which the iptables rules of the pods on the node are checked and repaired if they drifted.
+	// Reconciling is disabled if zero.
+	Interval time.Duration
+
+	// The directory where the CNI plugin records the iptables configuration of the pods it programmed
+	PodsDir string
+}
+
 func (c InstallConfig) String() string {
 	var b strings.Builder
 	b.WriteString("CNINetDir: " + c.CNINetDir + "\n")
@@ -157,3 +169,10 @@ func (c RepairConfig) String() string {
 	b.WriteString("FieldSelectors: " + c.FieldSelectors + "\n")
 	return b.String()
 }
+
+func (c ReconcileConfig) String() string {
+	var b strings.Builder
+	b.WriteString("Interval: " + fmt.Sprint(c.Interval) + "\n")
+	b.WriteString("PodsDir: " + c.PodsDir + "\n")
+	return b.String()
+}
@@ -48,6 +48,9 @@ const (
 	RepairInitExitCode       = "repair-init-container-exit-code"
 	RepairLabelSelectors     = "repair-label-selectors"
 	RepairFieldSelectors     = "repair-field-selectors"
+
+	// Reconcile
+	ReconcileInterval = "reconcile-interval"
 )
 
 // Internal constants
@@ -58,6 +61,9 @@ var (
 	ServiceAccountPath    = "/var/run/secrets/kubernetes.io/serviceaccount"
 	DefaultKubeconfigMode = 0o600
 	UDSLogPath            = "/log"
+	// Directory of the host, shared by the CNI plugin and the node agent, where the plugin records the iptables
+	// configuration of the pods it programmed for the node agent to reconcile their rules.
+	ReconcilePodsDir = "/var/run/istio-cni/pods"
 
 	// K8s liveness and readiness endpoints
 	LivenessEndpoint  = "/healthz"
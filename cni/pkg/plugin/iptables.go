@@ -22,6 +22,8 @@ import (
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/spf13/viper"
 
+	cniconstants "istio.io/istio/cni/pkg/constants"
+	"istio.io/istio/cni/pkg/reconcile"
 	"istio.io/istio/tools/istio-iptables/pkg/cmd"
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
 	"istio.io/pkg/env"
@@ -55,6 +57,7 @@ func (ipt *iptables) Program(podName, netns string, rdrct *Redirect) error {
 	viper.Set(constants.LocalOutboundPortsExclude, rdrct.excludeOutboundPorts)
 	viper.Set(constants.ServiceExcludeCidr, rdrct.excludeIPCidrs)
 	viper.Set(constants.KubeVirtInterfaces, rdrct.kubevirtInterfaces)
+	viper.Set(constants.OutboundUDPPorts, rdrct.outboundUDPPorts)
 	drf := dryRunFilePath.Get()
 	viper.Set(constants.DryRun, drf != "")
 	viper.Set(constants.OutputPath, drf)
@@ -76,6 +79,17 @@ func (ipt *iptables) Program(podName, netns string, rdrct *Redirect) error {
 		if err := iptablesCmd.Execute(); err != nil {
 			return err
 		}
+		// Record the configuration, constructed in the network namespace of the pod, for the node agent to reconcile
+		// the rules.
+		if !viper.GetBool(constants.DryRun) {
+			cfg, err := cmd.ConstructConfig()
+			if err == nil {
+				err = reconcile.Save(cniconstants.ReconcilePodsDir, podName, cfg)
+			}
+			if err != nil {
+				log.Warnf("Failed to record the iptables configuration of %v, its rules will not be reconciled: %v", podName, err)
+			}
+		}
 		return nil
 	}); err != nil {
 		return err
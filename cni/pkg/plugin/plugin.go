@@ -31,6 +31,7 @@ import (
 
 	"istio.io/api/annotation"
 	"istio.io/istio/cni/pkg/constants"
+	"istio.io/istio/cni/pkg/reconcile"
 	"istio.io/pkg/log"
 )
 
@@ -305,5 +306,9 @@ func CmdCheck(args *skel.CmdArgs) (err error) {
 }
 
 func CmdDelete(args *skel.CmdArgs) (err error) {
+	// Stop reconciling the iptables rules of the pod, if they were.
+	if err := reconcile.Remove(constants.ReconcilePodsDir, args.Netns); err != nil {
+		log.Warnf("Failed to remove the iptables configuration record of network namespace %s: %v", args.Netns, err)
+	}
 	return nil
 }
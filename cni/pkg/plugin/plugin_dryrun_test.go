@@ -32,6 +32,7 @@ import (
 	"istio.io/api/annotation"
 	"istio.io/istio/pilot/cmd/pilot-agent/options"
 	diff "istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/test/env"
 	"istio.io/istio/tools/istio-iptables/pkg/cmd"
 )
@@ -125,6 +126,19 @@ func TestIPTablesRuleGeneration(t *testing.T) {
 			},
 			golden: filepath.Join(env.IstioSrc, "cni/pkg/plugin/testdata/include-exclude-ports.txt.golden"),
 		},
+		{
+			name: "outbound-udp-ports",
+			input: &PodInfo{
+				Containers:     []string{"test", "istio-proxy"},
+				InitContainers: map[string]struct{}{"istio-validate": {}},
+				Annotations: map[string]string{
+					annotation.SidecarStatus.Name:        "true",
+					constants.OutboundUDPPortsAnnotation: "514,5353",
+				},
+				ProxyEnvironments: map[string]string{},
+			},
+			golden: filepath.Join(env.IstioSrc, "cni/pkg/plugin/testdata/outbound-udp-ports.txt.golden"),
+		},
 		{
 			name: "tproxy",
 			input: &PodInfo{
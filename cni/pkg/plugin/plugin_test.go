@@ -514,3 +514,27 @@ func Test_dedupPorts(t *testing.T) {
 		})
 	}
 }
+
+func Test_validatePortRangeList(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   string
+		wantErr bool
+	}{
+		{name: "Empty", ports: ""},
+		{name: "Ports", ports: "1234,2345"},
+		{name: "Ranges", ports: "1234,10000-20000"},
+		{name: "Single port range", ports: "10000-10000"},
+		{name: "Reversed range", ports: "20000-10000", wantErr: true},
+		{name: "Open range", ports: "10000-", wantErr: true},
+		{name: "Non-parseable", ports: "abcd", wantErr: true},
+		{name: "Out of range", ports: "10000-70000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePortRangeList(tt.ports); (err != nil) != tt.wantErr {
+				t.Errorf("validatePortRangeList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"istio.io/api/annotation"
 	"istio.io/istio/pilot/cmd/pilot-agent/options"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/tools/istio-iptables/pkg/cmd"
 	"istio.io/pkg/log"
 )
@@ -52,6 +53,8 @@ var (
 
 	kubevirtInterfacesKey = annotation.SidecarTrafficKubevirtInterfaces.Name
 
+	outboundUDPPortsKey = constants.OutboundUDPPortsAnnotation
+
 	annotationRegistry = map[string]*annotationParam{
 		"inject":               {injectAnnotationKey, "", alwaysValidFunc},
 		"status":               {sidecarStatusKey, "", alwaysValidFunc},
@@ -59,10 +62,11 @@ var (
 		"ports":                {sidecarPortListKey, "", validatePortList},
 		"includeIPCidrs":       {includeIPCidrsKey, defaultRedirectIPCidr, validateCIDRListWithWildcard},
 		"excludeIPCidrs":       {excludeIPCidrsKey, defaultRedirectExcludeIPCidr, validateCIDRList},
-		"includePorts":         {includePortsKey, "", validatePortListWithWildcard},
-		"excludeInboundPorts":  {excludeInboundPortsKey, defaultRedirectExcludePort, validatePortList},
+		"includePorts":         {includePortsKey, "", validatePortRangeListWithWildcard},
+		"excludeInboundPorts":  {excludeInboundPortsKey, defaultRedirectExcludePort, validatePortRangeList},
 		"excludeOutboundPorts": {excludeOutboundPortsKey, defaultRedirectExcludePort, validatePortList},
 		"kubevirtInterfaces":   {kubevirtInterfacesKey, defaultKubevirtInterfaces, alwaysValidFunc},
+		"outboundUDPPorts":     {outboundUDPPortsKey, "", validatePortList},
 	}
 )
 
@@ -78,6 +82,7 @@ type Redirect struct {
 	excludeOutboundPorts string
 	kubevirtInterfaces   string
 	excludeInterfaces    string
+	outboundUDPPorts     string
 	dnsRedirect          bool
 	invalidDrop          bool
 }
@@ -163,9 +168,35 @@ func validatePortList(ports string) error {
 	return nil
 }
 
-func validatePortListWithWildcard(ports string) error {
+// validatePortRangeList validates a list of ports and inclusive port ranges, such as "8080,10000-20000", as accepted by
+// istio-iptables for the inbound ports.
+func validatePortRangeList(ports string) error {
+	ports = strings.TrimSpace(ports)
+	if len(ports) == 0 {
+		return nil
+	}
+	for _, portRange := range splitPorts(ports) {
+		bounds := strings.SplitN(portRange, "-", 2)
+		start, err := parsePort(bounds[0])
+		if err != nil {
+			return fmt.Errorf("portList %q invalid: %v", ports, err)
+		}
+		if len(bounds) == 2 {
+			end, err := parsePort(bounds[1])
+			if err != nil {
+				return fmt.Errorf("portList %q invalid: %v", ports, err)
+			}
+			if end < start {
+				return fmt.Errorf("portList %q invalid: port range %q ends before it starts", ports, portRange)
+			}
+		}
+	}
+	return nil
+}
+
+func validatePortRangeListWithWildcard(ports string) error {
 	if ports != "*" {
-		return validatePortList(ports)
+		return validatePortRangeList(ports)
 	}
 	return nil
 }
@@ -250,6 +281,11 @@ func NewRedirect(pi *PodInfo) (*Redirect, error) {
 		return nil, fmt.Errorf("annotation value error for value %s; annotationFound = %t: %v",
 			"kubevirtInterfaces", isFound, valErr)
 	}
+	isFound, redir.outboundUDPPorts, valErr = getAnnotationOrDefault("outboundUDPPorts", pi.Annotations)
+	if valErr != nil {
+		return nil, fmt.Errorf("annotation value error for value %s; annotationFound = %t: %v",
+			"outboundUDPPorts", isFound, valErr)
+	}
 	if v, found := pi.ProxyEnvironments[options.DNSCaptureByAgent.Name]; found {
 		// parse and set the bool value of dnsRedirect
 		redir.dnsRedirect, valErr = strconv.ParseBool(v)
@@ -0,0 +1,208 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"istio.io/istio/cni/pkg/config"
+	"istio.io/istio/tools/istio-iptables/pkg/capture"
+	"istio.io/istio/tools/istio-iptables/pkg/cmd"
+	iptablesconfig "istio.io/istio/tools/istio-iptables/pkg/config"
+	"istio.io/pkg/log"
+)
+
+var reconcileLog = log.RegisterScope("reconcile", "CNI iptables reconcile", 0)
+
+// record is the iptables configuration of a pod programmed by the CNI plugin.
+type record struct {
+	Pod    string                 `json:"pod"`
+	Config *iptablesconfig.Config `json:"config"`
+}
+
+// recordPath returns the path of the record of the pod in the network namespace netns, which is unique on the node
+// and known to both the ADD and DEL commands of the CNI plugin.
+func recordPath(dir, netns string) string {
+	return filepath.Join(dir, strings.ReplaceAll(strings.Trim(netns, "/"), "/", "_")+".json")
+}
+
+// Save records the iptables configuration of a pod programmed by the CNI plugin, for the node agent to reconcile its
+// rules. Nothing is recorded unless the node agent reconciles the rules, i.e. unless dir exists.
+func Save(dir, pod string, cfg *iptablesconfig.Config) error {
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	b, err := json.Marshal(record{Pod: pod, Config: cfg})
+	if err != nil {
+		return err
+	}
+	// Write the record atomically, as the node agent may read it at any time.
+	f, err := os.CreateTemp(dir, ".record-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), recordPath(dir, cfg.NetworkNamespace))
+}
+
+// Remove removes the record of the pod in the network namespace netns, if any.
+func Remove(dir, netns string) error {
+	if netns == "" {
+		return nil
+	}
+	if err := os.Remove(recordPath(dir, netns)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// reconciledPod is a pod whose rules are reconciled, with the configurator keeping whether its rules were applied.
+type reconciledPod struct {
+	pod          string
+	modTime      time.Time
+	configurator *capture.IptablesConfigurator
+}
+
+// Reconciler reconciles the iptables rules of the pods recorded by the CNI plugin on the node.
+type Reconciler struct {
+	dir  string
+	pods map[string]*reconciledPod
+	// newConfigurator is a unit test override for the configurator of a pod.
+	newConfigurator func(cfg *iptablesconfig.Config) *capture.IptablesConfigurator
+}
+
+func NewReconciler(dir string) *Reconciler {
+	return &Reconciler{
+		dir:             dir,
+		pods:            map[string]*reconciledPod{},
+		newConfigurator: cmd.NewReconciler,
+	}
+}
+
+// Run reconciles the rules of the pods every interval, until ctx is done.
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcile()
+		}
+	}
+}
+
+func (r *Reconciler) reconcile() {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		reconcileLog.Errorf("Failed to list the pods to reconcile: %v", err)
+		return
+	}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(r.dir, e.Name())
+		p, err := r.pod(path)
+		if err != nil {
+			reconcileLog.Warnf("Failed to read the pod record %s: %v", path, err)
+			continue
+		}
+		if p == nil {
+			continue
+		}
+		seen[path] = true
+		reconcileLog.Debugf("Reconciling the iptables rules of pod %s", p.pod)
+		if p.configurator.Reconcile() {
+			reconcileLog.Infof("Repaired drift of the iptables rules of pod %s", p.pod)
+		}
+	}
+	for path := range r.pods {
+		if !seen[path] {
+			delete(r.pods, path)
+		}
+	}
+	reconciledPods.Record(float64(len(r.pods)))
+}
+
+// pod returns the pod recorded at path, or nil if its network namespace no longer exists.
+func (r *Reconciler) pod(path string) (*reconciledPod, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	// The record is rewritten if the pod is programmed again.
+	if p, f := r.pods[path]; f && p.modTime.Equal(info.ModTime()) {
+		return p, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rec := record{}
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, err
+	}
+	if rec.Config == nil {
+		return nil, fmt.Errorf("no iptables configuration")
+	}
+	if _, err := os.Stat(rec.Config.NetworkNamespace); os.IsNotExist(err) {
+		// The pod was deleted without a DEL command removing its record, or its network namespace is not visible to
+		// the node agent, which only reconciles the named network namespaces of /var/run/netns.
+		reconcileLog.Infof("Network namespace %s of pod %s not found, no longer reconciling it",
+			rec.Config.NetworkNamespace, rec.Pod)
+		delete(r.pods, path)
+		return nil, os.Remove(path)
+	}
+	p := &reconciledPod{pod: rec.Pod, modTime: info.ModTime(), configurator: r.newConfigurator(rec.Config)}
+	r.pods[path] = p
+	return p, nil
+}
+
+// StartReconcile reconciles the iptables rules of the pods programmed by the CNI plugin, if enabled.
+func StartReconcile(ctx context.Context, cfg *config.ReconcileConfig) {
+	if cfg.Interval <= 0 {
+		reconcileLog.Info("CNI iptables reconcile is disabled.")
+		// Stop the CNI plugin recording the pods.
+		if err := os.RemoveAll(cfg.PodsDir); err != nil {
+			reconcileLog.Errorf("Failed to remove %s: %v", cfg.PodsDir, err)
+		}
+		return
+	}
+	if err := os.MkdirAll(cfg.PodsDir, 0o755); err != nil {
+		reconcileLog.Errorf("CNI iptables reconcile could not create %s: %v", cfg.PodsDir, err)
+		return
+	}
+	reconcileLog.Infof("Start CNI iptables reconcile, every %v.", cfg.Interval)
+	go NewReconciler(cfg.PodsDir).Run(ctx, cfg.Interval)
+}
@@ -0,0 +1,113 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/istio/cni/pkg/config"
+	"istio.io/istio/tools/istio-iptables/pkg/capture"
+	iptablesconfig "istio.io/istio/tools/istio-iptables/pkg/config"
+	"istio.io/istio/tools/istio-iptables/pkg/dependencies"
+)
+
+func TestSaveAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &iptablesconfig.Config{NetworkNamespace: "/var/run/netns/cni-1", ProxyPort: "15001", CNIMode: true}
+
+	if err := Save(filepath.Join(dir, "missing"), "pod-1", cfg); err != nil {
+		t.Fatalf("expected no record without the directory, got %v", err)
+	}
+	if err := Save(dir, "pod-1", cfg); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "var_run_netns_cni-1.json" {
+		t.Fatalf("unexpected records %v", entries)
+	}
+
+	if err := Remove(dir, cfg.NetworkNamespace); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(dir, cfg.NetworkNamespace); err != nil {
+		t.Fatalf("expected removing a missing record to succeed, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected the record to be removed, got %v", entries)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	dir := t.TempDir()
+	// Network namespaces are faked by files.
+	netns := filepath.Join(t.TempDir(), "cni-1")
+	if err := os.WriteFile(netns, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	running := &iptablesconfig.Config{NetworkNamespace: netns, ProxyPort: "15001", CNIMode: true, DryRun: true}
+	deleted := &iptablesconfig.Config{NetworkNamespace: filepath.Join(dir, "cni-2"), CNIMode: true, DryRun: true}
+	for pod, cfg := range map[string]*iptablesconfig.Config{"running": running, "deleted": deleted} {
+		if err := Save(dir, pod, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewReconciler(dir)
+	var configured []*iptablesconfig.Config
+	r.newConfigurator = func(cfg *iptablesconfig.Config) *capture.IptablesConfigurator {
+		configured = append(configured, cfg)
+		return capture.NewIptablesConfigurator(cfg, &dependencies.StdoutStubDependencies{})
+	}
+	r.reconcile()
+	r.reconcile()
+
+	if len(configured) != 1 || !reflect.DeepEqual(configured[0], running) {
+		t.Fatalf("expected the running pod to be configured once, got %v", configured)
+	}
+	if _, err := os.Stat(recordPath(dir, deleted.NetworkNamespace)); !os.IsNotExist(err) {
+		t.Fatalf("expected the record of the deleted pod to be removed, got %v", err)
+	}
+
+	if err := Remove(dir, netns); err != nil {
+		t.Fatal(err)
+	}
+	r.reconcile()
+	if len(r.pods) != 0 {
+		t.Fatalf("expected the removed pod to be forgotten, got %v", r.pods)
+	}
+}
+
+func TestStartReconcile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pods")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	StartReconcile(ctx, &config.ReconcileConfig{Interval: 0, PodsDir: dir})
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected no pods directory when disabled, got %v", err)
+	}
+	StartReconcile(ctx, &config.ReconcileConfig{Interval: time.Hour, PodsDir: dir})
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("expected the pods directory to be created, got %v", err)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -29,7 +30,13 @@ import (
 	"istio.io/pkg/log"
 )
 
-var configDumpFile string
+var (
+	configDumpFile string
+
+	// the hypothetical request to evaluate
+	authzRequest authz.Request
+	authzHeaders []string
+)
 
 var checkCmd = &cobra.Command{
 	Use:   "check [<type>/]<name>[.<namespace>]",
@@ -39,7 +46,11 @@ the Envoy configuration of the pod. The command is especially useful for inspect
 the policy propagation from Istiod to Envoy and the final AuthorizationPolicy list merged
 from multiple sources (mesh-level, namespace-level and workload-level).
 
-The command also supports reading from a standalone config dump file with flag -f.`,
+The command also supports reading from a standalone config dump file with flag -f.
+
+When a request is described with --port and the other request flags, the command instead
+evaluates the request against the RBAC filters of the Envoy configuration, and prints
+whether the request is allowed or denied along with the matched policy and rule.`,
 	Example: `  # Check AuthorizationPolicy applied to pod httpbin-88ddbcfdd-nt5jb:
   istioctl x authz check httpbin-88ddbcfdd-nt5jb
 
@@ -47,13 +58,17 @@ The command also supports reading from a standalone config dump file with flag -
   istioctl x authz check deployment/productpage-v1
 
   # Check AuthorizationPolicy from Envoy config dump file:
-  istioctl x authz check -f httpbin_config_dump.json`,
+  istioctl x authz check -f httpbin_config_dump.json
+
+  # Check whether a GET request from the sleep service account to port 8000 is allowed:
+  istioctl x authz check httpbin-88ddbcfdd-nt5jb --port 8000 --method GET --path /headers \
+    --source-principal cluster.local/ns/default/sa/sleep`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) > 1 {
 			cmd.Println(cmd.UsageString())
 			return fmt.Errorf("check requires only <pod-name>[.<pod-namespace>]")
 		}
-		return nil
+		return validateAuthzRequest()
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var configDump *configdump.Wrapper
@@ -86,11 +101,49 @@ The command also supports reading from a standalone config dump file with flag -
 		if err != nil {
 			return err
 		}
-		analyzer.Print(cmd.OutOrStdout())
+		if authzRequest.Port == 0 {
+			analyzer.Print(cmd.OutOrStdout())
+			return nil
+		}
+		req := authzRequest
+		if req.Headers, err = parseAuthzHeaders(authzHeaders); err != nil {
+			return err
+		}
+		verdict, err := analyzer.Evaluate(&req)
+		if err != nil {
+			return err
+		}
+		verdict.Print(cmd.OutOrStdout())
 		return nil
 	},
 }
 
+func validateAuthzRequest() error {
+	if authzRequest.Port != 0 {
+		return nil
+	}
+	if authzRequest.SourceIP != "" || authzRequest.SourcePrincipal != "" || authzRequest.RequestPrincipal != "" ||
+		authzRequest.Method != "" || authzRequest.Host != "" || authzRequest.Path != "" || len(authzHeaders) > 0 {
+		return fmt.Errorf("--port is required to evaluate a request")
+	}
+	return nil
+}
+
+func parseAuthzHeaders(headers []string) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(headers))
+	for _, h := range headers {
+		parts := strings.SplitN(h, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid header %q, expected <name>=<value>", h)
+		}
+		out[parts[0]] = parts[1]
+	}
+	return out, nil
+}
+
 func getConfigDumpFromFile(filename string) (*configdump.Wrapper, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -156,4 +209,17 @@ func AuthZ() *cobra.Command {
 func init() {
 	checkCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
 		"The json file with Envoy config dump to be checked")
+	checkCmd.PersistentFlags().IntVar(&authzRequest.Port, "port", 0,
+		"The destination port of a request to evaluate against the authorization policies")
+	checkCmd.PersistentFlags().StringVar(&authzRequest.SourceIP, "source-ip", "", "The source IP address of the request")
+	checkCmd.PersistentFlags().StringVar(&authzRequest.SourcePrincipal, "source-principal", "",
+		"The mTLS identity of the client, e.g. cluster.local/ns/default/sa/sleep. The request is plaintext if not set")
+	checkCmd.PersistentFlags().StringVar(&authzRequest.RequestPrincipal, "request-principal", "",
+		"The principal of the request credential, in the <iss>/<sub> format")
+	checkCmd.PersistentFlags().StringVar(&authzRequest.Method, "method", "", "The HTTP method of the request")
+	checkCmd.PersistentFlags().StringVar(&authzRequest.Host, "host", "", "The HTTP host of the request")
+	checkCmd.PersistentFlags().StringVar(&authzRequest.Path, "path", "", "The HTTP path of the request")
+	checkCmd.PersistentFlags().StringArrayVar(&authzHeaders, "header", nil,
+		"An HTTP header of the request in the <name>=<value> format, can be repeated. "+
+			"The request is evaluated as a TCP connection if no HTTP attribute is set")
 }
@@ -0,0 +1,172 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/snapshot"
+	"istio.io/istio/istioctl/pkg/validate"
+)
+
+func configSnapshotCommand() *cobra.Command {
+	var outputFile string
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Export the Istio config of the cluster",
+		Long: `Export all Istio config resources of the cluster, or of a namespace, as a YAML file that can be
+re-applied with "istioctl x config restore". Server-set metadata and status are removed, labels such as the
+revision label are kept, and the resources are ordered so that they are applied after the ones they depend on.`,
+		Example: `  # Export the Istio config of all namespaces
+  istioctl x config snapshot -o istio-config.yaml
+
+  # Export the Istio config of the default namespace
+  istioctl x config snapshot -n default > istio-config.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			client, err := kubeClient(kubeconfig, configContext)
+			if err != nil {
+				return fmt.Errorf("failed to create Kubernetes client: %v", err)
+			}
+			objs, err := snapshot.Take(context.Background(), client.Dynamic(), namespace)
+			if err != nil {
+				return err
+			}
+			out := c.OutOrStdout()
+			if outputFile != "" {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := snapshot.Write(out, objs); err != nil {
+				return err
+			}
+			if outputFile != "" {
+				c.Printf("Exported %d Istio configs to %s\n", len(objs), outputFile)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "", "The file to write the snapshot to, instead of stdout")
+	return cmd
+}
+
+func configRestoreCommand() *cobra.Command {
+	var (
+		filename string
+		opts     snapshot.RestoreOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the Istio config of a snapshot to the cluster",
+		Long: `Re-apply a snapshot taken with "istioctl x config snapshot" to the cluster, for example after a disaster or
+to copy the config to another cluster. All the resources are validated before any is applied. The resources are
+then applied in dependency order: missing ones are created, and the difference is shown for the ones that change.`,
+		Example: `  # Show what restoring the snapshot would change
+  istioctl x config restore -f istio-config.yaml --dry-run
+
+  # Restore the snapshot, moving the revisioned configs to the canary control plane
+  istioctl x config restore -f istio-config.yaml --revision canary`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("restore takes no arguments")
+			}
+			if filename == "" {
+				return fmt.Errorf("a snapshot file is required, use --filename")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, _ []string) error {
+			var in io.Reader
+			if filename == "-" {
+				in = c.InOrStdin()
+			} else {
+				f, err := os.Open(filename)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+			objs, err := snapshot.Read(in)
+			if err != nil {
+				return fmt.Errorf("failed to read snapshot: %v", err)
+			}
+
+			var errs error
+			for _, obj := range objs {
+				prefix := fmt.Sprintf("%s %s/%s:", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+				warning, err := validate.ValidateResource(istioNamespace, obj.GetNamespace(), obj)
+				if err != nil {
+					errs = multierror.Append(errs, multierror.Prefix(err, prefix))
+				}
+				if warning != nil {
+					c.PrintErrf("Warning: %s %v\n", prefix, warning)
+				}
+			}
+			if errs != nil {
+				return fmt.Errorf("snapshot is not valid, nothing was restored: %v", errs)
+			}
+
+			client, err := kubeClient(kubeconfig, configContext)
+			if err != nil {
+				return fmt.Errorf("failed to create Kubernetes client: %v", err)
+			}
+			changes, err := snapshot.Restore(context.Background(), client.Dynamic(), objs, opts)
+			if err != nil {
+				return err
+			}
+			return printRestoreChanges(c.OutOrStdout(), changes, opts.DryRun)
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&filename, "filename", "f", "", "The snapshot file to restore, or - for stdin")
+	cmd.PersistentFlags().BoolVar(&opts.DryRun, "dry-run", false, "Only show the changes, without applying them")
+	cmd.PersistentFlags().StringVar(&opts.Revision, "revision", "",
+		"Restore the configs with a revision label to this control plane revision")
+	return cmd
+}
+
+func printRestoreChanges(w io.Writer, changes []snapshot.Change, dryRun bool) error {
+	suffix := ""
+	if dryRun {
+		suffix = " (dry run)"
+	}
+	failed := 0
+	for _, ch := range changes {
+		ref := fmt.Sprintf("%s %s/%s", ch.Object.GetKind(), ch.Object.GetNamespace(), ch.Object.GetName())
+		if ch.Err != nil {
+			failed++
+			fmt.Fprintf(w, "%s failed: %v\n", ref, ch.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s%s\n", ref, ch.Action, suffix)
+		if ch.Action == snapshot.Configured {
+			fmt.Fprintln(w, ch.Diff)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to restore %d of %d configs", failed, len(changes))
+	}
+	return nil
+}
@@ -43,12 +43,17 @@ var settableFlags = map[string]interface{}{
 func configCmd() *cobra.Command {
 	configCmd := &cobra.Command{
 		Use:   "config SUBCOMMAND",
-		Short: "Configure istioctl defaults",
+		Short: "Configure istioctl defaults, and snapshot and restore the Istio config",
 		Args:  cobra.NoArgs,
 		Example: `  # list configuration parameters
-  istioctl config list`,
+  istioctl config list
+
+  # export the Istio config of the cluster
+  istioctl x config snapshot -o istio-config.yaml`,
 	}
 	configCmd.AddCommand(listCommand())
+	configCmd.AddCommand(configSnapshotCommand())
+	configCmd.AddCommand(configRestoreCommand())
 	return configCmd
 }
 
@@ -0,0 +1,97 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/conformance"
+)
+
+const junitOutput = "junit"
+
+func conformanceCmd() *cobra.Command {
+	opts := conformance.Options{}
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "conformance",
+		Short: "Validate the mesh by running conformance checks against echo workloads",
+		Long: fmt.Sprintf(`Deploy minimal echo workloads in a dedicated namespace, and run a curated set of checks of the
+mesh against them: %s. The namespace is deleted at the end of the run, unless --keep-namespace or
+--existing-namespace is set.
+
+The command can run out of the cluster, or in the cluster as a Job, as in samples/conformance.
+It exits with an error if any check fails.`, strings.Join(conformance.CheckNames(), ", ")),
+		Example: `  # Run all checks, and print a summary
+  istioctl x conformance
+
+  # Run the mTLS and authorization checks with the sidecars of a revision, and write a JUnit report
+  istioctl x conformance --revision canary --checks mtls,authz -o junit > conformance.xml`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			switch outputFormat {
+			case summaryOutput, jsonOutput, junitOutput:
+			default:
+				return fmt.Errorf("unknown output format %q, want one of %s|%s|%s", outputFormat, summaryOutput, jsonOutput, junitOutput)
+			}
+			client, err := newKubeClientWithRevision(kubeconfig, configContext, revision)
+			if err != nil {
+				return fmt.Errorf("failed to create Kubernetes client: %v", err)
+			}
+			report, err := conformance.Run(context.Background(), client, opts)
+			if err != nil {
+				return err
+			}
+			out := c.OutOrStdout()
+			switch outputFormat {
+			case jsonOutput:
+				err = report.WriteJSON(out)
+			case junitOutput:
+				err = report.WriteJUnit(out)
+			default:
+				err = report.WriteText(out)
+			}
+			if err != nil {
+				return err
+			}
+			if failures := report.Failures(); failures > 0 {
+				return fmt.Errorf("%d of %d conformance checks failed", failures, len(report.Results))
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&opts.Namespace, "workload-namespace", "istio-conformance",
+		"The namespace the echo workloads are deployed in. It must not exist, unless --existing-namespace is set")
+	cmd.PersistentFlags().BoolVar(&opts.ExistingNamespace, "existing-namespace", false,
+		"Deploy the workloads in an existing namespace labeled for injection, and delete the workloads rather than "+
+			"the namespace at the end of the run, so that only permissions in the namespace are needed")
+	cmd.PersistentFlags().StringVar(&opts.Image, "image", conformance.DefaultImage, "The image of the echo workloads")
+	cmd.PersistentFlags().DurationVar(&opts.Timeout, "timeout", 2*time.Minute,
+		"The maximum time to wait for the workloads to be ready, and for each check to pass")
+	cmd.PersistentFlags().BoolVar(&opts.KeepNamespace, "keep-namespace", false,
+		"Keep the namespace of the workloads after the run, to debug failures")
+	cmd.PersistentFlags().StringSliceVar(&opts.Checks, "checks", nil,
+		"The checks to run, all by default. One or more of "+strings.Join(conformance.CheckNames(), ","))
+	cmd.PersistentFlags().StringVarP(&revision, "revision", "r", "",
+		"The control plane revision the sidecars of the workloads are injected with")
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput,
+		fmt.Sprintf("Output format of the report: one of %s|%s|%s", summaryOutput, jsonOutput, junitOutput))
+	return cmd
+}
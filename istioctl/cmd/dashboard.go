@@ -19,14 +19,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"runtime"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
 	"istio.io/istio/istioctl/pkg/clioptions"
+	meshdashboard "istio.io/istio/istioctl/pkg/dashboard"
 	"istio.io/istio/istioctl/pkg/util/handlers"
 	"istio.io/istio/pkg/kube"
 	"istio.io/pkg/log"
@@ -403,6 +407,51 @@ func skywalkingDashCmd() *cobra.Command {
 	return cmd
 }
 
+// serve a local UI showing the sync state of the proxies from istiod; open browser
+func meshDashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mesh",
+		Short: "Open the istioctl mesh web UI",
+		Long: `Serve a lightweight local web UI showing the proxies of the mesh, their sync state, push latency and
+recent NACKs, using the debug endpoints of istiod. It does not require any addon to be installed.`,
+		Example: `  istioctl dashboard mesh
+
+  # with short syntax
+  istioctl dash mesh
+  istioctl d mesh`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := kubeClient(kubeconfig, configContext)
+			if err != nil {
+				return fmt.Errorf("failed to create k8s client: %v", err)
+			}
+			handler := meshdashboard.NewHandler(func(ctx context.Context, path string) (map[string][]byte, error) {
+				return client.AllDiscoveryDo(ctx, istioNamespace, path)
+			})
+
+			l, err := net.Listen("tcp", net.JoinHostPort(bindAddress, strconv.Itoa(listenPort)))
+			if err != nil {
+				return fmt.Errorf("failed to listen for the mesh dashboard: %v", err)
+			}
+			srv := &http.Server{Handler: handler}
+			go func() {
+				signals := make(chan os.Signal, 1)
+				signal.Notify(signals, os.Interrupt)
+				defer signal.Stop(signals)
+				<-signals
+				_ = srv.Close()
+			}()
+
+			openBrowser(fmt.Sprintf("http://%s", l.Addr()), cmd.OutOrStdout(), browser)
+			if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
 // portForward first tries to forward localhost:remotePort to podName:remotePort, falls back to dynamic local port
 func portForward(podName, namespace, flavor, urlFormat, localAddress string, remotePort int,
 	client kube.ExtendedClient, writer io.Writer, browser bool) error {
@@ -515,6 +564,7 @@ func dashboard() *cobra.Command {
 	dashboardCmd.AddCommand(jaegerDashCmd())
 	dashboardCmd.AddCommand(zipkinDashCmd())
 	dashboardCmd.AddCommand(skywalkingDashCmd())
+	dashboardCmd.AddCommand(meshDashCmd())
 
 	envoy := envoyDashCmd()
 	envoy.PersistentFlags().StringVarP(&labelSelector, "selector", "l", "", "Label selector")
@@ -0,0 +1,109 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func deprecationsCommand() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	cmd := &cobra.Command{
+		Use:   "deprecations",
+		Short: "Lists the uses of deprecated features by the configs and proxies of the mesh.",
+		Long: `Lists the deprecated annotations, fields and Envoy names used by the configs known to istiod, and the
+deprecated annotations of the pods of the connected proxies, so that they can be cleaned up before an upgrade
+removes their support.`,
+		Example: `  # List the uses of deprecated features
+  istioctl x deprecations`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return err
+			}
+			res, err := kubeClient.AllDiscoveryDo(context.Background(), istioNamespace, "/debug/deprecationz")
+			if err != nil {
+				return err
+			}
+			usages, err := parseDeprecations(res)
+			if err != nil {
+				return err
+			}
+			if len(usages) == 0 {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No uses of deprecated features found.")
+				return nil
+			}
+			writeDeprecations(cmd.OutOrStdout(), usages)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	return cmd
+}
+
+// parseDeprecations merges the uses reported by each istiod. The configs are known to all of them, while the
+// proxies are only reported by the instance they are connected to.
+func parseDeprecations(input map[string][]byte) ([]model.DeprecatedUsage, error) {
+	seen := map[model.DeprecatedUsage]bool{}
+	var out []model.DeprecatedUsage
+	for _, bytes := range input {
+		var parsed []model.DeprecatedUsage
+		if err := json.Unmarshal(bytes, &parsed); err != nil {
+			return nil, err
+		}
+		for _, u := range parsed {
+			if !seen[u] {
+				seen[u] = true
+				out = append(out, u)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Feature != b.Feature {
+			return a.Feature < b.Feature
+		}
+		return a.Message < b.Message
+	})
+	return out, nil
+}
+
+func writeDeprecations(out io.Writer, usages []model.DeprecatedUsage) {
+	w := new(tabwriter.Writer).Init(out, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tFEATURE\tMESSAGE")
+	for _, u := range usages {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", u.Kind, u.Namespace, u.Name, u.Feature, u.Message)
+	}
+	_ = w.Flush()
+}
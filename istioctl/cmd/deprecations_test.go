@@ -0,0 +1,49 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDeprecations(t *testing.T) {
+	config := `{"kind": "VirtualService", "namespace": "default", "name": "reviews",
+		"feature": "VirtualService.http.mirrorPercent", "message": "http route \"v1\" uses mirrorPercent"}`
+	input := map[string][]byte{
+		"istiod-a": []byte(`[` + config + `, {"kind": "Pod", "namespace": "default", "name": "app-a",
+			"feature": "annotation:alpha.istio.io/identity", "message": "annotation alpha.istio.io/identity is deprecated"}]`),
+		"istiod-b": []byte(`[` + config + `]`),
+	}
+	usages, err := parseDeprecations(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 2 || usages[0].Kind != "Pod" || usages[1].Name != "reviews" {
+		t.Fatalf("unexpected deprecations %v", usages)
+	}
+
+	out := &bytes.Buffer{}
+	writeDeprecations(out, usages)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "KIND") || !strings.Contains(lines[2], "VirtualService.http.mirrorPercent") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	if _, err := parseDeprecations(map[string][]byte{"istiod-a": []byte("invalid")}); err == nil {
+		t.Fatal("expected error")
+	}
+}
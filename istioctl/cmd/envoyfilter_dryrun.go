@@ -0,0 +1,142 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/pkg/xds"
+)
+
+func envoyFilterDryRunCommand() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var filename, outputFormat string
+	cmd := &cobra.Command{
+		Use:   "envoyfilter-dryrun [<type>/]<name>[.<namespace>] -f <file>",
+		Short: "Shows the changes an EnvoyFilter makes to the config of a proxy, without applying it.",
+		Long: `Applies an EnvoyFilter to the listeners, routes and clusters istiod generates for a proxy, and shows the
+resources it adds, removes or modifies. The EnvoyFilter is only applied in memory by istiod: it is not created in
+the cluster, nor pushed to any proxy. It replaces the EnvoyFilter with the same name, if any, and defaults to the
+namespace of the proxy.`,
+		Example: `  # Show the changes of an EnvoyFilter to the config of a pod
+  istioctl x envoyfilter-dryrun productpage-v1-7c7c4d6d7d-8kxj2.default -f envoyfilter.yaml
+
+  # Show the changes of an EnvoyFilter to the config of a pod of a deployment, as JSON
+  istioctl x envoyfilter-dryrun deployment/productpage-v1 -f envoyfilter.yaml -o json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("envoyfilter-dryrun requires a pod")
+			}
+			if filename == "" {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("envoyfilter-dryrun requires an EnvoyFilter file")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return err
+			}
+			podName, ns, err := handlers.InferPodInfoFromTypedResource(args[0],
+				handlers.HandleNamespace(namespace, defaultNamespace),
+				kubeClient.UtilFactory())
+			if err != nil {
+				return err
+			}
+			envoyFilter, err := readConfigFile(filename)
+			if err != nil {
+				return err
+			}
+			path := fmt.Sprintf("/debug/envoyfilter_dryrun?proxyID=%s.%s&envoyfilter=%s",
+				podName, ns, url.QueryEscape(string(envoyFilter)))
+			res, err := kubeClient.AllDiscoveryDo(context.Background(), istioNamespace, path)
+			if err != nil {
+				return err
+			}
+			dryRun, err := parseEnvoyFilterDryRun(res)
+			if err != nil {
+				return err
+			}
+			switch outputFormat {
+			case jsonOutput:
+				out, err := json.MarshalIndent(dryRun, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(out))
+			case summaryOutput:
+				writeEnvoyFilterDryRun(cmd.OutOrStdout(), dryRun)
+			default:
+				return fmt.Errorf("unknown output format %q: must be one of %s|%s", outputFormat, summaryOutput, jsonOutput)
+			}
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&filename, "file", "f", "", "EnvoyFilter YAML file, or - for stdin")
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of short|json")
+	return cmd
+}
+
+// parseEnvoyFilterDryRun returns the dry run of the istiod the proxy is connected to. The other instances respond
+// with an error message.
+func parseEnvoyFilterDryRun(input map[string][]byte) (*xds.EnvoyFilterDryRun, error) {
+	var errs []string
+	for _, bytes := range input {
+		var dryRun xds.EnvoyFilterDryRun
+		if err := json.Unmarshal(bytes, &dryRun); err == nil && dryRun.ProxyID != "" {
+			return &dryRun, nil
+		}
+		if msg := strings.TrimSpace(string(bytes)); msg != "" {
+			errs = append(errs, msg)
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no response from istiod")
+	}
+	return nil, fmt.Errorf("failed to run the EnvoyFilter: %s", strings.Join(errs, "; "))
+}
+
+func writeEnvoyFilterDryRun(out io.Writer, dryRun *xds.EnvoyFilterDryRun) {
+	total := 0
+	for _, section := range []struct {
+		kind      string
+		resources []xds.ResourceDiff
+	}{
+		{"Listener", dryRun.Listeners},
+		{"Route", dryRun.Routes},
+		{"Cluster", dryRun.Clusters},
+	} {
+		for _, r := range section.resources {
+			total++
+			_, _ = fmt.Fprintf(out, "%s %s %s\n", section.kind, r.Name, r.Change)
+			_, _ = fmt.Fprintln(out, r.Diff)
+		}
+	}
+	if total == 0 {
+		_, _ = fmt.Fprintf(out, "EnvoyFilter %s does not change the config of %s.\n", dryRun.EnvoyFilter, dryRun.ProxyID)
+	}
+}
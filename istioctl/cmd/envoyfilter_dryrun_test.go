@@ -0,0 +1,54 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEnvoyFilterDryRun(t *testing.T) {
+	input := map[string][]byte{
+		"istiod-a": []byte("Proxy not connected to this Pilot instance. It may be connected to another instance.\n"),
+		"istiod-b": []byte(`{"proxy": "productpage.default", "envoy_filter": "default/timeout", "listeners": [], "routes": [],
+			"clusters": [{"name": "outbound|80||example.com", "change": "modified", "diff": "-  \"connectTimeout\": \"10s\"\n"}]}`),
+	}
+	dryRun, err := parseEnvoyFilterDryRun(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dryRun.EnvoyFilter != "default/timeout" || len(dryRun.Clusters) != 1 {
+		t.Fatalf("unexpected dry run %+v", dryRun)
+	}
+
+	out := &bytes.Buffer{}
+	writeEnvoyFilterDryRun(out, dryRun)
+	if !strings.HasPrefix(out.String(), "Cluster outbound|80||example.com modified\n") || !strings.Contains(out.String(), "connectTimeout") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	dryRun.Clusters = nil
+	out.Reset()
+	writeEnvoyFilterDryRun(out, dryRun)
+	if !strings.Contains(out.String(), "does not change") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	_, err = parseEnvoyFilterDryRun(map[string][]byte{"istiod-a": []byte("invalid EnvoyFilter: missing config patch\n")})
+	if err == nil || !strings.Contains(err.Error(), "missing config patch") {
+		t.Fatalf("expected the error of istiod, got %v", err)
+	}
+}
@@ -1053,6 +1053,134 @@ func secretConfigCmd() *cobra.Command {
 	return secretConfigCmd
 }
 
+func ecdsConfigCmd() *cobra.Command {
+	var podName, podNamespace string
+
+	ecdsConfigCmd := &cobra.Command{
+		Use:   "ecds [<type>/]<name>[.<namespace>]",
+		Short: "Retrieves extension configuration for the Envoy in the specified pod",
+		Long: `Retrieve information about extension configurations, such as Wasm plugins, received over ECDS ` +
+			`by the Envoy instance in the specified pod.`,
+		Example: `  # Retrieve summary about extension configuration for a given pod from Envoy.
+  istioctl proxy-config ecds <pod-name[.namespace]>
+
+  # Retrieve full extension configuration for a given pod from Envoy.
+  istioctl proxy-config ecds <pod-name[.namespace]> -o json
+
+  # Retrieve extension configuration without using Kubernetes API
+  ssh <user@hostname> 'curl localhost:15000/config_dump' > envoy-config.json
+  istioctl proxy-config ecds --file envoy-config.json
+`,
+		Aliases: []string{"ec"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 1) != (configDumpFile == "") {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("ecds requires pod name or --file parameter")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var configWriter *configdump.ConfigWriter
+			var err error
+			if len(args) == 1 {
+				if podName, podNamespace, err = getPodName(args[0]); err != nil {
+					return err
+				}
+				configWriter, err = setupPodConfigdumpWriter(podName, podNamespace, c.OutOrStdout())
+			} else {
+				configWriter, err = setupFileConfigdumpWriter(configDumpFile, c.OutOrStdout())
+			}
+			if err != nil {
+				return err
+			}
+			switch outputFormat {
+			case summaryOutput:
+				return configWriter.PrintEcdsSummary()
+			case jsonOutput, yamlOutput:
+				return configWriter.PrintEcdsDump(outputFormat)
+			default:
+				return fmt.Errorf("output format %q not supported", outputFormat)
+			}
+		},
+		ValidArgsFunction: validPodsNameArgs,
+	}
+
+	ecdsConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	ecdsConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
+		"Envoy config dump JSON file")
+
+	return ecdsConfigCmd
+}
+
+func logConfigCmd() *cobra.Command {
+	var podName, podNamespace string
+
+	logConfigCmd := &cobra.Command{
+		Use:   "log-config [<type>/]<name>[.<namespace>]",
+		Short: "Retrieves access log configuration for the Envoy in the specified pod",
+		Long: `Retrieve information about the access logs configured on the listeners of the Envoy instance ` +
+			`in the specified pod. To change the logging levels of Envoy, use 'istioctl proxy-config log'.`,
+		Example: `  # Retrieve summary about access log configuration for a given pod from Envoy.
+  istioctl proxy-config log-config <pod-name[.namespace]>
+
+  # Retrieve access log summary for listeners with port 9080.
+  istioctl proxy-config log-config <pod-name[.namespace]> --port 9080
+
+  # Retrieve full access log configuration for listeners with a wildcard address (0.0.0.0).
+  istioctl proxy-config log-config <pod-name[.namespace]> --address 0.0.0.0 -o json
+
+  # Retrieve access log configuration without using Kubernetes API
+  ssh <user@hostname> 'curl localhost:15000/config_dump' > envoy-config.json
+  istioctl proxy-config log-config --file envoy-config.json
+`,
+		Aliases: []string{"accesslog"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 1) != (configDumpFile == "") {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("log-config requires pod name or --file parameter")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var configWriter *configdump.ConfigWriter
+			var err error
+			if len(args) == 1 {
+				if podName, podNamespace, err = getPodName(args[0]); err != nil {
+					return err
+				}
+				configWriter, err = setupPodConfigdumpWriter(podName, podNamespace, c.OutOrStdout())
+			} else {
+				configWriter, err = setupFileConfigdumpWriter(configDumpFile, c.OutOrStdout())
+			}
+			if err != nil {
+				return err
+			}
+			filter := configdump.ListenerFilter{
+				Address: address,
+				Port:    uint32(port),
+			}
+
+			switch outputFormat {
+			case summaryOutput:
+				return configWriter.PrintAccessLogSummary(filter)
+			case jsonOutput, yamlOutput:
+				return configWriter.PrintAccessLogDump(filter, outputFormat)
+			default:
+				return fmt.Errorf("output format %q not supported", outputFormat)
+			}
+		},
+		ValidArgsFunction: validPodsNameArgs,
+	}
+
+	logConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	logConfigCmd.PersistentFlags().StringVar(&address, "address", "", "Filter listeners by address field")
+	logConfigCmd.PersistentFlags().IntVar(&port, "port", 0, "Filter listeners by Port field")
+	logConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
+		"Envoy config dump JSON file")
+
+	return logConfigCmd
+}
+
 func rootCACompareConfigCmd() *cobra.Command {
 	var podName1, podName2, podNamespace1, podNamespace2 string
 
@@ -1129,7 +1257,7 @@ func proxyConfig() *cobra.Command {
 		Short: "Retrieve information about proxy configuration from Envoy [kube only]",
 		Long:  `A group of commands used to retrieve information about proxy configuration from the Envoy config dump`,
 		Example: `  # Retrieve information about proxy configuration from an Envoy instance.
-  istioctl proxy-config <clusters|listeners|routes|endpoints|bootstrap|log|secret> <pod-name[.namespace]>`,
+  istioctl proxy-config <clusters|listeners|routes|endpoints|bootstrap|log|secret|ecds|log-config> <pod-name[.namespace]>`,
 		Aliases: []string{"pc"},
 	}
 
@@ -1143,6 +1271,8 @@ func proxyConfig() *cobra.Command {
 	configCmd.AddCommand(bootstrapConfigCmd())
 	configCmd.AddCommand(endpointConfigCmd())
 	configCmd.AddCommand(secretConfigCmd())
+	configCmd.AddCommand(ecdsConfigCmd())
+	configCmd.AddCommand(logConfigCmd())
 	configCmd.AddCommand(rootCACompareConfigCmd())
 
 	return configCmd
@@ -114,6 +114,16 @@ func TestProxyConfig(t *testing.T) {
 			expectedString: "unable to retrieve Pod: pods \"invalid\" not found",
 			wantException:  true, // "istioctl proxy-config endpoint invalid" should fail
 		},
+		{ // ecds invalid
+			args:           strings.Split("proxy-config ecds invalid", " "),
+			expectedString: "unable to retrieve Pod: pods \"invalid\" not found",
+			wantException:  true, // "istioctl proxy-config ecds invalid" should fail
+		},
+		{ // log-config invalid
+			args:           strings.Split("proxy-config log-config invalid", " "),
+			expectedString: "unable to retrieve Pod: pods \"invalid\" not found",
+			wantException:  true, // "istioctl proxy-config log-config invalid" should fail
+		},
 		{ // supplying nonexistent deployment name should result in error
 			args:           strings.Split("proxy-config clusters deployment/random-gibberish", " "),
 			expectedString: `"deployment/random-gibberish" does not refer to a pod`,
@@ -159,6 +169,18 @@ func TestProxyConfig(t *testing.T) {
 			expectedString:   `config dump has no configuration type`,
 			wantException:    true,
 		},
+		{ // supplying valid pod name retrieves Envoy config (fails because we don't check in Envoy config unit tests)
+			execClientConfig: loggingConfig,
+			args:             strings.Split("pc ecds httpbin-794b576b6c-qx6pf", " "),
+			expectedString:   `config dump has no configuration type`,
+			wantException:    true,
+		},
+		{ // supplying valid pod name retrieves Envoy config (fails because we don't check in Envoy config unit tests)
+			execClientConfig: loggingConfig,
+			args:             strings.Split("pc log-config httpbin-794b576b6c-qx6pf", " "),
+			expectedString:   `config dump has no configuration type`,
+			wantException:    true,
+		},
 	}
 
 	for i, c := range cases {
@@ -236,6 +236,10 @@ debug and diagnose their Istio mesh.
 	experimentalCmd.AddCommand(debugCommand())
 	experimentalCmd.AddCommand(preCheck())
 	experimentalCmd.AddCommand(statsConfigCmd())
+	experimentalCmd.AddCommand(deprecationsCommand())
+	experimentalCmd.AddCommand(envoyFilterDryRunCommand())
+	experimentalCmd.AddCommand(conformanceCmd())
+	experimentalCmd.AddCommand(snapshotCommand())
 
 	analyzeCmd := Analyze()
 	hideInheritedFlags(analyzeCmd, FlagIstioNamespace)
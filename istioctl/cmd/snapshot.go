@@ -0,0 +1,70 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+)
+
+func snapshotCommand() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var output string
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Exports the state istiod generates the config of the proxies from, to reproduce it offline.",
+		Long: `Exports the mesh config, the services and instances of the service registries, and the Istio configs known to
+istiod to a gzipped tarball. The config of any proxy can then be generated again from the archive without access to
+the cluster, by loading it in a fake discovery server, see TestSnapshotReproduction in pilot/pkg/xds.
+
+The archive holds the configs and the addresses of the workloads of the mesh: review it before sharing it.`,
+		Example: `  # Export the state of istiod to istiod-snapshot.tar.gz
+  istioctl x snapshot -o istiod-snapshot.tar.gz`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return err
+			}
+			res, err := kubeClient.AllDiscoveryDo(context.Background(), istioNamespace, "/debug/snapshotz")
+			if err != nil {
+				return err
+			}
+			if len(res) == 0 {
+				return fmt.Errorf("no istiod found in namespace %s", istioNamespace)
+			}
+			// All the instances of istiod watch the same state, any of them can be exported.
+			istiods := make([]string, 0, len(res))
+			for istiod := range res {
+				istiods = append(istiods, istiod)
+			}
+			sort.Strings(istiods)
+			if err := os.WriteFile(output, res[istiods[0]], 0o600); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Exported the snapshot of %s to %s\n", istiods[0], output)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", "istiod-snapshot.tar.gz", "The file the snapshot is written to")
+	return cmd
+}
@@ -0,0 +1,509 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	routepb "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcherpb "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+
+	authn "istio.io/istio/pilot/pkg/security/model"
+	"istio.io/istio/pkg/spiffe"
+)
+
+const requestPrincipalKey = "request.auth.principal"
+
+// Request is a hypothetical request evaluated against the RBAC filters of a proxy.
+type Request struct {
+	// Port is the destination port of the request.
+	Port int
+	// SourceIP is the IP address of the client.
+	SourceIP string
+	// SourcePrincipal is the mTLS identity of the client, e.g. cluster.local/ns/default/sa/sleep.
+	// The request is evaluated as a plaintext request if it is not set.
+	SourcePrincipal string
+	// RequestPrincipal is the principal of the request credential, in the <iss>/<sub> format.
+	RequestPrincipal string
+
+	// The HTTP attributes of the request. The request is evaluated as a TCP connection if none are set.
+	Method  string
+	Host    string
+	Path    string
+	Headers map[string]string
+}
+
+func (r *Request) isHTTP() bool {
+	return r.Method != "" || r.Host != "" || r.Path != "" || len(r.Headers) > 0
+}
+
+// PolicyResult is the result of evaluating a rule of an AuthorizationPolicy.
+type PolicyResult struct {
+	Action rbacpb.RBAC_Action
+	// Policy is the AuthorizationPolicy in the name.namespace format.
+	Policy  string
+	Rule    string
+	Matched bool
+	// Unknown is true if the rule uses matchers that cannot be evaluated, and whether it matches depends on them.
+	Unknown bool
+}
+
+// Verdict is the result of evaluating a request against the RBAC filters of a proxy.
+type Verdict struct {
+	Listener    string
+	FilterChain string
+	Allowed     bool
+	// Decision is the policy rule that decided the verdict, or nil if no rule matched.
+	Decision *PolicyResult
+	// Results are the results of all the evaluated policy rules, in evaluation order.
+	Results []PolicyResult
+	// Unsupported lists the matchers that cannot be evaluated from the request attributes. The rules whose
+	// result depends on them are reported as unknown, and treated as not matching.
+	Unsupported []string
+}
+
+// Evaluate evaluates a request against the RBAC filters of the inbound filter chain of the listeners that
+// would handle it, following the Envoy semantics: the request is denied if a DENY policy matches, or if there
+// are ALLOW policies and none matches.
+func Evaluate(listeners []*listener.Listener, req *Request) (*Verdict, error) {
+	l, fc := selectFilterChain(parse(listeners), req)
+	if fc == nil {
+		return nil, fmt.Errorf("no inbound filter chain found for port %d", req.Port)
+	}
+
+	e := &evaluator{req: req, unsupported: map[string]struct{}{}}
+	v := &Verdict{Listener: l.name, FilterChain: fc.name, Allowed: true}
+	var rules []*rbacpb.RBAC
+	// The network RBAC filters are before the HTTP connection manager in the filter chain.
+	for _, r := range fc.rbacTCP {
+		rules = append(rules, r.GetRules())
+	}
+	if req.isHTTP() {
+		for _, r := range fc.rbacHTTP {
+			rules = append(rules, r.GetRules())
+		}
+	}
+	for _, r := range rules {
+		if r == nil {
+			// Shadow rules only, which do not enforce anything.
+			continue
+		}
+		matched := e.evaluate(r, v)
+		switch r.GetAction() {
+		case rbacpb.RBAC_DENY:
+			if matched != nil {
+				v.Allowed, v.Decision = false, matched
+			}
+		case rbacpb.RBAC_ALLOW:
+			if matched == nil {
+				v.Allowed, v.Decision = false, nil
+			} else {
+				v.Decision = matched
+			}
+		}
+		if !v.Allowed {
+			break
+		}
+	}
+	for u := range e.unsupported {
+		v.Unsupported = append(v.Unsupported, u)
+	}
+	sort.Strings(v.Unsupported)
+	return v, nil
+}
+
+// Print prints the verdict and the evaluated policy rules.
+func (v *Verdict) Print(writer io.Writer) {
+	fmt.Fprintf(writer, "Evaluated listener %s, filter chain %s\n\n", v.Listener, v.FilterChain)
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tAuthorizationPolicy\tRULE\tMATCHED")
+	for _, r := range v.Results {
+		matched := strconv.FormatBool(r.Matched)
+		if r.Unknown {
+			matched = "unknown"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Action, r.Policy, r.Rule, matched)
+	}
+	_ = w.Flush()
+
+	fmt.Fprintln(writer)
+	switch {
+	case v.Decision != nil:
+		verdict := "ALLOW"
+		if !v.Allowed {
+			verdict = "DENY"
+		}
+		fmt.Fprintf(writer, "Verdict: %s, matched %s policy %s rule %s\n", verdict, v.Decision.Action, v.Decision.Policy, v.Decision.Rule)
+	case v.Allowed:
+		fmt.Fprintln(writer, "Verdict: ALLOW, no policy applies")
+	default:
+		fmt.Fprintln(writer, "Verdict: DENY, no ALLOW policy matched")
+	}
+	if len(v.Unsupported) > 0 {
+		fmt.Fprintf(writer, "\nWarning: these matchers cannot be evaluated, the rules using them are unknown and treated as not matching: %s\n",
+			strings.Join(v.Unsupported, ", "))
+	}
+}
+
+// selectFilterChain selects the inbound filter chain that would handle the request. Filter chains matching the
+// port of the request are preferred over the catch-all ones, then the ones matching its protocol and whether
+// it uses mTLS.
+func selectFilterChain(listeners []*parsedListener, req *Request) (*parsedListener, *filterChain) {
+	var selectedListener *parsedListener
+	var selected *filterChain
+	best := 0
+	for _, l := range listeners {
+		if l.outbound {
+			continue
+		}
+		for _, fc := range l.filterChains {
+			score := 0
+			switch port := int(fc.match.GetDestinationPort().GetValue()); {
+			case port == req.Port, port == 0 && l.port == req.Port:
+				score = 4
+			case port == 0 && l.name == "virtualInbound":
+				score = 1
+			default:
+				continue
+			}
+			if fc.http == req.isHTTP() {
+				score += 2
+			}
+			if (fc.match.GetTransportProtocol() == "tls") == (req.SourcePrincipal != "") {
+				score++
+			}
+			if score > best {
+				best, selectedListener, selected = score, l, fc
+			}
+		}
+	}
+	return selectedListener, selected
+}
+
+// result is the result of evaluating a matcher. Matchers that cannot be evaluated are unknown, and stay unknown
+// when negated, so that a negated unsupported matcher is not reported as matching.
+type result int
+
+const (
+	noMatch result = iota
+	match
+	unknown
+)
+
+func boolResult(b bool) result {
+	if b {
+		return match
+	}
+	return noMatch
+}
+
+func (r result) not() result {
+	switch r {
+	case match:
+		return noMatch
+	case noMatch:
+		return match
+	}
+	return unknown
+}
+
+// and returns the conjunction of the results: noMatch if any is noMatch, otherwise unknown if any is unknown.
+func (r result) and(o result) result {
+	if r == noMatch || o == noMatch {
+		return noMatch
+	}
+	if r == unknown || o == unknown {
+		return unknown
+	}
+	return match
+}
+
+// or returns the disjunction of the results: match if any is match, otherwise unknown if any is unknown.
+func (r result) or(o result) result {
+	if r == match || o == match {
+		return match
+	}
+	if r == unknown || o == unknown {
+		return unknown
+	}
+	return noMatch
+}
+
+type evaluator struct {
+	req         *Request
+	unsupported map[string]struct{}
+}
+
+// evaluate evaluates the policies of the RBAC rules, adding the results to the verdict, and returns the
+// first matched policy in name order.
+func (e *evaluator) evaluate(rules *rbacpb.RBAC, v *Verdict) *PolicyResult {
+	if len(rules.GetPolicies()) == 0 {
+		v.Results = append(v.Results, PolicyResult{Action: rules.GetAction(), Policy: anonymousName, Rule: "0"})
+		return nil
+	}
+	names := make([]string, 0, len(rules.GetPolicies()))
+	for name := range rules.GetPolicies() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matched := -1
+	for _, name := range names {
+		policy := rules.GetPolicies()[name]
+		r := PolicyResult{Action: rules.GetAction(), Policy: name}
+		if parts := re.FindStringSubmatch(name); len(parts) == 4 {
+			r.Policy, r.Rule = fmt.Sprintf("%s.%s", parts[2], parts[1]), parts[3]
+		}
+		res := e.anyPermission(policy.GetPermissions()).and(e.anyPrincipal(policy.GetPrincipals()))
+		r.Matched, r.Unknown = res == match, res == unknown
+		v.Results = append(v.Results, r)
+		if r.Matched && matched < 0 {
+			matched = len(v.Results) - 1
+		}
+	}
+	if matched < 0 {
+		return nil
+	}
+	r := v.Results[matched]
+	return &r
+}
+
+func (e *evaluator) anyPermission(permissions []*rbacpb.Permission) result {
+	res := noMatch
+	for _, p := range permissions {
+		if res = res.or(e.permission(p)); res == match {
+			break
+		}
+	}
+	return res
+}
+
+func (e *evaluator) permission(p *rbacpb.Permission) result {
+	switch r := p.GetRule().(type) {
+	case *rbacpb.Permission_Any:
+		return boolResult(r.Any)
+	case *rbacpb.Permission_AndRules:
+		res := match
+		for _, sub := range r.AndRules.GetRules() {
+			if res = res.and(e.permission(sub)); res == noMatch {
+				break
+			}
+		}
+		return res
+	case *rbacpb.Permission_OrRules:
+		return e.anyPermission(r.OrRules.GetRules())
+	case *rbacpb.Permission_NotRule:
+		return e.permission(r.NotRule).not()
+	case *rbacpb.Permission_Header:
+		return e.header(r.Header)
+	case *rbacpb.Permission_UrlPath:
+		return boolResult(e.path(r.UrlPath))
+	case *rbacpb.Permission_DestinationPort:
+		return boolResult(int(r.DestinationPort) == e.req.Port)
+	case *rbacpb.Permission_DestinationPortRange:
+		return boolResult(int32(e.req.Port) >= r.DestinationPortRange.GetStart() && int32(e.req.Port) < r.DestinationPortRange.GetEnd())
+	case *rbacpb.Permission_Metadata:
+		return e.metadata(r.Metadata)
+	case *rbacpb.Permission_DestinationIp:
+		e.unsupported["destination.ip"] = struct{}{}
+	case *rbacpb.Permission_RequestedServerName:
+		e.unsupported["connection.sni"] = struct{}{}
+	default:
+		e.unsupported[fmt.Sprintf("permission %T", r)] = struct{}{}
+	}
+	return unknown
+}
+
+func (e *evaluator) anyPrincipal(principals []*rbacpb.Principal) result {
+	res := noMatch
+	for _, p := range principals {
+		if res = res.or(e.principal(p)); res == match {
+			break
+		}
+	}
+	return res
+}
+
+func (e *evaluator) principal(p *rbacpb.Principal) result {
+	switch id := p.GetIdentifier().(type) {
+	case *rbacpb.Principal_Any:
+		return boolResult(id.Any)
+	case *rbacpb.Principal_AndIds:
+		res := match
+		for _, sub := range id.AndIds.GetIds() {
+			if res = res.and(e.principal(sub)); res == noMatch {
+				break
+			}
+		}
+		return res
+	case *rbacpb.Principal_OrIds:
+		return e.anyPrincipal(id.OrIds.GetIds())
+	case *rbacpb.Principal_NotId:
+		return e.principal(id.NotId).not()
+	case *rbacpb.Principal_Authenticated_:
+		// Only mTLS connections are authenticated.
+		if e.req.SourcePrincipal == "" {
+			return noMatch
+		}
+		if id.Authenticated.GetPrincipalName() == nil {
+			return match
+		}
+		principal := e.req.SourcePrincipal
+		if !strings.HasPrefix(principal, spiffe.URIPrefix) {
+			principal = spiffe.URIPrefix + principal
+		}
+		return boolResult(matchString(id.Authenticated.GetPrincipalName(), principal))
+	case *rbacpb.Principal_SourceIp:
+		return boolResult(matchCidr(id.SourceIp, e.req.SourceIP))
+	case *rbacpb.Principal_DirectRemoteIp:
+		return boolResult(matchCidr(id.DirectRemoteIp, e.req.SourceIP))
+	case *rbacpb.Principal_RemoteIp:
+		return boolResult(matchCidr(id.RemoteIp, e.req.SourceIP))
+	case *rbacpb.Principal_Header:
+		return e.header(id.Header)
+	case *rbacpb.Principal_UrlPath:
+		return boolResult(e.path(id.UrlPath))
+	case *rbacpb.Principal_Metadata:
+		return e.metadata(id.Metadata)
+	default:
+		e.unsupported[fmt.Sprintf("principal %T", id)] = struct{}{}
+	}
+	return unknown
+}
+
+func (e *evaluator) header(h *routepb.HeaderMatcher) result {
+	if !e.req.isHTTP() {
+		return noMatch
+	}
+	var value string
+	var present bool
+	switch name := strings.ToLower(h.GetName()); name {
+	case ":method":
+		value, present = e.req.Method, e.req.Method != ""
+	case ":authority", "host":
+		value, present = e.req.Host, e.req.Host != ""
+	case ":path":
+		value, present = e.req.Path, e.req.Path != ""
+	default:
+		for k, v := range e.req.Headers {
+			if strings.ToLower(k) == name {
+				value, present = v, true
+				break
+			}
+		}
+	}
+
+	var matched bool
+	switch m := h.GetHeaderMatchSpecifier().(type) {
+	case *routepb.HeaderMatcher_PresentMatch:
+		matched = present == m.PresentMatch
+	case *routepb.HeaderMatcher_ExactMatch:
+		matched = present && value == m.ExactMatch
+	case *routepb.HeaderMatcher_PrefixMatch:
+		matched = present && strings.HasPrefix(value, m.PrefixMatch)
+	case *routepb.HeaderMatcher_SuffixMatch:
+		matched = present && strings.HasSuffix(value, m.SuffixMatch)
+	case *routepb.HeaderMatcher_ContainsMatch:
+		matched = present && strings.Contains(value, m.ContainsMatch)
+	case *routepb.HeaderMatcher_SafeRegexMatch:
+		matched = present && matchRegex(m.SafeRegexMatch.GetRegex(), value)
+	case *routepb.HeaderMatcher_StringMatch:
+		matched = present && matchString(m.StringMatch, value)
+	default:
+		e.unsupported[fmt.Sprintf("header %s", h.GetName())] = struct{}{}
+		return unknown
+	}
+	return boolResult(matched != h.GetInvertMatch())
+}
+
+func (e *evaluator) path(m *matcherpb.PathMatcher) bool {
+	if e.req.Path == "" {
+		return false
+	}
+	path := e.req.Path
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	return matchString(m.GetPath(), path)
+}
+
+// metadata evaluates the metadata matchers of the request principal, the other metadata are unknown.
+func (e *evaluator) metadata(m *matcherpb.MetadataMatcher) result {
+	if m.GetFilter() != authn.AuthnFilterName || len(m.GetPath()) != 1 || m.GetPath()[0].GetKey() != requestPrincipalKey ||
+		m.GetValue().GetStringMatch() == nil {
+		e.unsupported[fmt.Sprintf("metadata %s", m.GetFilter())] = struct{}{}
+		return unknown
+	}
+	matched := e.req.RequestPrincipal != "" && matchString(m.GetValue().GetStringMatch(), e.req.RequestPrincipal)
+	return boolResult(matched != m.GetInvert())
+}
+
+func matchString(m *matcherpb.StringMatcher, v string) bool {
+	if m == nil {
+		return false
+	}
+	if m.GetIgnoreCase() {
+		v = strings.ToLower(v)
+	}
+	lower := func(s string) string {
+		if m.GetIgnoreCase() {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	switch p := m.GetMatchPattern().(type) {
+	case *matcherpb.StringMatcher_Exact:
+		return v == lower(p.Exact)
+	case *matcherpb.StringMatcher_Prefix:
+		return strings.HasPrefix(v, lower(p.Prefix))
+	case *matcherpb.StringMatcher_Suffix:
+		return strings.HasSuffix(v, lower(p.Suffix))
+	case *matcherpb.StringMatcher_Contains:
+		return strings.Contains(v, lower(p.Contains))
+	case *matcherpb.StringMatcher_SafeRegex:
+		return matchRegex(p.SafeRegex.GetRegex(), v)
+	}
+	return false
+}
+
+// matchRegex returns true if the value fully matches the RE2 regex, as Envoy does.
+func matchRegex(regex, v string) bool {
+	r, err := regexp.Compile("^(?:" + regex + ")$")
+	if err != nil {
+		return false
+	}
+	return r.MatchString(v)
+}
+
+func matchCidr(c *core.CidrRange, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(fmt.Sprintf("%s/%d", c.GetAddressPrefix(), c.GetPrefixLen().GetValue()))
+	if err != nil {
+		return false
+	}
+	return cidr.Contains(addr)
+}
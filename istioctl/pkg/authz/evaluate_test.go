@@ -0,0 +1,329 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	rbac_http_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	hcm_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	rbac_tcp_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"istio.io/istio/pilot/pkg/security/authz/matcher"
+	authn "istio.io/istio/pilot/pkg/security/model"
+)
+
+func toAny(t *testing.T, m proto.Message) *anypb.Any {
+	t.Helper()
+	a, err := anypb.New(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func httpChain(t *testing.T, name string, port uint32, transport string, rbacs ...*rbacpb.RBAC) *listener.FilterChain {
+	hcm := &hcm_filter.HttpConnectionManager{}
+	for _, r := range rbacs {
+		hcm.HttpFilters = append(hcm.HttpFilters, &hcm_filter.HttpFilter{
+			Name:       wellknown.HTTPRoleBasedAccessControl,
+			ConfigType: &hcm_filter.HttpFilter_TypedConfig{TypedConfig: toAny(t, &rbac_http_filter.RBAC{Rules: r})},
+		})
+	}
+	return &listener.FilterChain{
+		Name:             name,
+		FilterChainMatch: &listener.FilterChainMatch{DestinationPort: wrapPort(port), TransportProtocol: transport},
+		Filters: []*listener.Filter{{
+			Name:       wellknown.HTTPConnectionManager,
+			ConfigType: &listener.Filter_TypedConfig{TypedConfig: toAny(t, hcm)},
+		}},
+	}
+}
+
+func tcpChain(t *testing.T, name string, port uint32, transport string, rbacs ...*rbacpb.RBAC) *listener.FilterChain {
+	fc := &listener.FilterChain{
+		Name:             name,
+		FilterChainMatch: &listener.FilterChainMatch{DestinationPort: wrapPort(port), TransportProtocol: transport},
+	}
+	for _, r := range rbacs {
+		fc.Filters = append(fc.Filters, &listener.Filter{
+			Name:       wellknown.RoleBasedAccessControl,
+			ConfigType: &listener.Filter_TypedConfig{TypedConfig: toAny(t, &rbac_tcp_filter.RBAC{Rules: r})},
+		})
+	}
+	return fc
+}
+
+func TestEvaluate(t *testing.T) {
+	anyPrincipal := []*rbacpb.Principal{{Identifier: &rbacpb.Principal_Any{Any: true}}}
+	anyPermission := []*rbacpb.Permission{{Rule: &rbacpb.Permission_Any{Any: true}}}
+	denyHTTP := &rbacpb.RBAC{
+		Action: rbacpb.RBAC_DENY,
+		Policies: map[string]*rbacpb.Policy{
+			"ns[default]-policy[deny-admin]-rule[0]": {
+				Permissions: []*rbacpb.Permission{{Rule: &rbacpb.Permission_UrlPath{
+					UrlPath: matcher.PathMatcher("/admin*"),
+				}}},
+				Principals: anyPrincipal,
+			},
+		},
+	}
+	allowHTTP := &rbacpb.RBAC{
+		Action: rbacpb.RBAC_ALLOW,
+		Policies: map[string]*rbacpb.Policy{
+			"ns[default]-policy[allow-sleep]-rule[0]": {
+				Permissions: []*rbacpb.Permission{{Rule: &rbacpb.Permission_Header{
+					Header: matcher.HeaderMatcher(":method", "GET"),
+				}}},
+				Principals: []*rbacpb.Principal{{Identifier: &rbacpb.Principal_Authenticated_{
+					Authenticated: &rbacpb.Principal_Authenticated{
+						PrincipalName: matcher.StringMatcher("spiffe://cluster.local/ns/default/sa/sleep"),
+					},
+				}}},
+			},
+			"ns[default]-policy[allow-internal]-rule[1]": {
+				Permissions: anyPermission,
+				Principals: []*rbacpb.Principal{{Identifier: &rbacpb.Principal_DirectRemoteIp{
+					DirectRemoteIp: mustCidr(t, "10.0.0.0/8"),
+				}}},
+			},
+			"ns[default]-policy[allow-jwt]-rule[0]": {
+				Permissions: []*rbacpb.Permission{{Rule: &rbacpb.Permission_Header{
+					Header: matcher.HostMatcher(":authority", "*.example.com"),
+				}}},
+				Principals: []*rbacpb.Principal{{Identifier: &rbacpb.Principal_Metadata{
+					Metadata: matcher.MetadataStringMatcher(authn.AuthnFilterName, requestPrincipalKey,
+						matcher.StringMatcher("https://issuer/alice")),
+				}}},
+			},
+		},
+	}
+	allowTCP := &rbacpb.RBAC{
+		Action: rbacpb.RBAC_ALLOW,
+		Policies: map[string]*rbacpb.Policy{
+			"ns[db]-policy[allow-foo]-rule[0]": {
+				Permissions: []*rbacpb.Permission{{Rule: &rbacpb.Permission_DestinationPort{DestinationPort: 9000}}},
+				Principals: []*rbacpb.Principal{{Identifier: &rbacpb.Principal_Authenticated_{
+					Authenticated: &rbacpb.Principal_Authenticated{
+						PrincipalName: matcher.StringMatcherRegex(".*/ns/foo/.*"),
+					},
+				}}},
+			},
+		},
+	}
+	denyAll := &rbacpb.RBAC{Action: rbacpb.RBAC_ALLOW}
+
+	listeners := []*listener.Listener{
+		{
+			Name:             "0.0.0.0_8000",
+			TrafficDirection: core.TrafficDirection_OUTBOUND,
+			Address:          socketAddress(8000),
+			FilterChains:     []*listener.FilterChain{httpChain(t, "outbound", 0, "", denyAll)},
+		},
+		{
+			Name:             "virtualInbound",
+			TrafficDirection: core.TrafficDirection_INBOUND,
+			Address:          socketAddress(15006),
+			FilterChains: []*listener.FilterChain{
+				httpChain(t, "0.0.0.0_8000_tls", 8000, "tls", denyHTTP, allowHTTP),
+				httpChain(t, "0.0.0.0_8000", 8000, "raw_buffer", denyHTTP, allowHTTP),
+				tcpChain(t, "0.0.0.0_9000", 9000, "tls", allowTCP),
+				tcpChain(t, "virtualInbound", 0, "", denyAll),
+			},
+		},
+	}
+
+	cases := []struct {
+		name    string
+		req     *Request
+		chain   string
+		allowed bool
+		policy  string
+		rule    string
+	}{
+		{
+			name:    "allowed by principal and method",
+			req:     &Request{Port: 8000, Method: "GET", Path: "/ip", SourcePrincipal: "cluster.local/ns/default/sa/sleep"},
+			chain:   "0.0.0.0_8000_tls",
+			allowed: true,
+			policy:  "allow-sleep.default",
+			rule:    "0",
+		},
+		{
+			name:    "denied by path",
+			req:     &Request{Port: 8000, Method: "GET", Path: "/admin/users?id=1", SourcePrincipal: "cluster.local/ns/default/sa/sleep"},
+			chain:   "0.0.0.0_8000_tls",
+			allowed: false,
+			policy:  "deny-admin.default",
+			rule:    "0",
+		},
+		{
+			name:    "plaintext request from other principal",
+			req:     &Request{Port: 8000, Method: "GET", Path: "/ip", SourceIP: "192.168.0.1"},
+			chain:   "0.0.0.0_8000",
+			allowed: false,
+		},
+		{
+			name:    "allowed by source ip",
+			req:     &Request{Port: 8000, Method: "POST", Path: "/ip", SourceIP: "10.1.2.3"},
+			chain:   "0.0.0.0_8000",
+			allowed: true,
+			policy:  "allow-internal.default",
+			rule:    "1",
+		},
+		{
+			name:    "allowed by request principal and host",
+			req:     &Request{Port: 8000, Method: "POST", Host: "Foo.Example.com", RequestPrincipal: "https://issuer/alice"},
+			chain:   "0.0.0.0_8000",
+			allowed: true,
+			policy:  "allow-jwt.default",
+			rule:    "0",
+		},
+		{
+			name:    "tcp allowed by namespace",
+			req:     &Request{Port: 9000, SourcePrincipal: "cluster.local/ns/foo/sa/client"},
+			chain:   "0.0.0.0_9000",
+			allowed: true,
+			policy:  "allow-foo.db",
+			rule:    "0",
+		},
+		{
+			name:    "tcp denied for other namespace",
+			req:     &Request{Port: 9000, SourcePrincipal: "cluster.local/ns/bar/sa/client"},
+			chain:   "0.0.0.0_9000",
+			allowed: false,
+		},
+		{
+			name:    "catch all chain",
+			req:     &Request{Port: 7000},
+			chain:   "virtualInbound",
+			allowed: false,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Evaluate(listeners, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.FilterChain != tt.chain {
+				t.Errorf("expected filter chain %s, got %s", tt.chain, v.FilterChain)
+			}
+			if v.Allowed != tt.allowed {
+				t.Errorf("expected allowed %v, got %v", tt.allowed, v.Allowed)
+			}
+			var policy, rule string
+			if v.Decision != nil {
+				policy, rule = v.Decision.Policy, v.Decision.Rule
+			}
+			if policy != tt.policy || rule != tt.rule {
+				t.Errorf("expected policy %s rule %s, got policy %s rule %s", tt.policy, tt.rule, policy, rule)
+			}
+			if len(v.Unsupported) > 0 {
+				t.Errorf("unexpected unsupported matchers %v", v.Unsupported)
+			}
+		})
+	}
+
+	t.Run("no filter chain", func(t *testing.T) {
+		if _, err := Evaluate(listeners[:1], &Request{Port: 8000}); err == nil {
+			t.Fatal("expected an error for outbound listeners only")
+		}
+	})
+
+	t.Run("print", func(t *testing.T) {
+		v, err := Evaluate(listeners, &Request{Port: 8000, Method: "GET", Path: "/admin"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := &bytes.Buffer{}
+		v.Print(out)
+		want := "Verdict: DENY, matched DENY policy deny-admin.default rule 0"
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	})
+
+	t.Run("negated unsupported matcher", func(t *testing.T) {
+		allowNotSNI := &rbacpb.RBAC{
+			Action: rbacpb.RBAC_ALLOW,
+			Policies: map[string]*rbacpb.Policy{
+				"ns[db]-policy[allow-not-sni]-rule[0]": {
+					Permissions: []*rbacpb.Permission{{Rule: &rbacpb.Permission_NotRule{
+						NotRule: &rbacpb.Permission{Rule: &rbacpb.Permission_RequestedServerName{
+							RequestedServerName: matcher.StringMatcher("internal.example.com"),
+						}},
+					}}},
+					Principals: anyPrincipal,
+				},
+			},
+		}
+		inbound := []*listener.Listener{{
+			Name:             "virtualInbound",
+			TrafficDirection: core.TrafficDirection_INBOUND,
+			Address:          socketAddress(15006),
+			FilterChains:     []*listener.FilterChain{tcpChain(t, "0.0.0.0_9000", 9000, "", allowNotSNI)},
+		}}
+		v, err := Evaluate(inbound, &Request{Port: 9000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Allowed {
+			t.Errorf("expected a negated unsupported matcher not to allow the request")
+		}
+		if len(v.Results) != 1 || v.Results[0].Matched || !v.Results[0].Unknown {
+			t.Errorf("expected the rule to be unknown, got %+v", v.Results)
+		}
+		if len(v.Unsupported) != 1 || v.Unsupported[0] != "connection.sni" {
+			t.Errorf("expected connection.sni to be unsupported, got %v", v.Unsupported)
+		}
+		out := &bytes.Buffer{}
+		v.Print(out)
+		if !strings.Contains(out.String(), "unknown") {
+			t.Errorf("expected the rule to be printed as unknown, got:\n%s", out.String())
+		}
+	})
+}
+
+func wrapPort(port uint32) *wrapperspb.UInt32Value {
+	if port == 0 {
+		return nil
+	}
+	return &wrapperspb.UInt32Value{Value: port}
+}
+
+func socketAddress(port uint32) *core.Address {
+	return &core.Address{Address: &core.Address_SocketAddress{SocketAddress: &core.SocketAddress{
+		Address:       "0.0.0.0",
+		PortSpecifier: &core.SocketAddress_PortValue{PortValue: port},
+	}}}
+}
+
+func mustCidr(t *testing.T, v string) *core.CidrRange {
+	c, err := matcher.CidrRange(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
 	rbac_http_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
@@ -40,11 +41,17 @@ const (
 var re = regexp.MustCompile(`ns\[(.+)\]-policy\[(.+)\]-rule\[(.+)\]`)
 
 type filterChain struct {
+	name     string
+	match    *listener.FilterChainMatch
+	http     bool
 	rbacHTTP []*rbac_http_filter.RBAC
 	rbacTCP  []*rbac_tcp_filter.RBAC
 }
 
 type parsedListener struct {
+	name         string
+	port         int
+	outbound     bool
 	filterChains []*filterChain
 }
 
@@ -80,12 +87,17 @@ func getHTTPFilterConfig(filter *hcm_filter.HttpFilter, out proto.Message) error
 func parse(listeners []*listener.Listener) []*parsedListener {
 	var parsedListeners []*parsedListener
 	for _, l := range listeners {
-		parsed := &parsedListener{}
+		parsed := &parsedListener{
+			name:     l.Name,
+			port:     int(l.GetAddress().GetSocketAddress().GetPortValue()),
+			outbound: l.TrafficDirection == core.TrafficDirection_OUTBOUND,
+		}
 		for _, fc := range l.FilterChains {
-			parsedFC := &filterChain{}
+			parsedFC := &filterChain{name: fc.Name, match: fc.FilterChainMatch}
 			for _, filter := range fc.Filters {
 				switch filter.Name {
 				case wellknown.HTTPConnectionManager, "envoy.http_connection_manager":
+					parsedFC.http = true
 					if cm := getHTTPConnectionManager(filter); cm != nil {
 						for _, httpFilter := range cm.GetHttpFilters() {
 							switch httpFilter.GetName() {
@@ -0,0 +1,194 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/pkg/test/echo"
+)
+
+// Check is an assertion against the mesh.
+type Check struct {
+	Name        string
+	Description string
+	// Config is the template of the Istio config applied during the check.
+	Config string
+	run    func(ctx context.Context, e *env) error
+}
+
+// Checks are the checks run by a conformance run, in order.
+var Checks = []Check{
+	{
+		Name:        "mtls",
+		Description: "Requests between sidecars use mutual TLS with the identity of the client, in STRICT mode",
+		Config:      strictMTLSConfig,
+		run:         checkMTLS,
+	},
+	{
+		Name:        "routing",
+		Description: "Requests are routed to the subsets of a VirtualService according to their headers",
+		Config:      routingConfig,
+		run:         checkRouting,
+	},
+	{
+		Name:        "authz",
+		Description: "Requests denied by an AuthorizationPolicy are rejected, and the others are allowed",
+		Config:      authzConfig,
+		run:         checkAuthz,
+	},
+	{
+		Name:        "telemetry",
+		Description: "The sidecar of the server reports the istio_requests_total metric of the requests of the client",
+		run:         checkTelemetry,
+	},
+}
+
+// selectChecks returns the checks with the given names, or all checks if none are given.
+func selectChecks(names []string) ([]Check, error) {
+	if len(names) == 0 {
+		return Checks, nil
+	}
+	var checks []Check
+	for _, name := range names {
+		found := false
+		for _, c := range Checks {
+			if c.Name == name {
+				checks = append(checks, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown check %q, known checks: %s", name, strings.Join(CheckNames(), ", "))
+		}
+	}
+	return checks, nil
+}
+
+// CheckNames returns the names of the checks.
+func CheckNames() []string {
+	names := make([]string, 0, len(Checks))
+	for _, c := range Checks {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// expectCode returns an error if a response does not have the expected status code.
+func expectCode(responses echo.Responses, expected int) error {
+	for _, r := range responses {
+		if r.Code != strconv.Itoa(expected) {
+			return fmt.Errorf("expected status code %d, received %s", expected, r.Code)
+		}
+	}
+	return nil
+}
+
+func checkMTLS(ctx context.Context, e *env) error {
+	responses, err := e.call(ctx, "/", nil, 1)
+	if err != nil {
+		return err
+	}
+	if err := expectCode(responses, http.StatusOK); err != nil {
+		return err
+	}
+	identity := fmt.Sprintf("/ns/%s/sa/client", e.namespace)
+	for _, r := range responses {
+		xfcc := r.RequestHeaders.Get("X-Forwarded-Client-Cert")
+		if xfcc == "" {
+			return fmt.Errorf("request was not sent over mutual TLS")
+		}
+		if !strings.Contains(xfcc, identity) {
+			return fmt.Errorf("expected the client identity %s, received X-Forwarded-Client-Cert %s", identity, xfcc)
+		}
+	}
+	return nil
+}
+
+func checkRouting(ctx context.Context, e *env) error {
+	for header, version := range map[string]string{"": "v1", "v2": "v2"} {
+		var headers map[string]string
+		if header != "" {
+			headers = map[string]string{"x-conformance-route": header}
+		}
+		responses, err := e.call(ctx, "/", headers, 10)
+		if err != nil {
+			return err
+		}
+		if err := expectCode(responses, http.StatusOK); err != nil {
+			return err
+		}
+		for _, r := range responses {
+			if r.Version != version {
+				return fmt.Errorf("expected requests with header %q to be routed to %s, received %s", header, version, r.Version)
+			}
+		}
+	}
+	return nil
+}
+
+func checkAuthz(ctx context.Context, e *env) error {
+	responses, err := e.call(ctx, "/denied", nil, 1)
+	if err != nil {
+		return err
+	}
+	if err := expectCode(responses, http.StatusForbidden); err != nil {
+		return fmt.Errorf("denied request: %v", err)
+	}
+	responses, err = e.call(ctx, "/", nil, 1)
+	if err != nil {
+		return err
+	}
+	if err := expectCode(responses, http.StatusOK); err != nil {
+		return fmt.Errorf("allowed request: %v", err)
+	}
+	return nil
+}
+
+func checkTelemetry(ctx context.Context, e *env) error {
+	responses, err := e.call(ctx, "/", nil, 1)
+	if err != nil {
+		return err
+	}
+	if err := expectCode(responses, http.StatusOK); err != nil {
+		return err
+	}
+	stats, err := e.client.EnvoyDo(ctx, e.serverPod, e.namespace, "GET", "stats/prometheus")
+	if err != nil {
+		return err
+	}
+	if !hasRequestsTotal(string(stats), "client") {
+		return fmt.Errorf("istio_requests_total of the requests of the client not reported by %s", e.serverPod)
+	}
+	return nil
+}
+
+// hasRequestsTotal returns true if the Prometheus stats have the istio_requests_total metric reported by the
+// destination of the requests of the source workload.
+func hasRequestsTotal(stats, sourceWorkload string) bool {
+	for _, line := range strings.Split(stats, "\n") {
+		if strings.HasPrefix(line, "istio_requests_total{") &&
+			strings.Contains(line, `reporter="destination"`) &&
+			strings.Contains(line, `source_workload="`+sourceWorkload+`"`) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conformance validates an installed mesh by deploying minimal echo workloads and running a curated set of
+// checks of mTLS, routing, authorization and telemetry against them.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/echo"
+	"istio.io/istio/pkg/test/echo/proto"
+	"istio.io/pkg/log"
+)
+
+const (
+	// DefaultImage is the default image of the echo workloads.
+	DefaultImage = "gcr.io/istio-testing/app:latest"
+
+	echoGRPCPort = 7070
+	pollInterval = time.Second
+)
+
+// Options configure a conformance run.
+type Options struct {
+	// Namespace the workloads are deployed in. It is created by the run, and deleted unless KeepNamespace is set.
+	Namespace string
+	// ExistingNamespace deploys the workloads in an existing namespace, which is labeled for injection beforehand,
+	// and deletes the workloads rather than the namespace at the end of the run. The runner then only needs
+	// permissions in that namespace.
+	ExistingNamespace bool
+	// Image is the image of the echo workloads.
+	Image string
+	// Timeout is the maximum time to wait for the workloads to be ready, and for each check to pass once its config
+	// is applied.
+	Timeout time.Duration
+	// KeepNamespace keeps the namespace after the run, to debug failures.
+	KeepNamespace bool
+	// Checks are the names of the checks to run. All checks are run if empty.
+	Checks []string
+}
+
+// env is the environment the checks run in.
+type env struct {
+	client    kube.ExtendedClient
+	namespace string
+	// echo is the echo client workload, forwarding requests to the server workloads.
+	echo proto.EchoTestServiceClient
+	// serverPod is the name of a pod of the server workloads.
+	serverPod string
+}
+
+// call sends count requests from the client to the server workloads.
+func (e *env) call(ctx context.Context, path string, headers map[string]string, count int) (echo.Responses, error) {
+	req := &proto.ForwardEchoRequest{
+		Url:           "http://server" + path,
+		Count:         int32(count),
+		TimeoutMicros: (5 * time.Second).Microseconds(),
+	}
+	for k, v := range headers {
+		req.Headers = append(req.Headers, &proto.Header{Key: k, Value: v})
+	}
+	resp, err := e.echo.ForwardEcho(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return echo.ParseResponses(req, resp), nil
+}
+
+// Run deploys the workloads and runs the checks, returning their results.
+func Run(ctx context.Context, client kube.ExtendedClient, opts Options) (*Report, error) {
+	checks, err := selectChecks(opts.Checks)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.ExistingNamespace {
+		if err := createNamespace(ctx, client, opts.Namespace); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.KeepNamespace {
+		defer func() {
+			if opts.ExistingNamespace {
+				if err := deleteConfig(client, opts.Namespace, workloadsTemplate, opts); err != nil {
+					log.Warnf("failed to delete the workloads in namespace %s: %v", opts.Namespace, err)
+				}
+				return
+			}
+			if err := client.Kube().CoreV1().Namespaces().Delete(context.Background(), opts.Namespace, metav1.DeleteOptions{}); err != nil {
+				log.Warnf("failed to delete namespace %s: %v", opts.Namespace, err)
+			}
+		}()
+	}
+
+	if err := apply(client, opts.Namespace, workloadsTemplate, opts); err != nil {
+		return nil, fmt.Errorf("failed to deploy the workloads: %v", err)
+	}
+	e := &env{client: client, namespace: opts.Namespace}
+	var clientPod string
+	if err := poll(ctx, opts.Timeout, func() error {
+		var err error
+		if clientPod, err = readyPod(ctx, client, opts.Namespace, "app=client"); err != nil {
+			return err
+		}
+		e.serverPod, err = readyPod(ctx, client, opts.Namespace, "app=server")
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("workloads are not ready: %v", err)
+	}
+
+	fw, err := client.NewPortForwarder(clientPod, opts.Namespace, "127.0.0.1", 0, echoGRPCPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := fw.Start(); err != nil {
+		return nil, fmt.Errorf("failed to port forward to %s: %v", clientPod, err)
+	}
+	defer fw.Close()
+	conn, err := grpc.DialContext(ctx, fw.Address(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	e.echo = proto.NewEchoTestServiceClient(conn)
+
+	report := &Report{Namespace: opts.Namespace}
+	for _, c := range checks {
+		report.Results = append(report.Results, runCheck(ctx, e, c, opts))
+	}
+	return report, nil
+}
+
+func runCheck(ctx context.Context, e *env, c Check, opts Options) Result {
+	start := time.Now()
+	result := Result{Name: c.Name, Description: c.Description}
+	err := func() error {
+		if c.Config != "" {
+			if err := apply(e.client, e.namespace, c.Config, opts); err != nil {
+				return fmt.Errorf("failed to apply the config: %v", err)
+			}
+			defer func() {
+				if err := deleteConfig(e.client, e.namespace, c.Config, opts); err != nil {
+					log.Warnf("failed to delete the config of check %s: %v", c.Name, err)
+				}
+			}()
+		}
+		// The config takes some time to be propagated to the proxies.
+		return poll(ctx, opts.Timeout, func() error {
+			return c.run(ctx, e)
+		})
+	}()
+	result.Passed = err == nil
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+func createNamespace(ctx context.Context, client kube.ExtendedClient, namespace string) error {
+	labels := map[string]string{"istio-injection": "enabled"}
+	if rev := client.Revision(); rev != "" {
+		labels = map[string]string{"istio.io/rev": rev}
+	}
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: labels}}
+	if _, err := client.Kube().CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		if kerrors.IsAlreadyExists(err) {
+			return fmt.Errorf("namespace %s already exists, delete it or run in another namespace", namespace)
+		}
+		return err
+	}
+	return nil
+}
+
+// readyPod returns the name of a ready pod matching the selector.
+func readyPod(ctx context.Context, client kube.ExtendedClient, namespace, selector string) (string, error) {
+	pods, err := client.PodsForSelector(ctx, namespace, selector)
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		for _, c := range pod.Status.Conditions {
+			if c.Type == v1.PodReady && c.Status == v1.ConditionTrue {
+				return pod.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no ready pod matching %s", selector)
+}
+
+// render executes the template of the manifests with the options.
+func render(tmpl string, opts Options) (string, error) {
+	t, err := template.New("manifests").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	data := struct {
+		Options
+		Workloads []workload
+	}{opts, workloads}
+	if err := t.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func apply(client kube.ExtendedClient, namespace, tmpl string, opts Options) error {
+	return withManifestFile(tmpl, opts, func(file string) error {
+		return client.ApplyYAMLFiles(namespace, file)
+	})
+}
+
+func deleteConfig(client kube.ExtendedClient, namespace, tmpl string, opts Options) error {
+	return withManifestFile(tmpl, opts, func(file string) error {
+		return client.DeleteYAMLFiles(namespace, file)
+	})
+}
+
+// withManifestFile calls f with a temporary file holding the rendered manifests.
+func withManifestFile(tmpl string, opts Options, f func(file string) error) error {
+	manifests, err := render(tmpl, opts)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp("", "istio-conformance-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(manifests); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return f(file.Name())
+}
+
+// poll calls f until it succeeds or the timeout expires, returning its last error.
+func poll(ctx context.Context, timeout time.Duration, f func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRender(t *testing.T) {
+	opts := Options{Namespace: "conformance", Image: "example.com/app:1.0"}
+	for _, tmpl := range []string{workloadsTemplate, strictMTLSConfig, routingConfig, authzConfig} {
+		manifests, err := render(tmpl, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, doc := range strings.Split(manifests, "\n---\n") {
+			obj := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(doc), &obj.Object); err != nil {
+				t.Fatalf("invalid manifest %s: %v", doc, err)
+			}
+			if obj.GetKind() == "" || obj.GetName() == "" {
+				t.Fatalf("manifest without kind or name: %s", doc)
+			}
+			if obj.GetKind() == "Deployment" {
+				containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+				if image := containers[0].(map[string]interface{})["image"]; image != opts.Image {
+					t.Fatalf("expected image %s, got %v", opts.Image, image)
+				}
+			}
+		}
+	}
+}
+
+func TestSelectChecks(t *testing.T) {
+	checks, err := selectChecks(nil)
+	if err != nil || len(checks) != len(Checks) {
+		t.Fatalf("expected all checks, got %v, %v", checks, err)
+	}
+	checks, err = selectChecks([]string{"authz", "mtls"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := []string{checks[0].Name, checks[1].Name}; !reflect.DeepEqual(got, []string{"authz", "mtls"}) {
+		t.Fatalf("got checks %v", got)
+	}
+	if _, err := selectChecks([]string{"mtls", "unknown"}); err == nil {
+		t.Fatal("expected an error for an unknown check")
+	}
+}
+
+func TestHasRequestsTotal(t *testing.T) {
+	stats := `# TYPE istio_requests_total counter
+istio_requests_total{reporter="destination",source_workload="client",destination_workload="server-v1"} 3
+istio_requests_total{reporter="source",source_workload="other",destination_workload="server-v1"} 1
+`
+	if !hasRequestsTotal(stats, "client") {
+		t.Error("expected the requests of the client")
+	}
+	if hasRequestsTotal(stats, "other") {
+		t.Error("expected no destination requests of other")
+	}
+}
+
+func testReport() *Report {
+	return &Report{
+		Namespace: "conformance",
+		Results: []Result{
+			{Name: "mtls", Description: "mTLS", Passed: true, Duration: 1500 * time.Millisecond},
+			{Name: "authz", Description: "authz", Error: "expected status code 403, received 200", Duration: time.Second},
+		},
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := testReport().WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	got := &Report{}
+	if err := json.Unmarshal(out.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, testReport()) {
+		t.Fatalf("got %+v, want %+v", got, testReport())
+	}
+}
+
+func TestReportJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := testReport().WriteJUnit(&out); err != nil {
+		t.Fatal(err)
+	}
+	got := junitTestSuites{}
+	if err := xml.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	suite := got.Suites[0]
+	if suite.Tests != 2 || suite.Failures != 1 || suite.Time != "2.500" {
+		t.Fatalf("unexpected suite %+v", suite)
+	}
+	if suite.Cases[0].Failure != nil || suite.Cases[1].Failure == nil ||
+		suite.Cases[1].Failure.Text != "expected status code 403, received 200" {
+		t.Fatalf("unexpected cases %+v", suite.Cases)
+	}
+}
+
+func TestReportText(t *testing.T) {
+	var out bytes.Buffer
+	if err := testReport().WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"mtls   PASS", "authz  FAIL", "1/2 checks passed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
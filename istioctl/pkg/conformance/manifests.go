@@ -0,0 +1,165 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+// workload is an echo workload deployed by the workloadsTemplate.
+type workload struct {
+	Name    string
+	App     string
+	Version string
+}
+
+var workloads = []workload{
+	{Name: "client", App: "client", Version: "v1"},
+	{Name: "server-v1", App: "server", Version: "v1"},
+	{Name: "server-v2", App: "server", Version: "v2"},
+}
+
+// workloadsTemplate deploys a client echo workload, and two versions of a server echo workload behind a service.
+const workloadsTemplate = `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: client
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: server
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: server
+  labels:
+    app: server
+spec:
+  selector:
+    app: server
+  ports:
+  - name: http
+    port: 80
+    targetPort: 8080
+  - name: grpc
+    port: 7070
+    targetPort: 7070
+{{- range .Workloads }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Name }}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: {{ .App }}
+      version: {{ .Version }}
+  template:
+    metadata:
+      labels:
+        app: {{ .App }}
+        version: {{ .Version }}
+    spec:
+      serviceAccountName: {{ .App }}
+      containers:
+      - name: app
+        image: {{ $.Image }}
+        args:
+        - --port
+        - "8080"
+        - --grpc
+        - "7070"
+        - --version
+        - {{ .Version }}
+        env:
+        - name: INSTANCE_IP
+          valueFrom:
+            fieldRef:
+              fieldPath: status.podIP
+        ports:
+        - containerPort: 8080
+        - containerPort: 7070
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 8080
+          periodSeconds: 2
+{{- end }}
+`
+
+const strictMTLSConfig = `
+apiVersion: security.istio.io/v1beta1
+kind: PeerAuthentication
+metadata:
+  name: conformance-mtls
+spec:
+  mtls:
+    mode: STRICT
+`
+
+const routingConfig = `
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: conformance-routing
+spec:
+  host: server
+  subsets:
+  - name: v1
+    labels:
+      version: v1
+  - name: v2
+    labels:
+      version: v2
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: conformance-routing
+spec:
+  hosts:
+  - server
+  http:
+  - match:
+    - headers:
+        x-conformance-route:
+          exact: v2
+    route:
+    - destination:
+        host: server
+        subset: v2
+  - route:
+    - destination:
+        host: server
+        subset: v1
+`
+
+const authzConfig = `
+apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: conformance-authz
+spec:
+  selector:
+    matchLabels:
+      app: server
+  action: DENY
+  rules:
+  - to:
+    - operation:
+        paths:
+        - /denied
+`
@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Report holds the results of the checks of a conformance run.
+type Report struct {
+	Namespace string   `json:"namespace"`
+	Results   []Result `json:"results"`
+}
+
+// Result is the result of a check.
+type Result struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Passed      bool          `json:"passed"`
+	Error       string        `json:"error,omitempty"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Failures returns the number of checks which failed.
+func (r *Report) Failures() int {
+	failures := 0
+	for _, res := range r.Results {
+		if !res.Passed {
+			failures++
+		}
+	}
+	return failures
+}
+
+// WriteText writes the results as a table.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tRESULT\tDURATION\tDETAILS")
+	for _, res := range r.Results {
+		result, details := "PASS", res.Description
+		if !res.Passed {
+			result, details = "FAIL", res.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\n", res.Name, result, res.Duration.Round(time.Millisecond), details)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d/%d checks passed\n", len(r.Results)-r.Failures(), len(r.Results))
+	return err
+}
+
+// WriteJSON writes the report as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as a JUnit XML test suite.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{Name: "istio-conformance", Tests: len(r.Results), Failures: r.Failures()}
+	var total time.Duration
+	for _, res := range r.Results {
+		total += res.Duration
+		tc := junitTestCase{Name: res.Name, ClassName: suite.Name, Time: junitTime(res.Duration)}
+		if !res.Passed {
+			tc.Failure = &junitFailure{Message: res.Description, Text: res.Error}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
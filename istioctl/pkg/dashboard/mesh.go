@@ -0,0 +1,111 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dashboard implements the local mesh dashboard served by istioctl dashboard mesh.
+package dashboard
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"istio.io/istio/istioctl/pkg/writer/pilot"
+	"istio.io/istio/pilot/pkg/xds"
+)
+
+//go:embed static/index.html
+var indexHTML []byte
+
+// DiscoveryDo sends a request to the debug endpoint at path of all istiods, returning the responses by istiod.
+type DiscoveryDo func(ctx context.Context, path string) (map[string][]byte, error)
+
+// ProxyStatus is the state of a proxy as shown in the dashboard.
+type ProxyStatus struct {
+	Proxy   string `json:"proxy"`
+	Cluster string `json:"cluster,omitempty"`
+	Istiod  string `json:"istiod"`
+	Version string `json:"version,omitempty"`
+	// Sync is the sync status by xDS type, one of CDS, LDS, EDS and RDS.
+	Sync map[string]string `json:"sync"`
+	// PushLatency is the time the proxy took to ack the last accepted push.
+	PushLatency string   `json:"push_latency,omitempty"`
+	Nacked      []string `json:"nacked,omitempty"`
+}
+
+// NewHandler returns the handler of the dashboard. The UI at / polls /api/proxies, which reads the sync
+// status of the proxies from istiod on every request.
+func NewHandler(discoveryDo DiscoveryDo) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(indexHTML)
+	})
+	mux.HandleFunc("/api/proxies", func(w http.ResponseWriter, req *http.Request) {
+		proxies, err := proxyStatuses(req.Context(), discoveryDo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(proxies)
+	})
+	return mux
+}
+
+func proxyStatuses(ctx context.Context, discoveryDo DiscoveryDo) ([]ProxyStatus, error) {
+	res, err := discoveryDo(ctx, "/debug/syncz")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ProxyStatus, 0)
+	for istiod, body := range res {
+		var statuses []xds.SyncStatus
+		if err := json.Unmarshal(body, &statuses); err != nil {
+			return nil, fmt.Errorf("failed to parse sync status of %s: %v", istiod, err)
+		}
+		for _, s := range statuses {
+			ps := ProxyStatus{
+				Proxy:   s.ProxyID,
+				Cluster: s.ClusterID,
+				Istiod:  istiod,
+				Version: s.IstioVersion,
+				Sync: map[string]string{
+					"CDS": pilot.XdsStatus(s.ClusterSent, s.ClusterAcked),
+					"LDS": pilot.XdsStatus(s.ListenerSent, s.ListenerAcked),
+					"EDS": pilot.XdsStatus(s.EndpointSent, s.EndpointAcked),
+					"RDS": pilot.XdsStatus(s.RouteSent, s.RouteAcked),
+				},
+				Nacked: s.Nacked,
+			}
+			if s.AckLatency > 0 {
+				ps.PushLatency = s.AckLatency.String()
+			}
+			out = append(out, ps)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cluster != out[j].Cluster {
+			return out[i].Cluster < out[j].Cluster
+		}
+		return out[i].Proxy < out[j].Proxy
+	})
+	return out, nil
+}
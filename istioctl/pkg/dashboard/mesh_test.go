@@ -0,0 +1,121 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/xds"
+)
+
+func TestHandler(t *testing.T) {
+	statuses := func(ss ...xds.SyncStatus) []byte {
+		b, err := json.Marshal(ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	discoveryDo := func(ctx context.Context, path string) (map[string][]byte, error) {
+		if path != "/debug/syncz" {
+			return nil, errors.New("unexpected path " + path)
+		}
+		return map[string][]byte{
+			"istiod-1": statuses(xds.SyncStatus{
+				ProxyID:       "b.default",
+				ClusterID:     "Kubernetes",
+				IstioVersion:  "1.12.0",
+				ClusterSent:   "1",
+				ClusterAcked:  "1",
+				ListenerSent:  "2",
+				ListenerAcked: "1",
+				RouteSent:     "3",
+				Nacked:        []string{"listener"},
+				AckLatency:    20 * time.Millisecond,
+			}),
+			"istiod-2": statuses(xds.SyncStatus{
+				ProxyID:   "a.default",
+				ClusterID: "Kubernetes",
+			}),
+		}, nil
+	}
+	h := NewHandler(discoveryDo)
+
+	t.Run("index", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "api/proxies") {
+			t.Fatalf("unexpected index response %d: %s", rec.Code, rec.Body.String())
+		}
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected not found, got %d", rec.Code)
+		}
+	})
+
+	t.Run("proxies", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/proxies", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected response %d: %s", rec.Code, rec.Body.String())
+		}
+		var got []ProxyStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		want := []ProxyStatus{
+			{
+				Proxy:   "a.default",
+				Cluster: "Kubernetes",
+				Istiod:  "istiod-2",
+				Sync:    map[string]string{"CDS": "NOT SENT", "LDS": "NOT SENT", "EDS": "NOT SENT", "RDS": "NOT SENT"},
+			},
+			{
+				Proxy:   "b.default",
+				Cluster: "Kubernetes",
+				Istiod:  "istiod-1",
+				Version: "1.12.0",
+				Sync: map[string]string{
+					"CDS": "SYNCED", "LDS": "STALE", "EDS": "NOT SENT", "RDS": "STALE (Never Acknowledged)",
+				},
+				PushLatency: "20ms",
+				Nacked:      []string{"listener"},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("istiod error", func(t *testing.T) {
+		h := NewHandler(func(ctx context.Context, path string) (map[string][]byte, error) {
+			return nil, errors.New("no istiod")
+		})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/proxies", nil))
+		if rec.Code != http.StatusBadGateway {
+			t.Fatalf("expected bad gateway, got %d", rec.Code)
+		}
+	})
+}
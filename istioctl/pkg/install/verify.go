@@ -16,6 +16,7 @@ package install
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -24,9 +25,16 @@ import (
 	"istio.io/istio/istioctl/pkg/util/formatting"
 	"istio.io/istio/istioctl/pkg/verifier"
 	"istio.io/istio/operator/cmd/mesh"
+	"istio.io/istio/operator/pkg/util/clog"
 	"istio.io/istio/pkg/config/constants"
 )
 
+const (
+	textFormat = "text"
+	jsonFormat = "json"
+	yamlFormat = "yaml"
+)
+
 // NewVerifyCommand creates a new command for verifying Istio Installation Status
 func NewVerifyCommand() *cobra.Command {
 	var (
@@ -40,6 +48,7 @@ func NewVerifyCommand() *cobra.Command {
 		istioNamespace string
 		opts           clioptions.ControlPlaneOptions
 		manifestsPath  string
+		outputFormat   string
 	)
 	verifyInstallCmd := &cobra.Command{
 		Use:   "verify-install [-f <deployment or istio operator file>] [--revision <revision>]",
@@ -53,6 +62,12 @@ in ready status. It will report failure when any of them are not ready.
 If you do not specify an installation it will check for an IstioOperator resource
 and will verify if pods and services defined in it are present.
 
+It then verifies the parts of the mesh that span revisions and clusters: the
+injection and validation webhooks of every control plane revision, the remote
+secrets of a multicluster mesh, the reachability of the east-west gateways and
+the consistency of the trust bundle across clusters. Use --output json or yaml
+for a machine readable result.
+
 Note: For verifying whether your cluster is ready for Istio installation, see
 istioctl experimental precheck.
 `,
@@ -66,8 +81,14 @@ istioctl experimental precheck.
   istioctl verify-install --revision <canary>
 
   # Verify the installation of specific revision
-  istioctl verify-install -r 1-9-0`,
+  istioctl verify-install -r 1-9-0
+
+  # Verify the installation and the clusters of a multicluster mesh, as JSON
+  istioctl verify-install -o json`,
 		Args: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != textFormat && outputFormat != jsonFormat && outputFormat != yamlFormat {
+				return fmt.Errorf("unknown output format %q, must be one of text|json|yaml", outputFormat)
+			}
 			if len(filenames) > 0 && opts.Revision != "" {
 				cmd.Println(cmd.UsageString())
 				return fmt.Errorf("supply either a file or revision, but not both")
@@ -75,15 +96,32 @@ istioctl experimental precheck.
 			return nil
 		},
 		RunE: func(c *cobra.Command, args []string) error {
+			var options []verifier.StatusVerifierOptions
+			if outputFormat != textFormat {
+				// Only the report is written to stdout, so that it can be parsed.
+				options = append(options, verifier.WithLogger(clog.NewConsoleLogger(io.Discard, c.ErrOrStderr(), nil)))
+			}
 			installationVerifier, err := verifier.NewStatusVerifier(istioNamespace, manifestsPath,
-				*kubeConfigFlags.KubeConfig, *kubeConfigFlags.Context, filenames, opts)
+				*kubeConfigFlags.KubeConfig, *kubeConfigFlags.Context, filenames, opts, options...)
 			if err != nil {
 				return err
 			}
-			if formatting.IstioctlColorDefault(c.OutOrStdout()) {
+			if outputFormat == textFormat && formatting.IstioctlColorDefault(c.OutOrStdout()) {
 				installationVerifier.Colorize()
 			}
-			return installationVerifier.Verify()
+			installErr := installationVerifier.Verify()
+			meshErr := installationVerifier.VerifyMesh()
+			if outputFormat != textFormat {
+				out, err := verifier.FormatReport(installationVerifier.Report(), outputFormat)
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprint(c.OutOrStdout(), out)
+			}
+			if installErr != nil {
+				return installErr
+			}
+			return meshErr
 		},
 	}
 
@@ -93,6 +131,7 @@ istioctl experimental precheck.
 	kubeConfigFlags.AddFlags(flags)
 	flags.StringSliceVarP(&filenames, "filename", "f", filenames, "Istio YAML installation file.")
 	verifyInstallCmd.PersistentFlags().StringVarP(&manifestsPath, "manifests", "d", "", mesh.ManifestsFlagHelpStr)
+	flags.StringVarP(&outputFormat, "output", "o", textFormat, "Output format: one of text|json|yaml")
 	opts.AttachControlPlaneFlags(verifyInstallCmd)
 	return verifyInstallCmd
 }
@@ -0,0 +1,278 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snapshot exports the Istio config of a cluster and restores it, in an order that respects the
+// dependencies between the kinds.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	yamlDecoder "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/api/label"
+	"istio.io/istio/operator/pkg/util"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// kindOrder is the order configs are restored in. Configs are applied after the ones they refer to, so that no
+// route points at a missing host, subset or gateway while the restore is in progress.
+var kindOrder = []string{
+	"ServiceEntry",
+	"WorkloadGroup",
+	"WorkloadEntry",
+	"DestinationRule",
+	"Gateway",
+	"VirtualService",
+	"Sidecar",
+	"PeerAuthentication",
+	"RequestAuthentication",
+	"AuthorizationPolicy",
+	"Telemetry",
+	"ProxyConfig",
+	"EnvoyFilter",
+	"WasmPlugin",
+}
+
+// Schemas returns the schemas of the config kinds in a snapshot, in restore order.
+func Schemas() []collection.Schema {
+	out := make([]collection.Schema, 0, len(kindOrder))
+	for _, kind := range kindOrder {
+		if s, f := schemaForKind(kind); f {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func schemaForKind(kind string) (collection.Schema, bool) {
+	for _, s := range collections.Pilot.All() {
+		if s.Resource().Kind() == kind {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// schemaFor returns the schema of an object. Any version of a known group and kind is accepted, since the
+// versions of a kind share the same schema.
+func schemaFor(obj *unstructured.Unstructured) (collection.Schema, error) {
+	gvk := obj.GroupVersionKind()
+	s, f := schemaForKind(gvk.Kind)
+	if !f || s.Resource().Group() != gvk.Group {
+		return nil, fmt.Errorf("%s %s is not an Istio config kind", obj.GetAPIVersion(), gvk.Kind)
+	}
+	return s, nil
+}
+
+func rank(kind string) int {
+	for i, k := range kindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kindOrder)
+}
+
+// Sort sorts configs in restore order: by kind, then namespace and name.
+func Sort(objs []*unstructured.Unstructured) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		a, b := objs[i], objs[j]
+		if ra, rb := rank(a.GetKind()), rank(b.GetKind()); ra != rb {
+			return ra < rb
+		}
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		return a.GetName() < b.GetName()
+	})
+}
+
+// sanitize removes the status and the metadata set by the API server, which would prevent restoring the config
+// in another cluster. Labels, including the revision label, and annotations are kept.
+func sanitize(obj *unstructured.Unstructured) {
+	delete(obj.Object, "status")
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	obj.SetOwnerReferences(nil)
+	obj.SetSelfLink("")
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// Take lists the Istio configs in namespace, or in all namespaces if namespace is empty, in restore order.
+func Take(ctx context.Context, client dynamic.Interface, namespace string) ([]*unstructured.Unstructured, error) {
+	var out []*unstructured.Unstructured
+	for _, s := range Schemas() {
+		list, err := client.Resource(s.Resource().GroupVersionResource()).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				// The CRD of the kind is not installed.
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s: %v", s.Resource().Kind(), err)
+		}
+		for i := range list.Items {
+			obj := list.Items[i].DeepCopy()
+			sanitize(obj)
+			out = append(out, obj)
+		}
+	}
+	Sort(out)
+	return out, nil
+}
+
+// Write writes configs as a multi-document YAML stream.
+func Write(w io.Writer, objs []*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		b, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read reads configs written by Write, in restore order.
+func Read(r io.Reader) ([]*unstructured.Unstructured, error) {
+	reader := yamlDecoder.NewYAMLReader(bufio.NewReader(r))
+	var out []*unstructured.Unstructured
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if _, err := schemaFor(obj); err != nil {
+			return nil, err
+		}
+		if obj.GetName() == "" || obj.GetNamespace() == "" {
+			return nil, fmt.Errorf("%s must have a name and a namespace", obj.GetKind())
+		}
+		out = append(out, obj)
+	}
+	Sort(out)
+	return out, nil
+}
+
+// Action is what restoring a config does to the cluster.
+type Action string
+
+const (
+	Created    Action = "created"
+	Configured Action = "configured"
+	Unchanged  Action = "unchanged"
+)
+
+// Change is the result of restoring a config.
+type Change struct {
+	Object *unstructured.Unstructured
+	Action Action
+	// Diff is the difference between the config in the cluster and the restored one, for configured configs.
+	Diff string
+	Err  error
+}
+
+// RestoreOptions control how configs are restored.
+type RestoreOptions struct {
+	// DryRun only computes the changes, without applying them.
+	DryRun bool
+	// Revision, if set, replaces the revision label of the configs that have one, to restore them to another
+	// control plane revision.
+	Revision string
+}
+
+// Restore applies configs to the cluster in order, creating the missing ones and updating the ones that differ.
+// A config that fails to apply does not stop the ones after it; its error is in its Change.
+func Restore(ctx context.Context, client dynamic.Interface, objs []*unstructured.Unstructured, opts RestoreOptions) ([]Change, error) {
+	changes := make([]Change, 0, len(objs))
+	for _, in := range objs {
+		obj := in.DeepCopy()
+		sanitize(obj)
+		if opts.Revision != "" {
+			if labels := obj.GetLabels(); labels[label.IoIstioRev.Name] != "" {
+				labels[label.IoIstioRev.Name] = opts.Revision
+				obj.SetLabels(labels)
+			}
+		}
+		s, err := schemaFor(obj)
+		if err != nil {
+			return nil, err
+		}
+		resource := client.Resource(s.Resource().GroupVersionResource()).Namespace(obj.GetNamespace())
+		// The object is written with the version of the schema, which may differ from the one of the file.
+		obj.SetAPIVersion(s.Resource().GroupVersionKind().GroupVersion())
+
+		change := Change{Object: obj}
+		existing, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			change.Action = Created
+			if !opts.DryRun {
+				_, change.Err = resource.Create(ctx, obj, metav1.CreateOptions{})
+			}
+		case err != nil:
+			change.Err = err
+		default:
+			current := existing.DeepCopy()
+			sanitize(current)
+			change.Diff = util.YAMLDiff(util.ToYAML(current.Object), util.ToYAML(obj.Object))
+			if change.Diff == "" {
+				change.Action = Unchanged
+				break
+			}
+			change.Action = Configured
+			if !opts.DryRun {
+				obj.SetResourceVersion(existing.GetResourceVersion())
+				_, change.Err = resource.Update(ctx, obj, metav1.UpdateOptions{})
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
@@ -0,0 +1,227 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// fakeClient returns a dynamic client that stores the configs as unstructured objects, like the API server.
+func fakeClient() dynamic.Interface {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, s := range Schemas() {
+		listKinds[s.Resource().GroupVersionResource()] = s.Resource().Kind() + "List"
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+}
+
+func object(t *testing.T, manifest string) *unstructured.Unstructured {
+	t.Helper()
+	objs, err := Read(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expected one object, got %d", len(objs))
+	}
+	return objs[0]
+}
+
+func create(t *testing.T, client dynamic.Interface, obj *unstructured.Unstructured) {
+	t.Helper()
+	s, err := schemaFor(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.SetAPIVersion(s.Resource().GroupVersionKind().GroupVersion())
+	_, err = client.Resource(s.Resource().GroupVersionResource()).Namespace(obj.GetNamespace()).Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const (
+	virtualService = `apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: reviews
+  namespace: default
+  labels:
+    istio.io/rev: stable
+spec:
+  hosts:
+  - reviews
+  http:
+  - route:
+    - destination:
+        host: reviews
+        subset: v1
+`
+	destinationRule = `apiVersion: networking.istio.io/v1beta1
+kind: DestinationRule
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  host: reviews
+  subsets:
+  - name: v1
+    labels:
+      version: v1
+`
+	authorizationPolicy = `apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: deny-all
+  namespace: bar
+spec: {}
+`
+)
+
+func TestRead(t *testing.T) {
+	objs, err := Read(strings.NewReader(authorizationPolicy + "---\n" + virtualService + "---\n\n---\n" + destinationRule))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, obj := range objs {
+		got = append(got, obj.GetKind())
+	}
+	want := []string{"DestinationRule", "VirtualService", "AuthorizationPolicy"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got order %v, want %v", got, want)
+	}
+
+	for _, manifest := range []string{
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n  namespace: default\n",
+		"apiVersion: networking.istio.io/v1alpha3\nkind: VirtualService\nmetadata:\n  name: foo\n",
+		"kind: [",
+	} {
+		if _, err := Read(strings.NewReader(manifest)); err == nil {
+			t.Errorf("expected an error for %q", manifest)
+		}
+	}
+}
+
+func TestTakeAndRestore(t *testing.T) {
+	source := fakeClient()
+	vs := object(t, virtualService)
+	vs.SetResourceVersion("42")
+	vs.SetUID("abc")
+	vs.SetAnnotations(map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"})
+	vs.Object["status"] = map[string]interface{}{"observedGeneration": "1"}
+	create(t, source, vs)
+	create(t, source, object(t, destinationRule))
+	create(t, source, object(t, authorizationPolicy))
+
+	objs, err := Take(context.TODO(), source, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := Write(out, objs); err != nil {
+		t.Fatal(err)
+	}
+	for _, unwanted := range []string{"resourceVersion", "uid", "last-applied-configuration", "status"} {
+		if strings.Contains(out.String(), unwanted) {
+			t.Errorf("snapshot contains %s:\n%s", unwanted, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "istio.io/rev: stable") {
+		t.Errorf("snapshot lost the revision label:\n%s", out.String())
+	}
+
+	namespaced, err := Take(context.TODO(), source, "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(namespaced) != 1 || namespaced[0].GetKind() != "AuthorizationPolicy" {
+		t.Fatalf("expected only the bar namespace, got %v", namespaced)
+	}
+
+	restored, err := Read(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := fakeClient()
+	changed := object(t, destinationRule)
+	if err := unstructured.SetNestedField(changed.Object, "reviews.default.svc.cluster.local", "spec", "host"); err != nil {
+		t.Fatal(err)
+	}
+	create(t, target, changed)
+	create(t, target, object(t, authorizationPolicy))
+
+	actions := func(changes []Change) []Action {
+		var out []Action
+		for _, ch := range changes {
+			if ch.Err != nil {
+				t.Fatalf("failed to restore %s: %v", ch.Object.GetName(), ch.Err)
+			}
+			out = append(out, ch.Action)
+		}
+		return out
+	}
+
+	changes, err := Restore(context.TODO(), target, restored, RestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := actions(changes), []Action{Configured, Created, Unchanged}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got actions %v, want %v", got, want)
+	}
+	if !strings.Contains(changes[0].Diff, "reviews.default.svc.cluster.local") {
+		t.Errorf("expected the diff to show the host, got:\n%s", changes[0].Diff)
+	}
+	vsGVR := collections.IstioNetworkingV1Alpha3Virtualservices.Resource().GroupVersionResource()
+	if _, err := target.Resource(vsGVR).Namespace("default").Get(context.TODO(), "reviews", metav1.GetOptions{}); err == nil {
+		t.Fatal("dry run created the virtual service")
+	}
+
+	changes, err = Restore(context.TODO(), target, restored, RestoreOptions{Revision: "canary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := actions(changes), []Action{Configured, Created, Unchanged}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got actions %v, want %v", got, want)
+	}
+	got, err := target.Resource(vsGVR).Namespace("default").Get(context.TODO(), "reviews", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev := got.GetLabels()["istio.io/rev"]; rev != "canary" {
+		t.Errorf("expected revision canary, got %q", rev)
+	}
+
+	changes, err = Restore(context.TODO(), target, restored, RestoreOptions{Revision: "canary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := actions(changes), []Action{Unchanged, Unchanged, Unchanged}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got actions %v after restoring twice, want %v", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"encoding/json"
+	"fmt"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	any "google.golang.org/protobuf/types/known/anypb"
+)
+
+// EcdsFilter is an extension config received by the proxy over ECDS.
+type EcdsFilter struct {
+	VersionInfo string
+	LastUpdated string
+	Config      *core.TypedExtensionConfig
+}
+
+// GetEcdsConfigDump retrieves the extension configs received over ECDS from a config dump wrapper.
+// The Envoy API vendored here does not define envoy.admin.v3.EcdsConfigDump yet, so the section is
+// decoded from its JSON form. Envoy emits either proto or JSON field names depending on the version.
+func (w *Wrapper) GetEcdsConfigDump() ([]*EcdsFilter, error) {
+	if w.rawEcds == nil {
+		return nil, fmt.Errorf("config dump has no configuration type %s", ecds)
+	}
+	section := map[string]json.RawMessage{}
+	if err := json.Unmarshal(w.rawEcds, &section); err != nil {
+		return nil, err
+	}
+	var filters []map[string]json.RawMessage
+	if raw := field(section, "ecds_filters", "ecdsFilters"); raw != nil {
+		if err := json.Unmarshal(raw, &filters); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]*EcdsFilter, 0, len(filters))
+	for _, f := range filters {
+		filter := &EcdsFilter{}
+		_ = json.Unmarshal(field(f, "version_info", "versionInfo"), &filter.VersionInfo)
+		_ = json.Unmarshal(field(f, "last_updated", "lastUpdated"), &filter.LastUpdated)
+		cfg, err := unmarshalTypedExtensionConfig(field(f, "ecds_filter", "ecdsFilter"))
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal ecds filter: %v", err)
+		}
+		filter.Config = cfg
+		out = append(out, filter)
+	}
+	return out, nil
+}
+
+// unmarshalTypedExtensionConfig decodes the JSON of an Any holding a TypedExtensionConfig. If the type of the
+// extension is not known to istioctl, only its name and type are kept.
+func unmarshalTypedExtensionConfig(b json.RawMessage) (*core.TypedExtensionConfig, error) {
+	cfg := &core.TypedExtensionConfig{}
+	a := &any.Any{}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(b, a); err == nil {
+		if err := a.UnmarshalTo(cfg); err == nil {
+			return cfg, nil
+		}
+	}
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw["name"], &cfg.Name); err != nil {
+		return nil, err
+	}
+	typedConfig := map[string]json.RawMessage{}
+	_ = json.Unmarshal(field(raw, "typed_config", "typedConfig"), &typedConfig)
+	cfg.TypedConfig = &any.Any{}
+	_ = json.Unmarshal(typedConfig["@type"], &cfg.TypedConfig.TypeUrl)
+	return cfg, nil
+}
+
+// rawSection returns the JSON of the config dump section of the given type, or nil if there is none.
+func rawSection(b []byte, sectionTypeURL configTypeURL) json.RawMessage {
+	var dump struct {
+		Configs []json.RawMessage `json:"configs"`
+	}
+	if err := json.Unmarshal(b, &dump); err != nil {
+		return nil
+	}
+	for _, conf := range dump.Configs {
+		var typed struct {
+			Type string `json:"@type"`
+		}
+		if err := json.Unmarshal(conf, &typed); err == nil && typed.Type == string(sectionTypeURL) {
+			return conf
+		}
+	}
+	return nil
+}
+
+func field(m map[string]json.RawMessage, names ...string) json.RawMessage {
+	for _, n := range names {
+		if v, f := m[n]; f {
+			return v
+		}
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ package configdump
 
 import (
 	"bytes"
+	"encoding/json"
 	"reflect"
 	"strings"
 
@@ -50,6 +51,9 @@ func (m *nonstrictResolver) Resolve(typeURL string) (proto.Message, error) {
 // It has extra helper functions for handling any/struct/marshal protobuf pain
 type Wrapper struct {
 	*adminapi.ConfigDump
+
+	// rawEcds is the JSON of the ECDS section, which cannot be decoded into ConfigDump. See GetEcdsConfigDump.
+	rawEcds json.RawMessage
 }
 
 // MarshalJSON is a custom marshaller to handle protobuf pain
@@ -69,6 +73,10 @@ func (w *Wrapper) UnmarshalJSON(b []byte) error {
 		AllowUnknownFields: true,
 		AnyResolver:        &envoyResolver,
 	}).Unmarshal(bytes.NewReader(b), cd)
-	*w = Wrapper{cd}
-	return err
+	*w = Wrapper{ConfigDump: cd}
+	if err != nil {
+		return err
+	}
+	w.rawEcds = rawSection(b, ecds)
+	return nil
 }
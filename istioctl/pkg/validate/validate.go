@@ -168,6 +168,12 @@ func (v *validator) validateResource(istioNamespace, defaultNamespace string, un
 	return nil, nil
 }
 
+// ValidateResource validates a resource the same way validate does, returning its warnings.
+func ValidateResource(istioNamespace, defaultNamespace string, un *unstructured.Unstructured) (validation.Warning, error) {
+	v := &validator{}
+	return v.validateResource(istioNamespace, defaultNamespace, un, io.Discard)
+}
+
 func (v *validator) validateServicePortPrefix(istioNamespace string, un *unstructured.Unstructured) error {
 	var errs error
 	if un.GetNamespace() == handleNamespace(istioNamespace) {
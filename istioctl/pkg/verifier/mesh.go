@@ -0,0 +1,443 @@
+// Copyright Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	admit_v1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/api/label"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube/multicluster"
+)
+
+const (
+	// caRootCertConfigMap is the config map istiod writes the trust bundle of the mesh to in every namespace.
+	caRootCertConfigMap = "istio-ca-root-cert"
+	// eastWestGatewaySelector selects the east-west gateways installed by gen-eastwest-gateway.sh.
+	eastWestGatewaySelector = "istio=eastwestgateway"
+	// crossNetworkPort is the port east-west gateways expose for cross-network mTLS traffic.
+	crossNetworkPort = 15443
+
+	defaultRevision = "default"
+	dialTimeout     = 3 * time.Second
+)
+
+// CheckStatus is the outcome of a verification check.
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "Passed"
+	CheckWarning CheckStatus = "Warning"
+	CheckFailed  CheckStatus = "Failed"
+)
+
+// CheckResult is the result of a single verification check.
+type CheckResult struct {
+	// Name is the kind of check, for example install, webhook or trust-bundle.
+	Name string `json:"name"`
+	// Cluster is the ID of the remote cluster the check ran against. It is empty for the cluster verify-install
+	// was pointed at.
+	Cluster  string      `json:"cluster,omitempty"`
+	Revision string      `json:"revision,omitempty"`
+	Resource string      `json:"resource,omitempty"`
+	Status   CheckStatus `json:"status"`
+	Message  string      `json:"message,omitempty"`
+}
+
+// Report is the machine readable result of verify-install.
+type Report struct {
+	Passed bool          `json:"passed"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// clusterClient is a cluster of the mesh, the local one or one read from a remote secret.
+type clusterClient struct {
+	id     string
+	client kubernetes.Interface
+}
+
+// Report returns the results of the checks run so far.
+func (v *StatusVerifier) Report() Report {
+	report := Report{Passed: true, Checks: v.checks}
+	for _, c := range v.checks {
+		if c.Status == CheckFailed {
+			report.Passed = false
+		}
+	}
+	if report.Checks == nil {
+		report.Checks = []CheckResult{}
+	}
+	return report
+}
+
+func (v *StatusVerifier) record(c CheckResult) {
+	v.checks = append(v.checks, c)
+	target := c.Resource
+	if c.Cluster != "" {
+		target = fmt.Sprintf("%s in cluster %s", target, c.Cluster)
+	}
+	switch c.Status {
+	case CheckPassed:
+		v.logger.LogAndPrintf("%s %s: %s %s", v.successMarker, c.Name, target, c.Message)
+	case CheckWarning:
+		v.logger.LogAndPrintf("! %s: %s %s", c.Name, target, c.Message)
+	default:
+		v.logger.LogAndPrintf("%s %s: %s %s", v.failureMarker, c.Name, target, c.Message)
+	}
+}
+
+// VerifyMesh checks the parts of the mesh that span revisions and clusters: the webhooks of every control plane
+// revision, the remote secrets, the reachability of the east-west gateways and the consistency of the trust bundle
+// across clusters. The results are added to the Report.
+func (v *StatusVerifier) VerifyMesh() error {
+	ctx := context.TODO()
+	local := v.client.Kube()
+	v.verifyRevisionWebhooks(ctx, local)
+
+	clusters := append([]clusterClient{{client: local}}, v.remoteClusters(ctx, local)...)
+	v.verifyEastWestGateways(ctx, clusters)
+	v.verifyTrustBundles(ctx, clusters)
+
+	if !v.Report().Passed {
+		return fmt.Errorf("Istio mesh verification failed") // nolint: golint,stylecheck
+	}
+	return nil
+}
+
+// revisions returns the control plane revisions installed in the Istio namespace, or only the one selected with
+// --revision.
+func (v *StatusVerifier) revisions(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+	if v.controlPlaneOpts.Revision != "" {
+		return []string{v.controlPlaneOpts.Revision}, nil
+	}
+	deployments, err := client.AppsV1().Deployments(v.istioNamespace).List(ctx, meta_v1.ListOptions{LabelSelector: "app=istiod"})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	for _, d := range deployments.Items {
+		rev := d.Labels[label.IoIstioRev.Name]
+		if rev == "" {
+			rev = defaultRevision
+		}
+		seen[rev] = struct{}{}
+	}
+	revs := make([]string, 0, len(seen))
+	for rev := range seen {
+		revs = append(revs, rev)
+	}
+	sort.Strings(revs)
+	return revs, nil
+}
+
+func (v *StatusVerifier) verifyRevisionWebhooks(ctx context.Context, client kubernetes.Interface) {
+	revs, err := v.revisions(ctx, client)
+	if err != nil {
+		v.record(CheckResult{Name: "webhook", Resource: "revisions", Status: CheckFailed, Message: err.Error()})
+		return
+	}
+	for _, rev := range revs {
+		selector := meta_v1.ListOptions{LabelSelector: label.IoIstioRev.Name + "=" + rev}
+		mutating, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, selector)
+		if err != nil {
+			v.record(CheckResult{Name: "webhook", Revision: rev, Resource: "MutatingWebhookConfiguration", Status: CheckFailed, Message: err.Error()})
+			continue
+		}
+		if len(mutating.Items) == 0 {
+			v.record(CheckResult{
+				Name: "webhook", Revision: rev, Resource: "MutatingWebhookConfiguration", Status: CheckFailed,
+				Message: fmt.Sprintf("no sidecar injector webhook found for revision %s", rev),
+			})
+		}
+		for _, wh := range mutating.Items {
+			for _, hook := range wh.Webhooks {
+				v.record(v.webhookResult(ctx, client, rev, "MutatingWebhookConfiguration:"+wh.Name, hook.Name, hook.ClientConfig))
+			}
+		}
+
+		validating, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, selector)
+		if err != nil {
+			v.record(CheckResult{Name: "webhook", Revision: rev, Resource: "ValidatingWebhookConfiguration", Status: CheckFailed, Message: err.Error()})
+			continue
+		}
+		if len(validating.Items) == 0 {
+			v.record(CheckResult{
+				Name: "webhook", Revision: rev, Resource: "ValidatingWebhookConfiguration", Status: CheckWarning,
+				Message: fmt.Sprintf("no validation webhook found for revision %s, config is not validated on write", rev),
+			})
+		}
+		for _, wh := range validating.Items {
+			for _, hook := range wh.Webhooks {
+				v.record(v.webhookResult(ctx, client, rev, "ValidatingWebhookConfiguration:"+wh.Name, hook.Name, hook.ClientConfig))
+			}
+		}
+	}
+}
+
+// webhookResult checks that a webhook has a CA bundle and, when it calls a service in the cluster, that the
+// service has ready endpoints.
+func (v *StatusVerifier) webhookResult(ctx context.Context, client kubernetes.Interface, rev, resource, hook string,
+	cc admit_v1.WebhookClientConfig) CheckResult {
+	res := CheckResult{Name: "webhook", Revision: rev, Resource: resource, Status: CheckFailed}
+	if len(cc.CABundle) == 0 {
+		res.Message = fmt.Sprintf("webhook %s has no caBundle", hook)
+		return res
+	}
+	if cc.Service == nil {
+		if cc.URL == nil {
+			res.Message = fmt.Sprintf("webhook %s has neither a service nor a url", hook)
+			return res
+		}
+		res.Status = CheckPassed
+		res.Message = fmt.Sprintf("webhook %s calls %s", hook, *cc.URL)
+		return res
+	}
+	svc := cc.Service.Namespace + "/" + cc.Service.Name
+	endpoints, err := client.CoreV1().Endpoints(cc.Service.Namespace).Get(ctx, cc.Service.Name, meta_v1.GetOptions{})
+	if err != nil {
+		res.Message = fmt.Sprintf("webhook %s calls service %s: %v", hook, svc, err)
+		return res
+	}
+	if !hasReadyAddresses(endpoints) {
+		res.Message = fmt.Sprintf("webhook %s calls service %s, which has no ready endpoints", hook, svc)
+		return res
+	}
+	res.Status = CheckPassed
+	res.Message = fmt.Sprintf("webhook %s calls service %s", hook, svc)
+	return res
+}
+
+func hasReadyAddresses(endpoints *v1.Endpoints) bool {
+	for _, s := range endpoints.Subsets {
+		if len(s.Addresses) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteClusters returns the clusters of the remote secrets in the Istio namespace that can be reached with the
+// credentials in them.
+func (v *StatusVerifier) remoteClusters(ctx context.Context, client kubernetes.Interface) []clusterClient {
+	secrets, err := client.CoreV1().Secrets(v.istioNamespace).List(ctx, meta_v1.ListOptions{
+		LabelSelector: multicluster.MultiClusterSecretLabel + "=true",
+	})
+	if err != nil {
+		v.record(CheckResult{Name: "remote-secret", Resource: "secrets", Status: CheckFailed, Message: err.Error()})
+		return nil
+	}
+	var out []clusterClient
+	for _, secret := range secrets.Items {
+		ids := make([]string, 0, len(secret.Data))
+		for id := range secret.Data {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			res := CheckResult{Name: "remote-secret", Cluster: id, Resource: "Secret:" + secret.Name, Status: CheckFailed}
+			remote, err := multicluster.BuildClientsFromConfig(secret.Data[id])
+			if err != nil {
+				res.Message = err.Error()
+				v.record(res)
+				continue
+			}
+			version, err := remote.Kube().Discovery().ServerVersion()
+			if err != nil {
+				res.Message = fmt.Sprintf("cannot reach the API server: %v", err)
+				v.record(res)
+				continue
+			}
+			res.Status = CheckPassed
+			res.Message = fmt.Sprintf("reached the API server, version %s", version.GitVersion)
+			v.record(res)
+			out = append(out, clusterClient{id: id, client: remote.Kube()})
+		}
+	}
+	return out
+}
+
+// verifyEastWestGateways checks that the east-west gateways of every cluster expose the cross-network port on an
+// external address, and that the address can be reached from where verify-install runs.
+func (v *StatusVerifier) verifyEastWestGateways(ctx context.Context, clusters []clusterClient) {
+	found := false
+	for _, c := range clusters {
+		services, err := c.client.CoreV1().Services(v.istioNamespace).List(ctx, meta_v1.ListOptions{LabelSelector: eastWestGatewaySelector})
+		if err != nil {
+			v.record(CheckResult{Name: "east-west-gateway", Cluster: c.id, Resource: "services", Status: CheckFailed, Message: err.Error()})
+			continue
+		}
+		for i := range services.Items {
+			found = true
+			v.record(v.eastWestGatewayResult(c.id, &services.Items[i]))
+		}
+	}
+	if !found && len(clusters) > 1 {
+		v.record(CheckResult{
+			Name: "east-west-gateway", Resource: "services", Status: CheckWarning,
+			Message: "no east-west gateway found, the clusters must share a network",
+		})
+	}
+}
+
+func (v *StatusVerifier) eastWestGatewayResult(cluster string, svc *v1.Service) CheckResult {
+	res := CheckResult{Name: "east-west-gateway", Cluster: cluster, Resource: "Service:" + svc.Name, Status: CheckFailed}
+	exposed := false
+	for _, p := range svc.Spec.Ports {
+		if p.Port == crossNetworkPort {
+			exposed = true
+		}
+	}
+	if !exposed {
+		res.Message = fmt.Sprintf("does not expose the cross-network port %d", crossNetworkPort)
+		return res
+	}
+	var address string
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		if ing.IP != "" {
+			address = ing.IP
+		} else if ing.Hostname != "" {
+			address = ing.Hostname
+		}
+	}
+	if address == "" && len(svc.Spec.ExternalIPs) > 0 {
+		address = svc.Spec.ExternalIPs[0]
+	}
+	if address == "" {
+		res.Message = "has no external address"
+		return res
+	}
+	target := net.JoinHostPort(address, strconv.Itoa(crossNetworkPort))
+	conn, err := v.dial("tcp", target, dialTimeout)
+	if err != nil {
+		res.Status = CheckWarning
+		res.Message = fmt.Sprintf("%s is not reachable from this host: %v", target, err)
+		return res
+	}
+	_ = conn.Close()
+	res.Status = CheckPassed
+	res.Message = fmt.Sprintf("%s is reachable", target)
+	return res
+}
+
+// verifyTrustBundles checks that every cluster trusts the same root certificates as the local one. Clusters that
+// share only some roots, as during a root rotation, are reported as a warning.
+func (v *StatusVerifier) verifyTrustBundles(ctx context.Context, clusters []clusterClient) {
+	var localRoots map[string]struct{}
+	for i, c := range clusters {
+		res := CheckResult{Name: "trust-bundle", Cluster: c.id, Resource: "ConfigMap:" + caRootCertConfigMap, Status: CheckFailed}
+		cm, err := c.client.CoreV1().ConfigMaps(v.istioNamespace).Get(ctx, caRootCertConfigMap, meta_v1.GetOptions{})
+		if err != nil {
+			res.Message = err.Error()
+			v.record(res)
+			continue
+		}
+		roots, err := rootFingerprints(cm.Data[constants.CACertNamespaceConfigMapDataName])
+		if err != nil {
+			res.Message = err.Error()
+			v.record(res)
+			continue
+		}
+		if i == 0 {
+			localRoots = roots
+			res.Status = CheckPassed
+			res.Message = fmt.Sprintf("has %d root certificates", len(roots))
+			v.record(res)
+			continue
+		}
+		if localRoots == nil {
+			res.Status = CheckWarning
+			res.Message = "cannot be compared, the local trust bundle is not available"
+			v.record(res)
+			continue
+		}
+		shared := 0
+		for fp := range roots {
+			if _, f := localRoots[fp]; f {
+				shared++
+			}
+		}
+		switch {
+		case shared == 0:
+			res.Message = "shares no root certificate with the local cluster, cross-cluster mTLS will fail"
+		case shared != len(roots) || shared != len(localRoots):
+			res.Status = CheckWarning
+			res.Message = fmt.Sprintf("shares %d of %d root certificates with the local cluster", shared, len(localRoots))
+		default:
+			res.Status = CheckPassed
+			res.Message = "matches the local cluster"
+		}
+		v.record(res)
+	}
+}
+
+// rootFingerprints returns the SHA-256 fingerprints of the certificates in a PEM bundle.
+func rootFingerprints(bundle string) (map[string]struct{}, error) {
+	out := map[string]struct{}{}
+	rest := []byte(bundle)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return nil, fmt.Errorf("invalid root certificate: %v", err)
+		}
+		out[fmt.Sprintf("%x", sha256.Sum256(block.Bytes))] = struct{}{}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no root certificate in %s", constants.CACertNamespaceConfigMapDataName)
+	}
+	return out, nil
+}
+
+// FormatReport renders the report as json or yaml.
+func FormatReport(report Report, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "\n", nil
+	case "yaml":
+		b, err := yaml.Marshal(report)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("unknown output format %q, must be json or yaml", format)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	admit_v1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"istio.io/api/label"
+	"istio.io/istio/operator/pkg/util/clog"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/multicluster"
+)
+
+const testNamespace = "istio-system"
+
+func testRootCert(t *testing.T, cn string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func istiod(rev string) *appsv1.Deployment {
+	name := "istiod"
+	labels := map[string]string{"app": "istiod"}
+	if rev != "" {
+		name += "-" + rev
+		labels[label.IoIstioRev.Name] = rev
+	}
+	return &appsv1.Deployment{ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels}}
+}
+
+func injector(name, rev, svc string, caBundle []byte) *admit_v1.MutatingWebhookConfiguration {
+	return &admit_v1.MutatingWebhookConfiguration{
+		ObjectMeta: meta_v1.ObjectMeta{Name: name, Labels: map[string]string{label.IoIstioRev.Name: rev}},
+		Webhooks: []admit_v1.MutatingWebhook{{
+			Name: "rev.namespace.sidecar-injector.istio.io",
+			ClientConfig: admit_v1.WebhookClientConfig{
+				Service:  &admit_v1.ServiceReference{Namespace: testNamespace, Name: svc},
+				CABundle: caBundle,
+			},
+		}},
+	}
+}
+
+func validator(rev string) *admit_v1.ValidatingWebhookConfiguration {
+	return &admit_v1.ValidatingWebhookConfiguration{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "istio-validator-" + rev, Labels: map[string]string{label.IoIstioRev.Name: rev}},
+		Webhooks: []admit_v1.ValidatingWebhook{{
+			Name:         "rev.validation.istio.io",
+			ClientConfig: admit_v1.WebhookClientConfig{URL: &[]string{"https://istiod.example.com:15017/validate"}[0], CABundle: []byte("ca")},
+		}},
+	}
+}
+
+func readyEndpoints(name string) *v1.Endpoints {
+	return &v1.Endpoints{
+		ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Subsets:    []v1.EndpointSubset{{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}}}},
+	}
+}
+
+func rootCertConfigMap(certs ...string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{Name: caRootCertConfigMap, Namespace: testNamespace},
+		Data:       map[string]string{"root-cert.pem": strings.Join(certs, "")},
+	}
+}
+
+func eastWestGateway(ip string) *v1.Service {
+	svc := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "istio-eastwestgateway", Namespace: testNamespace, Labels: map[string]string{"istio": "eastwestgateway"}},
+		Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "tls", Port: crossNetworkPort}}},
+	}
+	if ip != "" {
+		svc.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: ip}}
+	}
+	return svc
+}
+
+func remoteSecret(clusters ...string) *v1.Secret {
+	s := &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "istio-remote-secret-" + clusters[0],
+			Namespace: testNamespace,
+			Labels:    map[string]string{multicluster.MultiClusterSecretLabel: "true"},
+		},
+		Data: map[string][]byte{},
+	}
+	for _, c := range clusters {
+		s.Data[c] = []byte(c)
+	}
+	return s
+}
+
+func TestVerifyMesh(t *testing.T) {
+	rootA, rootB := testRootCert(t, "root-a"), testRootCert(t, "root-b")
+
+	cases := []struct {
+		name     string
+		revision string
+		local    []runtime.Object
+		remotes  map[string][]runtime.Object
+		passed   bool
+		// want maps "<name> <cluster> <revision> <resource>" to the expected status of the check.
+		want map[string]CheckStatus
+	}{
+		{
+			name: "single cluster with revisions",
+			local: []runtime.Object{
+				istiod(""), istiod("canary"),
+				injector("istio-sidecar-injector", "default", "istiod", []byte("ca")),
+				injector("istio-sidecar-injector-canary", "canary", "istiod-canary", []byte("ca")),
+				validator("default"),
+				readyEndpoints("istiod"), readyEndpoints("istiod-canary"),
+				rootCertConfigMap(rootA),
+			},
+			passed: true,
+			want: map[string]CheckStatus{
+				"webhook  default MutatingWebhookConfiguration:istio-sidecar-injector":       CheckPassed,
+				"webhook  default ValidatingWebhookConfiguration:istio-validator-default":    CheckPassed,
+				"webhook  canary MutatingWebhookConfiguration:istio-sidecar-injector-canary": CheckPassed,
+				"webhook  canary ValidatingWebhookConfiguration":                             CheckWarning,
+				"trust-bundle   ConfigMap:istio-ca-root-cert":                                CheckPassed,
+			},
+		},
+		{
+			name:     "broken webhooks of the selected revision",
+			revision: "canary",
+			local: []runtime.Object{
+				istiod(""), istiod("canary"),
+				injector("istio-sidecar-injector", "default", "istiod", nil),
+				injector("istio-sidecar-injector-canary", "canary", "istiod-canary", nil),
+				injector("istio-revision-tag-prod", "canary", "istiod-canary", []byte("ca")),
+				rootCertConfigMap(rootA),
+			},
+			passed: false,
+			want: map[string]CheckStatus{
+				"webhook  canary MutatingWebhookConfiguration:istio-sidecar-injector-canary": CheckFailed,
+				"webhook  canary MutatingWebhookConfiguration:istio-revision-tag-prod":       CheckFailed,
+				"webhook  canary ValidatingWebhookConfiguration":                             CheckWarning,
+				"trust-bundle   ConfigMap:istio-ca-root-cert":                                CheckPassed,
+			},
+		},
+		{
+			name: "multicluster",
+			local: []runtime.Object{
+				istiod(""),
+				injector("istio-sidecar-injector", "default", "istiod", []byte("ca")),
+				validator("default"),
+				readyEndpoints("istiod"),
+				rootCertConfigMap(rootA, rootB),
+				eastWestGateway("1.1.1.1"),
+				remoteSecret("remote-same", "remote-rotating"),
+				remoteSecret("remote-other"),
+				remoteSecret("remote-broken"),
+			},
+			remotes: map[string][]runtime.Object{
+				"remote-same":     {rootCertConfigMap(rootB, rootA), eastWestGateway("2.2.2.2")},
+				"remote-rotating": {rootCertConfigMap(rootB), eastWestGateway("")},
+				"remote-other":    {rootCertConfigMap(testRootCert(t, "root-c"))},
+			},
+			passed: false,
+			want: map[string]CheckStatus{
+				"webhook  default MutatingWebhookConfiguration:istio-sidecar-injector":    CheckPassed,
+				"webhook  default ValidatingWebhookConfiguration:istio-validator-default": CheckPassed,
+				"remote-secret remote-rotating  Secret:istio-remote-secret-remote-same":   CheckPassed,
+				"remote-secret remote-same  Secret:istio-remote-secret-remote-same":       CheckPassed,
+				"remote-secret remote-other  Secret:istio-remote-secret-remote-other":     CheckPassed,
+				"remote-secret remote-broken  Secret:istio-remote-secret-remote-broken":   CheckFailed,
+				"east-west-gateway   Service:istio-eastwestgateway":                       CheckPassed,
+				"east-west-gateway remote-same  Service:istio-eastwestgateway":            CheckWarning,
+				"east-west-gateway remote-rotating  Service:istio-eastwestgateway":        CheckFailed,
+				"trust-bundle   ConfigMap:istio-ca-root-cert":                             CheckPassed,
+				"trust-bundle remote-same  ConfigMap:istio-ca-root-cert":                  CheckPassed,
+				"trust-bundle remote-rotating  ConfigMap:istio-ca-root-cert":              CheckWarning,
+				"trust-bundle remote-other  ConfigMap:istio-ca-root-cert":                 CheckFailed,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			build := multicluster.BuildClientsFromConfig
+			t.Cleanup(func() { multicluster.BuildClientsFromConfig = build })
+			multicluster.BuildClientsFromConfig = func(kubeConfig []byte) (kube.Client, error) {
+				objects, f := tt.remotes[string(kubeConfig)]
+				if !f {
+					return nil, errors.New("kubeconfig cannot be loaded")
+				}
+				return kube.NewFakeClient(objects...), nil
+			}
+
+			v := &StatusVerifier{
+				istioNamespace: testNamespace,
+				logger:         clog.NewConsoleLogger(io.Discard, io.Discard, nil),
+				client:         kube.NewFakeClient(tt.local...),
+				dial: func(network, address string, timeout time.Duration) (net.Conn, error) {
+					if address != "1.1.1.1:15443" {
+						return nil, errors.New("i/o timeout")
+					}
+					client, server := net.Pipe()
+					_ = server.Close()
+					return client, nil
+				},
+			}
+			v.controlPlaneOpts.Revision = tt.revision
+
+			err := v.VerifyMesh()
+			if (err == nil) != tt.passed {
+				t.Errorf("expected passed %v, got error %v", tt.passed, err)
+			}
+			report := v.Report()
+			if report.Passed != tt.passed {
+				t.Errorf("expected report passed %v, got %v", tt.passed, report.Passed)
+			}
+			got := map[string]CheckStatus{}
+			for _, c := range report.Checks {
+				got[strings.Join([]string{c.Name, c.Cluster, c.Revision, c.Resource}, " ")] = c.Status
+			}
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("expected %q to be %s, got %q", k, want, got[k])
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("expected %d checks, got %v", len(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestFormatReport(t *testing.T) {
+	report := Report{Passed: true, Checks: []CheckResult{{Name: "install", Status: CheckPassed}}}
+	out, err := FormatReport(report, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Passed || len(got.Checks) != 1 || got.Checks[0].Status != CheckPassed {
+		t.Fatalf("unexpected report %+v", got)
+	}
+	out, err = FormatReport(report, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "status: Passed") {
+		t.Fatalf("unexpected yaml report:\n%s", out)
+	}
+	if _, err := FormatReport(report, "table"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
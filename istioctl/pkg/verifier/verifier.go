@@ -17,7 +17,9 @@ package verifier
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	admit_v1 "k8s.io/api/admissionregistration/v1"
@@ -63,6 +65,8 @@ type StatusVerifier struct {
 	successMarker    string
 	failureMarker    string
 	client           kube.ExtendedClient
+	checks           []CheckResult
+	dial             func(network, address string, timeout time.Duration) (net.Conn, error)
 }
 
 type StatusVerifierOptions func(*StatusVerifier)
@@ -98,6 +102,7 @@ func NewStatusVerifier(istioNamespace, manifestsPath, kubeconfig, context string
 		filenames:        filenames,
 		controlPlaneOpts: controlPlaneOpts,
 		client:           client,
+		dial:             net.DialTimeout,
 	}
 
 	for _, opt := range options {
@@ -429,6 +434,19 @@ func (v *StatusVerifier) operatorFromCluster(revision string) (*v1alpha1.IstioOp
 func (v *StatusVerifier) reportStatus(crdCount, istioDeploymentCount int, err error) error {
 	v.logger.LogAndPrintf("Checked %v custom resource definitions", crdCount)
 	v.logger.LogAndPrintf("Checked %v Istio Deployments", istioDeploymentCount)
+	install := CheckResult{
+		Name:     "install",
+		Revision: v.controlPlaneOpts.Revision,
+		Status:   CheckPassed,
+		Message:  fmt.Sprintf("%d custom resource definitions, %d Istio Deployments", crdCount, istioDeploymentCount),
+	}
+	if istioDeploymentCount == 0 || err != nil {
+		install.Status = CheckFailed
+		if err != nil {
+			install.Message = err.Error()
+		}
+	}
+	v.checks = append(v.checks, install)
 	if istioDeploymentCount == 0 {
 		if err != nil {
 			v.logger.LogAndPrintf("! No Istio installation found: %v", err)
@@ -0,0 +1,184 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	fileaccesslog "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	grpcaccesslog "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/grpc/v3"
+	otelaccesslog "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/open_telemetry/v3"
+	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"sigs.k8s.io/yaml"
+
+	protio "istio.io/istio/istioctl/pkg/util/proto"
+)
+
+// Scopes of the access logs of a listener.
+const (
+	accessLogScopeListener = "listener"
+	accessLogScopeHTTP     = "http"
+	accessLogScopeTCP      = "tcp"
+)
+
+// listenerAccessLog is an access log configured on a listener, either on the listener itself or on the
+// HTTP connection manager or TCP proxy of its filter chains.
+type listenerAccessLog struct {
+	Listener  string          `json:"listener"`
+	Scope     string          `json:"scope"`
+	AccessLog json.RawMessage `json:"accessLog"`
+
+	log *accesslog.AccessLog
+}
+
+// PrintAccessLogSummary prints a summary of the access logs of the relevant listeners to the ConfigWriter stdout
+func (c *ConfigWriter) PrintAccessLogSummary(filter ListenerFilter) error {
+	logs, err := c.retrieveListenerAccessLogs(filter)
+	if err != nil {
+		return err
+	}
+	w := new(tabwriter.Writer).Init(c.Stdout, 0, 8, 5, ' ', 0)
+	fmt.Fprintln(w, "LISTENER\tSCOPE\tNAME\tDESTINATION\tFORMAT")
+	for _, l := range logs {
+		destination, format := describeAccessLog(l.log)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", l.Listener, l.Scope, l.log.GetName(), destination, format)
+	}
+	return w.Flush()
+}
+
+// PrintAccessLogDump prints the access logs of the relevant listeners to the ConfigWriter stdout
+func (c *ConfigWriter) PrintAccessLogDump(filter ListenerFilter, outputFormat string) error {
+	logs, err := c.retrieveListenerAccessLogs(filter)
+	if err != nil {
+		return err
+	}
+	for _, l := range logs {
+		if l.AccessLog, err = json.Marshal(protio.MessageSlice{l.log}); err != nil {
+			return fmt.Errorf("failed to marshal access log: %v", err)
+		}
+		// MessageSlice marshals a list, unwrap the single access log.
+		l.AccessLog = l.AccessLog[1 : len(l.AccessLog)-1]
+	}
+	out, err := json.MarshalIndent(logs, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal access logs: %v", err)
+	}
+	if outputFormat == "yaml" {
+		if out, err = yaml.JSONToYAML(out); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(c.Stdout, string(out))
+	return nil
+}
+
+// retrieveListenerAccessLogs returns the access logs of the listeners matching the filter. The filter chains of
+// a listener usually share the same access logs, which are only returned once per listener.
+func (c *ConfigWriter) retrieveListenerAccessLogs(filter ListenerFilter) ([]*listenerAccessLog, error) {
+	listeners, err := c.retrieveSortedListenerSlice()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*listenerAccessLog, 0)
+	for _, l := range listeners {
+		if !filter.Verify(l) {
+			continue
+		}
+		seen := map[string]struct{}{}
+		add := func(scope string, logs []*accesslog.AccessLog) {
+			for _, al := range logs {
+				key := scope + "/" + al.String()
+				if _, f := seen[key]; f {
+					continue
+				}
+				seen[key] = struct{}{}
+				out = append(out, &listenerAccessLog{Listener: l.Name, Scope: scope, log: al})
+			}
+		}
+		add(accessLogScopeListener, l.AccessLog)
+		for _, fc := range getFilterChains(l) {
+			scope, logs, err := filterChainAccessLogs(fc)
+			if err != nil {
+				return nil, err
+			}
+			add(scope, logs)
+		}
+	}
+	return out, nil
+}
+
+func filterChainAccessLogs(fc *listener.FilterChain) (string, []*accesslog.AccessLog, error) {
+	for _, filter := range fc.GetFilters() {
+		switch filter.Name {
+		case HTTPListener:
+			httpProxy := &httpConn.HttpConnectionManager{}
+			// Allow Unmarshal to work even if Envoy and istioctl are different
+			filter.GetTypedConfig().TypeUrl = "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager"
+			if err := filter.GetTypedConfig().UnmarshalTo(httpProxy); err != nil {
+				return "", nil, err
+			}
+			return accessLogScopeHTTP, httpProxy.GetAccessLog(), nil
+		case TCPListener:
+			tcpProxy := &tcp.TcpProxy{}
+			// Allow Unmarshal to work even if Envoy and istioctl are different
+			filter.GetTypedConfig().TypeUrl = "type.googleapis.com/envoy.extensions.filters.network.tcp_proxy.v3.TcpProxy"
+			if err := filter.GetTypedConfig().UnmarshalTo(tcpProxy); err != nil {
+				return "", nil, err
+			}
+			return accessLogScopeTCP, tcpProxy.GetAccessLog(), nil
+		}
+	}
+	return "", nil, nil
+}
+
+// describeAccessLog returns where an access log is written to and its format.
+func describeAccessLog(al *accesslog.AccessLog) (destination, format string) {
+	tc := al.GetTypedConfig()
+	file := &fileaccesslog.FileAccessLog{}
+	httpGrpc := &grpcaccesslog.HttpGrpcAccessLogConfig{}
+	tcpGrpc := &grpcaccesslog.TcpGrpcAccessLogConfig{}
+	otel := &otelaccesslog.OpenTelemetryAccessLogConfig{}
+	switch {
+	case tc.MessageIs(file) && tc.UnmarshalTo(file) == nil:
+		format = "default"
+		switch {
+		case file.GetLogFormat().GetJsonFormat() != nil || file.GetJsonFormat() != nil:
+			format = "json"
+		case file.GetLogFormat() != nil || file.GetFormat() != "":
+			format = "text"
+		}
+		return file.GetPath(), format
+	case tc.MessageIs(httpGrpc) && tc.UnmarshalTo(httpGrpc) == nil:
+		return describeGrpcService(httpGrpc.GetCommonConfig().GetGrpcService()), "grpc"
+	case tc.MessageIs(tcpGrpc) && tc.UnmarshalTo(tcpGrpc) == nil:
+		return describeGrpcService(tcpGrpc.GetCommonConfig().GetGrpcService()), "grpc"
+	case tc.MessageIs(otel) && tc.UnmarshalTo(otel) == nil:
+		return describeGrpcService(otel.GetCommonConfig().GetGrpcService()), "otlp"
+	}
+	return "", describeExtensionType(tc.GetTypeUrl())
+}
+
+func describeGrpcService(svc *core.GrpcService) string {
+	if cluster := svc.GetEnvoyGrpc().GetClusterName(); cluster != "" {
+		return cluster
+	}
+	return svc.GetGoogleGrpc().GetTargetUri()
+}
@@ -0,0 +1,55 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"istio.io/istio/pilot/test/util"
+)
+
+func TestConfigWriter_PrintAccessLogSummary(t *testing.T) {
+	tests := []struct {
+		name           string
+		filter         ListenerFilter
+		wantOutputFile string
+	}{
+		{
+			name:           "returns expected access log summary onto Stdout",
+			wantOutputFile: "testdata/accesslogsummary.txt",
+		},
+		{
+			name:           "filters listeners",
+			filter:         ListenerFilter{Port: 9090},
+			wantOutputFile: "testdata/accesslogsummary_empty.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOut := &bytes.Buffer{}
+			cw := &ConfigWriter{Stdout: gotOut}
+			cd, _ := os.ReadFile("testdata/extensions_configdump.json")
+			if err := cw.Prime(cd); err != nil {
+				t.Fatal(err)
+			}
+			if err := cw.PrintAccessLogSummary(tt.filter); err != nil {
+				t.Fatal(err)
+			}
+			util.CompareContent(t, gotOut.Bytes(), tt.wantOutputFile)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	protio "istio.io/istio/istioctl/pkg/util/proto"
+)
+
+// PrintEcdsSummary prints a summary of the extension configs received over ECDS to the ConfigWriter stdout
+func (c *ConfigWriter) PrintEcdsSummary() error {
+	filters, err := c.retrieveSortedEcdsSlice()
+	if err != nil {
+		return err
+	}
+	w := new(tabwriter.Writer).Init(c.Stdout, 0, 8, 5, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tSOURCE\tVERSION")
+	for _, f := range filters {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			f.Config.GetName(),
+			describeExtensionType(f.Config.GetTypedConfig().GetTypeUrl()),
+			describeExtensionSource(f.Config),
+			f.VersionInfo)
+	}
+	return w.Flush()
+}
+
+// PrintEcdsDump prints the extension configs received over ECDS to the ConfigWriter stdout
+func (c *ConfigWriter) PrintEcdsDump(outputFormat string) error {
+	filters, err := c.retrieveSortedEcdsSlice()
+	if err != nil {
+		return err
+	}
+	configs := make(protio.MessageSlice, 0, len(filters))
+	for _, f := range filters {
+		configs = append(configs, f.Config)
+	}
+	out, err := json.MarshalIndent(configs, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal extension configs: %v", err)
+	}
+	if outputFormat == "yaml" {
+		if out, err = yaml.JSONToYAML(out); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(c.Stdout, string(out))
+	return nil
+}
+
+func (c *ConfigWriter) retrieveSortedEcdsSlice() ([]*configdump.EcdsFilter, error) {
+	if c.configDump == nil {
+		return nil, fmt.Errorf("config writer has not been primed")
+	}
+	filters, err := c.configDump.GetEcdsConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("no extension configs found")
+	}
+	sort.Slice(filters, func(i, j int) bool {
+		return filters[i].Config.GetName() < filters[j].Config.GetName()
+	})
+	return filters, nil
+}
+
+// describeExtensionType returns the message name of an extension type URL.
+func describeExtensionType(typeURL string) string {
+	return typeURL[strings.LastIndex(typeURL, "/")+1:]
+}
+
+// describeExtensionSource returns where the code of a Wasm extension is loaded from, or "" for other extensions.
+func describeExtensionSource(cfg *core.TypedExtensionConfig) string {
+	w := &wasm.Wasm{}
+	if cfg.GetTypedConfig().MessageIs(w) && cfg.GetTypedConfig().UnmarshalTo(w) == nil {
+		code := w.GetConfig().GetVmConfig().GetCode()
+		if uri := code.GetRemote().GetHttpUri().GetUri(); uri != "" {
+			return uri
+		}
+		return code.GetLocal().GetFilename()
+	}
+	return ""
+}
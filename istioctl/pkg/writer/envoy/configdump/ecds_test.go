@@ -0,0 +1,62 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"istio.io/istio/pilot/test/util"
+)
+
+func TestConfigWriter_PrintEcdsSummary(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputFile      string
+		wantOutputFile string
+		wantErr        bool
+	}{
+		{
+			name:           "returns expected extension config summary onto Stdout",
+			inputFile:      "testdata/extensions_configdump.json",
+			wantOutputFile: "testdata/ecdssummary.txt",
+		},
+		{
+			name:      "errors if config dump has no ecds",
+			inputFile: "testdata/configdump.json",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOut := &bytes.Buffer{}
+			cw := &ConfigWriter{Stdout: gotOut}
+			cd, _ := os.ReadFile(tt.inputFile)
+			if err := cw.Prime(cd); err != nil {
+				t.Fatal(err)
+			}
+			err := cw.PrintEcdsSummary()
+			if tt.wantOutputFile != "" {
+				util.CompareContent(t, gotOut.Bytes(), tt.wantOutputFile)
+			}
+			if err == nil && tt.wantErr {
+				t.Errorf("PrintEcdsSummary (%v) did not produce expected err", tt.name)
+			} else if err != nil && !tt.wantErr {
+				t.Errorf("PrintEcdsSummary (%v) produced unexpected err: %v", tt.name, err)
+			}
+		})
+	}
+}
@@ -112,10 +112,10 @@ func (s *StatusWriter) setupStatusPrint(statuses map[string][]byte) (*tabwriter.
 }
 
 func statusPrintln(w io.Writer, status *writerStatus) error {
-	clusterSynced := xdsStatus(status.ClusterSent, status.ClusterAcked)
-	listenerSynced := xdsStatus(status.ListenerSent, status.ListenerAcked)
-	routeSynced := xdsStatus(status.RouteSent, status.RouteAcked)
-	endpointSynced := xdsStatus(status.EndpointSent, status.EndpointAcked)
+	clusterSynced := XdsStatus(status.ClusterSent, status.ClusterAcked)
+	listenerSynced := XdsStatus(status.ListenerSent, status.ListenerAcked)
+	routeSynced := XdsStatus(status.RouteSent, status.RouteAcked)
+	endpointSynced := XdsStatus(status.EndpointSent, status.EndpointAcked)
 	version := status.IstioVersion
 	if version == "" {
 		// If we can't find an Istio version (talking to a 1.1 pilot), fallback to the proxy version
@@ -128,7 +128,8 @@ func statusPrintln(w io.Writer, status *writerStatus) error {
 	return nil
 }
 
-func xdsStatus(sent, acked string) string {
+// XdsStatus returns the sync status of a type from the nonces sent to and acked by the proxy.
+func XdsStatus(sent, acked string) string {
 	if sent == "" {
 		return "NOT SENT"
 	}
@@ -0,0 +1,81 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/pkg/log"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// applyCgroupConcurrency replaces a concurrency of 0, which Envoy treats as all the cores of the node, with the
+// CPU limit of the container, if it has one.
+func applyCgroupConcurrency(proxyConfig *meshconfig.ProxyConfig, root string, headroom float64) {
+	if proxyConfig.Concurrency == nil || proxyConfig.Concurrency.Value != 0 {
+		return
+	}
+	cpu, ok := cgroupCPULimit(root)
+	if !ok {
+		return
+	}
+	// Round to the milli CPU, as the injector does for the CPU resources.
+	concurrency := mesh.MilliCPUToConcurrency(int64(math.Round(cpu*1000)), headroom)
+	log.Infof("Setting concurrency to %d from the container CPU limit %v", concurrency, cpu)
+	proxyConfig.Concurrency = &types.Int32Value{Value: int32(concurrency)}
+}
+
+// cgroupCPULimit returns the CPU limit of the container in cores, read from cgroup v2 or, failing that, v1.
+func cgroupCPULimit(root string) (float64, bool) {
+	// cgroup v2: "<quota> <period>", where the quota is "max" if unlimited.
+	if b, err := os.ReadFile(filepath.Join(root, "cpu.max")); err == nil {
+		fields := strings.Fields(string(b))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0, false
+		}
+		return cpuQuota(fields[0], fields[1])
+	}
+	// cgroup v1: the quota is -1 if unlimited.
+	quota, err := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+	if err != nil {
+		return 0, false
+	}
+	period, err := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+	if err != nil {
+		return 0, false
+	}
+	return cpuQuota(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+func cpuQuota(quota, period string) (float64, bool) {
+	q, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseInt(period, 10, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return float64(q) / float64(p), true
+}
@@ -0,0 +1,109 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+)
+
+func TestApplyCgroupConcurrency(t *testing.T) {
+	cases := []struct {
+		name        string
+		files       map[string]string
+		concurrency *types.Int32Value
+		headroom    float64
+		expect      *types.Int32Value
+	}{
+		{
+			name:        "cgroup v2",
+			files:       map[string]string{"cpu.max": "150000 100000\n"},
+			concurrency: &types.Int32Value{Value: 0},
+			headroom:    1,
+			expect:      &types.Int32Value{Value: 2},
+		},
+		{
+			name:        "cgroup v2 fractional",
+			files:       map[string]string{"cpu.max": "50000 100000\n"},
+			concurrency: &types.Int32Value{Value: 0},
+			headroom:    1,
+			expect:      &types.Int32Value{Value: 1},
+		},
+		{
+			name:        "cgroup v2 with headroom",
+			files:       map[string]string{"cpu.max": "400000 100000\n"},
+			concurrency: &types.Int32Value{Value: 0},
+			headroom:    0.75,
+			expect:      &types.Int32Value{Value: 3},
+		},
+		{
+			name:        "cgroup v2 unlimited",
+			files:       map[string]string{"cpu.max": "max 100000\n"},
+			concurrency: &types.Int32Value{Value: 0},
+			headroom:    1,
+			expect:      &types.Int32Value{Value: 0},
+		},
+		{
+			name:        "cgroup v1",
+			files:       map[string]string{"cpu/cpu.cfs_quota_us": "300000\n", "cpu/cpu.cfs_period_us": "100000\n"},
+			concurrency: &types.Int32Value{Value: 0},
+			headroom:    1,
+			expect:      &types.Int32Value{Value: 3},
+		},
+		{
+			name:        "cgroup v1 unlimited",
+			files:       map[string]string{"cpu/cpu.cfs_quota_us": "-1\n", "cpu/cpu.cfs_period_us": "100000\n"},
+			concurrency: &types.Int32Value{Value: 0},
+			headroom:    1,
+			expect:      &types.Int32Value{Value: 0},
+		},
+		{
+			name:        "explicit concurrency",
+			files:       map[string]string{"cpu.max": "150000 100000\n"},
+			concurrency: &types.Int32Value{Value: 4},
+			headroom:    1,
+			expect:      &types.Int32Value{Value: 4},
+		},
+		{
+			name:     "unset concurrency",
+			files:    map[string]string{"cpu.max": "150000 100000\n"},
+			headroom: 1,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, contents := range tt.files {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			pc := &meshconfig.ProxyConfig{Concurrency: tt.concurrency}
+			applyCgroupConcurrency(pc, root, tt.headroom)
+			if !pc.Concurrency.Equal(tt.expect) {
+				t.Fatalf("got concurrency %v, expected %v", pc.Concurrency, tt.expect)
+			}
+		})
+	}
+}
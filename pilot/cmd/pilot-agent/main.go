@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
@@ -149,6 +150,12 @@ var (
 
 			go iptableslog.ReadNFLOGSocket(ctx)
 
+			if options.IptablesReconcileInterval > 0 {
+				if err := initIptablesReconcile(ctx, options.IptablesReconcileInterval); err != nil {
+					return err
+				}
+			}
+
 			// On SIGINT or SIGTERM, cancel the context, triggering a graceful shutdown
 			go cmd.WaitSignalFunc(cancel)
 
@@ -219,6 +226,17 @@ func initStatusServer(ctx context.Context, proxy *model.Proxy, proxyConfig *mesh
 	return nil
 }
 
+// initIptablesReconcile repairs drift of the iptables rules of the proxy every interval, until ctx is done.
+func initIptablesReconcile(ctx context.Context, interval time.Duration) error {
+	cfg, err := iptables.ConstructConfig()
+	if err != nil {
+		return fmt.Errorf("invalid iptables configuration: %v", err)
+	}
+	log.Infof("Reconciling the iptables rules every %v", interval)
+	go iptables.NewReconciler(cfg).ReconcileLoop(interval, ctx.Done())
+	return nil
+}
+
 func initStsServer(proxy *model.Proxy, tokenManager security.TokenManager) (*stsserver.Server, error) {
 	localHostAddr := localHostIPv4
 	if network.IsIPv6Proxy(proxy.IPAddresses) {
@@ -32,6 +32,13 @@ var (
 		"The time from the process starting to being marked ready.",
 	)
 
+	// endpointDrainTime measures the time it takes for the proxies sending traffic to the workload to
+	// ack its endpoints as draining, when drained before stopping.
+	endpointDrainTime = monitoring.NewGauge(
+		"endpoint_drain_duration_seconds",
+		"The time from requesting the endpoint drain to istiod reporting it acked by all proxies.",
+	)
+
 	// scrapeErrors records total number of failed scrapes.
 	scrapeErrors = monitoring.NewSum(
 		"scrape_failures_total",
@@ -63,10 +70,15 @@ func RecordStartupTime() {
 	log.Infof("Readiness succeeded in %v", delta)
 }
 
+func RecordEndpointDrainTime(delta time.Duration) {
+	endpointDrainTime.Record(delta.Seconds())
+}
+
 func init() {
 	monitoring.MustRegister(
 		ScrapeTotals,
 		scrapeErrors,
 		startupTime,
+		endpointDrainTime,
 	)
 }
@@ -91,6 +91,13 @@ var (
 	DNSCaptureByAgent = env.RegisterBoolVar("ISTIO_META_DNS_CAPTURE", false,
 		"If set to true, enable the capture of outgoing DNS packets on port 53, redirecting to istio-agent on :15053")
 
+	// IptablesReconcileInterval is the interval at which the agent repairs drift of the iptables rules of the proxy.
+	IptablesReconcileInterval = env.RegisterDurationVar("ISTIO_IPTABLES_RECONCILE_INTERVAL", 0,
+		"If set, the agent checks the iptables rules of the proxy at this interval and repairs them if they drifted, "+
+			"e.g. because a node agent removed some of them. The rules are configured like in pilot-agent istio-iptables, "+
+			"and repairing them requires the NET_ADMIN capability, as on VMs where the agent applies the rules. "+
+			"Pods using the Istio CNI plugin are reconciled by the CNI node agent instead.").Get()
+
 	// DNSCaptureAddr is the address to listen.
 	DNSCaptureAddr = env.RegisterStringVar("DNS_PROXY_ADDR", "localhost:15053",
 		"Custom address for the DNS proxy. If it ends with :53 and running as root allows running without iptable DNS capture")
@@ -102,6 +109,11 @@ var (
 	wasmInsecureRegistries = env.RegisterStringVar("WASM_INSECURE_REGISTRIES", "",
 		"allow agent pull wasm plugin from insecure registries, for example: 'localhost:5000,docker-registry:5000'").Get()
 
+	wasmPinModuleChecksums = env.RegisterBoolVar("WASM_PIN_MODULE_CHECKSUMS", false,
+		"If enabled, a Wasm module fetched without sha256 checksum is pinned to the checksum of its first download, "+
+			"and served from the local cache instead of being fetched again on every config update. Modules configured "+
+			"with a sha256 checksum are always verified, and rejected on mismatch even if the plugin fails open").Get()
+
 	// Ability of istio-agent to retrieve bootstrap via XDS
 	enableBootstrapXdsEnv = env.RegisterBoolVar("BOOTSTRAP_XDS_AGENT", false,
 		"If set to true, agent retrieves the bootstrap configuration prior to starting Envoy").Get()
@@ -35,5 +35,6 @@ func NewStatusServerOptions(proxy *model.Proxy, proxyConfig *meshconfig.ProxyCon
 		NoEnvoy:        agent.EnvoyDisabled(),
 		FetchDNS:       agent.GetDNSTable,
 		GRPCBootstrap:  agent.GRPCBootstrapPath(),
+		DrainEndpoint:  agent.DrainEndpoint,
 	}
 }
@@ -40,6 +40,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/common/expfmt"
 	"go.opencensus.io/stats/view"
+	"go.uber.org/atomic"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
@@ -53,6 +54,7 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config"
 	dnsProto "istio.io/istio/pkg/dns/proto"
+	"istio.io/istio/pkg/envoy"
 	"istio.io/istio/pkg/kube/apimirror"
 	"istio.io/pkg/env"
 	"istio.io/pkg/log"
@@ -63,6 +65,8 @@ const (
 	readyPath = "/healthz/ready"
 	// quitPath is to notify the pilot agent to quit.
 	quitPath = "/quitquitquit"
+	// drainPath is to drain the workload before it is stopped, typically from a preStop hook.
+	drainPath = "/drain"
 	// KubeAppProberEnvName is the name of the command line flag for pilot agent to pass app prober config.
 	// The json encoded string to pass app HTTP probe information from injector(istioctl or webhook).
 	// For example, ISTIO_KUBE_APP_PROBERS='{"/app-health/httpbin/livez":{"httpGet":{"path": "/hello", "port": 8080}}.
@@ -92,6 +96,10 @@ var (
 	ProbeKeepaliveConnections = env.RegisterBoolVar("ENABLE_PROBE_KEEPALIVE_CONNECTIONS", false,
 		"If enabled, readiness probes will keep the connection from pilot-agent to the application alive. "+
 			"This mirrors older Istio versions' behaviors, but not kubelet's.").Get()
+
+	EndpointDrainTimeout = env.RegisterDurationVar("ENDPOINT_DRAIN_TIMEOUT", 10*time.Second,
+		"The maximum time the drain endpoint waits for the proxies sending traffic to the workload to ack its endpoints "+
+			"as draining, before draining Envoy anyway. Can be overridden with the timeout query parameter.").Get()
 )
 
 // KubeAppProbers holds the information about a Kubernetes pod prober.
@@ -125,6 +133,8 @@ type Options struct {
 	FetchDNS            func() *dnsProto.NameTable
 	NoEnvoy             bool
 	GRPCBootstrap       string
+	// DrainEndpoint asks istiod to report the endpoints of the proxy as draining, and waits until the change is acked.
+	DrainEndpoint func(ctx context.Context) (*model.EndpointDrainStatus, error)
 }
 
 // Server provides an endpoint for handling status probes.
@@ -140,6 +150,11 @@ type Server struct {
 	envoyStatsPort        int
 	fetchDNS              func() *dnsProto.NameTable
 	upstreamLocalAddress  *net.TCPAddr
+	envoyAdminPort        uint16
+	noEnvoy               bool
+	drainEndpoint         func(ctx context.Context) (*model.EndpointDrainStatus, error)
+	// draining fails the readiness probe once a drain is requested.
+	draining atomic.Bool
 }
 
 func init() {
@@ -187,6 +202,9 @@ func NewServer(config Options) (*Server, error) {
 		envoyStatsPort:        config.EnvoyPrometheusPort,
 		fetchDNS:              config.FetchDNS,
 		upstreamLocalAddress:  upstreamLocalAddress,
+		envoyAdminPort:        config.AdminPort,
+		noEnvoy:               config.NoEnvoy,
+		drainEndpoint:         config.DrainEndpoint,
 	}
 	if LegacyLocalhostProbeDestination.Get() {
 		s.appProbersDestination = "localhost"
@@ -324,6 +342,7 @@ func (s *Server) Run(ctx context.Context) {
 	mux.HandleFunc(readyPath, s.handleReadyProbe)
 	mux.HandleFunc(`/stats/prometheus`, s.handleStats)
 	mux.HandleFunc(quitPath, s.handleQuit)
+	mux.HandleFunc(drainPath, s.handleDrain)
 	mux.HandleFunc("/app-health/", s.handleAppProbe)
 
 	// Add the handler for pprof.
@@ -434,6 +453,9 @@ func (s *Server) handleReadyProbe(w http.ResponseWriter, _ *http.Request) {
 }
 
 func (s *Server) isReady() error {
+	if s.draining.Load() {
+		return errors.New("proxy is draining")
+	}
 	for _, p := range s.ready {
 		if err := p.Check(); err != nil {
 			return err
@@ -620,6 +642,75 @@ func (s *Server) handleQuit(w http.ResponseWriter, r *http.Request) {
 	notifyExit()
 }
 
+// DrainResult reports the steps of a drain, so rollouts can measure them.
+type DrainResult struct {
+	// Propagation is the time the proxies sending traffic to the workload took to ack its endpoints as draining.
+	Propagation string `json:"propagation,omitempty"`
+	// Pending are the proxies that had not acked the draining endpoints when the drain stopped waiting.
+	Pending []string `json:"pending,omitempty"`
+	// PropagationError is set when the drain stopped waiting before the draining endpoints were acked.
+	PropagationError string `json:"propagationError,omitempty"`
+	// Total is the time the whole drain took.
+	Total string `json:"total"`
+}
+
+// handleDrain drains the workload before it is stopped: it fails the readiness probe, asks istiod to report the
+// endpoints of the proxy as draining and waits, up to a timeout, until the proxies sending it traffic have acked
+// the change. Only then it gracefully drains the inbound listeners of Envoy, so no new request is dropped.
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if !isRequestFromLocalhost(r) {
+		http.Error(w, "Only requests from localhost are allowed", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	timeout := EndpointDrainTimeout
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil {
+			http.Error(w, fmt.Sprintf("invalid timeout %q: %v", t, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	start := time.Now()
+	log.Infof("handling %s, failing readiness and draining the endpoints", drainPath)
+	s.draining.Store(true)
+	result := DrainResult{}
+	if s.drainEndpoint != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		status, err := s.drainEndpoint(ctx)
+		cancel()
+		if status != nil {
+			result.Pending = status.Pending
+		}
+		if err != nil {
+			result.PropagationError = err.Error()
+			log.Warnf("endpoint drain did not complete in %v: %v, %d proxies pending", timeout, err, len(result.Pending))
+		} else {
+			propagation := time.Since(start)
+			result.Propagation = propagation.String()
+			metrics.RecordEndpointDrainTime(propagation)
+			log.Infof("endpoint drain acked by all proxies in %v", propagation)
+		}
+	}
+	if !s.noEnvoy {
+		if err := envoy.DrainListeners(uint32(s.envoyAdminPort), true); err != nil {
+			log.Warnf("failed to drain Envoy listeners: %v", err)
+		}
+	}
+	result.Total = time.Since(start).String()
+	b, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
 func (s *Server) handleAppProbe(w http.ResponseWriter, req *http.Request) {
 	// Validate the request first.
 	path := req.URL.Path
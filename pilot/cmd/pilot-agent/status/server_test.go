@@ -42,6 +42,7 @@ import (
 
 	"istio.io/istio/pilot/cmd/pilot-agent/status/ready"
 	"istio.io/istio/pilot/cmd/pilot-agent/status/testserver"
+	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/kube/apimirror"
 	"istio.io/istio/pkg/test/env"
 	"istio.io/istio/pkg/test/util/retry"
@@ -1320,6 +1321,103 @@ func TestHandleQuit(t *testing.T) {
 	}
 }
 
+func TestHandleDrain(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		query         string
+		remoteAddr    string
+		drainEndpoint func(ctx context.Context) (*model.EndpointDrainStatus, error)
+		expected      int
+		expectedBody  DrainResult
+	}{
+		{
+			name:       "should drain the endpoint",
+			method:     "POST",
+			remoteAddr: "127.0.0.1",
+			drainEndpoint: func(ctx context.Context) (*model.EndpointDrainStatus, error) {
+				return &model.EndpointDrainStatus{}, nil
+			},
+			expected: http.StatusOK,
+		},
+		{
+			name:       "should stop waiting after the timeout",
+			method:     "POST",
+			query:      "?timeout=10ms",
+			remoteAddr: "127.0.0.1",
+			drainEndpoint: func(ctx context.Context) (*model.EndpointDrainStatus, error) {
+				<-ctx.Done()
+				return &model.EndpointDrainStatus{Pending: []string{"a.default"}}, ctx.Err()
+			},
+			expected: http.StatusOK,
+			expectedBody: DrainResult{
+				Pending:          []string{"a.default"},
+				PropagationError: context.DeadlineExceeded.Error(),
+			},
+		},
+		{
+			name:       "should reject an invalid timeout",
+			method:     "POST",
+			query:      "?timeout=soon",
+			remoteAddr: "127.0.0.1",
+			expected:   http.StatusBadRequest,
+		},
+		{
+			name:       "should require POST method",
+			method:     "GET",
+			remoteAddr: "127.0.0.1",
+			expected:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "should require localhost",
+			method:   "POST",
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewServer(Options{StatusPort: 15020, NoEnvoy: true, DrainEndpoint: tt.drainEndpoint})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest(tt.method, "/drain"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr + ":15020"
+			}
+
+			resp := httptest.NewRecorder()
+			s.handleDrain(resp, req)
+			if resp.Code != tt.expected {
+				t.Fatalf("Expected response code %v got %v", tt.expected, resp.Code)
+			}
+			if tt.expected != http.StatusOK {
+				if s.isReady() != nil {
+					t.Fatalf("Expected the proxy to stay ready")
+				}
+				return
+			}
+			if s.isReady() == nil {
+				t.Fatalf("Expected the proxy to fail readiness once draining")
+			}
+			got := DrainResult{}
+			if err := json.Unmarshal(resp.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.PropagationError == "" && got.Propagation == "" {
+				t.Fatalf("Expected the propagation time to be reported, got %+v", got)
+			}
+			got.Propagation, got.Total = "", ""
+			if !reflect.DeepEqual(got, tt.expectedBody) {
+				t.Fatalf("Expected %+v got %+v", tt.expectedBody, got)
+			}
+		})
+	}
+}
+
 func TestAdditionalProbes(t *testing.T) {
 	rp := readyProbe{}
 	urp := unreadyProbe{}
@@ -15,12 +15,15 @@
 package bootstrap
 
 import (
+	"fmt"
 	"strings"
 
 	gogoproto "github.com/gogo/protobuf/proto"
 	"google.golang.org/protobuf/proto"
 
+	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 )
 
 // needsPush checks whether the passed in config has same spec and hence push needs
@@ -58,3 +61,50 @@ func needsPush(prev config.Config, curr config.Config) bool {
 	}
 	return true
 }
+
+// destinationRuleListenerFields are the fields of a DestinationRule read by the sidecar listeners.
+type destinationRuleListenerFields struct {
+	host           string
+	exportTo       string
+	protocolFilter string
+	redisOpTimeout string
+	// sourceIPHash is the source IP consistent hash of the traffic policy, then of each subset with a load balancer.
+	sourceIPHash string
+}
+
+// listenerFieldsOf returns the fields of the DestinationRule read by the sidecar listeners, or nil if it sets none of
+// them, in which case the listeners are built as without the DestinationRule.
+func listenerFieldsOf(c config.Config) *destinationRuleListenerFields {
+	dr, _ := c.Spec.(*networking.DestinationRule)
+	hash := []string{fmt.Sprint(dr.GetTrafficPolicy().GetLoadBalancer().GetConsistentHash().GetUseSourceIp())}
+	sourceIP := hash[0] == "true"
+	for _, subset := range dr.GetSubsets() {
+		if lb := subset.GetTrafficPolicy().GetLoadBalancer(); lb != nil {
+			useSourceIP := lb.GetConsistentHash().GetUseSourceIp()
+			sourceIP = sourceIP || useSourceIP
+			hash = append(hash, subset.Name+"="+fmt.Sprint(useSourceIP))
+		}
+	}
+	protocolFilter := c.Annotations[constants.ProtocolFilterAnnotation]
+	redisOpTimeout := c.Annotations[constants.RedisOpTimeoutAnnotation]
+	if !sourceIP && protocolFilter == "" && redisOpTimeout == "" {
+		return nil
+	}
+	return &destinationRuleListenerFields{
+		host:           dr.GetHost(),
+		exportTo:       strings.Join(dr.GetExportTo(), ","),
+		protocolFilter: protocolFilter,
+		redisOpTimeout: redisOpTimeout,
+		sourceIPHash:   strings.Join(hash, ","),
+	}
+}
+
+// destinationRuleListenersChanged checks whether the sidecar listeners may change with the DestinationRule update,
+// which is only the case if the fields they read change. The other DestinationRule updates only affect the clusters.
+func destinationRuleListenersChanged(prev config.Config, curr config.Config) bool {
+	p, c := listenerFieldsOf(prev), listenerFieldsOf(curr)
+	if p == nil || c == nil {
+		return p != c
+	}
+	return *p != *c
+}
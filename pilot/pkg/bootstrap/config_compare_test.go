@@ -166,3 +166,92 @@ func TestNeedsPush(t *testing.T) {
 		})
 	}
 }
+
+func TestDestinationRuleListenersChanged(t *testing.T) {
+	dr := func(annotations map[string]string, spec *networking.DestinationRule) config.Config {
+		return config.Config{
+			Meta: config.Meta{
+				GroupVersionKind: gvk.DestinationRule,
+				Name:             "db",
+				Namespace:        "default",
+				Annotations:      annotations,
+			},
+			Spec: spec,
+		}
+	}
+	sourceIPHash := &networking.TrafficPolicy{
+		LoadBalancer: &networking.LoadBalancerSettings{
+			LbPolicy: &networking.LoadBalancerSettings_ConsistentHash{
+				ConsistentHash: &networking.LoadBalancerSettings_ConsistentHashLB{
+					HashKey: &networking.LoadBalancerSettings_ConsistentHashLB_UseSourceIp{UseSourceIp: true},
+				},
+			},
+		},
+	}
+	stats := map[string]string{constants.ProtocolFilterAnnotation: constants.ProtocolFilterStats}
+	cases := []struct {
+		name     string
+		prev     config.Config
+		curr     config.Config
+		expected bool
+	}{
+		{
+			name:     "connection pool change",
+			prev:     dr(nil, &networking.DestinationRule{Host: "db"}),
+			curr:     dr(nil, &networking.DestinationRule{Host: "db", TrafficPolicy: &networking.TrafficPolicy{}}),
+			expected: false,
+		},
+		{
+			name:     "added without listener fields",
+			prev:     config.Config{},
+			curr:     dr(nil, &networking.DestinationRule{Host: "db"}),
+			expected: false,
+		},
+		{
+			name:     "added with protocol filter",
+			prev:     config.Config{},
+			curr:     dr(stats, &networking.DestinationRule{Host: "db"}),
+			expected: true,
+		},
+		{
+			name:     "deleted with protocol filter",
+			prev:     dr(stats, &networking.DestinationRule{Host: "db"}),
+			curr:     config.Config{},
+			expected: true,
+		},
+		{
+			name:     "protocol filter removed",
+			prev:     dr(stats, &networking.DestinationRule{Host: "db"}),
+			curr:     dr(nil, &networking.DestinationRule{Host: "db"}),
+			expected: true,
+		},
+		{
+			name:     "host of protocol filter changed",
+			prev:     dr(stats, &networking.DestinationRule{Host: "db"}),
+			curr:     dr(stats, &networking.DestinationRule{Host: "db2"}),
+			expected: true,
+		},
+		{
+			name:     "source ip hash set",
+			prev:     dr(nil, &networking.DestinationRule{Host: "db"}),
+			curr:     dr(nil, &networking.DestinationRule{Host: "db", TrafficPolicy: sourceIPHash}),
+			expected: true,
+		},
+		{
+			name: "source ip hash of subset set",
+			prev: dr(nil, &networking.DestinationRule{Host: "db", Subsets: []*networking.Subset{{Name: "v1"}}}),
+			curr: dr(nil, &networking.DestinationRule{
+				Host:    "db",
+				Subsets: []*networking.Subset{{Name: "v1", TrafficPolicy: sourceIPHash}},
+			}),
+			expected: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := destinationRuleListenersChanged(c.prev, c.curr); got != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, got)
+			}
+		})
+	}
+}
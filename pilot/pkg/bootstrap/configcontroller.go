@@ -30,6 +30,7 @@ import (
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/leaderelection"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
 	"istio.io/istio/pilot/pkg/status/distribution"
 	"istio.io/istio/pkg/adsc"
 	"istio.io/istio/pkg/config/analysis/incluster"
@@ -52,6 +53,11 @@ const (
 	// k8s:// - load in-cluster k8s controller
 	// example k8s://
 	Kubernetes ConfigSourceAddressScheme = "k8s"
+	// oci://REFERENCE - load the YAML files bundled in an OCI artifact, polled for new digests
+	// example oci://registry.example.com/mesh/config:v1?publicKey=/etc/istio/config-signing/cosign.pub
+	// The publicKey verifies the cosign signature of the artifact, and is required unless unsigned=true.
+	// insecure=true fetches the artifact over plain HTTP.
+	OCI ConfigSourceAddressScheme = "oci"
 )
 
 // initConfigController creates the config controller in the pilotConfig.
@@ -264,6 +270,15 @@ func (s *Server) initConfigSources(args *PilotArgs) (err error) {
 			}
 			s.ConfigStores = append(s.ConfigStores, configController)
 			log.Warn("Started XDS config ", s.ConfigStores)
+		case OCI:
+			store := memory.Make(collections.Pilot)
+			configController := memory.NewController(store)
+
+			err := s.makeOCIMonitor(srcAddress, args.RegistryOptions.KubeOptions.DomainSuffix, configController)
+			if err != nil {
+				return err
+			}
+			s.ConfigStores = append(s.ConfigStores, configController)
 		case Kubernetes:
 			if srcAddress.Path == "" || srcAddress.Path == "/" {
 				err2 := s.initK8SConfigStore(args)
@@ -327,6 +342,12 @@ func (s *Server) initStatusController(args *PilotArgs, writeStatus bool) {
 	})
 	s.XDSServer.StatusReporter = s.statusReporter
 	if writeStatus {
+		// The replicas share the endpoint drains of their proxies through their reports.
+		s.statusReporter.EndpointDrains = s.XDSServer.LocalEndpointDrains
+		s.addStartFunc(func(stop <-chan struct{}) error {
+			go distribution.NewEndpointDrainWatcher(s.kubeClient, args.Namespace, args.PodName, s.XDSServer).Run(stop)
+			return nil
+		})
 		s.addTerminatingStartFunc(func(stop <-chan struct{}) error {
 			leaderelection.
 				NewLeaderElection(args.Namespace, args.PodName, leaderelection.StatusController, args.Revision, s.kubeClient).
@@ -335,6 +356,11 @@ func (s *Server) initStatusController(args *PilotArgs, writeStatus bool) {
 					// avoid concurrently calling of informer Run() for controller in controller.Start
 					controller := distribution.NewController(s.kubeClient.RESTConfig(), args.Namespace, s.RWConfigStore, s.statusManager)
 					s.statusReporter.SetController(controller)
+					if features.EnableEnvoyFilterPatchStatus {
+						// The patches applied by the proxies of all the replicas are known through their reports.
+						go envoyfilter.NewStatusController(s.RWConfigStore, s.statusManager, controller,
+							features.EnvoyFilterPatchStatusInterval).Run(stop)
+					}
 					controller.Start(stop)
 				}).Run(stop)
 			return nil
@@ -358,3 +384,40 @@ func (s *Server) makeFileMonitor(fileDir string, domainSuffix string, configCont
 
 	return nil
 }
+
+// makeOCIMonitor populates the config controller with the configs of the OCI artifact. The controller only reports
+// synced once the artifact has been successfully fetched, so that istiod does not serve config, such as
+// AuthorizationPolicies, without the configs of the source.
+func (s *Server) makeOCIMonitor(srcAddress *url.URL, domainSuffix string, configController model.ConfigStoreCache) error {
+	if srcAddress.Host == "" || srcAddress.Path == "" {
+		return fmt.Errorf("invalid oci config URL %s, contains no artifact reference", srcAddress)
+	}
+	query := srcAddress.Query()
+	opts := configmonitor.OCISnapshotOptions{Insecure: query.Get("insecure") == "true"}
+	if keyFile := query.Get("publicKey"); keyFile != "" {
+		key, err := configmonitor.LoadPublicKey(keyFile)
+		if err != nil {
+			return fmt.Errorf("invalid public key for oci config URL %s: %v", srcAddress, err)
+		}
+		opts.PublicKey = key
+	} else if query.Get("unsigned") != "true" {
+		return fmt.Errorf("oci config URL %s has no publicKey to verify the artifact; set unsigned=true to skip verification",
+			srcAddress)
+	}
+	reference := srcAddress.Host + srcAddress.Path
+	ociSnapshot, err := configmonitor.NewOCISnapshot(reference, collections.Pilot, domainSuffix, opts)
+	if err != nil {
+		return err
+	}
+	ociMonitor := configmonitor.NewPollingMonitor("oci-monitor", configController, ociSnapshot.ReadConfigs,
+		features.OCIConfigSourcePollInterval)
+	configController.RegisterHasSyncedHandler(ociMonitor.HasSynced)
+
+	// Defer starting the OCI monitor until after the service is created.
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		ociMonitor.Start(stop)
+		return nil
+	})
+
+	return nil
+}
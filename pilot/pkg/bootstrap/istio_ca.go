@@ -39,6 +39,7 @@ import (
 	"istio.io/istio/pkg/jwt"
 	kubelib "istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/spiffe"
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
@@ -129,6 +130,15 @@ var (
 	audience = env.RegisterStringVar("AUDIENCE", "",
 		"Expected audience in the tokens. ")
 
+	trustedNodeAccounts = env.RegisterStringVar("CA_TRUSTED_NODE_ACCOUNTS", "",
+		"Comma separated list of node-level service accounts, in the form namespace/serviceaccount, that may request "+
+			"certificates for other identities. Each entry may be followed by ':' and a '|' separated list of "+
+			"namespace/serviceaccount patterns restricting which identities it may request, for example "+
+			"'kube-system/node-agent:app/*|db/postgres'.")
+
+	auditNodeImpersonation = env.RegisterBoolVar("CA_AUDIT_NODE_IMPERSONATION", false,
+		"If enabled, every CSR where the requested identity differs from the authenticated identity is logged.")
+
 	caRSAKeySize = env.RegisterIntVar("CITADEL_SELF_SIGNED_CA_RSA_KEY_SIZE", 2048,
 		"Specify the RSA key size to use for self-signed Istio CA certificates.")
 
@@ -179,6 +189,13 @@ func (s *Server) RunCA(grpc *grpc.Server, ca caserver.CertificateAuthority, opts
 	if startErr != nil {
 		log.Fatalf("failed to create istio ca server: %v", startErr)
 	}
+	nodeAuthorizer, err := caserver.NewNodeAuthorizer(trustedNodeAccounts.Get(), auditNodeImpersonation.Get(), func() []string {
+		return append([]string{spiffe.GetTrustDomain()}, s.environment.Mesh().TrustDomainAliases...)
+	})
+	if err != nil {
+		log.Fatalf("failed to parse %s: %v", trustedNodeAccounts.Name, err)
+	}
+	caServer.NodeAuthorizer = nodeAuthorizer
 
 	// TODO: if not set, parse Istiod's own token (if present) and get the issuer. The same issuer is used
 	// for all tokens - no need to configure twice. The token may also include cluster info to auto-configure
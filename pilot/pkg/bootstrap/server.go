@@ -264,6 +264,9 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 	}
 
 	s.XDSServer.InitGenerators(e, args.Namespace)
+	if err := s.XDSServer.InitConfigExport(e, features.ConfigExportReaders); err != nil {
+		return nil, fmt.Errorf("error initializing config export: %v", err)
+	}
 
 	// Initialize workloadTrustBundle after CA has been initialized
 	if err := s.initWorkloadTrustBundle(args); err != nil {
@@ -652,6 +655,18 @@ func (s *Server) initDiscoveryService(args *PilotArgs) {
 		return nil
 	})
 
+	if features.RetrySafetyPrometheusAddress != "" {
+		retrySafety, err := xds.NewRetrySafety(s.XDSServer, features.RetrySafetyPrometheusAddress)
+		if err != nil {
+			log.Errorf("failed to initialize the retry safety: %v", err)
+		} else {
+			s.addStartFunc(func(stop <-chan struct{}) error {
+				go retrySafety.Run(stop)
+				return nil
+			})
+		}
+	}
+
 	s.initGrpcServer(args.KeepaliveOptions)
 
 	if args.ServerOptions.GRPCAddr != "" {
@@ -674,6 +689,9 @@ func (s *Server) waitForShutdown(stop <-chan struct{}) {
 		if s.cacertsWatcher != nil {
 			_ = s.cacertsWatcher.Close()
 		}
+		// Close XDS connections gradually, so proxies do not all reconnect to other istiod
+		// instances at the same time.
+		s.XDSServer.Drain(features.XDSDrainDuration)
 		// Stop gRPC services.  If gRPC services fail to stop in the shutdown duration,
 		// force stop them. This does not happen normally.
 		stopped := make(chan struct{})
@@ -900,6 +918,9 @@ func (s *Server) initRegistryEventHandlers() {
 				}: {}},
 				Reason: []model.TriggerReason{model.ConfigUpdate},
 			}
+			if curr.GroupVersionKind == gvk.DestinationRule {
+				pushReq.ListenersUpdated = destinationRuleListenersChanged(prev, curr)
+			}
 			s.XDSServer.ConfigUpdate(pushReq)
 		}
 		schemas := collections.Pilot.All()
@@ -87,5 +87,8 @@ func (s *Server) initKubeRegistry(args *PilotArgs) (err error) {
 		s.environment.ClusterLocal(),
 		s.server))
 
+	// The default CORS policies are read from the namespaces of the config cluster.
+	s.environment.NamespaceCORSPolicies = kubecontroller.NewNamespaceCORSPolicies(s.kubeClient, s.XDSServer)
+
 	return
 }
@@ -24,6 +24,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/leaderelection"
 	"istio.io/istio/pkg/kube/inject"
 	"istio.io/istio/pkg/webhooks"
 	"istio.io/pkg/env"
@@ -82,6 +83,14 @@ func (s *Server) initSidecarInjector(args *PilotArgs) (*inject.Webhook, error) {
 		Revision: args.Revision,
 	}
 
+	if features.SidecarAutoscalingPrometheusAddress != "" && s.kubeClient != nil {
+		if autoscaler, err := s.initSidecarAutoscaler(args); err != nil {
+			log.Errorf("failed to initialize the sidecar autoscaler: %v", err)
+		} else {
+			parameters.ResourceRecommender = autoscaler
+		}
+	}
+
 	wh, err := inject.NewWebhook(parameters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create injection webhook: %v", err)
@@ -110,6 +119,31 @@ func (s *Server) initSidecarInjector(args *PilotArgs) (*inject.Webhook, error) {
 	return wh, nil
 }
 
+// initSidecarAutoscaler creates the sidecar autoscaler, whose tiers are updated by the leader.
+func (s *Server) initSidecarAutoscaler(args *PilotArgs) (*inject.SidecarAutoscaler, error) {
+	tiers, err := inject.ParseResourceTiers(features.SidecarResourceTiers)
+	if err != nil {
+		return nil, err
+	}
+	autoscaler, err := inject.NewSidecarAutoscaler(s.kubeClient, inject.SidecarAutoscalerOptions{
+		PrometheusAddress: features.SidecarAutoscalingPrometheusAddress,
+		Tiers:             tiers,
+		Interval:          features.SidecarAutoscalingInterval,
+		InPlaceResize:     features.SidecarAutoscalingInPlaceResize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		go leaderelection.
+			NewLeaderElection(args.Namespace, args.PodName, leaderelection.SidecarAutoscalingController, args.Revision, s.kubeClient).
+			AddRunFunction(autoscaler.Run).
+			Run(stop)
+		return nil
+	})
+	return autoscaler, nil
+}
+
 func getInjectorConfigMapName(revision string) string {
 	name := defaultInjectorConfigMapName
 	if revision == "" || revision == "default" {
@@ -16,7 +16,9 @@ package bootstrap
 
 import (
 	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/config/analysis/admission"
 	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/revisions"
 	"istio.io/istio/pkg/webhooks/validation/controller"
 	"istio.io/istio/pkg/webhooks/validation/server"
 	"istio.io/pkg/log"
@@ -34,7 +36,19 @@ func (s *Server) initConfigValidation(args *PilotArgs) error {
 		DomainSuffix: args.RegistryOptions.KubeOptions.DomainSuffix,
 		Mux:          s.httpsMux,
 	}
-	_, err := server.New(params)
+	policy, err := admission.ParsePolicy(features.AdmissionAnalysisPolicy)
+	if err != nil {
+		return err
+	}
+	if policy != nil && s.configController != nil {
+		params.Analysis = admission.New(policy, s.configController)
+		log.Infof("running analyzers at admission: %v", params.Analysis.Analyzers())
+	}
+	if features.ValidationRevisionCompatibility {
+		params.Revision = args.Revision
+		params.RevisionVersions = revisions.NewVersionWatcher(s.kubeClient).GetVersions
+	}
+	_, err = server.New(params)
 	if err != nil {
 		return err
 	}
@@ -22,10 +22,12 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"go.uber.org/atomic"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config"
 	istiolog "istio.io/pkg/log"
+	"istio.io/pkg/monitoring"
 )
 
 // Monitor will poll a config function in order to update a ConfigStore as
@@ -39,10 +41,29 @@ type Monitor struct {
 	// channel to trigger updates on
 	// generally set to a file watch, but used in tests as well
 	updateCh chan struct{}
+	// if set, the snapshot is also polled at this interval, for sources that cannot be watched
+	pollInterval time.Duration
+	// synced is set once a snapshot has been read and applied to the store
+	synced *atomic.Bool
 }
 
 var log = istiolog.RegisterScope("monitor", "file configuration monitor", 0)
 
+var (
+	sourceTag = monitoring.MustCreateLabel("source")
+
+	// rejectedConfigs tracks the configs of the monitored sources rejected by the validation of the store.
+	rejectedConfigs = monitoring.NewSum(
+		"pilot_config_source_rejected_configs",
+		"Total number of configs of file and OCI config sources rejected by validation.",
+		monitoring.WithLabels(sourceTag),
+	)
+)
+
+func init() {
+	monitoring.MustRegister(rejectedConfigs)
+}
+
 // NewMonitor creates a Monitor and will delegate to a passed in controller.
 // The controller holds a reference to the actual store.
 // Any func that returns a []*model.Config can be used with the Monitor
@@ -52,10 +73,20 @@ func NewMonitor(name string, delegateStore model.ConfigStore, getSnapshotFunc fu
 		root:            root,
 		store:           delegateStore,
 		getSnapshotFunc: getSnapshotFunc,
+		synced:          atomic.NewBool(false),
 	}
 	return monitor
 }
 
+// NewPollingMonitor creates a Monitor polling the getSnapshotFunc at the interval, for sources without files to
+// watch, such as OCI artifacts.
+func NewPollingMonitor(name string, delegateStore model.ConfigStore, getSnapshotFunc func() ([]*config.Config, error),
+	interval time.Duration) *Monitor {
+	monitor := NewMonitor(name, delegateStore, getSnapshotFunc, "")
+	monitor.pollInterval = interval
+	return monitor
+}
+
 const watchDebounceDelay = 50 * time.Millisecond
 
 // Trigger notifications when a file is mutated
@@ -106,6 +137,24 @@ func fileTrigger(path string, ch chan struct{}, stop <-chan struct{}) error {
 	return nil
 }
 
+// Trigger notifications at every interval
+func pollTrigger(interval time.Duration, ch chan struct{}, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			select {
+			case ch <- struct{}{}:
+			default:
+				// An update is already pending.
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
 // recursiveWatcher wraps a fsnotify wrapper to add a best-effort recursive directory watching in user
 // space. See https://github.com/fsnotify/fsnotify/issues/18. The implementation is inherently racy,
 // as files added to a directory immediately after creation may not trigger events; as such it is only useful
@@ -141,6 +190,9 @@ func (m *Monitor) Start(stop <-chan struct{}) {
 	if err := fileTrigger(m.root, m.updateCh, stop); err != nil {
 		log.Errorf("Unable to setup FileTrigger for %s: %v", m.root, err)
 	}
+	if m.pollInterval > 0 {
+		go pollTrigger(m.pollInterval, m.updateCh, stop)
+	}
 	// Run the close loop asynchronously.
 	go func() {
 		for {
@@ -155,6 +207,12 @@ func (m *Monitor) Start(stop <-chan struct{}) {
 	}()
 }
 
+// HasSynced returns true once a snapshot has been successfully read and applied to the store. Until then, the store
+// does not hold the configs of the source.
+func (m *Monitor) HasSynced() bool {
+	return m.synced.Load()
+}
+
 func (m *Monitor) checkAndUpdate() {
 	newConfigs, err := m.getSnapshotFunc()
 	// If an error exists then log it and return to running the check and update
@@ -208,10 +266,12 @@ func (m *Monitor) checkAndUpdate() {
 
 	// Save the updated list.
 	m.configs = copyConfigs
+	m.synced.Store(true)
 }
 
 func (m *Monitor) createConfig(c *config.Config) {
 	if _, err := m.store.Create(*c); err != nil {
+		rejectedConfigs.With(sourceTag.Value(m.name)).Increment()
 		log.Warnf("Failed to create config %s %s/%s: %v (%+v)", c.GroupVersionKind, c.Namespace, c.Name, err, *c)
 	}
 }
@@ -224,6 +284,7 @@ func (m *Monitor) updateConfig(c *config.Config) {
 	}
 
 	if _, err := m.store.Update(*c); err != nil {
+		rejectedConfigs.With(sourceTag.Value(m.name)).Increment()
 		log.Warnf("Failed to update config (%+v): %v ", *c, err)
 	}
 }
@@ -228,3 +228,30 @@ func TestMonitorForError(t *testing.T) {
 		return nil
 	}).Should(gomega.Succeed())
 }
+
+func TestMonitorHasSynced(t *testing.T) {
+	store := memory.Make(collection.SchemasFor(collections.IstioNetworkingV1Alpha3Gateways))
+
+	var callCount int
+	someConfigFunc := func() ([]*config.Config, error) {
+		callCount++
+		if callCount == 1 {
+			return nil, errors.New("registry unavailable")
+		}
+		return createConfigSet, nil
+	}
+	mon := NewMonitor("", store, someConfigFunc, "")
+	stop := make(chan struct{})
+	defer func() { close(stop) }()
+	mon.Start(stop)
+
+	// The failed first read must not report the store as synced.
+	if mon.HasSynced() {
+		t.Fatal("monitor synced after a failed read")
+	}
+	mon.updateCh <- struct{}{}
+	retry.UntilOrFail(t, mon.HasSynced)
+	if store.Get(gvk.Gateway, "magic", "") == nil {
+		t.Fatal("monitor synced before the configs were applied")
+	}
+}
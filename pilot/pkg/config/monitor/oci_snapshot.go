@@ -0,0 +1,288 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"archive/tar"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// cosignSignatureAnnotation is the annotation of the layers of a cosign signature holding the signature of the
+// layer, which is a simple signing payload naming the digest of the signed artifact.
+const cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// OCISnapshotOptions configures the fetching of an OCI artifact.
+type OCISnapshotOptions struct {
+	// PublicKey verifies the cosign signature of the artifact. If nil, the artifact is not verified.
+	PublicKey crypto.PublicKey
+	// Insecure allows fetching from a registry over plain HTTP.
+	Insecure bool
+}
+
+// OCISnapshot reads the configs bundled in an OCI artifact, as YAML files in the layers of an image, or as YAML
+// layers of an artifact. The artifact is only fetched again when the digest its reference resolves to changes.
+type OCISnapshot struct {
+	ref              name.Reference
+	domainSuffix     string
+	publicKey        crypto.PublicKey
+	fetchOpts        []remote.Option
+	configTypeFilter map[config.GroupVersionKind]bool
+
+	digest  v1.Hash
+	configs []*config.Config
+}
+
+// NewOCISnapshot returns a snapshotter of the artifact at the reference, for example
+// registry.example.com/mesh/config:v1. If no types are provided in the descriptor, all Istio types will be allowed.
+func NewOCISnapshot(reference string, schemas collection.Schemas, domainSuffix string, opts OCISnapshotOptions) (*OCISnapshot, error) {
+	var nameOpts []name.Option
+	if opts.Insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	ref, err := name.ParseReference(reference, nameOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCI reference %s: %v", reference, err)
+	}
+	snapshot := &OCISnapshot{
+		ref:              ref,
+		domainSuffix:     domainSuffix,
+		publicKey:        opts.PublicKey,
+		fetchOpts:        []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)},
+		configTypeFilter: make(map[config.GroupVersionKind]bool),
+	}
+	ss := schemas.All()
+	if len(ss) == 0 {
+		ss = collections.Pilot.All()
+	}
+	for _, k := range ss {
+		if _, ok := collections.Pilot.FindByGroupVersionKind(k.Resource().GroupVersionKind()); ok {
+			snapshot.configTypeFilter[k.Resource().GroupVersionKind()] = true
+		}
+	}
+	return snapshot, nil
+}
+
+// ReadConfigs fetches the artifact and returns a sorted slice of eligible model.Config. This can be used as a
+// configFunc when creating a Monitor. If the signature of the artifact cannot be verified, an error is returned
+// and the previous configs are kept by the Monitor.
+func (o *OCISnapshot) ReadConfigs() ([]*config.Config, error) {
+	desc, err := remote.Get(o.ref, o.fetchOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %v", o.ref, err)
+	}
+	if o.configs != nil && desc.Digest == o.digest {
+		return o.configs, nil
+	}
+	if o.publicKey != nil {
+		if err := o.verify(desc.Digest); err != nil {
+			return nil, fmt.Errorf("failed to verify %s@%s: %v", o.ref, desc.Digest, err)
+		}
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s@%s: %v", o.ref, desc.Digest, err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the layers of %s@%s: %v", o.ref, desc.Digest, err)
+	}
+	result := []*config.Config{}
+	for _, layer := range layers {
+		configs, err := o.readLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s@%s: %v", o.ref, desc.Digest, err)
+		}
+		for _, cfg := range configs {
+			if o.configTypeFilter[cfg.GroupVersionKind] {
+				result = append(result, cfg)
+			}
+		}
+	}
+	sort.Sort(byKey(result))
+
+	log.Infof("Read %d configs from %s@%s", len(result), o.ref, desc.Digest)
+	o.digest = desc.Digest
+	o.configs = result
+	return result, nil
+}
+
+// readLayer parses the YAML files of an image layer, or the layer itself if it is not a tarball.
+func (o *OCISnapshot) readLayer(layer v1.Layer) ([]*config.Config, error) {
+	mt, err := layer.MediaType()
+	if err != nil {
+		return nil, err
+	}
+	switch mt {
+	case types.DockerLayer, types.DockerUncompressedLayer, types.OCILayer, types.OCIUncompressedLayer:
+		rc, err := layer.Uncompressed()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		var result []*config.Config
+		tr := tar.NewReader(rc)
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if hdr.Typeflag != tar.TypeReg || !supportedExtensions[filepath.Ext(hdr.Name)] {
+				continue
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			configs, err := parseInputs(data, o.domainSuffix)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", hdr.Name, err)
+			}
+			result = append(result, configs...)
+		}
+	default:
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		return parseInputs(data, o.domainSuffix)
+	}
+}
+
+// simpleSigning is the payload signed by cosign.
+type simpleSigning struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verify checks that a cosign signature of the digest, stored with the sha256-<hex>.sig tag next to the artifact,
+// is signed by the public key.
+func (o *OCISnapshot) verify(digest v1.Hash) error {
+	sigRef := o.ref.Context().Tag(digest.Algorithm + "-" + digest.Hex + ".sig")
+	sigImg, err := remote.Image(sigRef, o.fetchOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signature %s: %v", sigRef, err)
+	}
+	manifest, err := sigImg.Manifest()
+	if err != nil {
+		return fmt.Errorf("failed to fetch signature %s: %v", sigRef, err)
+	}
+	for _, desc := range manifest.Layers {
+		encoded, f := desc.Annotations[cosignSignatureAnnotation]
+		if !f {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		layer, err := sigImg.LayerByDigest(desc.Digest)
+		if err != nil {
+			return err
+		}
+		payload, err := readBlob(layer)
+		if err != nil {
+			return err
+		}
+		if err := verifySignature(o.publicKey, payload, signature); err != nil {
+			continue
+		}
+		var ss simpleSigning
+		if err := json.Unmarshal(payload, &ss); err != nil {
+			continue
+		}
+		if ss.Critical.Image.DockerManifestDigest == digest.String() {
+			return nil
+		}
+	}
+	return fmt.Errorf("no signature of %s matches the public key", digest)
+}
+
+func readBlob(layer v1.Layer) ([]byte, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func verifySignature(key crypto.PublicKey, payload, signature []byte) error {
+	digest := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest[:], signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, payload, signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// LoadPublicKey reads a PEM encoded ECDSA, RSA or Ed25519 public key, such as a cosign.pub file.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || !strings.HasSuffix(block.Type, "PUBLIC KEY") {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded public key", path)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,172 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/onsi/gomega"
+
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+func pushConfigImage(t *testing.T, ref string, files map[string]string) v1.Hash {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for name, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: static.NewLayer(buf.Bytes(), types.OCIUncompressedLayer)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	if err := crane.Push(img, ref); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return digest
+}
+
+func pushSignature(t *testing.T, repo string, digest v1.Hash, key *ecdsa.PrivateKey) {
+	t.Helper()
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},`+
+		`"type":"cosign container image signature"},"optional":null}`, repo, digest.String()))
+	hash := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(payload, "application/vnd.dev.cosign.simplesigning.v1+json"),
+		Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	if err := crane.Push(img, fmt.Sprintf("%s:%s-%s.sig", repo, digest.Algorithm, digest.Hex)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOCISnapshot(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	repo := strings.TrimPrefix(s.URL, "http://") + "/mesh/config"
+	ref := repo + ":v1"
+
+	digest := pushConfigImage(t, ref, map[string]string{
+		"gateway.yaml":         gatewayYAML,
+		"routes/myapp.yml":     virtualServiceYAML,
+		"README.md":            "not a config",
+		"routes/invalid.other": "not a config",
+	})
+
+	snapshot, err := NewOCISnapshot(ref, collection.SchemasFor(), "cluster.local", OCISnapshotOptions{Insecure: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	configs, err := snapshot.ReadConfigs()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(2))
+	g.Expect(configs[0].GroupVersionKind).To(gomega.Equal(gvk.Gateway))
+	g.Expect(configs[0].Domain).To(gomega.Equal("cluster.local"))
+	g.Expect(configs[1].GroupVersionKind).To(gomega.Equal(gvk.VirtualService))
+
+	// The artifact is not fetched again while the tag resolves to the same digest.
+	again, err := snapshot.ReadConfigs()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(again).To(gomega.HaveLen(2))
+	g.Expect(again[0]).To(gomega.BeIdenticalTo(configs[0]))
+
+	// The artifact is verified when a public key is set.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	signed, err := NewOCISnapshot(ref, collection.SchemasFor(), "cluster.local",
+		OCISnapshotOptions{Insecure: true, PublicKey: &key.PublicKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = signed.ReadConfigs()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	pushSignature(t, repo, digest, key)
+	configs, err = signed.ReadConfigs()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(2))
+
+	// A signature by another key is rejected.
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	untrusted, err := NewOCISnapshot(ref, collection.SchemasFor(), "cluster.local",
+		OCISnapshotOptions{Insecure: true, PublicKey: &other.PublicKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = untrusted.ReadConfigs()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cosign.pub")
+	g.Expect(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644)).To(gomega.Succeed())
+
+	loaded, err := LoadPublicKey(path)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(loaded).To(gomega.Equal(&key.PublicKey))
+
+	invalid := filepath.Join(dir, "invalid.pub")
+	g.Expect(os.WriteFile(invalid, []byte("invalid"), 0o644)).To(gomega.Succeed())
+	_, err = LoadPublicKey(invalid)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
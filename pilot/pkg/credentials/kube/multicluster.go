@@ -139,6 +139,22 @@ func (a *AggregateController) GetCaCert(name, namespace string) (cert []byte, er
 	return nil, firstError
 }
 
+func (a *AggregateController) GetSecretValue(name, namespace, key string) (value []byte, err error) {
+	// Search through all clusters, find first non-empty result
+	var firstError error
+	for _, c := range a.controllers {
+		v, err := c.GetSecretValue(name, namespace, key)
+		if err != nil {
+			if firstError == nil {
+				firstError = err
+			}
+		} else {
+			return v, nil
+		}
+	}
+	return nil, firstError
+}
+
 func (a *AggregateController) Authorize(serviceAccount, namespace string) error {
 	return a.authController.Authorize(serviceAccount, namespace)
 }
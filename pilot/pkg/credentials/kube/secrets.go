@@ -218,6 +218,18 @@ func (s *CredentialsController) GetCaCert(name, namespace string) (cert []byte,
 	return extractRoot(k8sSecret)
 }
 
+func (s *CredentialsController) GetSecretValue(name, namespace, key string) (value []byte, err error) {
+	k8sSecret, err := s.secretLister.Secrets(namespace).Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("secret %v/%v not found", namespace, name)
+	}
+	if !hasValue(k8sSecret.Data, key) {
+		return nil, fmt.Errorf("found secret, but didn't have expected key %s; found: %s",
+			key, truncatedKeysMessage(k8sSecret.Data))
+	}
+	return k8sSecret.Data[key], nil
+}
+
 func hasKeys(d map[string][]byte, keys ...string) bool {
 	for _, k := range keys {
 		_, f := d[k]
@@ -98,6 +98,27 @@ var (
 			"for this time, we'll trigger a push.",
 	).Get()
 
+	PushPriority = env.RegisterStringVar(
+		"PILOT_PUSH_PRIORITY",
+		"",
+		"Comma separated list of proxy types (router, sidecar) pushed first during a push, in order, for example "+
+			"router so that gateways converge within a bounded time during large pushes. Proxies of lower priority "+
+			"are only pushed once no proxy of higher priority is queued, so they may be delayed for as long as "+
+			"proxies of higher priority keep being queued. By default, proxies are pushed in the order their "+
+			"pushes are queued.",
+	).Get()
+
+	DebounceByKind = env.RegisterStringVar(
+		"PILOT_DEBOUNCE_BY_KIND",
+		"",
+		"Comma separated list of kind=after[:max] debounce windows of the config events of a kind, for "+
+			"example endpoint=1s:30s,VirtualService=10ms. The events of each listed kind are debounced "+
+			"separately from the other events, so that churn of one kind does not delay the pushes of the others. "+
+			"The endpoint kind stands for the endpoint updates of all the registries, such as Pod or WorkloadEntry "+
+			"churn; they are only debounced if PILOT_ENABLE_EDS_DEBOUNCE is enabled, or cause a full push. "+
+			"max defaults to PILOT_DEBOUNCE_MAX.",
+	).Get()
+
 	EnableEDSDebounce = env.RegisterBoolVar(
 		"PILOT_ENABLE_EDS_DEBOUNCE",
 		true,
@@ -141,6 +162,15 @@ var (
 		"EnableMongoFilter enables injection of `envoy.filters.network.mongo_proxy` in the filter chain.",
 	).Get()
 
+	// EnableKafkaFilter enables injection of `envoy.filters.network.kafka_broker` in the filter chain.
+	// Pilot injects this filter if the service port name is `kafka`.
+	EnableKafkaFilter = env.RegisterBoolVar(
+		"PILOT_ENABLE_KAFKA_FILTER",
+		false,
+		"EnableKafkaFilter enables injection of `envoy.filters.network.kafka_broker` in the filter chain, "+
+			"for Kafka request and response stats. Requests are logged at the debug level of the kafka proxy logger.",
+	).Get()
+
 	// UseRemoteAddress sets useRemoteAddress to true for side car outbound listeners so that it picks up the localhost
 	// address of the sender, which is an internal address, so that trusted headers are not sanitized.
 	UseRemoteAddress = env.RegisterBoolVar(
@@ -435,12 +465,98 @@ var (
 	EnableRDSCaching = env.RegisterBoolVar("PILOT_ENABLE_RDS_CACHE", true,
 		"If true, Pilot will cache RDS responses. Note: this depends on PILOT_ENABLE_XDS_CACHE.").Get()
 
+	EnableScopeCache = env.RegisterBoolVar("PILOT_ENABLE_SCOPE_CACHE", false,
+		"If true, Pilot will share the SidecarScope, the outbound listeners and the outbound virtual hosts generated "+
+			"during a push between the sidecars of a namespace selected by the same Sidecar, with the same labels and "+
+			"the same proxy metadata.").Get()
+
 	EnableXDSCacheMetrics = env.RegisterBoolVar("PILOT_XDS_CACHE_STATS", false,
 		"If true, Pilot will collect metrics for XDS cache efficiency.").Get()
 
+	EnableRDSBuildMetrics = env.RegisterBoolVar("PILOT_RDS_BUILD_STATS", false,
+		"If true, Pilot will collect metrics for the time spent building each outbound route configuration.").Get()
+
+	EnableRDSProfilingLabels = env.RegisterBoolVar("PILOT_RDS_PROFILING_LABELS", false,
+		"If true, outbound route configuration generation is tagged with pprof labels and runtime/trace regions "+
+			"named after the route, so CPU profiles and execution traces can be broken down by route.").Get()
+
+	ConfigGenerationWorkers = env.RegisterIntVar("PILOT_CONFIG_GENERATION_WORKERS", 1,
+		"The maximum number of goroutines building the route configurations or the outbound clusters of a single "+
+			"proxy concurrently. This improves the push latency of gateways serving many routes, at the cost of CPU "+
+			"bursts, as up to PILOT_PUSH_THROTTLE proxies are pushed concurrently. If 1, they are built sequentially.").Get()
+
+	GatewayRouteShards = env.RegisterIntVar("PILOT_GATEWAY_ROUTE_SHARDS", 0,
+		"If greater than 1, the HTTPS gateway servers terminating TLS that share a route configuration, such as the "+
+			"servers of a gateway on the same port, are split by hash of their certificate into this number of shards, "+
+			"each with its own route configuration, so a change to a host only updates the route configuration of its "+
+			"shard. The servers with the same certificate stay in the same shard, so that the requests coalesced on a "+
+			"connection are served. Plaintext HTTP servers are not sharded. Sharded route configurations are named "+
+			"after the server route configuration, with a .shard-N suffix.").Get()
+
+	SortVirtualHostsBySpecificity = env.RegisterBoolVar("PILOT_SORT_VIRTUAL_HOSTS_BY_SPECIFICITY", false,
+		"If true, the virtual hosts of a route configuration are ordered by specificity: exact hosts first, then "+
+			"wildcard hosts from the most to the least specific. Otherwise, virtual hosts are ordered by name.").Get()
+
+	EnableVirtualHostSourceMetadata = env.RegisterBoolVar("PILOT_VIRTUAL_HOST_SOURCE_METADATA", false,
+		"If true, the routes of the outbound virtual hosts built for a service carry the registry and clusters "+
+			"the service was discovered from in their istio metadata, under the source key. This is intended "+
+			"for debugging multi-cluster routing, and increases the size of the route configurations.").Get()
+
+	RespectKubernetesTrafficPolicy = env.RegisterBoolVar("PILOT_RESPECT_KUBERNETES_TRAFFIC_POLICY", false,
+		"If true, EDS for in-mesh traffic honors the internalTrafficPolicy and topology aware hints of Kubernetes "+
+			"services. Individual services can override this with the "+
+			"experimental.istio.io/kubernetes-traffic-policy annotation.").Get()
+
+	DefaultHTTPRouteHeaders = env.RegisterStringVar("PILOT_DEFAULT_HTTP_ROUTE_HEADERS", "",
+		"Header operations, in the YAML or JSON format of the VirtualService headers field, that are applied to "+
+			"every outbound virtual host of sidecars. For example: "+
+			`{"request": {"set": {"x-mesh-source": "istio"}}, "response": {"remove": ["x-internal"]}}`).Get()
+
+	AdmissionAnalysisPolicy = env.RegisterStringVar("PILOT_ADMISSION_ANALYSIS_POLICY", "",
+		"If set, the validation webhook runs the config analyzers on the configs being applied, and warns about or "+
+			"denies them based on the severity of the messages. The value sets the severity thresholds of the "+
+			"analyzers in JSON, for example: "+
+			`{"default": {"warn": "Warning"}, "analyzers": {"virtualservice.GatewayAnalyzer": {"deny": "Error"}}}`).Get()
+
+	ValidationRevisionCompatibility = env.RegisterBoolVar("PILOT_VALIDATION_REVISION_COMPATIBILITY", true,
+		"If enabled, the validation webhook of the newest revision warns about the configs using features that "+
+			"the older revisions in use do not support. Each istiod registers its version on the injection webhooks "+
+			"of its revision.").Get()
+
+	StrictVirtualHostDomains = env.RegisterBoolVar("PILOT_STRICT_VIRTUAL_HOST_DOMAINS", false,
+		"If enabled, a sidecar whose outbound routes have a domain claimed by more than one Service or "+
+			"VirtualService does not receive the routes, instead of receiving them without the duplicate domains. "+
+			"The conflicts are logged and reported in the push status.").Get()
+
+	EnableXDSGeneratorAccounting = env.RegisterBoolVar("PILOT_ENABLE_XDS_GENERATOR_ACCOUNTING", false,
+		"If enabled, the CPU time and the memory allocated by each xDS generator are recorded for every push, "+
+			"by xDS type and proxy type, in the pilot_xds_generator_cpu_seconds and pilot_xds_generator_alloc_bytes "+
+			"metrics. The generations are pinned to their OS thread to measure their CPU time, which may increase "+
+			"the number of threads of istiod.").Get()
+
 	XDSCacheMaxSize = env.RegisterIntVar("PILOT_XDS_CACHE_SIZE", 60000,
 		"The maximum number of cache entries for the XDS cache.").Get()
 
+	XDSCacheMaxBytes = env.RegisterIntVar("PILOT_XDS_CACHE_MAX_BYTES", 0,
+		"The maximum total size, in bytes, of the resources held by the XDS cache. Least recently used entries "+
+			"are evicted once the limit is exceeded. If 0, the cache is only bounded by PILOT_XDS_CACHE_SIZE.").Get()
+
+	XDSCacheTypeQuotas = env.RegisterStringVar("PILOT_XDS_CACHE_TYPE_QUOTAS", "",
+		"Comma separated list of per resource type size quotas for the XDS cache, in the form type=quantity, "+
+			"for example \"rds=256Mi,eds=128Mi\". Supported types are cds, eds, rds and sds. "+
+			"Least recently used entries of a type are evicted once its quota is exceeded.").Get()
+
+	XDSConfigSizeThresholds = env.RegisterStringVar("PILOT_XDS_CONFIG_SIZE_THRESHOLDS", "",
+		"Comma separated list of per resource type thresholds for the size of the configuration pushed to a proxy, in "+
+			"the form type=quantity, for example \"lds=4Mi,cds=10Mi\". Supported types are cds, eds, lds and rds. "+
+			"A warning is logged and the pilot_xds_config_size_threshold_exceeded metric is incremented when the "+
+			"size of a response to a proxy exceeds the threshold of its type.").Get()
+
+	ConfigHistorySize = env.RegisterIntVar("PILOT_CONFIG_HISTORY_SIZE", 0,
+		"If greater than 0, the number of pushes of each type whose config is retained for each proxy, so that "+
+			"/debug/config_history can report the changes between consecutive pushes. The retained config uses "+
+			"memory proportional to the config of the proxies, so this is disabled by default.").Get()
+
 	// EnableLegacyFSGroupInjection has first-party-jwt as allowed because we only
 	// need the fsGroup configuration for the projected service account volume mount,
 	// which is only used by first-party-jwt. The installer will automatically
@@ -471,6 +587,26 @@ var (
 		"If true, pilot will add telemetry related metadata to Endpoint resource, which will be consumed by telemetry filter.",
 	).Get()
 
+	EndpointMetadataLabels = func() []string {
+		v := env.RegisterStringVar("PILOT_ENDPOINT_METADATA_LABELS", "",
+			"Comma separated list of workload labels that pilot adds to the envoy.lb metadata of endpoints, so upstream "+
+				"filters such as Wasm or Lua and load balancing policies can act on them. Only labels with few distinct "+
+				"values should be listed, as every endpoint carries them in EDS.").Get()
+		var labels []string
+		for _, l := range strings.Split(v, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				labels = append(labels, l)
+			}
+		}
+		return labels
+	}()
+
+	EnableEDSTLSModeSlimming = env.RegisterBoolVar("PILOT_ENABLE_EDS_TLS_MODE_SLIMMING", false,
+		"If true, pilot omits the tlsMode metadata of the endpoints from EDS when a DestinationRule sets the TLS "+
+			"settings of the cluster, as auto mTLS does not apply to it. This reduces the size of EDS for large "+
+			"services. The telemetry metadata and the envoy.lb labels of the endpoints are always sent, as they are "+
+			"read by filters pilot does not know about.").Get()
+
 	MetadataExchange = env.RegisterBoolVar("PILOT_ENABLE_METADATA_EXCHANGE", true,
 		"If true, pilot will add metadata exchange filters, which will be consumed by telemetry filter.",
 	).Get()
@@ -565,6 +701,15 @@ var (
 	EnableEnvoyFilterMetrics = env.RegisterBoolVar("PILOT_ENVOY_FILTER_STATS", false,
 		"If true, Pilot will collect metrics for envoy filter operations.").Get()
 
+	EnableEnvoyFilterPatchStatus = env.RegisterBoolVar("PILOT_ENVOY_FILTER_PATCH_STATUS", false,
+		"If true, istiod writes a PatchesMatched condition to the status of the EnvoyFilters, listing the patches "+
+			"that did not match the config of any proxy during PILOT_ENVOY_FILTER_PATCH_STATUS_INTERVAL after the "+
+			"EnvoyFilter was last changed. The proxies connected to all the istiod replicas are taken into account, "+
+			"through their distribution reports, so PILOT_ENABLE_STATUS must be enabled too.").Get()
+
+	EnvoyFilterPatchStatusInterval = env.RegisterDurationVar("PILOT_ENVOY_FILTER_PATCH_STATUS_INTERVAL", time.Minute,
+		"The interval at which the PatchesMatched condition of the EnvoyFilters is updated.").Get()
+
 	EnableRouteCollapse = env.RegisterBoolVar("PILOT_ENABLE_ROUTE_COLLAPSE_OPTIMIZATION", true,
 		"If true, Pilot will merge virtual hosts with the same routes into a single virtual host, as an optimization.").Get()
 
@@ -590,11 +735,102 @@ var (
 
 	EnableQUICListeners = env.RegisterBoolVar("PILOT_ENABLE_QUIC_LISTENERS", false,
 		"If true, QUIC listeners will be generated wherever there are listeners terminating TLS on gateways "+
-			"if the gateway service exposes a UDP port with the same number (for example 443/TCP and 443/UDP)").Get()
+			"if the gateway service exposes a UDP port with the same number (for example 443/TCP and 443/UDP), "+
+			"and on sidecars binding outbound HTTP listeners to their ports for services with the "+
+			"experimental.istio.io/http3 annotation").Get()
+
+	EnableUDPProxy = env.RegisterBoolVar("PILOT_ENABLE_UDP_PROXY", false,
+		"If true, clusters will be generated for UDP service ports, gateway servers with the UDP protocol will be "+
+			"served by UDP proxy listeners, and sidecars will proxy the UDP ports listed in their "+
+			"experimental.istio.io/outbound-udp-ports annotation").Get()
+
+	RetryBudgetPercent = env.RegisterFloatVar("PILOT_RETRY_BUDGET_PERCENT", 0,
+		"If greater than 0, the percentage of the active requests of a cluster that may be retries, limiting the retries "+
+			"of all the clusters whose DestinationRule does not set http.maxRetries. Should be 0.0 - 100.0.").Get()
+
+	RetryBudgetMinRetryConcurrency = env.RegisterIntVar("PILOT_RETRY_BUDGET_MIN_RETRY_CONCURRENCY", 3,
+		"The number of concurrent retries always allowed by the retry budget of PILOT_RETRY_BUDGET_PERCENT.").Get()
+
+	RetrySafetyPrometheusAddress = env.RegisterStringVar("PILOT_RETRY_SAFETY_PROMETHEUS_ADDRESS", "",
+		"If set, the address of the Prometheus server queried for the mesh-wide rate of 5xx responses. While the rate "+
+			"is above PILOT_RETRY_SAFETY_ERROR_RATE, the default retries are reduced to PILOT_RETRY_SAFETY_ATTEMPTS, "+
+			"so that retries do not amplify upstream failures.").Get()
+
+	RetrySafetyErrorRate = env.RegisterFloatVar("PILOT_RETRY_SAFETY_ERROR_RATE", 0.2,
+		"The mesh-wide ratio of 5xx responses, 0.0 - 1.0, above which the default retries are reduced. They are "+
+			"restored once the ratio is below half of it.").Get()
+
+	RetrySafetyAttempts = env.RegisterIntVar("PILOT_RETRY_SAFETY_ATTEMPTS", 1,
+		"The number of default retries while the mesh-wide rate of 5xx responses is high.").Get()
+
+	RetrySafetyInterval = env.RegisterDurationVar("PILOT_RETRY_SAFETY_INTERVAL", 30*time.Second,
+		"How often the mesh-wide rate of 5xx responses is queried.").Get()
+
+	SidecarAutoscalingPrometheusAddress = env.RegisterStringVar("PILOT_SIDECAR_AUTOSCALING_PROMETHEUS_ADDRESS", "",
+		"If set, the address of the Prometheus server queried for the CPU and memory usage of the sidecars. Istiod then "+
+			"picks one of PILOT_SIDECAR_RESOURCE_TIERS for each workload, records it on its pods, and injects the "+
+			"pods created later with its requests. Requires the permission to patch pods.").Get()
+
+	SidecarResourceTiers = env.RegisterStringVar("PILOT_SIDECAR_RESOURCE_TIERS",
+		"100m/128Mi,500m/256Mi,1000m/512Mi,2000m/1Gi",
+		"The comma separated CPU/memory requests that the sidecars are scaled between, such as 500m/256Mi.").Get()
+
+	SidecarAutoscalingInterval = env.RegisterDurationVar("PILOT_SIDECAR_AUTOSCALING_INTERVAL", 5*time.Minute,
+		"How often the usage of the sidecars is queried and their resource tiers updated.").Get()
+
+	SidecarAutoscalingInPlaceResize = env.RegisterBoolVar("PILOT_SIDECAR_AUTOSCALING_IN_PLACE_RESIZE", false,
+		"If true, the requests of the running sidecars are resized in place when their tier changes. Requires a "+
+			"cluster supporting in-place pod resize; otherwise the tier applies when the pods are recreated.").Get()
+
+	EnableLocalRateLimit = env.RegisterBoolVar("PILOT_ENABLE_LOCAL_RATE_LIMIT", false,
+		"If true, the HTTP connection managers include the local rate limit filter, enforcing the "+
+			"experimental.istio.io/local-rate-limit annotation of VirtualServices and the "+
+			"experimental.istio.io/inbound-local-rate-limit annotation of Sidecars.").Get()
+
+	GlobalRateLimitService = env.RegisterStringVar("PILOT_GLOBAL_RATE_LIMIT_SERVICE", "",
+		"The host:port of the gRPC rate limit service, for example ratelimit.istio-system.svc.cluster.local:8081. "+
+			"If set, the HTTP connection managers include the rate limit filter, sending the descriptors configured by the "+
+			"experimental.istio.io/global-rate-limit annotation of VirtualServices to the service.").Get()
+
+	GlobalRateLimitDomain = env.RegisterStringVar("PILOT_GLOBAL_RATE_LIMIT_DOMAIN", "istio",
+		"The domain of the descriptors sent to the global rate limit service.").Get()
+
+	GlobalRateLimitTimeout = env.RegisterDurationVar("PILOT_GLOBAL_RATE_LIMIT_TIMEOUT", 20*time.Millisecond,
+		"The timeout of the requests to the global rate limit service.").Get()
+
+	GlobalRateLimitFailClosed = env.RegisterBoolVar("PILOT_GLOBAL_RATE_LIMIT_FAIL_CLOSED", false,
+		"If true, the requests are rejected when the global rate limit service fails or times out, instead of "+
+			"being allowed.").Get()
+
+	OCIConfigSourcePollInterval = env.RegisterDurationVar("PILOT_OCI_CONFIG_SOURCE_POLL_INTERVAL", time.Minute,
+		"The interval at which the oci:// config sources are checked for a new artifact digest.").Get()
+
+	ConfigExportReaders = env.RegisterStringVar("PILOT_CONFIG_EXPORT_READERS", "",
+		"Comma separated list of the clients allowed to export the config of istiod over XDS with the config-export "+
+			"generator, and the namespaces they can read, for example audit/auditor=*,mesh-b/istiod=foo|bar. Clients "+
+			"are identified by <namespace>/<service account>, and * grants all the namespaces and the mesh config. "+
+			"If empty, the config cannot be exported.").Get()
+
+	EnableDualStack = env.RegisterBoolVar("ISTIO_DUAL_STACK", false,
+		"If true, proxies with both IPv4 and IPv6 addresses resolve DNS clusters to both families and bind their "+
+			"virtual listeners to both, and proxies reach dual-stack endpoints on an address of a family they support").Get()
 
 	VerifyCertAtClient = env.RegisterBoolVar("VERIFY_CERTIFICATE_AT_CLIENT", false,
 		"If enabled, certificates received by the proxy will be verified against the OS CA certificate bundle.").Get()
 
+	XDSDrainDuration = env.RegisterDurationVar("PILOT_XDS_DRAIN_DURATION", 0,
+		"On shutdown, istiod stops accepting new XDS connections and closes existing ones spread evenly over "+
+			"this period, to avoid all proxies reconnecting at once. This is in addition to the shutdown duration. "+
+			"If zero, connections are closed when the gRPC server stops.").Get()
+
+	XDSReconnectBackoff = env.RegisterDurationVar("PILOT_XDS_RECONNECT_BACKOFF", 0,
+		"If set, istiod asks XDS clients to wait this long before reconnecting when their stream is closed. "+
+			"Honored by istio-agent.").Get()
+
+	XDSReconnectJitter = env.RegisterDurationVar("PILOT_XDS_RECONNECT_JITTER", 0,
+		"If set, istiod asks XDS clients to add a random delay, up to this duration, to the reconnect backoff "+
+			"when their stream is closed. Honored by istio-agent.").Get()
+
 	PrioritizedLeaderElection = env.RegisterBoolVar("PRIORITIZED_LEADER_ELECTION", true,
 		"If enabled, the default revision will steal leader locks from non-default revisions").Get()
 
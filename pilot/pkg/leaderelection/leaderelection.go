@@ -49,6 +49,8 @@ const (
 	GatewayDeploymentController = "istio-gateway-deployment-leader"
 	StatusController            = "istio-status-leader"
 	AnalyzeController           = "istio-analyze-leader"
+	// SidecarAutoscalingController updates the resource tiers of the sidecars.
+	SidecarAutoscalingController = "istio-sidecar-autoscaling-leader"
 )
 
 type LeaderElection struct {
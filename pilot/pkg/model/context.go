@@ -40,7 +40,6 @@ import (
 	"istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
-	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/network"
 	"istio.io/istio/pkg/spiffe"
@@ -91,6 +90,16 @@ type Environment struct {
 	clusterLocalServices ClusterLocalProvider
 
 	GatewayAPIController GatewayController
+
+	// NamespaceCORSPolicies provides the default CORS policies of the namespaces, if any.
+	NamespaceCORSPolicies NamespaceCORSPolicyProvider
+}
+
+// NamespaceCORSPolicyProvider provides the CORS policies of the virtual hosts of the namespaces whose routes don't
+// set their own.
+type NamespaceCORSPolicyProvider interface {
+	// DefaultCORSPolicies returns the default CORS policies, by namespace. The caller must not modify the returned map.
+	DefaultCORSPolicies() map[string]*networking.CorsPolicy
 }
 
 func (e *Environment) Mesh() *meshconfig.MeshConfig {
@@ -260,6 +269,13 @@ type Proxy struct {
 	// Metadata key-value pairs extending the Node identifier
 	Metadata *NodeMetadata
 
+	// FeatureGates are the config generation features enabled or disabled for the proxy by its metadata and
+	// labels, see SetFeatureGates. The gates not set use their default. Guarded by the proxy lock for the readers
+	// outside of the pushes to the proxy.
+	FeatureGates map[FeatureGate]bool
+	// featureGatesSource are the labels and metadata the feature gates were computed from.
+	featureGatesSource string
+
 	// the sidecarScope associated with the proxy
 	SidecarScope *SidecarScope
 
@@ -336,6 +352,25 @@ type WatchedResource struct {
 
 	// LastSent tracks the time of the generated push, to determine the time it takes the client to ack.
 	LastSent time.Time
+
+	// LastAckLatency is the time the client took to ack the last accepted push.
+	LastAckLatency time.Duration
+
+	// LastSize is the serialized size in bytes of the resources of the last sent response.
+	LastSize int
+
+	// LastResourceCount is the number of resources of the last sent response.
+	LastResourceCount int
+}
+
+// EndpointDrainStatus is the JSON payload istiod returns to a proxy that asked to drain its endpoints.
+type EndpointDrainStatus struct {
+	// Started is when istiod started to report the endpoints of the proxy as draining.
+	Started time.Time `json:"started"`
+	// Pending are the proxies watching the endpoints of the draining proxy that have not acked an update
+	// reporting them as draining yet, whichever istiod replica they are connected to. The replicas that have not
+	// seen the drain yet are listed as "istiod/<replica>".
+	Pending []string `json:"pending,omitempty"`
 }
 
 var istioVersionRegexp = regexp.MustCompile(`^([1-9]+)\.([0-9]+)(\.([0-9]+))?`)
@@ -542,6 +577,10 @@ type NodeMetadata struct {
 	// will be replaced with the gateway defined in the settings.
 	Network network.ID `json:"NETWORK,omitempty"`
 
+	// NodeName is the name of the Kubernetes node the workload runs on. When unset, Pilot uses the node
+	// of the workload's endpoints, if any.
+	NodeName string `json:"NODE_NAME,omitempty"`
+
 	// RequestedNetworkView specifies the networks that the proxy wants to see
 	RequestedNetworkView StringList `json:"REQUESTED_NETWORK_VIEW,omitempty"`
 
@@ -575,6 +614,10 @@ type NodeMetadata struct {
 	// Generator indicates the client wants to use a custom Generator plugin.
 	Generator string `json:"GENERATOR,omitempty"`
 
+	// ConfigExportNamespaces restricts the config exported to a client of the config-export generator to these
+	// namespaces. The client is still limited to the namespaces it is allowed to read.
+	ConfigExportNamespaces StringList `json:"CONFIG_EXPORT_NAMESPACES,omitempty"`
+
 	// DNSCapture indicates whether the workload has enabled dns capture
 	DNSCapture StringBool `json:"DNS_CAPTURE,omitempty"`
 
@@ -583,6 +626,18 @@ type NodeMetadata struct {
 	// This depends on DNSCapture.
 	DNSAutoAllocate StringBool `json:"DNS_AUTO_ALLOCATE,omitempty"`
 
+	// DisableAltVirtualHosts indicates whether the workload should only get the FQDN and IP domains in
+	// its virtual hosts, without the short name alternatives (foo, foo.ns, foo.ns.svc). This reduces the
+	// size of RDS in large meshes, for workloads that always use fully qualified names.
+	// It can be set with ISTIO_META_DISABLE_ALT_VIRTUAL_HOSTS in the proxyMetadata of ProxyConfig.
+	DisableAltVirtualHosts StringBool `json:"DISABLE_ALT_VIRTUAL_HOSTS,omitempty"`
+
+	// FeatureGates enables or disables config generation features for the proxy, as a comma separated list of
+	// gate=bool pairs, such as "DeltaClusters=false,VirtualHostSourceMetadata=true". It can be set with
+	// ISTIO_META_FEATURE_GATES in the proxyMetadata of ProxyConfig, and takes precedence over the feature gate
+	// labels of the workload. See FeatureGate.
+	FeatureGates string `json:"FEATURE_GATES,omitempty"`
+
 	// AutoRegister will enable auto registration of the connected endpoint to the service registry using the given WorkloadGroup name
 	AutoRegisterGroup string `json:"AUTO_REGISTER_GROUP,omitempty"`
 
@@ -786,13 +841,7 @@ func (node *Proxy) ServiceNode() string {
 func (node *Proxy) SetSidecarScope(ps *PushContext) {
 	sidecarScope := node.SidecarScope
 
-	if node.Type == SidecarProxy {
-		workloadLabels := labels.Collection{node.Metadata.Labels}
-		node.SidecarScope = ps.getSidecarScope(node, workloadLabels)
-	} else {
-		// Gateways should just have a default scope with egress: */*
-		node.SidecarScope = ps.getSidecarScope(node, nil)
-	}
+	node.SidecarScope = ps.proxySidecarScope(node)
 	node.PrevSidecarScope = sidecarScope
 	// Build CatchAllVirtualHost and cache it. This depends on sidecar scope config.
 	node.BuildCatchAllVirtualHost()
@@ -1093,6 +1142,174 @@ func (node *Proxy) CanBindToPort(bindTo bool, port uint32) bool {
 	return true
 }
 
+// PortRange is an inclusive range of ports.
+type PortRange struct {
+	Start int
+	End   int
+}
+
+// Contains returns true if the port is in the range.
+func (r PortRange) Contains(port int) bool {
+	return r.Start <= port && port <= r.End
+}
+
+// ParsePortRanges parses a comma separated list of ports and inclusive port ranges, such as "8080,10000-20000".
+func ParsePortRanges(value string) ([]PortRange, error) {
+	var ranges []PortRange
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		bounds := strings.SplitN(item, "-", 2)
+		start, err := strconv.ParseUint(strings.TrimSpace(bounds[0]), 10, 16)
+		if err != nil || start == 0 {
+			return nil, fmt.Errorf("invalid port range %q: invalid port %q", item, bounds[0])
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.ParseUint(strings.TrimSpace(bounds[1]), 10, 16); err != nil || end == 0 {
+				return nil, fmt.Errorf("invalid port range %q: invalid port %q", item, bounds[1])
+			}
+			if end < start {
+				return nil, fmt.Errorf("invalid port range %q: end is lower than start", item)
+			}
+		}
+		ranges = append(ranges, PortRange{Start: int(start), End: int(end)})
+	}
+	return ranges, nil
+}
+
+// InboundPortRanges returns the port ranges of the InboundPortRangesAnnotation of the pod of the proxy, whose inbound
+// traffic is passed through to the workload by a single filter chain. Invalid values are ignored.
+func (node *Proxy) InboundPortRanges() []PortRange {
+	if node == nil || node.Metadata == nil {
+		return nil
+	}
+	value, f := node.Metadata.Annotations[constants.InboundPortRangesAnnotation]
+	if !f {
+		return nil
+	}
+	ranges, err := ParsePortRanges(value)
+	if err != nil {
+		log.Debugf("ignoring %s of proxy %s: %v", constants.InboundPortRangesAnnotation, node.ID, err)
+		return nil
+	}
+	return ranges
+}
+
+// OutboundUDPPorts returns the ports of the OutboundUDPPortsAnnotation of the pod of the proxy, whose outbound UDP
+// traffic is redirected to the proxy. Invalid values are ignored.
+func (node *Proxy) OutboundUDPPorts() []int {
+	if node == nil || node.Metadata == nil {
+		return nil
+	}
+	value, f := node.Metadata.Annotations[constants.OutboundUDPPortsAnnotation]
+	if !f {
+		return nil
+	}
+	var ports []int
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		port, err := strconv.ParseUint(item, 10, 16)
+		if err != nil || port == 0 {
+			log.Debugf("ignoring %s of proxy %s: invalid port %q", constants.OutboundUDPPortsAnnotation, node.ID, item)
+			return nil
+		}
+		ports = append(ports, int(port))
+	}
+	return ports
+}
+
+// ParseForwardClientCertDetails parses a forward_client_cert_details mode, such as "SANITIZE_SET".
+func ParseForwardClientCertDetails(value string) (meshconfig.Topology_ForwardClientCertDetails, error) {
+	mode, f := meshconfig.Topology_ForwardClientCertDetails_value[strings.ToUpper(strings.TrimSpace(value))]
+	if !f || mode == int32(meshconfig.Topology_UNDEFINED) {
+		return meshconfig.Topology_UNDEFINED, fmt.Errorf("invalid forward client cert details %q", value)
+	}
+	return meshconfig.Topology_ForwardClientCertDetails(mode), nil
+}
+
+// ClientCertDetails are the details of the client certificate set in the x-forwarded-client-cert header.
+type ClientCertDetails struct {
+	Subject bool
+	Cert    bool
+	Chain   bool
+	DNS     bool
+	URI     bool
+}
+
+// ParseClientCertDetails parses a comma separated list of client certificate details, such as "Subject,URI".
+func ParseClientCertDetails(value string) (ClientCertDetails, error) {
+	var details ClientCertDetails
+	for _, item := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(item)) {
+		case "":
+		case "subject":
+			details.Subject = true
+		case "cert":
+			details.Cert = true
+		case "chain":
+			details.Chain = true
+		case "dns":
+			details.DNS = true
+		case "uri":
+			details.URI = true
+		default:
+			return ClientCertDetails{}, fmt.Errorf("invalid client cert detail %q", item)
+		}
+	}
+	return details, nil
+}
+
+// ForwardClientCertDetails returns the mode of the ForwardClientCertDetailsAnnotation of the pod of the proxy, or
+// UNDEFINED if it is not set or invalid.
+func (node *Proxy) ForwardClientCertDetails() meshconfig.Topology_ForwardClientCertDetails {
+	if node == nil || node.Metadata == nil {
+		return meshconfig.Topology_UNDEFINED
+	}
+	value, f := node.Metadata.Annotations[constants.ForwardClientCertDetailsAnnotation]
+	if !f {
+		return meshconfig.Topology_UNDEFINED
+	}
+	mode, err := ParseForwardClientCertDetails(value)
+	if err != nil {
+		log.Debugf("ignoring %s of proxy %s: %v", constants.ForwardClientCertDetailsAnnotation, node.ID, err)
+		return meshconfig.Topology_UNDEFINED
+	}
+	return mode
+}
+
+// SetCurrentClientCertDetails returns the details of the SetCurrentClientCertDetailsAnnotation of the pod of the
+// proxy, or nil if it is not set or invalid.
+func (node *Proxy) SetCurrentClientCertDetails() *ClientCertDetails {
+	if node == nil || node.Metadata == nil {
+		return nil
+	}
+	value, f := node.Metadata.Annotations[constants.SetCurrentClientCertDetailsAnnotation]
+	if !f {
+		return nil
+	}
+	details, err := ParseClientCertDetails(value)
+	if err != nil {
+		log.Debugf("ignoring %s of proxy %s: %v", constants.SetCurrentClientCertDetailsAnnotation, node.ID, err)
+		return nil
+	}
+	return &details
+}
+
+// OutboundUDPListenerPort returns the port iptables redirects the outbound UDP traffic to port to, on which the proxy
+// listens for it. The proxy cannot bind privileged ports, so they are shifted by OutboundUDPPrivilegedPortOffset.
+func OutboundUDPListenerPort(port int) int {
+	if port < 1024 {
+		return port + constants.OutboundUDPPrivilegedPortOffset
+	}
+	return port
+}
+
 // IsPrivilegedPort returns true if a given port is in the range 1-1023.
 func IsPrivilegedPort(port uint32) bool {
 	// check for 0 is important because:
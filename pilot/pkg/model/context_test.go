@@ -28,6 +28,7 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/serviceregistry/memory"
 	"istio.io/istio/pilot/pkg/serviceregistry/mock"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/pkg/util/protomarshal"
@@ -436,6 +437,90 @@ func TestParsePort(t *testing.T) {
 	}
 }
 
+func TestParsePortRanges(t *testing.T) {
+	cases := []struct {
+		value string
+		want  []model.PortRange
+		err   bool
+	}{
+		{value: "", want: nil},
+		{value: "8080", want: []model.PortRange{{Start: 8080, End: 8080}}},
+		{value: "8080, 10000-20000,", want: []model.PortRange{{Start: 8080, End: 8080}, {Start: 10000, End: 20000}}},
+		{value: "10000 - 10000", want: []model.PortRange{{Start: 10000, End: 10000}}},
+		{value: "20000-10000", err: true},
+		{value: "0-100", err: true},
+		{value: "10000-70000", err: true},
+		{value: "http", err: true},
+		{value: "10000-", err: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := model.ParsePortRanges(tt.value)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, want error %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutboundUDPPorts(t *testing.T) {
+	cases := []struct {
+		value string
+		want  []int
+	}{
+		{value: "514", want: []int{514}},
+		{value: "514, 5353,", want: []int{514, 5353}},
+		{value: "514,syslog", want: nil},
+		{value: "70000", want: nil},
+	}
+	for _, tt := range cases {
+		t.Run(tt.value, func(t *testing.T) {
+			node := &model.Proxy{Metadata: &model.NodeMetadata{
+				Annotations: map[string]string{constants.OutboundUDPPortsAnnotation: tt.value},
+			}}
+			if got := node.OutboundUDPPorts(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCertDetails(t *testing.T) {
+	node := &model.Proxy{Metadata: &model.NodeMetadata{}}
+	if got := node.ForwardClientCertDetails(); got != meshconfig.Topology_UNDEFINED {
+		t.Fatalf("expected UNDEFINED without annotation, got %v", got)
+	}
+	if got := node.SetCurrentClientCertDetails(); got != nil {
+		t.Fatalf("expected nil without annotation, got %v", got)
+	}
+
+	node.Metadata.Annotations = map[string]string{
+		constants.ForwardClientCertDetailsAnnotation:    "forward_only",
+		constants.SetCurrentClientCertDetailsAnnotation: "Subject, Chain,dns",
+	}
+	if got := node.ForwardClientCertDetails(); got != meshconfig.Topology_FORWARD_ONLY {
+		t.Fatalf("expected FORWARD_ONLY, got %v", got)
+	}
+	want := &model.ClientCertDetails{Subject: true, Chain: true, DNS: true}
+	if got := node.SetCurrentClientCertDetails(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	node.Metadata.Annotations = map[string]string{
+		constants.ForwardClientCertDetailsAnnotation:    "FORWARD",
+		constants.SetCurrentClientCertDetailsAnnotation: "Subject,Issuer",
+	}
+	if got := node.ForwardClientCertDetails(); got != meshconfig.Topology_UNDEFINED {
+		t.Fatalf("expected UNDEFINED for an invalid annotation, got %v", got)
+	}
+	if got := node.SetCurrentClientCertDetails(); got != nil {
+		t.Fatalf("expected nil for an invalid annotation, got %v", got)
+	}
+}
+
 func TestGetOrDefault(t *testing.T) {
 	assert.Equal(t, "a", model.GetOrDefault("a", "b"))
 	assert.Equal(t, "b", model.GetOrDefault("", "b"))
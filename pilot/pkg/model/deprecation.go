@@ -0,0 +1,127 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"sync"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/validation"
+	"istio.io/pkg/monitoring"
+)
+
+var (
+	featureTag = monitoring.MustCreateLabel("feature")
+
+	// deprecatedConfigs tracks the number of configs using each deprecated feature, so that operators can clean
+	// them up before an upgrade removes the feature.
+	deprecatedConfigs = monitoring.NewGauge(
+		"pilot_deprecated_configs",
+		"Number of configs using a deprecated feature.",
+		monitoring.WithLabels(typeTag, featureTag),
+	)
+
+	// recordedDeprecations are the labels of deprecatedConfigs recorded by the last push, reset to 0 when the
+	// deprecated feature is no longer used.
+	recordedDeprecations   = map[deprecationKey]bool{}
+	recordedDeprecationsMu sync.Mutex
+
+	// deprecationKinds are the kinds of configs checked for deprecated features.
+	deprecationKinds = []config.GroupVersionKind{
+		gvk.VirtualService,
+		gvk.DestinationRule,
+		gvk.EnvoyFilter,
+		gvk.Gateway,
+		gvk.Sidecar,
+		gvk.ServiceEntry,
+	}
+)
+
+func init() {
+	monitoring.MustRegister(deprecatedConfigs)
+}
+
+type deprecationKey struct {
+	kind    string
+	feature string
+}
+
+// DeprecatedUsage is a use of a deprecated feature by a config, or by the pod of a proxy.
+type DeprecatedUsage struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	validation.Deprecation
+}
+
+// Deprecations returns the uses of deprecated features by the configs of the push context.
+func (ps *PushContext) Deprecations() []DeprecatedUsage {
+	return ps.deprecations
+}
+
+// initDeprecations finds the uses of deprecated features by the configs. They are recorded in the
+// pilot_deprecated_configs metric by UpdateMetrics.
+func (ps *PushContext) initDeprecations(env *Environment) error {
+	ps.deprecations = nil
+	for _, kind := range deprecationKinds {
+		configs, err := env.List(kind, NamespaceAll)
+		if err != nil {
+			return err
+		}
+		for _, cfg := range configs {
+			for _, d := range validation.FindDeprecations(cfg) {
+				ps.deprecations = append(ps.deprecations, DeprecatedUsage{
+					Kind:        kind.Kind,
+					Namespace:   cfg.Namespace,
+					Name:        cfg.Name,
+					Deprecation: d,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// recordDeprecations records the number of configs using each deprecated feature. A config using a feature in
+// several places counts once.
+func (ps *PushContext) recordDeprecations() {
+	counts := map[deprecationKey]int{}
+	seen := map[DeprecatedUsage]bool{}
+	for _, u := range ps.deprecations {
+		key := DeprecatedUsage{Kind: u.Kind, Namespace: u.Namespace, Name: u.Name,
+			Deprecation: validation.Deprecation{Feature: u.Feature}}
+		if !seen[key] {
+			seen[key] = true
+			counts[deprecationKey{kind: u.Kind, feature: u.Feature}]++
+		}
+	}
+	recordDeprecations(counts)
+}
+
+func recordDeprecations(counts map[deprecationKey]int) {
+	recordedDeprecationsMu.Lock()
+	defer recordedDeprecationsMu.Unlock()
+	for k := range recordedDeprecations {
+		if _, f := counts[k]; !f {
+			deprecatedConfigs.With(typeTag.Value(k.kind), featureTag.Value(k.feature)).Record(0)
+			delete(recordedDeprecations, k)
+		}
+	}
+	for k, count := range counts {
+		deprecatedConfigs.With(typeTag.Value(k.kind), featureTag.Value(k.feature)).Record(float64(count))
+		recordedDeprecations[k] = true
+	}
+}
@@ -33,6 +33,52 @@ type EnvoyFilterWrapper struct {
 	Namespace        string
 	workloadSelector labels.Instance
 	Patches          map[networking.EnvoyFilter_ApplyTo][]*EnvoyFilterConfigPatchWrapper
+	// patchContexts are the patch contexts of the patches, by the type of object they apply to. They are
+	// precomputed for the wrappers returned by PushContext.EnvoyFilters, see HasPatches.
+	patchContexts map[networking.EnvoyFilter_ApplyTo]patchContextSet
+}
+
+// patchContextSet is a bit set of patch contexts.
+type patchContextSet uint8
+
+// allPatchContexts is the set of the patch contexts matched by a patch with the ANY context.
+const allPatchContexts = ^patchContextSet(0)
+
+func computePatchContexts(patches map[networking.EnvoyFilter_ApplyTo][]*EnvoyFilterConfigPatchWrapper) map[networking.EnvoyFilter_ApplyTo]patchContextSet {
+	out := make(map[networking.EnvoyFilter_ApplyTo]patchContextSet, len(patches))
+	for applyTo, cps := range patches {
+		var contexts patchContextSet
+		for _, cp := range cps {
+			if cp.Match.GetContext() == networking.EnvoyFilter_ANY {
+				contexts = allPatchContexts
+				break
+			}
+			contexts |= 1 << uint(cp.Match.GetContext())
+		}
+		if contexts != 0 {
+			out[applyTo] = contexts
+		}
+	}
+	return out
+}
+
+// HasPatches returns whether any patch of the given object types may apply in the patch context. When it
+// returns false, patching these objects is a no-op, so callers can skip walking them altogether.
+func (efw *EnvoyFilterWrapper) HasPatches(pctx networking.EnvoyFilter_PatchContext, applyTo ...networking.EnvoyFilter_ApplyTo) bool {
+	if efw == nil {
+		return false
+	}
+	contexts := efw.patchContexts
+	if contexts == nil {
+		// The wrapper was not built by PushContext.EnvoyFilters.
+		contexts = computePatchContexts(efw.Patches)
+	}
+	for _, t := range applyTo {
+		if contexts[t]&(1<<uint(pctx)) != 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // EnvoyFilterConfigPatchWrapper is a wrapper over the EnvoyFilter ConfigPatch api object
@@ -50,6 +96,10 @@ type EnvoyFilterConfigPatchWrapper struct {
 	ProxyPrefixMatch string
 	Name             string
 	Namespace        string
+	// Index of the patch in the config patches of the EnvoyFilter.
+	Index int
+	// Generation of the EnvoyFilter.
+	Generation int64
 }
 
 // wellKnownVersions defines a mapping of well known regex matches to prefix matches
@@ -84,7 +134,7 @@ func convertToEnvoyFilterWrapper(local *config.Config) *EnvoyFilterWrapper {
 		out.workloadSelector = localEnvoyFilter.WorkloadSelector.Labels
 	}
 	out.Patches = make(map[networking.EnvoyFilter_ApplyTo][]*EnvoyFilterConfigPatchWrapper)
-	for _, cp := range localEnvoyFilter.ConfigPatches {
+	for i, cp := range localEnvoyFilter.ConfigPatches {
 		if cp.Patch == nil {
 			// Should be caught by validation, but sometimes its disabled and we don't want to crash
 			// as a result.
@@ -92,11 +142,13 @@ func convertToEnvoyFilterWrapper(local *config.Config) *EnvoyFilterWrapper {
 			continue
 		}
 		cpw := &EnvoyFilterConfigPatchWrapper{
-			Name:      local.Name,
-			Namespace: local.Namespace,
-			ApplyTo:   cp.ApplyTo,
-			Match:     cp.Match,
-			Operation: cp.Patch.Operation,
+			Name:       local.Name,
+			Namespace:  local.Namespace,
+			ApplyTo:    cp.ApplyTo,
+			Match:      cp.Match,
+			Operation:  cp.Patch.Operation,
+			Index:      i,
+			Generation: local.Generation,
 		}
 		var err error
 		// Use non-strict building to avoid issues where EnvoyFilter is valid but meant
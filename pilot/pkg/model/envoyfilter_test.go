@@ -133,3 +133,47 @@ func TestConvertEnvoyFilter(t *testing.T) {
 		t.Errorf("expected name %s got %s and namespace %s got %s", "test", cfilter.Name, "testns", cfilter.Namespace)
 	}
 }
+
+func TestEnvoyFilterWrapperHasPatches(t *testing.T) {
+	patch := func(ctx networking.EnvoyFilter_PatchContext) *EnvoyFilterConfigPatchWrapper {
+		return &EnvoyFilterConfigPatchWrapper{Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{Context: ctx}}
+	}
+	efw := &EnvoyFilterWrapper{
+		Patches: map[networking.EnvoyFilter_ApplyTo][]*EnvoyFilterConfigPatchWrapper{
+			networking.EnvoyFilter_CLUSTER:      {patch(networking.EnvoyFilter_SIDECAR_OUTBOUND)},
+			networking.EnvoyFilter_VIRTUAL_HOST: {patch(networking.EnvoyFilter_GATEWAY), patch(networking.EnvoyFilter_ANY)},
+		},
+	}
+	cases := []struct {
+		name    string
+		ctx     networking.EnvoyFilter_PatchContext
+		applyTo []networking.EnvoyFilter_ApplyTo
+		want    bool
+	}{
+		{"matching context", networking.EnvoyFilter_SIDECAR_OUTBOUND, []networking.EnvoyFilter_ApplyTo{networking.EnvoyFilter_CLUSTER}, true},
+		{"other context", networking.EnvoyFilter_GATEWAY, []networking.EnvoyFilter_ApplyTo{networking.EnvoyFilter_CLUSTER}, false},
+		{"any context", networking.EnvoyFilter_SIDECAR_INBOUND, []networking.EnvoyFilter_ApplyTo{networking.EnvoyFilter_VIRTUAL_HOST}, true},
+		{"no patches of type", networking.EnvoyFilter_SIDECAR_OUTBOUND, []networking.EnvoyFilter_ApplyTo{networking.EnvoyFilter_LISTENER}, false},
+		{
+			"one of the types",
+			networking.EnvoyFilter_GATEWAY,
+			[]networking.EnvoyFilter_ApplyTo{networking.EnvoyFilter_ROUTE_CONFIGURATION, networking.EnvoyFilter_VIRTUAL_HOST},
+			true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := efw.HasPatches(tt.ctx, tt.applyTo...); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			precomputed := &EnvoyFilterWrapper{Patches: efw.Patches, patchContexts: computePatchContexts(efw.Patches)}
+			if got := precomputed.HasPatches(tt.ctx, tt.applyTo...); got != tt.want {
+				t.Errorf("got %v with precomputed contexts, want %v", got, tt.want)
+			}
+		})
+	}
+	var nilWrapper *EnvoyFilterWrapper
+	if nilWrapper.HasPatches(networking.EnvoyFilter_ANY, networking.EnvoyFilter_CLUSTER) {
+		t.Errorf("expected a nil wrapper to have no patches")
+	}
+}
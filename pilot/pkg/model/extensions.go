@@ -20,7 +20,9 @@ import (
 	"time"
 
 	envoyCoreV3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoyExtProcV3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	envoyWasmFilterV3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	envoyNetworkWasmFilterV3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
 	envoyExtensionsWasmV3 "github.com/envoyproxy/go-control-plane/envoy/extensions/wasm/v3"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -29,6 +31,7 @@ import (
 	extensions "istio.io/api/extensions/v1alpha1"
 	"istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/util/gogoprotomarshal"
 )
 
@@ -43,6 +46,7 @@ type WasmPluginWrapper struct {
 
 	Name      string
 	Namespace string
+	Type      networking.WasmPluginType
 
 	ExtensionConfiguration *envoyCoreV3.TypedExtensionConfig
 }
@@ -53,6 +57,18 @@ func convertToWasmPluginWrapper(plugin *config.Config) *WasmPluginWrapper {
 	if wasmPlugin, ok = plugin.Spec.(*extensions.WasmPlugin); !ok {
 		return nil
 	}
+	pluginType, err := networking.ParseWasmPluginType(plugin.Annotations[constants.WasmPluginTypeAnnotation])
+	if err != nil {
+		log.Warnf("wasmplugin %v/%v discarded due to invalid %s: %s", plugin.Namespace, plugin.Name, constants.WasmPluginTypeAnnotation, err)
+		return nil
+	}
+	if value, f := plugin.Annotations[constants.ExtProcAnnotation]; f {
+		if pluginType != networking.WasmPluginTypeHTTP {
+			log.Warnf("wasmplugin %v/%v discarded: %s requires the HTTP type", plugin.Namespace, plugin.Name, constants.ExtProcAnnotation)
+			return nil
+		}
+		return convertToExtProcWrapper(plugin, wasmPlugin, value)
+	}
 
 	cfg := &anypb.Any{}
 	if wasmPlugin.PluginConfig != nil && len(wasmPlugin.PluginConfig.Fields) > 0 {
@@ -77,14 +93,18 @@ func convertToWasmPluginWrapper(plugin *config.Config) *WasmPluginWrapper {
 	}
 
 	datasource := buildDataSource(u, wasmPlugin)
-	typedConfig, err := anypb.New(&envoyWasmFilterV3.Wasm{
-		Config: &envoyExtensionsWasmV3.PluginConfig{
-			Name:          plugin.Namespace + "." + plugin.Name,
-			RootId:        wasmPlugin.PluginName,
-			Configuration: cfg,
-			Vm:            buildVMConfig(datasource, wasmPlugin.VmConfig),
-		},
-	})
+	pluginConfig := &envoyExtensionsWasmV3.PluginConfig{
+		Name:          plugin.Namespace + "." + plugin.Name,
+		RootId:        wasmPlugin.PluginName,
+		Configuration: cfg,
+		Vm:            buildVMConfig(datasource, wasmPlugin.VmConfig),
+	}
+	var typedConfig *anypb.Any
+	if pluginType == networking.WasmPluginTypeNetwork {
+		typedConfig, err = anypb.New(&envoyNetworkWasmFilterV3.Wasm{Config: pluginConfig})
+	} else {
+		typedConfig, err = anypb.New(&envoyWasmFilterV3.Wasm{Config: pluginConfig})
+	}
 	if err != nil {
 		log.Warnf("WasmPlugin %s/%s failed to marshal to TypedExtensionConfig: %s", plugin.Namespace, plugin.Name, err)
 		return nil
@@ -96,11 +116,50 @@ func convertToWasmPluginWrapper(plugin *config.Config) *WasmPluginWrapper {
 	return &WasmPluginWrapper{
 		Name:                   plugin.Name,
 		Namespace:              plugin.Namespace,
+		Type:                   pluginType,
 		WasmPlugin:             *wasmPlugin,
 		ExtensionConfiguration: ec,
 	}
 }
 
+// convertToExtProcWrapper builds the ext_proc filter of a WasmPlugin declaring an external processor.
+func convertToExtProcWrapper(plugin *config.Config, wasmPlugin *extensions.WasmPlugin, value string) *WasmPluginWrapper {
+	ep, err := networking.ParseExtProc(value)
+	if err != nil {
+		log.Warnf("wasmplugin %v/%v discarded due to invalid %s: %s", plugin.Namespace, plugin.Name, constants.ExtProcAnnotation, err)
+		return nil
+	}
+	grpcService := &envoyCoreV3.GrpcService{
+		TargetSpecifier: &envoyCoreV3.GrpcService_EnvoyGrpc_{
+			EnvoyGrpc: &envoyCoreV3.GrpcService_EnvoyGrpc{
+				ClusterName: BuildSubsetKey(TrafficDirectionOutbound, "", ep.Service, ep.Port),
+			},
+		},
+	}
+	filter := &envoyExtProcV3.ExternalProcessor{
+		GrpcService:      grpcService,
+		FailureModeAllow: ep.FailOpen,
+		ProcessingMode:   ep.ProcessingMode,
+	}
+	if ep.Timeout > 0 {
+		filter.MessageTimeout = durationpb.New(ep.Timeout)
+	}
+	typedConfig, err := anypb.New(filter)
+	if err != nil {
+		log.Warnf("WasmPlugin %s/%s failed to marshal to TypedExtensionConfig: %s", plugin.Namespace, plugin.Name, err)
+		return nil
+	}
+	return &WasmPluginWrapper{
+		Name:       plugin.Name,
+		Namespace:  plugin.Namespace,
+		WasmPlugin: *wasmPlugin,
+		ExtensionConfiguration: &envoyCoreV3.TypedExtensionConfig{
+			Name:        networking.ExtProcFilterName(plugin.Namespace + "/" + plugin.Name),
+			TypedConfig: typedConfig,
+		},
+	}
+}
+
 func buildDataSource(u *url.URL, wasmPlugin *extensions.WasmPlugin) *envoyCoreV3.AsyncDataSource {
 	if u.Scheme == fileScheme {
 		return &envoyCoreV3.AsyncDataSource{
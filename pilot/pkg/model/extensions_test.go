@@ -20,10 +20,15 @@ import (
 	"time"
 
 	envoyCoreV3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoyExtProcV3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	envoyNetworkWasmFilterV3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
 	envoyExtensionsWasmV3 "github.com/envoyproxy/go-control-plane/envoy/extensions/wasm/v3"
 	"google.golang.org/protobuf/types/known/durationpb"
 
 	extensions "istio.io/api/extensions/v1alpha1"
+	"istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/test/util/assert"
 )
 
@@ -128,3 +133,76 @@ func TestBuildVMConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertToExtProcWrapper(t *testing.T) {
+	plugin := &config.Config{
+		Meta: config.Meta{
+			Name:      "ext-proc",
+			Namespace: "istio-system",
+			Annotations: map[string]string{
+				constants.ExtProcAnnotation: `{"service": "ext-proc.foo.svc.cluster.local", "port": 9000, "timeout": "100ms",
+					"processingMode": {"requestBodyMode": "BUFFERED"}}`,
+			},
+		},
+		Spec: &extensions.WasmPlugin{Phase: extensions.PluginPhase_AUTHZ},
+	}
+	wrapper := convertToWasmPluginWrapper(plugin)
+	if wrapper == nil {
+		t.Fatal("expected the external processor to be converted")
+	}
+	assert.Equal(t, wrapper.Phase, extensions.PluginPhase_AUTHZ)
+	assert.Equal(t, wrapper.ExtensionConfiguration.Name, "istio-system.ext-proc")
+
+	got := &envoyExtProcV3.ExternalProcessor{}
+	if err := wrapper.ExtensionConfiguration.TypedConfig.UnmarshalTo(got); err != nil {
+		t.Fatal(err)
+	}
+	want := &envoyExtProcV3.ExternalProcessor{
+		GrpcService: &envoyCoreV3.GrpcService{
+			TargetSpecifier: &envoyCoreV3.GrpcService_EnvoyGrpc_{
+				EnvoyGrpc: &envoyCoreV3.GrpcService_EnvoyGrpc{ClusterName: "outbound|9000||ext-proc.foo.svc.cluster.local"},
+			},
+		},
+		ProcessingMode: &envoyExtProcV3.ProcessingMode{RequestBodyMode: envoyExtProcV3.ProcessingMode_BUFFERED},
+		MessageTimeout: durationpb.New(100 * time.Millisecond),
+	}
+	assert.Equal(t, got, want)
+
+	// An invalid processor discards the plugin.
+	plugin.Annotations[constants.ExtProcAnnotation] = `{"service": "ext-proc.foo.svc.cluster.local"}`
+	if wrapper := convertToWasmPluginWrapper(plugin); wrapper != nil {
+		t.Fatalf("expected the plugin to be discarded, got %v", wrapper)
+	}
+}
+
+func TestConvertToNetworkWasmPluginWrapper(t *testing.T) {
+	plugin := &config.Config{
+		Meta: config.Meta{
+			Name:        "tcp-plugin",
+			Namespace:   "istio-system",
+			Annotations: map[string]string{constants.WasmPluginTypeAnnotation: "NETWORK"},
+		},
+		Spec: &extensions.WasmPlugin{Url: "oci://hub/tcp-plugin:v1", PluginName: "root"},
+	}
+	wrapper := convertToWasmPluginWrapper(plugin)
+	if wrapper == nil {
+		t.Fatal("expected the network plugin to be converted")
+	}
+	assert.Equal(t, wrapper.Type, networking.WasmPluginTypeNetwork)
+	got := &envoyNetworkWasmFilterV3.Wasm{}
+	if err := wrapper.ExtensionConfiguration.TypedConfig.UnmarshalTo(got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, got.Config.RootId, "root")
+
+	// External processors are HTTP filters.
+	plugin.Annotations[constants.ExtProcAnnotation] = `{"service": "ext-proc.foo.svc.cluster.local", "port": 9000}`
+	if wrapper := convertToWasmPluginWrapper(plugin); wrapper != nil {
+		t.Fatalf("expected the plugin to be discarded, got %v", wrapper)
+	}
+
+	plugin.Annotations = map[string]string{constants.WasmPluginTypeAnnotation: "UDP"}
+	if wrapper := convertToWasmPluginWrapper(plugin); wrapper != nil {
+		t.Fatalf("expected the plugin to be discarded, got %v", wrapper)
+	}
+}
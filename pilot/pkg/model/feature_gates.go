@@ -0,0 +1,136 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/config/constants"
+)
+
+// FeatureGate is a config generation feature that can be enabled or disabled for a subset of the proxies, with the
+// FEATURE_GATES node metadata or the feature gate labels of their workloads. This allows rolling out a risky change
+// to part of the data plane, and comparing it with the rest, before changing the default for the whole mesh.
+type FeatureGate string
+
+const (
+	// FeatureGateDeltaClusters builds only the clusters affected by a config change in the CDS pushes to the
+	// proxies, instead of all their clusters. Enabled by default.
+	FeatureGateDeltaClusters FeatureGate = "DeltaClusters"
+	// FeatureGateRouteCollapse merges the gateway virtual hosts with the same routes. Defaults to
+	// PILOT_ENABLE_ROUTE_COLLAPSE_OPTIMIZATION.
+	FeatureGateRouteCollapse FeatureGate = "RouteCollapse"
+	// FeatureGateStrictVirtualHostDomains withholds the outbound routes with conflicting domains. Defaults to
+	// PILOT_STRICT_VIRTUAL_HOST_DOMAINS.
+	FeatureGateStrictVirtualHostDomains FeatureGate = "StrictVirtualHostDomains"
+	// FeatureGateVirtualHostSourceMetadata adds the registry source of the services to the metadata of their
+	// routes. Defaults to PILOT_VIRTUAL_HOST_SOURCE_METADATA.
+	FeatureGateVirtualHostSourceMetadata FeatureGate = "VirtualHostSourceMetadata"
+)
+
+// featureGateDefaults are the defaults of the feature gates, for the proxies which do not set them. They are
+// functions, as the features they default to may be changed by tests.
+var featureGateDefaults = map[FeatureGate]func() bool{
+	FeatureGateDeltaClusters:             func() bool { return true },
+	FeatureGateRouteCollapse:             func() bool { return features.EnableRouteCollapse },
+	FeatureGateStrictVirtualHostDomains:  func() bool { return features.StrictVirtualHostDomains },
+	FeatureGateVirtualHostSourceMetadata: func() bool { return features.EnableVirtualHostSourceMetadata },
+}
+
+// SetFeatureGates computes the feature gates of the proxy from the feature gate labels of its workload, overridden
+// by its FEATURE_GATES metadata. Unknown gates and invalid values are ignored. This is called on every push, so the
+// gates are only computed again, and the ignored ones logged again, when the labels or the metadata change.
+func (node *Proxy) SetFeatureGates() {
+	var labels []string
+	source := ""
+	if node.Metadata != nil {
+		for k, v := range node.Metadata.Labels {
+			if strings.HasPrefix(k, constants.FeatureGateLabelPrefix) {
+				labels = append(labels, strings.TrimPrefix(k, constants.FeatureGateLabelPrefix)+"="+v)
+			}
+		}
+		sort.Strings(labels)
+		source = strings.Join(labels, ",") + ";" + node.Metadata.FeatureGates
+	}
+	if source == node.featureGatesSource {
+		return
+	}
+
+	var gates map[FeatureGate]bool
+	set := func(name, value, source string) {
+		gate := FeatureGate(strings.TrimSpace(name))
+		if _, f := featureGateDefaults[gate]; !f {
+			log.Warnf("ignoring unknown feature gate %q in the %s of %s", gate, source, node.ID)
+			return
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			log.Warnf("ignoring invalid value %q of feature gate %s in the %s of %s", value, gate, source, node.ID)
+			return
+		}
+		if gates == nil {
+			gates = map[FeatureGate]bool{}
+		}
+		gates[gate] = enabled
+	}
+	if node.Metadata != nil {
+		for _, kv := range labels {
+			parts := strings.SplitN(kv, "=", 2)
+			set(parts[0], parts[1], "labels")
+		}
+		for _, kv := range strings.Split(node.Metadata.FeatureGates, ",") {
+			if strings.TrimSpace(kv) == "" {
+				continue
+			}
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				log.Warnf("ignoring invalid feature gate %q in the metadata of %s", kv, node.ID)
+				continue
+			}
+			set(parts[0], parts[1], "metadata")
+		}
+	}
+	node.Lock()
+	node.FeatureGates = gates
+	node.featureGatesSource = source
+	node.Unlock()
+}
+
+// FeatureEnabled returns whether the feature gate is enabled for the proxy.
+func (node *Proxy) FeatureEnabled(gate FeatureGate) bool {
+	if node != nil {
+		if enabled, f := node.FeatureGates[gate]; f {
+			return enabled
+		}
+	}
+	return featureGateDefaults[gate]()
+}
+
+// FeatureGatesKey returns the feature gates set for the proxy in a canonical form, such as
+// "DeltaClusters=false,RouteCollapse=true", for the caches of the config which depends on them.
+func (node *Proxy) FeatureGatesKey() string {
+	if node == nil || len(node.FeatureGates) == 0 {
+		return ""
+	}
+	gates := make([]string, 0, len(node.FeatureGates))
+	for gate, enabled := range node.FeatureGates {
+		gates = append(gates, string(gate)+"="+strconv.FormatBool(enabled))
+	}
+	sort.Strings(gates)
+	return strings.Join(gates, ",")
+}
@@ -0,0 +1,76 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/features"
+)
+
+func TestFeatureGates(t *testing.T) {
+	defaultValue := features.StrictVirtualHostDomains
+	defer func() { features.StrictVirtualHostDomains = defaultValue }()
+	features.StrictVirtualHostDomains = true
+
+	proxy := &Proxy{ID: "app.default", Metadata: &NodeMetadata{
+		Labels: map[string]string{
+			"feature-gates.experimental.istio.io/DeltaClusters":             "false",
+			"feature-gates.experimental.istio.io/RouteCollapse":             "false",
+			"feature-gates.experimental.istio.io/Unknown":                   "true",
+			"feature-gates.experimental.istio.io/VirtualHostSourceMetadata": "maybe",
+		},
+		FeatureGates: "RouteCollapse=true, StrictVirtualHostDomains=false,invalid",
+	}}
+	proxy.SetFeatureGates()
+
+	for gate, want := range map[FeatureGate]bool{
+		FeatureGateDeltaClusters: false,
+		// The metadata takes precedence over the labels.
+		FeatureGateRouteCollapse:            true,
+		FeatureGateStrictVirtualHostDomains: false,
+		// Invalid values are ignored.
+		FeatureGateVirtualHostSourceMetadata: features.EnableVirtualHostSourceMetadata,
+	} {
+		if got := proxy.FeatureEnabled(gate); got != want {
+			t.Errorf("%s: got %v, want %v", gate, got, want)
+		}
+	}
+	if got, want := proxy.FeatureGatesKey(), "DeltaClusters=false,RouteCollapse=true,StrictVirtualHostDomains=false"; got != want {
+		t.Errorf("got key %q, want %q", got, want)
+	}
+
+	// Proxies without feature gates use the defaults.
+	var unset *Proxy
+	if !unset.FeatureEnabled(FeatureGateDeltaClusters) || !unset.FeatureEnabled(FeatureGateStrictVirtualHostDomains) {
+		t.Error("expected the defaults of the feature gates")
+	}
+	if key := (&Proxy{}).FeatureGatesKey(); key != "" {
+		t.Errorf("expected no key without feature gates, got %q", key)
+	}
+
+	// The gates are computed again only when the labels or the metadata change.
+	computed := reflect.ValueOf(proxy.FeatureGates).Pointer()
+	proxy.SetFeatureGates()
+	if reflect.ValueOf(proxy.FeatureGates).Pointer() != computed {
+		t.Error("expected the feature gates not to be computed again")
+	}
+	proxy.Metadata.FeatureGates = "RouteCollapse=false"
+	proxy.SetFeatureGates()
+	if proxy.FeatureEnabled(FeatureGateRouteCollapse) || !proxy.FeatureEnabled(FeatureGateStrictVirtualHostDomains) {
+		t.Errorf("expected the feature gates of the new metadata, got %v", proxy.FeatureGates)
+	}
+}
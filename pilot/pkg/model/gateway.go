@@ -16,6 +16,7 @@ package model
 
 import (
 	"fmt"
+	"hash/fnv"
 	"sort"
 	"strconv"
 	"strings"
@@ -321,7 +322,7 @@ func MergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 		}
 	}
 
-	return &MergedGateway{
+	merged := &MergedGateway{
 		MergedServers:                   mergedServers,
 		MergedQUICTransportServers:      mergedQUICServers,
 		ServerPorts:                     serverPorts,
@@ -330,9 +331,83 @@ func MergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 		ServersByRouteName:              serversByRouteName,
 		HTTP3AdvertisingRoutes:          http3AdvertisingRoutes,
 		ContainsAutoPassthroughGateways: autoPassthrough,
-		PortMap:                         getTargetPortMap(serversByRouteName),
 		VerifiedCertificateReferences:   verifiedCertificateReferences,
 	}
+	if features.GatewayRouteShards > 1 {
+		merged.shardHTTPSServers(features.GatewayRouteShards)
+	}
+	merged.PortMap = getTargetPortMap(merged.ServersByRouteName)
+	return merged
+}
+
+// shardHTTPSServers splits the HTTPS servers terminating TLS that share a route configuration, such as the servers of
+// a gateway with the same port, into the given number of shards, by hashing their certificates. Each shard has its own
+// route configuration, so a change to a host only affects the route configuration of its shard. The servers already
+// have SNI filter chains of their own, which are left as they are. The servers with the same certificate are kept in
+// the same shard, as clients may send the requests of any host of the certificate on a connection established for
+// one of them, which must be served by the same route configuration.
+func (mg *MergedGateway) shardHTTPSServers(shards int) {
+	routeNames := make([]string, 0, len(mg.ServersByRouteName))
+	routeCount := make(map[*networking.Server]int)
+	for routeName, servers := range mg.ServersByRouteName {
+		routeNames = append(routeNames, routeName)
+		for _, s := range servers {
+			routeCount[s]++
+		}
+	}
+	sort.Strings(routeNames)
+
+	for _, routeName := range routeNames {
+		servers := mg.ServersByRouteName[routeName]
+		if len(servers) < 2 {
+			continue
+		}
+		shardServers := make([][]*networking.Server, shards)
+		shardable := true
+		for _, s := range servers {
+			// A server resolved to several ports has several route names, which would need shards of their own.
+			if routeCount[s] != 1 || mg.TLSServerInfo[s] == nil || !gateway.IsTLSServer(s) || !gateway.IsHTTPServer(s) {
+				shardable = false
+				break
+			}
+			hash := fnv.New32a()
+			_, _ = hash.Write([]byte(serverCertificateKey(s)))
+			shard := int(hash.Sum32() % uint32(shards))
+			shardServers[shard] = append(shardServers[shard], s)
+		}
+		nonEmpty := 0
+		for _, servers := range shardServers {
+			if len(servers) > 0 {
+				nonEmpty++
+			}
+		}
+		if !shardable || nonEmpty < 2 {
+			continue
+		}
+		for i, servers := range shardServers {
+			if len(servers) == 0 {
+				continue
+			}
+			shardRouteName := routeName + ".shard-" + strconv.Itoa(i)
+			mg.ServersByRouteName[shardRouteName] = servers
+			for _, s := range servers {
+				mg.TLSServerInfo[s].RouteName = shardRouteName
+			}
+			if _, f := mg.HTTP3AdvertisingRoutes[routeName]; f {
+				mg.HTTP3AdvertisingRoutes[shardRouteName] = struct{}{}
+			}
+		}
+		delete(mg.ServersByRouteName, routeName)
+		delete(mg.HTTP3AdvertisingRoutes, routeName)
+	}
+}
+
+// serverCertificateKey identifies the certificate of a server terminating TLS.
+func serverCertificateKey(s *networking.Server) string {
+	if s.Tls.CredentialName != "" {
+		return "credential/" + s.Tls.CredentialName
+	}
+	return "file/" + s.Tls.ServerCertificate
 }
 
 func udpSupportedPort(number uint32, instances []*ServiceInstance) bool {
@@ -16,9 +16,11 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pkg/config"
 )
 
@@ -160,6 +162,82 @@ func TestMergeGateways(t *testing.T) {
 	}
 }
 
+func TestMergeGatewaysShardHTTPSServers(t *testing.T) {
+	defaultValue := features.GatewayRouteShards
+	features.GatewayRouteShards = 4
+	defer func() { features.GatewayRouteShards = defaultValue }()
+
+	// The servers of a gateway on the same port share a route configuration. Each pair of servers shares a
+	// certificate.
+	gwSharded := makeConfig("sharded", "default", "", "https", "HTTPS", 443, "ingressgateway", "", networking.ServerTLSSettings_SIMPLE)
+	gwSharded.Spec.(*networking.Gateway).Servers = nil
+	for i := 0; i < 20; i++ {
+		gwSharded.Spec.(*networking.Gateway).Servers = append(gwSharded.Spec.(*networking.Gateway).Servers, &networking.Server{
+			Hosts: []string{fmt.Sprintf("./host-%d.example.com", i)},
+			Port:  &networking.Port{Name: "https", Number: 443, Protocol: "HTTPS"},
+			Tls: &networking.ServerTLSSettings{
+				Mode:           networking.ServerTLSSettings_SIMPLE,
+				CredentialName: fmt.Sprintf("cert-%d", i/2),
+			},
+		})
+	}
+	// A single server is not sharded, as its hosts share its certificate.
+	gwSingle := makeConfig("single", "default", "", "https", "HTTPS", 8443, "ingressgateway", "", networking.ServerTLSSettings_SIMPLE)
+	gwSingle.Spec.(*networking.Gateway).Servers[0].Hosts = []string{"./a.example.com", "./b.example.com", "./c.example.com"}
+	gwHTTP := makeConfig("http", "default", "", "http", "HTTP", 80, "ingressgateway", "", networking.ServerTLSSettings_SIMPLE)
+	gwHTTP.Spec.(*networking.Gateway).Servers[0].Hosts = []string{"./a.example.com", "./b.example.com"}
+	gwHTTP.Spec.(*networking.Gateway).Servers[0].Tls = nil
+
+	instances := []gatewayWithInstances{{gwSharded, true, nil}, {gwSingle, true, nil}, {gwHTTP, true, nil}}
+	mgw := MergeGateways(instances, &Proxy{}, nil)
+
+	if _, f := mgw.ServersByRouteName["https.443.https.sharded.default"]; f {
+		t.Fatalf("expected the route of the sharded servers to be replaced by its shards")
+	}
+	if servers := mgw.ServersByRouteName["https.8443.https.single.default"]; len(servers) != 1 || len(servers[0].Hosts) != 3 {
+		t.Fatalf("expected the single server not to be sharded, got %v", servers)
+	}
+	if servers := mgw.ServersByRouteName["http.80"]; len(servers) != 1 || len(servers[0].Hosts) != 2 {
+		t.Fatalf("expected the plaintext server not to be sharded")
+	}
+
+	servers := mgw.MergedServers[ServerPort{Number: 443, Protocol: "HTTPS"}].Servers
+	if len(servers) != 20 {
+		t.Fatalf("expected the servers to be kept, got %d", len(servers))
+	}
+	shardByCertificate := map[string]string{}
+	shards := map[string]struct{}{}
+	for _, server := range servers {
+		info := mgw.TLSServerInfo[server]
+		if info == nil || !strings.HasPrefix(info.RouteName, "https.443.https.sharded.default.shard-") {
+			t.Fatalf("unexpected TLS server info %v", info)
+		}
+		shards[info.RouteName] = struct{}{}
+		found := false
+		for _, s := range mgw.ServersByRouteName[info.RouteName] {
+			found = found || s == server
+		}
+		if !found {
+			t.Fatalf("expected route %s to serve server %v", info.RouteName, server.Hosts)
+		}
+		if other, f := shardByCertificate[server.Tls.CredentialName]; f && other != info.RouteName {
+			t.Fatalf("certificate %s is in shards %s and %s", server.Tls.CredentialName, other, info.RouteName)
+		}
+		shardByCertificate[server.Tls.CredentialName] = info.RouteName
+	}
+	if len(shards) < 2 || len(shards) > 4 {
+		t.Fatalf("expected 2 to 4 shards, got %d", len(shards))
+	}
+
+	// Sharding is stable, a server stays in the same shard.
+	again := MergeGateways(instances, &Proxy{}, nil)
+	for _, server := range again.MergedServers[ServerPort{Number: 443, Protocol: "HTTPS"}].Servers {
+		if got := again.TLSServerInfo[server].RouteName; got != shardByCertificate[server.Tls.CredentialName] {
+			t.Fatalf("server %v moved from shard %s to %s", server.Hosts, shardByCertificate[server.Tls.CredentialName], got)
+		}
+	}
+}
+
 func makeConfig(name, namespace, host, portName, portProtocol string, portNumber uint32, gw string, bind string,
 	mode networking.ServerTLSSettings_TLSmode) config.Config {
 	c := config.Config{
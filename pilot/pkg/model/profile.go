@@ -0,0 +1,77 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// GenerationProfile collects the time spent by each configuration builder generating the configuration of a proxy.
+// It is only set when profiling the generation for debugging; a nil profile records nothing. It is safe for
+// concurrent use, as some builders run in parallel.
+type GenerationProfile struct {
+	mu        sync.Mutex
+	builders  []string
+	durations map[string]time.Duration
+	calls     map[string]int
+}
+
+// BuilderTime is the time spent by a configuration builder, over all its calls.
+type BuilderTime struct {
+	Builder  string
+	Duration time.Duration
+	Calls    int
+}
+
+// NewGenerationProfile returns an empty profile.
+func NewGenerationProfile() *GenerationProfile {
+	return &GenerationProfile{
+		durations: map[string]time.Duration{},
+		calls:     map[string]int{},
+	}
+}
+
+// Time starts timing a call of the builder and returns the function that records it.
+func (p *GenerationProfile) Time(builder string) func() {
+	if p == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, f := p.durations[builder]; !f {
+			p.builders = append(p.builders, builder)
+		}
+		p.durations[builder] += elapsed
+		p.calls[builder]++
+	}
+}
+
+// Builders returns the time spent by each builder, in the order the builders first completed.
+func (p *GenerationProfile) Builders() []BuilderTime {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]BuilderTime, 0, len(p.builders))
+	for _, b := range p.builders {
+		out = append(out, BuilderTime{Builder: b, Duration: p.durations[b], Calls: p.calls[b]})
+	}
+	return out
+}
@@ -30,6 +30,7 @@ import (
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	"istio.io/istio/pilot/pkg/util/sets"
 	"istio.io/istio/pkg/cluster"
@@ -96,6 +97,8 @@ type virtualServiceIndex struct {
 	publicByGateway map[string][]config.Config
 	// root vs namespace/name ->delegate vs virtualservice gvk/namespace/name
 	delegates map[ConfigKey][]ConfigKey
+	// routeAnnotations are the parsed route annotations of the virtual services, after delegate merging.
+	routeAnnotations map[ConfigKey]*istionetworking.RouteAnnotations
 }
 
 func newVirtualServiceIndex() virtualServiceIndex {
@@ -104,6 +107,7 @@ func newVirtualServiceIndex() virtualServiceIndex {
 		privateByNamespaceAndGateway: map[string]map[string][]config.Config{},
 		exportedToNamespaceByGateway: map[string]map[string][]config.Config{},
 		delegates:                    map[ConfigKey][]ConfigKey{},
+		routeAnnotations:             map[ConfigKey]*istionetworking.RouteAnnotations{},
 	}
 }
 
@@ -200,15 +204,24 @@ type PushContext struct {
 	// clusterLocalHosts extracted from the MeshConfig
 	clusterLocalHosts ClusterLocalHosts
 
+	// defaultCORSPolicies are the default CORS policies, by namespace.
+	defaultCORSPolicies map[string]*networking.CorsPolicy
+
 	// sidecarIndex stores sidecar resources
 	sidecarIndex sidecarIndex
 
+	// scopeCache shares the configs generated during the push between the proxies of a SidecarScope.
+	scopeCache *ScopeCache
+
 	// envoy filters for each namespace including global config namespace
 	envoyFiltersByNamespace map[string][]*EnvoyFilterWrapper
 
 	// wasm plugins for each namespace including global config namespace
 	wasmPluginsByNamespace map[string][]*WasmPluginWrapper
 
+	// uses of deprecated features by the configs
+	deprecations []DeprecatedUsage
+
 	// AuthnPolicies contains Authn policies by namespace.
 	AuthnPolicies *AuthenticationPolicies `json:"-"`
 
@@ -360,6 +373,14 @@ type PushRequest struct {
 	// There should only be multiple reasons if the push request is the result of two distinct triggers, rather than
 	// classifying a single trigger as having multiple reasons.
 	Reason []TriggerReason
+
+	// ListenersUpdated is set if the sidecar listeners may change with the configs updated, although their kind
+	// alone does not affect the listeners. For example, the sidecar listeners only read a few fields of the
+	// DestinationRules.
+	ListenersUpdated bool
+
+	// Profile, when set, collects the time spent by the configuration builders, for debugging.
+	Profile *GenerationProfile
 }
 
 type TriggerReason string
@@ -412,6 +433,8 @@ func (pr *PushRequest) Merge(other *PushRequest) *PushRequest {
 	// If either is full we need a full push
 	pr.Full = pr.Full || other.Full
 
+	pr.ListenersUpdated = pr.ListenersUpdated || other.ListenersUpdated
+
 	// The other push context is presumed to be later and more up to date
 	pr.Push = other.Push
 
@@ -451,6 +474,8 @@ func (pr *PushRequest) CopyMerge(other *PushRequest) *PushRequest {
 		// If either is full we need a full push
 		Full: pr.Full || other.Full,
 
+		ListenersUpdated: pr.ListenersUpdated || other.ListenersUpdated,
+
 		// The other push context is presumed to be later and more up to date
 		Push: other.Push,
 
@@ -585,6 +610,13 @@ var (
 		"Virtual services with dup domains.",
 	)
 
+	// RejectedDuplicatedDomains tracks sidecars whose routes were not pushed due to duplicated domains,
+	// see features.StrictVirtualHostDomains.
+	RejectedDuplicatedDomains = monitoring.NewGauge(
+		"pilot_vservice_dup_domain_rejected",
+		"Sidecars whose routes were not pushed due to dup domains.",
+	)
+
 	// DuplicatedSubsets tracks duplicate subsets that we rejected while merging multiple destination rules for same host
 	DuplicatedSubsets = monitoring.NewGauge(
 		"pilot_destrule_subsets",
@@ -616,6 +648,7 @@ var (
 		DuplicatedClusters,
 		ProxyStatusClusterNoInstances,
 		DuplicatedDomains,
+		RejectedDuplicatedDomains,
 		DuplicatedSubsets,
 	}
 )
@@ -634,6 +667,7 @@ func NewPushContext() *PushContext {
 		virtualServiceIndex:     newVirtualServiceIndex(),
 		destinationRuleIndex:    newDestinationRuleIndex(),
 		sidecarIndex:            newSidecarIndex(),
+		scopeCache:              newScopeCache(),
 		envoyFiltersByNamespace: map[string][]*EnvoyFilterWrapper{},
 		gatewayIndex:            newGatewayIndex(),
 		ProxyStatus:             map[string]map[string]ProxyPushStatus{},
@@ -685,10 +719,12 @@ func (ps *PushContext) UpdateMetrics() {
 		mmap := ps.ProxyStatus[pm.Name()]
 		pm.Record(float64(len(mmap)))
 	}
+	ps.recordDeprecations()
 }
 
 // It is called after virtual service short host name is resolved to FQDN
-func virtualServiceDestinationHosts(v *networking.VirtualService) []string {
+func (ps *PushContext) virtualServiceDestinationHosts(cfg config.Config) []string {
+	v, _ := cfg.Spec.(*networking.VirtualService)
 	if v == nil {
 		return nil
 	}
@@ -719,10 +755,20 @@ func virtualServiceDestinationHosts(v *networking.VirtualService) []string {
 			}
 		}
 	}
+	out = append(out, virtualServiceMirrorHosts(cfg, ps.VirtualServiceRouteAnnotations(cfg))...)
 
 	return out
 }
 
+// virtualServiceMirrorHosts returns the FQDN of the hosts of the MirrorsAnnotation, by route name.
+func virtualServiceMirrorHosts(cfg config.Config, routeAnnotations *istionetworking.RouteAnnotations) []string {
+	var out []string
+	for _, h := range routeAnnotations.MirrorHosts() {
+		out = append(out, string(ResolveShortnameToFQDN(h, cfg.Meta)))
+	}
+	return out
+}
+
 // GatewayServices returns the set of services which are referred from the proxy gateways.
 func (ps *PushContext) GatewayServices(proxy *Proxy) []*Service {
 	svcs := proxy.SidecarScope.services
@@ -737,13 +783,12 @@ func (ps *PushContext) GatewayServices(proxy *Proxy) []*Service {
 
 	for _, gw := range proxy.MergedGateway.GatewayNameForServer {
 		for _, vsConfig := range ps.VirtualServicesForGateway(proxy.ConfigNamespace, gw) {
-			vs, ok := vsConfig.Spec.(*networking.VirtualService)
-			if !ok { // should never happen
+			if _, ok := vsConfig.Spec.(*networking.VirtualService); !ok { // should never happen
 				log.Errorf("Failed in getting a virtual service: %v", vsConfig.Labels)
 				return svcs
 			}
 
-			for _, host := range virtualServiceDestinationHosts(vs) {
+			for _, host := range ps.virtualServiceDestinationHosts(vsConfig) {
 				hostsFromGateways[host] = struct{}{}
 			}
 		}
@@ -842,6 +887,21 @@ func (ps *PushContext) VirtualServicesForGateway(proxyNamespace, gateway string)
 	return res
 }
 
+// Gateway returns the gateway with the given namespace/name, as used by MergedGateway.GatewayNameForServer, or nil
+// if there is none.
+func (ps *PushContext) Gateway(name string) *config.Config {
+	configs := ps.gatewayIndex.all
+	if features.ScopeGatewayToNamespace {
+		configs = ps.gatewayIndex.namespace[strings.SplitN(name, "/", 2)[0]]
+	}
+	for i, cfg := range configs {
+		if cfg.Namespace+"/"+cfg.Name == name {
+			return &configs[i]
+		}
+	}
+	return nil
+}
+
 // DelegateVirtualServicesConfigKey lists all the delegate virtual services configkeys associated with the provided virtual services
 func (ps *PushContext) DelegateVirtualServicesConfigKey(vses []config.Config) []ConfigKey {
 	var out []ConfigKey
@@ -936,6 +996,16 @@ func (ps *PushContext) getSidecarScope(proxy *Proxy, workloadLabels labels.Colle
 	return computed
 }
 
+// SidecarForWorkload returns the Sidecar config of the sidecar proxies of a workload, following the precedence of
+// their sidecar scopes, or nil if there is no Sidecar config for them.
+func (ps *PushContext) SidecarForWorkload(namespace string, workloadLabels labels.Instance) *networking.Sidecar {
+	if _, f := ps.sidecarIndex.sidecarsByNamespace[namespace]; !f && ps.sidecarIndex.rootConfig == nil {
+		return nil
+	}
+	proxy := &Proxy{Type: SidecarProxy, ConfigNamespace: namespace}
+	return ps.getSidecarScope(proxy, labels.Collection{workloadLabels}).Sidecar
+}
+
 // destinationRule returns a destination rule for a service name in a given namespace.
 func (ps *PushContext) destinationRule(proxyNameSpace string, service *Service) *config.Config {
 	if service == nil {
@@ -1018,6 +1088,21 @@ func (ps *PushContext) destinationRule(proxyNameSpace string, service *Service)
 	return nil
 }
 
+// inheritedDestinationRules returns the keys of the mesh and namespace destination rules inherited by the
+// destination rules of the proxies of the namespace.
+func (ps *PushContext) inheritedDestinationRules(proxyNameSpace string) []ConfigKey {
+	if !features.EnableDestinationRuleInheritance {
+		return nil
+	}
+	var keys []ConfigKey
+	for _, ns := range []string{proxyNameSpace, ps.Mesh.RootNamespace} {
+		if dr := ps.destinationRuleIndex.inheritedByNamespace[ns]; dr != nil {
+			keys = append(keys, ConfigKey{Kind: gvk.DestinationRule, Name: dr.Name, Namespace: dr.Namespace})
+		}
+	}
+	return keys
+}
+
 func (ps *PushContext) getExportedDestinationRuleFromNamespace(owningNamespace string, hostname host.Name, clientNamespace string) *config.Config {
 	if ps.destinationRuleIndex.exportedByNamespace[owningNamespace] != nil {
 		if specificHostname, ok := MostSpecificHostMatch(hostname,
@@ -1046,6 +1131,18 @@ func (ps *PushContext) getExportedDestinationRuleFromNamespace(owningNamespace s
 	return nil
 }
 
+// DefaultCORSPolicy returns the CORS policy of the virtual hosts of the namespace whose routes don't set their own:
+// the one of the namespace or, failing that, of the root namespace.
+func (ps *PushContext) DefaultCORSPolicy(namespace string) *networking.CorsPolicy {
+	if policy, f := ps.defaultCORSPolicies[namespace]; f {
+		return policy
+	}
+	if ps.Mesh == nil {
+		return nil
+	}
+	return ps.defaultCORSPolicies[ps.Mesh.RootNamespace]
+}
+
 // IsClusterLocal indicates whether the endpoints for the service should only be accessible to clients
 // within the cluster.
 func (ps *PushContext) IsClusterLocal(service *Service) bool {
@@ -1090,6 +1187,10 @@ func (ps *PushContext) InitContext(env *Environment, oldPushContext *PushContext
 
 	ps.clusterLocalHosts = env.ClusterLocal().GetClusterLocalHosts()
 
+	if env.NamespaceCORSPolicies != nil {
+		ps.defaultCORSPolicies = env.NamespaceCORSPolicies.DefaultCORSPolicies()
+	}
+
 	ps.InitDone.Store(true)
 	return nil
 }
@@ -1140,6 +1241,10 @@ func (ps *PushContext) createNewContext(env *Environment) error {
 		return err
 	}
 
+	if err := ps.initDeprecations(env); err != nil {
+		return err
+	}
+
 	// Must be initialized in the end
 	if err := ps.initSidecarScopes(env); err != nil {
 		return err
@@ -1279,6 +1384,15 @@ func (ps *PushContext) updateContext(
 		ps.gatewayIndex = oldPushContext.gatewayIndex
 	}
 
+	if servicesChanged || virtualServicesChanged || destinationRulesChanged || envoyFiltersChanged || gatewayChanged ||
+		sidecarsChanged {
+		if err := ps.initDeprecations(env); err != nil {
+			return err
+		}
+	} else {
+		ps.deprecations = oldPushContext.deprecations
+	}
+
 	// Must be initialized in the end
 	// Sidecars need to be updated if services, virtual services, destination rules, or the sidecar configs change
 	if servicesChanged || virtualServicesChanged || destinationRulesChanged || sidecarsChanged {
@@ -1394,6 +1508,7 @@ func (ps *PushContext) initVirtualServices(env *Environment) error {
 	ps.virtualServiceIndex.exportedToNamespaceByGateway = map[string]map[string][]config.Config{}
 	ps.virtualServiceIndex.privateByNamespaceAndGateway = map[string]map[string][]config.Config{}
 	ps.virtualServiceIndex.publicByGateway = map[string][]config.Config{}
+	ps.virtualServiceIndex.routeAnnotations = map[ConfigKey]*istionetworking.RouteAnnotations{}
 
 	virtualServices, err := env.List(gvk.VirtualService, NamespaceAll)
 	if err != nil {
@@ -1427,6 +1542,8 @@ func (ps *PushContext) initVirtualServices(env *Environment) error {
 		ns := virtualService.Namespace
 		rule := virtualService.Spec.(*networking.VirtualService)
 		gwNames := getGatewayNames(rule)
+		key := ConfigKey{Kind: gvk.VirtualService, Namespace: ns, Name: virtualService.Name}
+		ps.virtualServiceIndex.routeAnnotations[key] = istionetworking.ParseRouteAnnotations(virtualService.Meta)
 		if len(rule.ExportTo) == 0 {
 			// No exportTo in virtualService. Use the global default
 			// We only honor ., *
@@ -1489,6 +1606,12 @@ func (ps *PushContext) initVirtualServices(env *Environment) error {
 	return nil
 }
 
+// VirtualServiceRouteAnnotations returns the parsed route annotations of the virtual service, or nil if the virtual
+// service is not known to the push context.
+func (ps *PushContext) VirtualServiceRouteAnnotations(vs config.Config) *istionetworking.RouteAnnotations {
+	return ps.virtualServiceIndex.routeAnnotations[ConfigKey{Kind: gvk.VirtualService, Namespace: vs.Namespace, Name: vs.Name}]
+}
+
 var meshGateways = []string{constants.IstioMeshGateway}
 
 func getGatewayNames(vs *networking.VirtualService) []string {
@@ -1880,6 +2003,11 @@ func (ps *PushContext) EnvoyFilters(proxy *Proxy) *EnvoyFilterWrapper {
 				}
 			}
 		}
+		if len(out.Patches) == 0 {
+			// None of the patches apply to the proxy, skip the patching machinery entirely.
+			return nil
+		}
+		out.patchContexts = computePatchContexts(out.Patches)
 	}
 
 	return out
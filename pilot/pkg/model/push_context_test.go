@@ -35,6 +35,7 @@ import (
 	securityBeta "istio.io/api/security/v1beta1"
 	selectorpb "istio.io/api/type/v1beta1"
 	"istio.io/istio/pilot/pkg/features"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
@@ -259,6 +260,9 @@ func TestEnvoyFilters(t *testing.T) {
 				}
 				return
 			}
+			if tt.expectedClusterPatches == 0 && tt.expectedListenerPatches == 0 {
+				t.Errorf("Expect no envoy filter when no patch applies, but got %v", filter.Patches)
+			}
 			if len(filter.Patches[networking.EnvoyFilter_CLUSTER]) != tt.expectedClusterPatches {
 				t.Errorf("Expect %d envoy filter cluster patches, but got %d", tt.expectedClusterPatches, len(filter.Patches[networking.EnvoyFilter_CLUSTER]))
 			}
@@ -940,7 +944,7 @@ func TestInitPushContext(t *testing.T) {
 			destinationRuleIndex{}, gatewayIndex{}, processedDestRules{}, IstioEgressListenerWrapper{}, SidecarScope{},
 			AuthenticationPolicies{}, NetworkManager{}, sidecarIndex{}, Telemetries{}, ProxyConfigs{}),
 		// These are not feasible/worth comparing
-		cmpopts.IgnoreTypes(sync.RWMutex{}, localServiceDiscovery{}, FakeStore{}, atomic.Bool{}, sync.Mutex{}),
+		cmpopts.IgnoreTypes(sync.RWMutex{}, localServiceDiscovery{}, FakeStore{}, atomic.Bool{}, sync.Mutex{}, ScopeCache{}),
 		cmpopts.IgnoreInterfaces(struct{ mesh.Holder }{}),
 	)
 	if diff != "" {
@@ -948,6 +952,58 @@ func TestInitPushContext(t *testing.T) {
 	}
 }
 
+func TestPushContextDeprecations(t *testing.T) {
+	env := &Environment{}
+	configStore := NewFakeStore()
+	_, _ = configStore.Create(config.Config{
+		Meta: config.Meta{
+			Name:             "rule1",
+			Namespace:        "test1",
+			GroupVersionKind: gvk.VirtualService,
+			Annotations:      map[string]string{"alpha.istio.io/identity": "foo"},
+		},
+		Spec: &networking.VirtualService{
+			Hosts: []string{"rule1.com"},
+			Http: []*networking.HTTPRoute{
+				{Name: "a", MirrorPercent: &types.UInt32Value{Value: 10}},
+				{Name: "b", MirrorPercent: &types.UInt32Value{Value: 20}},
+			},
+		},
+	})
+	_, _ = configStore.Create(config.Config{
+		Meta: config.Meta{
+			Name:             "rule1",
+			Namespace:        "test1",
+			GroupVersionKind: gvk.DestinationRule,
+		},
+		Spec: &networking.DestinationRule{
+			Host: "rule1.com",
+		},
+	})
+	env.IstioConfigStore = &istioConfigStore{ConfigStore: configStore}
+	env.ServiceDiscovery = &localServiceDiscovery{}
+	m := mesh.DefaultMeshConfig()
+	env.Watcher = mesh.NewFixedWatcher(&m)
+	env.Init()
+
+	ps := NewPushContext()
+	if err := ps.InitContext(env, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range ps.Deprecations() {
+		got = append(got, fmt.Sprintf("%s/%s/%s %s", d.Kind, d.Namespace, d.Name, d.Feature))
+	}
+	expected := []string{
+		"VirtualService/test1/rule1 VirtualService.http.mirrorPercent",
+		"VirtualService/test1/rule1 VirtualService.http.mirrorPercent",
+		"VirtualService/test1/rule1 annotation:alpha.istio.io/identity",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got deprecations %v, expected %v", got, expected)
+	}
+}
+
 func TestSidecarScope(t *testing.T) {
 	ps := NewPushContext()
 	env := &Environment{Watcher: mesh.NewFixedWatcher(&meshconfig.MeshConfig{RootNamespace: "istio-system"})}
@@ -1806,6 +1862,9 @@ func TestInitVirtualService(t *testing.T) {
 			GroupVersionKind: collections.IstioNetworkingV1Alpha3Virtualservices.Resource().GroupVersionKind(),
 			Name:             "vs1",
 			Namespace:        "ns1",
+			Annotations: map[string]string{
+				constants.RegexRewriteAnnotation: `{"productpage": {"pattern": "^/productpage/(.*)$", "substitution": "/\\1"}}`,
+			},
 		},
 		Spec: &networking.VirtualService{
 			Hosts:    []string{"*.org"},
@@ -1889,6 +1948,22 @@ func TestInitVirtualService(t *testing.T) {
 			t.Errorf("got %+v", gotHTTPHosts)
 		}
 	})
+
+	t.Run("route annotations", func(t *testing.T) {
+		rules := ps.VirtualServicesForGateway("ns1", gatewayName)
+		if len(rules) != 1 {
+			t.Fatalf("wanted 1 virtualservice for gateway %s, actually got %d", gatewayName, len(rules))
+		}
+		// The annotations of the root virtual service are parsed once, with the delegates merged.
+		annotations := ps.VirtualServiceRouteAnnotations(rules[0])
+		want := istionetworking.RegexRewrite{Pattern: "^/productpage/(.*)$", Substitution: `/\1`}
+		if got, f := annotations.RegexRewrite("productpage"); !f || got != want {
+			t.Errorf("got regex rewrite %+v, want %+v", got, want)
+		}
+		if annotations != ps.VirtualServiceRouteAnnotations(rules[0]) {
+			t.Errorf("expected the route annotations to be parsed once")
+		}
+	})
 }
 
 func TestServiceWithExportTo(t *testing.T) {
@@ -0,0 +1,237 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/pkg/monitoring"
+)
+
+func init() {
+	monitoring.MustRegister(scopeCacheReads)
+}
+
+var scopeCacheReads = monitoring.NewSum(
+	"scope_cache_reads",
+	"Total number of scope cache reads, by result and resource type.",
+	monitoring.WithLabels(typeTag, resourceTypeTag),
+)
+
+// ScopeCacheKey identifies the proxies which share the configs generated from their SidecarScope: the proxies of a
+// namespace selected by the same Sidecar, with the same labels and the same other inputs.
+type ScopeCacheKey struct {
+	Namespace string
+	// Sidecar is the hash of the Sidecar config of the scope, or 0 for the default scope.
+	Sidecar uint64
+	// Labels is the hash of the labels of the proxies.
+	Labels uint64
+	// Proxy is the hash of the other inputs of the configs, such as the proxy metadata.
+	Proxy uint64
+}
+
+// NewScopeCacheKey returns the key of the proxy in the scope cache. Inputs are the other inputs of the configs,
+// which are not captured by the metadata of the proxy.
+func NewScopeCacheKey(proxy *Proxy, inputs ...string) ScopeCacheKey {
+	key := ScopeCacheKey{Namespace: proxy.ConfigNamespace}
+	if proxy.Metadata != nil {
+		key.Labels = labelsHash(proxy.Metadata.Labels)
+	}
+	if proxy.SidecarScope != nil {
+		key.Sidecar = proxy.SidecarScope.configHash
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(proxy.Type))
+	hash.Write([]byte(strconv.FormatBool(proxy.SupportsIPv4()) + strconv.FormatBool(proxy.SupportsIPv6())))
+	if proxy.Metadata != nil {
+		if b, err := json.Marshal(scopeMetadata(proxy.Metadata)); err == nil {
+			hash.Write(b)
+		}
+	}
+	for _, input := range inputs {
+		hash.Write([]byte{0})
+		hash.Write([]byte(input))
+	}
+	key.Proxy = hash.Sum64()
+	return key
+}
+
+// scopeAnnotations are the annotations of the proxies read by the config generation.
+var scopeAnnotations = []string{
+	constants.InboundPortRangesAnnotation,
+	constants.OutboundUDPPortsAnnotation,
+	constants.ForwardClientCertDetailsAnnotation,
+	constants.SetCurrentClientCertDetailsAnnotation,
+	constants.TraceOperationAnnotation,
+	constants.RequestAttemptCountAnnotation,
+}
+
+// scopeMetadata returns the metadata of the proxy read by the config generation. The other fields, such as the name
+// or the IPs of the pod, differ between the replicas of a workload and must not be part of the key: the configs
+// depending on them must be given as inputs.
+func scopeMetadata(metadata *NodeMetadata) NodeMetadata {
+	scoped := NodeMetadata{
+		ProxyConfig:                  metadata.ProxyConfig,
+		IstioVersion:                 metadata.IstioVersion,
+		Namespace:                    metadata.Namespace,
+		InterceptionMode:             metadata.InterceptionMode,
+		HTTPProxyPort:                metadata.HTTPProxyPort,
+		ClusterID:                    metadata.ClusterID,
+		Network:                      metadata.Network,
+		RequestedNetworkView:         metadata.RequestedNetworkView,
+		IdleTimeout:                  metadata.IdleTimeout,
+		HTTP10:                       metadata.HTTP10,
+		Generator:                    metadata.Generator,
+		DNSCapture:                   metadata.DNSCapture,
+		DNSAutoAllocate:              metadata.DNSAutoAllocate,
+		DisableAltVirtualHosts:       metadata.DisableAltVirtualHosts,
+		FeatureGates:                 metadata.FeatureGates,
+		UnprivilegedPod:              metadata.UnprivilegedPod,
+		InboundListenerExactBalance:  metadata.InboundListenerExactBalance,
+		OutboundListenerExactBalance: metadata.OutboundListenerExactBalance,
+	}
+	for _, k := range scopeAnnotations {
+		if v, f := metadata.Annotations[k]; f {
+			if scoped.Annotations == nil {
+				scoped.Annotations = map[string]string{}
+			}
+			scoped.Annotations[k] = v
+		}
+	}
+	return scoped
+}
+
+func labelsHash(labels map[string]string) uint64 {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	hash := fnv.New64a()
+	for _, k := range keys {
+		hash.Write([]byte(k))
+		hash.Write([]byte{'='})
+		hash.Write([]byte(labels[k]))
+		hash.Write([]byte{0})
+	}
+	return hash.Sum64()
+}
+
+// sidecarConfigHash returns the hash of the Sidecar config a SidecarScope is computed from.
+func sidecarConfigHash(sidecar *config.Config) uint64 {
+	hash := fnv.New64a()
+	for _, s := range []string{sidecar.Namespace, sidecar.Name, sidecar.ResourceVersion, strconv.FormatInt(sidecar.Generation, 10)} {
+		hash.Write([]byte(s))
+		hash.Write([]byte{0})
+	}
+	// The resource version is not set by all the config stores.
+	if b, err := config.ToJSON(sidecar.Spec); err == nil {
+		hash.Write(b)
+	}
+	// The annotations of the Sidecar configure its scope too.
+	hash.Write([]byte(strconv.FormatUint(labelsHash(sidecar.Annotations), 16)))
+	return hash.Sum64()
+}
+
+type scopeCacheEntryKey struct {
+	resourceType string
+	key          ScopeCacheKey
+}
+
+// ScopeCache shares the SidecarScopes and the configs generated during a push between the proxies of a SidecarScope.
+// It lives as long as the PushContext, so its entries never need to be invalidated.
+type ScopeCache struct {
+	mu      sync.Mutex
+	entries map[scopeCacheEntryKey]interface{}
+}
+
+func newScopeCache() *ScopeCache {
+	return &ScopeCache{entries: map[scopeCacheEntryKey]interface{}{}}
+}
+
+// Get returns the config of the resource type generated for the proxies of the key, if any.
+func (c *ScopeCache) Get(resourceType string, key ScopeCacheKey) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	value, f := c.entries[scopeCacheEntryKey{resourceType: resourceType, key: key}]
+	c.mu.Unlock()
+	if features.EnableXDSCacheMetrics {
+		result := "miss"
+		if f {
+			result = "hit"
+		}
+		scopeCacheReads.With(typeTag.Value(result), resourceTypeTag.Value(resourceType)).Increment()
+	}
+	return value, f
+}
+
+// Add stores the config of the resource type generated for the proxies of the key. Values must not be modified
+// once added, as they are shared.
+func (c *ScopeCache) Add(resourceType string, key ScopeCacheKey, value interface{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries[scopeCacheEntryKey{resourceType: resourceType, key: key}] = value
+	c.mu.Unlock()
+}
+
+// scopeCacheSidecarScope is the resource type of the SidecarScopes in the scope cache.
+const scopeCacheSidecarScope = "sidecar_scope"
+
+// proxySidecarScope returns the SidecarScope of the proxy. It is shared with the proxies of the namespace with the
+// same type and labels, which are the only inputs of its selection, when the scope cache is enabled.
+func (ps *PushContext) proxySidecarScope(node *Proxy) *SidecarScope {
+	var workloadLabels labels.Collection
+	// Gateways should just have a default scope with egress: */*
+	if node.Type == SidecarProxy {
+		workloadLabels = labels.Collection{node.Metadata.Labels}
+	}
+	cache := ps.ScopeCache()
+	if cache == nil {
+		return ps.getSidecarScope(node, workloadLabels)
+	}
+	key := ScopeCacheKey{Namespace: node.ConfigNamespace}
+	if node.Type == SidecarProxy {
+		key.Labels = labelsHash(node.Metadata.Labels)
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(node.Type))
+	key.Proxy = hash.Sum64()
+	if cached, f := cache.Get(scopeCacheSidecarScope, key); f {
+		return cached.(*SidecarScope)
+	}
+	scope := ps.getSidecarScope(node, workloadLabels)
+	cache.Add(scopeCacheSidecarScope, key, scope)
+	return scope
+}
+
+// ScopeCache returns the scope cache of the push, or nil if the configs are not shared.
+func (ps *PushContext) ScopeCache() *ScopeCache {
+	if !features.EnableScopeCache {
+		return nil
+	}
+	return ps.scopeCache
+}
@@ -0,0 +1,133 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh"
+)
+
+func TestScopeCacheKey(t *testing.T) {
+	ps := NewPushContext()
+	m := mesh.DefaultMeshConfig()
+	ps.Mesh = &m
+	sidecar := &config.Config{
+		Meta: config.Meta{Name: "foo", Namespace: "ns", ResourceVersion: "1"},
+		Spec: &networking.Sidecar{Egress: []*networking.IstioEgressListener{{Hosts: []string{"*/*"}}}},
+	}
+	scope := ConvertToSidecarScope(ps, sidecar, "ns")
+	proxy := func(ip string, labels map[string]string, mutate ...func(p *Proxy)) *Proxy {
+		p := &Proxy{
+			Type:            SidecarProxy,
+			ConfigNamespace: "ns",
+			IPAddresses:     []string{ip},
+			SidecarScope:    scope,
+			Metadata:        &NodeMetadata{Labels: labels, InstanceIPs: []string{ip}, IstioVersion: "1.13.0"},
+		}
+		for _, m := range mutate {
+			m(p)
+		}
+		p.DiscoverIPVersions()
+		return p
+	}
+	app := map[string]string{"app": "a"}
+	key := NewScopeCacheKey(proxy("10.0.0.1", app))
+
+	replica := proxy("10.0.0.2", map[string]string{"app": "a"}, func(p *Proxy) {
+		p.Metadata.NodeName = "node-2"
+		p.Metadata.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "now"}
+		p.Metadata.PlatformMetadata = map[string]string{"gcp_gce_instance_id": "2"}
+	})
+	if other := NewScopeCacheKey(replica); other != key {
+		t.Errorf("expected the replicas of a workload to share the key, got %v and %v", key, other)
+	}
+	cases := []struct {
+		name  string
+		proxy *Proxy
+	}{
+		{"labels", proxy("10.0.0.1", map[string]string{"app": "b"})},
+		{"namespace", proxy("10.0.0.1", app, func(p *Proxy) { p.ConfigNamespace = "other" })},
+		{"sidecar", proxy("10.0.0.1", app, func(p *Proxy) { p.SidecarScope = DefaultSidecarScopeForNamespace(ps, "ns") })},
+		{"metadata", proxy("10.0.0.1", app, func(p *Proxy) { p.Metadata.IstioVersion = "1.12.0" })},
+		{"annotations", proxy("10.0.0.1", app, func(p *Proxy) {
+			p.Metadata.Annotations = map[string]string{constants.OutboundUDPPortsAnnotation: "53"}
+		})},
+		{"ip family", proxy("::1", app)},
+	}
+	for _, c := range cases {
+		if other := NewScopeCacheKey(c.proxy); other == key {
+			t.Errorf("%s: expected a different key than %v", c.name, key)
+		}
+	}
+	if other := NewScopeCacheKey(proxy("10.0.0.1", app), "input"); other == key {
+		t.Errorf("expected inputs to change the key %v", key)
+	}
+
+	changed := sidecar.DeepCopy()
+	changed.Spec = &networking.Sidecar{Egress: []*networking.IstioEgressListener{{Hosts: []string{"ns/*"}}}}
+	if sidecarConfigHash(sidecar) == sidecarConfigHash(&changed) {
+		t.Errorf("expected the hash of the Sidecar to change with its spec")
+	}
+}
+
+func TestScopeCache(t *testing.T) {
+	cache := newScopeCache()
+	key := ScopeCacheKey{Namespace: "ns", Sidecar: 1, Labels: 2, Proxy: 3}
+	if _, f := cache.Get("listeners", key); f {
+		t.Fatalf("expected no entry")
+	}
+	cache.Add("listeners", key, "value")
+	if v, f := cache.Get("listeners", key); !f || v != "value" {
+		t.Fatalf("expected the entry to be found, got %v", v)
+	}
+	if _, f := cache.Get("clusters", key); f {
+		t.Fatalf("expected entries to be keyed by resource type")
+	}
+
+	var disabled *ScopeCache
+	disabled.Add("listeners", key, "value")
+	if _, f := disabled.Get("listeners", key); f {
+		t.Fatalf("expected a nil cache to be empty")
+	}
+}
+
+func TestProxySidecarScope(t *testing.T) {
+	defer func(v bool) { features.EnableScopeCache = v }(features.EnableScopeCache)
+	features.EnableScopeCache = true
+
+	ps := NewPushContext()
+	m := mesh.DefaultMeshConfig()
+	ps.Mesh = &m
+	proxy := func(app string) *Proxy {
+		return &Proxy{Type: SidecarProxy, ConfigNamespace: "ns", Metadata: &NodeMetadata{Labels: map[string]string{"app": app}}}
+	}
+	scope := ps.proxySidecarScope(proxy("a"))
+	if got := ps.proxySidecarScope(proxy("a")); got != scope {
+		t.Fatalf("expected the replicas to share the SidecarScope")
+	}
+	if n := len(ps.ScopeCache().entries); n != 1 {
+		t.Fatalf("expected the SidecarScope to be cached once, got %d entries", n)
+	}
+	ps.proxySidecarScope(proxy("b"))
+	ps.proxySidecarScope(&Proxy{Type: Router, ConfigNamespace: "ns", Metadata: &NodeMetadata{}})
+	if n := len(ps.ScopeCache().entries); n != 3 {
+		t.Fatalf("expected the SidecarScope to be selected for other labels and types, got %d entries", n)
+	}
+}
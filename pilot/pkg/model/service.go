@@ -421,6 +421,9 @@ type IstioEndpoint struct {
 	// Address is the address of the endpoint, using envoy proto.
 	Address string
 
+	// AdditionalAddresses are the other addresses of a dual-stack endpoint, of the other IP family than Address.
+	AdditionalAddresses []string
+
 	// ServicePortName tracks the name of the port, this is used to select the IstioEndpoint by service port.
 	ServicePortName string
 
@@ -456,6 +459,12 @@ type IstioEndpoint struct {
 	// Specifies the hostname of the Pod, empty for vm workload.
 	HostName string
 
+	// NodeName is the name of the Kubernetes node the endpoint runs on, empty for vm workload.
+	NodeName string
+
+	// ZoneHints are the zones this endpoint should serve, from the Kubernetes topology aware hints.
+	ZoneHints []string
+
 	// If specified, the fully qualified Pod hostname will be "<hostname>.<subdomain>.<pod namespace>.svc.<cluster domain>".
 	SubDomain string
 
@@ -562,6 +571,29 @@ type ServiceAttributes struct {
 	// The port that the user provides in the meshNetworks config is the service port.
 	// We translate that to the appropriate node port here.
 	ClusterExternalPorts map[cluster.ID]map[uint32]uint32
+
+	// NodeLocal is set for services with internalTrafficPolicy=Local when the Kubernetes traffic policy is
+	// respected. Clients are only sent the endpoints on their own node.
+	NodeLocal bool
+
+	// TopologyAware is set for services with topology aware hints when the Kubernetes traffic policy is
+	// respected. Clients are only sent the endpoints hinted for their zone.
+	TopologyAware bool
+
+	// HTTP3 is set for services advertising HTTP/3 support to the clients of sidecars.
+	HTTP3 bool
+
+	// For ServiceEntries with DNS resolution
+
+	// DNSRefreshRate, when set, overrides the mesh-wide DNS refresh rate of the clusters of the service.
+	DNSRefreshRate time.Duration
+
+	// DNSIgnoreTTL is set when the clusters of the service refresh at the DNS refresh rate instead of the TTL of
+	// the DNS records.
+	DNSIgnoreTTL bool
+
+	// DNSJitter is the upper bound of the offset added to the DNS refresh rate of each proxy.
+	DNSJitter time.Duration
 }
 
 // DeepCopy creates a deep copy of ServiceAttributes, but skips internal mutexes.
@@ -679,6 +711,16 @@ func (ports PortList) GetByPort(num int) (*Port, bool) {
 	return nil, false
 }
 
+// GetUDPByPort retrieves a UDP port declaration by port value
+func (ports PortList) GetUDPByPort(num int) (*Port, bool) {
+	for _, port := range ports {
+		if port.Port == num && port.Protocol == protocol.UDP {
+			return port, true
+		}
+	}
+	return nil, false
+}
+
 // External predicate checks whether the service is external
 func (s *Service) External() bool {
 	return s.MeshExternal
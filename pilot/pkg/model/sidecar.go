@@ -17,10 +17,14 @@ package model
 import (
 	"encoding/json"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	networking "istio.io/api/networking/v1alpha3"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
+	"istio.io/istio/pilot/pkg/util/sets"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
@@ -78,6 +82,9 @@ type SidecarScope struct {
 	// Version this sidecar was computed for
 	Version string
 
+	// configHash identifies the Sidecar config the scope was computed from, or is 0 for the default scope.
+	configHash uint64
+
 	// Set of egress listeners, and their associated services.  A sidecar
 	// scope should have either ingress/egress listeners or both.  For
 	// every proxy workload that maps to a sidecar API object (or the
@@ -99,6 +106,10 @@ type SidecarScope struct {
 	// destination rule.
 	destinationRules map[host.Name]*config.Config
 
+	// destinationRuleHosts indexes the hostnames of the destinationRules map above by the destination rule applied
+	// to them, so that the clusters affected by a destination rule change can be found.
+	destinationRuleHosts map[ConfigKey]sets.Set
+
 	// OutboundTrafficPolicy defines the outbound traffic policy for this sidecar.
 	// If OutboundTrafficPolicy is ALLOW_ANY traffic to unknown destinations will
 	// be forwarded.
@@ -114,6 +125,72 @@ type SidecarScope struct {
 	//
 	// Changes to Sidecar resources in this namespace will trigger a push.
 	RootNamespace string
+
+	// passthroughWildcardNamespaces are the namespaces whose Passthrough services get wildcard domains.
+	// If nil, the services of all namespaces get wildcard domains.
+	passthroughWildcardNamespaces sets.Set
+
+	// inboundConnectionLimits are the maximum numbers of connections of the inbound ports, keyed by port, or * for
+	// the ports without an entry of their own.
+	inboundConnectionLimits map[string]uint64
+
+	// inboundLocalRateLimits are the local rate limits of the inbound HTTP ports, keyed by port, or * for the ports
+	// without an entry of their own.
+	inboundLocalRateLimits map[string]istionetworking.LocalRateLimit
+}
+
+// PassthroughWildcardAllowed returns whether wildcard domains are generated for the Passthrough services
+// of the given namespace.
+func (sc *SidecarScope) PassthroughWildcardAllowed(namespace string) bool {
+	if sc == nil || sc.passthroughWildcardNamespaces == nil {
+		return true
+	}
+	return sc.passthroughWildcardNamespaces.Contains(namespace)
+}
+
+// InboundConnectionLimit returns the maximum number of connections of each filter chain of the inbound port, or 0 if
+// the connections are not limited.
+func (sc *SidecarScope) InboundConnectionLimit(port int) uint64 {
+	if sc == nil {
+		return 0
+	}
+	if limit, f := sc.inboundConnectionLimits[strconv.Itoa(port)]; f {
+		return limit
+	}
+	return sc.inboundConnectionLimits["*"]
+}
+
+// InboundLocalRateLimit returns the local rate limit of the inbound HTTP requests on the port, or nil if they are not
+// limited.
+func (sc *SidecarScope) InboundLocalRateLimit(port int) *istionetworking.LocalRateLimit {
+	if sc == nil {
+		return nil
+	}
+	limit, f := sc.inboundLocalRateLimits[strconv.Itoa(port)]
+	if !f {
+		if limit, f = sc.inboundLocalRateLimits["*"]; !f {
+			return nil
+		}
+	}
+	return &limit
+}
+
+// EgressConnectTimeout returns the connect timeout of the clusters of the port, from the egress listener they are
+// reached through, or 0 if the listener does not override it.
+func (sc *SidecarScope) EgressConnectTimeout(port int) time.Duration {
+	if l := sc.GetEgressListenerForRDS(port, ""); l != nil {
+		return l.ConnectTimeout
+	}
+	return 0
+}
+
+// PassthroughWildcardNamespaces returns the sorted namespaces whose Passthrough services get wildcard domains,
+// or nil if the services of all namespaces do.
+func (sc *SidecarScope) PassthroughWildcardNamespaces() []string {
+	if sc == nil || sc.passthroughWildcardNamespaces == nil {
+		return nil
+	}
+	return sc.passthroughWildcardNamespaces.SortedList()
 }
 
 // MarshalJSON implements json.Marshaller
@@ -163,6 +240,14 @@ type IstioEgressListenerWrapper struct {
 	virtualServices []config.Config
 
 	listenerHosts map[string][]host.Name
+
+	// CatchAll overrides the catch-all virtual host of the listener's route configuration, if set.
+	CatchAll *istionetworking.CatchAllAction
+
+	// ConnectTimeout and IdleTimeout override the connect timeout of the clusters reached through the listener,
+	// and the idle timeout of its connections, if set.
+	ConnectTimeout time.Duration
+	IdleTimeout    time.Duration
 }
 
 const defaultSidecar = "default-sidecar"
@@ -194,6 +279,7 @@ func DefaultSidecarScopeForNamespace(ps *PushContext, configNamespace string) *S
 	// Now that we have all the services that sidecars using this scope (in
 	// this config namespace) will see, identify all the destinationRules
 	// that these services need
+	parents := ps.inheritedDestinationRules(configNamespace)
 	for _, s := range out.services {
 		// In some scenarios, there may be multiple Services defined for the same hostname due to ServiceEntry allowing
 		// arbitrary hostnames. In these cases, we want to pick the first Service, which is the oldest. This ensures
@@ -207,7 +293,7 @@ func DefaultSidecarScopeForNamespace(ps *PushContext, configNamespace string) *S
 		}
 		out.servicesByHostname[s.Hostname] = s
 		if dr := ps.destinationRule(configNamespace, s); dr != nil {
-			out.destinationRules[s.Hostname] = dr
+			out.addDestinationRule(s.Hostname, dr, parents)
 		}
 		out.AddConfigDependencies(ConfigKey{
 			Kind:      gvk.ServiceEntry,
@@ -269,6 +355,7 @@ func ConvertToSidecarScope(ps *PushContext, sidecarConfig *config.Config, config
 		Name:      sidecarConfig.Name,
 		Namespace: sidecarConfig.Namespace,
 	})
+	out.configHash = sidecarConfigHash(sidecarConfig)
 
 	egressConfigs := sidecar.Egress
 	// If egress not set, setup a default listener
@@ -280,6 +367,69 @@ func ConvertToSidecarScope(ps *PushContext, sidecarConfig *config.Config, config
 		out.EgressListeners = append(out.EgressListeners,
 			convertIstioListenerToWrapper(ps, configNamespace, e))
 	}
+	if value, f := sidecarConfig.Annotations[constants.PassthroughWildcardNamespacesAnnotation]; f {
+		out.passthroughWildcardNamespaces = sets.NewSet()
+		for _, ns := range strings.Split(value, ",") {
+			ns = strings.TrimSpace(ns)
+			if ns == currentNamespace {
+				ns = configNamespace
+			}
+			if ns != "" {
+				out.passthroughWildcardNamespaces.Insert(ns)
+			}
+		}
+	}
+	// Invalid annotation values are rejected by validation, but may still be found in sidecars created without it.
+	ignore := func(annotation string, err error) {
+		log.Debugf("ignoring invalid %s annotation of sidecar %s/%s: %v", annotation, sidecarConfig.Namespace, sidecarConfig.Name, err)
+	}
+	if value, f := sidecarConfig.Annotations[constants.InboundConnectionLimitAnnotation]; f {
+		if limits, err := istionetworking.ParseConnectionLimits(value); err == nil {
+			out.inboundConnectionLimits = limits
+		} else {
+			ignore(constants.InboundConnectionLimitAnnotation, err)
+		}
+	}
+	if value, f := sidecarConfig.Annotations[constants.InboundLocalRateLimitAnnotation]; f {
+		if limits, err := istionetworking.ParseLocalRateLimits(value); err == nil {
+			out.inboundLocalRateLimits = limits
+		} else {
+			ignore(constants.InboundLocalRateLimitAnnotation, err)
+		}
+	}
+	if value, f := sidecarConfig.Annotations[constants.EgressCatchAllAnnotation]; f {
+		if actions, err := istionetworking.ParseCatchAllActions(value); err == nil {
+			for _, l := range out.EgressListeners {
+				port := "*"
+				if l.IstioListener.Port != nil {
+					port = strconv.Itoa(int(l.IstioListener.Port.Number))
+				}
+				if action, f := actions[port]; f {
+					l.CatchAll = &action
+				}
+			}
+		} else {
+			ignore(constants.EgressCatchAllAnnotation, err)
+		}
+	}
+	if value, f := sidecarConfig.Annotations[constants.EgressConnectTimeoutAnnotation]; f {
+		if timeouts, err := istionetworking.ParseEgressTimeouts(value); err == nil {
+			for _, l := range out.EgressListeners {
+				l.ConnectTimeout = timeouts[egressListenerPort(l)]
+			}
+		} else {
+			ignore(constants.EgressConnectTimeoutAnnotation, err)
+		}
+	}
+	if value, f := sidecarConfig.Annotations[constants.EgressIdleTimeoutAnnotation]; f {
+		if timeouts, err := istionetworking.ParseEgressTimeouts(value); err == nil {
+			for _, l := range out.EgressListeners {
+				l.IdleTimeout = timeouts[egressListenerPort(l)]
+			}
+		} else {
+			ignore(constants.EgressIdleTimeoutAnnotation, err)
+		}
+	}
 
 	// Now collect all the imported services across all egress listeners in
 	// this sidecar crd. This is needed to generate CDS output
@@ -339,14 +489,13 @@ func ConvertToSidecarScope(ps *PushContext, sidecarConfig *config.Config, config
 		// That way, if there is ambiguity around what hostname to pick, a user can specify the one they
 		// want in the hosts field, and the potentially random choice below won't matter
 		for _, vs := range listener.virtualServices {
-			v := vs.Spec.(*networking.VirtualService)
 			out.AddConfigDependencies(ConfigKey{
 				Kind:      gvk.VirtualService,
 				Name:      vs.Name,
 				Namespace: vs.Namespace,
 			})
 
-			for _, h := range virtualServiceDestinationHosts(v) {
+			for _, h := range ps.virtualServiceDestinationHosts(vs) {
 				// Default to this hostname in our config namespace
 				if s, ok := ps.ServiceIndex.HostnameAndNamespace[host.Name(h)][configNamespace]; ok {
 					// This won't overwrite hostnames that have already been found eg because they were requested in hosts
@@ -401,11 +550,12 @@ func ConvertToSidecarScope(ps *PushContext, sidecarConfig *config.Config, config
 	// that these services need
 	out.servicesByHostname = make(map[host.Name]*Service, len(out.services))
 	out.destinationRules = make(map[host.Name]*config.Config)
+	parents := ps.inheritedDestinationRules(configNamespace)
 	for _, s := range out.services {
 		out.servicesByHostname[s.Hostname] = s
 		dr := ps.destinationRule(configNamespace, s)
 		if dr != nil {
-			out.destinationRules[s.Hostname] = dr
+			out.addDestinationRule(s.Hostname, dr, parents)
 			out.AddConfigDependencies(ConfigKey{
 				Kind:      gvk.DestinationRule,
 				Name:      dr.Name,
@@ -457,6 +607,14 @@ func convertIstioListenerToWrapper(ps *PushContext, configNamespace string,
 	return out
 }
 
+// egressListenerPort returns the port of the egress listener, or * for the listener without a port.
+func egressListenerPort(l *IstioEgressListenerWrapper) string {
+	if l.IstioListener == nil || l.IstioListener.Port == nil {
+		return "*"
+	}
+	return strconv.Itoa(int(l.IstioListener.Port.Number))
+}
+
 // GetEgressListenerForRDS returns the egress listener corresponding to
 // the listener port or the bind address or the catch all listener
 func (sc *SidecarScope) GetEgressListenerForRDS(port int, bind string) *IstioEgressListenerWrapper {
@@ -556,6 +714,31 @@ func (sc *SidecarScope) DestinationRule(svc host.Name) *config.Config {
 	return sc.destinationRules[svc]
 }
 
+// DestinationRuleHosts returns the hostnames of the services the destination rule applies to, if it applies to any.
+// The mesh and namespace destination rules inherited by the destination rules of the scope apply to the hostnames
+// of the destination rules inheriting from them. Destination rules merged into another one are only known by the
+// rule they are merged into.
+func (sc *SidecarScope) DestinationRuleHosts(dr ConfigKey) (sets.Set, bool) {
+	hosts, f := sc.destinationRuleHosts[dr]
+	return hosts, f
+}
+
+// addDestinationRule adds the destination rule of the hostname, which inherits from the parents destination rules.
+func (sc *SidecarScope) addDestinationRule(hostname host.Name, dr *config.Config, parents []ConfigKey) {
+	sc.destinationRules[hostname] = dr
+	if sc.destinationRuleHosts == nil {
+		sc.destinationRuleHosts = make(map[ConfigKey]sets.Set)
+	}
+	keys := append([]ConfigKey{{Kind: gvk.DestinationRule, Name: dr.Name, Namespace: dr.Namespace}}, parents...)
+	for _, key := range keys {
+		if sc.destinationRuleHosts[key] == nil {
+			sc.destinationRuleHosts[key] = sets.NewSet()
+		}
+		sc.destinationRuleHosts[key].Insert(string(hostname))
+	}
+	sc.AddConfigDependencies(parents...)
+}
+
 // Services returns the list of services that are visible to a sidecar.
 func (sc *SidecarScope) Services() []*Service {
 	return sc.services
@@ -36,6 +36,7 @@ import (
 	tpb "istio.io/api/telemetry/v1alpha1"
 	"istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/util/sets"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/util/protomarshal"
@@ -49,6 +50,10 @@ type Telemetry struct {
 	Name      string         `json:"name"`
 	Namespace string         `json:"namespace"`
 	Spec      *tpb.Telemetry `json:"spec"`
+	// TraceOperation is the operation name of inbound spans set by the TraceOperationAnnotation.
+	TraceOperation string `json:"trace_operation,omitempty"`
+	// RequestAttemptCount is the attempt count reporting set by the RequestAttemptCountAnnotation.
+	RequestAttemptCount string `json:"request_attempt_count,omitempty"`
 }
 
 // Telemetries organizes Telemetry configuration by namespace.
@@ -109,9 +114,11 @@ func getTelemetries(env *Environment) (*Telemetries, error) {
 	sortConfigByCreationTime(fromEnv)
 	for _, config := range fromEnv {
 		telemetry := Telemetry{
-			Name:      config.Name,
-			Namespace: config.Namespace,
-			Spec:      config.Spec.(*tpb.Telemetry),
+			Name:                config.Name,
+			Namespace:           config.Namespace,
+			Spec:                config.Spec.(*tpb.Telemetry),
+			TraceOperation:      config.Annotations[constants.TraceOperationAnnotation],
+			RequestAttemptCount: config.Annotations[constants.RequestAttemptCountAnnotation],
 		}
 		telemetries.NamespaceToTelemetries[config.Namespace] = append(telemetries.NamespaceToTelemetries[config.Namespace], telemetry)
 	}
@@ -164,6 +171,10 @@ type computedTelemetries struct {
 	Metrics []*tpb.Metrics
 	Logging []*tpb.AccessLogging
 	Tracing []*tpb.Tracing
+	// TraceOperation is the operation name of inbound spans of the most specific Telemetry that sets one.
+	TraceOperation string
+	// RequestAttemptCount is the attempt count reporting of the most specific Telemetry that sets one.
+	RequestAttemptCount string
 }
 
 type TracingConfig struct {
@@ -271,6 +282,18 @@ func (t *Telemetries) Tracing(proxy *Proxy) *TracingConfig {
 	return &cfg
 }
 
+// TraceOperation returns the operation name of the inbound spans of a proxy set by the TraceOperationAnnotation of
+// the most specific Telemetry that has it, or "" if none does.
+func (t *Telemetries) TraceOperation(proxy *Proxy) string {
+	return t.applicableTelemetries(proxy).TraceOperation
+}
+
+// RequestAttemptCount returns the attempt count reporting of a proxy set by the RequestAttemptCountAnnotation of
+// the most specific Telemetry that has it, or "" if none does.
+func (t *Telemetries) RequestAttemptCount(proxy *Proxy) string {
+	return t.applicableTelemetries(proxy).RequestAttemptCount
+}
+
 // HTTPFilters computes the HttpFilter for a given proxy/class
 func (t *Telemetries) HTTPFilters(proxy *Proxy, class networking.ListenerClass) []*hcm.HttpFilter {
 	if res := t.telemetryFilters(proxy, class, networking.ListenerProtocolHTTP); res != nil {
@@ -299,6 +322,8 @@ func (t *Telemetries) applicableTelemetries(proxy *Proxy) computedTelemetries {
 	ms := []*tpb.Metrics{}
 	ls := []*tpb.AccessLogging{}
 	ts := []*tpb.Tracing{}
+	traceOperation := ""
+	requestAttemptCount := ""
 	key := telemetryKey{}
 	if t.RootNamespace != "" {
 		telemetry := t.namespaceWideTelemetryConfig(t.RootNamespace)
@@ -307,6 +332,12 @@ func (t *Telemetries) applicableTelemetries(proxy *Proxy) computedTelemetries {
 			ms = append(ms, telemetry.Spec.GetMetrics()...)
 			ls = append(ls, telemetry.Spec.GetAccessLogging()...)
 			ts = append(ts, telemetry.Spec.GetTracing()...)
+			if telemetry.TraceOperation != "" {
+				traceOperation = telemetry.TraceOperation
+			}
+			if telemetry.RequestAttemptCount != "" {
+				requestAttemptCount = telemetry.RequestAttemptCount
+			}
 		}
 	}
 
@@ -317,6 +348,12 @@ func (t *Telemetries) applicableTelemetries(proxy *Proxy) computedTelemetries {
 			ms = append(ms, telemetry.Spec.GetMetrics()...)
 			ls = append(ls, telemetry.Spec.GetAccessLogging()...)
 			ts = append(ts, telemetry.Spec.GetTracing()...)
+			if telemetry.TraceOperation != "" {
+				traceOperation = telemetry.TraceOperation
+			}
+			if telemetry.RequestAttemptCount != "" {
+				requestAttemptCount = telemetry.RequestAttemptCount
+			}
 		}
 	}
 
@@ -331,15 +368,23 @@ func (t *Telemetries) applicableTelemetries(proxy *Proxy) computedTelemetries {
 			ms = append(ms, spec.GetMetrics()...)
 			ls = append(ls, spec.GetAccessLogging()...)
 			ts = append(ts, spec.GetTracing()...)
+			if telemetry.TraceOperation != "" {
+				traceOperation = telemetry.TraceOperation
+			}
+			if telemetry.RequestAttemptCount != "" {
+				requestAttemptCount = telemetry.RequestAttemptCount
+			}
 			break
 		}
 	}
 
 	return computedTelemetries{
-		telemetryKey: key,
-		Metrics:      ms,
-		Logging:      ls,
-		Tracing:      ts,
+		telemetryKey:        key,
+		Metrics:             ms,
+		Logging:             ls,
+		Tracing:             ts,
+		TraceOperation:      traceOperation,
+		RequestAttemptCount: requestAttemptCount,
 	}
 }
 
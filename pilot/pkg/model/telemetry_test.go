@@ -28,8 +28,10 @@ import (
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	tpb "istio.io/api/telemetry/v1alpha1"
+	"istio.io/api/type/v1beta1"
 	"istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/config/schema/collections"
@@ -542,6 +544,58 @@ func TestTracing(t *testing.T) {
 	}
 }
 
+func TestTraceOperation(t *testing.T) {
+	sidecar := &Proxy{ConfigNamespace: "default", Metadata: &NodeMetadata{Labels: map[string]string{"app": "test"}}}
+	withOperation := func(cfg config.Config, operation string) config.Config {
+		cfg.Annotations = map[string]string{constants.TraceOperationAnnotation: operation}
+		return cfg
+	}
+	workload := newTelemetry("default", &tpb.Telemetry{
+		Selector: &v1beta1.WorkloadSelector{MatchLabels: map[string]string{"app": "test"}},
+	})
+	workload.Name = "workload"
+	tests := []struct {
+		name string
+		cfgs []config.Config
+		want string
+	}{
+		{
+			name: "none",
+			cfgs: []config.Config{newTelemetry("istio-system", &tpb.Telemetry{})},
+		},
+		{
+			name: "mesh",
+			cfgs: []config.Config{withOperation(newTelemetry("istio-system", &tpb.Telemetry{}), "mesh")},
+			want: "mesh",
+		},
+		{
+			name: "namespace without operation keeps mesh",
+			cfgs: []config.Config{
+				withOperation(newTelemetry("istio-system", &tpb.Telemetry{}), "mesh"),
+				newTelemetry("default", &tpb.Telemetry{}),
+			},
+			want: "mesh",
+		},
+		{
+			name: "workload",
+			cfgs: []config.Config{
+				withOperation(newTelemetry("istio-system", &tpb.Telemetry{}), "mesh"),
+				withOperation(newTelemetry("default", &tpb.Telemetry{}), "namespace"),
+				withOperation(workload, "workload"),
+			},
+			want: "workload",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telemetry := createTestTelemetries(tt.cfgs, t)
+			if got := telemetry.TraceOperation(sidecar); got != tt.want {
+				t.Fatalf("got operation %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestTelemetryFilters(t *testing.T) {
 	overrides := []*tpb.MetricsOverrides{{
 		Match: &tpb.MetricSelector{
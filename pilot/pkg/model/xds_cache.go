@@ -15,17 +15,21 @@
 package model
 
 import (
+	"container/list"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/google/go-cmp/cmp"
-	"github.com/hashicorp/golang-lru/simplelru"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/util/sets"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config"
 	"istio.io/pkg/monitoring"
 )
@@ -34,18 +38,24 @@ func init() {
 	monitoring.MustRegister(xdsCacheReads)
 	monitoring.MustRegister(xdsCacheEvictions)
 	monitoring.MustRegister(xdsCacheSize)
+	monitoring.MustRegister(xdsCacheBytes)
 }
 
 var (
+	resourceTypeTag = monitoring.MustCreateLabel("resource_type")
+	reasonTag       = monitoring.MustCreateLabel("reason")
+
 	xdsCacheReads = monitoring.NewSum(
 		"xds_cache_reads",
-		"Total number of xds cache xdsCacheReads.",
-		monitoring.WithLabels(typeTag),
+		"Total number of xds cache xdsCacheReads, by result and resource type. The hit ratio of a resource type "+
+			"is the ratio of its hits to its total reads.",
+		monitoring.WithLabels(typeTag, resourceTypeTag),
 	)
 
 	xdsCacheEvictions = monitoring.NewSum(
 		"xds_cache_evictions",
-		"Total number of xds cache evictions.",
+		"Total number of xds cache evictions, by resource type and reason.",
+		monitoring.WithLabels(resourceTypeTag, reasonTag),
 	)
 
 	xdsCacheSize = monitoring.NewGauge(
@@ -53,25 +63,40 @@ var (
 		"Current size of xds cache",
 	)
 
-	xdsCacheHits   = xdsCacheReads.With(typeTag.Value("hit"))
-	xdsCacheMisses = xdsCacheReads.With(typeTag.Value("miss"))
+	xdsCacheBytes = monitoring.NewGauge(
+		"xds_cache_bytes",
+		"Current size in bytes of the resources held by the xds cache, by resource type.",
+		monitoring.WithLabels(resourceTypeTag),
+	)
+)
+
+// Reasons for which an entry leaves the cache.
+const (
+	// evictClear is used when the entry is invalidated by a config change.
+	evictClear = "clear"
+	// evictSize is used when the cache exceeds PILOT_XDS_CACHE_SIZE entries.
+	evictSize = "size"
+	// evictBytes is used when the cache exceeds PILOT_XDS_CACHE_MAX_BYTES.
+	evictBytes = "bytes"
+	// evictQuota is used when a resource type exceeds its PILOT_XDS_CACHE_TYPE_QUOTAS quota.
+	evictQuota = "quota"
 )
 
-func hit() {
+func hit(resourceType string) {
 	if features.EnableXDSCacheMetrics {
-		xdsCacheHits.Increment()
+		xdsCacheReads.With(typeTag.Value("hit"), resourceTypeTag.Value(resourceType)).Increment()
 	}
 }
 
-func miss() {
+func miss(resourceType string) {
 	if features.EnableXDSCacheMetrics {
-		xdsCacheMisses.Increment()
+		xdsCacheReads.With(typeTag.Value("miss"), resourceTypeTag.Value(resourceType)).Increment()
 	}
 }
 
-func evict(k interface{}, v interface{}) {
-	if features.EnableXDSCacheMetrics {
-		xdsCacheEvictions.Increment()
+func evict(resourceType string, reason string, count int) {
+	if features.EnableXDSCacheMetrics && count > 0 {
+		xdsCacheEvictions.With(resourceTypeTag.Value(resourceType), reasonTag.Value(reason)).RecordInt(int64(count))
 	}
 }
 
@@ -81,6 +106,12 @@ func size(cs int) {
 	}
 }
 
+func bytesSize(resourceType string, bs int64) {
+	if features.EnableXDSCacheMetrics {
+		xdsCacheBytes.With(resourceTypeTag.Value(resourceType)).Record(float64(bs))
+	}
+}
+
 func indexConfig(configIndex map[ConfigKey]sets.Set, k string, entry XdsCacheEntry) {
 	for _, config := range entry.DependentConfigs() {
 		if configIndex[config] == nil {
@@ -114,6 +145,9 @@ type XdsCacheEntry interface {
 	// Cacheable indicates whether this entry is valid for cache. For example
 	// for EDS to be cacheable, the Endpoint should have corresponding service.
 	Cacheable() bool
+	// ResourceType is the xDS type URL of the cached resource. It is used to account the
+	// cache usage, and to enforce the quotas, per resource type.
+	ResourceType() string
 }
 
 type CacheToken uint64
@@ -141,27 +175,32 @@ type XdsCache interface {
 
 // NewXdsCache returns an instance of a cache.
 func NewXdsCache() XdsCache {
-	return &lruCache{
-		enableAssertions: features.EnableUnsafeAssertions,
-		store:            newLru(),
-		configIndex:      map[ConfigKey]sets.Set{},
-		typesIndex:       map[config.GroupVersionKind]sets.Set{},
-	}
+	return newLruCache(features.EnableUnsafeAssertions)
 }
 
 // NewLenientXdsCache returns an instance of a cache that does not validate token based get/set and enable assertions.
 func NewLenientXdsCache() XdsCache {
-	return &lruCache{
-		enableAssertions: false,
-		store:            newLru(),
-		configIndex:      map[ConfigKey]sets.Set{},
-		typesIndex:       map[config.GroupVersionKind]sets.Set{},
-	}
+	return newLruCache(false)
 }
 
+// lruCache is a least recently used cache bounded by its number of entries, by the total size of
+// the cached resources and by per resource type size quotas.
 type lruCache struct {
 	enableAssertions bool
-	store            simplelru.LRUCache
+	maxEntries       int
+	// maxBytes is the maximum total size of the entries, or 0 if unbounded.
+	maxBytes int64
+	// typeQuotas are the maximum total sizes of the entries of each resource type, keyed by
+	// the short resource type (cds, eds, ...).
+	typeQuotas map[string]int64
+	entries    map[string]*cacheEntry
+	// lru holds all the entries, most recently used first.
+	lru *list.List
+	// typeLru holds the entries of each resource type, most recently used first.
+	typeLru map[string]*list.List
+	// bytes and typeBytes are the total size of the entries, overall and by resource type.
+	bytes     int64
+	typeBytes map[string]int64
 	// token stores the latest token of the store, used to prevent stale data overwrite.
 	// It is refreshed when Clear or ClearAll are called
 	token       CacheToken
@@ -172,16 +211,139 @@ type lruCache struct {
 
 var _ XdsCache = &lruCache{}
 
-func newLru() simplelru.LRUCache {
+type cacheEntry struct {
+	key          string
+	resourceType string
+	value        cacheValue
+	size         int64
+	// lruElem and typeLruElem are the positions of the entry in the lru and typeLru lists.
+	lruElem     *list.Element
+	typeLruElem *list.Element
+}
+
+func newLruCache(enableAssertions bool) *lruCache {
 	sz := features.XDSCacheMaxSize
 	if sz <= 0 {
 		sz = 20000
 	}
-	l, err := simplelru.NewLRU(sz, evict)
-	if err != nil {
-		panic(fmt.Errorf("invalid lru configuration: %v", err))
+	return &lruCache{
+		enableAssertions: enableAssertions,
+		maxEntries:       sz,
+		maxBytes:         int64(features.XDSCacheMaxBytes),
+		typeQuotas:       parseXdsCacheTypeQuotas(features.XDSCacheTypeQuotas),
+		entries:          map[string]*cacheEntry{},
+		lru:              list.New(),
+		typeLru:          map[string]*list.List{},
+		typeBytes:        map[string]int64{},
+		configIndex:      map[ConfigKey]sets.Set{},
+		typesIndex:       map[config.GroupVersionKind]sets.Set{},
+	}
+}
+
+// parseXdsCacheTypeQuotas parses the PILOT_XDS_CACHE_TYPE_QUOTAS value, in the form
+// "rds=256Mi,eds=128Mi". Invalid quotas are logged and ignored.
+func parseXdsCacheTypeQuotas(spec string) map[string]int64 {
+	quotas := map[string]int64{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			log.Warnf("ignoring invalid xds cache quota %q: expected type=quantity", item)
+			continue
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(kv[1]))
+		if err != nil || q.Sign() <= 0 {
+			log.Warnf("ignoring invalid xds cache quota %q: expected a positive quantity", item)
+			continue
+		}
+		quotas[strings.TrimSpace(kv[0])] = q.Value()
+	}
+	return quotas
+}
+
+// entrySize approximates the memory held by a cache entry, dominated by the serialized resource.
+func entrySize(key string, value *discovery.Resource) int64 {
+	return int64(len(key) + proto.Size(value))
+}
+
+// get returns the entry for the key, marking it as the most recently used.
+func (l *lruCache) get(k string) (*cacheEntry, bool) {
+	e, f := l.entries[k]
+	if f {
+		l.lru.MoveToFront(e.lruElem)
+		l.typeLru[e.resourceType].MoveToFront(e.typeLruElem)
+	}
+	return e, f
+}
+
+// add adds or replaces the entry for the key, then evicts the least recently used entries
+// until the cache is within its limits.
+func (l *lruCache) add(k string, resourceType string, value cacheValue) {
+	sz := entrySize(k, value.value)
+	quota, hasQuota := l.typeQuotas[resourceType]
+	if (l.maxBytes > 0 && sz > l.maxBytes) || (hasQuota && sz > quota) {
+		// The entry could never fit, caching it would only flush the other entries.
+		if e, f := l.entries[k]; f {
+			l.remove(e)
+		}
+		return
+	}
+	if e, f := l.get(k); f {
+		l.bytes += sz - e.size
+		l.typeBytes[e.resourceType] += sz - e.size
+		e.value = value
+		e.size = sz
+	} else {
+		e = &cacheEntry{key: k, resourceType: resourceType, value: value, size: sz}
+		if l.typeLru[resourceType] == nil {
+			l.typeLru[resourceType] = list.New()
+		}
+		e.lruElem = l.lru.PushFront(e)
+		e.typeLruElem = l.typeLru[resourceType].PushFront(e)
+		l.entries[k] = e
+		l.bytes += sz
+		l.typeBytes[resourceType] += sz
+	}
+
+	// The new entry is the most recently used and fits on its own, so it is never evicted here.
+	if hasQuota {
+		evicted := 0
+		for l.typeBytes[resourceType] > quota {
+			l.remove(l.typeLru[resourceType].Back().Value.(*cacheEntry))
+			evicted++
+		}
+		evict(resourceType, evictQuota, evicted)
+	}
+	for l.maxBytes > 0 && l.bytes > l.maxBytes {
+		e := l.lru.Back().Value.(*cacheEntry)
+		l.remove(e)
+		evict(e.resourceType, evictBytes, 1)
+	}
+	for len(l.entries) > l.maxEntries {
+		e := l.lru.Back().Value.(*cacheEntry)
+		l.remove(e)
+		evict(e.resourceType, evictSize, 1)
+	}
+}
+
+// remove removes the entry from the cache. The caller is responsible for recording the eviction.
+func (l *lruCache) remove(e *cacheEntry) {
+	l.lru.Remove(e.lruElem)
+	l.typeLru[e.resourceType].Remove(e.typeLruElem)
+	delete(l.entries, e.key)
+	l.bytes -= e.size
+	l.typeBytes[e.resourceType] -= e.size
+}
+
+// recordSize records the current size of the cache.
+func (l *lruCache) recordSize() {
+	size(len(l.entries))
+	for resourceType, bs := range l.typeBytes {
+		bytesSize(resourceType, bs)
 	}
-	return l
 }
 
 // assertUnchanged checks that a cache entry is not changed. This helps catch bad cache invalidation
@@ -229,16 +391,16 @@ func (l *lruCache) Add(entry XdsCacheEntry, pushReq *PushRequest, value *discove
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	k := entry.Key()
-	cur, f := l.store.Get(k)
+	cur, f := l.get(k)
 	if f {
 		// This is the stale resource
-		if token < cur.(cacheValue).token || token < l.token {
+		if token < cur.value.token || token < l.token {
 			// entry may be stale, we need to drop it. This can happen when the cache is invalidated
 			// after we call Get.
 			return
 		}
 		if l.enableAssertions {
-			l.assertUnchanged(k, cur.(cacheValue).value, value)
+			l.assertUnchanged(k, cur.value.value, value)
 		}
 	}
 
@@ -247,11 +409,11 @@ func (l *lruCache) Add(entry XdsCacheEntry, pushReq *PushRequest, value *discove
 	}
 
 	toWrite := cacheValue{value: value, token: token}
-	l.store.Add(k, toWrite)
+	l.add(k, v3.GetMetricType(entry.ResourceType()), toWrite)
 	l.token = token
 	indexConfig(l.configIndex, k, entry)
 	indexType(l.typesIndex, k, entry)
-	size(l.store.Len())
+	l.recordSize()
 }
 
 type cacheValue struct {
@@ -266,56 +428,71 @@ func (l *lruCache) Get(entry XdsCacheEntry) (*discovery.Resource, bool) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	k := entry.Key()
-	val, ok := l.store.Get(k)
-	if !ok {
-		miss()
-		return nil, false
-	}
-	cv := val.(cacheValue)
-	if cv.value == nil {
-		miss()
+	resourceType := v3.GetMetricType(entry.ResourceType())
+	e, ok := l.get(k)
+	if !ok || e.value.value == nil {
+		miss(resourceType)
 		return nil, false
 	}
-	hit()
-	return cv.value, true
+	hit(resourceType)
+	return e.value.value, true
 }
 
 func (l *lruCache) Clear(configs map[ConfigKey]struct{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.token = CacheToken(time.Now().UnixNano())
+	evicted := map[string]int{}
+	clearKey := func(key string) {
+		if e, f := l.entries[key]; f {
+			l.remove(e)
+			evicted[e.resourceType]++
+		}
+	}
 	for ckey := range configs {
 		referenced := l.configIndex[ckey]
 		delete(l.configIndex, ckey)
 		for key := range referenced {
-			l.store.Remove(key)
+			clearKey(key)
 		}
 		tReferenced := l.typesIndex[ckey.Kind]
 		delete(l.typesIndex, ckey.Kind)
 		for key := range tReferenced {
-			l.store.Remove(key)
+			clearKey(key)
 		}
 	}
-	size(l.store.Len())
+	for resourceType, count := range evicted {
+		evict(resourceType, evictClear, count)
+	}
+	l.recordSize()
 }
 
 func (l *lruCache) ClearAll() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.token = CacheToken(time.Now().UnixNano())
-	l.store.Purge()
+	for resourceType, entries := range l.typeLru {
+		evict(resourceType, evictClear, entries.Len())
+	}
+	l.entries = map[string]*cacheEntry{}
+	l.lru.Init()
+	l.typeLru = map[string]*list.List{}
+	l.bytes = 0
+	for resourceType := range l.typeBytes {
+		l.typeBytes[resourceType] = 0
+	}
 	l.configIndex = map[ConfigKey]sets.Set{}
 	l.typesIndex = map[config.GroupVersionKind]sets.Set{}
-	size(l.store.Len())
+	l.recordSize()
 }
 
+// Keys returns the keys from the least to the most recently used.
 func (l *lruCache) Keys() []string {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
-	iKeys := l.store.Keys()
-	keys := make([]string, 0, len(iKeys))
-	for _, ik := range iKeys {
-		keys = append(keys, ik.(string))
+	keys := make([]string, 0, len(l.entries))
+	for e := l.lru.Back(); e != nil; e = e.Prev() {
+		keys = append(keys, e.Value.(*cacheEntry).key)
 	}
 	return keys
 }
@@ -323,15 +500,9 @@ func (l *lruCache) Keys() []string {
 func (l *lruCache) Snapshot() map[string]*discovery.Resource {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
-	iKeys := l.store.Keys()
-	res := make(map[string]*discovery.Resource, len(iKeys))
-	for _, ik := range iKeys {
-		v, ok := l.store.Get(ik)
-		if !ok {
-			continue
-		}
-
-		res[ik.(string)] = v.(cacheValue).value
+	res := make(map[string]*discovery.Resource, len(l.entries))
+	for k, e := range l.entries {
+		res[k] = e.value.value
 	}
 	return res
 }
@@ -0,0 +1,207 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apigen
+
+import (
+	"fmt"
+	"strings"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	gogotypes "github.com/gogo/protobuf/types"
+	golangany "google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/provider"
+	"istio.io/istio/pilot/pkg/serviceregistry/serviceentry"
+	"istio.io/istio/pilot/pkg/util/sets"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/pkg/log"
+)
+
+// ConfigExport is the generator of the clients exporting the config of istiod, such as other meshes or auditing
+// systems.
+const ConfigExport = "config-export"
+
+// allNamespaces grants a reader all the namespaces, and the mesh config.
+const allNamespaces = "*"
+
+// ConfigExportGenerator serves a read-only snapshot of the valid config of istiod, using the same type URLs as the
+// APIGenerator: networking.istio.io/v1alpha3/VirtualService, for example. Unlike the APIGenerator, clients must be
+// authenticated and allowed to read the namespaces of the config, and config failing validation is not exported.
+// Services are exported as synthetic ServiceEntries, without their endpoints.
+type ConfigExportGenerator struct {
+	store model.IstioConfigStore
+	// readers are the namespaces each client, identified by <namespace>/<service account>, can read.
+	readers map[string]sets.Set
+}
+
+func NewConfigExportGenerator(store model.IstioConfigStore, readers map[string]sets.Set) *ConfigExportGenerator {
+	return &ConfigExportGenerator{
+		store:   store,
+		readers: readers,
+	}
+}
+
+// ParseConfigExportReaders parses the clients allowed to export config, and the namespaces they can read, for
+// example audit/auditor=*,mesh-b/istiod=foo|bar.
+func ParseConfigExportReaders(value string) (map[string]sets.Set, error) {
+	out := map[string]sets.Set{}
+	for _, reader := range strings.Split(value, ",") {
+		reader = strings.TrimSpace(reader)
+		if reader == "" {
+			continue
+		}
+		parts := strings.Split(reader, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid config export reader %q: must be <namespace>/<service account>=<namespaces>", reader)
+		}
+		id := strings.Split(parts[0], "/")
+		if len(id) != 2 || id[0] == "" || id[1] == "" {
+			return nil, fmt.Errorf("invalid config export reader %q: must be <namespace>/<service account>", parts[0])
+		}
+		namespaces := sets.NewSet()
+		for _, ns := range strings.Split(parts[1], "|") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				namespaces.Insert(ns)
+			}
+		}
+		if namespaces.Empty() {
+			return nil, fmt.Errorf("config export reader %s must be allowed at least one namespace", parts[0])
+		}
+		out[parts[0]] = namespaces
+	}
+	return out, nil
+}
+
+// Generate returns the config of the watched type in the namespaces the client is allowed to read, restricted to
+// the CONFIG_EXPORT_NAMESPACES of its metadata, if any.
+func (g *ConfigExportGenerator) Generate(proxy *model.Proxy, push *model.PushContext, w *model.WatchedResource,
+	updates *model.PushRequest) (model.Resources, model.XdsLogDetails, error) {
+	all, namespaces, err := g.namespaces(proxy)
+	if err != nil {
+		return nil, model.DefaultXdsLogDetails, err
+	}
+	resp := model.Resources{}
+	kind := strings.SplitN(w.TypeUrl, "/", 3)
+	if len(kind) != 3 {
+		log.Warnf("ADS: Unknown watched resources %s", w.TypeUrl)
+		return resp, model.DefaultXdsLogDetails, nil
+	}
+	rgvk := config.GroupVersionKind{
+		Group:   kind[0],
+		Version: kind[1],
+		Kind:    kind[2],
+	}
+	if w.TypeUrl == collections.IstioMeshV1Alpha1MeshConfig.Resource().GroupVersionKind().String() {
+		if !all {
+			return resp, model.DefaultXdsLogDetails, nil
+		}
+		meshAny, err := gogotypes.MarshalAny(push.Mesh)
+		if err != nil {
+			return nil, model.DefaultXdsLogDetails, err
+		}
+		resp = append(resp, &discovery.Resource{
+			Resource: &golangany.Any{TypeUrl: meshAny.TypeUrl, Value: meshAny.Value},
+		})
+		return resp, model.DefaultXdsLogDetails, nil
+	}
+	schema, f := collections.Pilot.FindByGroupVersionKind(rgvk)
+	if !f {
+		// An empty set indicates we have no such config, as for the APIGenerator.
+		return resp, model.DefaultXdsLogDetails, nil
+	}
+
+	cfgs, err := g.store.List(rgvk, model.NamespaceAll)
+	if err != nil {
+		log.Warnf("ADS: Error reading resource %s %v", w.TypeUrl, err)
+		return resp, model.DefaultXdsLogDetails, nil
+	}
+	for i := range cfgs {
+		c := &cfgs[i]
+		if !all && !namespaces.Contains(c.Namespace) {
+			continue
+		}
+		if _, err := schema.Resource().ValidateConfig(*c); err != nil {
+			log.Debugf("ADS: Not exporting invalid %s %s/%s: %v", rgvk.Kind, c.Namespace, c.Name, err)
+			continue
+		}
+		r, err := configToResource(c)
+		if err != nil {
+			log.Warn("Resource error ", err, " ", c.Namespace, "/", c.Name)
+			continue
+		}
+		resp = append(resp, r)
+	}
+
+	if rgvk == gvk.ServiceEntry {
+		for _, s := range push.GetAllServices() {
+			// Ignore services that are result of conversion from ServiceEntry.
+			if s.Attributes.ServiceRegistry == provider.External {
+				continue
+			}
+			if !all && !namespaces.Contains(s.Attributes.Namespace) {
+				continue
+			}
+			r, err := configToResource(serviceentry.ServiceToServiceEntry(s, proxy))
+			if err != nil {
+				log.Warn("Resource error ", err, " ", s.Attributes.Namespace, "/", s.Hostname)
+				continue
+			}
+			resp = append(resp, r)
+		}
+	}
+	return resp, model.DefaultXdsLogDetails, nil
+}
+
+// namespaces returns the namespaces the client of the proxy can read, or all if it can read every namespace and the
+// mesh config.
+func (g *ConfigExportGenerator) namespaces(proxy *model.Proxy) (bool, sets.Set, error) {
+	id := proxy.VerifiedIdentity
+	if id == nil {
+		return false, nil, fmt.Errorf("config export requires an authenticated client")
+	}
+	granted, f := g.readers[id.Namespace+"/"+id.ServiceAccount]
+	if !f {
+		return false, nil, fmt.Errorf("%s/%s is not allowed to export config", id.Namespace, id.ServiceAccount)
+	}
+	requested := sets.NewSet(proxy.Metadata.ConfigExportNamespaces...).Delete("")
+	if granted.Contains(allNamespaces) {
+		if requested.Empty() {
+			return true, nil, nil
+		}
+		return false, requested, nil
+	}
+	if requested.Empty() {
+		return false, granted, nil
+	}
+	return false, granted.Intersection(requested), nil
+}
+
+func configToResource(c *config.Config) (*discovery.Resource, error) {
+	b, err := config.PilotConfigToResource(c)
+	if err != nil {
+		return nil, err
+	}
+	bany, err := gogotypes.MarshalAny(b)
+	if err != nil {
+		return nil, err
+	}
+	return &discovery.Resource{
+		Name:     c.Namespace + "/" + c.Name,
+		Resource: &golangany.Any{TypeUrl: bany.TypeUrl, Value: bany.Value},
+	}, nil
+}
@@ -0,0 +1,139 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apigen_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/apigen"
+	"istio.io/istio/pilot/pkg/util/sets"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/spiffe"
+)
+
+func TestParseConfigExportReaders(t *testing.T) {
+	got, err := apigen.ParseConfigExportReaders("audit/auditor=*, mesh-b/istiod=foo|bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]sets.Set{
+		"audit/auditor": sets.NewSet("*"),
+		"mesh-b/istiod": sets.NewSet("foo", "bar"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, invalid := range []string{"auditor=*", "audit/auditor", "audit/=foo", "audit/auditor=|"} {
+		if _, err := apigen.ParseConfigExportReaders(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestConfigExportGenerator(t *testing.T) {
+	store := model.MakeIstioStore(memory.NewController(memory.MakeSkipValidation(collections.Pilot)))
+	for _, c := range []struct {
+		namespace string
+		name      string
+		route     *networking.HTTPRoute
+	}{
+		{"foo", "reviews", &networking.HTTPRoute{Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "reviews"}}}}},
+		{"bar", "ratings", &networking.HTTPRoute{Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "ratings"}}}}},
+		{"baz", "details", &networking.HTTPRoute{Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "details"}}}}},
+		// Invalid: a route must have a destination, a redirect or a direct response.
+		{"foo", "invalid", &networking.HTTPRoute{}},
+	} {
+		if _, err := store.Create(config.Config{
+			Meta: config.Meta{GroupVersionKind: gvk.VirtualService, Namespace: c.namespace, Name: c.name},
+			Spec: &networking.VirtualService{
+				Hosts: []string{c.name},
+				Http:  []*networking.HTTPRoute{c.route},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readers, err := apigen.ParseConfigExportReaders("audit/auditor=*,mesh-b/istiod=foo|bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen := apigen.NewConfigExportGenerator(store, readers)
+	push := model.NewPushContext()
+	m := mesh.DefaultMeshConfig()
+	push.Mesh = &m
+
+	proxy := func(ns, sa string, namespaces ...string) *model.Proxy {
+		p := &model.Proxy{Metadata: &model.NodeMetadata{ConfigExportNamespaces: namespaces}}
+		if ns != "" {
+			p.VerifiedIdentity = &spiffe.Identity{Namespace: ns, ServiceAccount: sa}
+		}
+		return p
+	}
+	names := func(t *testing.T, p *model.Proxy, typeURL string) []string {
+		t.Helper()
+		res, _, err := gen.Generate(p, push, &model.WatchedResource{TypeUrl: typeURL}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := []string{}
+		for _, r := range res {
+			out = append(out, r.Name)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	cases := []struct {
+		name    string
+		proxy   *model.Proxy
+		typeURL string
+		want    []string
+	}{
+		{"all namespaces", proxy("audit", "auditor"), gvk.VirtualService.String(), []string{"bar/ratings", "baz/details", "foo/reviews"}},
+		{"requested namespaces", proxy("audit", "auditor", "baz"), gvk.VirtualService.String(), []string{"baz/details"}},
+		{"granted namespaces", proxy("mesh-b", "istiod"), gvk.VirtualService.String(), []string{"bar/ratings", "foo/reviews"}},
+		{"requested and granted namespaces", proxy("mesh-b", "istiod", "bar", "baz"), gvk.VirtualService.String(), []string{"bar/ratings"}},
+		{"mesh config", proxy("audit", "auditor"), collections.IstioMeshV1Alpha1MeshConfig.Resource().GroupVersionKind().String(), []string{""}},
+		{"mesh config not granted", proxy("mesh-b", "istiod"), collections.IstioMeshV1Alpha1MeshConfig.Resource().GroupVersionKind().String(), []string{}},
+		{"unknown type", proxy("audit", "auditor"), "example.com/v1/Unknown", []string{}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(t, tt.proxy, tt.typeURL); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for name, p := range map[string]*model.Proxy{
+		"unauthenticated": proxy("", ""),
+		"not allowed":     proxy("foo", "default"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := gen.Generate(p, push, &model.WatchedResource{TypeUrl: gvk.VirtualService.String()}, nil); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pilot/pkg/util/constant"
+)
+
+// IsJWTClaimHeader returns true if a header name used in a route match refers to a JWT claim.
+func IsJWTClaimHeader(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(lower, constant.HeaderJWTClaim) || strings.HasPrefix(lower, constant.HeaderJWTClaimBracket)
+}
+
+// ParseJWTClaimHeader returns the path of the nested claims referred to by a JWT claim header name. Claim names are
+// either separated by "." or, if they contain ".", each surrounded by brackets:
+// - `@request.auth.claims.group.id` refers to the nested claims "group" and "id".
+// - `@request.auth.claims[example.com/groups][id]` refers to the nested claims "example.com/groups" and "id".
+func ParseJWTClaimHeader(name string) ([]string, error) {
+	if !IsJWTClaimHeader(name) {
+		return nil, fmt.Errorf("%s is not a JWT claim header", name)
+	}
+	if strings.HasPrefix(strings.ToLower(name), constant.HeaderJWTClaim) {
+		return strings.Split(name[len(constant.HeaderJWTClaim):], "."), nil
+	}
+	var claims []string
+	for rest := name[len(constant.HeaderJWTClaimBracket)-1:]; rest != ""; {
+		end := strings.Index(rest, "]")
+		if rest[0] != '[' || end == -1 || strings.Contains(rest[1:end], "[") {
+			return nil, fmt.Errorf("invalid JWT claim header %s: expecting format [<NAME>][<NAME>]", name)
+		}
+		if end == 1 {
+			return nil, fmt.Errorf("invalid JWT claim header %s: claim name cannot be empty", name)
+		}
+		claims = append(claims, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return claims, nil
+}
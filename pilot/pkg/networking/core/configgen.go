@@ -33,6 +33,10 @@ type ConfigGenerator interface {
 	// once and shared across multiple invocations of this function.
 	BuildListeners(node *model.Proxy, push *model.PushContext) []*listener.Listener
 
+	// ProfileListeners builds the listeners like BuildListeners, recording the time spent by each listener builder
+	// into the profile. This is used for debugging.
+	ProfileListeners(node *model.Proxy, push *model.PushContext, profile *model.GenerationProfile) []*listener.Listener
+
 	// BuildClusters returns the list of clusters for the given proxy. This is the CDS output
 	BuildClusters(node *model.Proxy, req *model.PushRequest) ([]*discovery.Resource, model.XdsLogDetails)
 
@@ -44,6 +48,11 @@ type ConfigGenerator interface {
 	// BuildHTTPRoutes returns the list of HTTP routes for the given proxy. This is the RDS output
 	BuildHTTPRoutes(node *model.Proxy, req *model.PushRequest, routeNames []string) ([]*discovery.Resource, model.XdsLogDetails)
 
+	// BuildDeltaHTTPRoutes returns the HTTP routes that need to be pushed for a given proxy, skipping routes whose
+	// inputs have not changed. This is Delta RDS output.
+	BuildDeltaHTTPRoutes(node *model.Proxy, updates *model.PushRequest,
+		watched *model.WatchedResource) ([]*discovery.Resource, model.XdsLogDetails, bool)
+
 	// BuildNameTable returns list of hostnames and the associated IPs
 	BuildNameTable(node *model.Proxy, push *model.PushContext) *dnsProto.NameTable
 
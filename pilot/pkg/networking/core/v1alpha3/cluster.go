@@ -26,7 +26,6 @@ import (
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/gogo/protobuf/types"
-	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/structpb"
 	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 
@@ -55,9 +54,9 @@ var defaultTransportSocketMatch = &cluster.Cluster_TransportSocketMatch{
 	},
 }
 
-// deltaConfigTypes are used to detect changes and trigger delta calculations. When config updates has ONLY entries
-// in this map, then delta calculation is triggered.
-var deltaConfigTypes = sets.NewSet(gvk.ServiceEntry.Kind)
+// deltaClusterConfigTypes are the config types for which clusters are built as deltas, using the services and
+// destination rules indexed by the sidecar scope to find the affected clusters.
+var deltaClusterConfigTypes = sets.NewSet(gvk.ServiceEntry.Kind, gvk.DestinationRule.Kind)
 
 // getDefaultCircuitBreakerThresholds returns a copy of the default circuit breaker thresholds for the given traffic direction.
 func getDefaultCircuitBreakerThresholds() *cluster.CircuitBreakers_Thresholds {
@@ -75,6 +74,22 @@ func getDefaultCircuitBreakerThresholds() *cluster.CircuitBreakers_Thresholds {
 	}
 }
 
+// getDefaultRetryBudget returns the retry budget of the clusters whose DestinationRule does not set http.maxRetries, or
+// nil if PILOT_RETRY_BUDGET_PERCENT is not set. The budget limits the retries to a share of the active requests, so
+// retries cannot amplify the load of a failing upstream.
+func getDefaultRetryBudget() *cluster.CircuitBreakers_Thresholds_RetryBudget {
+	if features.RetryBudgetPercent <= 0 {
+		return nil
+	}
+	budget := &cluster.CircuitBreakers_Thresholds_RetryBudget{
+		BudgetPercent: &xdstype.Percent{Value: math.Min(features.RetryBudgetPercent, 100)},
+	}
+	if features.RetryBudgetMinRetryConcurrency >= 0 {
+		budget.MinRetryConcurrency = &wrappers.UInt32Value{Value: uint32(features.RetryBudgetMinRetryConcurrency)}
+	}
+	return budget
+}
+
 // BuildClusters returns the list of clusters for the given proxy. This is the CDS output
 // For outbound: Cluster for each service/subset hostname or cidr with SNI set to service hostname
 // Cluster type based on resolution
@@ -90,63 +105,74 @@ func (configgen *ConfigGeneratorImpl) BuildClusters(proxy *model.Proxy, req *mod
 	return configgen.buildClusters(proxy, req, services)
 }
 
-// BuildDeltaClusters generates the deltas (add and delete) for a given proxy. Currently, only service changes are reflected with deltas.
+// BuildDeltaClusters generates the deltas (add and delete) for a given proxy. Only the clusters of the services affected
+// by the updated ServiceEntries and DestinationRules are rebuilt, along with the clusters not tied to a service.
 // Otherwise, we fall back onto generating everything.
 func (configgen *ConfigGeneratorImpl) BuildDeltaClusters(proxy *model.Proxy, updates *model.PushRequest,
 	watched *model.WatchedResource) ([]*discovery.Resource, []string, model.XdsLogDetails, bool) {
+	var updatedHosts sets.Set
+	var ok bool
 	// if we can't use delta, fall back to generate all
-	if !shouldUseDelta(updates) {
+	if shouldUseDeltaClusters(proxy, updates) {
+		updatedHosts, ok = clusterDependencies(proxy, updates)
+	}
+	if !ok {
 		cl, lg := configgen.BuildClusters(proxy, updates)
 		return cl, nil, lg, false
 	}
 
-	deletedClusters := make([]string, 0)
-	services := make([]*model.Service, 0)
-	// holds clusters per service, keyed by hostname.
-	serviceClusters := make(map[string]sets.Set)
-	// holds service ports, keyed by hostname.
-	// inner map holds port and its cluster name.
-	servicePorts := make(map[string]map[int]string)
+	services := make([]*model.Service, 0, len(updatedHosts))
+	for hostname := range updatedHosts {
+		// if the service is removed, all its clusters are removed below.
+		if service := updates.Push.ServiceForHostname(proxy, host.Name(hostname)); service != nil {
+			services = append(services, service)
+		}
+	}
+	clusters, log := configgen.buildClusters(proxy, updates, services)
 
+	// The watched clusters of the updated services that are not built anymore are removed, such as the clusters of
+	// removed services, ports or subsets.
+	built := sets.NewSet()
+	for _, c := range clusters {
+		built.Insert(c.Name)
+	}
+	deletedClusters := make([]string, 0)
 	for _, cluster := range watched.ResourceNames {
 		// WatchedResources.ResourceNames will contain the names of the clusters it is subscribed to. We can
 		// check with the name of our service (cluster names are in the format outbound|<port>||<hostname>.
-		_, _, svcHost, port := model.ParseSubsetKey(cluster)
-		if serviceClusters[string(svcHost)] == nil {
-			serviceClusters[string(svcHost)] = sets.NewSet()
-		}
-		serviceClusters[string(svcHost)].Insert(cluster)
-		if servicePorts[string(svcHost)] == nil {
-			servicePorts[string(svcHost)] = make(map[int]string)
+		direction, _, svcHost, _ := model.ParseSubsetKey(cluster)
+		if direction == model.TrafficDirectionOutbound && updatedHosts.Contains(string(svcHost)) && !built.Contains(cluster) {
+			deletedClusters = append(deletedClusters, cluster)
 		}
-		servicePorts[string(svcHost)][port] = cluster
 	}
+	return clusters, deletedClusters, log, true
+}
 
-	// In delta, we only care about the services that have changed.
+// clusterDependencies returns the hostnames of the services whose clusters are affected by the updated configs, as
+// indexed by the current and previous sidecar scopes of the proxy. It returns false if the clusters affected by an
+// update cannot be determined, such as a destination rule merged into another one.
+func clusterDependencies(proxy *model.Proxy, updates *model.PushRequest) (sets.Set, bool) {
+	hosts := sets.NewSet()
 	for key := range updates.ConfigsUpdated {
-		// get the service that has changed.
-		service := updates.Push.ServiceForHostname(proxy, host.Name(key.Name))
-		// if this service removed, we can conclude that it is a removed cluster.
-		if service == nil {
-			for cluster := range serviceClusters[key.Name] {
-				deletedClusters = append(deletedClusters, cluster)
+		if key.Kind == gvk.ServiceEntry {
+			hosts.Insert(key.Name)
+			continue
+		}
+		found := false
+		for _, scope := range []*model.SidecarScope{proxy.SidecarScope, proxy.PrevSidecarScope} {
+			if scope == nil {
+				continue
 			}
-		} else {
-			services = append(services, service)
-			// If servicePorts has this service, that means it is old service.
-			if servicePorts[service.Hostname.String()] != nil {
-				oldPorts := servicePorts[service.Hostname.String()]
-				for port, cluster := range oldPorts {
-					// if this service port is removed, we can conclude that it is a removed cluster.
-					if _, exists := service.Ports.GetByPort(port); !exists {
-						deletedClusters = append(deletedClusters, cluster)
-					}
-				}
+			if drHosts, f := scope.DestinationRuleHosts(key); f {
+				hosts = hosts.Union(drHosts)
+				found = true
 			}
 		}
+		if !found {
+			return nil, false
+		}
 	}
-	clusters, log := configgen.buildClusters(proxy, updates, services)
-	return clusters, deletedClusters, log, true
+	return hosts, true
 }
 
 // buildClusters builds clusters for the proxy with the services passed.
@@ -162,28 +188,36 @@ func (configgen *ConfigGeneratorImpl) buildClusters(proxy *model.Proxy, req *mod
 	case model.SidecarProxy:
 		// Setup outbound clusters
 		outboundPatcher := clusterPatcher{efw: envoyFilterPatches, pctx: networking.EnvoyFilter_SIDECAR_OUTBOUND}
+		done := req.Profile.Time("outbound_clusters")
 		ob, cs := configgen.buildOutboundClusters(cb, proxy, outboundPatcher, services)
 		cacheStats = cacheStats.merge(cs)
 		resources = append(resources, ob...)
 		// Add a blackhole and passthrough cluster for catching traffic to unresolved routes
 		clusters = outboundPatcher.conditionallyAppend(clusters, nil, cb.buildBlackHoleCluster(), cb.buildDefaultPassthroughCluster())
 		clusters = append(clusters, outboundPatcher.insertedClusters()...)
+		done()
 
 		// Setup inbound clusters
 		inboundPatcher := clusterPatcher{efw: envoyFilterPatches, pctx: networking.EnvoyFilter_SIDECAR_INBOUND}
+		done = req.Profile.Time("inbound_clusters")
 		clusters = append(clusters, configgen.buildInboundClusters(cb, proxy, instances, inboundPatcher)...)
 		// Pass through clusters for inbound traffic. These cluster bind loopback-ish src address to access node local service.
 		clusters = inboundPatcher.conditionallyAppend(clusters, nil, cb.buildInboundPassthroughClusters()...)
 		clusters = append(clusters, inboundPatcher.insertedClusters()...)
+		done()
 	default: // Gateways
 		patcher := clusterPatcher{efw: envoyFilterPatches, pctx: networking.EnvoyFilter_GATEWAY}
+		done := req.Profile.Time("outbound_clusters")
 		ob, cs := configgen.buildOutboundClusters(cb, proxy, patcher, services)
 		cacheStats = cacheStats.merge(cs)
 		resources = append(resources, ob...)
 		// Gateways do not require the default passthrough cluster as they do not have original dst listeners.
 		clusters = patcher.conditionallyAppend(clusters, nil, cb.buildBlackHoleCluster())
+		done()
 		if proxy.Type == model.Router && proxy.MergedGateway != nil && proxy.MergedGateway.ContainsAutoPassthroughGateways {
+			done = req.Profile.Time("sni_dnat_clusters")
 			clusters = append(clusters, configgen.buildOutboundSniDnatClusters(proxy, req, patcher)...)
+			done()
 		}
 		clusters = append(clusters, patcher.insertedClusters()...)
 	}
@@ -199,14 +233,24 @@ func (configgen *ConfigGeneratorImpl) buildClusters(proxy *model.Proxy, req *mod
 	return resources, model.XdsLogDetails{AdditionalInfo: fmt.Sprintf("cached:%v/%v", cacheStats.hits, cacheStats.hits+cacheStats.miss)}
 }
 
-func shouldUseDelta(updates *model.PushRequest) bool {
-	return updates != nil && deltaAwareConfigTypes(updates.ConfigsUpdated) && len(updates.ConfigsUpdated) > 0
-}
-
-// deltaAwareConfigTypes returns true if all updated configs are delta enabled.
-func deltaAwareConfigTypes(cfgs map[model.ConfigKey]struct{}) bool {
-	for k := range cfgs {
-		if !deltaConfigTypes.Contains(k.Kind.Kind) {
+// shouldUseDeltaClusters returns true if the clusters of the proxy can be built as deltas for the updated configs.
+// Destination rules are only handled for sidecars, as gateways build clusters for services not in their scope.
+func shouldUseDeltaClusters(proxy *model.Proxy, updates *model.PushRequest) bool {
+	if updates == nil || len(updates.ConfigsUpdated) == 0 {
+		return false
+	}
+	if !proxy.FeatureEnabled(model.FeatureGateDeltaClusters) {
+		return false
+	}
+	if proxy.Type == model.Router && proxy.MergedGateway != nil && proxy.MergedGateway.ContainsAutoPassthroughGateways {
+		// SNI DNAT clusters are not built per service.
+		return false
+	}
+	for k := range updates.ConfigsUpdated {
+		if !deltaClusterConfigTypes.Contains(k.Kind.Kind) {
+			return false
+		}
+		if k.Kind == gvk.DestinationRule && proxy.Type != model.SidecarProxy {
 			return false
 		}
 	}
@@ -240,6 +284,7 @@ func buildClusterKey(service *model.Service, port *model.Port, cb *ClusterBuilde
 		http2:           port.Protocol.IsHTTP2(),
 		downstreamAuto:  cb.sidecarProxy() && util.IsProtocolSniffingEnabledForOutboundPort(port),
 		supportsIPv4:    cb.supportsIPv4,
+		dualStack:       cb.dualStack(),
 		service:         service,
 		destinationRule: proxy.SidecarScope.DestinationRule(service.Hostname),
 		envoyFilterKeys: efKeys,
@@ -247,68 +292,109 @@ func buildClusterKey(service *model.Service, port *model.Port, cb *ClusterBuilde
 		peerAuthVersion: cb.req.Push.AuthnPolicies.GetVersion(),
 		serviceAccounts: cb.req.Push.ServiceAccounts[service.Hostname][port.Port],
 	}
+	if service.Attributes.DNSJitter > 0 {
+		clusterKey.dnsJitterProxyID = cb.proxyID
+	}
+	if cb.sidecarProxy() {
+		clusterKey.connectTimeout = cb.sidecarScope.EgressConnectTimeout(port.Port)
+	}
 	return clusterKey
 }
 
+// needsUDPCluster returns true if a UDP service port needs its own outbound cluster, used by UDP proxy listeners.
+// A UDP port sharing its number with another port of the service, such as DNS, uses the cluster of that port, as
+// clusters are keyed by port number and both usually reach the same endpoints.
+func needsUDPCluster(service *model.Service, port *model.Port) bool {
+	if !features.EnableUDPProxy {
+		return false
+	}
+	_, f := service.Ports.GetByPort(port.Port)
+	return !f
+}
+
 // buildOutboundClusters generates all outbound (including subsets) clusters for a given proxy.
 func (configgen *ConfigGeneratorImpl) buildOutboundClusters(cb *ClusterBuilder, proxy *model.Proxy, cp clusterPatcher,
 	services []*model.Service) ([]*discovery.Resource, cacheStats) {
-	resources := make([]*discovery.Resource, 0)
-	efKeys := cp.efw.Keys()
-	hit, miss := 0, 0
+	type servicePort struct {
+		service *model.Service
+		port    *model.Port
+	}
+	var servicePorts []servicePort
 	for _, service := range services {
 		for _, port := range service.Ports {
-			if port.Protocol == protocol.UDP {
+			if port.Protocol == protocol.UDP && !needsUDPCluster(service, port) {
 				continue
 			}
-			clusterKey := buildClusterKey(service, port, cb, proxy, efKeys)
-			cached, allFound := cb.getAllCachedSubsetClusters(*clusterKey)
-			if allFound && !features.EnableUnsafeAssertions {
-				hit += len(cached)
-				resources = append(resources, cached...)
-				continue
-			} else {
-				miss += len(cached)
-			}
+			servicePorts = append(servicePorts, servicePort{service: service, port: port})
+		}
+	}
 
-			// We have a cache miss, so we will re-generate the cluster and later store it in the cache.
-			lbEndpoints := cb.buildLocalityLbEndpoints(clusterKey.networkView, service, port.Port, nil)
+	// Clusters are built concurrently when configured to, but are stored by index so the output is deterministic.
+	efKeys := cp.efw.Keys()
+	built := make([][]*discovery.Resource, len(servicePorts))
+	stats := make([]cacheStats, len(servicePorts))
+	buildInParallel(len(servicePorts), configGenerationWorkers(len(servicePorts)), func(_, i int) {
+		built[i], stats[i] = configgen.buildOutboundServicePortClusters(cb, proxy, cp, servicePorts[i].service, servicePorts[i].port, efKeys)
+	})
 
-			// create default cluster
-			discoveryType := convertResolution(cb.proxyType, service)
-			defaultCluster := cb.buildDefaultCluster(clusterKey.clusterName, discoveryType, lbEndpoints, model.TrafficDirectionOutbound, port, service, nil)
-			if defaultCluster == nil {
-				continue
-			}
-			// If stat name is configured, build the alternate stats name.
-			if len(cb.req.Push.Mesh.OutboundClusterStatName) != 0 {
-				defaultCluster.cluster.AltStatName = util.BuildStatPrefix(cb.req.Push.Mesh.OutboundClusterStatName,
-					string(service.Hostname), "", port, &service.Attributes)
-			}
+	resources := make([]*discovery.Resource, 0)
+	var cs cacheStats
+	for i := range built {
+		resources = append(resources, built[i]...)
+		cs = cs.merge(stats[i])
+	}
+	return resources, cs
+}
 
-			subsetClusters := cb.applyDestinationRule(defaultCluster, DefaultClusterMode, service, port,
-				clusterKey.networkView, clusterKey.destinationRule, clusterKey.serviceAccounts)
+// buildOutboundServicePortClusters generates the outbound default and subset clusters for a service port.
+func (configgen *ConfigGeneratorImpl) buildOutboundServicePortClusters(cb *ClusterBuilder, proxy *model.Proxy, cp clusterPatcher,
+	service *model.Service, port *model.Port, efKeys []string) ([]*discovery.Resource, cacheStats) {
+	clusterKey := buildClusterKey(service, port, cb, proxy, efKeys)
+	cached, allFound := cb.getAllCachedSubsetClusters(*clusterKey)
+	if allFound && !features.EnableUnsafeAssertions {
+		return cached, cacheStats{hits: len(cached)}
+	}
+	// We have a cache miss, so we will re-generate the cluster and later store it in the cache.
+	stats := cacheStats{miss: len(cached)}
 
-			if patched := cp.applyResource(nil, defaultCluster.build()); patched != nil {
-				resources = append(resources, patched)
-				if features.EnableCDSCaching {
-					cb.cache.Add(clusterKey, cb.req, patched)
-				}
-			}
-			for _, ss := range subsetClusters {
-				if patched := cp.applyResource(nil, ss); patched != nil {
-					nk := *clusterKey
-					nk.clusterName = ss.Name
-					resources = append(resources, patched)
-					if features.EnableCDSCaching {
-						cb.cache.Add(&nk, cb.req, patched)
-					}
-				}
+	lbEndpoints := cb.buildLocalityLbEndpoints(clusterKey.networkView, service, port.Port, nil)
+
+	// create default cluster
+	discoveryType := convertResolution(cb.proxyType, service)
+	if forcesOriginalDst(clusterKey.destinationRule) {
+		discoveryType = cluster.Cluster_ORIGINAL_DST
+	}
+	defaultCluster := cb.buildDefaultCluster(clusterKey.clusterName, discoveryType, lbEndpoints, model.TrafficDirectionOutbound, port, service, nil)
+	if defaultCluster == nil {
+		return nil, stats
+	}
+	// If stat name is configured, build the alternate stats name.
+	if len(cb.req.Push.Mesh.OutboundClusterStatName) != 0 {
+		defaultCluster.cluster.AltStatName = util.BuildStatPrefix(cb.req.Push.Mesh.OutboundClusterStatName,
+			string(service.Hostname), "", port, &service.Attributes)
+	}
+
+	subsetClusters := cb.applyDestinationRule(defaultCluster, DefaultClusterMode, service, port,
+		clusterKey.networkView, clusterKey.destinationRule, clusterKey.serviceAccounts)
+
+	var resources []*discovery.Resource
+	if patched := cp.applyResource(nil, defaultCluster.build()); patched != nil {
+		resources = append(resources, patched)
+		if features.EnableCDSCaching {
+			cb.cache.Add(clusterKey, cb.req, patched)
+		}
+	}
+	for _, ss := range subsetClusters {
+		if patched := cp.applyResource(nil, ss); patched != nil {
+			nk := *clusterKey
+			nk.clusterName = ss.Name
+			resources = append(resources, patched)
+			if features.EnableCDSCaching {
+				cb.cache.Add(&nk, cb.req, patched)
 			}
 		}
 	}
-
-	return resources, cacheStats{hits: hit, miss: miss}
+	return resources, stats
 }
 
 type clusterPatcher struct {
@@ -348,7 +434,7 @@ func (p clusterPatcher) insertedClusters() []*cluster.Cluster {
 }
 
 func (p clusterPatcher) hasPatches() bool {
-	return p.efw != nil && len(p.efw.Patches[networking.EnvoyFilter_CLUSTER]) > 0
+	return p.efw.HasPatches(p.pctx, networking.EnvoyFilter_CLUSTER)
 }
 
 // SniDnat clusters do not have any TLS setting, as they simply forward traffic to upstream
@@ -433,12 +519,17 @@ func (configgen *ConfigGeneratorImpl) buildInboundClusters(cb *ClusterBuilder, p
 		}
 
 		clustersToBuild := make(map[int][]*model.ServiceInstance)
+		portRanges := proxy.InboundPortRanges()
 		for _, instance := range instances {
 			// For service instances with the same port,
 			// we still need to capture all the instances on this port, as its required to populate telemetry metadata
 			// The first instance will be used as the "primary" instance; this means if we have an conflicts between
 			// Services the first one wins
 			ep := int(instance.Endpoint.EndpointPort)
+			// The ports of the inbound port ranges are passed through, without a listener or a cluster.
+			if inPortRanges(portRanges, ep) {
+				continue
+			}
 			clustersToBuild[ep] = append(clustersToBuild[ep], instance)
 		}
 
@@ -645,16 +736,28 @@ func setKeepAliveSettings(c *cluster.Cluster, keepalive *networking.ConnectionPo
 	}
 
 	if keepalive.Time != nil {
-		c.UpstreamConnectionOptions.TcpKeepalive.KeepaliveTime = &wrappers.UInt32Value{Value: uint32(keepalive.Time.Seconds)}
+		c.UpstreamConnectionOptions.TcpKeepalive.KeepaliveTime = &wrappers.UInt32Value{Value: keepaliveSeconds(keepalive.Time)}
 	}
 
 	if keepalive.Interval != nil {
-		c.UpstreamConnectionOptions.TcpKeepalive.KeepaliveInterval = &wrappers.UInt32Value{Value: uint32(keepalive.Interval.Seconds)}
+		c.UpstreamConnectionOptions.TcpKeepalive.KeepaliveInterval = &wrappers.UInt32Value{Value: keepaliveSeconds(keepalive.Interval)}
+	}
+}
+
+// keepaliveSeconds converts a keepalive duration to the whole seconds Envoy expects. Sub-second
+// remainders are rounded up so that a small non-zero duration does not become zero, which the
+// kernel would reject.
+func keepaliveSeconds(d *types.Duration) uint32 {
+	seconds := d.Seconds
+	if d.Nanos > 0 {
+		seconds++
 	}
+	return uint32(seconds)
 }
 
+// ApplyOutlierDetection sets the cluster outlier detection from the given settings.
 // FIXME: there isn't a way to distinguish between unset values and zero values
-func applyOutlierDetection(c *cluster.Cluster, outlier *networking.OutlierDetection) {
+func ApplyOutlierDetection(c *cluster.Cluster, outlier *networking.OutlierDetection) {
 	if outlier == nil {
 		return
 	}
@@ -804,7 +907,7 @@ func ApplyLeastRequestLoadBalancer(c *cluster.Cluster, loadbalancer *networking.
 // setSlowStartConfig will set the warmupDurationSecs for LEAST_REQUEST and ROUND_ROBIN if provided in DestinationRule
 func setSlowStartConfig(warmupDurationSecs *types.Duration) *cluster.Cluster_SlowStartConfig {
 	return &cluster.Cluster_SlowStartConfig{
-		SlowStartWindow: &durationpb.Duration{Seconds: warmupDurationSecs.GetSeconds()},
+		SlowStartWindow: gogo.DurationToProtoDuration(warmupDurationSecs),
 	}
 }
 
@@ -18,10 +18,11 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
-	"math"
+	"hash/fnv"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -29,6 +30,7 @@ import (
 	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/gogo/protobuf/types"
 	any "google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -39,6 +41,8 @@ import (
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/loadbalancer"
 	"istio.io/istio/pilot/pkg/networking/util"
 	authn_model "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
@@ -47,6 +51,7 @@ import (
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	istio_cluster "istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/network"
@@ -219,6 +224,12 @@ func (cb *ClusterBuilder) buildSubsetCluster(opts buildClusterOpts, destRule *co
 	opts.policy = MergeTrafficPolicy(opts.policy, subset.TrafficPolicy, opts.port)
 	// Apply traffic policy for the subset cluster.
 	cb.applyTrafficPolicy(opts)
+	applyRetryBudget(subsetCluster.cluster, destRule)
+	applyLoadBalancingPolicy(subsetCluster.cluster, destRule)
+	applyOriginalDstLbConfig(subsetCluster.cluster, destRule)
+	applySlowStartAggression(subsetCluster.cluster, destRule)
+	applyLeastRequestLbConfig(subsetCluster.cluster, destRule)
+	applyOutlierFailurePercentage(subsetCluster.cluster, destRule)
 
 	maybeApplyEdsConfig(subsetCluster.cluster)
 
@@ -261,6 +272,12 @@ func (cb *ClusterBuilder) applyDestinationRule(mc *MutableCluster, clusterMode C
 	}
 	// Apply traffic policy for the main default cluster.
 	cb.applyTrafficPolicy(opts)
+	applyRetryBudget(mc.cluster, destRule)
+	applyLoadBalancingPolicy(mc.cluster, destRule)
+	applyOriginalDstLbConfig(mc.cluster, destRule)
+	applySlowStartAggression(mc.cluster, destRule)
+	applyLeastRequestLbConfig(mc.cluster, destRule)
+	applyOutlierFailurePercentage(mc.cluster, destRule)
 
 	// Apply EdsConfig if needed. This should be called after traffic policy is applied because, traffic policy might change
 	// discovery type.
@@ -352,14 +369,17 @@ func (cb *ClusterBuilder) buildDefaultCluster(name string, discoveryType cluster
 	ec := NewMutableCluster(c)
 	switch discoveryType {
 	case cluster.Cluster_STRICT_DNS, cluster.Cluster_LOGICAL_DNS:
-		if cb.supportsIPv4 {
+		switch {
+		case cb.dualStack():
+			// Dual-stack proxies resolve both families, and race the connections to the addresses of each.
+			c.DnsLookupFamily = cluster.Cluster_ALL
+		case cb.supportsIPv4:
 			c.DnsLookupFamily = cluster.Cluster_V4_ONLY
-		} else {
+		default:
 			c.DnsLookupFamily = cluster.Cluster_V6_ONLY
 		}
-		dnsRate := gogo.DurationToProtoDuration(cb.req.Push.Mesh.DnsRefreshRate)
-		c.DnsRefreshRate = dnsRate
-		c.RespectDnsTtl = true
+		c.DnsRefreshRate = cb.dnsRefreshRate(name, service)
+		c.RespectDnsTtl = service == nil || !service.Attributes.DNSIgnoreTTL
 		fallthrough
 	case cluster.Cluster_STATIC:
 		if len(localityLbEndpoints) == 0 {
@@ -417,6 +437,7 @@ type clusterCache struct {
 	http2          bool // http2 identifies if the cluster is for an http2 service
 	downstreamAuto bool
 	supportsIPv4   bool
+	dualStack      bool
 
 	// Dependent configs
 	service         *model.Service
@@ -424,6 +445,9 @@ type clusterCache struct {
 	envoyFilterKeys []string
 	peerAuthVersion string   // identifies the versions of all peer authentications
 	serviceAccounts []string // contains all the service accounts associated with the service
+
+	dnsJitterProxyID string        // set for services with a DNS jitter, whose clusters differ per proxy
+	connectTimeout   time.Duration // set by the Sidecar egress listener the cluster is reached through
 }
 
 func (t *clusterCache) Key() string {
@@ -432,6 +456,9 @@ func (t *clusterCache) Key() string {
 		t.proxyClusterID, strconv.FormatBool(t.proxySidecar),
 		strconv.FormatBool(t.http2), strconv.FormatBool(t.downstreamAuto), strconv.FormatBool(t.supportsIPv4),
 	}
+	if t.dualStack {
+		params = append(params, "dualStack")
+	}
 	if t.networkView != nil {
 		nv := make([]string, 0, len(t.networkView))
 		for nw := range t.networkView {
@@ -452,6 +479,12 @@ func (t *clusterCache) Key() string {
 	params = append(params, t.envoyFilterKeys...)
 	params = append(params, t.peerAuthVersion)
 	params = append(params, t.serviceAccounts...)
+	if t.dnsJitterProxyID != "" {
+		params = append(params, t.dnsJitterProxyID)
+	}
+	if t.connectTimeout > 0 {
+		params = append(params, t.connectTimeout.String())
+	}
 
 	hash := md5.New()
 	for _, param := range params {
@@ -484,6 +517,10 @@ func (t clusterCache) Cacheable() bool {
 	return true
 }
 
+func (t clusterCache) ResourceType() string {
+	return v3.ClusterType
+}
+
 // buildInboundClusterForPortOrUDS constructs a single inbound listener. The cluster will be bound to
 // `inbound|clusterPort||`, and send traffic to <bind>:<instance.Endpoint.EndpointPort>. A workload
 // will have a single inbound cluster per port. In general this works properly, with the exception of
@@ -612,12 +649,8 @@ func (cb *ClusterBuilder) buildLocalityLbEndpoints(proxyNetworkView map[network.
 	}
 	for _, locality := range locs {
 		eps := lbEndpoints[locality]
-		var weight uint32
-		var overflowStatus bool
-		for _, ep := range eps {
-			weight, overflowStatus = addUint32(weight, ep.LoadBalancingWeight.GetValue())
-		}
-		if overflowStatus {
+		weight, overflow := loadbalancer.LocalityWeight(eps)
+		if overflow {
 			log.Warnf("Sum of localityLbEndpoints weight is overflow: service:%s, port: %d, locality:%s",
 				service.Hostname, port, locality)
 		}
@@ -633,13 +666,9 @@ func (cb *ClusterBuilder) buildLocalityLbEndpoints(proxyNetworkView map[network.
 	return localityLbEndpoints
 }
 
-// addUint32AvoidOverflow returns sum of two uint32 and status. If sum overflows,
-// and returns MaxUint32 and status.
-func addUint32(left, right uint32) (uint32, bool) {
-	if math.MaxUint32-right < left {
-		return math.MaxUint32, true
-	}
-	return left + right, false
+// dualStack returns whether the proxy has addresses of both IP families, and dual-stack support is enabled.
+func (cb *ClusterBuilder) dualStack() bool {
+	return features.EnableDualStack && cb.supportsIPv4 && cb.supportsIPv6
 }
 
 // buildInboundPassthroughClusters builds passthrough clusters for inbound.
@@ -780,8 +809,9 @@ func (cb *ClusterBuilder) applyTrafficPolicy(opts buildClusterOpts) {
 	}
 	cb.applyConnectionPool(opts.mesh, opts.mutable, connectionPool)
 	if opts.direction != model.TrafficDirectionInbound {
+		cb.applyEgressConnectTimeout(opts, connectionPool)
 		cb.applyH2Upgrade(opts, connectionPool)
-		applyOutlierDetection(opts.mutable.cluster, outlierDetection)
+		ApplyOutlierDetection(opts.mutable.cluster, outlierDetection)
 		applyLoadBalancer(opts.mutable.cluster, loadBalancer, opts.port, cb.locality, cb.proxyLabels, opts.mesh)
 		if opts.clusterMode != SniDnatClusterMode {
 			autoMTLSEnabled := opts.mesh.GetEnableAutoMtls().Value
@@ -796,6 +826,17 @@ func (cb *ClusterBuilder) applyTrafficPolicy(opts buildClusterOpts) {
 	}
 }
 
+// applyEgressConnectTimeout applies the connect timeout of the Sidecar egress listener the cluster is reached
+// through, unless the traffic policy sets one.
+func (cb *ClusterBuilder) applyEgressConnectTimeout(opts buildClusterOpts, connectionPool *networking.ConnectionPoolSettings) {
+	if !cb.sidecarProxy() || opts.port == nil || connectionPool.GetTcp().GetConnectTimeout() != nil {
+		return
+	}
+	if timeout := cb.sidecarScope.EgressConnectTimeout(opts.port.Port); timeout > 0 {
+		opts.mutable.cluster.ConnectTimeout = durationpb.New(timeout)
+	}
+}
+
 // buildAutoMtlsSettings fills key cert fields for all TLSSettings when the mode is `ISTIO_MUTUAL`.
 // If the (input) TLS setting is nil (i.e not set), *and* the service mTLS mode is STRICT, it also
 // creates and populates the config as if they are set as ISTIO_MUTUAL.
@@ -880,6 +921,212 @@ func (cb *ClusterBuilder) buildIstioMutualTLS(serviceAccounts []string, sni stri
 	}
 }
 
+// dnsRefreshRate returns the DNS refresh rate of a cluster of the service: the mesh-wide rate unless the service
+// overrides it, plus an offset of up to the DNS jitter of the service derived from the proxy and the cluster name.
+func (cb *ClusterBuilder) dnsRefreshRate(clusterName string, service *model.Service) *durationpb.Duration {
+	rate := gogo.DurationToProtoDuration(cb.req.Push.Mesh.DnsRefreshRate)
+	if service == nil {
+		return rate
+	}
+	if service.Attributes.DNSRefreshRate > 0 {
+		rate = durationpb.New(service.Attributes.DNSRefreshRate)
+	}
+	if jitter := service.Attributes.DNSJitter; jitter > 0 {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(cb.proxyID + "/" + clusterName))
+		rate = durationpb.New(rate.AsDuration() + time.Duration(h.Sum64()%uint64(jitter)))
+	}
+	return rate
+}
+
+// ignoreInvalidAnnotation logs the invalid value of an annotation of the destination rule, which is ignored. Such
+// values are rejected by validation, but may still be found in destination rules created without it.
+func ignoreInvalidAnnotation(destRule *config.Config, annotation, value string) {
+	log.Debugf("ignoring invalid %s annotation %q of destination rule %s/%s", annotation, value, destRule.Namespace, destRule.Name)
+}
+
+// applyRetryBudget configures the retry budget of the cluster circuit breakers from the destination rule
+// annotations.
+func applyRetryBudget(c *cluster.Cluster, destRule *config.Config) {
+	if destRule == nil || c.CircuitBreakers == nil || len(c.CircuitBreakers.Thresholds) == 0 {
+		return
+	}
+	percent, f := destRule.Annotations[constants.RetryBudgetPercentAnnotation]
+	if !f {
+		return
+	}
+	p, err := strconv.ParseFloat(percent, 64)
+	if err != nil || p < 0 || p > 100 {
+		ignoreInvalidAnnotation(destRule, constants.RetryBudgetPercentAnnotation, percent)
+		return
+	}
+	budget := &cluster.CircuitBreakers_Thresholds_RetryBudget{
+		BudgetPercent: &xdstype.Percent{Value: p},
+	}
+	if concurrency, f := destRule.Annotations[constants.RetryBudgetMinRetryConcurrencyAnnotation]; f {
+		if n, err := strconv.ParseUint(concurrency, 10, 32); err == nil {
+			budget.MinRetryConcurrency = &wrappers.UInt32Value{Value: uint32(n)}
+		} else {
+			ignoreInvalidAnnotation(destRule, constants.RetryBudgetMinRetryConcurrencyAnnotation, concurrency)
+		}
+	}
+	c.CircuitBreakers.Thresholds[0].RetryBudget = budget
+}
+
+// forcesOriginalDst returns whether the destination rule annotations force the ORIGINAL_DST discovery type.
+func forcesOriginalDst(destRule *config.Config) bool {
+	if destRule == nil {
+		return false
+	}
+	value := destRule.Annotations[constants.OriginalDstAnnotation]
+	return value == "true" || value == constants.OriginalDstUseHTTPHeader
+}
+
+// applyOriginalDstLbConfig configures the ORIGINAL_DST load balancer of the cluster from the destination rule
+// annotations.
+func applyOriginalDstLbConfig(c *cluster.Cluster, destRule *config.Config) {
+	if destRule == nil || c.GetType() != cluster.Cluster_ORIGINAL_DST {
+		return
+	}
+	if destRule.Annotations[constants.OriginalDstAnnotation] != constants.OriginalDstUseHTTPHeader {
+		return
+	}
+	c.LbConfig = &cluster.Cluster_OriginalDstLbConfig_{
+		OriginalDstLbConfig: &cluster.Cluster_OriginalDstLbConfig{UseHttpHeader: true},
+	}
+}
+
+// applySlowStartAggression sets the aggression of the slow start config of the cluster from the destination rule
+// annotations.
+func applySlowStartAggression(c *cluster.Cluster, destRule *config.Config) {
+	if destRule == nil {
+		return
+	}
+	value, f := destRule.Annotations[constants.SlowStartAggressionAnnotation]
+	if !f {
+		return
+	}
+	aggression, err := strconv.ParseFloat(value, 64)
+	if err != nil || aggression <= 0 {
+		ignoreInvalidAnnotation(destRule, constants.SlowStartAggressionAnnotation, value)
+		return
+	}
+	var slowStart *cluster.Cluster_SlowStartConfig
+	switch lbConfig := c.LbConfig.(type) {
+	case *cluster.Cluster_RoundRobinLbConfig_:
+		slowStart = lbConfig.RoundRobinLbConfig.GetSlowStartConfig()
+	case *cluster.Cluster_LeastRequestLbConfig_:
+		slowStart = lbConfig.LeastRequestLbConfig.GetSlowStartConfig()
+	}
+	if slowStart == nil {
+		return
+	}
+	slowStart.Aggression = &core.RuntimeDouble{
+		DefaultValue: aggression,
+		RuntimeKey:   "upstream.slow_start_aggression",
+	}
+}
+
+// applyLeastRequestLbConfig sets the choice count and active request bias of the LEAST_REQUEST load balancer of the
+// cluster from the destination rule annotations.
+func applyLeastRequestLbConfig(c *cluster.Cluster, destRule *config.Config) {
+	if destRule == nil || c.LbPolicy != cluster.Cluster_LEAST_REQUEST {
+		return
+	}
+	var choiceCount *wrappers.UInt32Value
+	if value, f := destRule.Annotations[constants.LeastRequestChoiceCountAnnotation]; f {
+		if n, err := strconv.ParseUint(value, 10, 32); err == nil && n >= 2 {
+			choiceCount = &wrappers.UInt32Value{Value: uint32(n)}
+		} else {
+			ignoreInvalidAnnotation(destRule, constants.LeastRequestChoiceCountAnnotation, value)
+		}
+	}
+	var activeRequestBias *core.RuntimeDouble
+	if value, f := destRule.Annotations[constants.LeastRequestActiveRequestBiasAnnotation]; f {
+		if bias, err := strconv.ParseFloat(value, 64); err == nil && bias >= 0 {
+			activeRequestBias = &core.RuntimeDouble{
+				DefaultValue: bias,
+				RuntimeKey:   "upstream.least_request_active_request_bias",
+			}
+		} else {
+			ignoreInvalidAnnotation(destRule, constants.LeastRequestActiveRequestBiasAnnotation, value)
+		}
+	}
+	if choiceCount == nil && activeRequestBias == nil {
+		return
+	}
+	lbConfig := c.GetLeastRequestLbConfig()
+	if lbConfig == nil {
+		lbConfig = &cluster.Cluster_LeastRequestLbConfig{}
+		c.LbConfig = &cluster.Cluster_LeastRequestLbConfig_{LeastRequestLbConfig: lbConfig}
+	}
+	if choiceCount != nil {
+		lbConfig.ChoiceCount = choiceCount
+	}
+	if activeRequestBias != nil {
+		lbConfig.ActiveRequestBias = activeRequestBias
+	}
+}
+
+// applyOutlierFailurePercentage enables failure percentage based ejection in the outlier detection of the cluster from
+// the destination rule annotations.
+func applyOutlierFailurePercentage(c *cluster.Cluster, destRule *config.Config) {
+	if destRule == nil || c.OutlierDetection == nil {
+		return
+	}
+	value, f := destRule.Annotations[constants.OutlierFailurePercentageThresholdAnnotation]
+	if !f {
+		return
+	}
+	threshold, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || threshold > 100 {
+		ignoreInvalidAnnotation(destRule, constants.OutlierFailurePercentageThresholdAnnotation, value)
+		return
+	}
+	out := c.OutlierDetection
+	out.FailurePercentageThreshold = &wrappers.UInt32Value{Value: uint32(threshold)}
+	out.EnforcingFailurePercentage = &wrappers.UInt32Value{Value: 100}
+	if out.SplitExternalLocalOriginErrors {
+		out.EnforcingFailurePercentageLocalOrigin = &wrappers.UInt32Value{Value: 100}
+	}
+	for annotation, field := range map[string]**wrappers.UInt32Value{
+		constants.OutlierFailurePercentageRequestVolumeAnnotation: &out.FailurePercentageRequestVolume,
+		constants.OutlierFailurePercentageMinimumHostsAnnotation:  &out.FailurePercentageMinimumHosts,
+	} {
+		value, f := destRule.Annotations[annotation]
+		if !f {
+			continue
+		}
+		if v, err := strconv.ParseUint(value, 10, 32); err == nil {
+			*field = &wrappers.UInt32Value{Value: uint32(v)}
+		} else {
+			ignoreInvalidAnnotation(destRule, annotation, value)
+		}
+	}
+}
+
+// applyLoadBalancingPolicy configures the load balancing policy extension of the cluster from the destination rule
+// annotations.
+func applyLoadBalancingPolicy(c *cluster.Cluster, destRule *config.Config) {
+	if destRule == nil || c.LbPolicy == cluster.Cluster_CLUSTER_PROVIDED {
+		return
+	}
+	value, f := destRule.Annotations[constants.LoadBalancingPolicyAnnotation]
+	if !f {
+		return
+	}
+	policy, err := istionetworking.ParseLoadBalancingPolicy(value)
+	if err != nil {
+		ignoreInvalidAnnotation(destRule, constants.LoadBalancingPolicyAnnotation, value)
+		return
+	}
+	c.LbPolicy = cluster.Cluster_LOAD_BALANCING_POLICY_CONFIG
+	c.LbConfig = nil
+	c.LoadBalancingPolicy = &cluster.LoadBalancingPolicy{
+		Policies: []*cluster.LoadBalancingPolicy_Policy{{TypedExtensionConfig: policy}},
+	}
+}
+
 func (cb *ClusterBuilder) applyDefaultConnectionPool(cluster *cluster.Cluster) {
 	defaultConnectTimeout := &types.Duration{
 		Seconds: cb.req.Push.Mesh.ConnectTimeout.Seconds,
@@ -917,6 +1164,11 @@ func (cb *ClusterBuilder) applyConnectionPool(mesh *meshconfig.MeshConfig, mc *M
 		maxRequestsPerConnection = uint32(settings.Http.MaxRequestsPerConnection)
 	}
 
+	// Envoy ignores max_retries when a retry budget is set, so the budget only applies without an explicit maximum.
+	if settings.GetHttp().GetMaxRetries() <= 0 {
+		threshold.RetryBudget = getDefaultRetryBudget()
+	}
+
 	cb.applyDefaultConnectionPool(mc.cluster)
 	if settings.Tcp != nil {
 		if settings.Tcp != nil && settings.Tcp.ConnectTimeout != nil {
@@ -28,8 +28,10 @@ import (
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/gogo/protobuf/types"
 	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -1060,6 +1062,69 @@ func TestBuildDefaultCluster(t *testing.T) {
 	}
 }
 
+func TestBuildDefaultClusterDNSRefresh(t *testing.T) {
+	servicePort := &model.Port{Name: "default", Port: 8080, Protocol: protocol.HTTP}
+	endpoints := []*endpoint.LocalityLbEndpoints{{LbEndpoints: []*endpoint.LbEndpoint{}}}
+
+	cases := []struct {
+		name       string
+		attributes *model.ServiceAttributes
+		minRate    time.Duration
+		maxRate    time.Duration
+		respectTTL bool
+	}{
+		{
+			name:       "mesh default",
+			minRate:    5 * time.Second,
+			maxRate:    5 * time.Second,
+			respectTTL: true,
+		},
+		{
+			name:       "refresh rate and ttl",
+			attributes: &model.ServiceAttributes{DNSRefreshRate: time.Second, DNSIgnoreTTL: true},
+			minRate:    time.Second,
+			maxRate:    time.Second,
+			respectTTL: false,
+		},
+		{
+			name:       "jitter",
+			attributes: &model.ServiceAttributes{DNSRefreshRate: time.Second, DNSJitter: 500 * time.Millisecond},
+			minRate:    time.Second,
+			maxRate:    1500*time.Millisecond - 1,
+			respectTTL: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mesh := testMesh()
+			mesh.DnsRefreshRate = types.DurationProto(5 * time.Second)
+			cg := NewConfigGenTest(t, TestOptions{MeshConfig: &mesh})
+			cb := NewClusterBuilder(cg.SetupProxy(nil), &model.PushRequest{Push: cg.PushContext()}, nil)
+			service := &model.Service{
+				Ports:      model.PortList{servicePort},
+				Hostname:   "host",
+				Resolution: model.DNSLB,
+			}
+			if tt.attributes != nil {
+				service.Attributes = tt.attributes.DeepCopy()
+			}
+			c := cb.buildDefaultCluster("foo", cluster.Cluster_STRICT_DNS, endpoints, model.TrafficDirectionOutbound,
+				servicePort, service, nil).build()
+			if rate := c.DnsRefreshRate.AsDuration(); rate < tt.minRate || rate > tt.maxRate {
+				t.Errorf("got DNS refresh rate %v, want between %v and %v", rate, tt.minRate, tt.maxRate)
+			}
+			if c.RespectDnsTtl != tt.respectTTL {
+				t.Errorf("got respect DNS TTL %v, want %v", c.RespectDnsTtl, tt.respectTTL)
+			}
+			again := cb.buildDefaultCluster("foo", cluster.Cluster_STRICT_DNS, endpoints, model.TrafficDirectionOutbound,
+				servicePort, service, nil).build()
+			if !proto.Equal(c.DnsRefreshRate, again.DnsRefreshRate) {
+				t.Errorf("DNS refresh rate is not stable: %v then %v", c.DnsRefreshRate, again.DnsRefreshRate)
+			}
+		})
+	}
+}
+
 func TestBuildLocalityLbEndpoints(t *testing.T) {
 	proxy := &model.Proxy{
 		Metadata: &model.NodeMetadata{
@@ -3198,6 +3263,156 @@ func TestApplyTCPKeepalive(t *testing.T) {
 	}
 }
 
+func TestApplyRetryBudget(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *cluster.CircuitBreakers_Thresholds_RetryBudget
+	}{
+		{
+			name:        "no annotations",
+			annotations: nil,
+			expected:    nil,
+		},
+		{
+			name:        "percent",
+			annotations: map[string]string{constants.RetryBudgetPercentAnnotation: "20"},
+			expected: &cluster.CircuitBreakers_Thresholds_RetryBudget{
+				BudgetPercent: &xdstype.Percent{Value: 20},
+			},
+		},
+		{
+			name: "percent and min retry concurrency",
+			annotations: map[string]string{
+				constants.RetryBudgetPercentAnnotation:             "12.5",
+				constants.RetryBudgetMinRetryConcurrencyAnnotation: "5",
+			},
+			expected: &cluster.CircuitBreakers_Thresholds_RetryBudget{
+				BudgetPercent:       &xdstype.Percent{Value: 12.5},
+				MinRetryConcurrency: &wrappers.UInt32Value{Value: 5},
+			},
+		},
+		{
+			name:        "invalid percent",
+			annotations: map[string]string{constants.RetryBudgetPercentAnnotation: "200"},
+			expected:    nil,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cluster.Cluster{
+				CircuitBreakers: &cluster.CircuitBreakers{
+					Thresholds: []*cluster.CircuitBreakers_Thresholds{getDefaultCircuitBreakerThresholds()},
+				},
+			}
+			applyRetryBudget(c, &config.Config{Meta: config.Meta{Annotations: tt.annotations}})
+			if diff := cmp.Diff(c.CircuitBreakers.Thresholds[0].RetryBudget, tt.expected, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected retry budget: %v", diff)
+			}
+		})
+	}
+}
+
+func TestApplyDefaultRetryBudget(t *testing.T) {
+	defer func(percent float64, concurrency int) {
+		features.RetryBudgetPercent = percent
+		features.RetryBudgetMinRetryConcurrency = concurrency
+	}(features.RetryBudgetPercent, features.RetryBudgetMinRetryConcurrency)
+
+	cases := []struct {
+		name           string
+		percent        float64
+		connectionPool *networking.ConnectionPoolSettings
+		expected       *cluster.CircuitBreakers_Thresholds_RetryBudget
+	}{
+		{
+			name:           "disabled",
+			percent:        0,
+			connectionPool: &networking.ConnectionPoolSettings{},
+			expected:       nil,
+		},
+		{
+			name:           "default",
+			percent:        25,
+			connectionPool: &networking.ConnectionPoolSettings{},
+			expected: &cluster.CircuitBreakers_Thresholds_RetryBudget{
+				BudgetPercent:       &xdstype.Percent{Value: 25},
+				MinRetryConcurrency: &wrappers.UInt32Value{Value: 3},
+			},
+		},
+		{
+			name:    "max retries set",
+			percent: 25,
+			connectionPool: &networking.ConnectionPoolSettings{
+				Http: &networking.ConnectionPoolSettings_HTTPSettings{MaxRetries: 10},
+			},
+			expected: nil,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			features.RetryBudgetPercent = tt.percent
+			features.RetryBudgetMinRetryConcurrency = 3
+			cg := NewConfigGenTest(t, TestOptions{})
+			cb := NewClusterBuilder(cg.SetupProxy(nil), &model.PushRequest{Push: cg.PushContext()}, nil)
+			mc := NewMutableCluster(&cluster.Cluster{Name: "foo", ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_EDS}})
+			cb.applyConnectionPool(cb.req.Push.Mesh, mc, tt.connectionPool)
+			if diff := cmp.Diff(mc.cluster.CircuitBreakers.Thresholds[0].RetryBudget, tt.expected, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected retry budget: %v", diff)
+			}
+		})
+	}
+}
+
+func TestApplyLoadBalancingPolicy(t *testing.T) {
+	roundRobin := "type.googleapis.com/envoy.extensions.load_balancing_policies.round_robin.v3.RoundRobin"
+	cases := []struct {
+		name        string
+		lbPolicy    cluster.Cluster_LbPolicy
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			name:        "round robin",
+			lbPolicy:    cluster.Cluster_LEAST_REQUEST,
+			annotations: map[string]string{constants.LoadBalancingPolicyAnnotation: `{"@type": "` + roundRobin + `"}`},
+			expected:    roundRobin,
+		},
+		{
+			name:     "no annotation",
+			lbPolicy: cluster.Cluster_LEAST_REQUEST,
+		},
+		{
+			name:        "invalid policy",
+			lbPolicy:    cluster.Cluster_LEAST_REQUEST,
+			annotations: map[string]string{constants.LoadBalancingPolicyAnnotation: `{"@type": "foo"}`},
+		},
+		{
+			name:        "original destination",
+			lbPolicy:    cluster.Cluster_CLUSTER_PROVIDED,
+			annotations: map[string]string{constants.LoadBalancingPolicyAnnotation: `{"@type": "` + roundRobin + `"}`},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cluster.Cluster{LbPolicy: tt.lbPolicy}
+			applyLoadBalancingPolicy(c, &config.Config{Meta: config.Meta{Annotations: tt.annotations}})
+			if tt.expected == "" {
+				if c.LoadBalancingPolicy != nil || c.LbPolicy != tt.lbPolicy {
+					t.Fatalf("expected the cluster to be unchanged, got %v", c)
+				}
+				return
+			}
+			if c.LbPolicy != cluster.Cluster_LOAD_BALANCING_POLICY_CONFIG {
+				t.Fatalf("expected LOAD_BALANCING_POLICY_CONFIG, got %v", c.LbPolicy)
+			}
+			if got := c.LoadBalancingPolicy.GetPolicies()[0].GetTypedExtensionConfig().GetTypedConfig().GetTypeUrl(); got != tt.expected {
+				t.Fatalf("expected policy %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestApplyConnectionPool(t *testing.T) {
 	// only test connectionPool.Http.IdleTimeout and connectionPool.Http.IdleTimeout.MaxRequestsPerConnection
 	cases := []struct {
@@ -46,6 +46,7 @@ import (
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
@@ -254,8 +255,10 @@ type clusterTest struct {
 	locality          *core.Locality
 	mesh              meshconfig.MeshConfig
 	destRule          proto.Message
-	peerAuthn         *authn_beta.PeerAuthentication
-	externalService   bool
+	// destRuleAnnotations are the annotations of destRule.
+	destRuleAnnotations map[string]string
+	peerAuthn           *authn_beta.PeerAuthentication
+	externalService     bool
 
 	meta         *model.NodeMetadata
 	istioVersion *model.IstioVersion
@@ -366,6 +369,7 @@ func buildTestClusters(c clusterTest) []*cluster.Cluster {
 			Meta: config.Meta{
 				GroupVersionKind: gvk.DestinationRule,
 				Name:             "acme",
+				Annotations:      c.destRuleAnnotations,
 			},
 			Spec: c.destRule,
 		})
@@ -659,6 +663,23 @@ func TestBuildSidecarClustersWithMeshWideTCPKeepalive(t *testing.T) {
 	}
 }
 
+func TestSetKeepAliveSettingsRoundsUp(t *testing.T) {
+	c := &cluster.Cluster{}
+	setKeepAliveSettings(c, &networking.ConnectionPoolSettings_TCPSettings_TcpKeepalive{
+		Probes:   3,
+		Time:     &types.Duration{Nanos: 500000000},
+		Interval: &types.Duration{Seconds: 10, Nanos: 1000000},
+	})
+	want := &core.TcpKeepalive{
+		KeepaliveProbes:   &wrappers.UInt32Value{Value: 3},
+		KeepaliveTime:     &wrappers.UInt32Value{Value: 1},
+		KeepaliveInterval: &wrappers.UInt32Value{Value: 11},
+	}
+	if diff := cmp.Diff(c.GetUpstreamConnectionOptions().GetTcpKeepalive(), want, protocmp.Transform()); diff != "" {
+		t.Fatalf("unexpected tcp keepalive: %v", diff)
+	}
+}
+
 func buildTestClustersWithTCPKeepalive(t testing.TB, configType ConfigType) []*cluster.Cluster {
 	// Set mesh wide defaults.
 	m := testMesh()
@@ -1371,6 +1392,294 @@ func getSlowStartTrafficPolicy(slowStartEnabled bool, lbType networking.LoadBala
 	}
 }
 
+func TestSlowStartAggression(t *testing.T) {
+	g := NewWithT(t)
+	cg := NewConfigGenTest(t, TestOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: dns
+  namespace: default
+spec:
+  hosts: [dns.example.com]
+  ports:
+  - {name: http, number: 80, protocol: HTTP}
+  resolution: DNS
+  endpoints:
+  - address: dns.example.com
+    labels: {version: v1}
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: static
+  namespace: default
+spec:
+  hosts: [static.example.com]
+  ports:
+  - {name: http, number: 80, protocol: HTTP}
+  resolution: STATIC
+  endpoints:
+  - address: 1.1.1.1
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: dns
+  namespace: default
+  annotations:
+    experimental.istio.io/slow-start-aggression: "1.5"
+spec:
+  host: dns.example.com
+  trafficPolicy:
+    loadBalancer:
+      simple: ROUND_ROBIN
+      warmupDurationSecs: 10.5s
+  subsets:
+  - name: v1
+    labels: {version: v1}
+    trafficPolicy:
+      loadBalancer:
+        simple: LEAST_REQUEST
+        warmupDurationSecs: 20s
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: static
+  namespace: default
+  annotations:
+    experimental.istio.io/slow-start-aggression: "2"
+spec:
+  host: static.example.com
+  trafficPolicy:
+    loadBalancer:
+      simple: LEAST_REQUEST
+      warmupDurationSecs: 30s
+`})
+	clusters := xdstest.ExtractClusters(cg.Clusters(cg.SetupProxy(nil)))
+
+	cases := []struct {
+		name        string
+		clusterType cluster.Cluster_DiscoveryType
+		window      time.Duration
+		aggression  float64
+	}{
+		{"outbound|80||dns.example.com", cluster.Cluster_STRICT_DNS, 10500 * time.Millisecond, 1.5},
+		{"outbound|80|v1|dns.example.com", cluster.Cluster_STRICT_DNS, 20 * time.Second, 1.5},
+		{"outbound|80||static.example.com", cluster.Cluster_EDS, 30 * time.Second, 2},
+	}
+	for _, tt := range cases {
+		c := clusters[tt.name]
+		g.Expect(c).NotTo(BeNil(), tt.name)
+		g.Expect(c.GetType()).To(Equal(tt.clusterType), tt.name)
+		slowStart := c.GetRoundRobinLbConfig().GetSlowStartConfig()
+		if c.GetLbPolicy() == cluster.Cluster_LEAST_REQUEST {
+			slowStart = c.GetLeastRequestLbConfig().GetSlowStartConfig()
+		}
+		g.Expect(slowStart.GetSlowStartWindow().AsDuration()).To(Equal(tt.window), tt.name)
+		g.Expect(slowStart.GetAggression().GetDefaultValue()).To(Equal(tt.aggression), tt.name)
+	}
+}
+
+func TestSidecarEgressConnectTimeout(t *testing.T) {
+	g := NewWithT(t)
+	cg := NewConfigGenTest(t, TestOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: external
+  namespace: default
+spec:
+  hosts: [a.example.com]
+  ports:
+  - {name: tcp, number: 9000, protocol: TCP}
+  - {name: http, number: 80, protocol: HTTP}
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: override
+  namespace: default
+spec:
+  hosts: [b.example.com]
+  ports:
+  - {name: http, number: 80, protocol: HTTP}
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: override
+  namespace: default
+spec:
+  host: b.example.com
+  trafficPolicy:
+    connectionPool:
+      tcp:
+        connectTimeout: 2s
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: Sidecar
+metadata:
+  name: default
+  namespace: default
+  annotations:
+    experimental.istio.io/egress-connect-timeout: "9000=5s,*=1s"
+spec:
+  egress:
+  - port: {number: 9000, protocol: TCP, name: tcp}
+    hosts: ["*/*"]
+  - hosts: ["*/*"]
+`})
+	clusters := xdstest.ExtractClusters(cg.Clusters(cg.SetupProxy(nil)))
+
+	cases := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"outbound|9000||a.example.com", 5 * time.Second},
+		{"outbound|80||a.example.com", time.Second},
+		// The DestinationRule takes precedence over the Sidecar.
+		{"outbound|80||b.example.com", 2 * time.Second},
+	}
+	for _, tt := range cases {
+		c := clusters[tt.name]
+		g.Expect(c).NotTo(BeNil(), tt.name)
+		g.Expect(c.ConnectTimeout.AsDuration()).To(Equal(tt.timeout), tt.name)
+	}
+}
+
+func TestLeastRequestLbConfig(t *testing.T) {
+	g := NewWithT(t)
+	cases := []struct {
+		name        string
+		lbType      networking.LoadBalancerSettings_SimpleLB
+		warmup      bool
+		annotations map[string]string
+		choiceCount uint32
+		bias        float64
+	}{
+		{
+			name:   "choice count and bias",
+			lbType: networking.LoadBalancerSettings_LEAST_REQUEST,
+			annotations: map[string]string{
+				constants.LeastRequestChoiceCountAnnotation:       "3",
+				constants.LeastRequestActiveRequestBiasAnnotation: "0.5",
+			},
+			choiceCount: 3,
+			bias:        0.5,
+		},
+		{
+			name:        "with slow start",
+			lbType:      networking.LoadBalancerSettings_LEAST_CONN,
+			warmup:      true,
+			annotations: map[string]string{constants.LeastRequestActiveRequestBiasAnnotation: "0"},
+		},
+		{
+			name:        "round robin",
+			lbType:      networking.LoadBalancerSettings_ROUND_ROBIN,
+			annotations: map[string]string{constants.LeastRequestChoiceCountAnnotation: "3"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := buildTestClusters(clusterTest{
+				t:               t,
+				serviceHostname: "foo.example.org",
+				nodeType:        model.SidecarProxy,
+				mesh:            testMesh(),
+				destRule: &networking.DestinationRule{
+					Host:          "foo.example.org",
+					TrafficPolicy: getSlowStartTrafficPolicy(tt.warmup, tt.lbType),
+				},
+				destRuleAnnotations: tt.annotations,
+			})
+			c := xdstest.ExtractCluster("outbound|8080||foo.example.org", clusters)
+			if tt.lbType == networking.LoadBalancerSettings_ROUND_ROBIN {
+				g.Expect(c.GetLeastRequestLbConfig()).To(BeNil())
+				return
+			}
+			g.Expect(c.GetLbPolicy()).To(Equal(cluster.Cluster_LEAST_REQUEST))
+			lbConfig := c.GetLeastRequestLbConfig()
+			g.Expect(lbConfig).NotTo(BeNil())
+			g.Expect(lbConfig.GetChoiceCount().GetValue()).To(Equal(tt.choiceCount))
+			g.Expect(lbConfig.GetActiveRequestBias()).NotTo(BeNil())
+			g.Expect(lbConfig.GetActiveRequestBias().GetDefaultValue()).To(Equal(tt.bias))
+			g.Expect(lbConfig.GetSlowStartConfig() != nil).To(Equal(tt.warmup))
+		})
+	}
+}
+
+func TestOutlierFailurePercentage(t *testing.T) {
+	g := NewWithT(t)
+	cases := []struct {
+		name        string
+		outlier     *networking.OutlierDetection
+		annotations map[string]string
+		want        *cluster.OutlierDetection
+	}{
+		{
+			name:    "failure percentage",
+			outlier: &networking.OutlierDetection{Consecutive_5XxErrors: &types.UInt32Value{Value: 5}},
+			annotations: map[string]string{
+				constants.OutlierFailurePercentageThresholdAnnotation:     "20",
+				constants.OutlierFailurePercentageRequestVolumeAnnotation: "10",
+				constants.OutlierFailurePercentageMinimumHostsAnnotation:  "2",
+			},
+			want: &cluster.OutlierDetection{
+				EnforcingSuccessRate:           &wrappers.UInt32Value{Value: 0},
+				Consecutive_5Xx:                &wrappers.UInt32Value{Value: 5},
+				EnforcingConsecutive_5Xx:       &wrappers.UInt32Value{Value: 100},
+				FailurePercentageThreshold:     &wrappers.UInt32Value{Value: 20},
+				EnforcingFailurePercentage:     &wrappers.UInt32Value{Value: 100},
+				FailurePercentageRequestVolume: &wrappers.UInt32Value{Value: 10},
+				FailurePercentageMinimumHosts:  &wrappers.UInt32Value{Value: 2},
+			},
+		},
+		{
+			name: "split local origin errors",
+			outlier: &networking.OutlierDetection{
+				SplitExternalLocalOriginErrors: true,
+				ConsecutiveLocalOriginFailures: &types.UInt32Value{Value: 3},
+			},
+			annotations: map[string]string{constants.OutlierFailurePercentageThresholdAnnotation: "50"},
+			want: &cluster.OutlierDetection{
+				EnforcingSuccessRate:                   &wrappers.UInt32Value{Value: 0},
+				SplitExternalLocalOriginErrors:         true,
+				ConsecutiveLocalOriginFailure:          &wrappers.UInt32Value{Value: 3},
+				EnforcingConsecutiveLocalOriginFailure: &wrappers.UInt32Value{Value: 100},
+				EnforcingLocalOriginSuccessRate:        &wrappers.UInt32Value{Value: 0},
+				FailurePercentageThreshold:             &wrappers.UInt32Value{Value: 50},
+				EnforcingFailurePercentage:             &wrappers.UInt32Value{Value: 100},
+				EnforcingFailurePercentageLocalOrigin:  &wrappers.UInt32Value{Value: 100},
+			},
+		},
+		{
+			name:        "without outlier detection",
+			annotations: map[string]string{constants.OutlierFailurePercentageThresholdAnnotation: "50"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := buildTestClusters(clusterTest{
+				t:               t,
+				serviceHostname: "foo.example.org",
+				nodeType:        model.SidecarProxy,
+				mesh:            testMesh(),
+				destRule: &networking.DestinationRule{
+					Host:          "foo.example.org",
+					TrafficPolicy: &networking.TrafficPolicy{OutlierDetection: tt.outlier},
+				},
+				destRuleAnnotations: tt.annotations,
+			})
+			c := xdstest.ExtractCluster("outbound|8080||foo.example.org", clusters)
+			g.Expect(cmp.Diff(c.GetOutlierDetection(), tt.want, protocmp.Transform())).To(BeEmpty())
+		})
+	}
+}
+
 func TestClusterDiscoveryTypeAndLbPolicyPassthrough(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1831,6 +2140,53 @@ func TestBuildStaticClusterWithNoEndPoint(t *testing.T) {
 	g.Expect(xdstest.MapKeys(xdstest.ExtractClusters(clusters))).To(Equal([]string{"BlackHoleCluster", "InboundPassthroughClusterIpv4", "PassthroughCluster"}))
 }
 
+func TestBuildOriginalDstClusterFromAnnotation(t *testing.T) {
+	g := NewWithT(t)
+
+	service := &model.Service{
+		Hostname: host.Name("static.test"),
+		Ports: []*model.Port{
+			{
+				Name:     "default",
+				Port:     8080,
+				Protocol: protocol.HTTP,
+			},
+		},
+		Resolution:   model.DNSLB,
+		MeshExternal: true,
+		Attributes: model.ServiceAttributes{
+			Namespace: TestServiceNamespace,
+		},
+	}
+	dr := config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: gvk.DestinationRule,
+			Name:             "original-dst",
+			Namespace:        TestServiceNamespace,
+			Annotations:      map[string]string{constants.OriginalDstAnnotation: constants.OriginalDstUseHTTPHeader},
+		},
+		Spec: &networking.DestinationRule{
+			Host:    "static.test",
+			Subsets: []*networking.Subset{{Name: "v1", Labels: map[string]string{"version": "v1"}}},
+		},
+	}
+	cg := NewConfigGenTest(t, TestOptions{
+		Services: []*model.Service{service},
+		Configs:  []config.Config{dr},
+	})
+	clusters := xdstest.ExtractClusters(cg.Clusters(cg.SetupProxy(nil)))
+
+	// The STRICT_DNS cluster without endpoints would be ignored, but the annotation forces ORIGINAL_DST.
+	for _, name := range []string{"outbound|8080||static.test", "outbound|8080|v1|static.test"} {
+		c := clusters[name]
+		g.Expect(c).NotTo(BeNil(), name)
+		g.Expect(c.GetType()).To(Equal(cluster.Cluster_ORIGINAL_DST), name)
+		g.Expect(c.GetLbPolicy()).To(Equal(cluster.Cluster_CLUSTER_PROVIDED), name)
+		g.Expect(c.GetLoadAssignment()).To(BeNil(), name)
+		g.Expect(c.GetOriginalDstLbConfig().GetUseHttpHeader()).To(BeTrue(), name)
+	}
+}
+
 func TestEnvoyFilterPatching(t *testing.T) {
 	service := &model.Service{
 		Hostname: host.Name("static.test"),
@@ -2518,10 +2874,39 @@ func TestBuildDeltaClusters(t *testing.T) {
 		},
 	}
 
+	testDestinationRule := config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: gvk.DestinationRule,
+			Name:             "test",
+			Namespace:        TestServiceNamespace,
+		},
+		Spec: &networking.DestinationRule{
+			Host:    "test.com",
+			Subsets: []*networking.Subset{{Name: "v1", Labels: map[string]string{"version": "v1"}}},
+		},
+	}
+
+	meshDestinationRule := config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: gvk.DestinationRule,
+			Name:             "mesh",
+			Namespace:        "istio-system",
+		},
+		Spec: &networking.DestinationRule{
+			TrafficPolicy: &networking.TrafficPolicy{
+				ConnectionPool: &networking.ConnectionPoolSettings{
+					Http: &networking.ConnectionPoolSettings_HTTPSettings{MaxRetries: 10},
+				},
+			},
+		},
+	}
+
 	// TODO: Add more test cases.
 	testCases := []struct {
 		name                 string
 		services             []*model.Service
+		configs              []config.Config
+		inheritance          bool
 		configUpdated        map[model.ConfigKey]struct{}
 		watchedResourceNames []string
 		usedDelta            bool
@@ -2555,6 +2940,53 @@ func TestBuildDeltaClusters(t *testing.T) {
 			removedClusters:      []string{"outbound|7070||test.com"},
 			expectedClusters:     []string{"BlackHoleCluster", "InboundPassthroughClusterIpv4", "PassthroughCluster", "outbound|8080||test.com"},
 		},
+		{
+			name:          "destination rule is updated",
+			services:      []*model.Service{testService1, testService2},
+			configs:       []config.Config{testDestinationRule},
+			configUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.DestinationRule, Name: "test", Namespace: TestServiceNamespace}: {}},
+			watchedResourceNames: []string{
+				"outbound|8080||test.com", "outbound|8080|v0|test.com", "outbound|8080||testnew.com",
+			},
+			usedDelta:       true,
+			removedClusters: []string{"outbound|8080|v0|test.com"},
+			expectedClusters: []string{
+				"BlackHoleCluster", "InboundPassthroughClusterIpv4", "PassthroughCluster",
+				"outbound|8080|v1|test.com", "outbound|8080||test.com",
+			},
+		},
+		{
+			name:     "destination rule and service are updated",
+			services: []*model.Service{testService1, testService2},
+			configs:  []config.Config{testDestinationRule},
+			configUpdated: map[model.ConfigKey]struct{}{
+				{Kind: gvk.DestinationRule, Name: "test", Namespace: TestServiceNamespace}:     {},
+				{Kind: gvk.ServiceEntry, Name: "testnew.com", Namespace: TestServiceNamespace}: {},
+			},
+			watchedResourceNames: []string{"outbound|8080||test.com"},
+			usedDelta:            true,
+			removedClusters:      []string{},
+			expectedClusters: []string{
+				"BlackHoleCluster", "InboundPassthroughClusterIpv4", "PassthroughCluster",
+				"outbound|8080|v1|test.com", "outbound|8080||test.com", "outbound|8080||testnew.com",
+			},
+		},
+		{
+			name:          "inherited mesh destination rule is updated",
+			services:      []*model.Service{testService1, testService2},
+			configs:       []config.Config{testDestinationRule, meshDestinationRule},
+			inheritance:   true,
+			configUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.DestinationRule, Name: "mesh", Namespace: "istio-system"}: {}},
+			watchedResourceNames: []string{
+				"outbound|8080||test.com", "outbound|8080|v1|test.com", "outbound|8080||testnew.com",
+			},
+			usedDelta:       true,
+			removedClusters: []string{},
+			expectedClusters: []string{
+				"BlackHoleCluster", "InboundPassthroughClusterIpv4", "PassthroughCluster",
+				"outbound|8080|v1|test.com", "outbound|8080||test.com", "outbound|8080||testnew.com",
+			},
+		},
 		{
 			name:                 "config update that is not delta aware",
 			services:             []*model.Service{testService1, testService2},
@@ -2571,8 +3003,12 @@ func TestBuildDeltaClusters(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			inheritance := features.EnableDestinationRuleInheritance
+			features.EnableDestinationRuleInheritance = tc.inheritance
+			defer func() { features.EnableDestinationRuleInheritance = inheritance }()
 			cg := NewConfigGenTest(t, TestOptions{
 				Services: tc.services,
+				Configs:  tc.configs,
 			})
 			clusters, removed, delta := cg.DeltaClusters(cg.SetupProxy(nil), tc.configUpdated,
 				&model.WatchedResource{ResourceNames: tc.watchedResourceNames})
@@ -35,16 +35,17 @@ func ApplyClusterMerge(pctx networking.EnvoyFilter_PatchContext, efw *model.Envo
 	defer runtime.HandleCrash(runtime.LogPanic, func(interface{}) {
 		log.Errorf("clusters patch caused panic, so the patches did not take effect")
 		IncrementEnvoyFilterErrorMetric(Cluster)
+		incrementEnvoyFilterPatchErrors(efw, Cluster, networking.EnvoyFilter_CLUSTER)
 	})
 	// In case the patches cause panic, use the clusters generated before to reduce the influence.
 	out = c
-	if efw == nil {
+	if !efw.HasPatches(pctx, networking.EnvoyFilter_CLUSTER) {
 		return
 	}
 	for _, cp := range efw.Patches[networking.EnvoyFilter_CLUSTER] {
 		applied := false
 		if cp.Operation != networking.EnvoyFilter_Patch_MERGE {
-			IncrementEnvoyFilterMetric(cp.Key(), Cluster, applied)
+			IncrementEnvoyFilterMetric(cp, Cluster, applied)
 			continue
 		}
 		if commonConditionMatch(pctx, cp) && clusterMatch(c, cp, hosts) {
@@ -59,7 +60,7 @@ func ApplyClusterMerge(pctx networking.EnvoyFilter_PatchContext, efw *model.Envo
 				proto.Merge(c, cp.Value)
 			}
 		}
-		IncrementEnvoyFilterMetric(cp.Key(), Cluster, applied)
+		IncrementEnvoyFilterMetric(cp, Cluster, applied)
 	}
 	return c
 }
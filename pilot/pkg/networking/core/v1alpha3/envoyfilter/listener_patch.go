@@ -40,12 +40,15 @@ func ApplyListenerPatches(
 	skipAdds bool) (out []*xdslistener.Listener) {
 	defer runtime.HandleCrash(runtime.LogPanic, func(interface{}) {
 		IncrementEnvoyFilterErrorMetric(Listener)
+		incrementEnvoyFilterPatchErrors(efw, Listener, networking.EnvoyFilter_LISTENER, networking.EnvoyFilter_FILTER_CHAIN,
+			networking.EnvoyFilter_NETWORK_FILTER, networking.EnvoyFilter_HTTP_FILTER)
 		log.Errorf("listeners patch caused panic, so the patches did not take effect")
 	})
 	// In case the patches cause panic, use the listeners generated before to reduce the influence.
 	out = listeners
 
-	if efw == nil {
+	if !efw.HasPatches(patchContext, networking.EnvoyFilter_LISTENER, networking.EnvoyFilter_FILTER_CHAIN,
+		networking.EnvoyFilter_NETWORK_FILTER, networking.EnvoyFilter_HTTP_FILTER) {
 		return
 	}
 
@@ -80,13 +83,13 @@ func patchListeners(
 					continue
 				}
 				if !commonConditionMatch(patchContext, lp) {
-					IncrementEnvoyFilterMetric(lp.Key(), Listener, false)
+					IncrementEnvoyFilterMetric(lp, Listener, false)
 					continue
 				}
 				// clone before append. Otherwise, subsequent operations on this listener will corrupt
 				// the master value stored in CP.
 				listeners = append(listeners, proto.Clone(lp.Value).(*xdslistener.Listener))
-				IncrementEnvoyFilterMetric(lp.Key(), Listener, true)
+				IncrementEnvoyFilterMetric(lp, Listener, true)
 			}
 		}
 	}
@@ -108,10 +111,10 @@ func patchListener(patchContext networking.EnvoyFilter_PatchContext,
 	for _, lp := range patches[networking.EnvoyFilter_LISTENER] {
 		if !commonConditionMatch(patchContext, lp) ||
 			!listenerMatch(listener, lp) {
-			IncrementEnvoyFilterMetric(lp.Key(), Listener, false)
+			IncrementEnvoyFilterMetric(lp, Listener, false)
 			continue
 		}
-		IncrementEnvoyFilterMetric(lp.Key(), Listener, true)
+		IncrementEnvoyFilterMetric(lp, Listener, true)
 		if lp.Operation == networking.EnvoyFilter_Patch_REMOVE {
 			listener.Name = ""
 			*listenersRemoved = true
@@ -145,10 +148,10 @@ func patchFilterChains(patchContext networking.EnvoyFilter_PatchContext,
 		if lp.Operation == networking.EnvoyFilter_Patch_ADD {
 			if !commonConditionMatch(patchContext, lp) ||
 				!listenerMatch(listener, lp) {
-				IncrementEnvoyFilterMetric(lp.Key(), FilterChain, false)
+				IncrementEnvoyFilterMetric(lp, FilterChain, false)
 				continue
 			}
-			IncrementEnvoyFilterMetric(lp.Key(), FilterChain, true)
+			IncrementEnvoyFilterMetric(lp, FilterChain, true)
 			listener.FilterChains = append(listener.FilterChains, proto.Clone(lp.Value).(*xdslistener.FilterChain))
 		}
 	}
@@ -171,10 +174,10 @@ func patchFilterChain(patchContext networking.EnvoyFilter_PatchContext,
 		if !commonConditionMatch(patchContext, lp) ||
 			!listenerMatch(listener, lp) ||
 			!filterChainMatch(listener, fc, lp) {
-			IncrementEnvoyFilterMetric(lp.Key(), FilterChain, false)
+			IncrementEnvoyFilterMetric(lp, FilterChain, false)
 			continue
 		}
-		IncrementEnvoyFilterMetric(lp.Key(), FilterChain, true)
+		IncrementEnvoyFilterMetric(lp, FilterChain, true)
 		if lp.Operation == networking.EnvoyFilter_Patch_REMOVE {
 			fc.Filters = nil
 			*filterChainRemoved = true
@@ -249,7 +252,7 @@ func patchNetworkFilters(patchContext networking.EnvoyFilter_PatchContext,
 		if !commonConditionMatch(patchContext, lp) ||
 			!listenerMatch(listener, lp) ||
 			!filterChainMatch(listener, fc, lp) {
-			IncrementEnvoyFilterMetric(lp.Key(), NetworkFilter, false)
+			IncrementEnvoyFilterMetric(lp, NetworkFilter, false)
 			continue
 		}
 		applied := false
@@ -326,7 +329,7 @@ func patchNetworkFilters(patchContext networking.EnvoyFilter_PatchContext,
 			applied = true
 			fc.Filters[replacePosition] = proto.Clone(lp.Value).(*xdslistener.Filter)
 		}
-		IncrementEnvoyFilterMetric(lp.Key(), NetworkFilter, applied)
+		IncrementEnvoyFilterMetric(lp, NetworkFilter, applied)
 	}
 	if len(removedFilters) > 0 {
 		tempArray := make([]*xdslistener.Filter, 0, len(fc.Filters)-len(removedFilters))
@@ -351,7 +354,7 @@ func patchNetworkFilter(patchContext networking.EnvoyFilter_PatchContext,
 			!listenerMatch(listener, lp) ||
 			!filterChainMatch(listener, fc, lp) ||
 			!networkFilterMatch(filter, lp) {
-			IncrementEnvoyFilterMetric(lp.Key(), NetworkFilter, false)
+			IncrementEnvoyFilterMetric(lp, NetworkFilter, false)
 			continue
 		}
 		if lp.Operation == networking.EnvoyFilter_Patch_REMOVE {
@@ -377,7 +380,7 @@ func patchNetworkFilter(patchContext networking.EnvoyFilter_PatchContext,
 			}
 			var retVal *any.Any
 			if userFilter.GetTypedConfig() != nil {
-				IncrementEnvoyFilterMetric(lp.Key(), NetworkFilter, true)
+				IncrementEnvoyFilterMetric(lp, NetworkFilter, true)
 				// user has any typed struct
 				// The type may not match up exactly. For example, if we use v2 internally but they use v3.
 				// Assuming they are not using deprecated/new fields, we can safely swap out the TypeUrl
@@ -425,7 +428,7 @@ func patchHTTPFilters(patchContext networking.EnvoyFilter_PatchContext,
 			!listenerMatch(listener, lp) ||
 			!filterChainMatch(listener, fc, lp) ||
 			!networkFilterMatch(filter, lp) {
-			IncrementEnvoyFilterMetric(lp.Key(), HttpFilter, false)
+			IncrementEnvoyFilterMetric(lp, HttpFilter, false)
 			continue
 		}
 		if lp.Operation == networking.EnvoyFilter_Patch_ADD {
@@ -504,7 +507,7 @@ func patchHTTPFilters(patchContext networking.EnvoyFilter_PatchContext,
 			clonedVal := proto.Clone(lp.Value).(*hcm.HttpFilter)
 			httpconn.HttpFilters[replacePosition] = clonedVal
 		}
-		IncrementEnvoyFilterMetric(lp.Key(), HttpFilter, applied)
+		IncrementEnvoyFilterMetric(lp, HttpFilter, applied)
 	}
 	if len(removedFilters) > 0 {
 		tempArray := make([]*hcm.HttpFilter, 0, len(httpconn.HttpFilters)-len(removedFilters))
@@ -534,7 +537,7 @@ func patchHTTPFilter(patchContext networking.EnvoyFilter_PatchContext,
 			!filterChainMatch(listener, fc, lp) ||
 			!networkFilterMatch(filter, lp) ||
 			!httpFilterMatch(httpFilter, lp) {
-			IncrementEnvoyFilterMetric(lp.Key(), HttpFilter, applied)
+			IncrementEnvoyFilterMetric(lp, HttpFilter, applied)
 			continue
 		}
 		if lp.Operation == networking.EnvoyFilter_Patch_REMOVE {
@@ -578,7 +581,7 @@ func patchHTTPFilter(patchContext networking.EnvoyFilter_PatchContext,
 				httpFilter.ConfigType = &hcm.HttpFilter_TypedConfig{TypedConfig: retVal}
 			}
 		}
-		IncrementEnvoyFilterMetric(lp.Key(), HttpFilter, applied)
+		IncrementEnvoyFilterMetric(lp, HttpFilter, applied)
 	}
 	return false
 }
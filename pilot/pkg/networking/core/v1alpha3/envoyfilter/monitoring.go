@@ -16,7 +16,9 @@ package envoyfilter
 import (
 	"sync"
 
+	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
 	"istio.io/pkg/monitoring"
 )
 
@@ -25,6 +27,7 @@ type Result string
 const (
 	Error   Result = "error"
 	Applied Result = "applied"
+	Skipped Result = "skipped"
 )
 
 type PatchType string
@@ -51,6 +54,13 @@ var (
 		"Status of Envoy filters whether it was applied or errored.",
 		monitoring.WithLabels(nameType, patchType, resultType),
 	)
+
+	envoyFilterPatches = monitoring.NewSum(
+		"pilot_envoy_filter_patches",
+		"Number of times the patches of Envoy filters were applied, skipped because they did not match, "+
+			"or errored, when generating the config of the proxies.",
+		monitoring.WithLabels(nameType, patchType, resultType),
+	)
 )
 
 var (
@@ -60,16 +70,25 @@ var (
 
 func init() {
 	if features.EnableEnvoyFilterMetrics {
-		monitoring.MustRegister(envoyFilterStatus)
+		monitoring.MustRegister(envoyFilterStatus, envoyFilterPatches)
 		envoyFilterStatusMap = make(map[string]map[string]bool)
 	}
 }
 
 // IncrementEnvoyFilterMetric increments filter metric.
-func IncrementEnvoyFilterMetric(name string, pt PatchType, applied bool) {
+func IncrementEnvoyFilterMetric(cp *model.EnvoyFilterConfigPatchWrapper, pt PatchType, applied bool) {
+	if applied {
+		patches.recordApplied(cp)
+	}
 	if !features.EnableEnvoyFilterMetrics {
 		return
 	}
+	name := cp.Key()
+	result := Skipped
+	if applied {
+		result = Applied
+	}
+	envoyFilterPatches.With(nameType.Value(name)).With(patchType.Value(string(pt))).With(resultType.Value(string(result))).Increment()
 	envoyFilterMutex.Lock()
 	defer envoyFilterMutex.Unlock()
 	if _, exists := envoyFilterStatusMap[name]; !exists {
@@ -88,6 +107,19 @@ func IncrementEnvoyFilterErrorMetric(pt PatchType) {
 	envoyFilterStatus.With(patchType.Value(string(pt))).With(resultType.Value(string(Error))).Record(1)
 }
 
+// incrementEnvoyFilterPatchErrors counts an error for each patch of the given types, none of which took effect.
+func incrementEnvoyFilterPatchErrors(efw *model.EnvoyFilterWrapper, pt PatchType, applyTo ...networking.EnvoyFilter_ApplyTo) {
+	if !features.EnableEnvoyFilterMetrics {
+		return
+	}
+	for _, at := range applyTo {
+		for _, cp := range efw.Patches[at] {
+			envoyFilterPatches.With(nameType.Value(cp.Key())).With(patchType.Value(string(pt))).
+				With(resultType.Value(string(Error))).Increment()
+		}
+	}
+}
+
 func RecordMetrics() {
 	if !features.EnableEnvoyFilterMetrics {
 		return
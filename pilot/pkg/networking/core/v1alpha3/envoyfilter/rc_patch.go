@@ -35,11 +35,14 @@ func ApplyRouteConfigurationPatches(
 	routeConfiguration *route.RouteConfiguration) (out *route.RouteConfiguration) {
 	defer runtime.HandleCrash(runtime.LogPanic, func(interface{}) {
 		IncrementEnvoyFilterErrorMetric(Route)
+		incrementEnvoyFilterPatchErrors(efw, Route, networking.EnvoyFilter_ROUTE_CONFIGURATION,
+			networking.EnvoyFilter_VIRTUAL_HOST, networking.EnvoyFilter_HTTP_ROUTE)
 		log.Errorf("route patch caused panic, so the patches did not take effect")
 	})
 	// In case the patches cause panic, use the route generated before to reduce the influence.
 	out = routeConfiguration
-	if efw == nil {
+	if !efw.HasPatches(patchContext, networking.EnvoyFilter_ROUTE_CONFIGURATION,
+		networking.EnvoyFilter_VIRTUAL_HOST, networking.EnvoyFilter_HTTP_ROUTE) {
 		return out
 	}
 
@@ -56,9 +59,9 @@ func ApplyRouteConfigurationPatches(
 		if commonConditionMatch(patchContext, rp) &&
 			routeConfigurationMatch(patchContext, routeConfiguration, rp, portMap) {
 			proto.Merge(routeConfiguration, rp.Value)
-			IncrementEnvoyFilterMetric(rp.Key(), Route, true)
+			IncrementEnvoyFilterMetric(rp, Route, true)
 		} else {
-			IncrementEnvoyFilterMetric(rp.Key(), Route, false)
+			IncrementEnvoyFilterMetric(rp, Route, false)
 		}
 	}
 	patchVirtualHosts(patchContext, efw.Patches, routeConfiguration, portMap)
@@ -85,9 +88,9 @@ func patchVirtualHosts(patchContext networking.EnvoyFilter_PatchContext,
 		if commonConditionMatch(patchContext, rp) &&
 			routeConfigurationMatch(patchContext, routeConfiguration, rp, portMap) {
 			routeConfiguration.VirtualHosts = append(routeConfiguration.VirtualHosts, proto.Clone(rp.Value).(*route.VirtualHost))
-			IncrementEnvoyFilterMetric(rp.Key(), VirtualHost, true)
+			IncrementEnvoyFilterMetric(rp, VirtualHost, true)
 		} else {
-			IncrementEnvoyFilterMetric(rp.Key(), VirtualHost, false)
+			IncrementEnvoyFilterMetric(rp, VirtualHost, false)
 		}
 	}
 	if len(removedVirtualHosts) > 0 {
@@ -115,6 +118,7 @@ func patchVirtualHost(patchContext networking.EnvoyFilter_PatchContext,
 			virtualHostMatch(virtualHosts[idx], rp) {
 			applied = true
 			if rp.Operation == networking.EnvoyFilter_Patch_REMOVE {
+				IncrementEnvoyFilterMetric(rp, VirtualHost, applied)
 				return true
 			} else if rp.Operation == networking.EnvoyFilter_Patch_MERGE {
 				proto.Merge(virtualHosts[idx], rp.Value)
@@ -122,7 +126,7 @@ func patchVirtualHost(patchContext networking.EnvoyFilter_PatchContext,
 				virtualHosts[idx] = proto.Clone(rp.Value).(*route.VirtualHost)
 			}
 		}
-		IncrementEnvoyFilterMetric(rp.Key(), VirtualHost, applied)
+		IncrementEnvoyFilterMetric(rp, VirtualHost, applied)
 	}
 	patchHTTPRoutes(patchContext, patches, routeConfiguration, virtualHosts[idx], portMap)
 	return false
@@ -157,7 +161,7 @@ func patchHTTPRoutes(patchContext networking.EnvoyFilter_PatchContext,
 		if !commonConditionMatch(patchContext, rp) ||
 			!routeConfigurationMatch(patchContext, routeConfiguration, rp, portMap) ||
 			!virtualHostMatch(virtualHost, rp) {
-			IncrementEnvoyFilterMetric(rp.Key(), Route, applied)
+			IncrementEnvoyFilterMetric(rp, Route, applied)
 			continue
 		}
 		if rp.Operation == networking.EnvoyFilter_Patch_ADD {
@@ -167,6 +171,7 @@ func patchHTTPRoutes(patchContext networking.EnvoyFilter_PatchContext,
 			// Insert after without a route match is same as ADD in the end
 			if !hasRouteMatch(rp) {
 				virtualHost.Routes = append(virtualHost.Routes, proto.Clone(rp.Value).(*route.Route))
+				IncrementEnvoyFilterMetric(rp, Route, true)
 				continue
 			}
 			// find the matching route first
@@ -179,6 +184,7 @@ func patchHTTPRoutes(patchContext networking.EnvoyFilter_PatchContext,
 			}
 
 			if insertPosition == -1 {
+				IncrementEnvoyFilterMetric(rp, Route, false)
 				continue
 			}
 			applied = true
@@ -192,6 +198,7 @@ func patchHTTPRoutes(patchContext networking.EnvoyFilter_PatchContext,
 			// insert before/first without a route match is same as insert in the beginning
 			if !hasRouteMatch(rp) {
 				virtualHost.Routes = append([]*route.Route{proto.Clone(rp.Value).(*route.Route)}, virtualHost.Routes...)
+				IncrementEnvoyFilterMetric(rp, Route, true)
 				continue
 			}
 			// find the matching route first
@@ -205,6 +212,7 @@ func patchHTTPRoutes(patchContext networking.EnvoyFilter_PatchContext,
 
 			// If matching route is not found, then don't insert and continue.
 			if insertPosition == -1 {
+				IncrementEnvoyFilterMetric(rp, Route, false)
 				continue
 			}
 
@@ -220,7 +228,7 @@ func patchHTTPRoutes(patchContext networking.EnvoyFilter_PatchContext,
 			copy(virtualHost.Routes[insertPosition+1:], virtualHost.Routes[insertPosition:])
 			virtualHost.Routes[insertPosition] = clonedVal
 		}
-		IncrementEnvoyFilterMetric(rp.Key(), Route, applied)
+		IncrementEnvoyFilterMetric(rp, Route, applied)
 	}
 	if routesRemoved {
 		trimmedRoutes := make([]*route.Route, 0, len(virtualHost.Routes))
@@ -249,13 +257,14 @@ func patchHTTPRoute(patchContext networking.EnvoyFilter_PatchContext,
 			if rp.Operation == networking.EnvoyFilter_Patch_REMOVE {
 				virtualHost.Routes[routeIndex] = nil
 				*routesRemoved = true
+				IncrementEnvoyFilterMetric(rp, Route, true)
 				return
 			} else if rp.Operation == networking.EnvoyFilter_Patch_MERGE {
 				proto.Merge(virtualHost.Routes[routeIndex], rp.Value)
 			}
 			applied = true
 		}
-		IncrementEnvoyFilterMetric(rp.Key(), Route, applied)
+		IncrementEnvoyFilterMetric(rp, Route, applied)
 	}
 }
 
@@ -0,0 +1,254 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/api/meta/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/status"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/pkg/log"
+)
+
+// PatchesMatchedCondition is the condition of the status of an EnvoyFilter telling whether its patches matched the
+// config of a proxy.
+const PatchesMatchedCondition = "PatchesMatched"
+
+// trackedPatches are the patches applied by istiod, whose matches are recorded. The other patches, such as the
+// bootstrap patches applied by the proxies, are never reported as unmatched.
+var trackedPatches = map[networking.EnvoyFilter_ApplyTo]bool{
+	networking.EnvoyFilter_LISTENER:            true,
+	networking.EnvoyFilter_FILTER_CHAIN:        true,
+	networking.EnvoyFilter_NETWORK_FILTER:      true,
+	networking.EnvoyFilter_HTTP_FILTER:         true,
+	networking.EnvoyFilter_ROUTE_CONFIGURATION: true,
+	networking.EnvoyFilter_VIRTUAL_HOST:        true,
+	networking.EnvoyFilter_HTTP_ROUTE:          true,
+	networking.EnvoyFilter_CLUSTER:             true,
+}
+
+// patchTracker records the patches of the EnvoyFilters that were applied to the config of a proxy.
+type patchTracker struct {
+	mu sync.Mutex
+	// applied are the patches applied, by EnvoyFilter namespace/name.
+	applied map[string]*appliedPatches
+}
+
+// appliedPatches are the indexes of the patches applied for a generation of an EnvoyFilter.
+type appliedPatches struct {
+	generation int64
+	indexes    map[int]struct{}
+}
+
+var patches = &patchTracker{applied: map[string]*appliedPatches{}}
+
+func (t *patchTracker) recordApplied(cp *model.EnvoyFilterConfigPatchWrapper) {
+	if !features.EnableEnvoyFilterPatchStatus || cp == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.applied[cp.Key()]
+	if a == nil || a.generation < cp.Generation {
+		a = &appliedPatches{generation: cp.Generation, indexes: map[int]struct{}{}}
+		t.applied[cp.Key()] = a
+	} else if a.generation > cp.Generation {
+		// The config of a proxy was generated with a previous push context.
+		return
+	}
+	a.indexes[cp.Index] = struct{}{}
+}
+
+func (t *patchTracker) delete(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.applied, key)
+}
+
+// isApplied returns true if the patch of the generation of the EnvoyFilter was applied.
+func (t *patchTracker) isApplied(key string, generation int64, index int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.applied[key]
+	if a == nil || a.generation != generation {
+		return false
+	}
+	_, f := a.indexes[index]
+	return f
+}
+
+func (t *patchTracker) snapshot() map[string]AppliedPatches {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.applied) == 0 {
+		return nil
+	}
+	out := make(map[string]AppliedPatches, len(t.applied))
+	for key, a := range t.applied {
+		indexes := make([]int, 0, len(a.indexes))
+		for i := range a.indexes {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		out[key] = AppliedPatches{Generation: a.generation, Indexes: indexes}
+	}
+	return out
+}
+
+// AppliedPatches are the indexes of the patches of a generation of an EnvoyFilter that were applied to the config of
+// the proxies connected to an istiod.
+type AppliedPatches struct {
+	Generation int64 `json:"generation"`
+	Indexes    []int `json:"indexes"`
+}
+
+// LocalAppliedPatches returns the patches applied to the config of the proxies connected to this istiod, by
+// EnvoyFilter namespace/name, for the status leader to aggregate them across the replicas.
+func LocalAppliedPatches() map[string]AppliedPatches {
+	return patches.snapshot()
+}
+
+// ForgetAppliedPatches forgets the patches applied for a deleted EnvoyFilter, by namespace/name.
+func ForgetAppliedPatches(key string) {
+	patches.delete(key)
+}
+
+// PatchSource tells whether a patch of a generation of an EnvoyFilter, by namespace/name, was applied to the config
+// of a proxy connected to any istiod.
+type PatchSource interface {
+	IsPatchApplied(key string, generation int64, index int) bool
+}
+
+// StatusController writes the PatchesMatched condition to the status of the EnvoyFilters. The patches that did not
+// match the config of any proxy during an interval after the EnvoyFilter was last changed are reported as unmatched.
+// The patches applied by all the istiod replicas are taken into account, through the source.
+type StatusController struct {
+	store     model.ConfigStore
+	statusctl *status.Controller
+	source    PatchSource
+	interval  time.Duration
+	// generations are the generations of the EnvoyFilters, when they were last seen.
+	generations map[string]int64
+	// reported are the messages last reported, by EnvoyFilter.
+	reported map[string]string
+}
+
+func NewStatusController(store model.ConfigStore, statusManager *status.Manager, source PatchSource,
+	interval time.Duration) *StatusController {
+	c := &StatusController{
+		store:       store,
+		source:      source,
+		interval:    interval,
+		generations: map[string]int64{},
+		reported:    map[string]string{},
+	}
+	c.statusctl = statusManager.CreateIstioStatusController(func(s *v1alpha1.IstioStatus, context interface{}) *v1alpha1.IstioStatus {
+		return setCondition(s, context.(*v1alpha1.IstioCondition))
+	})
+	return c
+}
+
+// Run is blocking
+func (c *StatusController) Run(stop <-chan struct{}) {
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			c.reconcile()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (c *StatusController) reconcile() {
+	efs, err := c.store.List(gvk.EnvoyFilter, model.NamespaceAll)
+	if err != nil {
+		log.Errorf("failed to list EnvoyFilters for status: %v", err)
+		return
+	}
+	seen := map[string]struct{}{}
+	for _, ef := range efs {
+		key := ef.Namespace + "/" + ef.Name
+		seen[key] = struct{}{}
+		if g, f := c.generations[key]; !f || g != ef.Generation {
+			// The EnvoyFilter is new or changed: give the proxies an interval to be pushed its patches.
+			c.generations[key] = ef.Generation
+			continue
+		}
+		var unmatched []string
+		for i, cp := range ef.Spec.(*networking.EnvoyFilter).ConfigPatches {
+			if trackedPatches[cp.ApplyTo] && !c.source.IsPatchApplied(key, ef.Generation, i) {
+				unmatched = append(unmatched, strconv.Itoa(i))
+			}
+		}
+		condition := &v1alpha1.IstioCondition{
+			Type:               PatchesMatchedCondition,
+			Status:             "True",
+			LastProbeTime:      types.TimestampNow(),
+			LastTransitionTime: types.TimestampNow(),
+			Message:            "All patches matched the config of a proxy.",
+		}
+		if len(unmatched) > 0 {
+			condition.Status = "False"
+			condition.Reason = "PatchNotMatched"
+			condition.Message = fmt.Sprintf("Patches %s did not match the config of any proxy.", strings.Join(unmatched, ", "))
+		}
+		if c.reported[key] == condition.Message {
+			continue
+		}
+		c.reported[key] = condition.Message
+		c.statusctl.EnqueueStatusUpdateResource(condition, status.ResourceFromModelConfig(ef))
+	}
+	for key := range c.generations {
+		if _, f := seen[key]; !f {
+			delete(c.generations, key)
+			delete(c.reported, key)
+		}
+	}
+}
+
+// setCondition replaces the condition of the same type in the status.
+func setCondition(current *v1alpha1.IstioStatus, desired *v1alpha1.IstioCondition) *v1alpha1.IstioStatus {
+	condition := desired.DeepCopy()
+	if current == nil {
+		current = &v1alpha1.IstioStatus{}
+	} else {
+		current = current.DeepCopy()
+	}
+	for i, c := range current.Conditions {
+		if c.Type == condition.Type {
+			if c.Status == condition.Status {
+				condition.LastTransitionTime = c.LastTransitionTime
+			}
+			current.Conditions[i] = condition
+			return current
+		}
+	}
+	current.Conditions = append(current.Conditions, condition)
+	return current
+}
@@ -0,0 +1,149 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/api/meta/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/status"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestPatchTracker(t *testing.T) {
+	defer func(v bool) { features.EnableEnvoyFilterPatchStatus = v }(features.EnableEnvoyFilterPatchStatus)
+	features.EnableEnvoyFilterPatchStatus = true
+
+	tracker := &patchTracker{applied: map[string]*appliedPatches{}}
+	patch := func(generation int64, index int) *model.EnvoyFilterConfigPatchWrapper {
+		return &model.EnvoyFilterConfigPatchWrapper{Namespace: "default", Name: "ef", Generation: generation, Index: index}
+	}
+
+	tracker.recordApplied(patch(1, 0))
+	if !tracker.isApplied("default/ef", 1, 0) {
+		t.Fatalf("expected patch 0 of generation 1 to be applied")
+	}
+	if tracker.isApplied("default/ef", 1, 1) {
+		t.Fatalf("expected patch 1 of generation 1 not to be applied")
+	}
+
+	// A new generation resets the patches applied.
+	tracker.recordApplied(patch(2, 1))
+	if tracker.isApplied("default/ef", 2, 0) || !tracker.isApplied("default/ef", 2, 1) {
+		t.Fatalf("expected only patch 1 of generation 2 to be applied")
+	}
+
+	// Configs generated from a previous push context are ignored.
+	tracker.recordApplied(patch(1, 0))
+	if tracker.isApplied("default/ef", 2, 0) || tracker.isApplied("default/ef", 1, 0) {
+		t.Fatalf("expected patches of generation 1 to be ignored")
+	}
+
+	want := map[string]AppliedPatches{"default/ef": {Generation: 2, Indexes: []int{1}}}
+	if got := tracker.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected snapshot %v, got %v", want, got)
+	}
+
+	tracker.delete("default/ef")
+	if tracker.isApplied("default/ef", 2, 1) {
+		t.Fatalf("expected patches to be deleted")
+	}
+}
+
+// trackerSource is a PatchSource backed by a single tracker, as if there was a single replica.
+type trackerSource struct {
+	*patchTracker
+}
+
+func (s trackerSource) IsPatchApplied(key string, generation int64, index int) bool {
+	return s.isApplied(key, generation, index)
+}
+
+func TestStatusController(t *testing.T) {
+	defer func(v bool) { features.EnableEnvoyFilterPatchStatus = v }(features.EnableEnvoyFilterPatchStatus)
+	features.EnableEnvoyFilterPatchStatus = true
+
+	stop := make(chan struct{})
+	defer close(stop)
+	store := model.MakeIstioStore(memory.MakeSkipValidation(collections.Pilot))
+	manager := status.NewManager(store)
+	manager.Start(stop)
+
+	ef := config.Config{
+		Meta: config.Meta{GroupVersionKind: gvk.EnvoyFilter, Name: "ef", Namespace: "default", Generation: 1},
+		Spec: &networking.EnvoyFilter{
+			ConfigPatches: []*networking.EnvoyFilter_EnvoyConfigObjectPatch{
+				{ApplyTo: networking.EnvoyFilter_CLUSTER},
+				{ApplyTo: networking.EnvoyFilter_HTTP_FILTER},
+				// Bootstrap patches are applied by the proxies, and are not tracked.
+				{ApplyTo: networking.EnvoyFilter_BOOTSTRAP},
+			},
+		},
+	}
+	if _, err := store.Create(ef); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := &patchTracker{applied: map[string]*appliedPatches{}}
+	c := NewStatusController(store, manager, trackerSource{tracker}, time.Minute)
+	// The EnvoyFilter is only reported once its generation was seen for an interval.
+	c.reconcile()
+	tracker.recordApplied(&model.EnvoyFilterConfigPatchWrapper{Namespace: "default", Name: "ef", Generation: 1, Index: 0})
+	c.reconcile()
+	expectCondition(t, store, "False", "Patches 1 did not match the config of any proxy.")
+
+	tracker.recordApplied(&model.EnvoyFilterConfigPatchWrapper{Namespace: "default", Name: "ef", Generation: 1, Index: 1})
+	c.reconcile()
+	expectCondition(t, store, "True", "All patches matched the config of a proxy.")
+
+	if err := store.Delete(gvk.EnvoyFilter, "ef", "default", nil); err != nil {
+		t.Fatal(err)
+	}
+	c.reconcile()
+	if len(c.generations) != 0 || len(c.reported) != 0 {
+		t.Fatalf("expected the deleted EnvoyFilter to be cleaned up")
+	}
+}
+
+func expectCondition(t *testing.T, store model.ConfigStore, conditionStatus, message string) {
+	t.Helper()
+	retry.UntilSuccessOrFail(t, func() error {
+		cfg := store.Get(gvk.EnvoyFilter, "ef", "default")
+		if cfg == nil {
+			return fmt.Errorf("EnvoyFilter not found")
+		}
+		s, _ := cfg.Status.(*v1alpha1.IstioStatus)
+		for _, c := range s.GetConditions() {
+			if c.Type != PatchesMatchedCondition {
+				continue
+			}
+			if c.Status != conditionStatus || c.Message != message {
+				return fmt.Errorf("got condition %v %q, want %v %q", c.Status, c.Message, conditionStatus, message)
+			}
+			return nil
+		}
+		return fmt.Errorf("condition %v not found in %v", PatchesMatchedCondition, s)
+	}, retry.Timeout(time.Second*10), retry.BackoffDelay(time.Millisecond*10))
+}
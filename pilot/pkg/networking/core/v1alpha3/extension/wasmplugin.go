@@ -16,6 +16,7 @@ package extension
 
 import (
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	hcm_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"google.golang.org/protobuf/proto"
@@ -31,10 +32,7 @@ import (
 	_ "istio.io/istio/pkg/wasm"
 )
 
-const (
-	wasmFilterType  = "envoy.extensions.filters.http.wasm.v3.Wasm"
-	statsFilterName = "istio.stats"
-)
+const statsFilterName = "istio.stats"
 
 var defaultConfigSource = &envoy_config_core_v3.ConfigSource{
 	ConfigSourceSpecifier: &envoy_config_core_v3.ConfigSource_Ads{
@@ -46,7 +44,8 @@ var defaultConfigSource = &envoy_config_core_v3.ConfigSource{
 	InitialFetchTimeout: &durationpb.Duration{Seconds: 0},
 }
 
-// AddWasmPluginsToMutableObjects adds WasmPlugins to HTTP filterChains
+// AddWasmPluginsToMutableObjects adds HTTP WasmPlugins to HTTP filterChains, and network WasmPlugins
+// to TCP filterChains.
 // Note that the slices in the map must already be ordered by plugin
 // priority! This will be the case for maps returned by PushContext.WasmPlugin()
 func AddWasmPluginsToMutableObjects(
@@ -58,21 +57,32 @@ func AddWasmPluginsToMutableObjects(
 	}
 
 	for fcIndex, fc := range mutable.FilterChains {
-		// we currently only support HTTP
-		if fc.ListenerProtocol != networking.ListenerProtocolHTTP {
-			continue
+		switch fc.ListenerProtocol {
+		case networking.ListenerProtocolHTTP:
+			mutable.FilterChains[fcIndex].HTTP = injectExtensions(fc.HTTP, extensionsMap)
+		case networking.ListenerProtocolTCP:
+			mutable.FilterChains[fcIndex].TCP = injectNetworkExtensions(fc.TCP, extensionsMap)
 		}
-		mutable.FilterChains[fcIndex].HTTP = injectExtensions(fc.HTTP, extensionsMap)
 	}
 }
 
-func injectExtensions(filterChain []*hcm_filter.HttpFilter, exts map[extensions.PluginPhase][]*model.WasmPluginWrapper) []*hcm_filter.HttpFilter {
-	// copy map as we'll manipulate it in the loop
+// extensionsOfType copies the extensions of the type, as the map is manipulated while injecting them.
+func extensionsOfType(exts map[extensions.PluginPhase][]*model.WasmPluginWrapper,
+	pluginType networking.WasmPluginType) map[extensions.PluginPhase][]*model.WasmPluginWrapper {
 	extMap := make(map[extensions.PluginPhase][]*model.WasmPluginWrapper)
 	for phase, list := range exts {
 		extMap[phase] = []*model.WasmPluginWrapper{}
-		extMap[phase] = append(extMap[phase], list...)
+		for _, ext := range list {
+			if ext.Type == pluginType {
+				extMap[phase] = append(extMap[phase], ext)
+			}
+		}
 	}
+	return extMap
+}
+
+func injectExtensions(filterChain []*hcm_filter.HttpFilter, exts map[extensions.PluginPhase][]*model.WasmPluginWrapper) []*hcm_filter.HttpFilter {
+	extMap := extensionsOfType(exts, networking.WasmPluginTypeHTTP)
 	newHTTPFilters := make([]*hcm_filter.HttpFilter, 0)
 	// The following algorithm tries to make as few assumptions as possible about the filter
 	// chain - it might contain any number of filters that will have to retain their ordering.
@@ -133,7 +143,51 @@ func toEnvoyHTTPFilter(wasmPlugin *model.WasmPluginWrapper) *hcm_filter.HttpFilt
 		ConfigType: &hcm_filter.HttpFilter_ConfigDiscovery{
 			ConfigDiscovery: &envoy_config_core_v3.ExtensionConfigSource{
 				ConfigSource: defaultConfigSource,
-				TypeUrls:     []string{"type.googleapis.com/" + wasmFilterType},
+				TypeUrls:     []string{wasmPlugin.ExtensionConfiguration.GetTypedConfig().GetTypeUrl()},
+			},
+		},
+	}
+}
+
+// injectNetworkExtensions injects the network WasmPlugins in the network filters built by the plugins. The
+// terminal filter, such as the TCP proxy, is added after them when building the listener, so all the
+// WasmPlugins are injected before it. The AUTHN and AUTHZ phases are injected before the network RBAC and
+// ext_authz filters, and the others at the end.
+func injectNetworkExtensions(filterChain []*listener.Filter, exts map[extensions.PluginPhase][]*model.WasmPluginWrapper) []*listener.Filter {
+	extMap := extensionsOfType(exts, networking.WasmPluginTypeNetwork)
+	var newFilters []*listener.Filter
+	for _, filter := range filterChain {
+		switch filter.Name {
+		case wellknown.RoleBasedAccessControl, wellknown.ExternalAuthorization:
+			newFilters = popAppendNetwork(newFilters, extMap, extensions.PluginPhase_AUTHN)
+			newFilters = popAppendNetwork(newFilters, extMap, extensions.PluginPhase_AUTHZ)
+		}
+		newFilters = append(newFilters, filter)
+	}
+	newFilters = popAppendNetwork(newFilters, extMap, extensions.PluginPhase_AUTHN)
+	newFilters = popAppendNetwork(newFilters, extMap, extensions.PluginPhase_AUTHZ)
+	newFilters = popAppendNetwork(newFilters, extMap, extensions.PluginPhase_STATS)
+	newFilters = popAppendNetwork(newFilters, extMap, extensions.PluginPhase_UNSPECIFIED_PHASE)
+	return newFilters
+}
+
+func popAppendNetwork(list []*listener.Filter,
+	filterMap map[extensions.PluginPhase][]*model.WasmPluginWrapper,
+	phase extensions.PluginPhase) []*listener.Filter {
+	for _, ext := range filterMap[phase] {
+		list = append(list, toEnvoyNetworkFilter(ext))
+	}
+	filterMap[phase] = []*model.WasmPluginWrapper{}
+	return list
+}
+
+func toEnvoyNetworkFilter(wasmPlugin *model.WasmPluginWrapper) *listener.Filter {
+	return &listener.Filter{
+		Name: wasmPlugin.ExtensionConfiguration.Name,
+		ConfigType: &listener.Filter_ConfigDiscovery{
+			ConfigDiscovery: &envoy_config_core_v3.ExtensionConfigSource{
+				ConfigSource: defaultConfigSource,
+				TypeUrls:     []string{wasmPlugin.ExtensionConfiguration.GetTypedConfig().GetTypeUrl()},
 			},
 		},
 	}
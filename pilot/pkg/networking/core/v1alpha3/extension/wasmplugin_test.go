@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	http_conn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/gogo/protobuf/types"
@@ -76,6 +77,28 @@ var (
 			Name: "istio-system.someAuthZFilter",
 		},
 	}
+	networkRBAC = &listener.Filter{
+		Name: wellknown.RoleBasedAccessControl,
+	}
+	networkUnknown = &listener.Filter{
+		Name: "unknown.network.filter",
+	}
+	someNetworkAuthZFilter = &model.WasmPluginWrapper{
+		Name:      "someNetworkAuthZFilter",
+		Namespace: "istio-system",
+		Type:      networking.WasmPluginTypeNetwork,
+		ExtensionConfiguration: &envoy_config_core_v3.TypedExtensionConfig{
+			Name: "istio-system.someNetworkAuthZFilter",
+		},
+	}
+	someNetworkStatsFilter = &model.WasmPluginWrapper{
+		Name:      "someNetworkStatsFilter",
+		Namespace: "istio-system",
+		Type:      networking.WasmPluginTypeNetwork,
+		ExtensionConfiguration: &envoy_config_core_v3.TypedExtensionConfig{
+			Name: "istio-system.someNetworkStatsFilter",
+		},
+	}
 )
 
 func TestAddWasmPluginsToMutableObjects(t *testing.T) {
@@ -178,6 +201,51 @@ func TestAddWasmPluginsToMutableObjects(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "network",
+			filterChains: []networking.FilterChain{
+				{
+					ListenerProtocol: networking.ListenerProtocolTCP,
+					TCP: []*listener.Filter{
+						networkUnknown,
+						networkRBAC,
+					},
+				},
+				{
+					ListenerProtocol: networking.ListenerProtocolHTTP,
+					HTTP: []*http_conn.HttpFilter{
+						istioAuthZ,
+					},
+				},
+			},
+			extensions: map[extensions.PluginPhase][]*model.WasmPluginWrapper{
+				extensions.PluginPhase_AUTHZ: {
+					someNetworkAuthZFilter,
+					someAuthZFilter,
+				},
+				extensions.PluginPhase_STATS: {
+					someNetworkStatsFilter,
+				},
+			},
+			expectedResult: []networking.FilterChain{
+				{
+					ListenerProtocol: networking.ListenerProtocolTCP,
+					TCP: []*listener.Filter{
+						networkUnknown,
+						toEnvoyNetworkFilter(someNetworkAuthZFilter),
+						networkRBAC,
+						toEnvoyNetworkFilter(someNetworkStatsFilter),
+					},
+				},
+				{
+					ListenerProtocol: networking.ListenerProtocolHTTP,
+					HTTP: []*http_conn.HttpFilter{
+						toEnvoyHTTPFilter(someAuthZFilter),
+						istioAuthZ,
+					},
+				},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -235,6 +235,7 @@ func (f *ConfigGenTest) SetupProxy(p *model.Proxy) *model.Proxy {
 	}
 
 	// Initialize data structures
+	p.SetFeatureGates()
 	pc := f.PushContext()
 	p.SetSidecarScope(pc)
 	p.SetServiceInstances(f.env.ServiceDiscovery)
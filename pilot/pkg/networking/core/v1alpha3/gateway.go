@@ -28,13 +28,16 @@ import (
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/hashicorp/go-multierror"
+	golangproto "google.golang.org/protobuf/proto"
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	istionetworking "istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/extension"
 	istio_route "istio.io/istio/pilot/pkg/networking/core/v1alpha3/route"
 	"istio.io/istio/pilot/pkg/networking/plugin"
@@ -56,6 +59,7 @@ type mutableListenerOpts struct {
 }
 
 func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBuilder) *ListenerBuilder {
+	defer builder.profile.Time("gateway_listeners")()
 	if builder.node.MergedGateway == nil {
 		log.Debugf("buildGatewayListeners: no gateways for router %v", builder.node.ID)
 		return builder
@@ -68,6 +72,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 	errs := istiomultierror.New()
 	// Mutable objects keyed by listener name so that we can build listeners at the end.
 	mutableopts := make(map[string]mutableListenerOpts)
+	var udpListeners []*listener.Listener
 	proxyConfig := builder.node.Metadata.ProxyConfigOrDefault(builder.push.Mesh.DefaultConfig)
 	for _, port := range mergedGateway.ServerPorts {
 		// Skip ports we cannot bind to. Note that MergeGateways will already translate Service port to
@@ -83,6 +88,13 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 			bind = port.Bind
 		}
 
+		if features.EnableUDPProxy && protocol.Parse(port.Protocol) == protocol.UDP {
+			if l := buildGatewayUDPListener(builder.node, builder.push, bind, port, mergedGateway.MergedServers[port]); l != nil {
+				udpListeners = append(udpListeners, l)
+			}
+			continue
+		}
+
 		// NOTE: There is no gating here to check for the value of the QUIC feature flag. However,
 		// they are created in MergeGatways only when the flag is set. So when it is turned off, the
 		// MergedQUICTransportServers would be nil so that no listener would be created. It is written this way
@@ -169,6 +181,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 		}
 		listeners = append(listeners, ml.mutable.Listener)
 	}
+	listeners = append(listeners, udpListeners...)
 	// We'll try to return any listeners we successfully marshaled; if we have none, we'll emit the error we built up
 	err := errs.ErrorOrNil()
 	if err != nil {
@@ -176,7 +189,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 		log.Info(err.Error())
 	}
 
-	if len(mutableopts) == 0 {
+	if len(mutableopts) == 0 && len(udpListeners) == 0 {
 		log.Warnf("gateway has zero listeners for node %v", builder.node.ID)
 		return builder
 	}
@@ -274,7 +287,7 @@ func getListenerName(bind string, port int, transport istionetworking.TransportP
 	switch transport {
 	case istionetworking.TransportProtocolTCP:
 		return bind + "_" + strconv.Itoa(port)
-	case istionetworking.TransportProtocolQUIC:
+	case istionetworking.TransportProtocolQUIC, istionetworking.TransportProtocolUDP:
 		return "udp_" + bind + "_" + strconv.Itoa(port)
 	}
 	return "unknown"
@@ -322,15 +335,18 @@ func buildNameToServiceMapForHTTPRoutes(node *model.Proxy, push *model.PushConte
 	return nameToServiceMap
 }
 
-func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Proxy, push *model.PushContext,
-	routeName string) *route.RouteConfiguration {
+// buildGatewayHTTPRouteConfig builds the route configuration of a gateway with the envoy filter patches applied.
+// The returned bool is true if the route configuration was served from the cache.
+func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Proxy, req *model.PushRequest,
+	routeName string, efw *model.EnvoyFilterWrapper, efKeys []string) (*discovery.Resource, bool) {
+	push := req.Push
 	if node.MergedGateway == nil {
 		log.Warnf("buildGatewayRoutes: no gateways for router %v", node.ID)
-		return &route.RouteConfiguration{
+		return gatewayRouteResource(node, efw, &route.RouteConfiguration{
 			Name:             routeName,
 			VirtualHosts:     []*route.VirtualHost{},
 			ValidateClusters: proto.BoolFalse,
-		}
+		}), false
 	}
 
 	merged := node.MergedGateway
@@ -342,7 +358,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 
 		// This can happen when a gateway has recently been deleted. Envoy will still request route
 		// information due to the draining of listeners, so we should not return an error.
-		return nil
+		return nil, false
 	}
 
 	servers := merged.ServersByRouteName[routeName]
@@ -352,21 +368,82 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 	// very important for discovering HTTP/3 services
 	_, isH3DiscoveryNeeded := merged.HTTP3AdvertisingRoutes[routeName]
 
-	gatewayRoutes := make(map[string]map[string][]*route.Route)
+	// Collect the virtual services bound to the gateways of the servers, along with the services and consistent
+	// hash policies of their destinations, as the route configuration only depends on these and the gateways.
+	routeCache := &istio_route.GatewayCache{
+		RouteName:       routeName,
+		ProxyVersion:    node.Metadata.IstioVersion,
+		ClusterID:       string(node.Metadata.ClusterID),
+		DNSDomain:       node.DNSDomain,
+		FeatureGates:    node.FeatureGatesKey(),
+		HTTP3:           isH3DiscoveryNeeded,
+		EnvoyFilterKeys: efKeys,
+	}
 	gatewayVirtualServices := make(map[string][]config.Config)
-	vHostDedupMap := make(map[host.Name]*route.VirtualHost)
+	servicesByVirtualService := make(map[string]map[host.Name]*model.Service)
+	hashesByVirtualService := make(map[string]map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB)
+	services := make(map[string]*model.Service)
+	serversByGateway := make(map[string][]*networking.Server)
 	for _, server := range servers {
 		gatewayName := merged.GatewayNameForServer[server]
-		port := int(server.Port.Number)
-
-		var virtualServices []config.Config
-		var exists bool
+		serversByGateway[gatewayName] = append(serversByGateway[gatewayName], server)
+	}
+	for _, server := range servers {
+		gatewayName := merged.GatewayNameForServer[server]
+		if _, exists := gatewayVirtualServices[gatewayName]; exists {
+			continue
+		}
+		// Only keep the virtual services matching the hosts of the servers, so that the route configuration of a
+		// server, or of a shard of the servers, is not invalidated by changes to unrelated virtual services.
+		virtualServices := virtualServicesForServers(push.VirtualServicesForGateway(node.ConfigNamespace, gatewayName),
+			serversByGateway[gatewayName])
+		gatewayVirtualServices[gatewayName] = virtualServices
+		routeCache.Gateways = append(routeCache.Gateways, gatewayName)
 
-		if virtualServices, exists = gatewayVirtualServices[gatewayName]; !exists {
-			virtualServices = push.VirtualServicesForGateway(node.ConfigNamespace, gatewayName)
-			gatewayVirtualServices[gatewayName] = virtualServices
+		for _, virtualService := range virtualServices {
+			vskey := virtualService.Name + "/" + virtualService.Namespace
+			if _, exists := servicesByVirtualService[vskey]; exists {
+				continue
+			}
+			// Make sure we can obtain services which are visible to this virtualService as much as possible.
+			nameToServiceMap := buildNameToServiceMapForHTTPRoutes(node, push, virtualService)
+			hashByDestination, destinationRules := istio_route.GetConsistentHashForVirtualService(push, node, virtualService, nameToServiceMap)
+			servicesByVirtualService[vskey] = nameToServiceMap
+			hashesByVirtualService[vskey] = hashByDestination
+			routeCache.VirtualServices = append(routeCache.VirtualServices, virtualService)
+			routeCache.DestinationRules = append(routeCache.DestinationRules, destinationRules...)
+			for _, svc := range nameToServiceMap {
+				if svc != nil {
+					services[string(svc.Hostname)+"/"+svc.Attributes.Namespace] = svc
+				}
+			}
+		}
+	}
+	sort.Strings(routeCache.Gateways)
+	routeCache.DelegateVirtualServices = push.DelegateVirtualServicesConfigKey(routeCache.VirtualServices)
+	routeCache.Services = make([]*model.Service, 0, len(services))
+	for _, svc := range services {
+		routeCache.Services = append(routeCache.Services, svc)
+	}
+	sort.Slice(routeCache.Services, func(i, j int) bool {
+		if routeCache.Services[i].Hostname != routeCache.Services[j].Hostname {
+			return routeCache.Services[i].Hostname < routeCache.Services[j].Hostname
 		}
+		return routeCache.Services[i].Attributes.Namespace < routeCache.Services[j].Attributes.Namespace
+	})
+
+	resource, exist := configgen.Cache.Get(routeCache)
+	if exist && !features.EnableUnsafeAssertions {
+		return resource, true
+	}
+
+	gatewayRoutes := make(map[string]map[string][]*route.Route)
+	vHostDedupMap := make(map[host.Name]*route.VirtualHost)
+	for _, server := range servers {
+		gatewayName := merged.GatewayNameForServer[server]
+		port := int(server.Port.Number)
 
+		virtualServices := gatewayVirtualServices[gatewayName]
 		for _, virtualService := range virtualServices {
 			virtualServiceHosts := host.NewNames(virtualService.Spec.(*networking.VirtualService).Hosts)
 			serverHosts := host.NamesForNamespace(server.Hosts, virtualService.Namespace)
@@ -379,9 +456,6 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 				continue
 			}
 
-			// Make sure we can obtain services which are visible to this virtualService as much as possible.
-			nameToServiceMap := buildNameToServiceMapForHTTPRoutes(node, push, virtualService)
-
 			var routes []*route.Route
 			var exists bool
 			var err error
@@ -392,9 +466,9 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 			vskey := virtualService.Name + "/" + virtualService.Namespace
 
 			if routes, exists = gatewayRoutes[gatewayName][vskey]; !exists {
-				hashByDestination := istio_route.GetConsistentHashForVirtualService(push, node, virtualService, nameToServiceMap)
-				routes, err = istio_route.BuildHTTPRoutesForVirtualService(node, virtualService, nameToServiceMap,
-					hashByDestination, port, map[string]bool{gatewayName: true}, isH3DiscoveryNeeded, push.Mesh)
+				routes, err = istio_route.BuildHTTPRoutesForVirtualService(node, virtualService, push.VirtualServiceRouteAnnotations(virtualService),
+					servicesByVirtualService[vskey], hashesByVirtualService[vskey], port, map[string]bool{gatewayName: true},
+					isH3DiscoveryNeeded, push.Mesh)
 				if err != nil {
 					log.Debugf("%s omitting routes for virtual service %v/%v due to error: %v", node.ID, virtualService.Namespace, virtualService.Name, err)
 					continue
@@ -418,6 +492,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 					if server.Tls != nil && server.Tls.HttpsRedirect {
 						newVHost.RequireTls = route.VirtualHost_ALL
 					}
+					istio_route.ApplyDefaultCORSPolicy(newVHost, push, virtualService.Namespace)
 					vHostDedupMap[hostname] = newVHost
 				}
 			}
@@ -455,7 +530,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 		}}
 	} else {
 		virtualHosts = make([]*route.VirtualHost, 0, len(vHostDedupMap))
-		vHostDedupMap = collapseDuplicateRoutes(vHostDedupMap)
+		vHostDedupMap = collapseDuplicateRoutes(node, vHostDedupMap)
 		for _, v := range vHostDedupMap {
 			v.Routes = istio_route.CombineVHostRoutes(v.Routes)
 			virtualHosts = append(virtualHosts, v)
@@ -471,7 +546,35 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 		ValidateClusters: proto.BoolFalse,
 	}
 
-	return routeCfg
+	resource = gatewayRouteResource(node, efw, routeCfg)
+	if features.EnableRDSCaching {
+		configgen.Cache.Add(routeCache, req, resource)
+	}
+	return resource, false
+}
+
+// virtualServicesForServers returns the virtual services with hosts matching the hosts of at least one of the servers.
+func virtualServicesForServers(virtualServices []config.Config, servers []*networking.Server) []config.Config {
+	out := make([]config.Config, 0, len(virtualServices))
+	for _, virtualService := range virtualServices {
+		virtualServiceHosts := host.NewNames(virtualService.Spec.(*networking.VirtualService).Hosts)
+		for _, server := range servers {
+			if len(host.NamesForNamespace(server.Hosts, virtualService.Namespace).Intersection(virtualServiceHosts)) > 0 {
+				out = append(out, virtualService)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// gatewayRouteResource applies the envoy filter patches to a gateway route configuration.
+func gatewayRouteResource(node *model.Proxy, efw *model.EnvoyFilterWrapper, rc *route.RouteConfiguration) *discovery.Resource {
+	rc = envoyfilter.ApplyRouteConfigurationPatches(networking.EnvoyFilter_GATEWAY, node, efw, rc)
+	return &discovery.Resource{
+		Name:     rc.Name,
+		Resource: util.MessageToAny(rc),
+	}
 }
 
 // hashRouteList returns a hash of a list of pointers
@@ -500,8 +603,8 @@ func hashRouteList(r []*route.Route) uint64 {
 // after: [{vhosts: [a,b], routes: [r1, r2]}]
 // Note: At this point in the code, r1 and r2 are just pointers. However, once we send them over the wire
 // they are fully expanded and expensive, so the optimization is important.
-func collapseDuplicateRoutes(input map[host.Name]*route.VirtualHost) map[host.Name]*route.VirtualHost {
-	if !features.EnableRouteCollapse {
+func collapseDuplicateRoutes(node *model.Proxy, input map[host.Name]*route.VirtualHost) map[host.Name]*route.VirtualHost {
+	if !node.FeatureEnabled(model.FeatureGateRouteCollapse) {
 		return input
 	}
 	dedupe := make(map[host.Name]*route.VirtualHost, len(input))
@@ -539,6 +642,9 @@ func vhostMergeable(a, b *route.VirtualHost) bool {
 	if a.RequireTls != b.RequireTls {
 		return false
 	}
+	if !golangproto.Equal(a.Cors, b.Cors) {
+		return false
+	}
 	if !routesEqual(a.Routes, b.Routes) {
 		return false
 	}
@@ -637,6 +743,7 @@ func buildGatewayConnectionManager(proxyConfig *meshconfig.ProxyConfig, node *mo
 		httpConnManager.Http3ProtocolOptions = &core.Http3ProtocolOptions{}
 		httpConnManager.CodecType = hcm.HttpConnectionManager_HTTP3
 	}
+	applyClientCertDetails(node, httpConnManager)
 	return httpConnManager
 }
 
@@ -723,6 +830,16 @@ func buildGatewayNetworkFiltersFromTCPRoutes(node *model.Proxy, push *model.Push
 		Protocol: protocol.Parse(server.Port.Protocol),
 	}
 
+	if routes, meta := gatewayTCPRouteForServer(node, push, server, gateway); len(routes) > 0 {
+		return buildOutboundNetworkFilters(node, routes, push, port, meta)
+	}
+	return nil
+}
+
+// gatewayTCPRouteForServer returns the destinations of the first TCP route of the VirtualServices bound to the
+// gateway matching the server, along with the metadata of its VirtualService.
+func gatewayTCPRouteForServer(node *model.Proxy, push *model.PushContext, server *networking.Server,
+	gateway string) ([]*networking.RouteDestination, config.Meta) {
 	gatewayServerHosts := make(map[host.Name]bool, len(server.Hosts))
 	for _, hostname := range server.Hosts {
 		gatewayServerHosts[host.Name(hostname)] = true
@@ -749,12 +866,12 @@ func buildGatewayNetworkFiltersFromTCPRoutes(node *model.Proxy, push *model.Push
 		// based on the match port/server port and the gateway name
 		for _, tcp := range vsvc.Tcp {
 			if l4MultiMatch(tcp.Match, server, gateway) {
-				return buildOutboundNetworkFilters(node, tcp.Route, push, port, v.Meta)
+				return tcp.Route, v.Meta
 			}
 		}
 	}
 
-	return nil
+	return nil, config.Meta{}
 }
 
 // buildGatewayNetworkFiltersFromTLSRoutes builds tcp proxy routes for all VirtualServices with TLS blocks.
@@ -864,7 +981,7 @@ func builtAutoPassthroughFilterChains(push *model.PushContext, proxy *model.Prox
 			if len(push.Mesh.OutboundClusterStatName) != 0 {
 				statPrefix = util.BuildStatPrefix(push.Mesh.OutboundClusterStatName, string(service.Hostname), "", port, &service.Attributes)
 			}
-			destinationRule := CastDestinationRule(proxy.SidecarScope.DestinationRule(service.Hostname))
+			destinationRule := proxy.SidecarScope.DestinationRule(service.Hostname)
 
 			// First, we build the standard cluster. We match on the SNI matching the cluster name
 			// (per the spec of AUTO_PASSTHROUGH), as well as all possible Istio mTLS ALPNs. This,
@@ -880,7 +997,7 @@ func builtAutoPassthroughFilterChains(push *model.PushContext, proxy *model.Prox
 			})
 
 			// Do the same, but for each subset
-			for _, subset := range destinationRule.GetSubsets() {
+			for _, subset := range CastDestinationRule(destinationRule).GetSubsets() {
 				subsetClusterName := model.BuildDNSSrvSubsetKey(model.TrafficDirectionOutbound, subset.Name, service.Hostname, port.Port)
 				subsetStatPrefix := subsetClusterName
 				// If stat name is configured, build the stat prefix from configured pattern.
@@ -15,15 +15,19 @@
 package v1alpha3
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	udpproxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
 	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -1754,10 +1758,17 @@ func TestGatewayHTTPRouteConfig(t *testing.T) {
 				cg := NewConfigGenTest(t, TestOptions{
 					Configs: cfgs,
 				})
-				r := cg.ConfigGen.buildGatewayHTTPRouteConfig(cg.SetupProxy(&proxyGateway), cg.PushContext(), tt.routeName)
-				if r == nil {
+				proxy := cg.SetupProxy(&proxyGateway)
+				push := cg.PushContext()
+				resource, _ := cg.ConfigGen.buildGatewayHTTPRouteConfig(proxy, &pilot_model.PushRequest{Push: push},
+					tt.routeName, push.EnvoyFilters(proxy), nil)
+				if resource == nil {
 					t.Fatal("got an empty route configuration")
 				}
+				r := &route.RouteConfiguration{}
+				if err := resource.Resource.UnmarshalTo(r); err != nil {
+					t.Fatal(err)
+				}
 				vh := make(map[string][]string)
 				hr := make(map[string]int)
 				for _, h := range r.VirtualHosts {
@@ -1788,6 +1799,198 @@ func TestGatewayHTTPRouteConfig(t *testing.T) {
 	}
 }
 
+func TestGatewayHTTPRouteConfigCache(t *testing.T) {
+	gateway := config.Config{
+		Meta: config.Meta{Name: "gateway", Namespace: "default", GroupVersionKind: gvk.Gateway},
+		Spec: &networking.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+			Servers: []*networking.Server{{
+				Hosts: []string{"example.org"},
+				Port:  &networking.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+			}},
+		},
+	}
+	virtualService := config.Config{
+		Meta: config.Meta{Name: "virtual-service", Namespace: "default", GroupVersionKind: gvk.VirtualService},
+		Spec: &networking.VirtualService{
+			Hosts:    []string{"example.org"},
+			Gateways: []string{"gateway"},
+			Http: []*networking.HTTPRoute{{
+				Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "example.org"}}},
+			}},
+		},
+	}
+	sourceMatchVirtualService := config.Config{
+		Meta: config.Meta{Name: "virtual-service", Namespace: "default", GroupVersionKind: gvk.VirtualService},
+		Spec: &networking.VirtualService{
+			Hosts:    []string{"example.org"},
+			Gateways: []string{"gateway"},
+			Http: []*networking.HTTPRoute{{
+				Match: []*networking.HTTPMatchRequest{{SourceNamespace: "istio-system"}},
+				Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "example.org"}}},
+			}},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		virtualService config.Config
+		clear          pilot_model.ConfigKey
+		cachedBefore   bool
+		cachedAfter    bool
+	}{
+		{
+			name:           "gateway updated",
+			virtualService: virtualService,
+			clear:          pilot_model.ConfigKey{Kind: gvk.Gateway, Name: "gateway", Namespace: "default"},
+			cachedBefore:   true,
+			cachedAfter:    false,
+		},
+		{
+			name:           "virtual service updated",
+			virtualService: virtualService,
+			clear:          pilot_model.ConfigKey{Kind: gvk.VirtualService, Name: "virtual-service", Namespace: "default"},
+			cachedBefore:   true,
+			cachedAfter:    false,
+		},
+		{
+			name:           "gateway api route updated",
+			virtualService: virtualService,
+			clear:          pilot_model.ConfigKey{Kind: gvk.HTTPRoute, Name: "route", Namespace: "default"},
+			cachedBefore:   true,
+			cachedAfter:    false,
+		},
+		{
+			name:           "unrelated virtual service updated",
+			virtualService: virtualService,
+			clear:          pilot_model.ConfigKey{Kind: gvk.VirtualService, Name: "other", Namespace: "default"},
+			cachedBefore:   true,
+			cachedAfter:    true,
+		},
+		{
+			name:           "source match",
+			virtualService: sourceMatchVirtualService,
+			clear:          pilot_model.ConfigKey{Kind: gvk.VirtualService, Name: "other", Namespace: "default"},
+			cachedBefore:   false,
+			cachedAfter:    false,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cg := NewConfigGenTest(t, TestOptions{Configs: []config.Config{gateway, tt.virtualService}})
+			cache := pilot_model.NewXdsCache()
+			cg.ConfigGen.Cache = cache
+			proxy := cg.SetupProxy(&proxyGateway)
+			push := cg.PushContext()
+			build := func() (*discovery.Resource, bool) {
+				return cg.ConfigGen.buildGatewayHTTPRouteConfig(proxy, &pilot_model.PushRequest{Push: push, Start: time.Now()},
+					"http.80", push.EnvoyFilters(proxy), nil)
+			}
+
+			want, cached := build()
+			if cached {
+				t.Fatalf("expected the first build not to be cached")
+			}
+			got, cached := build()
+			if cached != tt.cachedBefore {
+				t.Fatalf("expected cached %v, got %v", tt.cachedBefore, cached)
+			}
+			if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+				t.Fatalf("cached route configuration differs from the built one: %v", diff)
+			}
+
+			cache.Clear(map[pilot_model.ConfigKey]struct{}{tt.clear: {}})
+			if _, cached := build(); cached != tt.cachedAfter {
+				t.Fatalf("expected cached %v after clearing %v, got %v", tt.cachedAfter, tt.clear, cached)
+			}
+		})
+	}
+}
+
+func TestGatewayHTTPRouteConfigShards(t *testing.T) {
+	defaultValue := features.GatewayRouteShards
+	features.GatewayRouteShards = 4
+	defer func() { features.GatewayRouteShards = defaultValue }()
+
+	var hosts []string
+	var servers []*networking.Server
+	configs := []config.Config{}
+	for i := 0; i < 20; i++ {
+		hostname := fmt.Sprintf("host-%d.example.org", i)
+		hosts = append(hosts, hostname)
+		servers = append(servers, &networking.Server{
+			Hosts: []string{hostname},
+			Port:  &networking.Port{Name: "https", Number: 443, Protocol: "HTTPS"},
+			Tls: &networking.ServerTLSSettings{
+				Mode:           networking.ServerTLSSettings_SIMPLE,
+				CredentialName: fmt.Sprintf("cert-%d", i),
+			},
+		})
+		configs = append(configs, config.Config{
+			Meta: config.Meta{Name: fmt.Sprintf("vs-%d", i), Namespace: "default", GroupVersionKind: gvk.VirtualService},
+			Spec: &networking.VirtualService{
+				Hosts:    []string{hostname},
+				Gateways: []string{"default/gateway"},
+				Http: []*networking.HTTPRoute{{
+					Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: hostname}}},
+				}},
+			},
+		})
+	}
+	configs = append(configs, config.Config{
+		Meta: config.Meta{Name: "gateway", Namespace: "default", GroupVersionKind: gvk.Gateway},
+		Spec: &networking.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+			Servers:  servers,
+		},
+	})
+
+	cg := NewConfigGenTest(t, TestOptions{Configs: configs})
+	cache := pilot_model.NewXdsCache()
+	cg.ConfigGen.Cache = cache
+	proxy := cg.SetupProxy(&proxyGateway)
+	push := cg.PushContext()
+	build := func(routeName string) (*route.RouteConfiguration, bool) {
+		resource, cached := cg.ConfigGen.buildGatewayHTTPRouteConfig(proxy, &pilot_model.PushRequest{Push: push, Start: time.Now()},
+			routeName, push.EnvoyFilters(proxy), nil)
+		rc := &route.RouteConfiguration{}
+		if err := resource.Resource.UnmarshalTo(rc); err != nil {
+			t.Fatal(err)
+		}
+		return rc, cached
+	}
+
+	routeNames := xdstest.ExtractRoutesFromListeners(cg.Listeners(proxy))
+	if len(routeNames) < 2 {
+		t.Fatalf("expected the servers to be sharded, got routes %v", routeNames)
+	}
+	shardOfHost := map[string]string{}
+	for _, routeName := range routeNames {
+		if !strings.HasPrefix(routeName, "https.443.https.gateway.default.shard-") {
+			t.Fatalf("unexpected route %s", routeName)
+		}
+		rc, _ := build(routeName)
+		for _, vh := range rc.VirtualHosts {
+			shardOfHost[vh.Domains[0]] = routeName
+		}
+		if shardServers := proxy.MergedGateway.ServersByRouteName[routeName]; len(shardServers) != len(rc.VirtualHosts) {
+			t.Fatalf("expected route %s to only have the virtual hosts of its shard, got %d", routeName, len(rc.VirtualHosts))
+		}
+	}
+	if len(shardOfHost) != len(hosts) {
+		t.Fatalf("expected a virtual host per host, got %v", shardOfHost)
+	}
+
+	// Updating a virtual service only invalidates the route configuration of the shard of its host.
+	cache.Clear(map[pilot_model.ConfigKey]struct{}{{Kind: gvk.VirtualService, Name: "vs-0", Namespace: "default"}: {}})
+	for _, routeName := range routeNames {
+		_, cached := build(routeName)
+		if want := routeName != shardOfHost["host-0.example.org"]; cached != want {
+			t.Errorf("expected route %s cached %v, got %v", routeName, want, cached)
+		}
+	}
+}
+
 func TestBuildGatewayListeners(t *testing.T) {
 	cases := []struct {
 		name              string
@@ -2139,6 +2342,100 @@ func TestBuildGatewayListeners(t *testing.T) {
 	}
 }
 
+func TestBuildGatewayUDPListeners(t *testing.T) {
+	gateway := config.Config{
+		Meta: config.Meta{Name: "gateway", Namespace: "testns", GroupVersionKind: gvk.Gateway},
+		Spec: &networking.Gateway{
+			Servers: []*networking.Server{
+				{
+					Port:  &networking.Port{Name: "dns", Number: 53, Protocol: "UDP"},
+					Hosts: []string{"dns.example.com"},
+				},
+				{
+					Port:  &networking.Port{Name: "syslog", Number: 514, Protocol: "UDP"},
+					Hosts: []string{"syslog.example.com"},
+				},
+			},
+		},
+	}
+	virtualService := config.Config{
+		Meta: config.Meta{Name: "dns", Namespace: "testns", GroupVersionKind: gvk.VirtualService},
+		Spec: &networking.VirtualService{
+			Gateways: []string{"testns/gateway"},
+			Hosts:    []string{"dns.example.com"},
+			Tcp: []*networking.TCPRoute{
+				{
+					Route: []*networking.RouteDestination{
+						{Destination: &networking.Destination{Host: "dns.com", Port: &networking.PortSelector{Number: 5353}}},
+					},
+				},
+			},
+		},
+	}
+	syslogVirtualService := config.Config{
+		Meta: config.Meta{Name: "syslog", Namespace: "testns", GroupVersionKind: gvk.VirtualService},
+		Spec: &networking.VirtualService{
+			Gateways: []string{"testns/gateway"},
+			Hosts:    []string{"syslog.example.com"},
+			Tcp: []*networking.TCPRoute{
+				{
+					Route: []*networking.RouteDestination{
+						{Destination: &networking.Destination{Host: "syslog-a.com", Port: &networking.PortSelector{Number: 514}}, Weight: 50},
+						{Destination: &networking.Destination{Host: "syslog-b.com", Port: &networking.PortSelector{Number: 514}}, Weight: 50},
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name              string
+		enableUDP         bool
+		expectedListeners []string
+	}{
+		{
+			// without UDP support, the servers fall back to the TCP proxy
+			name:              "disabled",
+			expectedListeners: []string{"0.0.0.0_53", "0.0.0.0_514"},
+		},
+		{
+			// the syslog route has several destinations, which the UDP proxy cannot weigh
+			name:              "enabled",
+			enableUDP:         true,
+			expectedListeners: []string{"udp_0.0.0.0_53"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultValue := features.EnableUDPProxy
+			features.EnableUDPProxy = tt.enableUDP
+			defer func() { features.EnableUDPProxy = defaultValue }()
+
+			cg := NewConfigGenTest(t, TestOptions{
+				Configs: []config.Config{gateway, virtualService, syslogVirtualService},
+			})
+			proxy := cg.SetupProxy(&proxyGateway)
+			proxy.Metadata = &proxyGatewayMetadata
+
+			builder := cg.ConfigGen.buildGatewayListeners(&ListenerBuilder{node: proxy, push: cg.PushContext()})
+			listeners := xdstest.ExtractListenerNames(builder.gatewayListeners)
+			if !reflect.DeepEqual(listeners, tt.expectedListeners) {
+				t.Fatalf("Expected listeners: %v, got: %v", tt.expectedListeners, listeners)
+			}
+			if !tt.enableUDP {
+				return
+			}
+			cfg := &udpproxy.UdpProxyConfig{}
+			if err := builder.gatewayListeners[0].ListenerFilters[0].GetTypedConfig().UnmarshalTo(cfg); err != nil {
+				t.Fatal(err)
+			}
+			if want := "outbound|5353||dns.com"; cfg.GetCluster() != want {
+				t.Fatalf("got cluster %q, want %q", cfg.GetCluster(), want)
+			}
+		})
+	}
+}
+
 func TestBuildNameToServiceMapForHttpRoutes(t *testing.T) {
 	virtualServiceSpec := &networking.VirtualService{
 		Hosts: []string{"*.example.org"},
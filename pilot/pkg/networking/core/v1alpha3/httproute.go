@@ -16,6 +16,7 @@ package v1alpha3
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
@@ -27,16 +28,22 @@ import (
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
 	istio_route "istio.io/istio/pilot/pkg/networking/core/v1alpha3/route"
 	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/security/authn/factory"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	"istio.io/istio/pilot/pkg/util/sets"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/proto"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+	"istio.io/pkg/log"
 )
 
 const (
@@ -44,27 +51,60 @@ const (
 	inboundVirtualHostPrefix = string(model.TrafficDirectionInbound) + "|http|"
 )
 
+// defaultHTTPRouteHeaders are the header operations applied to every outbound virtual host of sidecars.
+var defaultHTTPRouteHeaders = parseDefaultHTTPRouteHeaders(features.DefaultHTTPRouteHeaders)
+
+func parseDefaultHTTPRouteHeaders(value string) *networking.Headers {
+	if value == "" {
+		return nil
+	}
+	headers := &networking.Headers{}
+	if err := gogoprotomarshal.ApplyYAMLStrict(value, headers); err != nil {
+		log.Errorf("ignoring invalid PILOT_DEFAULT_HTTP_ROUTE_HEADERS: %v", err)
+		return nil
+	}
+	return headers
+}
+
 // BuildHTTPRoutes produces a list of routes for the proxy
 func (configgen *ConfigGeneratorImpl) BuildHTTPRoutes(
 	node *model.Proxy,
 	req *model.PushRequest,
 	routeNames []string) ([]*discovery.Resource, model.XdsLogDetails) {
-	routeConfigurations := make([]*discovery.Resource, 0)
+	routeConfigurations := make([]*discovery.Resource, 0, len(routeNames))
 
 	efw := req.Push.EnvoyFilters(node)
 	hit, miss := 0, 0
+	// Routes are built concurrently when configured to, but are stored by index so the output is deterministic.
+	workers := configGenerationWorkers(len(routeNames))
+	resources := make([]*discovery.Resource, len(routeNames))
+	cachedRoutes := make([]bool, len(routeNames))
 	switch node.Type {
 	case model.SidecarProxy:
-		vHostCache := make(map[int][]*route.VirtualHost)
+		// The virtual hosts cache is not safe for concurrent use, so each worker has its own.
+		vHostCaches := make([]map[int][]*route.VirtualHost, workers)
+		for w := range vHostCaches {
+			vHostCaches[w] = make(map[int][]*route.VirtualHost)
+		}
 		// dependent envoyfilters' key, calculate in front once to prevent calc for each route.
 		envoyfilterKeys := efw.Keys()
-		for _, routeName := range routeNames {
-			rc, cached := configgen.buildSidecarOutboundHTTPRouteConfig(node, req, routeName, vHostCache, efw, envoyfilterKeys)
-			if cached && !features.EnableUnsafeAssertions {
+		routeConflicts := make([][]domainConflict, len(routeNames))
+		buildInParallel(len(routeNames), workers, func(worker, i int) {
+			routeName := routeNames[i]
+			profileRoute(routeName, func() {
+				resources[i], cachedRoutes[i], routeConflicts[i] = configgen.buildSidecarOutboundHTTPRouteConfig(
+					node, req, routeName, vHostCaches[worker], efw, envoyfilterKeys)
+			})
+		})
+		var conflicts []domainConflict
+		for i, routeName := range routeNames {
+			conflicts = append(conflicts, routeConflicts[i]...)
+			if cachedRoutes[i] && !features.EnableUnsafeAssertions {
 				hit++
 			} else {
 				miss++
 			}
+			rc := resources[i]
 			if rc == nil {
 				emptyRoute := &route.RouteConfiguration{
 					Name:             routeName,
@@ -78,31 +118,173 @@ func (configgen *ConfigGeneratorImpl) BuildHTTPRoutes(
 			}
 			routeConfigurations = append(routeConfigurations, rc)
 		}
+		if node.FeatureEnabled(model.FeatureGateStrictVirtualHostDomains) && len(conflicts) > 0 {
+			rejectDuplicateDomains(node, req.Push, conflicts)
+			return nil, model.DefaultXdsLogDetails
+		}
 	case model.Router:
-		for _, routeName := range routeNames {
-			rc := configgen.buildGatewayHTTPRouteConfig(node, req.Push, routeName)
+		envoyfilterKeys := efw.Keys()
+		buildInParallel(len(routeNames), workers, func(_, i int) {
+			defer req.Profile.Time("gateway_routes")()
+			resources[i], cachedRoutes[i] = configgen.buildGatewayHTTPRouteConfig(node, req, routeNames[i], efw, envoyfilterKeys)
+		})
+		for i, rc := range resources {
+			if cachedRoutes[i] && !features.EnableUnsafeAssertions {
+				hit++
+			} else {
+				miss++
+			}
 			if rc != nil {
-				rc = envoyfilter.ApplyRouteConfigurationPatches(networking.EnvoyFilter_GATEWAY, node, efw, rc)
-				resource := &discovery.Resource{
-					Name:     routeName,
-					Resource: util.MessageToAny(rc),
-				}
-				routeConfigurations = append(routeConfigurations, resource)
+				routeConfigurations = append(routeConfigurations, rc)
 			}
 		}
 	}
 	if !features.EnableRDSCaching {
 		return routeConfigurations, model.DefaultXdsLogDetails
 	}
+	recordRDSCacheResults(node.Type, hit, miss)
 	return routeConfigurations, model.XdsLogDetails{AdditionalInfo: fmt.Sprintf("cached:%v/%v", hit, hit+miss)}
 }
 
+// deltaRouteConfigTypes are the config types for which sidecar routes are built as deltas.
+var deltaRouteConfigTypes = sets.NewSet(gvk.ServiceEntry.Kind, gvk.VirtualService.Kind)
+
+// BuildDeltaHTTPRoutes builds only the sidecar routes affected by the updated configs. Unchanged routes are
+// omitted, so they are left untouched by the delta xDS response. If delta generation cannot be used, all the
+// watched routes are built: for gateways, for updates to configs other than ServiceEntry and VirtualService, and for
+// VirtualServices whose routes cannot be found from their hosts, such as delegates and VirtualServices with wildcard
+// hosts. EnvoyFilters are not handled, as the routes their patches applied to in the previous push are not known.
+func (configgen *ConfigGeneratorImpl) BuildDeltaHTTPRoutes(
+	node *model.Proxy,
+	updates *model.PushRequest,
+	watched *model.WatchedResource) ([]*discovery.Resource, model.XdsLogDetails, bool) {
+	if node.Type != model.SidecarProxy || !shouldUseDeltaRoutes(updates) {
+		routes, logs := configgen.BuildHTTPRoutes(node, updates, watched.ResourceNames)
+		return routes, logs, false
+	}
+
+	// Collect the ports, both current and previous, of the updated services visible to the proxy, and of the
+	// services the updated VirtualServices route.
+	updatedHosts := sets.NewSet()
+	updatedPorts := make(map[int]struct{})
+	updatedVirtualServices := make(map[model.ConfigKey]struct{})
+	for key := range updates.ConfigsUpdated {
+		if key.Kind == gvk.VirtualService {
+			updatedVirtualServices[key] = struct{}{}
+		} else {
+			updatedHosts.Insert(key.Name)
+		}
+	}
+	if len(updatedVirtualServices) > 0 {
+		hosts, ok := virtualServiceHosts(node, updates.Push, updatedVirtualServices)
+		if !ok {
+			routes, logs := configgen.BuildHTTPRoutes(node, updates, watched.ResourceNames)
+			return routes, logs, false
+		}
+		updatedHosts = updatedHosts.Union(hosts)
+	}
+	for _, scope := range []*model.SidecarScope{node.SidecarScope, node.PrevSidecarScope} {
+		if scope == nil {
+			continue
+		}
+		for _, svc := range scope.Services() {
+			if !updatedHosts.Contains(string(svc.Hostname)) {
+				continue
+			}
+			for _, port := range svc.Ports {
+				updatedPorts[port.Port] = struct{}{}
+			}
+		}
+	}
+
+	routeNames := make([]string, 0, len(watched.ResourceNames))
+	for _, routeName := range watched.ResourceNames {
+		if routeAffected(routeName, updatedHosts, updatedPorts) {
+			routeNames = append(routeNames, routeName)
+		}
+	}
+	if len(routeNames) == 0 {
+		return nil, model.DefaultXdsLogDetails, true
+	}
+	routes, logs := configgen.BuildHTTPRoutes(node, updates, routeNames)
+	return routes, logs, true
+}
+
+// shouldUseDeltaRoutes returns true if the sidecar routes can be built as deltas for the updated configs.
+func shouldUseDeltaRoutes(updates *model.PushRequest) bool {
+	if updates == nil || len(updates.ConfigsUpdated) == 0 {
+		return false
+	}
+	for k := range updates.ConfigsUpdated {
+		if !deltaRouteConfigTypes.Contains(k.Kind.Kind) {
+			return false
+		}
+	}
+	return true
+}
+
+// virtualServiceHosts returns the hosts of the updated VirtualServices, in the current and previous sidecar scopes
+// of the proxy. It returns false if the routes affected by the updates cannot be found from these hosts: when one of
+// the hosts is not the hostname of a service of the scope, such as wildcard hosts, or when a VirtualService is a
+// delegate, which is merged into the VirtualServices delegating to it.
+func virtualServiceHosts(node *model.Proxy, push *model.PushContext, updated map[model.ConfigKey]struct{}) (sets.Set, bool) {
+	hosts := sets.NewSet()
+	for _, scope := range []*model.SidecarScope{node.SidecarScope, node.PrevSidecarScope} {
+		if scope == nil {
+			continue
+		}
+		services := sets.NewSet()
+		for _, svc := range scope.Services() {
+			services.Insert(string(svc.Hostname))
+		}
+		for _, listener := range scope.EgressListeners {
+			vses := listener.VirtualServices()
+			for _, delegate := range push.DelegateVirtualServicesConfigKey(vses) {
+				if _, f := updated[delegate]; f {
+					return nil, false
+				}
+			}
+			for _, vs := range vses {
+				if _, f := updated[model.ConfigKey{Kind: gvk.VirtualService, Name: vs.Name, Namespace: vs.Namespace}]; !f {
+					continue
+				}
+				for _, h := range vs.Spec.(*networking.VirtualService).Hosts {
+					if !services.Contains(h) {
+						return nil, false
+					}
+					hosts.Insert(h)
+				}
+			}
+		}
+	}
+	return hosts, true
+}
+
+// routeAffected returns whether a sidecar outbound route may be affected by updates to the given hosts and ports.
+// Route names are either a port ("80") or a hostname and port ("foo.default.svc.cluster.local:80") for sniffed
+// routes. Any other route is considered affected.
+func routeAffected(routeName string, updatedHosts sets.Set, updatedPorts map[int]struct{}) bool {
+	if port, err := strconv.Atoi(routeName); err == nil {
+		_, f := updatedPorts[port]
+		return f
+	}
+	hostname, portStr, err := net.SplitHostPort(routeName)
+	if err != nil {
+		return true
+	}
+	if _, err := strconv.Atoi(portStr); err != nil {
+		return true
+	}
+	return updatedHosts.Contains(hostname)
+}
+
 // buildSidecarInboundHTTPRouteConfig builds the route config with a single wildcard virtual host on the inbound path
-// TODO: trace decorators, inbound timeouts
+// TODO: inbound timeouts
 func (configgen *ConfigGeneratorImpl) buildSidecarInboundHTTPRouteConfig(
 	node *model.Proxy, push *model.PushContext, instance *model.ServiceInstance, clusterName string) *route.RouteConfiguration {
-	traceOperation := util.TraceOperation(string(instance.Service.Hostname), instance.ServicePort.Port)
+	traceOperation := inboundTraceOperation(node, push, instance)
 	defaultRoute := istio_route.BuildDefaultHTTPInboundRoute(clusterName, traceOperation)
+	istio_route.ApplyLocalRateLimit(defaultRoute, node.SidecarScope.InboundLocalRateLimit(int(instance.Endpoint.EndpointPort)))
 
 	inboundVHost := &route.VirtualHost{
 		Name:    inboundVirtualHostPrefix + strconv.Itoa(instance.ServicePort.Port), // Format: "inbound|http|%d"
@@ -115,13 +297,59 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundHTTPRouteConfig(
 		VirtualHosts:     []*route.VirtualHost{inboundVHost},
 		ValidateClusters: proto.BoolFalse,
 	}
+	applier := factory.NewPolicyApplier(push, node.Metadata.Namespace, labels.Collection{node.Metadata.Labels})
+	r.RequestHeadersToAdd, r.RequestHeadersToRemove = applier.IdentityHeaders()
 	efw := push.EnvoyFilters(node)
 	r = envoyfilter.ApplyRouteConfigurationPatches(networking.EnvoyFilter_SIDECAR_INBOUND, node, efw, r)
 	return r
 }
 
+// inboundTraceOperation returns the operation name of the spans of the inbound route of a service port: the one set
+// by the TraceOperationAnnotation of the pod or, failing that, of Telemetry, and <service>:<port>/* by default.
+func inboundTraceOperation(node *model.Proxy, push *model.PushContext, instance *model.ServiceInstance) string {
+	hostname := string(instance.Service.Hostname)
+	operation := node.Metadata.Annotations[constants.TraceOperationAnnotation]
+	if operation == "" {
+		operation = push.Telemetry.TraceOperation(node)
+	}
+	if operation == "" {
+		return util.TraceOperation(hostname, instance.ServicePort.Port)
+	}
+	return strings.NewReplacer("{service}", hostname, "{port}", strconv.Itoa(instance.ServicePort.Port)).Replace(operation)
+}
+
+// rejectDuplicateDomains reports the domain conflicts that prevent the routes of the sidecar from being pushed.
+func rejectDuplicateDomains(node *model.Proxy, push *model.PushContext, conflicts []domainConflict) {
+	msgs := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		msgs = append(msgs, c.String())
+	}
+	msg := strings.Join(msgs, "; ")
+	log.Warnf("not pushing routes to %s due to duplicate domains: %s", node.ID, msg)
+	push.AddMetric(model.RejectedDuplicatedDomains, node.ID, node.ID, msg)
+}
+
+// requestAttemptCount returns where the outbound virtual hosts of a sidecar report the attempt count: as set by the
+// RequestAttemptCountAnnotation of the pod or, failing that, of Telemetry, and on requests only by default.
+func requestAttemptCount(node *model.Proxy, push *model.PushContext) istionetworking.AttemptCount {
+	value := node.Metadata.Annotations[constants.RequestAttemptCountAnnotation]
+	if value == "" {
+		value = push.Telemetry.RequestAttemptCount(node)
+	}
+	if value == "" {
+		return istionetworking.DefaultAttemptCount
+	}
+	attemptCount, err := istionetworking.ParseAttemptCount(value)
+	if err != nil {
+		log.Warnf("ignoring invalid %s for %s: %v", constants.RequestAttemptCountAnnotation, node.ID, err)
+		return istionetworking.DefaultAttemptCount
+	}
+	return attemptCount
+}
+
 // buildSidecarOutboundHTTPRouteConfig builds an outbound HTTP Route for sidecar.
 // Based on port, will determine all virtual hosts that listen on the port.
+// The domains dropped from the virtual hosts because of duplicates are returned when the route is built.
 func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPRouteConfig(
 	node *model.Proxy,
 	req *model.PushRequest,
@@ -129,7 +357,9 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPRouteConfig(
 	vHostCache map[int][]*route.VirtualHost,
 	efw *model.EnvoyFilterWrapper,
 	efKeys []string,
-) (*discovery.Resource, bool) {
+) (*discovery.Resource, bool, []domainConflict) {
+	defer recordRDSBuildTime(routeConfigPhase, routeName)()
+
 	var virtualHosts []*route.VirtualHost
 	listenerPort := 0
 	useSniffing := false
@@ -153,7 +383,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPRouteConfig(
 			// user wants to ship a custom RDS. But at this point, the match semantics are murky. We have no
 			// object to match upon. This needs more thought. For now, we will continue to return nil for
 			// unknown routes
-			return nil, false
+			return nil, false, nil
 		}
 	}
 
@@ -170,10 +400,13 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPRouteConfig(
 			cacheHit = true
 		}
 	}
+	var conflicts []domainConflict
 	if !cacheHit {
-		virtualHosts, resource, routeCache = BuildSidecarOutboundVirtualHosts(node, req.Push, routeName, listenerPort, efKeys, configgen.Cache)
+		done := req.Profile.Time("virtual_hosts")
+		virtualHosts, resource, routeCache, conflicts = sharedSidecarOutboundVirtualHosts(node, req.Push, routeName, listenerPort, efKeys, configgen.Cache)
+		done()
 		if resource != nil {
-			return resource, true
+			return resource, true, nil
 		}
 		if useSniffing && listenerPort > 0 {
 			// only cache for tcp ports and not for uds
@@ -191,9 +424,12 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPRouteConfig(
 	util.SortVirtualHosts(virtualHosts)
 
 	if !useSniffing {
+		catchAll := node.CatchAllVirtualHost
+		if l := node.SidecarScope.GetEgressListenerForRDS(listenerPort, routeName); l != nil && l.CatchAll != nil {
+			catchAll = istionetworking.BuildCatchAllVirtualHostForAction(*l.CatchAll)
+		}
 		// virtualhost envoyfilter can mutate this sharing config.
-		catchAll := protobuf.Clone(node.CatchAllVirtualHost).(*route.VirtualHost)
-		virtualHosts = append(virtualHosts, catchAll)
+		virtualHosts = append(virtualHosts, protobuf.Clone(catchAll).(*route.VirtualHost))
 	}
 
 	out := &route.RouteConfiguration{
@@ -203,18 +439,68 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPRouteConfig(
 	}
 
 	// apply envoy filter patches
+	done := req.Profile.Time("route_patching")
 	out = envoyfilter.ApplyRouteConfigurationPatches(networking.EnvoyFilter_SIDECAR_OUTBOUND, node, efw, out)
+	done()
 
 	resource = &discovery.Resource{
 		Name:     out.Name,
 		Resource: util.MessageToAny(out),
 	}
 
-	if features.EnableRDSCaching && routeCache != nil {
+	// Routes with conflicts are not cached in strict mode, so that the conflicts are reported on every push.
+	if features.EnableRDSCaching && routeCache != nil && !(node.FeatureEnabled(model.FeatureGateStrictVirtualHostDomains) && len(conflicts) > 0) {
 		configgen.Cache.Add(routeCache, req, resource)
 	}
 
-	return resource, false
+	return resource, false, conflicts
+}
+
+// scopeCacheVirtualHosts is the resource type of the outbound virtual hosts in the scope cache.
+const scopeCacheVirtualHosts = "virtual_hosts"
+
+// virtualHostsEntry is the entry of the outbound virtual hosts of a route in the scope cache.
+type virtualHostsEntry struct {
+	virtualHosts []*route.VirtualHost
+	routeCache   *istio_route.Cache
+	conflicts    []domainConflict
+}
+
+// sharedSidecarOutboundVirtualHosts builds the outbound virtual hosts of the route of the proxy, or reuses the ones
+// built for another proxy of the same scope when the scope cache is enabled. As with the XdsCache, the duplicate
+// domain metrics are only recorded for the proxy the virtual hosts are built for.
+func sharedSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext, routeName string, listenerPort int,
+	efKeys []string, xdsCache model.XdsCache) ([]*route.VirtualHost, *discovery.Resource, *istio_route.Cache, []domainConflict) {
+	cache := push.ScopeCache()
+	if cache == nil {
+		return buildSidecarOutboundVirtualHosts(node, push, routeName, listenerPort, efKeys, xdsCache)
+	}
+	// The virtual hosts are cloned, as they are patched by EnvoyFilters afterwards.
+	key := model.NewScopeCacheKey(node, routeName, node.DNSDomain, strings.Join(efKeys, ","))
+	if cached, f := cache.Get(scopeCacheVirtualHosts, key); f {
+		entry := cached.(virtualHostsEntry)
+		if resource, exist := xdsCache.Get(entry.routeCache); exist && !features.EnableUnsafeAssertions {
+			return nil, resource, entry.routeCache, nil
+		}
+		return cloneVirtualHosts(entry.virtualHosts), nil, entry.routeCache, entry.conflicts
+	}
+	virtualHosts, resource, routeCache, conflicts := buildSidecarOutboundVirtualHosts(node, push, routeName, listenerPort, efKeys, xdsCache)
+	if resource == nil {
+		cache.Add(scopeCacheVirtualHosts, key, virtualHostsEntry{
+			virtualHosts: cloneVirtualHosts(virtualHosts),
+			routeCache:   routeCache,
+			conflicts:    conflicts,
+		})
+	}
+	return virtualHosts, resource, routeCache, conflicts
+}
+
+func cloneVirtualHosts(virtualHosts []*route.VirtualHost) []*route.VirtualHost {
+	out := make([]*route.VirtualHost, 0, len(virtualHosts))
+	for _, vh := range virtualHosts {
+		out = append(out, protobuf.Clone(vh).(*route.VirtualHost))
+	}
+	return out
 }
 
 func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext,
@@ -222,6 +508,47 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 	listenerPort int,
 	efKeys []string,
 	xdsCache model.XdsCache) ([]*route.VirtualHost, *discovery.Resource, *istio_route.Cache) {
+	vhosts, resource, routeCache, _ := buildSidecarOutboundVirtualHosts(node, push, routeName, listenerPort, efKeys, xdsCache)
+	return vhosts, resource, routeCache
+}
+
+// domainConflict is a virtual host name or domain that is claimed by more than one Service or VirtualService.
+type domainConflict struct {
+	domain string
+	// owner is the source the domain was kept for, and other the one it was dropped from.
+	owner, other string
+}
+
+func (c domainConflict) String() string {
+	return fmt.Sprintf("domain %s of %s conflicts with %s", c.domain, c.other, c.owner)
+}
+
+// virtualHostNamespace returns the namespace whose default CORS policy applies to the virtual host: the one of its
+// service or, for the hosts of a VirtualService without a service, of the VirtualService.
+func virtualHostNamespace(vhwrapper istio_route.VirtualHostWrapper, svc *model.Service) string {
+	if svc != nil {
+		return svc.Attributes.Namespace
+	}
+	return strings.SplitN(vhwrapper.VirtualService, "/", 2)[0]
+}
+
+// virtualHostSource describes the config that defines a virtual host, for conflict reports.
+func virtualHostSource(vhwrapper istio_route.VirtualHostWrapper, svc *model.Service) string {
+	if vhwrapper.VirtualService != "" {
+		return "VirtualService " + vhwrapper.VirtualService
+	}
+	return "Service " + string(svc.Hostname)
+}
+
+// buildSidecarOutboundVirtualHosts is BuildSidecarOutboundVirtualHosts, which also returns the domains that were
+// dropped because another virtual host of the route already has them. Conflicts are not computed for cached routes.
+func buildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext,
+	routeName string,
+	listenerPort int,
+	efKeys []string,
+	xdsCache model.XdsCache) ([]*route.VirtualHost, *discovery.Resource, *istio_route.Cache, []domainConflict) {
+	defer recordRDSBuildTime(virtualHostsPhase, routeName)()
+
 	var virtualServices []config.Config
 	var services []*model.Service
 
@@ -234,7 +561,7 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 	// We should never be getting a nil egress listener because the code that setup this RDS
 	// call obviously saw an egress listener
 	if egressListener == nil {
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
 
 	services = egressListener.Services()
@@ -251,7 +578,14 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 
 	servicesByName := make(map[host.Name]*model.Service)
 	hostsByNamespace := make(map[string][]host.Name)
+	var serviceSources map[host.Name]string
+	if node.FeatureEnabled(model.FeatureGateVirtualHostSourceMetadata) {
+		serviceSources = make(map[host.Name]string, len(services))
+	}
 	for _, svc := range services {
+		if serviceSources != nil {
+			serviceSources[svc.Hostname] = serviceRegistrySource(svc)
+		}
 		if listenerPort == 0 {
 			// Take all ports when listen port is 0 (http_proxy or uds)
 			// Expect virtualServices to resolve to right port
@@ -267,18 +601,36 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 				Attributes: model.ServiceAttributes{
 					Namespace:       svc.Attributes.Namespace,
 					ServiceRegistry: svc.Attributes.ServiceRegistry,
+					HTTP3:           svc.Attributes.HTTP3,
 				},
 			}
 			hostsByNamespace[svc.Attributes.Namespace] = append(hostsByNamespace[svc.Attributes.Namespace], svc.Hostname)
 		}
 	}
 
+	// In whitebox mode, the requests to the local port of a static mapping are routed to its upstream service.
+	whiteboxUpstream, whiteboxDomains := whiteboxLocalDomains(node, egressListener, listenerPort, servicesByName)
+
 	// This is hack to keep consistent with previous behavior.
 	if listenerPort != 80 {
 		// only select virtualServices that matches a service
 		virtualServices = model.SelectVirtualServices(virtualServices, hostsByNamespace)
 	}
 
+	// HTTP/3 is only advertised when the sidecar has a QUIC listener for the port, see buildSidecarOutboundHTTP3Listener.
+	http3 := features.EnableQUICListeners && listenerPort > 0 && egressBindsToPort(node, egressListener)
+	advertisesHTTP3 := func(svc *model.Service, port int) bool {
+		if !http3 || svc == nil || !svc.Attributes.HTTP3 {
+			return false
+		}
+		if egressListener.IstioListener != nil && egressListener.IstioListener.Port != nil {
+			return protocol.Parse(egressListener.IstioListener.Port.Protocol).IsHTTP()
+		}
+		p, f := svc.Ports.GetByPort(port)
+		return f && p.Protocol.IsHTTP()
+	}
+
+	attemptCount := requestAttemptCount(node, push)
 	var routeCache *istio_route.Cache
 
 	if listenerPort > 0 {
@@ -298,12 +650,21 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 			DNSDomain:               node.DNSDomain,
 			DNSCapture:              bool(node.Metadata.DNSCapture),
 			DNSAutoAllocate:         bool(node.Metadata.DNSAutoAllocate),
+			DisableAltVirtualHosts:  bool(node.Metadata.DisableAltVirtualHosts),
+			FeatureGates:            node.FeatureGatesKey(),
 			ListenerPort:            listenerPort,
 			Services:                services,
 			VirtualServices:         virtualServices,
 			DelegateVirtualServices: push.DelegateVirtualServicesConfigKey(virtualServices),
 			EnvoyFilterKeys:         efKeys,
 		}
+		if egressListener.CatchAll != nil {
+			routeCache.CatchAll = egressListener.CatchAll.String()
+		}
+		routeCache.PassthroughWildcardNamespaces = node.SidecarScope.PassthroughWildcardNamespaces()
+		routeCache.HTTP3 = http3
+		routeCache.AttemptCount = attemptCount
+		routeCache.LocalDomains = whiteboxDomains
 	}
 
 	// Get list of virtual services bound to the mesh gateway
@@ -311,17 +672,25 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 
 	resource, exist := xdsCache.Get(routeCache)
 	if exist && !features.EnableUnsafeAssertions {
-		return nil, resource, routeCache
+		return nil, resource, routeCache, nil
 	}
 
 	vHostPortMap := make(map[int][]*route.VirtualHost)
 	vhosts := sets.Set{}
 	vhdomains := sets.Set{}
 	knownFQDN := sets.Set{}
+	// vhostOwners and domainOwners are the sources of the virtual host names and domains, to report conflicts.
+	vhostOwners := map[string]string{}
+	domainOwners := map[string]string{}
+	var conflicts []domainConflict
 
 	buildVirtualHost := func(hostname string, vhwrapper istio_route.VirtualHostWrapper, svc *model.Service) *route.VirtualHost {
 		name := util.DomainName(hostname, vhwrapper.Port)
+		source := virtualHostSource(vhwrapper, svc)
 		if duplicateVirtualHost(name, vhosts) {
+			if vhostOwners[name] != source {
+				conflicts = append(conflicts, domainConflict{domain: name, owner: vhostOwners[name], other: source})
+			}
 			// This means this virtual host has caused duplicate virtual host name.
 			var msg string
 			if svc == nil {
@@ -332,15 +701,27 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 			push.AddMetric(model.DuplicatedDomains, name, node.ID, msg)
 			return nil
 		}
+		vhostOwners[name] = source
 		var domains []string
 		var altHosts []string
 		if svc == nil {
 			domains = []string{util.IPv6Compliant(hostname), name}
 		} else {
 			domains, altHosts = generateVirtualHostDomains(svc, vhwrapper.Port, node)
+			if svc.Hostname == whiteboxUpstream {
+				domains = append(domains, whiteboxDomains...)
+			}
 		}
 		dl := len(domains)
+		for _, d := range domains {
+			if vhdomains.Contains(d) && domainOwners[d] != source {
+				conflicts = append(conflicts, domainConflict{domain: d, owner: domainOwners[d], other: source})
+			}
+		}
 		domains = dedupeDomains(domains, vhdomains, altHosts, knownFQDN)
+		for _, d := range domains {
+			domainOwners[d] = source
+		}
 		if dl != len(domains) {
 			var msg string
 			if svc == nil {
@@ -352,12 +733,23 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 			push.AddMetric(model.DuplicatedDomains, name, node.ID, msg)
 		}
 		if len(domains) > 0 {
-			return &route.VirtualHost{
-				Name:                       name,
-				Domains:                    domains,
-				Routes:                     vhwrapper.Routes,
-				IncludeRequestAttemptCount: true,
+			vh := &route.VirtualHost{
+				Name:                          name,
+				Domains:                       domains,
+				Routes:                        vhwrapper.Routes,
+				IncludeRequestAttemptCount:    attemptCount.Request,
+				IncludeAttemptCountInResponse: attemptCount.Response,
 			}
+			if svc != nil && serviceSources != nil {
+				vh.Routes = withServiceSource(vh.Routes, serviceSources[svc.Hostname])
+			}
+			istio_route.ApplyDefaultCORSPolicy(vh, push, virtualHostNamespace(vhwrapper, svc))
+			istio_route.ApplyVirtualHostHeaders(vh, defaultHTTPRouteHeaders)
+			if advertisesHTTP3(svc, vhwrapper.Port) {
+				vh.ResponseHeadersToAdd = append(vh.ResponseHeadersToAdd,
+					istio_route.BuildHTTP3AltSvcHeader(vhwrapper.Port, util.ALPNHttp3OverQUIC))
+			}
+			return vh
 		}
 
 		return nil
@@ -398,7 +790,34 @@ func BuildSidecarOutboundVirtualHosts(node *model.Proxy, push *model.PushContext
 		out = vHostPortMap[listenerPort]
 	}
 
-	return out, nil, routeCache
+	return out, nil, routeCache, conflicts
+}
+
+// serviceRegistrySource describes the registry and the clusters a service was discovered from,
+// for example Kubernetes/cluster1,cluster2.
+func serviceRegistrySource(svc *model.Service) string {
+	addresses := svc.ClusterVIPs.GetAddresses()
+	clusters := make([]string, 0, len(addresses))
+	for c := range addresses {
+		clusters = append(clusters, c.String())
+	}
+	if len(clusters) == 0 {
+		return string(svc.Attributes.ServiceRegistry)
+	}
+	sort.Strings(clusters)
+	return string(svc.Attributes.ServiceRegistry) + "/" + strings.Join(clusters, ",")
+}
+
+// withServiceSource returns copies of the routes with the service source in their metadata. The routes
+// are copied because they are shared by all the virtual hosts of a VirtualService.
+func withServiceSource(routes []*route.Route, source string) []*route.Route {
+	out := make([]*route.Route, 0, len(routes))
+	for _, r := range routes {
+		r = protobuf.Clone(r).(*route.Route)
+		r.Metadata = util.AddServiceSourceMetadata(r.Metadata, source)
+		out = append(out, r)
+	}
+	return out
 }
 
 // duplicateVirtualHost checks whether the virtual host with the same name exists in the route.
@@ -444,6 +863,12 @@ func getVirtualHostsForSniffedServicePort(vhosts []*route.VirtualHost, routeName
 			}
 		}
 	}
+	if len(virtualHosts) == 0 {
+		// Fall back to wildcard hosts, as Envoy does when matching the Host header on explicit HTTP listeners.
+		if vh := getWildcardVirtualHostForSniffedServicePort(vhosts, routeName); vh != nil {
+			virtualHosts = append(virtualHosts, vh)
+		}
+	}
 
 	if len(virtualHosts) == 0 {
 		return virtualHosts
@@ -458,15 +883,40 @@ func getVirtualHostsForSniffedServicePort(vhosts []*route.VirtualHost, routeName
 	return virtualHosts
 }
 
+// getWildcardVirtualHostForSniffedServicePort returns the virtual host with the longest suffix wildcard domain,
+// such as *.bar.com:8080, matching the route name, or nil if none does.
+func getWildcardVirtualHostForSniffedServicePort(vhosts []*route.VirtualHost, routeName string) *route.VirtualHost {
+	var match *route.VirtualHost
+	longest := 0
+	for _, vh := range vhosts {
+		for _, domain := range vh.Domains {
+			if !strings.HasPrefix(domain, wildcardDomainPrefix) || len(domain) <= longest {
+				continue
+			}
+			// The wildcard must match at least one character, so *.bar.com does not match .bar.com.
+			suffix := domain[1:]
+			if len(routeName) > len(suffix) && strings.HasSuffix(routeName, suffix) {
+				match = vh
+				longest = len(domain)
+			}
+		}
+	}
+	return match
+}
+
 // generateVirtualHostDomains generates the set of domain matches for a service being accessed from
-// a proxy node
+// a proxy node. If the proxy disabled alt virtual hosts, only the FQDN and IP domains are generated.
 func generateVirtualHostDomains(service *model.Service, port int, node *model.Proxy) ([]string, []string) {
-	altHosts := GenerateAltVirtualHosts(string(service.Hostname), port, node.DNSDomain)
+	var altHosts []string
+	if node.Metadata == nil || !node.Metadata.DisableAltVirtualHosts {
+		altHosts = GenerateAltVirtualHosts(string(service.Hostname), port, node.DNSDomain)
+	}
 	domains := []string{util.IPv6Compliant(string(service.Hostname)), util.DomainName(string(service.Hostname), port)}
 	domains = append(domains, altHosts...)
 
 	if service.Resolution == model.Passthrough &&
-		service.Attributes.ServiceRegistry == provider.Kubernetes {
+		service.Attributes.ServiceRegistry == provider.Kubernetes &&
+		node.SidecarScope.PassthroughWildcardAllowed(service.Attributes.Namespace) {
 		for _, domain := range domains {
 			domains = append(domains, wildcardDomainPrefix+domain)
 		}
@@ -17,6 +17,7 @@ package v1alpha3
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -24,11 +25,16 @@ import (
 
 	meshapi "istio.io/api/mesh/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
+	tpb "istio.io/api/telemetry/v1alpha1"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/collections"
@@ -36,6 +42,57 @@ import (
 	"istio.io/istio/pkg/config/visibility"
 )
 
+func TestGenerateVirtualHostDomainsPassthroughWildcardNamespaces(t *testing.T) {
+	headless := func(namespace string) *model.Service {
+		return &model.Service{
+			Hostname:   host.Name("echo." + namespace + ".svc.cluster.local"),
+			Resolution: model.Passthrough,
+			Attributes: model.ServiceAttributes{Namespace: namespace, ServiceRegistry: provider.Kubernetes},
+		}
+	}
+	ps := model.NewPushContext()
+	ps.Mesh = &meshapi.MeshConfig{RootNamespace: "istio-system"}
+	sidecarScope := func(value string) *model.SidecarScope {
+		return model.ConvertToSidecarScope(ps, &config.Config{
+			Meta: config.Meta{
+				Name:        "sidecar",
+				Namespace:   "default",
+				Annotations: map[string]string{constants.PassthroughWildcardNamespacesAnnotation: value},
+			},
+			Spec: &networking.Sidecar{},
+		}, "default")
+	}
+	cases := []struct {
+		name         string
+		service      *model.Service
+		sidecarScope *model.SidecarScope
+		wildcard     bool
+	}{
+		{name: "no sidecar", service: headless("other"), wildcard: true},
+		{name: "own namespace", service: headless("default"), sidecarScope: sidecarScope("."), wildcard: true},
+		{name: "other namespace", service: headless("other"), sidecarScope: sidecarScope("."), wildcard: false},
+		{name: "listed namespace", service: headless("other"), sidecarScope: sidecarScope(".,other"), wildcard: true},
+		{name: "no namespaces", service: headless("default"), sidecarScope: sidecarScope(""), wildcard: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			node := &model.Proxy{
+				DNSDomain:    "default.svc.cluster.local",
+				Metadata:     &model.NodeMetadata{DisableAltVirtualHosts: true},
+				SidecarScope: c.sidecarScope,
+			}
+			out, _ := generateVirtualHostDomains(c.service, 80, node)
+			wildcard := false
+			for _, domain := range out {
+				wildcard = wildcard || strings.HasPrefix(domain, wildcardDomainPrefix)
+			}
+			if wildcard != c.wildcard {
+				t.Fatalf("expected wildcard domains %v, got %v", c.wildcard, out)
+			}
+		})
+	}
+}
+
 func TestGenerateVirtualHostDomains(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -214,6 +271,25 @@ func TestGenerateVirtualHostDomains(t *testing.T) {
 			},
 			want: []string{"aaa.example.com", "aaa.example.com:7777"},
 		},
+		{
+			name: "k8s service with alt virtual hosts disabled",
+			service: &model.Service{
+				Hostname:       "echo.default.svc.cluster.local",
+				MeshExternal:   false,
+				DefaultAddress: "10.0.0.1",
+			},
+			port: 8123,
+			node: &model.Proxy{
+				DNSDomain: "default.svc.cluster.local",
+				Metadata:  &model.NodeMetadata{DisableAltVirtualHosts: true},
+			},
+			want: []string{
+				"echo.default.svc.cluster.local",
+				"echo.default.svc.cluster.local:8123",
+				"10.0.0.1",
+				"10.0.0.1:8123",
+			},
+		},
 	}
 
 	testFn := func(service *model.Service, port int, node *model.Proxy, want []string) error {
@@ -234,6 +310,238 @@ func TestGenerateVirtualHostDomains(t *testing.T) {
 	}
 }
 
+func TestGetVirtualHostsForSniffedServicePort(t *testing.T) {
+	vhosts := []*route.VirtualHost{
+		{Name: "foo.bar.com:8080", Domains: []string{"foo.bar.com", "foo.bar.com:8080"}},
+		{Name: "*.bar.com:8080", Domains: []string{"*.bar.com", "*.bar.com:8080"}},
+		{Name: "*.baz.bar.com:8080", Domains: []string{"*.baz.bar.com", "*.baz.bar.com:8080"}},
+	}
+	cases := []struct {
+		name      string
+		routeName string
+		want      string
+	}{
+		{name: "exact", routeName: "foo.bar.com:8080", want: "foo.bar.com:8080"},
+		{name: "wildcard", routeName: "other.bar.com:8080", want: "*.bar.com:8080"},
+		{name: "longest wildcard", routeName: "a.baz.bar.com:8080", want: "*.baz.bar.com:8080"},
+		{name: "wildcard needs a label", routeName: ".bar.com:8080"},
+		{name: "different port", routeName: "other.bar.com:9090"},
+		{name: "no match", routeName: "foo.example.com:8080"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make([]*route.VirtualHost, 0, len(vhosts))
+			for _, vh := range vhosts {
+				in = append(in, &route.VirtualHost{Name: vh.Name, Domains: vh.Domains})
+			}
+			got := getVirtualHostsForSniffedServicePort(in, tt.routeName)
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Fatalf("expected no virtual hosts, got %v", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].Name != tt.want {
+				t.Fatalf("expected virtual host %v, got %v", tt.want, got)
+			}
+			if !reflect.DeepEqual(got[0].Domains, []string{"*"}) {
+				t.Fatalf("expected domains to be rewritten to *, got %v", got[0].Domains)
+			}
+		})
+	}
+}
+
+func TestBuildDeltaHTTPRoutes(t *testing.T) {
+	newService := func(hostname string, port int) *model.Service {
+		return &model.Service{
+			Hostname:   host.Name(hostname),
+			Ports:      []*model.Port{{Name: "http", Port: port, Protocol: protocol.HTTP}},
+			Resolution: model.ClientSideLB,
+			Attributes: model.ServiceAttributes{Namespace: "default"},
+		}
+	}
+	newVirtualService := func(name string, hosts ...string) config.Config {
+		return config.Config{
+			Meta: config.Meta{GroupVersionKind: gvk.VirtualService, Name: name, Namespace: "default"},
+			Spec: &networking.VirtualService{
+				Hosts: hosts,
+				Http: []*networking.HTTPRoute{{
+					Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "other.com"}}},
+				}},
+			},
+		}
+	}
+	services := []*model.Service{newService("test.com", 8080), newService("other.com", 9090)}
+	virtualServices := []config.Config{newVirtualService("test", "test.com"), newVirtualService("wildcard", "*.com")}
+	watched := &model.WatchedResource{ResourceNames: []string{"8080", "9090", "test.com:8080", "other.com:9090"}}
+
+	cases := []struct {
+		name          string
+		configUpdated map[model.ConfigKey]struct{}
+		usedDelta     bool
+		want          []string
+	}{
+		{
+			name:          "service updated",
+			configUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.ServiceEntry, Name: "test.com", Namespace: "default"}: {}},
+			usedDelta:     true,
+			want:          []string{"8080", "test.com:8080"},
+		},
+		{
+			name:          "service not visible",
+			configUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.ServiceEntry, Name: "unknown.com", Namespace: "default"}: {}},
+			usedDelta:     true,
+			want:          []string{},
+		},
+		{
+			// the routes of the hosts of the VirtualService are affected, not the ones of its destinations.
+			name:          "virtual service updated",
+			configUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.VirtualService, Name: "test", Namespace: "default"}: {}},
+			usedDelta:     true,
+			want:          []string{"8080", "test.com:8080"},
+		},
+		{
+			name:          "virtual service not visible",
+			configUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.VirtualService, Name: "unknown", Namespace: "default"}: {}},
+			usedDelta:     true,
+			want:          []string{},
+		},
+		{
+			name:          "virtual service with wildcard hosts",
+			configUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.VirtualService, Name: "wildcard", Namespace: "default"}: {}},
+			usedDelta:     false,
+			want:          []string{"8080", "9090", "test.com:8080", "other.com:9090"},
+		},
+		{
+			name:          "config update that is not delta aware",
+			configUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.EnvoyFilter, Name: "test", Namespace: "default"}: {}},
+			usedDelta:     false,
+			want:          []string{"8080", "9090", "test.com:8080", "other.com:9090"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cg := NewConfigGenTest(t, TestOptions{Services: services, Configs: virtualServices})
+			routes, _, usedDelta := cg.ConfigGen.BuildDeltaHTTPRoutes(cg.SetupProxy(nil),
+				&model.PushRequest{Push: cg.PushContext(), Full: true, ConfigsUpdated: tt.configUpdated}, watched)
+			if usedDelta != tt.usedDelta {
+				t.Fatalf("expected delta %v, got %v", tt.usedDelta, usedDelta)
+			}
+			got := make([]string, 0, len(routes))
+			for _, r := range routes {
+				got = append(got, r.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected routes %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInboundTraceOperation(t *testing.T) {
+	instance := &model.ServiceInstance{
+		Service:     &model.Service{Hostname: "reviews.default.svc.cluster.local"},
+		ServicePort: &model.Port{Port: 9080},
+	}
+	telemetry := func(namespace, operation string) model.Telemetry {
+		return model.Telemetry{Name: "default", Namespace: namespace, Spec: &tpb.Telemetry{}, TraceOperation: operation}
+	}
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		telemetries *model.Telemetries
+		want        string
+	}{
+		{
+			name: "default",
+			want: "reviews.default.svc.cluster.local:9080/*",
+		},
+		{
+			name:        "pod annotation",
+			annotations: map[string]string{constants.TraceOperationAnnotation: "reviews-{port}"},
+			want:        "reviews-9080",
+		},
+		{
+			name: "namespace telemetry wins over mesh",
+			telemetries: &model.Telemetries{
+				RootNamespace: "istio-system",
+				NamespaceToTelemetries: map[string][]model.Telemetry{
+					"istio-system": {telemetry("istio-system", "mesh")},
+					"default":      {telemetry("default", "inbound {service}")},
+				},
+			},
+			want: "inbound reviews.default.svc.cluster.local",
+		},
+		{
+			name:        "pod annotation wins over telemetry",
+			annotations: map[string]string{constants.TraceOperationAnnotation: "pod"},
+			telemetries: &model.Telemetries{
+				RootNamespace: "istio-system",
+				NamespaceToTelemetries: map[string][]model.Telemetry{
+					"istio-system": {telemetry("istio-system", "mesh")},
+				},
+			},
+			want: "pod",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &model.Proxy{ConfigNamespace: "default", Metadata: &model.NodeMetadata{Annotations: tt.annotations}}
+			push := &model.PushContext{Telemetry: tt.telemetries}
+			if got := inboundTraceOperation(node, push, instance); got != tt.want {
+				t.Fatalf("got operation %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestAttemptCount(t *testing.T) {
+	telemetry := func(namespace, attemptCount string) model.Telemetry {
+		return model.Telemetry{Name: "default", Namespace: namespace, Spec: &tpb.Telemetry{}, RequestAttemptCount: attemptCount}
+	}
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		telemetries *model.Telemetries
+		want        istionetworking.AttemptCount
+	}{
+		{
+			name: "default",
+			want: istionetworking.DefaultAttemptCount,
+		},
+		{
+			name:        "pod annotation",
+			annotations: map[string]string{constants.RequestAttemptCountAnnotation: "request,response"},
+			want:        istionetworking.AttemptCount{Request: true, Response: true},
+		},
+		{
+			name: "namespace telemetry wins over mesh",
+			telemetries: &model.Telemetries{
+				RootNamespace: "istio-system",
+				NamespaceToTelemetries: map[string][]model.Telemetry{
+					"istio-system": {telemetry("istio-system", "none")},
+					"default":      {telemetry("default", "response")},
+				},
+			},
+			want: istionetworking.AttemptCount{Response: true},
+		},
+		{
+			name:        "invalid pod annotation",
+			annotations: map[string]string{constants.RequestAttemptCountAnnotation: "upstream"},
+			want:        istionetworking.DefaultAttemptCount,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &model.Proxy{ConfigNamespace: "default", Metadata: &model.NodeMetadata{Annotations: tt.annotations}}
+			push := &model.PushContext{Telemetry: tt.telemetries}
+			if got := requestAttemptCount(node, push); got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSidecarOutboundHTTPRouteConfigWithDuplicateHosts(t *testing.T) {
 	virtualServiceSpec := &networking.VirtualService{
 		Hosts:    []string{"test-duplicate-domains.default.svc.cluster.local", "test-duplicate-domains.default"},
@@ -419,7 +727,7 @@ func TestSidecarOutboundHTTPRouteConfigWithDuplicateHosts(t *testing.T) {
 
 			vHostCache := make(map[int][]*route.VirtualHost)
 			routeName := "80"
-			resource, _ := cg.ConfigGen.buildSidecarOutboundHTTPRouteConfig(
+			resource, _, _ := cg.ConfigGen.buildSidecarOutboundHTTPRouteConfig(
 				cg.SetupProxy(nil), &model.PushRequest{Push: cg.PushContext()}, "80", vHostCache, nil, nil)
 			routeCfg := &route.RouteConfiguration{}
 			resource.Resource.UnmarshalTo(routeCfg)
@@ -446,6 +754,51 @@ func TestSidecarOutboundHTTPRouteConfigWithDuplicateHosts(t *testing.T) {
 	}
 }
 
+func TestSidecarOutboundHTTPRouteConfigStrictDomains(t *testing.T) {
+	defaultValue := features.StrictVirtualHostDomains
+	defer func() { features.StrictVirtualHostDomains = defaultValue }()
+
+	vs := config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: gvk.VirtualService,
+			Name:             "acme",
+			Namespace:        "default",
+		},
+		Spec: &networking.VirtualService{
+			Hosts: []string{"test.default"},
+			Http: []*networking.HTTPRoute{{
+				Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "test.default"}}},
+			}},
+		},
+	}
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprint(strict), func(t *testing.T) {
+			features.StrictVirtualHostDomains = strict
+			cg := NewConfigGenTest(t, TestOptions{
+				Services: []*model.Service{buildHTTPService("test.default.svc.cluster.local", visibility.Public, "", "default", 80)},
+				Configs:  []config.Config{vs},
+			})
+			proxy := cg.SetupProxy(nil)
+			push := cg.PushContext()
+			routes, _ := cg.ConfigGen.BuildHTTPRoutes(proxy, &model.PushRequest{Push: push}, []string{"80"})
+			rejected := push.ProxyStatus[model.RejectedDuplicatedDomains.Name()][proxy.ID]
+			if !strict {
+				if len(routes) != 1 || rejected.Message != "" {
+					t.Fatalf("expected the routes to be pushed, got %v and %q", routes, rejected.Message)
+				}
+				return
+			}
+			if routes != nil {
+				t.Fatalf("expected no routes, got %v", routes)
+			}
+			want := "domain test.default of Service test.default.svc.cluster.local conflicts with VirtualService default/acme"
+			if !strings.Contains(rejected.Message, want) {
+				t.Fatalf("expected the conflict to be reported, got %q", rejected.Message)
+			}
+		})
+	}
+}
+
 func TestSidecarOutboundHTTPRouteConfig(t *testing.T) {
 	services := []*model.Service{
 		buildHTTPService("bookinfo.com", visibility.Public, wildcardIP, "default", 9999, 70),
@@ -1283,6 +1636,359 @@ func TestSidecarOutboundHTTPRouteConfig(t *testing.T) {
 	}
 }
 
+func TestSidecarOutboundHTTPRouteConfigCatchAll(t *testing.T) {
+	services := []*model.Service{
+		buildHTTPService("test.com", visibility.Public, "8.8.8.8", "not-default", 8080),
+	}
+	sidecarConfig := &config.Config{
+		Meta: config.Meta{
+			Name:      "foo",
+			Namespace: "not-default",
+			Annotations: map[string]string{
+				constants.EgressCatchAllAnnotation: "9000=blackhole:404,*=cluster:outbound|80||egress.example.com",
+			},
+		},
+		Spec: &networking.Sidecar{
+			Egress: []*networking.IstioEgressListener{
+				{
+					Port:  &networking.Port{Number: 9000, Protocol: "HTTP", Name: "something"},
+					Hosts: []string{"*/*"},
+				},
+				{
+					Port:  &networking.Port{Number: 9100, Protocol: "HTTP", Name: "other"},
+					Hosts: []string{"*/*"},
+				},
+				{
+					Hosts: []string{"*/*"},
+				},
+			},
+		},
+	}
+	cases := []struct {
+		routeName string
+		want      func(t *testing.T, vh *route.VirtualHost)
+	}{
+		{
+			routeName: "9000",
+			want: func(t *testing.T, vh *route.VirtualHost) {
+				if got := vh.Routes[0].GetDirectResponse().GetStatus(); got != 404 {
+					t.Fatalf("expected direct response 404, got %v", vh)
+				}
+			},
+		},
+		{
+			routeName: "9100",
+			want: func(t *testing.T, vh *route.VirtualHost) {
+				if got := vh.Routes[0].GetRoute().GetCluster(); got != "PassthroughCluster" {
+					t.Fatalf("expected the outbound traffic policy, got %v", vh)
+				}
+			},
+		},
+		{
+			routeName: "8080",
+			want: func(t *testing.T, vh *route.VirtualHost) {
+				if got := vh.Routes[0].GetRoute().GetCluster(); got != "outbound|80||egress.example.com" {
+					t.Fatalf("expected the egress cluster, got %v", vh)
+				}
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.routeName, func(t *testing.T) {
+			configgen := NewConfigGenerator([]plugin.Plugin{&fakePlugin{}}, &model.DisabledCache{})
+			env := buildListenerEnvWithAdditionalConfig(services, nil, nil)
+			if err := env.PushContext.InitContext(env, nil, nil); err != nil {
+				t.Fatalf("failed to initialize push context")
+			}
+			proxy := getProxy()
+			proxy.SidecarScope = model.ConvertToSidecarScope(env.PushContext, sidecarConfig, sidecarConfig.Namespace)
+			proxy.BuildCatchAllVirtualHost()
+
+			resource, _, _ := configgen.buildSidecarOutboundHTTPRouteConfig(proxy, &model.PushRequest{Push: env.PushContext},
+				tt.routeName, map[int][]*route.VirtualHost{}, nil, nil)
+			routeCfg := &route.RouteConfiguration{}
+			if err := resource.Resource.UnmarshalTo(routeCfg); err != nil {
+				t.Fatal(err)
+			}
+			tt.want(t, routeCfg.VirtualHosts[len(routeCfg.VirtualHosts)-1])
+		})
+	}
+}
+
+func TestSidecarOutboundHTTPRouteConfigWhitebox(t *testing.T) {
+	services := []*model.Service{
+		buildHTTPService("test.com", visibility.Public, "8.8.8.8", "not-default", 8080),
+		buildHTTPService("other.com", visibility.Public, "9.9.9.9", "not-default", 8080),
+	}
+	sidecarConfig := func(captureMode networking.CaptureMode, hosts ...string) *config.Config {
+		return &config.Config{
+			Meta: config.Meta{Name: "foo", Namespace: "not-default"},
+			Spec: &networking.Sidecar{
+				Egress: []*networking.IstioEgressListener{
+					{
+						Port:        &networking.Port{Number: 8080, Protocol: "HTTP", Name: "test"},
+						Hosts:       hosts,
+						CaptureMode: captureMode,
+					},
+				},
+			},
+		}
+	}
+	localDomains := []string{"127.0.0.1", "127.0.0.1:8080", "localhost", "localhost:8080"}
+	cases := []struct {
+		name    string
+		sidecar *config.Config
+		want    []string
+	}{
+		{
+			name:    "static mapping",
+			sidecar: sidecarConfig(networking.CaptureMode_NONE, "not-default/test.com"),
+			want:    localDomains,
+		},
+		{
+			name:    "intercepted",
+			sidecar: sidecarConfig(networking.CaptureMode_DEFAULT, "not-default/test.com"),
+		},
+		{
+			name:    "multiple services",
+			sidecar: sidecarConfig(networking.CaptureMode_NONE, "not-default/*"),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			configgen := NewConfigGenerator([]plugin.Plugin{&fakePlugin{}}, &model.DisabledCache{})
+			env := buildListenerEnvWithAdditionalConfig(services, nil, nil)
+			if err := env.PushContext.InitContext(env, nil, nil); err != nil {
+				t.Fatalf("failed to initialize push context")
+			}
+			proxy := getProxy()
+			proxy.SidecarScope = model.ConvertToSidecarScope(env.PushContext, tt.sidecar, tt.sidecar.Namespace)
+			proxy.BuildCatchAllVirtualHost()
+
+			resource, _, _ := configgen.buildSidecarOutboundHTTPRouteConfig(proxy, &model.PushRequest{Push: env.PushContext},
+				"8080", map[int][]*route.VirtualHost{}, nil, nil)
+			routeCfg := &route.RouteConfiguration{}
+			if err := resource.Resource.UnmarshalTo(routeCfg); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, vh := range routeCfg.VirtualHosts {
+				for _, d := range vh.Domains {
+					for _, local := range localDomains {
+						if d == local {
+							if vh.Name != "test.com:8080" {
+								t.Fatalf("expected local domain %s on test.com, got it on %s", d, vh.Name)
+							}
+							got = append(got, d)
+						}
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected local domains %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSidecarOutboundHTTPRouteConfigDefaultHeaders(t *testing.T) {
+	if parseDefaultHTTPRouteHeaders(`{"request": {"bogus": {}}}`) != nil {
+		t.Fatal("expected invalid headers to be ignored")
+	}
+	defer func(h *networking.Headers) { defaultHTTPRouteHeaders = h }(defaultHTTPRouteHeaders)
+	defaultHTTPRouteHeaders = parseDefaultHTTPRouteHeaders(`
+request:
+  set:
+    x-mesh-source: istio
+response:
+  remove:
+  - x-internal
+`)
+
+	services := []*model.Service{
+		buildHTTPService("test.com", visibility.Public, "8.8.8.8", "not-default", 8080),
+	}
+	configgen := NewConfigGenerator([]plugin.Plugin{&fakePlugin{}}, &model.DisabledCache{})
+	env := buildListenerEnvWithAdditionalConfig(services, nil, nil)
+	if err := env.PushContext.InitContext(env, nil, nil); err != nil {
+		t.Fatalf("failed to initialize push context")
+	}
+	proxy := getProxy()
+	proxy.SidecarScope = model.DefaultSidecarScopeForNamespace(env.PushContext, "not-default")
+	proxy.BuildCatchAllVirtualHost()
+
+	resource, _, _ := configgen.buildSidecarOutboundHTTPRouteConfig(proxy, &model.PushRequest{Push: env.PushContext},
+		"8080", map[int][]*route.VirtualHost{}, nil, nil)
+	routeCfg := &route.RouteConfiguration{}
+	if err := resource.Resource.UnmarshalTo(routeCfg); err != nil {
+		t.Fatal(err)
+	}
+	checked := 0
+	for _, vh := range routeCfg.VirtualHosts {
+		if vh.Name == util.Passthrough || vh.Name == util.BlackHole {
+			continue
+		}
+		checked++
+		if len(vh.RequestHeadersToAdd) != 1 || vh.RequestHeadersToAdd[0].Header.Key != "x-mesh-source" ||
+			vh.RequestHeadersToAdd[0].Header.Value != "istio" {
+			t.Errorf("expected x-mesh-source to be added to %s, got %v", vh.Name, vh.RequestHeadersToAdd)
+		}
+		if !reflect.DeepEqual(vh.ResponseHeadersToRemove, []string{"x-internal"}) {
+			t.Errorf("expected x-internal to be removed from %s, got %v", vh.Name, vh.ResponseHeadersToRemove)
+		}
+	}
+	if checked == 0 {
+		t.Fatal("expected virtual hosts for the services")
+	}
+}
+
+// fakeCORSPolicies provides the default CORS policies of a fixed set of namespaces.
+type fakeCORSPolicies map[string]*networking.CorsPolicy
+
+func (f fakeCORSPolicies) DefaultCORSPolicies() map[string]*networking.CorsPolicy {
+	return f
+}
+
+func TestSidecarOutboundHTTPRouteConfigDefaultCORSPolicy(t *testing.T) {
+	services := []*model.Service{
+		buildHTTPService("test.com", visibility.Public, "8.8.8.8", "not-default", 8080),
+		buildHTTPService("frontend.com", visibility.Public, "8.8.4.4", "frontend", 8080),
+	}
+	redirect := &config.Config{
+		Meta: config.Meta{GroupVersionKind: gvk.VirtualService, Name: "redirect", Namespace: "frontend"},
+		Spec: &networking.VirtualService{
+			Hosts: []string{"redirect.example.com"},
+			Http: []*networking.HTTPRoute{{
+				Redirect: &networking.HTTPRedirect{Authority: "frontend.com"},
+			}},
+		},
+	}
+	configgen := NewConfigGenerator([]plugin.Plugin{&fakePlugin{}}, &model.DisabledCache{})
+	env := buildListenerEnvWithAdditionalConfig(services, []*config.Config{redirect}, nil)
+	env.NamespaceCORSPolicies = fakeCORSPolicies{
+		"frontend":     {AllowMethods: []string{"GET"}},
+		"istio-system": {AllowMethods: []string{"POST"}},
+	}
+	if err := env.PushContext.InitContext(env, nil, nil); err != nil {
+		t.Fatalf("failed to initialize push context")
+	}
+	proxy := getProxy()
+	proxy.SidecarScope = model.DefaultSidecarScopeForNamespace(env.PushContext, "not-default")
+	proxy.BuildCatchAllVirtualHost()
+
+	resource, _, _ := configgen.buildSidecarOutboundHTTPRouteConfig(proxy, &model.PushRequest{Push: env.PushContext},
+		"8080", map[int][]*route.VirtualHost{}, nil, nil)
+	routeCfg := &route.RouteConfiguration{}
+	if err := resource.Resource.UnmarshalTo(routeCfg); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		// the service of a namespace without a policy gets the one of the root namespace.
+		"test.com:8080":     "POST",
+		"frontend.com:8080": "GET",
+		// the hosts of a VirtualService without a service get the one of its namespace, including redirects.
+		"redirect.example.com:8080": "GET",
+	}
+	for _, vh := range routeCfg.VirtualHosts {
+		w, f := want[vh.Name]
+		if !f {
+			continue
+		}
+		delete(want, vh.Name)
+		if got := vh.GetCors().GetAllowMethods(); got != w {
+			t.Errorf("got allowed methods %q for %s, want %q", got, vh.Name, w)
+		}
+	}
+	if len(want) != 0 {
+		t.Fatalf("expected virtual hosts %v", want)
+	}
+}
+
+func TestSidecarOutboundHTTPRouteConfigServiceSource(t *testing.T) {
+	defaultValue := features.EnableVirtualHostSourceMetadata
+	features.EnableVirtualHostSourceMetadata = true
+	defer func() { features.EnableVirtualHostSourceMetadata = defaultValue }()
+
+	svc := buildHTTPService("test.com", visibility.Public, "8.8.8.8", "not-default", 8080)
+	svc.ClusterVIPs.SetAddressesFor("cluster-b", []string{"8.8.8.8"})
+	svc.ClusterVIPs.SetAddressesFor("cluster-a", []string{"8.8.4.4"})
+	configgen := NewConfigGenerator([]plugin.Plugin{&fakePlugin{}}, &model.DisabledCache{})
+	env := buildListenerEnvWithAdditionalConfig([]*model.Service{svc}, nil, nil)
+	if err := env.PushContext.InitContext(env, nil, nil); err != nil {
+		t.Fatalf("failed to initialize push context")
+	}
+	proxy := getProxy()
+	proxy.SidecarScope = model.DefaultSidecarScopeForNamespace(env.PushContext, "not-default")
+	proxy.BuildCatchAllVirtualHost()
+
+	resource, _, _ := configgen.buildSidecarOutboundHTTPRouteConfig(proxy, &model.PushRequest{Push: env.PushContext},
+		"8080", map[int][]*route.VirtualHost{}, nil, nil)
+	routeCfg := &route.RouteConfiguration{}
+	if err := resource.Resource.UnmarshalTo(routeCfg); err != nil {
+		t.Fatal(err)
+	}
+	checked := 0
+	for _, vh := range routeCfg.VirtualHosts {
+		if vh.Name != "test.com:8080" {
+			continue
+		}
+		for _, r := range vh.Routes {
+			checked++
+			got := r.GetMetadata().GetFilterMetadata()[util.IstioMetadataKey].GetFields()["source"].GetStringValue()
+			if want := "Kubernetes/cluster-a,cluster-b"; got != want {
+				t.Errorf("unexpected source for route %s: got %q, want %q", r.Name, got, want)
+			}
+		}
+	}
+	if checked == 0 {
+		t.Fatal("expected routes for the service")
+	}
+}
+
+func TestSidecarOutboundHTTPRouteConfigServiceSourceFeatureGate(t *testing.T) {
+	defaultValue := features.EnableVirtualHostSourceMetadata
+	features.EnableVirtualHostSourceMetadata = false
+	defer func() { features.EnableVirtualHostSourceMetadata = defaultValue }()
+
+	svc := buildHTTPService("test.com", visibility.Public, "8.8.8.8", "not-default", 8080)
+	configgen := NewConfigGenerator([]plugin.Plugin{&fakePlugin{}}, &model.DisabledCache{})
+	env := buildListenerEnvWithAdditionalConfig([]*model.Service{svc}, nil, nil)
+	if err := env.PushContext.InitContext(env, nil, nil); err != nil {
+		t.Fatalf("failed to initialize push context")
+	}
+	for _, tc := range []struct {
+		gates      string
+		wantSource bool
+	}{
+		{"", false},
+		{"VirtualHostSourceMetadata=true", true},
+	} {
+		proxy := getProxy()
+		proxy.Metadata.FeatureGates = tc.gates
+		proxy.SetFeatureGates()
+		proxy.SidecarScope = model.DefaultSidecarScopeForNamespace(env.PushContext, "not-default")
+		proxy.BuildCatchAllVirtualHost()
+
+		resource, _, _ := configgen.buildSidecarOutboundHTTPRouteConfig(proxy, &model.PushRequest{Push: env.PushContext},
+			"8080", map[int][]*route.VirtualHost{}, nil, nil)
+		routeCfg := &route.RouteConfiguration{}
+		if err := resource.Resource.UnmarshalTo(routeCfg); err != nil {
+			t.Fatal(err)
+		}
+		for _, vh := range routeCfg.VirtualHosts {
+			if vh.Name != "test.com:8080" {
+				continue
+			}
+			for _, r := range vh.Routes {
+				_, hasSource := r.GetMetadata().GetFilterMetadata()[util.IstioMetadataKey].GetFields()["source"]
+				if hasSource != tc.wantSource {
+					t.Errorf("feature gates %q: got source %v for route %s, want %v", tc.gates, hasSource, r.Name, tc.wantSource)
+				}
+			}
+		}
+	}
+}
+
 func testSidecarRDSVHosts(t *testing.T, services []*model.Service,
 	sidecarConfig *config.Config, virtualServices []*config.Config, routeName string,
 	expectedHosts map[string]map[string]bool, expectedRoutes int, registryOnly bool) {
@@ -1307,7 +2013,7 @@ func testSidecarRDSVHosts(t *testing.T, services []*model.Service,
 	proxy.BuildCatchAllVirtualHost()
 
 	vHostCache := make(map[int][]*route.VirtualHost)
-	resource, _ := configgen.buildSidecarOutboundHTTPRouteConfig(proxy, &model.PushRequest{Push: env.PushContext}, routeName, vHostCache, nil, nil)
+	resource, _, _ := configgen.buildSidecarOutboundHTTPRouteConfig(proxy, &model.PushRequest{Push: env.PushContext}, routeName, vHostCache, nil, nil)
 	routeCfg := &route.RouteConfiguration{}
 	resource.Resource.UnmarshalTo(routeCfg)
 	xdstest.ValidateRouteConfiguration(t, routeCfg)
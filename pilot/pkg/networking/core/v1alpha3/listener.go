@@ -27,6 +27,7 @@ import (
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	envoyquicv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/quic/v3"
 	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
@@ -115,7 +116,20 @@ var (
 // BuildListeners produces a list of listeners and referenced clusters for all proxies
 func (configgen *ConfigGeneratorImpl) BuildListeners(node *model.Proxy,
 	push *model.PushContext) []*listener.Listener {
+	return configgen.buildListeners(node, push, nil)
+}
+
+// ProfileListeners builds the listeners of the proxy like BuildListeners, recording the time spent by each listener
+// builder into the profile.
+func (configgen *ConfigGeneratorImpl) ProfileListeners(node *model.Proxy, push *model.PushContext,
+	profile *model.GenerationProfile) []*listener.Listener {
+	return configgen.buildListeners(node, push, profile)
+}
+
+func (configgen *ConfigGeneratorImpl) buildListeners(node *model.Proxy, push *model.PushContext,
+	profile *model.GenerationProfile) []*listener.Listener {
 	builder := NewListenerBuilder(node, push)
+	builder.profile = profile
 
 	switch node.Type {
 	case model.SidecarProxy:
@@ -274,8 +288,14 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListeners(
 		//
 		//	Pilot will generate three listeners, the last one will use protocol sniffing.
 		//
+		// The ports of the inbound port ranges of the proxy get no listener; their traffic is handled by
+		// the inbound passthrough filter chain.
+		portRanges := node.InboundPortRanges()
 		for _, instance := range node.ServiceInstances {
 			endpoint := instance.Endpoint
+			if inPortRanges(portRanges, int(endpoint.EndpointPort)) {
+				continue
+			}
 			// Inbound listeners will be aggregated into a single virtual listener (port 15006)
 			// As a result, we don't need to worry about binding to the endpoint IP; we already know
 			// all traffic for these listeners is inbound.
@@ -422,9 +442,28 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundHTTPListenerOptsForPort
 		}
 	}
 
+	applyClientCertDetails(node, httpOpts.connectionManager)
+
 	return httpOpts
 }
 
+// applyClientCertDetails overrides how the connection manager handles the x-forwarded-client-cert header with the
+// ForwardClientCertDetailsAnnotation and SetCurrentClientCertDetailsAnnotation of the pod of the proxy.
+func applyClientCertDetails(node *model.Proxy, connectionManager *hcm.HttpConnectionManager) {
+	if mode := node.ForwardClientCertDetails(); mode != meshconfig.Topology_UNDEFINED {
+		connectionManager.ForwardClientCertDetails = util.MeshConfigToEnvoyForwardClientCertDetails(mode)
+	}
+	if details := node.SetCurrentClientCertDetails(); details != nil {
+		connectionManager.SetCurrentClientCertDetails = &hcm.HttpConnectionManager_SetCurrentClientCertDetails{
+			Subject: &wrappers.BoolValue{Value: details.Subject},
+			Cert:    details.Cert,
+			Chain:   details.Chain,
+			Dns:     details.DNS,
+			Uri:     details.URI,
+		}
+	}
+}
+
 // if enableFlag is "1" indicates that AcceptHttp_10 is enabled.
 func enableHTTP10(enableFlag string) bool {
 	return enableFlag == "1"
@@ -495,6 +534,16 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListenerForPortOrUDS(li
 	return mutable.Listener
 }
 
+// inPortRanges returns true if the port is in one of the ranges.
+func inPortRanges(ranges []model.PortRange, port int) bool {
+	for _, r := range ranges {
+		if r.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
 type inboundListenerEntry struct {
 	instanceHostname host.Name // could be empty if generated via Sidecar CRD
 	protocol         protocol.Instance
@@ -553,9 +602,16 @@ func (c outboundListenerConflict) addMetric(metrics model.Metrics) {
 // outbound connections from the proxy based on the sidecar scope associated with the proxy.
 func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListeners(node *model.Proxy,
 	push *model.PushContext) []*listener.Listener {
-	noneMode := node.GetInterceptionMode() == model.InterceptionNone
+	listeners, _ := configgen.buildSidecarOutboundListenersWithConflicts(node, push)
+	return listeners
+}
 
+// buildSidecarOutboundListenersWithConflicts generates the outbound listeners of the proxy, and returns the listener
+// conflicts reported while building them.
+func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListenersWithConflicts(node *model.Proxy,
+	push *model.PushContext) ([]*listener.Listener, []outboundListenerConflict) {
 	actualWildcard, actualLocalHostAddress := getActualWildcardAndLocalHost(node)
+	var conflicts []outboundListenerConflict
 
 	var tcpListeners, httpListeners []*listener.Listener
 	// For conflict resolution
@@ -579,26 +635,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListeners(node *model.
 		services := egressListener.Services()
 		virtualServices := egressListener.VirtualServices()
 
-		// determine the bindToPort setting for listeners
-		bindToPort := false
-		if noneMode {
-			// do not care what the listener's capture mode setting is. The proxy does not use iptables
-			bindToPort = true
-		} else if egressListener.IstioListener != nil {
-			if egressListener.IstioListener.CaptureMode == networking.CaptureMode_NONE {
-				// proxy uses iptables redirect or tproxy. IF mode is not set
-				// for older proxies, it defaults to iptables redirect.  If the
-				// listener's capture mode specifies NONE, then the proxy wants
-				// this listener alone to be on a physical port. If the
-				// listener's capture mode is default, then its same as
-				// iptables i.e. bindToPort is false.
-				bindToPort = true
-			} else if strings.HasPrefix(egressListener.IstioListener.Bind, model.UnixAddressPrefix) {
-				// If the bind is a Unix domain socket, set bindtoPort to true as it makes no
-				// sense to have ORIG_DST listener for unix domain socket listeners.
-				bindToPort = true
-			}
-		}
+		bindToPort := egressBindsToPort(node, egressListener)
 
 		if egressListener.IstioListener != nil &&
 			egressListener.IstioListener.Port != nil {
@@ -648,11 +685,13 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListeners(node *model.
 
 			// Build ListenerOpts and PluginParams once and reuse across all Services to avoid unnecessary allocations.
 			listenerOpts := buildListenerOpts{
-				push:       push,
-				proxy:      node,
-				bind:       bind,
-				port:       listenPort,
-				bindToPort: bindToPort,
+				push:        push,
+				proxy:       node,
+				bind:        bind,
+				port:        listenPort,
+				bindToPort:  bindToPort,
+				idleTimeout: egressListener.IdleTimeout,
+				conflicts:   &conflicts,
 			}
 
 			for _, service := range services {
@@ -695,9 +734,11 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListeners(node *model.
 
 			// Build ListenerOpts and PluginParams once and reuse across all Services to avoid unnecessary allocations.
 			listenerOpts := buildListenerOpts{
-				push:       push,
-				proxy:      node,
-				bindToPort: bindToPort,
+				push:        push,
+				proxy:       node,
+				bindToPort:  bindToPort,
+				idleTimeout: egressListener.IdleTimeout,
+				conflicts:   &conflicts,
 			}
 
 			for _, service := range services {
@@ -778,7 +819,109 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListeners(node *model.
 		configgen.appendListenerFallthroughRouteForCompleteListener(listener, node, push)
 	}
 	removeListenerFilterTimeout(tcpListeners)
-	return tcpListeners
+
+	if features.EnableQUICListeners {
+		for _, l := range listenerMap {
+			if http3 := configgen.buildSidecarOutboundHTTP3Listener(node, push, l); http3 != nil {
+				tcpListeners = append(tcpListeners, http3)
+			}
+		}
+	}
+
+	if features.EnableUDPProxy {
+		tcpListeners = append(tcpListeners, buildSidecarOutboundUDPListeners(node, push)...)
+	}
+	return tcpListeners, conflicts
+}
+
+// egressBindsToPort determines the bindToPort setting for the outbound listeners of an egress listener.
+func egressBindsToPort(node *model.Proxy, egressListener *model.IstioEgressListenerWrapper) bool {
+	if node.GetInterceptionMode() == model.InterceptionNone {
+		// do not care what the listener's capture mode setting is. The proxy does not use iptables
+		return true
+	}
+	if egressListener.IstioListener != nil {
+		if egressListener.IstioListener.CaptureMode == networking.CaptureMode_NONE {
+			// proxy uses iptables redirect or tproxy. IF mode is not set
+			// for older proxies, it defaults to iptables redirect.  If the
+			// listener's capture mode specifies NONE, then the proxy wants
+			// this listener alone to be on a physical port. If the
+			// listener's capture mode is default, then its same as
+			// iptables i.e. bindToPort is false.
+			return true
+		} else if strings.HasPrefix(egressListener.IstioListener.Bind, model.UnixAddressPrefix) {
+			// If the bind is a Unix domain socket, set bindtoPort to true as it makes no
+			// sense to have ORIG_DST listener for unix domain socket listeners.
+			return true
+		}
+	}
+	return false
+}
+
+// buildSidecarOutboundHTTP3Listener builds a QUIC listener mirroring an outbound HTTP listener bound to its port,
+// if any of its services advertise HTTP/3. UDP traffic is not redirected by iptables, so listeners that are not
+// bound to their port cannot receive QUIC.
+func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTP3Listener(node *model.Proxy, push *model.PushContext,
+	entry *outboundListenerEntry) *listener.Listener {
+	if !entry.servicePort.Protocol.IsHTTP() || entry.servicePort.Port == 0 ||
+		entry.listener.BindToPort != nil || strings.HasPrefix(entry.bind, model.UnixAddressPrefix) {
+		return nil
+	}
+	http3 := false
+	for _, svc := range entry.services {
+		if svc != nil && svc.Attributes.HTTP3 {
+			http3 = true
+			break
+		}
+	}
+	if !http3 {
+		return nil
+	}
+
+	tlsContext := &auth.DownstreamTlsContext{
+		CommonTlsContext: &auth.CommonTlsContext{AlpnProtocols: util.ALPNHttp3OverQUIC},
+	}
+	authn_model.ApplyToCommonTLSContext(tlsContext.CommonTlsContext, node, nil, nil, false)
+	opts := buildListenerOpts{
+		push:       push,
+		proxy:      node,
+		bind:       entry.bind,
+		port:       entry.servicePort,
+		bindToPort: true,
+		class:      istionetworking.ListenerClassSidecarOutbound,
+		transport:  istionetworking.TransportProtocolQUIC,
+		filterChainOpts: []*filterChainOpts{{
+			tlsContext: tlsContext,
+			httpOpts: &httpListenerOpts{
+				useRemoteAddress: features.UseRemoteAddress,
+				rds:              strconv.Itoa(entry.servicePort.Port),
+				http3Only:        true,
+			},
+		}},
+	}
+	mutable := &MutableListener{
+		MutableObjects: istionetworking.MutableObjects{
+			Listener: buildListener(opts, core.TrafficDirection_OUTBOUND),
+			FilterChains: []istionetworking.FilterChain{{
+				ListenerProtocol:  istionetworking.ListenerProtocolHTTP,
+				TransportProtocol: istionetworking.TransportProtocolQUIC,
+			}},
+		},
+	}
+	pluginParams := &plugin.InputParams{
+		Node: node,
+		Push: push,
+	}
+	for _, p := range configgen.Plugins {
+		if err := p.OnOutboundListener(pluginParams, &mutable.MutableObjects); err != nil {
+			log.Warn(err.Error())
+		}
+	}
+	if err := mutable.build(opts); err != nil {
+		log.Warn("buildSidecarOutboundHTTP3Listener: ", err.Error())
+		return nil
+	}
+	return mutable.Listener
 }
 
 func (configgen *ConfigGeneratorImpl) buildHTTPProxy(node *model.Proxy,
@@ -880,7 +1023,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPListenerOptsForPor
 		if !sniffingEnabled {
 			if listenerOpts.service != nil {
 				if !(*currentListenerEntry).servicePort.Protocol.IsHTTP() {
-					outboundListenerConflict{
+					listenerOpts.reportConflict(outboundListenerConflict{
 						metric:          model.ProxyStatusConflictOutboundListenerTCPOverHTTP,
 						node:            listenerOpts.proxy,
 						listenerName:    *listenerMapKey,
@@ -888,7 +1031,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPListenerOptsForPor
 						currentProtocol: (*currentListenerEntry).servicePort.Protocol,
 						newHostname:     listenerOpts.service.Hostname,
 						newProtocol:     listenerOpts.port.Protocol,
-					}.addMetric(listenerOpts.push)
+					})
 				}
 
 				// Skip building listener for the same http port
@@ -1017,7 +1160,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundTCPListenerOptsForPort
 				// on same IP.  Unfortunately we won't know if this is a real
 				// conflict or not until we process the VirtualServices, etc.
 				// The conflict resolution is done later in this code
-				outboundListenerConflict{
+				listenerOpts.reportConflict(outboundListenerConflict{
 					metric:          model.ProxyStatusConflictOutboundListenerHTTPOverTCP,
 					node:            listenerOpts.proxy,
 					listenerName:    *listenerMapKey,
@@ -1025,17 +1168,39 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundTCPListenerOptsForPort
 					currentProtocol: (*currentListenerEntry).servicePort.Protocol,
 					newHostname:     newHostname,
 					newProtocol:     listenerOpts.port.Protocol,
-				}.addMetric(listenerOpts.push)
+				})
 				return false, nil
 			}
 		}
 	}
 
 	meshGateway := map[string]bool{constants.IstioMeshGateway: true}
-	return true, buildSidecarOutboundTCPTLSFilterChainOpts(listenerOpts.proxy,
+	opts := buildSidecarOutboundTCPTLSFilterChainOpts(listenerOpts.proxy,
 		listenerOpts.push, virtualServices,
 		*destinationCIDR, listenerOpts.service,
 		listenerOpts.bind, listenerOpts.port, meshGateway)
+	if listenerOpts.idleTimeout > 0 {
+		setTCPProxyIdleTimeout(opts, listenerOpts.idleTimeout)
+	}
+	return true, opts
+}
+
+// setTCPProxyIdleTimeout overrides the idle timeout of the TCP proxy filters of the filter chains.
+func setTCPProxyIdleTimeout(opts []*filterChainOpts, idleTimeout time.Duration) {
+	for _, opt := range opts {
+		for _, f := range opt.networkFilters {
+			if f.Name != wellknown.TCPProxy || f.GetTypedConfig() == nil {
+				continue
+			}
+			tcpProxy := &tcp.TcpProxy{}
+			if err := f.GetTypedConfig().UnmarshalTo(tcpProxy); err != nil {
+				log.Warnf("failed to set the idle timeout of filter chain %s: %v", opt.filterChainName, err)
+				continue
+			}
+			tcpProxy.IdleTimeout = durationpb.New(idleTimeout)
+			f.ConfigType = &listener.Filter_TypedConfig{TypedConfig: util.MessageToAny(tcpProxy)}
+		}
+	}
 }
 
 // buildSidecarOutboundListenerForPortOrUDS builds a single listener and
@@ -1365,6 +1530,18 @@ type buildListenerOpts struct {
 	protocol          istionetworking.ListenerProtocol
 	transport         istionetworking.TransportProtocol
 	tlsSettings       *networking.ServerTLSSettings
+	// idleTimeout overrides the idle timeout of the proxy, if set.
+	idleTimeout time.Duration
+	// conflicts, if set, records the outbound listener conflicts reported while building the listener.
+	conflicts *[]outboundListenerConflict
+}
+
+// reportConflict adds the metric of the outbound listener conflict, and records it in the conflicts of the options.
+func (opts buildListenerOpts) reportConflict(c outboundListenerConflict) {
+	c.addMetric(opts.push)
+	if opts.conflicts != nil {
+		*opts.conflicts = append(*opts.conflicts, c)
+	}
 }
 
 func buildHTTPConnectionManager(listenerOpts buildListenerOpts, httpOpts *httpListenerOpts,
@@ -1410,6 +1587,9 @@ func buildHTTPConnectionManager(listenerOpts buildListenerOpts, httpOpts *httpLi
 	connectionManager.UpgradeConfigs = []*hcm.HttpConnectionManager_UpgradeConfig{websocketUpgrade}
 
 	idleTimeout, err := time.ParseDuration(listenerOpts.proxy.Metadata.IdleTimeout)
+	if listenerOpts.idleTimeout > 0 {
+		idleTimeout, err = listenerOpts.idleTimeout, nil
+	}
 	if err == nil {
 		connectionManager.CommonHttpProtocolOptions = &core.HttpProtocolOptions{
 			IdleTimeout: durationpb.New(idleTimeout),
@@ -1462,6 +1642,12 @@ func buildHTTPConnectionManager(listenerOpts buildListenerOpts, httpOpts *httpLi
 	}
 
 	// TypedPerFilterConfig in route needs these filters.
+	if features.EnableLocalRateLimit {
+		filters = append(filters, xdsfilters.LocalRateLimit)
+	}
+	if rl := xdsfilters.BuildGlobalRateLimitFilter(listenerOpts.proxy, listenerOpts.push); rl != nil {
+		filters = append(filters, rl)
+	}
 	filters = append(filters, xdsfilters.Fault, xdsfilters.Cors)
 	filters = append(filters, listenerOpts.push.Telemetry.HTTPFilters(listenerOpts.proxy, listenerOpts.class)...)
 	filters = append(filters, xdsfilters.BuildRouterFilter(routerFilterCtx))
@@ -1776,7 +1962,7 @@ func mergeTCPFilterChains(incoming []*listener.FilterChain, listenerOpts buildLi
 					newHostname = "sidecar-config-egress-tcp-listener"
 				}
 
-				outboundListenerConflict{
+				listenerOpts.reportConflict(outboundListenerConflict{
 					metric:          model.ProxyStatusConflictOutboundListenerTCPOverTCP,
 					node:            listenerOpts.proxy,
 					listenerName:    listenerMapKey,
@@ -1784,7 +1970,7 @@ func mergeTCPFilterChains(incoming []*listener.FilterChain, listenerOpts buildLi
 					currentProtocol: currentListenerEntry.servicePort.Protocol,
 					newHostname:     newHostname,
 					newProtocol:     listenerOpts.port.Protocol,
-				}.addMetric(listenerOpts.push)
+				})
 				break
 			}
 
@@ -1899,7 +2085,7 @@ func getPluginFilterChain(opts buildListenerOpts) []istionetworking.FilterChain
 }
 
 // isConflictWithWellKnownPort checks conflicts between incoming protocol and existing protocol.
-// Mongo and MySQL are not allowed to co-exist with other protocols in one port.
+// Mongo, MySQL and Kafka are not allowed to co-exist with other protocols in one port.
 func isConflictWithWellKnownPort(incoming, existing protocol.Instance, conflict int) bool {
 	if conflict == NoConflict {
 		return true
@@ -1907,8 +2093,10 @@ func isConflictWithWellKnownPort(incoming, existing protocol.Instance, conflict
 
 	if (incoming == protocol.Mongo ||
 		incoming == protocol.MySQL ||
+		incoming == protocol.Kafka ||
 		existing == protocol.Mongo ||
-		existing == protocol.MySQL) && incoming != existing {
+		existing == protocol.MySQL ||
+		existing == protocol.Kafka) && incoming != existing {
 		return false
 	}
 
@@ -15,7 +15,11 @@
 package v1alpha3
 
 import (
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
 )
 
 const (
@@ -45,6 +49,18 @@ func getActualWildcardAndLocalHost(node *model.Proxy) (string, string) {
 	return WildcardIPv6Address, LocalhostIPv6Address
 }
 
+// buildWildcardAddress returns the address of a listener binding to all the addresses of the proxy on the port.
+// Dual-stack proxies bind to the IPv6 wildcard address with IPv4 compatibility, accepting traffic of both families.
+func buildWildcardAddress(node *model.Proxy, port uint32) *core.Address {
+	if !features.EnableDualStack || !node.SupportsIPv4() || !node.SupportsIPv6() {
+		actualWildcard, _ := getActualWildcardAndLocalHost(node)
+		return util.BuildAddress(actualWildcard, port)
+	}
+	address := util.BuildAddress(WildcardIPv6Address, port)
+	address.GetSocketAddress().Ipv4Compat = true
+	return address
+}
+
 func getPassthroughBindIP(node *model.Proxy) string {
 	if node.SupportsIPv4() {
 		return InboundPassthroughBindIpv4
@@ -65,6 +65,9 @@ type ListenerBuilder struct {
 	virtualInboundListener  *listener.Listener
 
 	envoyFilterWrapper *model.EnvoyFilterWrapper
+
+	// profile, when set, collects the time spent by each builder.
+	profile *model.GenerationProfile
 }
 
 // Setup the filter chain match so that the match should work under both
@@ -329,16 +332,72 @@ func NewListenerBuilder(node *model.Proxy, push *model.PushContext) *ListenerBui
 }
 
 func (lb *ListenerBuilder) buildSidecarInboundListeners(configgen *ConfigGeneratorImpl) *ListenerBuilder {
+	defer lb.profile.Time("inbound_listeners")()
 	lb.inboundListeners = configgen.buildSidecarInboundListeners(lb.node, lb.push)
 	return lb
 }
 
+// buildSidecarOutboundListeners builds the outbound listeners of the proxy, or reuses the ones built for another
+// proxy of the same scope when the scope cache is enabled.
 func (lb *ListenerBuilder) buildSidecarOutboundListeners(configgen *ConfigGeneratorImpl) *ListenerBuilder {
-	lb.outboundListeners = configgen.buildSidecarOutboundListeners(lb.node, lb.push)
+	defer lb.profile.Time("outbound_listeners")()
+	cache := lb.push.ScopeCache()
+	if cache == nil {
+		lb.outboundListeners = configgen.buildSidecarOutboundListeners(lb.node, lb.push)
+		return lb
+	}
+	// The outbound listeners are shared with the proxies of the scope with the same inputs. They are cloned, as
+	// they are patched by EnvoyFilters afterwards.
+	key := model.NewScopeCacheKey(lb.node, outboundListenersScopeInputs(lb.node)...)
+	if cached, f := cache.Get(scopeCacheOutboundListeners, key); f {
+		entry := cached.(outboundListenersEntry)
+		lb.outboundListeners = cloneListeners(entry.listeners)
+		// The conflicts found while building the listeners are reported for this proxy too.
+		for _, c := range entry.conflicts {
+			c.node = lb.node
+			c.addMetric(lb.push)
+		}
+		return lb
+	}
+	listeners, conflicts := configgen.buildSidecarOutboundListenersWithConflicts(lb.node, lb.push)
+	lb.outboundListeners = listeners
+	cache.Add(scopeCacheOutboundListeners, key, outboundListenersEntry{listeners: cloneListeners(listeners), conflicts: conflicts})
 	return lb
 }
 
+// scopeCacheOutboundListeners is the resource type of the outbound listeners in the scope cache.
+const scopeCacheOutboundListeners = "outbound_listeners"
+
+// outboundListenersEntry is the entry of the outbound listeners in the scope cache.
+type outboundListenersEntry struct {
+	listeners []*listener.Listener
+	conflicts []outboundListenerConflict
+}
+
+// outboundListenersScopeInputs returns the inputs of the outbound listeners of the proxy which are not part of
+// its scope cache key.
+func outboundListenersScopeInputs(node *model.Proxy) []string {
+	// The listeners of headless services skip the endpoint of the proxy itself.
+	if features.EnableHeadlessService && len(node.IPAddresses) > 0 {
+		for _, svc := range node.SidecarScope.Services() {
+			if svc.Resolution == model.Passthrough {
+				return []string{node.IPAddresses[0]}
+			}
+		}
+	}
+	return nil
+}
+
+func cloneListeners(listeners []*listener.Listener) []*listener.Listener {
+	out := make([]*listener.Listener, 0, len(listeners))
+	for _, l := range listeners {
+		out = append(out, golangproto.Clone(l).(*listener.Listener))
+	}
+	return out
+}
+
 func (lb *ListenerBuilder) buildHTTPProxyListener(configgen *ConfigGeneratorImpl) *ListenerBuilder {
+	defer lb.profile.Time("http_proxy_listener")()
 	httpProxy := configgen.buildHTTPProxy(lb.node, lb.push)
 	if httpProxy == nil {
 		return lb
@@ -350,6 +409,7 @@ func (lb *ListenerBuilder) buildHTTPProxyListener(configgen *ConfigGeneratorImpl
 }
 
 func (lb *ListenerBuilder) buildVirtualOutboundListener(configgen *ConfigGeneratorImpl) *ListenerBuilder {
+	defer lb.profile.Time("virtual_outbound_listener")()
 	if lb.node.GetInterceptionMode() == model.InterceptionNone {
 		// virtual listener is not necessary since workload is not using IPtables for traffic interception
 		return lb
@@ -362,12 +422,10 @@ func (lb *ListenerBuilder) buildVirtualOutboundListener(configgen *ConfigGenerat
 
 	filterChains := buildOutboundCatchAllNetworkFilterChains(configgen, lb.node, lb.push)
 
-	actualWildcard, _ := getActualWildcardAndLocalHost(lb.node)
-
 	// add an extra listener that binds to the port that is the recipient of the iptables redirect
 	ipTablesListener := &listener.Listener{
 		Name:             model.VirtualOutboundListenerName,
-		Address:          util.BuildAddress(actualWildcard, uint32(lb.push.Mesh.ProxyListenPort)),
+		Address:          buildWildcardAddress(lb.node, uint32(lb.push.Mesh.ProxyListenPort)),
 		Transparent:      isTransparentProxy,
 		UseOriginalDst:   proto.BoolTrue,
 		FilterChains:     filterChains,
@@ -381,6 +439,7 @@ func (lb *ListenerBuilder) buildVirtualOutboundListener(configgen *ConfigGenerat
 // TProxy uses only the virtual outbound listener on 15001 for both directions
 // but we still ship the no-op virtual inbound listener, so that the code flow is same across REDIRECT and TPROXY.
 func (lb *ListenerBuilder) buildVirtualInboundListener(configgen *ConfigGeneratorImpl) *ListenerBuilder {
+	defer lb.profile.Time("virtual_inbound_listener")()
 	if lb.node.GetInterceptionMode() == model.InterceptionNone {
 		// virtual listener is not necessary since workload is not using IPtables for traffic interception
 		return lb
@@ -391,7 +450,6 @@ func (lb *ListenerBuilder) buildVirtualInboundListener(configgen *ConfigGenerato
 		isTransparentProxy = proto.BoolTrue
 	}
 
-	actualWildcard, _ := getActualWildcardAndLocalHost(lb.node)
 	// add an extra listener that binds to the port that is the recipient of the iptables redirect
 	filterChains, passthroughInspector, usesQUIC := buildInboundCatchAllFilterChains(configgen, lb.node, lb.push)
 
@@ -406,7 +464,7 @@ func (lb *ListenerBuilder) buildVirtualInboundListener(configgen *ConfigGenerato
 	}
 	lb.virtualInboundListener = &listener.Listener{
 		Name:                    model.VirtualInboundListenerName,
-		Address:                 util.BuildAddress(actualWildcard, ProxyInboundListenPort),
+		Address:                 buildWildcardAddress(lb.node, ProxyInboundListenPort),
 		Transparent:             isTransparentProxy,
 		UseOriginalDst:          proto.BoolTrue,
 		TrafficDirection:        core.TrafficDirection_INBOUND,
@@ -433,6 +491,7 @@ func (lb *ListenerBuilder) patchOneListener(l *listener.Listener, ctx networking
 }
 
 func (lb *ListenerBuilder) patchListeners() {
+	defer lb.profile.Time("listener_patching")()
 	lb.envoyFilterWrapper = lb.push.EnvoyFilters(lb.node)
 	if lb.envoyFilterWrapper == nil {
 		return
@@ -688,6 +747,11 @@ func (configgen *ConfigGeneratorImpl) buildInboundFilterchains(in *plugin.InputP
 			fcOpt.httpOpts = configgen.buildSidecarInboundHTTPListenerOptsForPortOrUDS(in.Node, in, clusterName)
 			fcOpt.networkFilters = buildInboundNetworkFilters(in.Push, in.Node, in.ServiceInstance, clusterName)
 		}
+		if !passthrough {
+			if limit := in.Node.SidecarScope.InboundConnectionLimit(listenerOpts.port.Port); limit > 0 {
+				fcOpt.filterChain.TCP = append([]*listener.Filter{buildConnectionLimitFilter(clusterName, limit)}, fcOpt.filterChain.TCP...)
+			}
+		}
 		fcOpt.filterChainName = model.VirtualInboundListenerName
 		if opt.fc.ListenerProtocol == istionetworking.ListenerProtocolHTTP {
 			fcOpt.filterChainName = model.VirtualInboundCatchAllHTTPFilterChainName
@@ -26,6 +26,8 @@ import (
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/types"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
@@ -33,6 +35,7 @@ import (
 	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/networking/plugin"
 	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/util/sets"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/protocol"
@@ -225,6 +228,34 @@ func TestVirtualInboundListenerBuilder(t *testing.T) {
 	}
 }
 
+func TestVirtualListenerDualStackAddress(t *testing.T) {
+	cases := []struct {
+		name       string
+		ips        []string
+		dualStack  bool
+		address    string
+		ipv4Compat bool
+	}{
+		{name: "ipv4", ips: []string{"1.1.1.1"}, dualStack: true, address: WildcardAddress},
+		{name: "ipv6", ips: []string{"1::1"}, dualStack: true, address: WildcardIPv6Address},
+		{name: "dual stack disabled", ips: []string{"1.1.1.1", "1::1"}, address: WildcardAddress},
+		{name: "dual stack", ips: []string{"1.1.1.1", "1::1"}, dualStack: true, address: WildcardIPv6Address, ipv4Compat: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(old bool) { features.EnableDualStack = old }(features.EnableDualStack)
+			features.EnableDualStack = tt.dualStack
+			proxy := &model.Proxy{IPAddresses: tt.ips}
+			proxy.DiscoverIPVersions()
+
+			address := buildWildcardAddress(proxy, ProxyInboundListenPort).GetSocketAddress()
+			if address.Address != tt.address || address.Ipv4Compat != tt.ipv4Compat {
+				t.Fatalf("expected address %v with ipv4 compat %v, got %v", tt.address, tt.ipv4Compat, address)
+			}
+		})
+	}
+}
+
 func TestVirtualInboundHasPassthroughClusters(t *testing.T) {
 	// prepare
 	t.Helper()
@@ -925,3 +956,185 @@ func (t TestAuthnPlugin) OnInboundPassthrough(in *plugin.InputParams, mutable *i
 func (t TestAuthnPlugin) InboundMTLSConfiguration(in *plugin.InputParams, passthrough bool) []plugin.MTLSSettings {
 	return t.mtlsSettings
 }
+
+func TestSidecarOutboundListenersScopeCache(t *testing.T) {
+	defer func(v bool) { features.EnableScopeCache = v }(features.EnableScopeCache)
+	features.EnableScopeCache = true
+
+	cg := NewConfigGenTest(t, TestOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts: [a.example.com]
+  addresses: [2.2.2.2]
+  ports:
+  - {name: http, number: 80, protocol: HTTP}
+  - {name: tcp, number: 9000, protocol: TCP}
+  resolution: STATIC
+  endpoints:
+  - address: 3.3.3.3
+`})
+	labels := map[string]string{"app": "a"}
+	first := cg.SetupProxy(&model.Proxy{IPAddresses: []string{"1.1.1.1"}, Metadata: &model.NodeMetadata{Labels: labels}})
+	second := cg.SetupProxy(&model.Proxy{IPAddresses: []string{"1.1.1.2"}, Metadata: &model.NodeMetadata{Labels: labels}})
+	other := cg.SetupProxy(&model.Proxy{IPAddresses: []string{"1.1.1.3"}, Metadata: &model.NodeMetadata{Labels: map[string]string{"app": "b"}}})
+
+	expected := xdstest.ExtractListenerNames(cg.Listeners(first))
+	key := model.NewScopeCacheKey(first)
+	if _, f := cg.PushContext().ScopeCache().Get(scopeCacheOutboundListeners, key); !f {
+		t.Fatalf("expected the outbound listeners to be cached")
+	}
+	if model.NewScopeCacheKey(second) != key {
+		t.Fatalf("expected the replicas to share the outbound listeners")
+	}
+	if model.NewScopeCacheKey(other) == key {
+		t.Fatalf("expected proxies with other labels not to share the outbound listeners")
+	}
+
+	// The listeners served from the cache can be patched without affecting the cache.
+	listeners := cg.Listeners(second)
+	if got := xdstest.ExtractListenerNames(listeners); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected listeners %v, got %v", expected, got)
+	}
+	for _, l := range listeners {
+		l.Name = "patched"
+	}
+	if got := xdstest.ExtractListenerNames(cg.Listeners(second)); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected listeners %v after a patch, got %v", expected, got)
+	}
+}
+
+func TestSidecarOutboundListenersScopeCacheConflicts(t *testing.T) {
+	defer func(v bool) { features.EnableScopeCache = v }(features.EnableScopeCache)
+	features.EnableScopeCache = true
+
+	cg := NewConfigGenTest(t, TestOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: first
+  namespace: default
+spec:
+  hosts: [first.example.com]
+  ports:
+  - {name: tcp, number: 9000, protocol: TCP}
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: second
+  namespace: default
+spec:
+  hosts: [second.example.com]
+  ports:
+  - {name: tcp, number: 9000, protocol: TCP}
+  resolution: DNS
+`})
+	first := cg.SetupProxy(&model.Proxy{ID: "first.default", IPAddresses: []string{"1.1.1.1"}})
+	second := cg.SetupProxy(&model.Proxy{ID: "second.default", IPAddresses: []string{"1.1.1.2"}})
+
+	conflictProxies := func() []string {
+		var proxies []string
+		for _, status := range cg.PushContext().ProxyStatus[model.ProxyStatusConflictOutboundListenerTCPOverTCP.Name()] {
+			proxies = append(proxies, status.Proxy)
+		}
+		return proxies
+	}
+	cg.Listeners(first)
+	if got := conflictProxies(); !reflect.DeepEqual(got, []string{first.ID}) {
+		t.Fatalf("expected the conflict to be reported for %s, got %v", first.ID, got)
+	}
+	// The listeners of the second proxy are served from the cache, and the conflict is reported for it too.
+	cg.Listeners(second)
+	if got := conflictProxies(); !reflect.DeepEqual(got, []string{second.ID}) {
+		t.Fatalf("expected the conflict to be reported for %s, got %v", second.ID, got)
+	}
+}
+
+// TestScopeCacheExcludedMetadata checks that the proxies which only differ in the metadata excluded from the scope
+// cache key, as the replicas of a workload do, get the same listeners and routes, and share them.
+func TestScopeCacheExcludedMetadata(t *testing.T) {
+	cg := NewConfigGenTest(t, TestOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts: [a.example.com]
+  addresses: [2.2.2.2]
+  ports:
+  - {name: http, number: 80, protocol: HTTP}
+  - {name: tcp, number: 9000, protocol: TCP}
+  resolution: STATIC
+  endpoints:
+  - address: 3.3.3.3
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: vs
+  namespace: default
+spec:
+  hosts: [a.example.com]
+  http:
+  - match:
+    - sourceLabels: {app: a}
+    route:
+    - destination: {host: a.example.com}
+    timeout: 5s
+`})
+	proxy := func(name, ip string) *model.Proxy {
+		return cg.SetupProxy(&model.Proxy{
+			ID:          name + ".default",
+			IPAddresses: []string{"1.1.1.1"},
+			Metadata: &model.NodeMetadata{
+				Labels:           map[string]string{"app": "a"},
+				Annotations:      map[string]string{"kubectl.kubernetes.io/restartedAt": name},
+				InstanceIPs:      []string{ip},
+				NodeName:         name,
+				PlatformMetadata: map[string]string{"gcp_gce_instance_id": name},
+			},
+		})
+	}
+	first, second := proxy("first", "10.0.0.1"), proxy("second", "10.0.0.2")
+	if model.NewScopeCacheKey(first) != model.NewScopeCacheKey(second) {
+		t.Fatalf("expected the proxies to have the same scope cache key")
+	}
+	if first.SidecarScope != second.SidecarScope {
+		t.Fatalf("expected the proxies to have the same SidecarScope")
+	}
+
+	// Without the scope cache, the configs are generated for each proxy.
+	listeners, routes := cg.Listeners(first), cg.Routes(first)
+	if diff := cmp.Diff(listeners, cg.Listeners(second), protocmp.Transform()); diff != "" {
+		t.Fatalf("expected the proxies to get the same listeners, got diff %v", diff)
+	}
+	if diff := cmp.Diff(routes, cg.Routes(second), protocmp.Transform()); diff != "" {
+		t.Fatalf("expected the proxies to get the same routes, got diff %v", diff)
+	}
+
+	// With it, the second proxy is served the configs generated for the first one.
+	defer func(v bool) { features.EnableScopeCache = v }(features.EnableScopeCache)
+	features.EnableScopeCache = true
+	cg.Routes(first)
+	for _, resourceType := range []string{scopeCacheOutboundListeners, scopeCacheVirtualHosts} {
+		inputs := []string{"80", first.DNSDomain, ""}
+		if resourceType == scopeCacheOutboundListeners {
+			inputs = nil
+		}
+		if _, f := cg.PushContext().ScopeCache().Get(resourceType, model.NewScopeCacheKey(second, inputs...)); !f {
+			t.Fatalf("expected the %s of the first proxy to be shared", resourceType)
+		}
+	}
+	if diff := cmp.Diff(listeners, cg.Listeners(second), protocmp.Transform()); diff != "" {
+		t.Fatalf("expected the shared listeners to be the same, got diff %v", diff)
+	}
+	if diff := cmp.Diff(routes, cg.Routes(second), protocmp.Transform()); diff != "" {
+		t.Fatalf("expected the shared routes to be the same, got diff %v", diff)
+	}
+}
@@ -17,6 +17,7 @@ package v1alpha3
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -24,8 +25,10 @@ import (
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	connectionlimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/connection_limit/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	udpproxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	tracing "github.com/envoyproxy/go-control-plane/envoy/type/tracing/v3"
 	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
@@ -51,6 +54,7 @@ import (
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/protocol"
@@ -566,6 +570,94 @@ func TestOutboundListenerForHeadlessServices(t *testing.T) {
 	}
 }
 
+func TestInboundListenerClientCertDetails(t *testing.T) {
+	p := registry.NewPlugins([]string{plugin.Authn})[0]
+	sidecarConfig := &config.Config{
+		Meta: config.Meta{Name: "foo", Namespace: "not-default"},
+		Spec: &networking.Sidecar{
+			Ingress: []*networking.IstioIngressListener{
+				{
+					Port:            &networking.Port{Number: 8080, Protocol: "HTTP", Name: "http"},
+					DefaultEndpoint: "127.0.0.1:80",
+				},
+			},
+		},
+	}
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		forward     hcm.HttpConnectionManager_ForwardClientCertDetails
+		setCurrent  *hcm.HttpConnectionManager_SetCurrentClientCertDetails
+	}{
+		{
+			name:    "default",
+			forward: hcm.HttpConnectionManager_APPEND_FORWARD,
+			setCurrent: &hcm.HttpConnectionManager_SetCurrentClientCertDetails{
+				Subject: &wrappers.BoolValue{Value: true},
+				Uri:     true,
+				Dns:     true,
+			},
+		},
+		{
+			name: "annotations",
+			annotations: map[string]string{
+				constants.ForwardClientCertDetailsAnnotation:    "SANITIZE_SET",
+				constants.SetCurrentClientCertDetailsAnnotation: "Cert,URI",
+			},
+			forward: hcm.HttpConnectionManager_SANITIZE_SET,
+			setCurrent: &hcm.HttpConnectionManager_SetCurrentClientCertDetails{
+				Subject: &wrappers.BoolValue{Value: false},
+				Cert:    true,
+				Uri:     true,
+			},
+		},
+		{
+			name: "invalid annotations",
+			annotations: map[string]string{
+				constants.ForwardClientCertDetailsAnnotation:    "UNDEFINED",
+				constants.SetCurrentClientCertDetailsAnnotation: "Cert,Issuer",
+			},
+			forward: hcm.HttpConnectionManager_APPEND_FORWARD,
+			setCurrent: &hcm.HttpConnectionManager_SetCurrentClientCertDetails{
+				Subject: &wrappers.BoolValue{Value: true},
+				Uri:     true,
+				Dns:     true,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := getProxy()
+			proxy.Metadata.Annotations = tt.annotations
+			listeners := buildInboundListeners(t, p, proxy, sidecarConfig)
+			found := false
+			for _, l := range listeners {
+				for _, fc := range l.FilterChains {
+					for _, f := range fc.Filters {
+						if f.Name != wellknown.HTTPConnectionManager {
+							continue
+						}
+						found = true
+						h := &hcm.HttpConnectionManager{}
+						if err := getFilterConfig(f, h); err != nil {
+							t.Fatal(err)
+						}
+						if h.ForwardClientCertDetails != tt.forward {
+							t.Errorf("expected forward client cert details %v, got %v", tt.forward, h.ForwardClientCertDetails)
+						}
+						if !cmp.Equal(h.SetCurrentClientCertDetails, tt.setCurrent, protocmp.Transform()) {
+							t.Errorf("expected set current client cert details %v, got %v", tt.setCurrent, h.SetCurrentClientCertDetails)
+						}
+					}
+				}
+			}
+			if !found {
+				t.Fatal("no HTTP connection manager")
+			}
+		})
+	}
+}
+
 func TestInboundListenerConfig_HTTP10(t *testing.T) {
 	for _, p := range []*model.Proxy{getProxy(), &proxyHTTP10} {
 		// Add a service and verify it's config
@@ -831,6 +923,102 @@ func TestFilterChainMatchFields(t *testing.T) {
 	}
 }
 
+func TestInboundListenerConnectionLimit(t *testing.T) {
+	p := registry.NewPlugins([]string{plugin.Authn})[0]
+	sidecarConfig := &config.Config{
+		Meta: config.Meta{
+			Name:        "foo",
+			Namespace:   "not-default",
+			Annotations: map[string]string{constants.InboundConnectionLimitAnnotation: "8080=100,*=10"},
+		},
+		Spec: &networking.Sidecar{
+			Ingress: []*networking.IstioIngressListener{
+				{
+					Port:            &networking.Port{Number: 8080, Protocol: "HTTP", Name: "http"},
+					DefaultEndpoint: "127.0.0.1:80",
+				},
+				{
+					Port:            &networking.Port{Number: 9090, Protocol: "TCP", Name: "tcp"},
+					DefaultEndpoint: "127.0.0.1:90",
+				},
+			},
+		},
+	}
+	listeners := buildInboundListeners(t, p, getProxy(), sidecarConfig)
+	limits := map[uint32]uint64{}
+	for _, l := range listeners {
+		for _, fc := range l.FilterChains {
+			port := l.Address.GetSocketAddress().GetPortValue()
+			for _, f := range fc.Filters {
+				if f.Name != util.ConnectionLimitFilter {
+					continue
+				}
+				cl := &connectionlimit.ConnectionLimit{}
+				if err := f.GetTypedConfig().UnmarshalTo(cl); err != nil {
+					t.Fatal(err)
+				}
+				limits[port] = cl.MaxConnections.GetValue()
+			}
+		}
+	}
+	if want := map[uint32]uint64{8080: 100, 9090: 10}; !reflect.DeepEqual(limits, want) {
+		t.Fatalf("expected connection limits %v, got %v", want, limits)
+	}
+}
+
+func TestOutboundListenerEgressIdleTimeout(t *testing.T) {
+	p := &fakePlugin{}
+	sidecarConfig := &config.Config{
+		Meta: config.Meta{
+			Name:             "foo",
+			Namespace:        "not-default",
+			GroupVersionKind: gvk.Sidecar,
+			Annotations:      map[string]string{constants.EgressIdleTimeoutAnnotation: "9000=30s,*=1m"},
+		},
+		Spec: &networking.Sidecar{
+			Egress: []*networking.IstioEgressListener{
+				{
+					Port:  &networking.Port{Number: 9000, Protocol: "TCP", Name: "tcp"},
+					Hosts: []string{"default/*"},
+				},
+				{
+					Hosts: []string{"default/*"},
+				},
+			},
+		},
+	}
+	services := []*model.Service{
+		buildServiceWithPort("tcp.com", 9000, protocol.TCP, tnow),
+		buildService("http.com", wildcardIP, protocol.HTTP, tnow),
+	}
+	listeners := buildOutboundListeners(t, p, getProxy(), sidecarConfig, nil, services...)
+	timeouts := map[uint32]time.Duration{}
+	for _, l := range listeners {
+		port := l.Address.GetSocketAddress().GetPortValue()
+		for _, fc := range l.FilterChains {
+			for _, f := range fc.Filters {
+				switch f.Name {
+				case wellknown.TCPProxy:
+					tp := &tcp.TcpProxy{}
+					if err := f.GetTypedConfig().UnmarshalTo(tp); err != nil {
+						t.Fatal(err)
+					}
+					timeouts[port] = tp.IdleTimeout.AsDuration()
+				case wellknown.HTTPConnectionManager:
+					cm := &hcm.HttpConnectionManager{}
+					if err := f.GetTypedConfig().UnmarshalTo(cm); err != nil {
+						t.Fatal(err)
+					}
+					timeouts[port] = cm.CommonHttpProtocolOptions.GetIdleTimeout().AsDuration()
+				}
+			}
+		}
+	}
+	if want := map[uint32]time.Duration{9000: 30 * time.Second, 8080: time.Minute}; !reflect.DeepEqual(timeouts, want) {
+		t.Fatalf("expected idle timeouts %v, got %v", want, timeouts)
+	}
+}
+
 func TestInboundListener_PrivilegedPorts(t *testing.T) {
 	// Verify that an explicit ingress listener will not bind to privileged ports
 	// if proxy is not using Iptables and cannot bind to privileged ports (1-1023).
@@ -1032,6 +1220,173 @@ func testPrivilegedPorts(t *testing.T, buildListeners func(t *testing.T, proxy *
 	}
 }
 
+func TestOutboundListenerHTTP3(t *testing.T) {
+	http3Service := buildService("http3.com", "1.2.3.4", protocol.HTTP, tnow)
+	http3Service.Attributes.HTTP3 = true
+	services := []*model.Service{
+		http3Service,
+		buildServiceWithPort("http.com", 9090, protocol.HTTP, tnow),
+	}
+
+	cases := []struct {
+		name          string
+		enableQUIC    bool
+		interception  model.TrafficInterceptionMode
+		wantListeners []string
+	}{
+		{
+			name:          "disabled",
+			interception:  model.InterceptionNone,
+			wantListeners: []string{"127.0.0.1_8080", "127.0.0.1_9090"},
+		},
+		{
+			name:          "captured",
+			enableQUIC:    true,
+			interception:  model.InterceptionRedirect,
+			wantListeners: []string{"0.0.0.0_8080", "0.0.0.0_9090"},
+		},
+		{
+			name:          "bound to port",
+			enableQUIC:    true,
+			interception:  model.InterceptionNone,
+			wantListeners: []string{"127.0.0.1_8080", "127.0.0.1_9090", "udp_127.0.0.1_8080"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultValue := features.EnableQUICListeners
+			features.EnableQUICListeners = tt.enableQUIC
+			defer func() { features.EnableQUICListeners = defaultValue }()
+
+			proxy := getProxy()
+			proxy.Metadata.InterceptionMode = tt.interception
+			listeners := buildOutboundListeners(t, &fakePlugin{}, proxy, nil, nil, services...)
+			got := make([]string, 0, len(listeners))
+			for _, l := range listeners {
+				got = append(got, l.Name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.wantListeners) {
+				t.Fatalf("got listeners %v, want %v", got, tt.wantListeners)
+			}
+
+			quic := xdstest.ExtractListener("udp_127.0.0.1_8080", listeners)
+			if quic == nil {
+				return
+			}
+			if quic.UdpListenerConfig == nil || quic.FilterChains[0].TransportSocket.GetName() != util.EnvoyQUICSocketName {
+				t.Fatalf("expected a QUIC listener, got %v", quic)
+			}
+			conn := &hcm.HttpConnectionManager{}
+			if err := getFilterConfig(quic.FilterChains[0].Filters[len(quic.FilterChains[0].Filters)-1], conn); err != nil {
+				t.Fatal(err)
+			}
+			if conn.CodecType != hcm.HttpConnectionManager_HTTP3 || conn.GetRds().GetRouteConfigName() != "8080" {
+				t.Fatalf("expected HTTP/3 connection manager for route 8080, got %v", conn)
+			}
+		})
+	}
+}
+
+func TestOutboundListenerUDP(t *testing.T) {
+	services := []*model.Service{
+		buildServiceWithPort("dns.com", 5353, protocol.UDP, tnow),
+		buildServiceWithPort("kube-dns.com", 53, protocol.UDP, tnow),
+		buildServiceWithPort("syslog.com", 514, protocol.UDP, tnow),
+		buildServiceWithPort("ntp-a.com", 1123, protocol.UDP, tnow),
+		buildServiceWithPort("ntp-b.com", 1123, protocol.UDP, tnow),
+		buildServiceWithPort("http.com", 9090, protocol.HTTP, tnow),
+	}
+
+	cases := []struct {
+		name          string
+		enableUDP     bool
+		dnsCapture    bool
+		annotation    string
+		wantListeners []string
+	}{
+		{
+			name:          "disabled",
+			annotation:    "5353",
+			wantListeners: []string{"0.0.0.0_9090"},
+		},
+		{
+			name:          "not annotated",
+			enableUDP:     true,
+			wantListeners: []string{"0.0.0.0_9090"},
+		},
+		{
+			name:          "annotated",
+			enableUDP:     true,
+			annotation:    "5353,5353,6000",
+			wantListeners: []string{"0.0.0.0_9090", "udp_127.0.0.1_5353"},
+		},
+		{
+			name:          "privileged ports",
+			enableUDP:     true,
+			annotation:    "53,514",
+			wantListeners: []string{"0.0.0.0_9090", "udp_127.0.0.1_16053", "udp_127.0.0.1_16514"},
+		},
+		{
+			name:          "dns capture",
+			enableUDP:     true,
+			dnsCapture:    true,
+			annotation:    "53,514",
+			wantListeners: []string{"0.0.0.0_9090", "udp_127.0.0.1_16514"},
+		},
+		{
+			// the traffic cannot be told apart, so it is not proxied to either service
+			name:          "port of several services",
+			enableUDP:     true,
+			annotation:    "1123,5353",
+			wantListeners: []string{"0.0.0.0_9090", "udp_127.0.0.1_5353"},
+		},
+		{
+			name:          "ports redirected to the same listener port",
+			enableUDP:     true,
+			annotation:    "53,16053,5353",
+			wantListeners: []string{"0.0.0.0_9090", "udp_127.0.0.1_5353"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultValue := features.EnableUDPProxy
+			features.EnableUDPProxy = tt.enableUDP
+			defer func() { features.EnableUDPProxy = defaultValue }()
+
+			proxy := getProxy()
+			if tt.annotation != "" {
+				proxy.Metadata.Annotations = map[string]string{constants.OutboundUDPPortsAnnotation: tt.annotation}
+			}
+			proxy.Metadata.DNSCapture = model.StringBool(tt.dnsCapture)
+			listeners := buildOutboundListeners(t, &fakePlugin{}, proxy, nil, nil, services...)
+			got := make([]string, 0, len(listeners))
+			for _, l := range listeners {
+				got = append(got, l.Name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.wantListeners) {
+				t.Fatalf("got listeners %v, want %v", got, tt.wantListeners)
+			}
+
+			udp := xdstest.ExtractListener("udp_127.0.0.1_5353", listeners)
+			if udp == nil {
+				return
+			}
+			if udp.UdpListenerConfig == nil || len(udp.ListenerFilters) != 1 || udp.ListenerFilters[0].Name != util.UDPProxyFilter {
+				t.Fatalf("expected a UDP proxy listener, got %v", udp)
+			}
+			cfg := &udpproxy.UdpProxyConfig{}
+			if err := udp.ListenerFilters[0].GetTypedConfig().UnmarshalTo(cfg); err != nil {
+				t.Fatal(err)
+			}
+			if want := "outbound|5353||dns.com"; cfg.GetCluster() != want {
+				t.Fatalf("got cluster %q, want %q", cfg.GetCluster(), want)
+			}
+		})
+	}
+}
+
 func testOutboundListenerConflictWithSniffingDisabled(t *testing.T, services ...*model.Service) {
 	t.Helper()
 
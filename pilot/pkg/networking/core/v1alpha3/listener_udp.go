@@ -0,0 +1,164 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	udpproxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
+	istioroute "istio.io/istio/pilot/pkg/networking/core/v1alpha3/route"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/pkg/log"
+)
+
+// buildSidecarOutboundUDPListeners builds a UDP proxy listener on localhost for each port of the
+// experimental.istio.io/outbound-udp-ports annotation of the proxy, to which iptables redirects the outbound UDP
+// traffic of the workload. The traffic of a port is proxied to the service visible to the proxy declaring a UDP port
+// with that number. As the redirected traffic has no destination address left to tell several such services apart,
+// no listener is built for a port declared by several services, nor for ports redirected to the same listener port,
+// rather than proxying their traffic to the wrong service; the analyzers report both.
+// Port 53 is skipped when the proxy captures DNS, as iptables redirects it to the DNS proxy of the agent.
+func buildSidecarOutboundUDPListeners(node *model.Proxy, push *model.PushContext) []*listener.Listener {
+	ports := node.OutboundUDPPorts()
+	if len(ports) == 0 {
+		return nil
+	}
+	_, localhost := getActualWildcardAndLocalHost(node)
+	// The annotated ports by listener port.
+	listenerPorts := make(map[int][]int, len(ports))
+	for _, port := range ports {
+		listenerPort := model.OutboundUDPListenerPort(port)
+		if !containsPort(listenerPorts[listenerPort], port) {
+			listenerPorts[listenerPort] = append(listenerPorts[listenerPort], port)
+		}
+	}
+	listeners := make([]*listener.Listener, 0, len(ports))
+	seen := make(map[int]struct{}, len(ports))
+	for _, port := range ports {
+		if _, f := seen[port]; f {
+			continue
+		}
+		seen[port] = struct{}{}
+		if port == 53 && bool(node.Metadata.DNSCapture) {
+			log.Debugf("buildSidecarOutboundUDPListeners: UDP port 53 is captured for DNS for node %s", node.ID)
+			continue
+		}
+		listenerPort := model.OutboundUDPListenerPort(port)
+		if conflicting := listenerPorts[listenerPort]; len(conflicting) > 1 {
+			log.Warnf("buildSidecarOutboundUDPListeners: UDP ports %v are all redirected to port %d for node %s, skipping them",
+				conflicting, listenerPort, node.ID)
+			continue
+		}
+
+		var services []*model.Service
+		var servicePort *model.Port
+		for _, svc := range node.SidecarScope.Services() {
+			if p, f := svc.Ports.GetUDPByPort(port); f {
+				services = append(services, svc)
+				servicePort = p
+			}
+		}
+		if len(services) == 0 {
+			log.Debugf("buildSidecarOutboundUDPListeners: no UDP service on port %d for node %s", port, node.ID)
+			continue
+		}
+		if len(services) > 1 {
+			hostnames := make([]string, 0, len(services))
+			for _, svc := range services {
+				hostnames = append(hostnames, string(svc.Hostname))
+			}
+			log.Warnf("buildSidecarOutboundUDPListeners: UDP port %d is declared by services %v visible to node %s, skipping it",
+				port, hostnames, node.ID)
+			continue
+		}
+		service := services[0]
+
+		clusterName := model.BuildSubsetKey(model.TrafficDirectionOutbound, "", service.Hostname, port)
+		statPrefix := clusterName
+		if len(push.Mesh.OutboundClusterStatName) != 0 {
+			statPrefix = util.BuildStatPrefix(push.Mesh.OutboundClusterStatName, string(service.Hostname), "", servicePort, &service.Attributes)
+		}
+		listeners = append(listeners, buildUDPProxyListener(node, localhost, listenerPort, clusterName, statPrefix))
+	}
+	return listeners
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// buildGatewayUDPListener builds a UDP proxy listener for gateway servers with the UDP protocol. The datagrams are
+// proxied to the destination of the first VirtualService TCP route matching the servers. As the UDP proxy supports a
+// single cluster, no listener is built if the route has several destinations, rather than ignoring their weights;
+// the analyzers report such routes.
+func buildGatewayUDPListener(node *model.Proxy, push *model.PushContext, bind string, port model.ServerPort,
+	servers *model.MergedServers) *listener.Listener {
+	if servers == nil {
+		return nil
+	}
+	for _, server := range servers.Servers {
+		gatewayName := node.MergedGateway.GatewayNameForServer[server]
+		route, _ := gatewayTCPRouteForServer(node, push, server, gatewayName)
+		if len(route) == 0 {
+			continue
+		}
+		if len(route) > 1 {
+			log.Warnf("buildGatewayUDPListener: the route of gateway %s for UDP port %d has %d destinations, "+
+				"but the UDP proxy supports a single one, skipping the port for node %s", gatewayName, port.Number, len(route), node.ID)
+			return nil
+		}
+		destination := route[0].Destination
+		service := push.ServiceForHostname(node, host.Name(destination.Host))
+		clusterName := istioroute.GetDestinationCluster(destination, service, int(port.Number))
+		return buildUDPProxyListener(node, bind, int(port.Number), clusterName, clusterName)
+	}
+	log.Warnf("buildGatewayUDPListener: no virtual service route for UDP port %d of node %s", port.Number, node.ID)
+	return nil
+}
+
+// buildUDPProxyListener builds a listener bound to the address, proxying its UDP datagrams to the cluster.
+func buildUDPProxyListener(node *model.Proxy, bind string, port int, clusterName, statPrefix string) *listener.Listener {
+	udpProxy := &udpproxy.UdpProxyConfig{
+		StatPrefix:     statPrefix,
+		RouteSpecifier: &udpproxy.UdpProxyConfig_Cluster{Cluster: clusterName},
+	}
+	if idleTimeout, err := time.ParseDuration(node.Metadata.IdleTimeout); err == nil {
+		udpProxy.IdleTimeout = durationpb.New(idleTimeout)
+	}
+	return &listener.Listener{
+		Name:             getListenerName(bind, port, istionetworking.TransportProtocolUDP),
+		Address:          util.BuildNetworkAddress(bind, uint32(port), istionetworking.TransportProtocolUDP),
+		TrafficDirection: core.TrafficDirection_OUTBOUND,
+		ListenerFilters: []*listener.ListenerFilter{{
+			Name:       util.UDPProxyFilter,
+			ConfigType: &listener.ListenerFilter_TypedConfig{TypedConfig: util.MessageToAny(udpProxy)},
+		}},
+		UdpListenerConfig: &listener.UdpListenerConfig{
+			DownstreamSocketConfig: &core.UdpSocketConfig{},
+		},
+	}
+}
@@ -28,6 +28,24 @@ import (
 	"istio.io/istio/pilot/pkg/networking/util"
 )
 
+// LocalityWeight returns the load balancing weight of a locality, the sum of the weights of its endpoints.
+// Endpoints without a weight count as 1, as they do in Envoy. The sum is capped at the maximum uint32, in
+// which case overflow is true.
+func LocalityWeight(eps []*endpoint.LbEndpoint) (weight uint32, overflow bool) {
+	var sum uint64
+	for _, ep := range eps {
+		if w := ep.GetLoadBalancingWeight(); w != nil {
+			sum += uint64(w.GetValue())
+		} else {
+			sum++
+		}
+	}
+	if sum > math.MaxUint32 {
+		return math.MaxUint32, true
+	}
+	return uint32(sum), false
+}
+
 func GetLocalityLbSetting(
 	mesh *v1alpha3.LocalityLoadBalancerSetting,
 	destrule *v1alpha3.LocalityLoadBalancerSetting,
@@ -107,7 +125,7 @@ func applyLocalityWeight(
 			for locality, weight := range localityWeightSetting.To {
 				// index -> original weight
 				destLocMap := map[int]uint32{}
-				totalWeight := uint32(0)
+				totalWeight := uint64(0)
 				for i, ep := range loadAssignment.Endpoints {
 					if _, exist := misMatched[i]; exist {
 						if util.LocalityMatch(ep.Locality, locality) {
@@ -117,14 +135,14 @@ func applyLocalityWeight(
 							} else {
 								destLocMap[i] = 1
 							}
-							totalWeight += destLocMap[i]
+							totalWeight += uint64(destLocMap[i])
 						}
 					}
 				}
 				// in case wildcard dest matching multi groups of endpoints
 				// the load balancing weight for a locality is divided by the sum of the weights of all localities
 				for index, originalWeight := range destLocMap {
-					destWeight := float64(originalWeight*weight) / float64(totalWeight)
+					destWeight := float64(originalWeight) * float64(weight) / float64(totalWeight)
 					if destWeight > 0 {
 						loadAssignment.Endpoints[index].LoadBalancingWeight = &wrappers.UInt32Value{
 							Value: uint32(math.Ceil(destWeight)),
@@ -273,11 +291,10 @@ func applyPriorityFailoverPerLocality(
 		out[i] = util.CloneLocalityLbEndpoint(ep.LocalityLbEndpoints)
 		out[i].LbEndpoints = nil
 		out[i].Priority = uint32(priority)
-		var weight uint32
 		for _, index := range priorityMap[priority] {
 			out[i].LbEndpoints = append(out[i].LbEndpoints, ep.LocalityLbEndpoints.LbEndpoints[index])
-			weight += ep.LocalityLbEndpoints.LbEndpoints[index].GetLoadBalancingWeight().GetValue()
 		}
+		weight, _ := LocalityWeight(out[i].LbEndpoints)
 		// reset weight
 		out[i].LoadBalancingWeight = &wrappers.UInt32Value{
 			Value: weight,
@@ -15,6 +15,7 @@
 package loadbalancer
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
@@ -80,6 +81,31 @@ func TestApplyLocalitySetting(t *testing.T) {
 		}
 	})
 
+	t.Run("Distribute with endpoint weights", func(t *testing.T) {
+		env := buildEnvForClustersWithDistribute([]*networking.LocalityLoadBalancerSetting_Distribute{
+			{
+				From: "region1/zone1/subzone1",
+				To: map[string]uint32{
+					"region1/zone1/subzone1": 80,
+					"region1/zone1/subzone2": 15,
+					"region1/zone1/subzone3": 5,
+				},
+			},
+		})
+		cluster := buildFakeCluster()
+		// The weights of the localities are the sums of large endpoint weights.
+		cluster.LoadAssignment.Endpoints[0].LoadBalancingWeight = &wrappers.UInt32Value{Value: 100000000}
+		cluster.LoadAssignment.Endpoints[1].LoadBalancingWeight = &wrappers.UInt32Value{Value: 300000000}
+		ApplyLocalityLBSetting(cluster.LoadAssignment, nil, locality, nil, env.Mesh().LocalityLbSetting, true)
+		weights := make([]int, 0)
+		for _, localityEndpoint := range cluster.LoadAssignment.Endpoints {
+			weights = append(weights, int(localityEndpoint.LoadBalancingWeight.GetValue()))
+		}
+		if expected := []int{20, 60, 15, 5, 0, 0, 0}; !reflect.DeepEqual(weights, expected) {
+			t.Errorf("Got weights %v expected %v", weights, expected)
+		}
+	})
+
 	t.Run("Failover: all priorities", func(t *testing.T) {
 		g := NewWithT(t)
 		env := buildEnvForClustersWithFailover()
@@ -497,6 +523,31 @@ func TestApplyLocalitySetting(t *testing.T) {
 	})
 }
 
+func TestLocalityWeight(t *testing.T) {
+	weighted := func(w uint32) *endpoint.LbEndpoint {
+		return &endpoint.LbEndpoint{LoadBalancingWeight: &wrappers.UInt32Value{Value: w}}
+	}
+	cases := []struct {
+		name     string
+		eps      []*endpoint.LbEndpoint
+		weight   uint32
+		overflow bool
+	}{
+		{name: "empty", weight: 0},
+		{name: "weighted", eps: []*endpoint.LbEndpoint{weighted(3), weighted(5)}, weight: 8},
+		{name: "unweighted count as 1", eps: []*endpoint.LbEndpoint{weighted(3), {}}, weight: 4},
+		{name: "overflow", eps: []*endpoint.LbEndpoint{weighted(math.MaxUint32), weighted(1)}, weight: math.MaxUint32, overflow: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			weight, overflow := LocalityWeight(tt.eps)
+			if weight != tt.weight || overflow != tt.overflow {
+				t.Errorf("got %d, %v, want %d, %v", weight, overflow, tt.weight, tt.overflow)
+			}
+		})
+	}
+}
+
 func TestGetLocalityLbSetting(t *testing.T) {
 	// dummy config for test
 	failover := []*networking.LocalityLoadBalancerSetting_Failover{nil}
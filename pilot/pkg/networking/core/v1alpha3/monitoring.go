@@ -0,0 +1,106 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"time"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/pkg/monitoring"
+)
+
+const (
+	routeConfigPhase  = "route_config"
+	virtualHostsPhase = "virtual_hosts"
+)
+
+func init() {
+	monitoring.MustRegister(rdsBuildTime, rdsCacheHits, rdsCacheMisses)
+}
+
+var (
+	routeTag     = monitoring.MustCreateLabel("route")
+	phaseTag     = monitoring.MustCreateLabel("phase")
+	proxyTypeTag = monitoring.MustCreateLabel("proxy_type")
+
+	rdsBuildTime = monitoring.NewDistribution(
+		"pilot_rds_build_seconds",
+		"Time in seconds Pilot takes to build an outbound route configuration, including cache hits.",
+		[]float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		monitoring.WithLabels(routeTag, phaseTag),
+	)
+
+	rdsCacheHits = monitoring.NewSum(
+		"pilot_rds_cache_hits",
+		"Total number of route configurations served from the RDS cache.",
+		monitoring.WithLabels(proxyTypeTag),
+	)
+
+	rdsCacheMisses = monitoring.NewSum(
+		"pilot_rds_cache_misses",
+		"Total number of route configurations built because they were not in the RDS cache.",
+		monitoring.WithLabels(proxyTypeTag),
+	)
+)
+
+// recordRDSCacheResults records the RDS cache hits and misses of building the routes of a proxy.
+func recordRDSCacheResults(proxyType model.NodeType, hits, misses int) {
+	if hits > 0 {
+		rdsCacheHits.With(proxyTypeTag.Value(string(proxyType))).RecordInt(int64(hits))
+	}
+	if misses > 0 {
+		rdsCacheMisses.With(proxyTypeTag.Value(string(proxyType))).RecordInt(int64(misses))
+	}
+}
+
+// recordRDSBuildTime starts timing a phase of building the given route and returns the function that records it.
+func recordRDSBuildTime(phase, routeName string) func() {
+	if !features.EnableRDSBuildMetrics {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		rdsBuildTime.With(routeTag.Value(metricRouteName(routeName)), phaseTag.Value(phase)).
+			Record(time.Since(start).Seconds())
+	}
+}
+
+// metricRouteName bounds the cardinality of the route label. Sniffed routes are named host:port, one per service,
+// so they are reported by port.
+func metricRouteName(routeName string) string {
+	if strings.HasPrefix(routeName, model.UnixAddressPrefix) {
+		return routeName
+	}
+	if i := strings.LastIndexByte(routeName, ':'); i != -1 {
+		return routeName[i+1:]
+	}
+	return routeName
+}
+
+// profileRoute runs f with pprof labels and a runtime/trace region for the route, when enabled.
+func profileRoute(routeName string, f func()) {
+	if !features.EnableRDSProfilingLabels {
+		f()
+		return
+	}
+	pprof.Do(context.Background(), pprof.Labels("route", routeName), func(ctx context.Context) {
+		trace.WithRegion(ctx, "rds:"+routeName, f)
+	})
+}
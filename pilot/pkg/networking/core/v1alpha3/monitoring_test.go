@@ -0,0 +1,33 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"testing"
+)
+
+func TestMetricRouteName(t *testing.T) {
+	cases := map[string]string{
+		"80":                   "80",
+		"http_proxy":           "http_proxy",
+		"foo.example.com:8080": "8080",
+		"unix:///var/run/sock": "unix:///var/run/sock",
+	}
+	for in, want := range cases {
+		if got := metricRouteName(in); got != want {
+			t.Errorf("metricRouteName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -17,14 +17,17 @@ package v1alpha3
 import (
 	"time"
 
+	kafka "github.com/envoyproxy/go-control-plane/contrib/envoy/extensions/filters/network/kafka_broker/v3"
 	mysql "github.com/envoyproxy/go-control-plane/contrib/envoy/extensions/filters/network/mysql_proxy/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	connectionlimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/connection_limit/v3"
 	mongo "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/mongo_proxy/v3"
 	redis "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/redis_proxy/v3"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	hashpolicy "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"google.golang.org/protobuf/types/known/durationpb"
+	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
@@ -34,6 +37,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/util"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
 )
@@ -76,7 +80,7 @@ func buildInboundNetworkFilters(push *model.PushContext, proxy *model.Proxy, ins
 	var filters []*listener.Filter
 	filters = append(filters, buildMetadataExchangeNetworkFilters(istionetworking.ListenerClassSidecarInbound)...)
 	filters = append(filters, buildMetricsNetworkFilters(push, proxy, istionetworking.ListenerClassSidecarInbound)...)
-	filters = append(filters, buildNetworkFiltersStack(instance.ServicePort, tcpFilter, statPrefix, clusterName)...)
+	filters = append(filters, buildNetworkFiltersStack(instance.ServicePort, tcpFilter, statPrefix, clusterName, protocolFiltersFor(nil))...)
 	return filters
 }
 
@@ -95,7 +99,7 @@ func setAccessLogAndBuildTCPFilter(push *model.PushContext, node *model.Proxy, c
 // buildOutboundNetworkFiltersWithSingleDestination takes a single cluster name
 // and builds a stack of network filters.
 func buildOutboundNetworkFiltersWithSingleDestination(push *model.PushContext, node *model.Proxy,
-	statPrefix, clusterName, subsetName string, port *model.Port, destinationRule *networking.DestinationRule) []*listener.Filter {
+	statPrefix, clusterName, subsetName string, port *model.Port, destinationRule *config.Config) []*listener.Filter {
 	tcpProxy := &tcp.TcpProxy{
 		StatPrefix:       statPrefix,
 		ClusterSpecifier: &tcp.TcpProxy_Cluster{Cluster: clusterName},
@@ -105,20 +109,20 @@ func buildOutboundNetworkFiltersWithSingleDestination(push *model.PushContext, n
 	if err == nil {
 		tcpProxy.IdleTimeout = durationpb.New(idleTimeout)
 	}
-	maybeSetHashPolicy(destinationRule, tcpProxy, subsetName)
+	maybeSetHashPolicy(CastDestinationRule(destinationRule), tcpProxy, subsetName)
 	tcpFilter := setAccessLogAndBuildTCPFilter(push, node, tcpProxy)
 
 	var filters []*listener.Filter
 	filters = append(filters, buildMetadataExchangeNetworkFilters(model.OutboundListenerClass(node.Type))...)
 	filters = append(filters, buildMetricsNetworkFilters(push, node, model.OutboundListenerClass(node.Type))...)
-	filters = append(filters, buildNetworkFiltersStack(port, tcpFilter, statPrefix, clusterName)...)
+	filters = append(filters, buildNetworkFiltersStack(port, tcpFilter, statPrefix, clusterName, protocolFiltersFor(destinationRule))...)
 	return filters
 }
 
 // buildOutboundNetworkFiltersWithWeightedClusters takes a set of weighted
 // destination routes and builds a stack of network filters.
 func buildOutboundNetworkFiltersWithWeightedClusters(node *model.Proxy, routes []*networking.RouteDestination,
-	push *model.PushContext, port *model.Port, configMeta config.Meta, destinationRule *networking.DestinationRule) []*listener.Filter {
+	push *model.PushContext, port *model.Port, configMeta config.Meta, destinationRule *config.Config) []*listener.Filter {
 	statPrefix := configMeta.Name + "." + configMeta.Namespace
 	clusterSpecifier := &tcp.TcpProxy_WeightedClusters{
 		WeightedClusters: &tcp.TcpProxy_WeightedCluster{},
@@ -146,7 +150,7 @@ func buildOutboundNetworkFiltersWithWeightedClusters(node *model.Proxy, routes [
 	}
 
 	// For weighted clusters set hash policy if any of the upstream destinations have sourceIP.
-	maybeSetHashPolicy(destinationRule, tcpProxy, "")
+	maybeSetHashPolicy(CastDestinationRule(destinationRule), tcpProxy, "")
 
 	// TODO: Need to handle multiple cluster names for Redis
 	clusterName := clusterSpecifier.WeightedClusters.Clusters[0].Name
@@ -155,7 +159,7 @@ func buildOutboundNetworkFiltersWithWeightedClusters(node *model.Proxy, routes [
 	var filters []*listener.Filter
 	filters = append(filters, buildMetadataExchangeNetworkFilters(model.OutboundListenerClass(node.Type))...)
 	filters = append(filters, buildMetricsNetworkFilters(push, node, model.OutboundListenerClass(node.Type))...)
-	filters = append(filters, buildNetworkFiltersStack(port, tcpFilter, statPrefix, clusterName)...)
+	filters = append(filters, buildNetworkFiltersStack(port, tcpFilter, statPrefix, clusterName, protocolFiltersFor(destinationRule))...)
 	return filters
 }
 
@@ -186,29 +190,74 @@ func maybeSetHashPolicy(destinationRule *networking.DestinationRule, tcpProxy *t
 	}
 }
 
+// protocolFilters selects the protocol specific filters built for Mongo, MySQL and Redis ports.
+type protocolFilters struct {
+	mongo          bool
+	mysql          bool
+	redis          bool
+	kafka          bool
+	redisOpTimeout time.Duration
+}
+
+// protocolFiltersFor returns the protocol filters enabled by the PILOT_ENABLE_*_FILTER flags or by the
+// experimental.istio.io/protocol-filter annotation of the destination rule, which may be nil.
+func protocolFiltersFor(destinationRule *config.Config) protocolFilters {
+	filters := protocolFilters{
+		mongo:          features.EnableMongoFilter,
+		mysql:          features.EnableMysqlFilter,
+		redis:          features.EnableRedisFilter,
+		kafka:          features.EnableKafkaFilter,
+		redisOpTimeout: redisOpTimeout,
+	}
+	if destinationRule == nil {
+		return filters
+	}
+	switch destinationRule.Annotations[constants.ProtocolFilterAnnotation] {
+	case constants.ProtocolFilterRouting:
+		filters.redis = true
+		filters.mongo, filters.mysql, filters.kafka = true, true, true
+	case constants.ProtocolFilterStats:
+		filters.mongo, filters.mysql, filters.kafka = true, true, true
+	}
+	if value, f := destinationRule.Annotations[constants.RedisOpTimeoutAnnotation]; f {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			filters.redisOpTimeout = d
+		} else {
+			ignoreInvalidAnnotation(destinationRule, constants.RedisOpTimeoutAnnotation, value)
+		}
+	}
+	return filters
+}
+
 // buildNetworkFiltersStack builds a slice of network filters based on
 // the protocol in use and the given TCP filter instance.
-func buildNetworkFiltersStack(port *model.Port, tcpFilter *listener.Filter, statPrefix string, clusterName string) []*listener.Filter {
+func buildNetworkFiltersStack(port *model.Port, tcpFilter *listener.Filter, statPrefix string, clusterName string,
+	enabled protocolFilters) []*listener.Filter {
 	filterstack := make([]*listener.Filter, 0)
 	switch port.Protocol {
 	case protocol.Mongo:
-		if features.EnableMongoFilter {
+		if enabled.mongo {
 			filterstack = append(filterstack, buildMongoFilter(statPrefix), tcpFilter)
 		} else {
 			filterstack = append(filterstack, tcpFilter)
 		}
 	case protocol.Redis:
-		if features.EnableRedisFilter {
+		if enabled.redis {
 			// redis filter has route config, it is a terminating filter, no need append tcp filter.
-			filterstack = append(filterstack, buildRedisFilter(statPrefix, clusterName))
+			filterstack = append(filterstack, buildRedisFilter(statPrefix, clusterName, enabled.redisOpTimeout))
 		} else {
 			filterstack = append(filterstack, tcpFilter)
 		}
 	case protocol.MySQL:
-		if features.EnableMysqlFilter {
+		if enabled.mysql {
 			filterstack = append(filterstack, buildMySQLFilter(statPrefix))
 		}
 		filterstack = append(filterstack, tcpFilter)
+	case protocol.Kafka:
+		if enabled.kafka {
+			filterstack = append(filterstack, buildKafkaBrokerFilter(statPrefix))
+		}
+		filterstack = append(filterstack, tcpFilter)
 	default:
 		filterstack = append(filterstack, tcpFilter)
 	}
@@ -223,9 +272,9 @@ func buildOutboundNetworkFilters(node *model.Proxy,
 	routes []*networking.RouteDestination, push *model.PushContext,
 	port *model.Port, configMeta config.Meta) []*listener.Filter {
 	service := push.ServiceForHostname(node, host.Name(routes[0].Destination.Host))
-	var destinationRule *networking.DestinationRule
+	var destinationRule *config.Config
 	if service != nil {
-		destinationRule = CastDestinationRule(node.SidecarScope.DestinationRule(service.Hostname))
+		destinationRule = node.SidecarScope.DestinationRule(service.Hostname)
 	}
 	if len(routes) == 1 {
 		clusterName := istioroute.GetDestinationCluster(routes[0].Destination, service, port.Port)
@@ -277,12 +326,12 @@ func buildOutboundAutoPassthroughFilterStack(push *model.PushContext, node *mode
 // buildRedisFilter builds an outbound Envoy RedisProxy filter.
 // Currently, if multiple clusters are defined, one of them will be picked for
 // configuring the Redis proxy.
-func buildRedisFilter(statPrefix, clusterName string) *listener.Filter {
+func buildRedisFilter(statPrefix, clusterName string, opTimeout time.Duration) *listener.Filter {
 	redisProxy := &redis.RedisProxy{
 		LatencyInMicros: true,       // redis latency stats are captured in micro seconds which is typically the case.
 		StatPrefix:      statPrefix, // redis stats are prefixed with redis.<statPrefix> by Envoy
 		Settings: &redis.RedisProxy_ConnPoolSettings{
-			OpTimeout: durationpb.New(redisOpTimeout), // TODO: Make this user configurable
+			OpTimeout: durationpb.New(opTimeout),
 		},
 		PrefixRoutes: &redis.RedisProxy_PrefixRoutes{
 			CatchAllRoute: &redis.RedisProxy_PrefixRoutes_Route{
@@ -299,6 +348,33 @@ func buildRedisFilter(statPrefix, clusterName string) *listener.Filter {
 	return out
 }
 
+// buildKafkaBrokerFilter builds an Envoy KafkaBroker filter, decoding the Kafka requests and responses for stats.
+func buildKafkaBrokerFilter(statPrefix string) *listener.Filter {
+	kafkaBroker := &kafka.KafkaBroker{
+		StatPrefix: statPrefix, // Kafka stats are prefixed with kafka.<statPrefix> by Envoy.
+	}
+
+	return &listener.Filter{
+		Name:       util.KafkaBrokerFilter,
+		ConfigType: &listener.Filter_TypedConfig{TypedConfig: util.MessageToAny(kafkaBroker)},
+	}
+}
+
+// buildConnectionLimitFilter builds an inbound Envoy ConnectionLimit filter, closing the connections of the filter
+// chain beyond the limit. Envoy counts the connections of each filter chain apart, so the limit is not shared by the
+// filter chains of a port.
+func buildConnectionLimitFilter(statPrefix string, maxConnections uint64) *listener.Filter {
+	connectionLimit := &connectionlimit.ConnectionLimit{
+		StatPrefix:     statPrefix, // connection limit stats are prefixed with connection_limit.<statPrefix> by Envoy.
+		MaxConnections: &wrappers.UInt64Value{Value: maxConnections},
+	}
+
+	return &listener.Filter{
+		Name:       util.ConnectionLimitFilter,
+		ConfigType: &listener.Filter_TypedConfig{TypedConfig: util.MessageToAny(connectionLimit)},
+	}
+}
+
 // buildMySQLFilter builds an outbound Envoy MySQLProxy filter.
 func buildMySQLFilter(statPrefix string) *listener.Filter {
 	mySQLProxy := &mysql.MySQLProxy{
@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	kafka "github.com/envoyproxy/go-control-plane/contrib/envoy/extensions/filters/network/kafka_broker/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	redis "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/redis_proxy/v3"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
@@ -26,14 +27,17 @@ import (
 	"google.golang.org/protobuf/types/known/durationpb"
 
 	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/collections"
 )
 
 func TestBuildRedisFilter(t *testing.T) {
-	redisFilter := buildRedisFilter("redis", "redis-cluster")
+	redisFilter := buildRedisFilter("redis", "redis-cluster", redisOpTimeout)
 	if redisFilter.Name != wellknown.RedisProxy {
 		t.Errorf("redis filter name is %s not %s", redisFilter.Name, wellknown.RedisProxy)
 	}
@@ -113,6 +117,44 @@ func TestInboundNetworkFilterStatPrefix(t *testing.T) {
 	}
 }
 
+func TestInboundNetworkFilterKafka(t *testing.T) {
+	defaultValue := features.EnableKafkaFilter
+	defer func() { features.EnableKafkaFilter = defaultValue }()
+
+	env := buildListenerEnv(nil)
+	env.PushContext.InitContext(env, nil, nil)
+	instance := &model.ServiceInstance{
+		Service: &model.Service{
+			Hostname:   "kafka.default.example.org",
+			Attributes: model.ServiceAttributes{Namespace: "not-default"},
+		},
+		ServicePort: &model.Port{Port: 9092, Name: "kafka", Protocol: protocol.Kafka},
+		Endpoint:    &model.IstioEndpoint{EndpointPort: 9092},
+	}
+
+	// Without the flag, kafka ports are proxied as plain TCP.
+	features.EnableKafkaFilter = false
+	filters := buildInboundNetworkFilters(env.PushContext, &model.Proxy{Metadata: &model.NodeMetadata{}}, instance, "inbound|9092||")
+	for _, f := range filters {
+		if f.Name == util.KafkaBrokerFilter {
+			t.Fatalf("unexpected kafka broker filter with PILOT_ENABLE_KAFKA_FILTER disabled, got %v", filters)
+		}
+	}
+
+	features.EnableKafkaFilter = true
+	filters = buildInboundNetworkFilters(env.PushContext, &model.Proxy{Metadata: &model.NodeMetadata{}}, instance, "inbound|9092||")
+	if len(filters) < 2 || filters[len(filters)-2].Name != util.KafkaBrokerFilter {
+		t.Fatalf("expected the kafka broker filter before the tcp proxy, got %v", filters)
+	}
+	kafkaBroker := &kafka.KafkaBroker{}
+	if err := filters[len(filters)-2].GetTypedConfig().UnmarshalTo(kafkaBroker); err != nil {
+		t.Fatal(err)
+	}
+	if kafkaBroker.StatPrefix != "inbound|9092||" {
+		t.Errorf("unexpected stat prefix %s", kafkaBroker.StatPrefix)
+	}
+}
+
 func TestInboundNetworkFilterIdleTimeout(t *testing.T) {
 	cases := []struct {
 		name        string
@@ -481,3 +523,78 @@ func TestOutboundNetworkFilterWithSourceIPHashing(t *testing.T) {
 		})
 	}
 }
+
+func TestOutboundNetworkFilterProtocolFilterAnnotation(t *testing.T) {
+	services := []*model.Service{
+		buildService("mongo.com", "10.10.0.0/24", protocol.Mongo, tnow),
+		buildService("mysql.com", "10.10.0.0/24", protocol.MySQL, tnow),
+		buildService("redis.com", "10.10.0.0/24", protocol.Redis, tnow),
+		buildService("redis-stats.com", "10.10.0.0/24", protocol.Redis, tnow),
+		buildService("kafka.com", "10.10.0.0/24", protocol.Kafka, tnow),
+	}
+	destinationRule := func(host string, annotations map[string]string) *config.Config {
+		return &config.Config{
+			Meta: config.Meta{
+				GroupVersionKind: collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind(),
+				Name:             host,
+				Namespace:        "not-default",
+				Annotations:      annotations,
+			},
+			Spec: &networking.DestinationRule{Host: host},
+		}
+	}
+	destinationRules := []*config.Config{
+		destinationRule("mongo.com", map[string]string{constants.ProtocolFilterAnnotation: constants.ProtocolFilterStats}),
+		destinationRule("mysql.com", map[string]string{constants.ProtocolFilterAnnotation: constants.ProtocolFilterStats}),
+		destinationRule("redis.com", map[string]string{
+			constants.ProtocolFilterAnnotation: constants.ProtocolFilterRouting,
+			constants.RedisOpTimeoutAnnotation: "500ms",
+		}),
+		destinationRule("redis-stats.com", map[string]string{constants.ProtocolFilterAnnotation: constants.ProtocolFilterStats}),
+		destinationRule("kafka.com", map[string]string{constants.ProtocolFilterAnnotation: constants.ProtocolFilterStats}),
+	}
+
+	env := buildListenerEnvWithAdditionalConfig(services, nil, destinationRules)
+	env.PushContext.InitContext(env, nil, nil)
+
+	proxy := getProxy()
+	proxy.IstioVersion = model.ParseIstioVersion(proxy.Metadata.IstioVersion)
+	proxy.SidecarScope = model.DefaultSidecarScopeForNamespace(env.PushContext, "not-default")
+
+	cases := []struct {
+		host     string
+		protocol protocol.Instance
+		filters  []string
+	}{
+		{"mongo.com", protocol.Mongo, []string{wellknown.MongoProxy, wellknown.TCPProxy}},
+		{"mysql.com", protocol.MySQL, []string{wellknown.MySQLProxy, wellknown.TCPProxy}},
+		{"redis.com", protocol.Redis, []string{wellknown.RedisProxy}},
+		{"redis-stats.com", protocol.Redis, []string{wellknown.TCPProxy}},
+		{"kafka.com", protocol.Kafka, []string{util.KafkaBrokerFilter, wellknown.TCPProxy}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.host, func(t *testing.T) {
+			routes := []*networking.RouteDestination{{
+				Destination: &networking.Destination{Host: tt.host, Port: &networking.PortSelector{Number: 9999}},
+			}}
+			filters := buildOutboundNetworkFilters(proxy, routes, env.PushContext, &model.Port{Port: 9999, Protocol: tt.protocol},
+				config.Meta{Name: tt.host, Namespace: "ns"})
+			names := make([]string, 0, len(filters))
+			for _, f := range filters {
+				names = append(names, f.Name)
+			}
+			if !reflect.DeepEqual(names, tt.filters) {
+				t.Fatalf("expected filters %v, got %v", tt.filters, names)
+			}
+			if tt.filters[0] == wellknown.RedisProxy {
+				redisProxy := &redis.RedisProxy{}
+				if err := filters[0].GetTypedConfig().UnmarshalTo(redisProxy); err != nil {
+					t.Fatal(err)
+				}
+				if got := redisProxy.Settings.OpTimeout.AsDuration(); got != 500*time.Millisecond {
+					t.Errorf("expected op timeout 500ms, got %v", got)
+				}
+			}
+		})
+	}
+}
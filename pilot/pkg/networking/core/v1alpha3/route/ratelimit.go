@@ -0,0 +1,152 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"strings"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	localratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
+	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	any "google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
+
+	"istio.io/istio/pilot/pkg/features"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/util"
+	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
+	"istio.io/istio/pkg/config"
+)
+
+// ApplyLocalRateLimit limits the requests of the route with the local rate limit filter. The headers of the
+// descriptors of the limit are added to the rate limits of the route action. It does nothing unless
+// PILOT_ENABLE_LOCAL_RATE_LIMIT is set, since the filter is not in the HTTP connection managers otherwise.
+func ApplyLocalRateLimit(out *route.Route, limit *istionetworking.LocalRateLimit) {
+	if !features.EnableLocalRateLimit || limit == nil {
+		return
+	}
+	enabled := &core.RuntimeFractionalPercent{
+		DefaultValue: &xdstype.FractionalPercent{Numerator: 100, Denominator: xdstype.FractionalPercent_HUNDRED},
+	}
+	config := &localratelimit.LocalRateLimit{
+		StatPrefix:     xdsfilters.LocalRateLimitStatPrefix,
+		TokenBucket:    buildTokenBucket(limit.TokenBucket),
+		FilterEnabled:  enabled,
+		FilterEnforced: enabled,
+	}
+	for _, d := range limit.Descriptors {
+		config.Descriptors = append(config.Descriptors, &ratelimit.LocalRateLimitDescriptor{
+			Entries:     []*ratelimit.RateLimitDescriptor_Entry{{Key: d.Header, Value: d.Value}},
+			TokenBucket: buildTokenBucket(d.TokenBucket),
+		})
+	}
+	if action := out.GetRoute(); action != nil {
+		for _, header := range limit.Headers() {
+			action.RateLimits = append(action.RateLimits, &route.RateLimit{
+				Actions: []*route.RateLimit_Action{{
+					ActionSpecifier: &route.RateLimit_Action_RequestHeaders_{
+						RequestHeaders: &route.RateLimit_Action_RequestHeaders{
+							HeaderName:    header,
+							DescriptorKey: header,
+						},
+					},
+				}},
+			})
+		}
+	}
+	if out.TypedPerFilterConfig == nil {
+		out.TypedPerFilterConfig = make(map[string]*any.Any)
+	}
+	out.TypedPerFilterConfig[util.LocalRateLimitFilter] = util.MessageToAny(config)
+}
+
+// applyRouteLocalRateLimit applies the local rate limit configured for the named HTTP route, or for all routes, by
+// the VirtualService annotations.
+func applyRouteLocalRateLimit(out *route.Route, routeName string, routeAnnotations *istionetworking.RouteAnnotations) {
+	if limit, f := routeAnnotations.LocalRateLimit(routeName); f {
+		ApplyLocalRateLimit(out, &limit)
+	}
+}
+
+func buildTokenBucket(b istionetworking.TokenBucket) *xdstype.TokenBucket {
+	return &xdstype.TokenBucket{
+		MaxTokens:     b.MaxTokens,
+		TokensPerFill: &wrappers.UInt32Value{Value: b.Fill()},
+		FillInterval:  durationpb.New(b.Interval()),
+	}
+}
+
+// applyGlobalRateLimit adds the descriptors configured for the named HTTP route, or for all routes, by the
+// VirtualService annotations to the rate limits of the route action, in the stage of the global rate limit filter.
+// It does nothing unless PILOT_GLOBAL_RATE_LIMIT_SERVICE is set.
+func applyGlobalRateLimit(action *route.RouteAction, routeName string, vs config.Config, routeAnnotations *istionetworking.RouteAnnotations) {
+	if features.GlobalRateLimitService == "" || action == nil {
+		return
+	}
+	for _, d := range routeAnnotations.GlobalRateLimits(routeName) {
+		rl := &route.RateLimit{Stage: &wrappers.UInt32Value{Value: xdsfilters.GlobalRateLimitStage}}
+		for _, entry := range d {
+			rl.Actions = append(rl.Actions, buildRateLimitAction(entry, routeName, vs))
+		}
+		action.RateLimits = append(action.RateLimits, rl)
+	}
+}
+
+func buildRateLimitAction(entry, routeName string, vs config.Config) *route.RateLimit_Action {
+	switch entry {
+	case istionetworking.RouteRateLimitEntry:
+		return &route.RateLimit_Action{
+			ActionSpecifier: &route.RateLimit_Action_GenericKey_{
+				GenericKey: &route.RateLimit_Action_GenericKey{
+					DescriptorKey:   istionetworking.RouteRateLimitEntry,
+					DescriptorValue: vs.Namespace + "/" + vs.Name + "/" + routeName,
+				},
+			},
+		}
+	case istionetworking.PathRateLimitEntry:
+		return &route.RateLimit_Action{
+			ActionSpecifier: &route.RateLimit_Action_RequestHeaders_{
+				RequestHeaders: &route.RateLimit_Action_RequestHeaders{
+					HeaderName:    ":path",
+					DescriptorKey: istionetworking.PathRateLimitEntry,
+				},
+			},
+		}
+	case istionetworking.DestinationRateLimitEntry:
+		return &route.RateLimit_Action{
+			ActionSpecifier: &route.RateLimit_Action_DestinationCluster_{
+				DestinationCluster: &route.RateLimit_Action_DestinationCluster{},
+			},
+		}
+	case istionetworking.SourceRateLimitEntry:
+		return &route.RateLimit_Action{
+			ActionSpecifier: &route.RateLimit_Action_RemoteAddress_{
+				RemoteAddress: &route.RateLimit_Action_RemoteAddress{},
+			},
+		}
+	}
+	header := strings.TrimPrefix(entry, istionetworking.HeaderRateLimitEntry)
+	return &route.RateLimit_Action{
+		ActionSpecifier: &route.RateLimit_Action_RequestHeaders_{
+			RequestHeaders: &route.RateLimit_Action_RequestHeaders{
+				HeaderName:    header,
+				DescriptorKey: header,
+			},
+		},
+	}
+}
@@ -21,14 +21,30 @@ import (
 
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	previouspriorities "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
+	"go.uber.org/atomic"
 	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 
 	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/networking/util"
 )
 
 var defaultRetryPriorityTypedConfig = util.MessageToAny(buildPreviousPrioritiesConfig())
 
+// reduced is set while the upstream error rate of the mesh is high, reducing the default retries.
+var reduced = atomic.NewBool(false)
+
+// SetReduced sets whether the default retries are reduced, and returns whether it changed. Routes built before the
+// change keep their retries, so the caller must push them again.
+func SetReduced(r bool) bool {
+	return reduced.Swap(r) != r
+}
+
+// Reduced returns whether the default retries are reduced.
+func Reduced() bool {
+	return reduced.Load()
+}
+
 // DefaultPolicy gets a copy of the default retry policy.
 func DefaultPolicy() *route.RetryPolicy {
 	policy := route.RetryPolicy{
@@ -103,6 +119,23 @@ func ConvertPolicy(in *networking.HTTPRetry) *route.RetryPolicy {
 	return out
 }
 
+// ConvertDefaultPolicy converts the mesh default retry policy, used by the routes without a retry policy of their own.
+// While the default retries are reduced, it retries at most PILOT_RETRY_SAFETY_ATTEMPTS times.
+func ConvertDefaultPolicy(in *networking.HTTPRetry) *route.RetryPolicy {
+	out := ConvertPolicy(in)
+	if out == nil || !Reduced() {
+		return out
+	}
+	attempts := uint32(0)
+	if features.RetrySafetyAttempts > 0 {
+		attempts = uint32(features.RetrySafetyAttempts)
+	}
+	if out.NumRetries.GetValue() > attempts {
+		out.NumRetries = &wrappers.UInt32Value{Value: attempts}
+	}
+	return out
+}
+
 func parseRetryOn(retryOn string) (string, []uint32) {
 	codes := make([]uint32, 0)
 	tojoin := make([]string, 0)
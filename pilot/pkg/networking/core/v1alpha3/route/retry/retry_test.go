@@ -225,3 +225,24 @@ func TestRetry(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertDefaultPolicy(t *testing.T) {
+	g := NewWithT(t)
+	defer retry.SetReduced(false)
+
+	// Without reduction, the default policy is converted as is.
+	g.Expect(retry.ConvertDefaultPolicy(nil)).To(Equal(retry.ConvertPolicy(nil)))
+	g.Expect(retry.ConvertDefaultPolicy(&networking.HTTPRetry{Attempts: 5}).NumRetries.GetValue()).To(Equal(uint32(5)))
+
+	g.Expect(retry.SetReduced(true)).To(BeTrue())
+	g.Expect(retry.SetReduced(true)).To(BeFalse())
+	g.Expect(retry.ConvertDefaultPolicy(nil).NumRetries.GetValue()).To(Equal(uint32(1)))
+	g.Expect(retry.ConvertDefaultPolicy(&networking.HTTPRetry{Attempts: 5}).NumRetries.GetValue()).To(Equal(uint32(1)))
+	// Disabled retries stay disabled.
+	g.Expect(retry.ConvertDefaultPolicy(&networking.HTTPRetry{Attempts: 0})).To(BeNil())
+	// Explicit route retry policies are not reduced.
+	g.Expect(retry.ConvertPolicy(&networking.HTTPRetry{Attempts: 5}).NumRetries.GetValue()).To(Equal(uint32(5)))
+
+	g.Expect(retry.SetReduced(false)).To(BeTrue())
+	g.Expect(retry.ConvertDefaultPolicy(nil).NumRetries.GetValue()).To(Equal(uint32(2)))
+}
@@ -23,6 +23,7 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	xdsfault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/common/fault/v3"
+	extproc "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	xdshttpfault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
@@ -35,10 +36,10 @@ import (
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/route/retry"
 	"istio.io/istio/pilot/pkg/networking/util"
 	authz "istio.io/istio/pilot/pkg/security/authz/model"
-	"istio.io/istio/pilot/pkg/util/constant"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
@@ -79,6 +80,9 @@ type VirtualHostWrapper struct {
 	// service's hostname within a platform (e.g., foo, foo.default, foo.default.svc, etc.)
 	VirtualServiceHosts []string
 
+	// VirtualService is the namespace/name of the VirtualService the routes come from, if any.
+	VirtualService string
+
 	// Routes in the virtual host
 	Routes []*route.Route
 }
@@ -116,7 +120,8 @@ func BuildSidecarVirtualHostWrapper(routeCache *Cache, node *model.Proxy, push *
 
 	// translate all virtual service configs into virtual hosts
 	for _, virtualService := range virtualServices {
-		wrappers := buildSidecarVirtualHostsForVirtualService(node, virtualService, serviceRegistry, hashByDestination, listenPort, push.Mesh)
+		wrappers := buildSidecarVirtualHostsForVirtualService(node, virtualService, push.VirtualServiceRouteAnnotations(virtualService),
+			serviceRegistry, hashByDestination, listenPort, push.Mesh)
 		out = append(out, wrappers...)
 	}
 
@@ -202,13 +207,14 @@ func separateVSHostsAndServices(virtualService config.Config,
 func buildSidecarVirtualHostsForVirtualService(
 	node *model.Proxy,
 	virtualService config.Config,
+	routeAnnotations *istionetworking.RouteAnnotations,
 	serviceRegistry map[host.Name]*model.Service,
 	hashByDestination map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB,
 	listenPort int,
 	mesh *meshconfig.MeshConfig,
 ) []VirtualHostWrapper {
 	meshGateway := map[string]bool{constants.IstioMeshGateway: true}
-	routes, err := BuildHTTPRoutesForVirtualService(node, virtualService, serviceRegistry, hashByDestination,
+	routes, err := BuildHTTPRoutesForVirtualService(node, virtualService, routeAnnotations, serviceRegistry, hashByDestination,
 		listenPort, meshGateway, false /* isH3DiscoveryNeeded */, mesh)
 	if err != nil || len(routes) == 0 {
 		return nil
@@ -244,6 +250,7 @@ func buildSidecarVirtualHostsForVirtualService(
 			Port:                port,
 			Services:            services,
 			VirtualServiceHosts: hosts,
+			VirtualService:      virtualService.Namespace + "/" + virtualService.Name,
 			Routes:              routes,
 		})
 	}
@@ -312,6 +319,7 @@ func GetDestinationCluster(destination *networking.Destination, service *model.S
 func BuildHTTPRoutesForVirtualService(
 	node *model.Proxy,
 	virtualService config.Config,
+	routeAnnotations *istionetworking.RouteAnnotations,
 	serviceRegistry map[host.Name]*model.Service,
 	hashByDestination map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB,
 	listenPort int,
@@ -329,14 +337,14 @@ func BuildHTTPRoutesForVirtualService(
 	catchall := false
 	for _, http := range vs.Http {
 		if len(http.Match) == 0 {
-			if r := translateRoute(node, http, nil, listenPort, virtualService, serviceRegistry,
+			if r := translateRoute(node, http, nil, listenPort, virtualService, routeAnnotations, serviceRegistry,
 				hashByDestination, gatewayNames, isHTTP3AltSvcHeaderNeeded, mesh); r != nil {
 				out = append(out, r)
 			}
 			catchall = true
 		} else {
 			for _, match := range http.Match {
-				if r := translateRoute(node, http, match, listenPort, virtualService, serviceRegistry,
+				if r := translateRoute(node, http, match, listenPort, virtualService, routeAnnotations, serviceRegistry,
 					hashByDestination, gatewayNames, isHTTP3AltSvcHeaderNeeded, mesh); r != nil {
 					out = append(out, r)
 					// This is a catch all path. Routes are matched in order, so we will never go beyond this match
@@ -387,6 +395,7 @@ func translateRoute(
 	match *networking.HTTPMatchRequest,
 	listenPort int,
 	virtualService config.Config,
+	routeAnnotations *istionetworking.RouteAnnotations,
 	serviceRegistry map[host.Name]*model.Service,
 	hashByDestination map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB,
 	gatewayNames map[string]bool,
@@ -425,10 +434,18 @@ func translateRoute(
 		authority = operations.authority
 	}
 
-	if in.Redirect != nil {
+	switch {
+	case applyDirectResponse(out, in.Name, routeAnnotations):
+		// The route responds from the proxy and has no destination.
+	case in.Redirect != nil:
 		applyRedirect(out, in.Redirect, listenPort)
-	} else {
+	default:
 		applyHTTPRouteDestination(out, node, in, mesh, authority, serviceRegistry, listenPort, hashByDestination)
+		out.GetRoute().InternalRedirectPolicy = buildInternalRedirectPolicy(in.Name, routeAnnotations)
+		applyRegexRewrite(out.GetRoute(), in.Name, routeAnnotations)
+		applyIdleTimeout(out.GetRoute(), in.Name, routeAnnotations)
+		applyMirrors(out.GetRoute(), in.Name, virtualService, routeAnnotations, serviceRegistry, listenPort)
+		applyGlobalRateLimit(out.GetRoute(), in.Name, virtualService, routeAnnotations)
 	}
 
 	out.Decorator = &route.Decorator{
@@ -438,9 +455,11 @@ func translateRoute(
 		out.TypedPerFilterConfig = make(map[string]*any.Any)
 		out.TypedPerFilterConfig[wellknown.Fault] = util.MessageToAny(translateFault(in.Fault))
 	}
+	applyRouteLocalRateLimit(out, in.Name, routeAnnotations)
+	applyExtProcOverrides(out, in.Name, routeAnnotations)
 
 	if isHTTP3AltSvcHeaderNeeded {
-		http3AltSvcHeader := buildHTTP3AltSvcHeader(listenPort, util.ALPNHttp3OverQUIC)
+		http3AltSvcHeader := BuildHTTP3AltSvcHeader(listenPort, util.ALPNHttp3OverQUIC)
 		if out.ResponseHeadersToAdd == nil {
 			out.ResponseHeadersToAdd = make([]*core.HeaderValueOption, 0)
 		}
@@ -459,14 +478,14 @@ func applyHTTPRouteDestination(
 	serviceRegistry map[host.Name]*model.Service,
 	listenerPort int,
 	hashByDestination map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB) {
-	policy := in.Retries
-	if policy == nil {
-		// No VS policy set, use mesh defaults
-		policy = mesh.GetDefaultHttpRetryPolicy()
-	}
 	action := &route.RouteAction{
-		Cors:        translateCORSPolicy(in.CorsPolicy),
-		RetryPolicy: retry.ConvertPolicy(policy),
+		Cors: translateCORSPolicy(in.CorsPolicy),
+	}
+	if in.Retries != nil {
+		action.RetryPolicy = retry.ConvertPolicy(in.Retries)
+	} else {
+		// No VS policy set, use mesh defaults
+		action.RetryPolicy = retry.ConvertDefaultPolicy(mesh.GetDefaultHttpRetryPolicy())
 	}
 
 	// Configure timeouts specified by Virtual Service if they are provided, otherwise set it to defaults.
@@ -573,6 +592,126 @@ func applyHTTPRouteDestination(
 	}
 }
 
+// buildInternalRedirectPolicy builds the internal redirect policy configured for the named HTTP route, or for all
+// routes, by the VirtualService annotations.
+func buildInternalRedirectPolicy(routeName string, routeAnnotations *istionetworking.RouteAnnotations) *route.InternalRedirectPolicy {
+	redirect, f := routeAnnotations.InternalRedirect(routeName)
+	if !f {
+		return nil
+	}
+	return &route.InternalRedirectPolicy{
+		MaxInternalRedirects:     &wrappers.UInt32Value{Value: redirect.MaxRedirects},
+		RedirectResponseCodes:    redirect.ResponseCodes,
+		AllowCrossSchemeRedirect: redirect.AllowCrossScheme,
+	}
+}
+
+// applyRegexRewrite sets the regex rewrite configured for the named HTTP route by the VirtualService annotations.
+func applyRegexRewrite(action *route.RouteAction, routeName string, routeAnnotations *istionetworking.RouteAnnotations) {
+	if action.PrefixRewrite != "" {
+		return
+	}
+	rewrite, f := routeAnnotations.RegexRewrite(routeName)
+	if !f {
+		return
+	}
+	action.RegexRewrite = &matcher.RegexMatchAndSubstitute{
+		Pattern: &matcher.RegexMatcher{
+			EngineType: regexEngine,
+			Regex:      rewrite.Pattern,
+		},
+		Substitution: rewrite.Substitution,
+	}
+}
+
+// applyIdleTimeout sets the idle timeout configured for the named HTTP route, or for all routes, by the
+// VirtualService annotations.
+func applyIdleTimeout(action *route.RouteAction, routeName string, routeAnnotations *istionetworking.RouteAnnotations) {
+	if timeout, f := routeAnnotations.IdleTimeout(routeName); f {
+		action.IdleTimeout = durationpb.New(timeout)
+	}
+}
+
+// applyMirrors adds the mirrors configured for the named HTTP route by the VirtualService annotations to the mirror
+// policies of the route.
+func applyMirrors(action *route.RouteAction, routeName string, virtualService config.Config,
+	routeAnnotations *istionetworking.RouteAnnotations, serviceRegistry map[host.Name]*model.Service, listenerPort int) {
+	for _, m := range routeAnnotations.Mirrors(routeName) {
+		percent := 100.0
+		if m.Percentage != nil {
+			percent = *m.Percentage
+		}
+		if percent == 0 {
+			continue
+		}
+		destination := &networking.Destination{
+			Host:   string(model.ResolveShortnameToFQDN(m.Host, virtualService.Meta)),
+			Subset: m.Subset,
+		}
+		if m.Port != 0 {
+			destination.Port = &networking.PortSelector{Number: m.Port}
+		}
+		action.RequestMirrorPolicies = append(action.RequestMirrorPolicies, &route.RouteAction_RequestMirrorPolicy{
+			Cluster: GetDestinationCluster(destination, serviceRegistry[host.Name(destination.Host)], listenerPort),
+			RuntimeFraction: &core.RuntimeFractionalPercent{
+				DefaultValue: translatePercentToFractionalPercent(&networking.Percent{Value: percent}),
+			},
+			TraceSampled: &wrappers.BoolValue{Value: false},
+		})
+	}
+}
+
+// applyExtProcOverrides sets the overrides of the external processors configured for the named HTTP route, or for all
+// routes, by the VirtualService annotations.
+func applyExtProcOverrides(out *route.Route, routeName string, routeAnnotations *istionetworking.RouteAnnotations) {
+	overrides := routeAnnotations.ExtProcOverrides(routeName)
+	if len(overrides) == 0 {
+		return
+	}
+	if out.TypedPerFilterConfig == nil {
+		out.TypedPerFilterConfig = make(map[string]*any.Any)
+	}
+	for plugin, o := range overrides {
+		perRoute := &extproc.ExtProcPerRoute{}
+		if o.Disabled {
+			perRoute.Override = &extproc.ExtProcPerRoute_Disabled{Disabled: true}
+		} else {
+			perRoute.Override = &extproc.ExtProcPerRoute_Overrides{
+				Overrides: &extproc.ExtProcOverrides{ProcessingMode: o.ProcessingMode},
+			}
+		}
+		out.TypedPerFilterConfig[istionetworking.ExtProcFilterName(plugin)] = util.MessageToAny(perRoute)
+	}
+}
+
+// applyDirectResponse sets the direct response configured for the named HTTP route by the VirtualService
+// annotations, and returns whether it did.
+func applyDirectResponse(out *route.Route, routeName string, routeAnnotations *istionetworking.RouteAnnotations) bool {
+	response, f := routeAnnotations.DirectResponse(routeName)
+	if !f {
+		return false
+	}
+	action := &route.DirectResponseAction{Status: response.Status}
+	if response.Body != "" {
+		action.Body = &core.DataSource{Specifier: &core.DataSource_InlineString{InlineString: response.Body}}
+	}
+	out.Action = &route.Route_DirectResponse{DirectResponse: action}
+
+	headers := make([]*core.HeaderValueOption, 0, len(response.Headers))
+	for key, value := range response.Headers {
+		headers = append(headers, &core.HeaderValueOption{
+			Header: &core.HeaderValue{
+				Key:   key,
+				Value: value,
+			},
+			Append: proto.BoolFalse,
+		})
+	}
+	sort.Stable(SortHeaderValueOption(headers))
+	out.ResponseHeadersToAdd = append(out.ResponseHeadersToAdd, headers...)
+	return true
+}
+
 func applyRedirect(out *route.Route, redirect *networking.HTTPRedirect, port int) {
 	action := &route.Route_Redirect{
 		Redirect: &route.RedirectAction{
@@ -621,7 +760,8 @@ func applyRedirect(out *route.Route, redirect *networking.HTTPRedirect, port int
 	out.Action = action
 }
 
-func buildHTTP3AltSvcHeader(port int, h3Alpns []string) *core.HeaderValueOption {
+// BuildHTTP3AltSvcHeader builds the alt-svc response header advertising HTTP/3 on the port.
+func BuildHTTP3AltSvcHeader(port int, h3Alpns []string) *core.HeaderValueOption {
 	// For example, www.cloudflare.com returns the following
 	// alt-svc: h3-27=":443"; ma=86400, h3-28=":443"; ma=86400, h3-29=":443"; ma=86400, h3=":443"; ma=86400
 	valParts := make([]string, 0, len(h3Alpns))
@@ -772,6 +912,19 @@ func translateHeadersOperations(headers *networking.Headers) headersOperations {
 	}
 }
 
+// ApplyVirtualHostHeaders appends the header operations to the virtual host. Rewriting the authority is not
+// possible at the virtual host level, so it is ignored.
+func ApplyVirtualHostHeaders(vh *route.VirtualHost, headers *networking.Headers) {
+	if headers == nil {
+		return
+	}
+	ops := translateHeadersOperations(headers)
+	vh.RequestHeadersToAdd = append(vh.RequestHeadersToAdd, ops.requestHeadersToAdd...)
+	vh.ResponseHeadersToAdd = append(vh.ResponseHeadersToAdd, ops.responseHeadersToAdd...)
+	vh.RequestHeadersToRemove = append(vh.RequestHeadersToRemove, ops.requestHeadersToRemove...)
+	vh.ResponseHeadersToRemove = append(vh.ResponseHeadersToRemove, ops.responseHeadersToRemove...)
+}
+
 // translateRouteMatch translates match condition
 func translateRouteMatch(in *networking.HTTPMatchRequest) *route.RouteMatch {
 	out := &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}}
@@ -895,15 +1048,24 @@ func isCatchAllHeaderMatch(in *networking.StringMatch) bool {
 // or the header format is invalid for generating metadata matcher.
 //
 // The currently only supported header is @request.auth.claims for JWT claims matching. Claims of type string or list of string
-// are supported and nested claims are also supported using `.` as a separator for claim names.
+// are supported, and a list matches if any of its elements matches. Nested claims are also supported using `.` as a separator
+// for claim names, or brackets around each claim name if the names contain `.`. A catch-all match matches any request where
+// the claim is present.
 // Examples:
 // - `@request.auth.claims.admin` matches the claim "admin".
 // - `@request.auth.claims.group.id` matches the nested claims "group" and "id".
+// - `@request.auth.claims[example.com/groups][id]` matches the nested claims "example.com/groups" and "id".
 func translateMetadataMatch(name string, in *networking.StringMatch) *matcher.MetadataMatcher {
-	if !strings.HasPrefix(strings.ToLower(name), constant.HeaderJWTClaim) {
+	if !istionetworking.IsJWTClaimHeader(name) {
+		return nil
+	}
+	claims, err := istionetworking.ParseJWTClaimHeader(name)
+	if err != nil {
 		return nil
 	}
-	claims := strings.Split(name[len(constant.HeaderJWTClaim):], ".")
+	if isCatchAllHeaderMatch(in) || in.MatchType == nil {
+		return authz.MetadataMatcherForJWTClaimsPresence(claims)
+	}
 
 	var value *matcher.StringMatcher
 	switch m := in.MatchType.(type) {
@@ -992,6 +1154,13 @@ func convertToEnvoyMatch(in []*networking.StringMatch) []*matcher.StringMatcher
 	return res
 }
 
+// ApplyDefaultCORSPolicy sets the CORS policy of the virtual host to the default one of the namespace. It applies to
+// all the routes of the virtual host, including redirects and direct responses, except those with a corsPolicy of
+// their own, which Envoy prefers.
+func ApplyDefaultCORSPolicy(vh *route.VirtualHost, push *model.PushContext, namespace string) {
+	vh.Cors = translateCORSPolicy(push.DefaultCORSPolicy(namespace))
+}
+
 // translateCORSPolicy translates CORS policy
 func translateCORSPolicy(in *networking.CorsPolicy) *route.CorsPolicy {
 	if in == nil {
@@ -1085,7 +1254,7 @@ func BuildDefaultHTTPOutboundRoute(clusterName string, operation string, mesh *m
 	out := BuildDefaultHTTPInboundRoute(clusterName, operation)
 
 	// Add a default retry policy for outbound routes.
-	out.GetRoute().RetryPolicy = retry.ConvertPolicy(mesh.GetDefaultHttpRetryPolicy())
+	out.GetRoute().RetryPolicy = retry.ConvertDefaultPolicy(mesh.GetDefaultHttpRetryPolicy())
 	return out
 }
 
@@ -1240,10 +1409,14 @@ func getHashForService(node *model.Proxy, push *model.PushContext,
 	return consistentHash, destinationRule
 }
 
+// GetConsistentHashForVirtualService returns the consistent hash policies of the http route destinations of
+// the virtual service, along with the destination rules defining them.
 func GetConsistentHashForVirtualService(push *model.PushContext, node *model.Proxy,
 	virtualService config.Config,
-	serviceRegistry map[host.Name]*model.Service) map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB {
+	serviceRegistry map[host.Name]*model.Service) (map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB,
+	[]*config.Config) {
 	hashByDestination := map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB{}
+	var destinationRules []*config.Config
 	for _, httpRoute := range virtualService.Spec.(*networking.VirtualService).Http {
 		for _, destination := range httpRoute.Route {
 			hostName := destination.Destination.Host
@@ -1253,14 +1426,15 @@ func GetConsistentHashForVirtualService(push *model.PushContext, node *model.Pro
 			} else {
 				configNamespace = virtualService.Namespace
 			}
-			hash, _ := GetHashForHTTPDestination(push, node, destination, configNamespace)
+			hash, destinationRule := GetHashForHTTPDestination(push, node, destination, configNamespace)
 			if hash != nil {
 				hashByDestination[destination] = hash
+				destinationRules = append(destinationRules, destinationRule)
 			}
 		}
 	}
 
-	return hashByDestination
+	return hashByDestination, destinationRules
 }
 
 // GetHashForHTTPDestination return the ConsistentHashLB and the DestinationRule associated with HTTP route destination.
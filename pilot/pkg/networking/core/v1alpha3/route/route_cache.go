@@ -22,6 +22,8 @@ import (
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/schema/gvk"
 )
@@ -42,6 +44,8 @@ type Cache struct {
 	// This allows resolving ServiceEntries, which is especially useful for distinguishing TCP traffic
 	// This depends on DNSCapture.
 	DNSAutoAllocate bool
+	// DisableAltVirtualHosts indicates whether short name domains are omitted from virtual hosts
+	DisableAltVirtualHosts bool
 
 	ListenerPort            int
 	Services                []*model.Service
@@ -49,6 +53,19 @@ type Cache struct {
 	DelegateVirtualServices []model.ConfigKey
 	DestinationRules        []*config.Config
 	EnvoyFilterKeys         []string
+	// CatchAll is the catch-all action of the egress listener, if it overrides the outbound traffic policy.
+	CatchAll string
+	// PassthroughWildcardNamespaces are the namespaces whose Passthrough services get wildcard domains, or nil if
+	// the services of all namespaces do.
+	PassthroughWildcardNamespaces []string
+	// HTTP3 is set when the virtual hosts of services advertising HTTP/3 include the alt-svc header.
+	HTTP3 bool
+	// AttemptCount is where the virtual hosts report the attempt count of requests.
+	AttemptCount istionetworking.AttemptCount
+	// LocalDomains are the local domains of the upstream service of a whitebox static mapping.
+	LocalDomains []string
+	// FeatureGates are the feature gates set for the proxy, which may change its routes.
+	FeatureGates string
 }
 
 func (r *Cache) Cacheable() bool {
@@ -59,19 +76,23 @@ func (r *Cache) Cacheable() bool {
 		return false
 	}
 
-	for _, config := range r.VirtualServices {
+	return !hasSourceMatch(r.VirtualServices)
+}
+
+// hasSourceMatch returns true if any of the virtual services matches on the source of the requests,
+// which makes the routes depend on the labels and namespace of the proxy.
+func hasSourceMatch(virtualServices []config.Config) bool {
+	for _, config := range virtualServices {
 		vs := config.Spec.(*networking.VirtualService)
 		for _, httpRoute := range vs.Http {
 			for _, match := range httpRoute.Match {
-				// if vs has source match, not cacheable
 				if len(match.SourceLabels) > 0 || match.SourceNamespace != "" {
-					return false
+					return true
 				}
 			}
 		}
 	}
-
-	return true
+	return false
 }
 
 func (r *Cache) DependentConfigs() []model.ConfigKey {
@@ -101,11 +122,123 @@ func (r *Cache) DependentTypes() []config.GroupVersionKind {
 	return nil
 }
 
+func (r *Cache) ResourceType() string {
+	return v3.RouteType
+}
+
 func (r *Cache) Key() string {
 	params := []string{
 		r.RouteName, r.ProxyVersion, r.ClusterID, r.DNSDomain,
 		strconv.FormatBool(r.DNSCapture), strconv.FormatBool(r.DNSAutoAllocate),
+		strconv.FormatBool(r.DisableAltVirtualHosts), r.CatchAll,
+		strconv.FormatBool(r.AttemptCount.Request), strconv.FormatBool(r.AttemptCount.Response),
+	}
+	for _, svc := range r.Services {
+		params = append(params, string(svc.Hostname)+"/"+svc.Attributes.Namespace)
+		if r.HTTP3 && svc.Attributes.HTTP3 {
+			params = append(params, "h3")
+		}
+	}
+	for _, vs := range r.VirtualServices {
+		params = append(params, vs.Name+"/"+vs.Namespace)
+	}
+	for _, dr := range r.DestinationRules {
+		params = append(params, dr.Name+"/"+dr.Namespace)
+	}
+	params = append(params, r.EnvoyFilterKeys...)
+	if r.PassthroughWildcardNamespaces != nil {
+		params = append(params, "passthrough-wildcard:"+strings.Join(r.PassthroughWildcardNamespaces, ","))
+	}
+	if len(r.LocalDomains) > 0 {
+		params = append(params, "local-domains:"+strings.Join(r.LocalDomains, ","))
 	}
+	if r.FeatureGates != "" {
+		params = append(params, "feature-gates:"+r.FeatureGates)
+	}
+
+	hash := md5.New()
+	for _, param := range params {
+		hash.Write([]byte(param))
+	}
+	sum := hash.Sum(nil)
+	return hex.EncodeToString(sum)
+}
+
+// GatewayCache includes the variables that can influence a gateway Route Configuration.
+// Implements XdsCacheEntry interface.
+type GatewayCache struct {
+	RouteName string
+
+	ProxyVersion string
+	// proxy cluster ID
+	ClusterID string
+	// proxy dns domain
+	DNSDomain string
+	// FeatureGates are the feature gates set for the proxy, which may change its routes.
+	FeatureGates string
+	// HTTP3 is set if the routes advertise HTTP/3 with the alt-svc header.
+	HTTP3 bool
+
+	// Gateways are the gateways with servers for the route, in the namespace/name format.
+	Gateways                []string
+	VirtualServices         []config.Config
+	DelegateVirtualServices []model.ConfigKey
+	// Services are the services referenced by the virtual services.
+	Services []*model.Service
+	// DestinationRules are the destination rules with consistent hash policies for the route destinations.
+	DestinationRules []*config.Config
+	EnvoyFilterKeys  []string
+}
+
+// gatewayAPITypes are the Gateway API types that the gateways and virtual services may be generated from.
+// Updates to these are keyed on the Gateway API resources, not on the generated configs.
+var gatewayAPITypes = []config.GroupVersionKind{
+	gvk.KubernetesGateway, gvk.GatewayClass, gvk.HTTPRoute, gvk.TCPRoute, gvk.TLSRoute, gvk.ReferencePolicy,
+}
+
+func (r *GatewayCache) Cacheable() bool {
+	if r == nil {
+		return false
+	}
+	return !hasSourceMatch(r.VirtualServices)
+}
+
+func (r *GatewayCache) DependentConfigs() []model.ConfigKey {
+	configs := make([]model.ConfigKey, 0, len(r.Gateways)+len(r.Services)+len(r.VirtualServices)+
+		len(r.DelegateVirtualServices)+len(r.DestinationRules)+len(r.EnvoyFilterKeys))
+	for _, gw := range r.Gateways {
+		items := strings.Split(gw, "/")
+		configs = append(configs, model.ConfigKey{Kind: gvk.Gateway, Name: items[1], Namespace: items[0]})
+	}
+	for _, svc := range r.Services {
+		configs = append(configs, model.ConfigKey{Kind: gvk.ServiceEntry, Name: string(svc.Hostname), Namespace: svc.Attributes.Namespace})
+	}
+	for _, vs := range r.VirtualServices {
+		configs = append(configs, model.ConfigKey{Kind: gvk.VirtualService, Name: vs.Name, Namespace: vs.Namespace})
+	}
+	configs = append(configs, r.DelegateVirtualServices...)
+	for _, dr := range r.DestinationRules {
+		configs = append(configs, model.ConfigKey{Kind: gvk.DestinationRule, Name: dr.Name, Namespace: dr.Namespace})
+	}
+	for _, efKey := range r.EnvoyFilterKeys {
+		items := strings.Split(efKey, "/")
+		configs = append(configs, model.ConfigKey{Kind: gvk.EnvoyFilter, Name: items[1], Namespace: items[0]})
+	}
+	return configs
+}
+
+func (r *GatewayCache) DependentTypes() []config.GroupVersionKind {
+	return gatewayAPITypes
+}
+
+func (r *GatewayCache) ResourceType() string {
+	return v3.RouteType
+}
+
+func (r *GatewayCache) Key() string {
+	// The gateway prefix keeps the keys distinct from the sidecar route keys.
+	params := []string{"gateway", r.RouteName, r.ProxyVersion, r.ClusterID, r.DNSDomain, r.FeatureGates, strconv.FormatBool(r.HTTP3)}
+	params = append(params, r.Gateways...)
 	for _, svc := range r.Services {
 		params = append(params, string(svc.Hostname)+"/"+svc.Attributes.Namespace)
 	}
@@ -81,3 +81,12 @@ func TestClearRDSCacheOnDelegateUpdate(t *testing.T) {
 		t.Fatalf("rds cache was cleared by irrelevant delegate virtual service update")
 	}
 }
+
+func TestGatewayCacheKeyHTTP3(t *testing.T) {
+	entry := GatewayCache{RouteName: "https.443.https.gw.default", Gateways: []string{"default/gw"}}
+	h3 := entry
+	h3.HTTP3 = true
+	if entry.Key() == h3.Key() {
+		t.Fatalf("expected the routes advertising HTTP/3 to have another key")
+	}
+}
@@ -17,16 +17,30 @@ package route
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	extproc "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	localratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/gogo/protobuf/types"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 
 	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/util"
 	authzmatcher "istio.io/istio/pilot/pkg/security/authz/matcher"
 	authz "istio.io/istio/pilot/pkg/security/authz/model"
+	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
 )
 
@@ -315,6 +329,310 @@ func TestTranslateCORSPolicy(t *testing.T) {
 	}
 }
 
+func TestBuildInternalRedirectPolicy(t *testing.T) {
+	redirects := map[string]string{
+		constants.InternalRedirectsAnnotation: `{"login": {"maxRedirects": 3, "responseCodes": [301, 302, 307], "allowCrossScheme": true},
+			"*": {"maxRedirects": 2}}`,
+	}
+	cases := []struct {
+		name        string
+		routeName   string
+		annotations map[string]string
+		want        *route.InternalRedirectPolicy
+	}{
+		{
+			name:      "no annotations",
+			routeName: "login",
+			want:      nil,
+		},
+		{
+			name:        "named route",
+			routeName:   "login",
+			annotations: redirects,
+			want: &route.InternalRedirectPolicy{
+				MaxInternalRedirects:     &wrappers.UInt32Value{Value: 3},
+				RedirectResponseCodes:    []uint32{301, 302, 307},
+				AllowCrossSchemeRedirect: true,
+			},
+		},
+		{
+			name:        "other route",
+			routeName:   "ratings",
+			annotations: redirects,
+			want: &route.InternalRedirectPolicy{
+				MaxInternalRedirects: &wrappers.UInt32Value{Value: 2},
+			},
+		},
+		{
+			name:        "no default",
+			routeName:   "ratings",
+			annotations: map[string]string{constants.InternalRedirectsAnnotation: `{"login": {"maxRedirects": 2}}`},
+			want:        nil,
+		},
+		{
+			name:        "invalid response code",
+			routeName:   "login",
+			annotations: map[string]string{constants.InternalRedirectsAnnotation: `{"login": {"maxRedirects": 2, "responseCodes": [304]}}`},
+			want:        nil,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildInternalRedirectPolicy(tt.routeName, routeAnnotations(tt.annotations)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildInternalRedirectPolicy() = \n%v, want \n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+// routeAnnotations parses the route annotations of a VirtualService as the push context does.
+func routeAnnotations(annotations map[string]string) *istionetworking.RouteAnnotations {
+	return istionetworking.ParseRouteAnnotations(config.Meta{Name: "reviews", Namespace: "default", Annotations: annotations})
+}
+
+func TestApplyRegexRewrite(t *testing.T) {
+	rewrites := map[string]string{
+		constants.RegexRewriteAnnotation: `{"reviews": {"pattern": "^/v1/(.*)$", "substitution": "/\\1"}}`,
+	}
+	cases := []struct {
+		name        string
+		routeName   string
+		annotations map[string]string
+		action      *route.RouteAction
+		want        *matcher.RegexMatchAndSubstitute
+	}{
+		{
+			name:      "no annotations",
+			routeName: "reviews",
+			action:    &route.RouteAction{},
+		},
+		{
+			name:        "named route",
+			routeName:   "reviews",
+			annotations: rewrites,
+			action:      &route.RouteAction{},
+			want: &matcher.RegexMatchAndSubstitute{
+				Pattern:      &matcher.RegexMatcher{EngineType: regexEngine, Regex: "^/v1/(.*)$"},
+				Substitution: `/\1`,
+			},
+		},
+		{
+			name:        "other route",
+			routeName:   "ratings",
+			annotations: rewrites,
+			action:      &route.RouteAction{},
+		},
+		{
+			name:        "prefix rewrite",
+			routeName:   "reviews",
+			annotations: rewrites,
+			action:      &route.RouteAction{PrefixRewrite: "/"},
+		},
+		{
+			name:        "invalid annotation",
+			routeName:   "reviews",
+			annotations: map[string]string{constants.RegexRewriteAnnotation: "reviews"},
+			action:      &route.RouteAction{},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			applyRegexRewrite(tt.action, tt.routeName, routeAnnotations(tt.annotations))
+			if got := tt.action.RegexRewrite; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyRegexRewrite() = \n%v, want \n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyIdleTimeout(t *testing.T) {
+	timeouts := map[string]string{
+		constants.RouteIdleTimeoutAnnotation: `{"events": "1h", "*": "5m"}`,
+	}
+	cases := []struct {
+		name        string
+		routeName   string
+		annotations map[string]string
+		want        *durationpb.Duration
+	}{
+		{
+			name:      "no annotations",
+			routeName: "events",
+		},
+		{
+			name:        "named route",
+			routeName:   "events",
+			annotations: timeouts,
+			want:        durationpb.New(time.Hour),
+		},
+		{
+			name:        "other route",
+			routeName:   "ratings",
+			annotations: timeouts,
+			want:        durationpb.New(5 * time.Minute),
+		},
+		{
+			name:        "unnamed route",
+			annotations: timeouts,
+			want:        durationpb.New(5 * time.Minute),
+		},
+		{
+			name:        "disabled",
+			routeName:   "events",
+			annotations: map[string]string{constants.RouteIdleTimeoutAnnotation: `{"events": "0s"}`},
+			want:        durationpb.New(0),
+		},
+		{
+			name:        "no default",
+			routeName:   "ratings",
+			annotations: map[string]string{constants.RouteIdleTimeoutAnnotation: `{"events": "1h"}`},
+		},
+		{
+			name:        "invalid annotation",
+			routeName:   "events",
+			annotations: map[string]string{constants.RouteIdleTimeoutAnnotation: "1h"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &route.RouteAction{}
+			applyIdleTimeout(action, tt.routeName, routeAnnotations(tt.annotations))
+			if got := action.IdleTimeout; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyIdleTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMirrors(t *testing.T) {
+	mirrors := `{"reviews": [
+		{"host": "reviews-staging", "subset": "v2", "percentage": 10},
+		{"host": "reviews.qa.svc.cluster.local", "port": 8080},
+		{"host": "reviews-off", "percentage": 0}
+	]}`
+	serviceRegistry := map[host.Name]*model.Service{
+		"reviews-staging.default.svc.cluster.local": {Ports: model.PortList{{Port: 9080}}},
+	}
+	mirror := func(cluster string, percent float64) *route.RouteAction_RequestMirrorPolicy {
+		return &route.RouteAction_RequestMirrorPolicy{
+			Cluster:         cluster,
+			RuntimeFraction: &core.RuntimeFractionalPercent{DefaultValue: translatePercentToFractionalPercent(&networking.Percent{Value: percent})},
+			TraceSampled:    &wrappers.BoolValue{Value: false},
+		}
+	}
+	cases := []struct {
+		name        string
+		routeName   string
+		annotations map[string]string
+		want        []*route.RouteAction_RequestMirrorPolicy
+	}{
+		{
+			name:      "no annotations",
+			routeName: "reviews",
+		},
+		{
+			name:        "named route",
+			routeName:   "reviews",
+			annotations: map[string]string{constants.MirrorsAnnotation: mirrors},
+			want: []*route.RouteAction_RequestMirrorPolicy{
+				mirror("existing", 100),
+				mirror("outbound|9080|v2|reviews-staging.default.svc.cluster.local", 10),
+				mirror("outbound|8080||reviews.qa.svc.cluster.local", 100),
+			},
+		},
+		{
+			name:        "other route",
+			routeName:   "ratings",
+			annotations: map[string]string{constants.MirrorsAnnotation: mirrors},
+		},
+		{
+			name:        "invalid annotation",
+			routeName:   "reviews",
+			annotations: map[string]string{constants.MirrorsAnnotation: `{"reviews": [{"percentage": 10}]}`},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &route.RouteAction{RequestMirrorPolicies: []*route.RouteAction_RequestMirrorPolicy{mirror("existing", 100)}}
+			vs := config.Config{Meta: config.Meta{Name: "reviews", Namespace: "default", Domain: "cluster.local", Annotations: tt.annotations}}
+			applyMirrors(action, tt.routeName, vs, istionetworking.ParseRouteAnnotations(vs.Meta), serviceRegistry, 80)
+			want := tt.want
+			if want == nil {
+				want = []*route.RouteAction_RequestMirrorPolicy{mirror("existing", 100)}
+			}
+			if got := action.RequestMirrorPolicies; !reflect.DeepEqual(got, want) {
+				t.Errorf("applyMirrors() = \n%v, want \n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyDirectResponse(t *testing.T) {
+	responses := map[string]string{
+		constants.DirectResponseAnnotation: `{"maintenance": {"status": 503, "body": "down", "headers": {"retry-after": "60"}}}`,
+	}
+	cases := []struct {
+		name        string
+		routeName   string
+		annotations map[string]string
+		want        *route.Route
+	}{
+		{
+			name:      "no annotations",
+			routeName: "maintenance",
+		},
+		{
+			name:        "named route",
+			routeName:   "maintenance",
+			annotations: responses,
+			want: &route.Route{
+				Action: &route.Route_DirectResponse{DirectResponse: &route.DirectResponseAction{
+					Status: 503,
+					Body:   &core.DataSource{Specifier: &core.DataSource_InlineString{InlineString: "down"}},
+				}},
+				ResponseHeadersToAdd: []*core.HeaderValueOption{{
+					Header: &core.HeaderValue{Key: "retry-after", Value: "60"},
+					Append: &wrappers.BoolValue{Value: false},
+				}},
+			},
+		},
+		{
+			name:        "no body",
+			routeName:   "maintenance",
+			annotations: map[string]string{constants.DirectResponseAnnotation: `{"maintenance": {"status": 204}}`},
+			want: &route.Route{
+				Action: &route.Route_DirectResponse{DirectResponse: &route.DirectResponseAction{Status: 204}},
+			},
+		},
+		{
+			name:        "other route",
+			routeName:   "reviews",
+			annotations: responses,
+		},
+		{
+			name:        "unnamed route",
+			annotations: responses,
+		},
+		{
+			name:        "invalid annotation",
+			routeName:   "maintenance",
+			annotations: map[string]string{constants.DirectResponseAnnotation: `{"maintenance": {"status": 99}}`},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &route.Route{}
+			applied := applyDirectResponse(out, tt.routeName, routeAnnotations(tt.annotations))
+			if applied != (tt.want != nil) {
+				t.Fatalf("applyDirectResponse() = %v, want %v", applied, tt.want != nil)
+			}
+			if applied && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("applyDirectResponse() = \n%v, want \n%v", out, tt.want)
+			}
+		})
+	}
+}
+
 func TestMirrorPercent(t *testing.T) {
 	cases := []struct {
 		name  string
@@ -510,6 +828,25 @@ func TestTranslateMetadataMatch(t *testing.T) {
 			in:   &networking.StringMatch{MatchType: &networking.StringMatch_Regex{Regex: ".+?\\..+?\\..+?"}},
 			want: authz.MetadataMatcherForJWTClaims([]string{"regex"}, authzmatcher.StringMatcherRegex(".+?\\..+?\\..+?")),
 		},
+		{
+			name: "@request.auth.claims[example.com/groups][id]",
+			in:   &networking.StringMatch{MatchType: &networking.StringMatch_Exact{Exact: "exact"}},
+			want: authz.MetadataMatcherForJWTClaims([]string{"example.com/groups", "id"}, authzmatcher.StringMatcher("exact")),
+		},
+		{
+			name: "@request.auth.claims[example.com/groups",
+			in:   &networking.StringMatch{MatchType: &networking.StringMatch_Exact{Exact: "exact"}},
+		},
+		{
+			name: "@request.auth.claims.present",
+			in:   &networking.StringMatch{},
+			want: authz.MetadataMatcherForJWTClaimsPresence([]string{"present"}),
+		},
+		{
+			name: "@request.auth.claims.catch-all",
+			in:   &networking.StringMatch{MatchType: &networking.StringMatch_Regex{Regex: "*"}},
+			want: authz.MetadataMatcherForJWTClaimsPresence([]string{"catch-all"}),
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -520,3 +857,137 @@ func TestTranslateMetadataMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyRouteLocalRateLimit(t *testing.T) {
+	defer func(enabled bool) { features.EnableLocalRateLimit = enabled }(features.EnableLocalRateLimit)
+	features.EnableLocalRateLimit = true
+
+	annotations := routeAnnotations(map[string]string{
+		constants.LocalRateLimitAnnotation: `{"reviews": {"maxTokens": 100, "tokensPerFill": 10, "fillInterval": "1s",
+			"descriptors": [{"header": "x-plan", "value": "free", "maxTokens": 10, "fillInterval": "2s"}]}}`,
+	})
+	out := &route.Route{Action: &route.Route_Route{Route: &route.RouteAction{}}}
+	applyRouteLocalRateLimit(out, "reviews", annotations)
+
+	config := &localratelimit.LocalRateLimit{}
+	if err := out.TypedPerFilterConfig[util.LocalRateLimitFilter].UnmarshalTo(config); err != nil {
+		t.Fatal(err)
+	}
+	bucket := &xdstype.TokenBucket{
+		MaxTokens:     100,
+		TokensPerFill: &wrappers.UInt32Value{Value: 10},
+		FillInterval:  durationpb.New(time.Second),
+	}
+	if !proto.Equal(config.TokenBucket, bucket) {
+		t.Errorf("unexpected token bucket %v", config.TokenBucket)
+	}
+	if config.FilterEnforced.GetDefaultValue().GetNumerator() != 100 {
+		t.Errorf("expected the limit to be enforced, got %v", config.FilterEnforced)
+	}
+	if len(config.Descriptors) != 1 || config.Descriptors[0].Entries[0].Key != "x-plan" ||
+		config.Descriptors[0].TokenBucket.TokensPerFill.GetValue() != 10 {
+		t.Errorf("unexpected descriptors %v", config.Descriptors)
+	}
+	rateLimits := out.GetRoute().RateLimits
+	if len(rateLimits) != 1 || rateLimits[0].Actions[0].GetRequestHeaders().GetHeaderName() != "x-plan" {
+		t.Errorf("unexpected rate limits %v", rateLimits)
+	}
+
+	// Other routes are not limited without a default.
+	out = &route.Route{Action: &route.Route_Route{Route: &route.RouteAction{}}}
+	applyRouteLocalRateLimit(out, "ratings", annotations)
+	if out.TypedPerFilterConfig != nil {
+		t.Errorf("unexpected per filter config %v", out.TypedPerFilterConfig)
+	}
+
+	// Nothing is applied while the filter is disabled.
+	features.EnableLocalRateLimit = false
+	out = &route.Route{Action: &route.Route_Route{Route: &route.RouteAction{}}}
+	applyRouteLocalRateLimit(out, "reviews", annotations)
+	if out.TypedPerFilterConfig != nil {
+		t.Errorf("unexpected per filter config %v", out.TypedPerFilterConfig)
+	}
+}
+
+func TestApplyGlobalRateLimit(t *testing.T) {
+	defer func(service string) { features.GlobalRateLimitService = service }(features.GlobalRateLimitService)
+	features.GlobalRateLimitService = "ratelimit.istio-system.svc.cluster.local:8081"
+
+	vs := config.Config{Meta: config.Meta{
+		Name:      "reviews",
+		Namespace: "default",
+		Annotations: map[string]string{
+			constants.GlobalRateLimitAnnotation: `{"v1": [["route", "header:x-user-id"]], "*": [["destination"], ["path", "source"]]}`,
+		},
+	}}
+	annotations := istionetworking.ParseRouteAnnotations(vs.Meta)
+	action := &route.RouteAction{}
+	applyGlobalRateLimit(action, "v1", vs, annotations)
+	expected := &route.RateLimit{
+		Stage: &wrappers.UInt32Value{Value: xdsfilters.GlobalRateLimitStage},
+		Actions: []*route.RateLimit_Action{
+			{ActionSpecifier: &route.RateLimit_Action_GenericKey_{GenericKey: &route.RateLimit_Action_GenericKey{
+				DescriptorKey:   "route",
+				DescriptorValue: "default/reviews/v1",
+			}}},
+			{ActionSpecifier: &route.RateLimit_Action_RequestHeaders_{RequestHeaders: &route.RateLimit_Action_RequestHeaders{
+				HeaderName:    "x-user-id",
+				DescriptorKey: "x-user-id",
+			}}},
+		},
+	}
+	if len(action.RateLimits) != 1 || !proto.Equal(action.RateLimits[0], expected) {
+		t.Errorf("got rate limits %v, expected %v", action.RateLimits, expected)
+	}
+
+	// The other routes use the descriptors of all routes.
+	action = &route.RouteAction{}
+	applyGlobalRateLimit(action, "v2", vs, annotations)
+	if len(action.RateLimits) != 2 || action.RateLimits[0].Actions[0].GetDestinationCluster() == nil ||
+		action.RateLimits[1].Actions[0].GetRequestHeaders().GetHeaderName() != ":path" ||
+		action.RateLimits[1].Actions[1].GetRemoteAddress() == nil {
+		t.Errorf("unexpected rate limits %v", action.RateLimits)
+	}
+
+	// Nothing is applied without a rate limit service.
+	features.GlobalRateLimitService = ""
+	action = &route.RouteAction{}
+	applyGlobalRateLimit(action, "v1", vs, annotations)
+	if action.RateLimits != nil {
+		t.Errorf("unexpected rate limits %v", action.RateLimits)
+	}
+}
+
+func TestApplyExtProcOverrides(t *testing.T) {
+	annotations := routeAnnotations(map[string]string{
+		constants.ExtProcOverridesAnnotation: `{"health": {"istio-system/ext-proc": {"disabled": true}},
+			"*": {"istio-system/ext-proc": {"processingMode": {"requestBodyMode": "STREAMED"}}}}`,
+	})
+	out := &route.Route{}
+	applyExtProcOverrides(out, "health", annotations)
+	expected := util.MessageToAny(&extproc.ExtProcPerRoute{
+		Override: &extproc.ExtProcPerRoute_Disabled{Disabled: true},
+	})
+	if got := out.TypedPerFilterConfig["istio-system.ext-proc"]; !proto.Equal(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	// The other routes use the overrides of all routes.
+	out = &route.Route{}
+	applyExtProcOverrides(out, "reviews", annotations)
+	expected = util.MessageToAny(&extproc.ExtProcPerRoute{
+		Override: &extproc.ExtProcPerRoute_Overrides{Overrides: &extproc.ExtProcOverrides{
+			ProcessingMode: &extproc.ProcessingMode{RequestBodyMode: extproc.ProcessingMode_STREAMED},
+		}},
+	})
+	if got := out.TypedPerFilterConfig["istio-system.ext-proc"]; !proto.Equal(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	// Nothing is applied without the annotation.
+	out = &route.Route{}
+	applyExtProcOverrides(out, "health", nil)
+	if out.TypedPerFilterConfig != nil {
+		t.Errorf("unexpected per filter config %v", out.TypedPerFilterConfig)
+	}
+}
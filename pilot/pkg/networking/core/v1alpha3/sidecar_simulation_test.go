@@ -37,6 +37,7 @@ import (
 	"istio.io/istio/pilot/pkg/xds"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/protocol"
@@ -453,6 +454,57 @@ func TestInboundClusters(t *testing.T) {
 	}
 }
 
+func TestInboundPortRanges(t *testing.T) {
+	proxy := &model.Proxy{
+		IPAddresses: []string{"1.2.3.4"},
+		Metadata: &model.NodeMetadata{
+			Annotations: map[string]string{constants.InboundPortRangesAnnotation: "10000-10001"},
+		},
+	}
+	service := &model.Service{
+		Hostname:       host.Name("media.default.svc.cluster.local"),
+		DefaultAddress: "1.1.1.1",
+		Ports: model.PortList{
+			&model.Port{Name: "http", Port: 80, Protocol: protocol.HTTP},
+			&model.Port{Name: "rtp-0", Port: 10000, Protocol: protocol.TCP},
+			&model.Port{Name: "rtp-1", Port: 10001, Protocol: protocol.TCP},
+		},
+		Resolution: model.ClientSideLB,
+	}
+	s := v1alpha3.NewConfigGenTest(t, v1alpha3.TestOptions{
+		Services: []*model.Service{service},
+		Instances: flattenInstances(
+			makeInstances(proxy, service, 80, 8080),
+			makeInstances(proxy, service, 10000, 10000),
+			makeInstances(proxy, service, 10001, 10001)),
+	})
+	sim := simulation.NewSimulationFromConfigGen(t, s, s.SetupProxy(proxy))
+
+	clusters := xdstest.MapKeys(xdstest.ExtractClusters(xdstest.FilterClusters(sim.Clusters, func(c *cluster.Cluster) bool {
+		return strings.HasPrefix(c.Name, "inbound")
+	})))
+	if want := []string{"inbound|8080||"}; !reflect.DeepEqual(clusters, want) {
+		t.Fatalf("expected clusters %v, got %v", want, clusters)
+	}
+	sim.Run(simulation.Call{
+		Port:     8080,
+		Protocol: simulation.HTTP,
+		Address:  "1.2.3.4",
+		CallMode: simulation.CallModeInbound,
+	}).Matches(t, simulation.Result{ClusterMatched: "inbound|8080||"})
+	for _, port := range []int{10000, 10001} {
+		sim.Run(simulation.Call{
+			Port:     port,
+			Protocol: simulation.TCP,
+			Address:  "1.2.3.4",
+			CallMode: simulation.CallModeInbound,
+		}).Matches(t, simulation.Result{
+			ClusterMatched:     "InboundPassthroughClusterIpv4",
+			FilterChainMatched: "virtualInbound",
+		})
+	}
+}
+
 type clusterServicesMetadata struct {
 	Services []struct {
 		Host      string
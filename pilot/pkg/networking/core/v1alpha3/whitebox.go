@@ -0,0 +1,52 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"strings"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/host"
+)
+
+// whiteboxLocalDomains returns the upstream service of an egress listener which statically maps a local port of the
+// sidecar to a single service, along with the domains of the requests of the applications to the local port. This
+// is the whitebox mode, in which the traffic of the applications is not intercepted, and they are configured to
+// talk to the sidecar on the local ports instead: the egress listener has an explicit port the sidecar binds to, and
+// its hosts select a single service, which the requests addressed to the local port are routed to.
+func whiteboxLocalDomains(node *model.Proxy, egressListener *model.IstioEgressListenerWrapper, listenerPort int,
+	services map[host.Name]*model.Service) (host.Name, []string) {
+	if len(services) != 1 || egressListener.IstioListener == nil || egressListener.IstioListener.Port == nil ||
+		int(egressListener.IstioListener.Port.Number) != listenerPort || !egressBindsToPort(node, egressListener) {
+		return "", nil
+	}
+	bind := egressListener.IstioListener.Bind
+	if strings.HasPrefix(bind, model.UnixAddressPrefix) {
+		return "", nil
+	}
+	if bind == "" {
+		_, bind = getActualWildcardAndLocalHost(node)
+	}
+	var upstream host.Name
+	for hostname := range services {
+		upstream = hostname
+	}
+	var domains []string
+	for _, addr := range []string{bind, "localhost"} {
+		domains = append(domains, util.IPv6Compliant(addr), util.DomainName(addr, listenerPort))
+	}
+	return upstream, domains
+}
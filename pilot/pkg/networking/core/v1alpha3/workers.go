@@ -0,0 +1,62 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"istio.io/istio/pilot/pkg/features"
+)
+
+// configGenerationWorkers returns the number of workers to use to build n independent resources of a proxy.
+func configGenerationWorkers(n int) int {
+	workers := features.ConfigGenerationWorkers
+	if workers > n {
+		workers = n
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// buildInParallel calls build for each index in [0, n) using up to the given number of goroutines, and returns
+// once all calls completed. Each call is passed the index of the worker running it, in [0, workers), so callers
+// can keep per-worker state without locking. Callers should store results by index to keep a deterministic order.
+func buildInParallel(n, workers int, build func(worker, i int)) {
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			build(0, i)
+		}
+		return
+	}
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(worker int) {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				build(worker, i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
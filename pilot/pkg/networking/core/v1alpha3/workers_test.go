@@ -0,0 +1,154 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/test/xdstest"
+	"istio.io/istio/pkg/config/visibility"
+)
+
+func TestBuildInParallel(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 10} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			n := 100
+			results := make([]int, n)
+			var mu sync.Mutex
+			seenWorkers := map[int]struct{}{}
+			buildInParallel(n, workers, func(worker, i int) {
+				results[i] += i
+				mu.Lock()
+				seenWorkers[worker] = struct{}{}
+				mu.Unlock()
+			})
+			for i, r := range results {
+				if r != i {
+					t.Fatalf("index %d built with result %d", i, r)
+				}
+			}
+			for w := range seenWorkers {
+				if w < 0 || (workers > 1 && w >= workers) || (workers <= 1 && w != 0) {
+					t.Fatalf("unexpected worker %d", w)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigGenerationWorkers(t *testing.T) {
+	defaultValue := features.ConfigGenerationWorkers
+	defer func() { features.ConfigGenerationWorkers = defaultValue }()
+
+	cases := []struct {
+		workers int
+		n       int
+		want    int
+	}{
+		{workers: 1, n: 10, want: 1},
+		{workers: 4, n: 10, want: 4},
+		{workers: 4, n: 2, want: 2},
+		{workers: 4, n: 0, want: 1},
+		{workers: 0, n: 10, want: 1},
+	}
+	for _, tt := range cases {
+		features.ConfigGenerationWorkers = tt.workers
+		if got := configGenerationWorkers(tt.n); got != tt.want {
+			t.Errorf("configGenerationWorkers(%d) with %d workers: got %d, want %d", tt.n, tt.workers, got, tt.want)
+		}
+	}
+}
+
+func TestParallelConfigGenerationIsDeterministic(t *testing.T) {
+	defaultValue := features.ConfigGenerationWorkers
+	defer func() { features.ConfigGenerationWorkers = defaultValue }()
+
+	var services []*model.Service
+	for i := 0; i < 20; i++ {
+		services = append(services, buildHTTPService(fmt.Sprintf("svc-%d.example.com", i), visibility.Public,
+			fmt.Sprintf("10.0.0.%d", i+1), "default", 80, 8000+i, 9000+i))
+	}
+	gatewayConfig := `apiVersion: networking.istio.io/v1alpha3
+kind: Gateway
+metadata:
+  name: gateway
+  namespace: istio-system
+spec:
+  selector:
+    istio: ingressgateway
+  servers:
+  - hosts: ["*"]
+    port: {name: http-80, number: 80, protocol: HTTP}
+  - hosts: ["*"]
+    port: {name: http-8080, number: 8080, protocol: HTTP}
+  - hosts: ["*"]
+    port: {name: http-9090, number: 9090, protocol: HTTP}
+`
+	build := func(t *testing.T, workers int, proxy *model.Proxy) ([]*route.RouteConfiguration, []*cluster.Cluster) {
+		features.ConfigGenerationWorkers = workers
+		cg := NewConfigGenTest(t, TestOptions{Services: services, ConfigString: gatewayConfig})
+		p := cg.SetupProxy(proxy)
+		// Listeners are extracted in random order, so sort the route names to compare the output.
+		routeNames := xdstest.ExtractRoutesFromListeners(cg.Listeners(p))
+		sort.Strings(routeNames)
+		resources, _ := cg.ConfigGen.BuildHTTPRoutes(p, &model.PushRequest{Push: cg.PushContext()}, routeNames)
+		routes := make([]*route.RouteConfiguration, 0, len(resources))
+		for _, r := range resources {
+			rc := &route.RouteConfiguration{}
+			if err := r.Resource.UnmarshalTo(rc); err != nil {
+				t.Fatal(err)
+			}
+			routes = append(routes, rc)
+		}
+		return routes, cg.Clusters(p)
+	}
+	proxies := map[string]func() *model.Proxy{
+		"sidecar": func() *model.Proxy { return nil },
+		"gateway": func() *model.Proxy {
+			return &model.Proxy{
+				Type: model.Router,
+				Metadata: &model.NodeMetadata{
+					Labels:    map[string]string{"istio": "ingressgateway"},
+					Namespace: "istio-system",
+				},
+			}
+		},
+	}
+	for name, proxy := range proxies {
+		t.Run(name, func(t *testing.T) {
+			wantRoutes, wantClusters := build(t, 1, proxy())
+			if len(wantRoutes) < 2 {
+				t.Fatalf("expected several routes, got %d", len(wantRoutes))
+			}
+			gotRoutes, gotClusters := build(t, 8, proxy())
+			if diff := cmp.Diff(wantRoutes, gotRoutes, protocmp.Transform()); diff != "" {
+				t.Errorf("routes built in parallel differ: %v", diff)
+			}
+			if diff := cmp.Diff(wantClusters, gotClusters, protocmp.Transform()); diff != "" {
+				t.Errorf("clusters built in parallel differ: %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	extproc "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"istio.io/istio/pkg/config/host"
+)
+
+// ExtProc is an external processing gRPC service the requests and responses of a proxy are sent to.
+type ExtProc struct {
+	// Service is the hostname of the service of the processor.
+	Service host.Name
+	// Port is the port of the service, which must be a gRPC port.
+	Port int
+	// Timeout bounds the exchange of each message with the processor. Envoy defaults to 200ms if unset.
+	Timeout time.Duration
+	// FailOpen lets the requests through if the processor cannot be reached.
+	FailOpen bool
+	// ProcessingMode selects the parts of the requests and responses sent to the processor.
+	ProcessingMode *extproc.ProcessingMode
+}
+
+type extProcJSON struct {
+	Service        string          `json:"service"`
+	Port           int             `json:"port"`
+	Timeout        string          `json:"timeout,omitempty"`
+	FailOpen       bool            `json:"failOpen,omitempty"`
+	ProcessingMode json.RawMessage `json:"processingMode,omitempty"`
+}
+
+// ParseExtProc parses an external processor, for example {"service": "ext-proc.foo.svc.cluster.local", "port": 9000,
+// "timeout": "100ms", "failOpen": true, "processingMode": {"requestBodyMode": "BUFFERED", "responseHeaderMode":
+// "SKIP"}}. The processing mode has the fields of the Envoy ProcessingMode.
+func ParseExtProc(value string) (*ExtProc, error) {
+	var in extProcJSON
+	if err := json.Unmarshal([]byte(value), &in); err != nil {
+		return nil, fmt.Errorf("invalid ext_proc: %v", err)
+	}
+	if in.Service == "" || strings.Contains(in.Service, "/") {
+		return nil, fmt.Errorf("invalid ext_proc service %q: must be a hostname", in.Service)
+	}
+	if in.Port < 1 || in.Port > 65535 {
+		return nil, fmt.Errorf("invalid ext_proc port %d: must be in the range [1, 65535]", in.Port)
+	}
+	out := &ExtProc{
+		Service:  host.Name(in.Service),
+		Port:     in.Port,
+		FailOpen: in.FailOpen,
+	}
+	if in.Timeout != "" {
+		d, err := time.ParseDuration(in.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid ext_proc timeout %q", in.Timeout)
+		}
+		out.Timeout = d
+	}
+	mode, err := parseProcessingMode(in.ProcessingMode)
+	if err != nil {
+		return nil, err
+	}
+	out.ProcessingMode = mode
+	return out, nil
+}
+
+// ExtProcOverride changes the external processing of the requests of a route: they are either not sent to the
+// processor, or sent with another processing mode.
+type ExtProcOverride struct {
+	Disabled       bool
+	ProcessingMode *extproc.ProcessingMode
+}
+
+type extProcOverrideJSON struct {
+	Disabled       bool            `json:"disabled,omitempty"`
+	ProcessingMode json.RawMessage `json:"processingMode,omitempty"`
+}
+
+// ParseExtProcOverrides parses the overrides of the external processors of the HTTP routes of a VirtualService, keyed
+// by route name or AllRoutes, then by the <namespace>/<name> of the WasmPlugin declaring the processor, for example
+// {"health": {"istio-system/ext-proc": {"disabled": true}}}.
+func ParseExtProcOverrides(value string) (map[string]map[string]ExtProcOverride, error) {
+	in := map[string]map[string]extProcOverrideJSON{}
+	if err := json.Unmarshal([]byte(value), &in); err != nil {
+		return nil, fmt.Errorf("invalid ext_proc overrides: %v", err)
+	}
+	out := make(map[string]map[string]ExtProcOverride, len(in))
+	for name, processors := range in {
+		if name == "" {
+			return nil, fmt.Errorf("ext_proc overrides must have a route name")
+		}
+		out[name] = make(map[string]ExtProcOverride, len(processors))
+		for plugin, o := range processors {
+			if parts := strings.Split(plugin, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return nil, fmt.Errorf("invalid ext_proc override %s of route %s: must be <namespace>/<name>", plugin, name)
+			}
+			if o.Disabled == (len(o.ProcessingMode) > 0) {
+				return nil, fmt.Errorf("ext_proc override %s of route %s must set exactly one of disabled and processingMode",
+					plugin, name)
+			}
+			mode, err := parseProcessingMode(o.ProcessingMode)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ext_proc override %s of route %s: %v", plugin, name, err)
+			}
+			out[name][plugin] = ExtProcOverride{Disabled: o.Disabled, ProcessingMode: mode}
+		}
+	}
+	return out, nil
+}
+
+// ExtProcFilterName returns the name of the HTTP filter of the external processor declared by a WasmPlugin, which is
+// referred to as <namespace>/<name>.
+func ExtProcFilterName(plugin string) string {
+	return strings.Replace(plugin, "/", ".", 1)
+}
+
+func parseProcessingMode(value json.RawMessage) (*extproc.ProcessingMode, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	mode := &extproc.ProcessingMode{}
+	if err := protojson.Unmarshal(value, mode); err != nil {
+		return nil, fmt.Errorf("invalid ext_proc processingMode: %v", err)
+	}
+	return mode, nil
+}
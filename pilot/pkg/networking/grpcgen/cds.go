@@ -21,6 +21,7 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/model"
@@ -208,9 +209,24 @@ func (b *clusterBuilder) applyTrafficPolicy(c *cluster.Cluster, trafficPolicy *n
 	}
 	b.applyTLS(c, trafficPolicy)
 	b.applyLoadBalancing(c, trafficPolicy)
+	applyOutlierDetection(c, trafficPolicy.GetOutlierDetection())
 	// TODO status or log when unsupported features are included
 }
 
+// applyOutlierDetection configures outlier detection for gRPC clients. gRPC does not implement
+// consecutive error based ejection, only the success rate and failure percentage algorithms. When
+// consecutive errors are configured, failure percentage ejection is enabled instead, using Envoy's
+// default threshold, so that failing endpoints are still ejected.
+func applyOutlierDetection(c *cluster.Cluster, outlier *networking.OutlierDetection) {
+	if outlier == nil {
+		return
+	}
+	corexds.ApplyOutlierDetection(c, outlier)
+	if outlier.GetConsecutive_5XxErrors().GetValue() > 0 || outlier.GetConsecutiveGatewayErrors().GetValue() > 0 {
+		c.OutlierDetection.EnforcingFailurePercentage = &wrappers.UInt32Value{Value: 100}
+	}
+}
+
 func (b *clusterBuilder) applyLoadBalancing(c *cluster.Cluster, policy *networking.TrafficPolicy) {
 	switch policy.GetLoadBalancer().GetSimple() {
 	case networking.LoadBalancerSettings_ROUND_ROBIN, networking.LoadBalancerSettings_UNSPECIFIED:
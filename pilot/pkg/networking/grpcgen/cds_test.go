@@ -0,0 +1,91 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcgen
+
+import (
+	"testing"
+	"time"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	"github.com/gogo/protobuf/types"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
+
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func TestApplyOutlierDetection(t *testing.T) {
+	cases := []struct {
+		name string
+		in   *networking.OutlierDetection
+		want *cluster.OutlierDetection
+	}{
+		{
+			name: "nil",
+			in:   nil,
+			want: nil,
+		},
+		{
+			name: "consecutive 5xx errors enable failure percentage",
+			in: &networking.OutlierDetection{
+				Consecutive_5XxErrors: &types.UInt32Value{Value: 5},
+				Interval:              types.DurationProto(10 * time.Second),
+				MaxEjectionPercent:    50,
+			},
+			want: &cluster.OutlierDetection{
+				Consecutive_5Xx:            &wrappers.UInt32Value{Value: 5},
+				EnforcingConsecutive_5Xx:   &wrappers.UInt32Value{Value: 100},
+				EnforcingSuccessRate:       &wrappers.UInt32Value{Value: 0},
+				EnforcingFailurePercentage: &wrappers.UInt32Value{Value: 100},
+				Interval:                   durationpb.New(10 * time.Second),
+				MaxEjectionPercent:         &wrappers.UInt32Value{Value: 50},
+			},
+		},
+		{
+			name: "consecutive gateway errors enable failure percentage",
+			in: &networking.OutlierDetection{
+				ConsecutiveGatewayErrors: &types.UInt32Value{Value: 3},
+			},
+			want: &cluster.OutlierDetection{
+				ConsecutiveGatewayFailure:          &wrappers.UInt32Value{Value: 3},
+				EnforcingConsecutiveGatewayFailure: &wrappers.UInt32Value{Value: 100},
+				EnforcingSuccessRate:               &wrappers.UInt32Value{Value: 0},
+				EnforcingFailurePercentage:         &wrappers.UInt32Value{Value: 100},
+			},
+		},
+		{
+			name: "disabled consecutive errors",
+			in: &networking.OutlierDetection{
+				Consecutive_5XxErrors: &types.UInt32Value{Value: 0},
+			},
+			want: &cluster.OutlierDetection{
+				Consecutive_5Xx:          &wrappers.UInt32Value{Value: 0},
+				EnforcingConsecutive_5Xx: &wrappers.UInt32Value{Value: 0},
+				EnforcingSuccessRate:     &wrappers.UInt32Value{Value: 0},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cluster.Cluster{}
+			applyOutlierDetection(c, tt.in)
+			if diff := cmp.Diff(tt.want, c.OutlierDetection, protocmp.Transform()); diff != "" {
+				t.Fatalf("unexpected outlier detection (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -32,6 +32,7 @@ import (
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/collections"
@@ -354,6 +355,75 @@ spec:
 	// TODO test timeouts, aborts
 }
 
+func TestOutlierDetection(t *testing.T) {
+	tt := newConfigGenTest(t, xds.FakeOptions{
+		KubernetesObjectString: `
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app: echo-app
+  name: echo-app
+  namespace: default
+spec:
+  clusterIP: 1.2.3.4
+  selector:
+    app: echo
+  ports:
+  - name: grpc
+    targetPort: grpc
+    port: 7072
+`,
+		ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: echo-dr
+  namespace: default
+spec:
+  host: echo-app.default.svc.cluster.local
+  trafficPolicy:
+    outlierDetection:
+      consecutive5xxErrors: 1
+      interval: 1s
+      baseEjectionTime: 30s
+      maxEjectionPercent: 50
+`,
+	}, echoCfg{version: "v1"}, echoCfg{version: "v2"})
+
+	retry.UntilSuccessOrFail(tt.T, func() error {
+		cw := tt.dialEcho("xds:///echo-app.default.svc.cluster.local:7072")
+		distribution := map[string]int{}
+		for i := 0; i < 100; i++ {
+			res, err := cw.Echo(context.Background(), &proto.EchoRequest{Message: "needle"})
+			if err != nil {
+				return err
+			}
+			distribution[res.Version]++
+		}
+		if err := expectAlmost(distribution["v1"], 50); err != nil {
+			return err
+		}
+		return expectAlmost(distribution["v2"], 50)
+	}, retry.Timeout(5*time.Second), retry.Delay(0))
+
+	// A client rejecting the outlier detection of the cluster NACKs it and keeps its previous clusters.
+	retry.UntilSuccessOrFail(tt.T, func() error {
+		for _, conn := range tt.ds.Discovery.AllClients() {
+			if !conn.Watching(v3.ClusterType) {
+				continue
+			}
+			if acked, sent := conn.NonceAcked(v3.ClusterType), conn.NonceSent(v3.ClusterType); acked != sent {
+				return fmt.Errorf("%s did not ACK its clusters: acked nonce %q, sent nonce %q", conn.ConID, acked, sent)
+			}
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+
+	// The gRPC Go release vendored here accepts outlier detection but does not eject endpoints yet, which
+	// requires gRPC Go 1.50. Until it is updated, failing one endpoint would not change the distribution.
+}
+
 func expectAlmost(got, want int) error {
 	if math.Abs(float64(want-got)) > 10 {
 		return fmt.Errorf("expected within %d of %d but got %d", 10, want, got)
@@ -29,9 +29,12 @@ import (
 	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/plugin"
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/security/authn"
 	"istio.io/istio/pilot/pkg/security/authn/factory"
+	"istio.io/istio/pilot/pkg/security/authz/builder"
+	"istio.io/istio/pilot/pkg/security/trustdomain"
 	"istio.io/istio/pilot/pkg/util/sets"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pkg/config/labels"
@@ -136,19 +139,41 @@ func buildInboundFilterChains(node *model.Proxy, push *model.PushContext, si *mo
 		mode = model.MTLSDisable
 	}
 
+	httpFilters := append(buildRBAC(node, push), xdsfilters.Router)
+
 	var out []*listener.FilterChain
 	switch mode {
 	case model.MTLSDisable:
-		out = append(out, buildInboundFilterChain("plaintext", nil))
+		out = append(out, buildInboundFilterChain("plaintext", nil, httpFilters))
 	case model.MTLSStrict:
-		out = append(out, buildInboundFilterChain("mtls", tlsContext))
+		out = append(out, buildInboundFilterChain("mtls", tlsContext, httpFilters))
 		// TODO permissive builts both plaintext and mtls; when tlsContext is present add a match for protocol
 	}
 
 	return out
 }
 
-func buildInboundFilterChain(nameSuffix string, tlsContext *tls.DownstreamTlsContext) *listener.FilterChain {
+// buildRBAC builds the RBAC filters for the AuthorizationPolicies selecting the node. Only the subset of
+// policies gRPC can enforce is included; see builder.Option.IsProxylessGrpc.
+func buildRBAC(node *model.Proxy, push *model.PushContext) []*hcm.HttpFilter {
+	if push.AuthzPolicies == nil {
+		return nil
+	}
+	in := &plugin.InputParams{Node: node, Push: push}
+	option := builder.Option{
+		IsProxylessGrpc: true,
+		Logger:          &builder.AuthzLogger{},
+	}
+	defer option.Logger.Report(in)
+	tdBundle := trustdomain.NewBundle(push.Mesh.TrustDomain, push.Mesh.TrustDomainAliases)
+	b := builder.New(tdBundle, in, option)
+	if b == nil {
+		return nil
+	}
+	return b.BuildHTTP()
+}
+
+func buildInboundFilterChain(nameSuffix string, tlsContext *tls.DownstreamTlsContext, httpFilters []*hcm.HttpFilter) *listener.FilterChain {
 	out := &listener.FilterChain{
 		Name:             "inbound-" + nameSuffix,
 		FilterChainMatch: nil,
@@ -171,7 +196,7 @@ func buildInboundFilterChain(nameSuffix string, tlsContext *tls.DownstreamTlsCon
 							}},
 						},
 					},
-					HttpFilters: []*hcm.HttpFilter{xdsfilters.Router},
+					HttpFilters: httpFilters,
 				}),
 			},
 		}},
@@ -15,12 +15,15 @@
 package grpcgen
 
 import (
+	"strings"
+
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3"
 	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/util/sets"
 )
 
 // BuildHTTPRoutes supports per-VIP routes, as used by GRPC.
@@ -48,6 +51,13 @@ func buildHTTPRoute(node *model.Proxy, push *model.PushContext, routeName string
 	}
 
 	virtualHosts, _, _ := v1alpha3.BuildSidecarOutboundVirtualHosts(node, push, routeName, port, nil, &model.DisabledCache{})
+	for _, vh := range virtualHosts {
+		for _, r := range vh.Routes {
+			if action := r.GetRoute(); action != nil {
+				action.RetryPolicy = buildRetryPolicy(action.RetryPolicy)
+			}
+		}
+	}
 
 	// Only generate the required route for grpc. Will need to generate more
 	// as GRPC adds more features.
@@ -56,3 +66,29 @@ func buildHTTPRoute(node *model.Proxy, push *model.PushContext, routeName string
 		VirtualHosts: virtualHosts,
 	}
 }
+
+// grpcRetryOn are the retry conditions supported by gRPC clients. gRPC only retries on status codes.
+var grpcRetryOn = sets.NewSet("cancelled", "deadline-exceeded", "internal", "resource-exhausted", "unavailable")
+
+// buildRetryPolicy trims a retry policy to the fields gRPC supports: num_retries, retry_back_off and the
+// retry_on status code conditions. If no supported condition remains, nil is returned, disabling retries.
+func buildRetryPolicy(in *route.RetryPolicy) *route.RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	var retryOn []string
+	for _, cond := range strings.Split(in.RetryOn, ",") {
+		cond = strings.TrimSpace(cond)
+		if grpcRetryOn.Contains(cond) {
+			retryOn = append(retryOn, cond)
+		}
+	}
+	if len(retryOn) == 0 || in.GetNumRetries().GetValue() == 0 {
+		return nil
+	}
+	return &route.RetryPolicy{
+		RetryOn:      strings.Join(retryOn, ","),
+		NumRetries:   in.NumRetries,
+		RetryBackOff: in.RetryBackOff,
+	}
+}
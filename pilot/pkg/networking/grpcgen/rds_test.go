@@ -0,0 +1,74 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcgen
+
+import (
+	"testing"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBuildRetryPolicy(t *testing.T) {
+	cases := []struct {
+		name string
+		in   *route.RetryPolicy
+		want *route.RetryPolicy
+	}{
+		{
+			name: "nil",
+			in:   nil,
+			want: nil,
+		},
+		{
+			name: "unsupported conditions dropped",
+			in: &route.RetryPolicy{
+				RetryOn:                       "connect-failure,refused-stream,unavailable,cancelled,retriable-status-codes",
+				NumRetries:                    &wrappers.UInt32Value{Value: 3},
+				HostSelectionRetryMaxAttempts: 5,
+			},
+			want: &route.RetryPolicy{
+				RetryOn:    "unavailable,cancelled",
+				NumRetries: &wrappers.UInt32Value{Value: 3},
+			},
+		},
+		{
+			name: "no supported conditions",
+			in: &route.RetryPolicy{
+				RetryOn:    "connect-failure,5xx",
+				NumRetries: &wrappers.UInt32Value{Value: 3},
+			},
+			want: nil,
+		},
+		{
+			name: "zero retries",
+			in: &route.RetryPolicy{
+				RetryOn:    "unavailable",
+				NumRetries: &wrappers.UInt32Value{Value: 0},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildRetryPolicy(tt.in)
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Fatalf("unexpected retry policy (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const lbPolicyTypePrefix = "type.googleapis.com/envoy.extensions.load_balancing_policies."
+
+// loadBalancingPolicies are the Envoy load balancing policy extensions that may be configured on a cluster,
+// keyed by type URL.
+var loadBalancingPolicies = map[string]string{
+	lbPolicyTypePrefix + "client_side_weighted_round_robin.v3.ClientSideWeightedRoundRobin": "envoy.load_balancing_policies.client_side_weighted_round_robin",
+	lbPolicyTypePrefix + "least_request.v3.LeastRequest":                                    "envoy.load_balancing_policies.least_request",
+	lbPolicyTypePrefix + "maglev.v3.Maglev":                                                 "envoy.load_balancing_policies.maglev",
+	lbPolicyTypePrefix + "random.v3.Random":                                                 "envoy.load_balancing_policies.random",
+	lbPolicyTypePrefix + "ring_hash.v3.RingHash":                                            "envoy.load_balancing_policies.ring_hash",
+	lbPolicyTypePrefix + "round_robin.v3.RoundRobin":                                        "envoy.load_balancing_policies.round_robin",
+	lbPolicyTypePrefix + "wrr_locality.v3.WrrLocality":                                      "envoy.load_balancing_policies.wrr_locality",
+}
+
+// ParseLoadBalancingPolicy parses the JSON form of a load balancing policy extension config, for example
+// {"@type": "type.googleapis.com/envoy.extensions.load_balancing_policies.round_robin.v3.RoundRobin"}.
+// Only the extensions in the allowlist are accepted. Extensions whose config type is not known to istiod can
+// only be configured with their default settings.
+func ParseLoadBalancingPolicy(value string) (*core.TypedExtensionConfig, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return nil, fmt.Errorf("invalid load balancing policy: %v", err)
+	}
+	var typeURL string
+	if err := json.Unmarshal(fields["@type"], &typeURL); err != nil || typeURL == "" {
+		return nil, fmt.Errorf("load balancing policy must have a @type")
+	}
+	name, f := loadBalancingPolicies[typeURL]
+	if !f {
+		return nil, fmt.Errorf("unsupported load balancing policy %s", typeURL)
+	}
+	typedConfig := &anypb.Any{TypeUrl: typeURL}
+	if _, err := protoregistry.GlobalTypes.FindMessageByURL(typeURL); err == nil {
+		if err := protojson.Unmarshal([]byte(value), typedConfig); err != nil {
+			return nil, fmt.Errorf("invalid load balancing policy %s: %v", typeURL, err)
+		}
+	} else if len(fields) > 1 {
+		return nil, fmt.Errorf("load balancing policy %s can only be configured with its default settings", typeURL)
+	}
+	return &core.TypedExtensionConfig{Name: name, TypedConfig: typedConfig}, nil
+}
@@ -16,6 +16,9 @@ package networking
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
@@ -66,7 +69,7 @@ func ModelProtocolToListenerProtocol(p protocol.Instance,
 	case protocol.HTTP, protocol.HTTP2, protocol.HTTP_PROXY, protocol.GRPC, protocol.GRPCWeb:
 		return ListenerProtocolHTTP
 	case protocol.TCP, protocol.HTTPS, protocol.TLS,
-		protocol.Mongo, protocol.Redis, protocol.MySQL:
+		protocol.Mongo, protocol.Redis, protocol.MySQL, protocol.Kafka:
 		return ListenerProtocolTCP
 	case protocol.UDP:
 		return ListenerProtocolUnknown
@@ -98,6 +101,8 @@ const (
 	TransportProtocolTCP = iota
 	// TransportProtocolQUIC is a QUIC listener
 	TransportProtocolQUIC
+	// TransportProtocolUDP is a UDP proxy listener
+	TransportProtocolUDP
 )
 
 func (tp TransportProtocol) String() string {
@@ -106,12 +111,14 @@ func (tp TransportProtocol) String() string {
 		return "tcp"
 	case TransportProtocolQUIC:
 		return "quic"
+	case TransportProtocolUDP:
+		return "udp"
 	}
 	return "unknown"
 }
 
 func (tp TransportProtocol) ToEnvoySocketProtocol() core.SocketAddress_Protocol {
-	if tp == TransportProtocolQUIC {
+	if tp == TransportProtocolQUIC || tp == TransportProtocolUDP {
 		return core.SocketAddress_UDP
 	}
 	return core.SocketAddress_TCP
@@ -199,41 +206,237 @@ const (
 func BuildCatchAllVirtualHost(allowAnyoutbound bool, sidecarDestination string) *route.VirtualHost {
 	if allowAnyoutbound {
 		egressCluster := PassthroughCluster
-		notimeout := durationpb.New(0)
-
 		if sidecarDestination != "" {
 			// user has provided an explicit destination for all the unknown traffic.
 			// build a cluster out of this destination
 			egressCluster = sidecarDestination
 		}
+		return buildCatchAllRouteVirtualHost(egressCluster)
+	}
+	return buildCatchAllBlackHoleVirtualHost(502)
+}
+
+// Types of CatchAllAction.
+const (
+	CatchAllPassthrough = "passthrough"
+	CatchAllBlackHole   = "blackhole"
+	CatchAllCluster     = "cluster"
+)
+
+// CatchAllAction is the action taken by the catch-all virtual host of an egress listener, overriding the
+// outbound traffic policy of the sidecar.
+type CatchAllAction struct {
+	// Type is CatchAllPassthrough, CatchAllBlackHole or CatchAllCluster.
+	Type string
+	// Status is the response code for CatchAllBlackHole.
+	Status uint32
+	// Cluster is the destination for CatchAllCluster.
+	Cluster string
+}
 
-		routeAction := &route.RouteAction{
-			ClusterSpecifier: &route.RouteAction_Cluster{Cluster: egressCluster},
-			// Disable timeout instead of assuming some defaults.
-			Timeout: notimeout,
-			// Use deprecated value for now as the replacement MaxStreamDuration has some regressions.
-			// nolint: staticcheck
-			MaxGrpcTimeout: notimeout,
+func (a CatchAllAction) String() string {
+	switch a.Type {
+	case CatchAllBlackHole:
+		return a.Type + ":" + strconv.Itoa(int(a.Status))
+	case CatchAllCluster:
+		return a.Type + ":" + a.Cluster
+	default:
+		return a.Type
+	}
+}
+
+// ParseCatchAllActions parses the per egress listener catch-all actions of a Sidecar, a comma separated list
+// of <port>=<action> where <port> is the port of the egress listener, or * for the listener without a port,
+// and <action> is passthrough, blackhole, blackhole:<status> or cluster:<name>.
+func ParseCatchAllActions(value string) (map[string]CatchAllAction, error) {
+	out := map[string]CatchAllAction{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid catch-all entry %q, expected <port>=<action>", entry)
+		}
+		port := strings.TrimSpace(kv[0])
+		if port != "*" {
+			if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+				return nil, fmt.Errorf("invalid catch-all port %q", port)
+			}
 		}
+		if _, f := out[port]; f {
+			return nil, fmt.Errorf("duplicate catch-all port %q", port)
+		}
+		action, err := parseCatchAllAction(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, err
+		}
+		out[port] = action
+	}
+	return out, nil
+}
 
-		return &route.VirtualHost{
-			Name:    Passthrough,
-			Domains: []string{"*"},
-			Routes: []*route.Route{
-				{
-					Name: Passthrough,
-					Match: &route.RouteMatch{
-						PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
-					},
-					Action: &route.Route_Route{
-						Route: routeAction,
-					},
+func parseCatchAllAction(value string) (CatchAllAction, error) {
+	parts := strings.SplitN(value, ":", 2)
+	switch parts[0] {
+	case CatchAllPassthrough:
+		if len(parts) == 1 {
+			return CatchAllAction{Type: CatchAllPassthrough}, nil
+		}
+	case CatchAllBlackHole:
+		if len(parts) == 1 {
+			return CatchAllAction{Type: CatchAllBlackHole, Status: 502}, nil
+		}
+		status, err := strconv.Atoi(parts[1])
+		if err != nil || status < 200 || status > 599 {
+			return CatchAllAction{}, fmt.Errorf("invalid catch-all status %q, must be between 200 and 599", parts[1])
+		}
+		return CatchAllAction{Type: CatchAllBlackHole, Status: uint32(status)}, nil
+	case CatchAllCluster:
+		if len(parts) == 2 && parts[1] != "" {
+			return CatchAllAction{Type: CatchAllCluster, Cluster: parts[1]}, nil
+		}
+	}
+	return CatchAllAction{}, fmt.Errorf("invalid catch-all action %q, expected passthrough, blackhole[:<status>] or cluster:<name>", value)
+}
+
+// AttemptCount selects where virtual hosts report the attempt count of requests, in the x-envoy-attempt-count header.
+type AttemptCount struct {
+	// Request adds the header to the requests sent upstream.
+	Request bool
+	// Response adds the header to the responses sent downstream.
+	Response bool
+}
+
+// DefaultAttemptCount is the attempt count reporting of virtual hosts without the RequestAttemptCountAnnotation.
+var DefaultAttemptCount = AttemptCount{Request: true}
+
+// ParseAttemptCount parses the value of the RequestAttemptCountAnnotation: a comma separated list of "request" and
+// "response", or "none".
+func ParseAttemptCount(value string) (AttemptCount, error) {
+	if strings.TrimSpace(value) == "none" {
+		return AttemptCount{}, nil
+	}
+	out := AttemptCount{}
+	for _, entry := range strings.Split(value, ",") {
+		switch strings.TrimSpace(entry) {
+		case "request":
+			out.Request = true
+		case "response":
+			out.Response = true
+		default:
+			return AttemptCount{}, fmt.Errorf("invalid attempt count %q, expected none or a list of request and response", entry)
+		}
+	}
+	return out, nil
+}
+
+// ParseConnectionLimits parses the inbound connection limits of a Sidecar, a comma separated list of <port>=<max>
+// where <port> is an inbound port, or * for the ports without an entry of their own, and <max> is the maximum
+// number of connections.
+func ParseConnectionLimits(value string) (map[string]uint64, error) {
+	out := map[string]uint64{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid connection limit entry %q, expected <port>=<max>", entry)
+		}
+		port := strings.TrimSpace(kv[0])
+		if port != "*" {
+			if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+				return nil, fmt.Errorf("invalid connection limit port %q", port)
+			}
+		}
+		if _, f := out[port]; f {
+			return nil, fmt.Errorf("duplicate connection limit port %q", port)
+		}
+		limit, err := strconv.ParseUint(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil || limit == 0 {
+			return nil, fmt.Errorf("invalid connection limit %q, must be a positive integer", kv[1])
+		}
+		out[port] = limit
+	}
+	return out, nil
+}
+
+// ParseEgressTimeouts parses the per egress listener timeouts of a Sidecar, a comma separated list of
+// <port>=<duration> where <port> is the port of the egress listener, or * for the listener without a port.
+func ParseEgressTimeouts(value string) (map[string]time.Duration, error) {
+	out := map[string]time.Duration{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid timeout entry %q, expected <port>=<duration>", entry)
+		}
+		port := strings.TrimSpace(kv[0])
+		if port != "*" {
+			if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+				return nil, fmt.Errorf("invalid timeout port %q", port)
+			}
+		}
+		if _, f := out[port]; f {
+			return nil, fmt.Errorf("duplicate timeout port %q", port)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(kv[1]))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q, must be a positive duration", kv[1])
+		}
+		out[port] = timeout
+	}
+	return out, nil
+}
+
+// BuildCatchAllVirtualHostForAction builds the catch-all virtual host of an egress listener with a CatchAllAction.
+func BuildCatchAllVirtualHostForAction(action CatchAllAction) *route.VirtualHost {
+	switch action.Type {
+	case CatchAllPassthrough:
+		return buildCatchAllRouteVirtualHost(PassthroughCluster)
+	case CatchAllCluster:
+		return buildCatchAllRouteVirtualHost(action.Cluster)
+	default:
+		return buildCatchAllBlackHoleVirtualHost(action.Status)
+	}
+}
+
+func buildCatchAllRouteVirtualHost(egressCluster string) *route.VirtualHost {
+	notimeout := durationpb.New(0)
+	routeAction := &route.RouteAction{
+		ClusterSpecifier: &route.RouteAction_Cluster{Cluster: egressCluster},
+		// Disable timeout instead of assuming some defaults.
+		Timeout: notimeout,
+		// Use deprecated value for now as the replacement MaxStreamDuration has some regressions.
+		// nolint: staticcheck
+		MaxGrpcTimeout: notimeout,
+	}
+
+	return &route.VirtualHost{
+		Name:    Passthrough,
+		Domains: []string{"*"},
+		Routes: []*route.Route{
+			{
+				Name: Passthrough,
+				Match: &route.RouteMatch{
+					PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+				},
+				Action: &route.Route_Route{
+					Route: routeAction,
 				},
 			},
-			IncludeRequestAttemptCount: true,
-		}
+		},
+		IncludeRequestAttemptCount: true,
 	}
+}
 
+func buildCatchAllBlackHoleVirtualHost(status uint32) *route.VirtualHost {
 	return &route.VirtualHost{
 		Name:    BlackHole,
 		Domains: []string{"*"},
@@ -245,7 +448,7 @@ func BuildCatchAllVirtualHost(allowAnyoutbound bool, sidecarDestination string)
 				},
 				Action: &route.Route_DirectResponse{
 					DirectResponse: &route.DirectResponseAction{
-						Status: 502,
+						Status: status,
 					},
 				},
 			},
@@ -15,11 +15,19 @@
 package networking
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extproc "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
 
 	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/protocol"
 )
 
@@ -122,3 +130,464 @@ func TestModelProtocolToListenerProtocol(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCatchAllActions(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]CatchAllAction
+		wantErr bool
+	}{
+		{
+			value: "8080=passthrough, 9090=blackhole,*=blackhole:404",
+			want: map[string]CatchAllAction{
+				"8080": {Type: CatchAllPassthrough},
+				"9090": {Type: CatchAllBlackHole, Status: 502},
+				"*":    {Type: CatchAllBlackHole, Status: 404},
+			},
+		},
+		{
+			value: "8080=cluster:outbound|80||egress.example.com",
+			want: map[string]CatchAllAction{
+				"8080": {Type: CatchAllCluster, Cluster: "outbound|80||egress.example.com"},
+			},
+		},
+		{value: "8080", wantErr: true},
+		{value: "http=passthrough", wantErr: true},
+		{value: "8080=passthrough,8080=blackhole", wantErr: true},
+		{value: "8080=blackhole:100", wantErr: true},
+		{value: "8080=cluster:", wantErr: true},
+		{value: "8080=redirect", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseCatchAllActions(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEgressTimeouts(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{
+			value: "8080=5s, *=1m30s",
+			want:  map[string]time.Duration{"8080": 5 * time.Second, "*": 90 * time.Second},
+		},
+		{value: "8080", wantErr: true},
+		{value: "http=5s", wantErr: true},
+		{value: "8080=5", wantErr: true},
+		{value: "8080=0s", wantErr: true},
+		{value: "8080=5s,8080=10s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseEgressTimeouts(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConnectionLimits(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]uint64
+		wantErr bool
+	}{
+		{
+			value: "8080=100, *=1000",
+			want:  map[string]uint64{"8080": 100, "*": 1000},
+		},
+		{value: "8080", wantErr: true},
+		{value: "0=100", wantErr: true},
+		{value: "http=100", wantErr: true},
+		{value: "8080=0", wantErr: true},
+		{value: "8080=-1", wantErr: true},
+		{value: "8080=100,8080=200", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseConnectionLimits(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLoadBalancingPolicy(t *testing.T) {
+	cswrr := "type.googleapis.com/envoy.extensions.load_balancing_policies.client_side_weighted_round_robin.v3.ClientSideWeightedRoundRobin"
+	got, err := ParseLoadBalancingPolicy(`{"@type": "` + cswrr + `"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "envoy.load_balancing_policies.client_side_weighted_round_robin" || got.TypedConfig.TypeUrl != cswrr {
+		t.Fatalf("unexpected policy %v", got)
+	}
+
+	for _, value := range []string{
+		`{"@type": "` + cswrr + `", "blackoutPeriod": "10s"}`,
+		`{"@type": "type.googleapis.com/envoy.extensions.load_balancing_policies.unknown.v3.Unknown"}`,
+		`{}`,
+		`round_robin`,
+	} {
+		if _, err := ParseLoadBalancingPolicy(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseRegexRewrites(t *testing.T) {
+	got, err := ParseRegexRewrites(`{"reviews": {"pattern": "^/v1/(.*)$", "substitution": "/\\1"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]RegexRewrite{"reviews": {Pattern: "^/v1/(.*)$", Substitution: `/\1`}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, value := range []string{
+		`{"reviews": {"substitution": "/"}}`,
+		`{"reviews": {"pattern": "(", "substitution": "/"}}`,
+		`{"": {"pattern": "/", "substitution": "/"}}`,
+		`reviews`,
+	} {
+		if _, err := ParseRegexRewrites(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseRouteIdleTimeouts(t *testing.T) {
+	got, err := ParseRouteIdleTimeouts(`{"events": "1h", "*": "0s"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]time.Duration{"events": time.Hour, AllRoutes: 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, value := range []string{
+		`{"events": "1x"}`,
+		`{"events": "-1s"}`,
+		`{"events": 5}`,
+		`{"": "1s"}`,
+		`events`,
+	} {
+		if _, err := ParseRouteIdleTimeouts(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseLocalRateLimits(t *testing.T) {
+	got, err := ParseLocalRateLimits(`{"*": {"maxTokens": 100, "fillInterval": "1s",
+		"descriptors": [{"header": "x-plan", "value": "free", "maxTokens": 10, "tokensPerFill": 1, "fillInterval": "2s"}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]LocalRateLimit{AllRoutes: {
+		TokenBucket: TokenBucket{MaxTokens: 100, FillInterval: "1s"},
+		Descriptors: []LocalRateLimitDescriptor{{
+			Header:      "x-plan",
+			Value:       "free",
+			TokenBucket: TokenBucket{MaxTokens: 10, TokensPerFill: 1, FillInterval: "2s"},
+		}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if fill := got[AllRoutes].Fill(); fill != 100 {
+		t.Fatalf("expected tokens per fill to default to max tokens, got %d", fill)
+	}
+
+	for _, value := range []string{
+		`{"reviews": {"fillInterval": "1s"}}`,
+		`{"reviews": {"maxTokens": 10, "fillInterval": "10ms"}}`,
+		`{"reviews": {"maxTokens": 10, "fillInterval": "1x"}}`,
+		`{"": {"maxTokens": 10, "fillInterval": "1s"}}`,
+		`{"reviews": {"maxTokens": 10, "fillInterval": "1s", "descriptors": [{"header": "x-plan", "maxTokens": 1, "fillInterval": "1s"}]}}`,
+		`{"reviews": {"maxTokens": 10, "fillInterval": "1s", "descriptors": [{"header": "x-plan", "value": "free", "maxTokens": 1, "fillInterval": "1500ms"}]}}`,
+		`{"reviews": {"maxTokens": 10, "fillInterval": "1s", "descriptors": [` +
+			`{"header": "x-plan", "value": "free", "maxTokens": 1, "fillInterval": "1s"},` +
+			`{"header": "x-plan", "value": "free", "maxTokens": 2, "fillInterval": "1s"}]}}`,
+		`reviews`,
+	} {
+		if _, err := ParseLocalRateLimits(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseGlobalRateLimits(t *testing.T) {
+	got, err := ParseGlobalRateLimits(`{"*": [["route"], ["route", "header:x-user-id"]], "reviews": [["path", "source"]]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]GlobalRateLimitDescriptor{
+		AllRoutes: {{RouteRateLimitEntry}, {RouteRateLimitEntry, "header:x-user-id"}},
+		"reviews": {{PathRateLimitEntry, SourceRateLimitEntry}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, value := range []string{
+		`{"reviews": []}`,
+		`{"reviews": [[]]}`,
+		`{"reviews": [["header:"]]}`,
+		`{"reviews": [["method"]]}`,
+		`{"": [["route"]]}`,
+		`reviews`,
+	} {
+		if _, err := ParseGlobalRateLimits(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseExtProc(t *testing.T) {
+	got, err := ParseExtProc(`{"service": "ext-proc.foo.svc.cluster.local", "port": 9000, "timeout": "100ms",
+		"failOpen": true, "processingMode": {"requestBodyMode": "BUFFERED", "responseHeaderMode": "SKIP"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ExtProc{
+		Service:  "ext-proc.foo.svc.cluster.local",
+		Port:     9000,
+		Timeout:  100 * time.Millisecond,
+		FailOpen: true,
+		ProcessingMode: &extproc.ProcessingMode{
+			RequestBodyMode:    extproc.ProcessingMode_BUFFERED,
+			ResponseHeaderMode: extproc.ProcessingMode_SKIP,
+		},
+	}
+	if !cmp.Equal(got, want, protocmp.Transform()) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, value := range []string{
+		`{"port": 9000}`,
+		`{"service": "foo/ext-proc", "port": 9000}`,
+		`{"service": "ext-proc.foo.svc.cluster.local"}`,
+		`{"service": "ext-proc.foo.svc.cluster.local", "port": 9000, "timeout": "0s"}`,
+		`{"service": "ext-proc.foo.svc.cluster.local", "port": 9000, "processingMode": {"requestBodyMode": "ALL"}}`,
+		`ext-proc`,
+	} {
+		if _, err := ParseExtProc(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseExtProcOverrides(t *testing.T) {
+	got, err := ParseExtProcOverrides(`{"health": {"istio-system/ext-proc": {"disabled": true}},
+		"*": {"istio-system/ext-proc": {"processingMode": {"requestBodyMode": "STREAMED"}}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]ExtProcOverride{
+		"health": {"istio-system/ext-proc": {Disabled: true}},
+		AllRoutes: {"istio-system/ext-proc": {
+			ProcessingMode: &extproc.ProcessingMode{RequestBodyMode: extproc.ProcessingMode_STREAMED},
+		}},
+	}
+	if !cmp.Equal(got, want, protocmp.Transform()) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, value := range []string{
+		`{"health": {"ext-proc": {"disabled": true}}}`,
+		`{"health": {"istio-system/ext-proc": {}}}`,
+		`{"health": {"istio-system/ext-proc": {"disabled": true, "processingMode": {}}}}`,
+		`{"": {"istio-system/ext-proc": {"disabled": true}}}`,
+		`health`,
+	} {
+		if _, err := ParseExtProcOverrides(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseDirectResponses(t *testing.T) {
+	got, err := ParseDirectResponses(`{"maintenance": {"status": 503, "body": "down", "headers": {"retry-after": "60"}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]DirectResponse{"maintenance": {Status: 503, Body: "down", Headers: map[string]string{"retry-after": "60"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, value := range []string{
+		`{"maintenance": {"status": 99}}`,
+		`{"maintenance": {"status": 600}}`,
+		`{"maintenance": {"body": "down"}}`,
+		`{"maintenance": {"status": 200, "body": "` + strings.Repeat("x", MaxDirectResponseBodySize+1) + `"}}`,
+		`{"maintenance": {"status": 200, "headers": {":status": "500"}}}`,
+		`{"": {"status": 200}}`,
+		`maintenance`,
+	} {
+		if _, err := ParseDirectResponses(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseInternalRedirects(t *testing.T) {
+	got, err := ParseInternalRedirects(`{"login": {"maxRedirects": 2, "responseCodes": [301, 308], "allowCrossScheme": true}, "*": {"maxRedirects": 1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]InternalRedirect{
+		"login":   {MaxRedirects: 2, ResponseCodes: []uint32{301, 308}, AllowCrossScheme: true},
+		AllRoutes: {MaxRedirects: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, value := range []string{
+		`{"login": {}}`,
+		`{"login": {"maxRedirects": 2, "responseCodes": [304]}}`,
+		`{"login": {"maxRedirects": -1}}`,
+		`{"": {"maxRedirects": 2}}`,
+		`2`,
+	} {
+		if _, err := ParseInternalRedirects(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseMirrors(t *testing.T) {
+	got, err := ParseMirrors(`{"reviews": [{"host": "reviews.staging", "subset": "v2", "port": 9080, "percentage": 10}, {"host": "reviews.qa"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	percentage := 10.0
+	want := map[string][]Mirror{"reviews": {
+		{Host: "reviews.staging", Subset: "v2", Port: 9080, Percentage: &percentage},
+		{Host: "reviews.qa"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, value := range []string{
+		`{"reviews": [{"percentage": 10}]}`,
+		`{"reviews": [{"host": "reviews.qa", "percentage": 101}]}`,
+		`{"reviews": [{"host": "reviews.qa", "percentage": -1}]}`,
+		`{"reviews": [{"host": "reviews.qa", "port": 70000}]}`,
+		`{"": [{"host": "reviews.qa"}]}`,
+		`{"reviews": {"host": "reviews.qa"}}`,
+	} {
+		if _, err := ParseMirrors(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestParseRouteAnnotations(t *testing.T) {
+	annotations := ParseRouteAnnotations(config.Meta{Annotations: map[string]string{
+		constants.RouteIdleTimeoutAnnotation: `{"reviews": "5m", "*": "1m"}`,
+		constants.MirrorsAnnotation:          `{"*": [{"host": "reviews.qa"}], "ratings": [{"host": "ratings.qa"}]}`,
+		constants.DirectResponseAnnotation:   `{"reviews": {"status": 99}}`,
+	}})
+	for route, want := range map[string]time.Duration{"reviews": 5 * time.Minute, "details": time.Minute, "": time.Minute} {
+		if got, f := annotations.IdleTimeout(route); !f || got != want {
+			t.Errorf("got timeout %v of route %q, want %v", got, route, want)
+		}
+	}
+
+	// the AllRoutes key is a route name for the annotations which do not support it.
+	if got := annotations.Mirrors("reviews"); got != nil {
+		t.Errorf("got mirrors %v of route without mirrors", got)
+	}
+	if got, want := annotations.MirrorHosts(), []string{"reviews.qa", "ratings.qa"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got mirror hosts %v, want %v", got, want)
+	}
+
+	// invalid values configure no route.
+	if got, f := annotations.DirectResponse("reviews"); f {
+		t.Errorf("got direct response %v of invalid annotation", got)
+	}
+
+	// a nil value configures no route.
+	var none *RouteAnnotations
+	if got, f := none.IdleTimeout("reviews"); f {
+		t.Errorf("got timeout %v without annotations", got)
+	}
+}
+
+func TestParseAttemptCount(t *testing.T) {
+	cases := map[string]AttemptCount{
+		"none":              {},
+		"request":           {Request: true},
+		"response":          {Response: true},
+		"request, response": {Request: true, Response: true},
+	}
+	for value, want := range cases {
+		got, err := ParseAttemptCount(value)
+		if err != nil {
+			t.Fatalf("%s: %v", value, err)
+		}
+		if got != want {
+			t.Fatalf("%s: got %+v, want %+v", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"", "upstream", "none,request"} {
+		if _, err := ParseAttemptCount(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestParseJWTClaimHeader(t *testing.T) {
+	cases := []struct {
+		name    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "@request.auth.claims.admin", want: []string{"admin"}},
+		{name: "@request.auth.claims.group.id", want: []string{"group", "id"}},
+		{name: "@Request.Auth.Claims.Group", want: []string{"Group"}},
+		{name: "@request.auth.claims[example.com/groups]", want: []string{"example.com/groups"}},
+		{name: "@request.auth.claims[example.com/groups][id]", want: []string{"example.com/groups", "id"}},
+		{name: "@request.auth.claims[example.com/groups", wantErr: true},
+		{name: "@request.auth.claims[a]b", wantErr: true},
+		{name: "@request.auth.claims[a[b]]", wantErr: true},
+		{name: "@request.auth.claims[]", wantErr: true},
+		{name: "@request.auth.claims-abc", wantErr: true},
+		{name: "x-some-other-header", wantErr: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJWTClaimHeader(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got err %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
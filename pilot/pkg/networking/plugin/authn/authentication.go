@@ -63,7 +63,12 @@ func buildFilter(in *plugin.InputParams, mutable *networking.MutableObjects) err
 	forSidecar := in.Node.Type == model.SidecarProxy
 	for i := range mutable.FilterChains {
 		if mutable.FilterChains[i].ListenerProtocol == networking.ListenerProtocolHTTP {
-			// Adding Jwt filter and authn filter, if needed.
+			// Adding OAuth2 filter (gateways only), Jwt filter and authn filter, if needed.
+			if !forSidecar {
+				if filter := applier.OAuth2Filter(); filter != nil {
+					mutable.FilterChains[i].HTTP = append(mutable.FilterChains[i].HTTP, filter)
+				}
+			}
 			if filter := applier.JwtFilter(); filter != nil {
 				mutable.FilterChains[i].HTTP = append(mutable.FilterChains[i].HTTP, filter)
 			}
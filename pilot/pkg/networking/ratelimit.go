@@ -0,0 +1,190 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MinFillInterval is the shortest fill interval of a token bucket accepted by Envoy.
+const MinFillInterval = 50 * time.Millisecond
+
+// TokenBucket allows up to MaxTokens requests at once, and is refilled with TokensPerFill tokens every FillInterval.
+type TokenBucket struct {
+	MaxTokens uint32 `json:"maxTokens"`
+	// TokensPerFill defaults to MaxTokens.
+	TokensPerFill uint32 `json:"tokensPerFill,omitempty"`
+	FillInterval  string `json:"fillInterval"`
+}
+
+// Interval returns the fill interval of the bucket, or 0 if it is invalid.
+func (b TokenBucket) Interval() time.Duration {
+	d, _ := time.ParseDuration(b.FillInterval)
+	return d
+}
+
+// Fill returns the number of tokens added to the bucket every fill interval.
+func (b TokenBucket) Fill() uint32 {
+	if b.TokensPerFill == 0 {
+		return b.MaxTokens
+	}
+	return b.TokensPerFill
+}
+
+func (b TokenBucket) validate() error {
+	if b.MaxTokens == 0 {
+		return fmt.Errorf("maxTokens must be positive")
+	}
+	d, err := time.ParseDuration(b.FillInterval)
+	if err != nil {
+		return fmt.Errorf("invalid fillInterval: %v", err)
+	}
+	if d < MinFillInterval {
+		return fmt.Errorf("fillInterval must be at least %v", MinFillInterval)
+	}
+	return nil
+}
+
+// LocalRateLimitDescriptor limits the requests whose Header has the Value with a token bucket of their own.
+type LocalRateLimitDescriptor struct {
+	Header string `json:"header"`
+	Value  string `json:"value"`
+	TokenBucket
+}
+
+// LocalRateLimit limits the requests of a route with a token bucket, local to each proxy. The requests matching one
+// of the descriptors are limited by the bucket of the descriptor instead.
+type LocalRateLimit struct {
+	TokenBucket
+	Descriptors []LocalRateLimitDescriptor `json:"descriptors,omitempty"`
+}
+
+// Headers returns the distinct headers of the descriptors, in order.
+func (l LocalRateLimit) Headers() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, d := range l.Descriptors {
+		if !seen[d.Header] {
+			seen[d.Header] = true
+			out = append(out, d.Header)
+		}
+	}
+	return out
+}
+
+// ParseLocalRateLimits parses the local rate limits of the HTTP routes of a VirtualService, keyed by route name or
+// AllRoutes, or of the inbound ports of a Sidecar, keyed by port or "*", for example
+// {"reviews": {"maxTokens": 100, "fillInterval": "1s", "descriptors": [{"header": "x-plan", "value": "free",
+// "maxTokens": 10, "fillInterval": "1s"}]}}.
+func ParseLocalRateLimits(value string) (map[string]LocalRateLimit, error) {
+	out := map[string]LocalRateLimit{}
+	if err := json.Unmarshal([]byte(value), &out); err != nil {
+		return nil, fmt.Errorf("invalid local rate limits: %v", err)
+	}
+	for name, limit := range out {
+		if name == "" {
+			return nil, fmt.Errorf("local rate limit must have a name")
+		}
+		if err := limit.validate(); err != nil {
+			return nil, fmt.Errorf("invalid local rate limit %s: %v", name, err)
+		}
+		seen := map[string]bool{}
+		for i, d := range limit.Descriptors {
+			if d.Header == "" || strings.HasPrefix(d.Header, ":") {
+				return nil, fmt.Errorf("invalid header %q of descriptor %d of local rate limit %s", d.Header, i, name)
+			}
+			if d.Value == "" {
+				return nil, fmt.Errorf("descriptor %d of local rate limit %s must have a value", i, name)
+			}
+			key := d.Header + "=" + d.Value
+			if seen[key] {
+				return nil, fmt.Errorf("duplicate descriptor %s of local rate limit %s", key, name)
+			}
+			seen[key] = true
+			if err := d.validate(); err != nil {
+				return nil, fmt.Errorf("invalid descriptor %d of local rate limit %s: %v", i, name, err)
+			}
+			// Envoy requires the descriptor buckets to be refilled on the ticks of the route bucket.
+			if d.Interval()%limit.Interval() != 0 {
+				return nil, fmt.Errorf("fillInterval of descriptor %d of local rate limit %s must be a multiple of %s",
+					i, name, limit.FillInterval)
+			}
+		}
+	}
+	return out, nil
+}
+
+// The entries of the descriptors of a global rate limit. HeaderRateLimitEntry is followed by the name of a header.
+const (
+	// RouteRateLimitEntry is the VirtualService and name of the route, as <namespace>/<name>/<route>.
+	RouteRateLimitEntry = "route"
+	// PathRateLimitEntry is the path of the request.
+	PathRateLimitEntry = "path"
+	// DestinationRateLimitEntry is the cluster the request is routed to.
+	DestinationRateLimitEntry = "destination"
+	// SourceRateLimitEntry is the address of the client.
+	SourceRateLimitEntry = "source"
+	// HeaderRateLimitEntry is the value of a request header, for example header:x-user-id.
+	HeaderRateLimitEntry = "header:"
+)
+
+// GlobalRateLimitDescriptor is the list of entries of a descriptor sent to the global rate limit service. A
+// descriptor is only sent if all its entries are present in the request.
+type GlobalRateLimitDescriptor []string
+
+// ParseGlobalRateLimits parses the descriptors sent to the global rate limit service for the HTTP routes of a
+// VirtualService, keyed by route name or AllRoutes, for example {"*": [["route"], ["route", "header:x-user-id"]]}.
+func ParseGlobalRateLimits(value string) (map[string][]GlobalRateLimitDescriptor, error) {
+	out := map[string][]GlobalRateLimitDescriptor{}
+	if err := json.Unmarshal([]byte(value), &out); err != nil {
+		return nil, fmt.Errorf("invalid global rate limits: %v", err)
+	}
+	for name, descriptors := range out {
+		if name == "" {
+			return nil, fmt.Errorf("global rate limit must have a name")
+		}
+		if len(descriptors) == 0 {
+			return nil, fmt.Errorf("global rate limit %s must have descriptors", name)
+		}
+		for i, d := range descriptors {
+			if len(d) == 0 {
+				return nil, fmt.Errorf("descriptor %d of global rate limit %s must have entries", i, name)
+			}
+			for _, entry := range d {
+				if err := validateGlobalRateLimitEntry(entry); err != nil {
+					return nil, fmt.Errorf("invalid descriptor %d of global rate limit %s: %v", i, name, err)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+func validateGlobalRateLimitEntry(entry string) error {
+	switch entry {
+	case RouteRateLimitEntry, PathRateLimitEntry, DestinationRateLimitEntry, SourceRateLimitEntry:
+		return nil
+	}
+	if header := strings.TrimPrefix(entry, HeaderRateLimitEntry); header != entry {
+		if header == "" {
+			return fmt.Errorf("entry %q must have a header name", entry)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown entry %q", entry)
+}
@@ -0,0 +1,181 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RegexRewrite rewrites the path of a request by replacing the matches of Pattern with Substitution.
+type RegexRewrite struct {
+	Pattern      string `json:"pattern"`
+	Substitution string `json:"substitution"`
+}
+
+// ParseRegexRewrites parses the regex rewrites of the HTTP routes of a VirtualService, keyed by route name, for
+// example {"reviews": {"pattern": "^/v1/(.*)$", "substitution": "/\\1"}}.
+func ParseRegexRewrites(value string) (map[string]RegexRewrite, error) {
+	out := map[string]RegexRewrite{}
+	if err := json.Unmarshal([]byte(value), &out); err != nil {
+		return nil, fmt.Errorf("invalid regex rewrites: %v", err)
+	}
+	for name, rewrite := range out {
+		if name == "" {
+			return nil, fmt.Errorf("regex rewrite must have a route name")
+		}
+		if rewrite.Pattern == "" {
+			return nil, fmt.Errorf("regex rewrite of route %s must have a pattern", name)
+		}
+		if _, err := regexp.Compile(rewrite.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid regex rewrite pattern of route %s: %v", name, err)
+		}
+	}
+	return out, nil
+}
+
+// AllRoutes is the key of the value of a route annotation that applies to the routes without their own.
+const AllRoutes = "*"
+
+// ParseRouteIdleTimeouts parses the idle timeouts of the HTTP routes of a VirtualService, keyed by route name or
+// AllRoutes, for example {"events": "1h", "*": "5m"}.
+func ParseRouteIdleTimeouts(value string) (map[string]time.Duration, error) {
+	durations := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &durations); err != nil {
+		return nil, fmt.Errorf("invalid idle timeouts: %v", err)
+	}
+	out := make(map[string]time.Duration, len(durations))
+	for name, duration := range durations {
+		if name == "" {
+			return nil, fmt.Errorf("idle timeout must have a route name")
+		}
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid idle timeout of route %s: %v", name, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("idle timeout of route %s must not be negative", name)
+		}
+		out[name] = d
+	}
+	return out, nil
+}
+
+// MaxDirectResponseBodySize is the largest body of a direct response accepted by Envoy by default, in bytes.
+const MaxDirectResponseBodySize = 4096
+
+// DirectResponse is a static response returned by the proxy instead of forwarding the request.
+type DirectResponse struct {
+	Status  uint32            `json:"status"`
+	Body    string            `json:"body,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ParseDirectResponses parses the direct responses of the HTTP routes of a VirtualService, keyed by route name, for
+// example {"maintenance": {"status": 503, "body": "down for maintenance"}}.
+func ParseDirectResponses(value string) (map[string]DirectResponse, error) {
+	out := map[string]DirectResponse{}
+	if err := json.Unmarshal([]byte(value), &out); err != nil {
+		return nil, fmt.Errorf("invalid direct responses: %v", err)
+	}
+	for name, response := range out {
+		if name == "" {
+			return nil, fmt.Errorf("direct response must have a route name")
+		}
+		if response.Status < 200 || response.Status > 599 {
+			return nil, fmt.Errorf("direct response status of route %s must be between 200 and 599, got %d", name, response.Status)
+		}
+		if len(response.Body) > MaxDirectResponseBodySize {
+			return nil, fmt.Errorf("direct response body of route %s must be at most %d bytes", name, MaxDirectResponseBodySize)
+		}
+		for header := range response.Headers {
+			if header == "" || strings.HasPrefix(header, ":") {
+				return nil, fmt.Errorf("invalid direct response header %q of route %s", header, name)
+			}
+		}
+	}
+	return out, nil
+}
+
+// InternalRedirect lets the proxy follow the 3xx responses of a route instead of returning them to the client.
+type InternalRedirect struct {
+	MaxRedirects uint32 `json:"maxRedirects"`
+	// ResponseCodes are the 3xx response codes followed, only 302 if unset.
+	ResponseCodes    []uint32 `json:"responseCodes,omitempty"`
+	AllowCrossScheme bool     `json:"allowCrossScheme,omitempty"`
+}
+
+// ParseInternalRedirects parses the internal redirects of the HTTP routes of a VirtualService, keyed by route name or
+// AllRoutes, for example {"login": {"maxRedirects": 2, "responseCodes": [301, 302]}}.
+func ParseInternalRedirects(value string) (map[string]InternalRedirect, error) {
+	out := map[string]InternalRedirect{}
+	if err := json.Unmarshal([]byte(value), &out); err != nil {
+		return nil, fmt.Errorf("invalid internal redirects: %v", err)
+	}
+	for name, redirect := range out {
+		if name == "" {
+			return nil, fmt.Errorf("internal redirect must have a route name")
+		}
+		if redirect.MaxRedirects == 0 {
+			return nil, fmt.Errorf("max redirects of route %s must be positive", name)
+		}
+		for _, code := range redirect.ResponseCodes {
+			switch code {
+			case 301, 302, 303, 307, 308:
+			default:
+				return nil, fmt.Errorf("unsupported internal redirect response code %d of route %s", code, name)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Mirror is a destination that the requests of a route are mirrored to.
+type Mirror struct {
+	Host   string `json:"host"`
+	Subset string `json:"subset,omitempty"`
+	Port   uint32 `json:"port,omitempty"`
+	// Percentage of the requests that are mirrored, 100 if unset.
+	Percentage *float64 `json:"percentage,omitempty"`
+}
+
+// ParseMirrors parses the mirrors of the HTTP routes of a VirtualService, keyed by route name, for example
+// {"reviews": [{"host": "reviews.staging", "percentage": 10}]}.
+func ParseMirrors(value string) (map[string][]Mirror, error) {
+	out := map[string][]Mirror{}
+	if err := json.Unmarshal([]byte(value), &out); err != nil {
+		return nil, fmt.Errorf("invalid mirrors: %v", err)
+	}
+	for name, mirrors := range out {
+		if name == "" {
+			return nil, fmt.Errorf("mirrors must have a route name")
+		}
+		for i, m := range mirrors {
+			if m.Host == "" {
+				return nil, fmt.Errorf("mirror %d of route %s must have a host", i, name)
+			}
+			if m.Port > 65535 {
+				return nil, fmt.Errorf("invalid port %d of mirror %d of route %s", m.Port, i, name)
+			}
+			if m.Percentage != nil && (*m.Percentage < 0 || *m.Percentage > 100) {
+				return nil, fmt.Errorf("percentage of mirror %d of route %s must be between 0 and 100", i, name)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,207 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"sort"
+	"time"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+)
+
+// RouteAnnotations are the values of the annotations of a VirtualService configuring its HTTP routes by name, parsed
+// once per VirtualService when the push context is built. A nil RouteAnnotations configures no route.
+type RouteAnnotations struct {
+	regexRewrites    map[string]RegexRewrite
+	idleTimeouts     map[string]time.Duration
+	directResponses  map[string]DirectResponse
+	mirrors          map[string][]Mirror
+	localRateLimits  map[string]LocalRateLimit
+	globalRateLimits map[string][]GlobalRateLimitDescriptor
+	extProcOverrides map[string]map[string]ExtProcOverride
+	redirects        map[string]InternalRedirect
+}
+
+// ParseRouteAnnotations parses the route annotations of a VirtualService. Invalid values are rejected by validation,
+// so they are only logged and ignored here.
+func ParseRouteAnnotations(vs config.Meta) *RouteAnnotations {
+	out := &RouteAnnotations{}
+	ignore := func(annotation string, err error) {
+		log.Debugf("ignoring invalid %s annotation of virtual service %s/%s: %v", annotation, vs.Namespace, vs.Name, err)
+	}
+	if value, f := vs.Annotations[constants.RegexRewriteAnnotation]; f {
+		if rewrites, err := ParseRegexRewrites(value); err == nil {
+			out.regexRewrites = rewrites
+		} else {
+			ignore(constants.RegexRewriteAnnotation, err)
+		}
+	}
+	if value, f := vs.Annotations[constants.RouteIdleTimeoutAnnotation]; f {
+		if timeouts, err := ParseRouteIdleTimeouts(value); err == nil {
+			out.idleTimeouts = timeouts
+		} else {
+			ignore(constants.RouteIdleTimeoutAnnotation, err)
+		}
+	}
+	if value, f := vs.Annotations[constants.DirectResponseAnnotation]; f {
+		if responses, err := ParseDirectResponses(value); err == nil {
+			out.directResponses = responses
+		} else {
+			ignore(constants.DirectResponseAnnotation, err)
+		}
+	}
+	if value, f := vs.Annotations[constants.MirrorsAnnotation]; f {
+		if mirrors, err := ParseMirrors(value); err == nil {
+			out.mirrors = mirrors
+		} else {
+			ignore(constants.MirrorsAnnotation, err)
+		}
+	}
+	if value, f := vs.Annotations[constants.LocalRateLimitAnnotation]; f {
+		if limits, err := ParseLocalRateLimits(value); err == nil {
+			out.localRateLimits = limits
+		} else {
+			ignore(constants.LocalRateLimitAnnotation, err)
+		}
+	}
+	if value, f := vs.Annotations[constants.GlobalRateLimitAnnotation]; f {
+		if descriptors, err := ParseGlobalRateLimits(value); err == nil {
+			out.globalRateLimits = descriptors
+		} else {
+			ignore(constants.GlobalRateLimitAnnotation, err)
+		}
+	}
+	if value, f := vs.Annotations[constants.ExtProcOverridesAnnotation]; f {
+		if overrides, err := ParseExtProcOverrides(value); err == nil {
+			out.extProcOverrides = overrides
+		} else {
+			ignore(constants.ExtProcOverridesAnnotation, err)
+		}
+	}
+	if value, f := vs.Annotations[constants.InternalRedirectsAnnotation]; f {
+		if redirects, err := ParseInternalRedirects(value); err == nil {
+			out.redirects = redirects
+		} else {
+			ignore(constants.InternalRedirectsAnnotation, err)
+		}
+	}
+	return out
+}
+
+// RegexRewrite returns the regex rewrite of the named route.
+func (a *RouteAnnotations) RegexRewrite(routeName string) (RegexRewrite, bool) {
+	if a == nil || routeName == "" {
+		return RegexRewrite{}, false
+	}
+	rewrite, f := a.regexRewrites[routeName]
+	return rewrite, f
+}
+
+// IdleTimeout returns the idle timeout of the named route, or of AllRoutes if the route has none of its own.
+func (a *RouteAnnotations) IdleTimeout(routeName string) (time.Duration, bool) {
+	if a == nil {
+		return 0, false
+	}
+	if timeout, f := a.idleTimeouts[routeName]; f && routeName != "" {
+		return timeout, true
+	}
+	timeout, f := a.idleTimeouts[AllRoutes]
+	return timeout, f
+}
+
+// DirectResponse returns the direct response of the named route.
+func (a *RouteAnnotations) DirectResponse(routeName string) (DirectResponse, bool) {
+	if a == nil || routeName == "" {
+		return DirectResponse{}, false
+	}
+	response, f := a.directResponses[routeName]
+	return response, f
+}
+
+// Mirrors returns the mirrors of the named route.
+func (a *RouteAnnotations) Mirrors(routeName string) []Mirror {
+	if a == nil || routeName == "" {
+		return nil
+	}
+	return a.mirrors[routeName]
+}
+
+// MirrorHosts returns the hosts of the mirrors of all the routes, as written in the annotation, by route name.
+func (a *RouteAnnotations) MirrorHosts() []string {
+	if a == nil {
+		return nil
+	}
+	names := make([]string, 0, len(a.mirrors))
+	for name := range a.mirrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []string
+	for _, name := range names {
+		for _, m := range a.mirrors[name] {
+			out = append(out, m.Host)
+		}
+	}
+	return out
+}
+
+// LocalRateLimit returns the local rate limit of the named route, or of AllRoutes if the route has none of its own.
+func (a *RouteAnnotations) LocalRateLimit(routeName string) (LocalRateLimit, bool) {
+	if a == nil {
+		return LocalRateLimit{}, false
+	}
+	if limit, f := a.localRateLimits[routeName]; f && routeName != "" {
+		return limit, true
+	}
+	limit, f := a.localRateLimits[AllRoutes]
+	return limit, f
+}
+
+// GlobalRateLimits returns the global rate limit descriptors of the named route, or of AllRoutes if the route has
+// none of its own.
+func (a *RouteAnnotations) GlobalRateLimits(routeName string) []GlobalRateLimitDescriptor {
+	if a == nil {
+		return nil
+	}
+	if descriptors, f := a.globalRateLimits[routeName]; f && routeName != "" {
+		return descriptors
+	}
+	return a.globalRateLimits[AllRoutes]
+}
+
+// ExtProcOverrides returns the overrides of the external processors of the named route, keyed by the
+// <namespace>/<name> of the WasmPlugin declaring the processor, or those of AllRoutes if the route has none of its own.
+func (a *RouteAnnotations) ExtProcOverrides(routeName string) map[string]ExtProcOverride {
+	if a == nil {
+		return nil
+	}
+	if overrides, f := a.extProcOverrides[routeName]; f && routeName != "" {
+		return overrides
+	}
+	return a.extProcOverrides[AllRoutes]
+}
+
+// InternalRedirect returns the internal redirect of the named route, or of AllRoutes if the route has none of its own.
+func (a *RouteAnnotations) InternalRedirect(routeName string) (InternalRedirect, bool) {
+	if a == nil {
+		return InternalRedirect{}, false
+	}
+	if redirect, f := a.redirects[routeName]; f && routeName != "" {
+		return redirect, true
+	}
+	redirect, f := a.redirects[AllRoutes]
+	return redirect, f
+}
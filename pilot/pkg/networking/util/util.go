@@ -71,6 +71,18 @@ const (
 	// SniClusterFilter is the name of the sni_cluster envoy filter
 	SniClusterFilter = "envoy.filters.network.sni_cluster"
 
+	// KafkaBrokerFilter is the name of the kafka_broker envoy filter
+	KafkaBrokerFilter = "envoy.filters.network.kafka_broker"
+
+	// ConnectionLimitFilter is the name of the connection_limit envoy filter
+	ConnectionLimitFilter = "envoy.filters.network.connection_limit"
+
+	// LocalRateLimitFilter is the name of the local_ratelimit envoy HTTP filter
+	LocalRateLimitFilter = "envoy.filters.http.local_ratelimit"
+
+	// UDPProxyFilter is the name of the udp_proxy envoy listener filter
+	UDPProxyFilter = "envoy.filters.udp_listener.udp_proxy"
+
 	// IstioMetadataKey is the key under which metadata is added to a route or cluster
 	// regarding the virtual service or destination rule used for each
 	IstioMetadataKey = "istio"
@@ -79,6 +91,10 @@ const (
 	// which determines the endpoint level transport socket configuration.
 	EnvoyTransportSocketMetadataKey = "envoy.transport_socket_match"
 
+	// EnvoyLbMetadataKey is the key under which the allowlisted workload labels are added to an endpoint,
+	// for load balancing policies and upstream filters to act on.
+	EnvoyLbMetadataKey = "envoy.lb"
+
 	// EnvoyRawBufferSocketName matched with hardcoded built-in Envoy transport name which determines
 	// endpoint level plantext transport socket configuration
 	EnvoyRawBufferSocketName = wellknown.TransportSocketRawBuffer
@@ -245,7 +261,8 @@ func GogoDurationToDuration(d *types.Duration) *durationpb.Duration {
 	}
 }
 
-// SortVirtualHosts sorts a slice of virtual hosts by name.
+// SortVirtualHosts sorts a slice of virtual hosts by name. If PILOT_SORT_VIRTUAL_HOSTS_BY_SPECIFICITY is enabled,
+// exact hosts are sorted before wildcard hosts, and longer wildcards before shorter ones.
 //
 // Envoy computes a hash of RDS to see if things have changed - hash is affected by order of elements in the filter. Therefore
 // we sort virtual hosts by name before handing them back so the ordering is stable across HTTP Route Configs.
@@ -253,6 +270,19 @@ func SortVirtualHosts(hosts []*route.VirtualHost) {
 	if len(hosts) < 2 {
 		return
 	}
+	if features.SortVirtualHostsBySpecificity {
+		sort.SliceStable(hosts, func(i, j int) bool {
+			wi, wj := strings.HasPrefix(hosts[i].Name, "*"), strings.HasPrefix(hosts[j].Name, "*")
+			if wi != wj {
+				return wj
+			}
+			if wi && len(hosts[i].Name) != len(hosts[j].Name) {
+				return len(hosts[i].Name) > len(hosts[j].Name)
+			}
+			return hosts[i].Name < hosts[j].Name
+		})
+		return
+	}
 	sort.SliceStable(hosts, func(i, j int) bool {
 		return hosts[i].Name < hosts[j].Name
 	})
@@ -402,6 +432,27 @@ func AddConfigInfoMetadata(metadata *core.Metadata, config config.Meta) *core.Me
 	return metadata
 }
 
+// AddServiceSourceMetadata adds the registry and clusters of the service a route was built for, to the given
+// core.Metadata struct. If metadata is not initialized, build a new metadata. This is used for debugging only.
+func AddServiceSourceMetadata(metadata *core.Metadata, source string) *core.Metadata {
+	if metadata == nil {
+		metadata = &core.Metadata{
+			FilterMetadata: map[string]*structpb.Struct{},
+		}
+	}
+	if _, ok := metadata.FilterMetadata[IstioMetadataKey]; !ok {
+		metadata.FilterMetadata[IstioMetadataKey] = &structpb.Struct{
+			Fields: map[string]*structpb.Value{},
+		}
+	}
+	metadata.FilterMetadata[IstioMetadataKey].Fields["source"] = &structpb.Value{
+		Kind: &structpb.Value_StringValue{
+			StringValue: source,
+		},
+	}
+	return metadata
+}
+
 // AddSubsetToMetadata will insert the subset name supplied. This should be called after the initial
 // "istio" metadata has been created for the cluster. If the "istio" metadata field is not already
 // defined, the subset information will not be added (to prevent adding this information where not
@@ -459,8 +510,9 @@ func MergeAnyWithAny(dst *anypb.Any, src *anypb.Any) (*anypb.Any, error) {
 // BuildLbEndpointMetadata adds metadata values to a lb endpoint
 func BuildLbEndpointMetadata(networkID network.ID, tlsMode, workloadname, namespace string,
 	clusterID cluster.ID, labels labels.Instance) *core.Metadata {
+	lbLabels := buildLbEndpointLabels(labels)
 	if networkID == "" && (tlsMode == "" || tlsMode == model.DisabledTLSModeLabel) &&
-		(!features.EndpointTelemetryLabel || !features.EnableTelemetryLabel) {
+		(!features.EndpointTelemetryLabel || !features.EnableTelemetryLabel) && lbLabels == nil {
 		return nil
 	}
 
@@ -499,9 +551,30 @@ func BuildLbEndpointMetadata(networkID network.ID, tlsMode, workloadname, namesp
 		addIstioEndpointLabel(metadata, "workload", &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: sb.String()}})
 	}
 
+	if lbLabels != nil {
+		metadata.FilterMetadata[EnvoyLbMetadataKey] = lbLabels
+	}
+
 	return metadata
 }
 
+// buildLbEndpointLabels returns the workload labels allowlisted by PILOT_ENDPOINT_METADATA_LABELS, or nil if
+// the workload has none of them.
+func buildLbEndpointLabels(labels labels.Instance) *structpb.Struct {
+	var out *structpb.Struct
+	for _, k := range features.EndpointMetadataLabels {
+		v, f := labels[k]
+		if !f {
+			continue
+		}
+		if out == nil {
+			out = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		}
+		out.Fields[k] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: v}}
+	}
+	return out
+}
+
 // MaybeApplyTLSModeLabel may or may not update the metadata for the Envoy transport socket matches for auto mTLS.
 func MaybeApplyTLSModeLabel(ep *endpoint.LbEndpoint, tlsMode string) (*endpoint.LbEndpoint, bool) {
 	if ep == nil || ep.Metadata == nil {
@@ -22,6 +22,7 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	xdsutil "github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -596,6 +597,61 @@ func TestAddSubsetToMetadata(t *testing.T) {
 	}
 }
 
+func TestAddServiceSourceMetadata(t *testing.T) {
+	in := BuildConfigInfoMetadata(config.Meta{
+		Name:             "svcA",
+		Namespace:        "default",
+		Domain:           "svc.cluster.local",
+		GroupVersionKind: collections.IstioNetworkingV1Alpha3Virtualservices.Resource().GroupVersionKind(),
+	})
+	got := AddServiceSourceMetadata(in, "Kubernetes/cluster-a")
+	fields := got.FilterMetadata[IstioMetadataKey].Fields
+	if fields["config"].GetStringValue() == "" {
+		t.Errorf("expected the config metadata to be preserved, got %v", got)
+	}
+	if fields["source"].GetStringValue() != "Kubernetes/cluster-a" {
+		t.Errorf("unexpected source metadata: %v", got)
+	}
+	if got := AddServiceSourceMetadata(nil, "External"); got.FilterMetadata[IstioMetadataKey].Fields["source"].GetStringValue() != "External" {
+		t.Errorf("unexpected source metadata: %v", got)
+	}
+}
+
+func TestSortVirtualHosts(t *testing.T) {
+	names := func(hosts []*route.VirtualHost) []string {
+		out := make([]string, 0, len(hosts))
+		for _, h := range hosts {
+			out = append(out, h.Name)
+		}
+		return out
+	}
+	build := func() []*route.VirtualHost {
+		return []*route.VirtualHost{
+			{Name: "*.com:80"}, {Name: "b.foo.com:80"}, {Name: "*.foo.com:80"}, {Name: "a.foo.com:80"}, {Name: "*.bar.com:80"},
+		}
+	}
+	cases := []struct {
+		name        string
+		specificity bool
+		want        []string
+	}{
+		{"by name", false, []string{"*.bar.com:80", "*.com:80", "*.foo.com:80", "a.foo.com:80", "b.foo.com:80"}},
+		{"by specificity", true, []string{"a.foo.com:80", "b.foo.com:80", "*.bar.com:80", "*.foo.com:80", "*.com:80"}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultValue := features.SortVirtualHostsBySpecificity
+			features.SortVirtualHostsBySpecificity = tt.specificity
+			defer func() { features.SortVirtualHostsBySpecificity = defaultValue }()
+			hosts := build()
+			SortVirtualHosts(hosts)
+			if got := names(hosts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsHTTPFilterChain(t *testing.T) {
 	httpFilterChain := &listener.FilterChain{
 		Filters: []*listener.Filter{
@@ -1260,6 +1316,34 @@ func TestEndpointMetadata(t *testing.T) {
 	}
 }
 
+func TestEndpointMetadataLabels(t *testing.T) {
+	defer func(telemetry bool, metadataLabels []string) {
+		features.EndpointTelemetryLabel = telemetry
+		features.EndpointMetadataLabels = metadataLabels
+	}(features.EndpointTelemetryLabel, features.EndpointMetadataLabels)
+	features.EndpointTelemetryLabel = false
+	features.EndpointMetadataLabels = []string{"hardware", "canary"}
+
+	got := BuildLbEndpointMetadata("", "", "workload", "default", "cluster",
+		labels.Instance{"hardware": "gpu", "app": "foo"})
+	want := &core.Metadata{
+		FilterMetadata: map[string]*structpb.Struct{
+			EnvoyLbMetadataKey: {
+				Fields: map[string]*structpb.Value{
+					"hardware": {Kind: &structpb.Value_StringValue{StringValue: "gpu"}},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected Endpoint metadata got %v, want %v", got, want)
+	}
+
+	if got := BuildLbEndpointMetadata("", "", "workload", "default", "cluster", labels.Instance{"app": "foo"}); got != nil {
+		t.Errorf("expected no metadata without allowlisted labels, got %v", got)
+	}
+}
+
 func TestByteCount(t *testing.T) {
 	cases := []struct {
 		in  int
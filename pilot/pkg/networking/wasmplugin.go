@@ -0,0 +1,39 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import "fmt"
+
+// WasmPluginType is the type of the filter injected by a WasmPlugin.
+type WasmPluginType int
+
+const (
+	// WasmPluginTypeHTTP plugins are injected in the HTTP filters of the HTTP filter chains.
+	WasmPluginTypeHTTP WasmPluginType = iota
+	// WasmPluginTypeNetwork plugins are injected in the network filters of the TCP filter chains.
+	WasmPluginTypeNetwork
+)
+
+// ParseWasmPluginType parses the value of the experimental.istio.io/wasm-plugin-type annotation.
+func ParseWasmPluginType(value string) (WasmPluginType, error) {
+	switch value {
+	case "", "HTTP":
+		return WasmPluginTypeHTTP, nil
+	case "NETWORK":
+		return WasmPluginTypeNetwork, nil
+	default:
+		return WasmPluginTypeHTTP, fmt.Errorf("invalid type %q, must be HTTP or NETWORK", value)
+	}
+}
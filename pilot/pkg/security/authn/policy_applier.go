@@ -15,6 +15,7 @@
 package authn
 
 import (
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	http_conn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 
 	"istio.io/api/security/v1beta1"
@@ -32,6 +33,14 @@ type PolicyApplier interface {
 	// It may return nil, if no JWT validation is needed.
 	JwtFilter() *http_conn.HttpFilter
 
+	// OAuth2Filter returns the OAuth2 HTTP filter to authenticate browser sessions at gateways.
+	// It may return nil, if no policy configures OAuth2.
+	OAuth2Filter() *http_conn.HttpFilter
+
+	// IdentityHeaders returns the inbound request headers carrying the authenticated identity, and the
+	// headers to remove from client requests so they cannot be spoofed.
+	IdentityHeaders() (toAdd []*core.HeaderValueOption, toRemove []string)
+
 	// AuthNFilter returns the (authn) HTTP filter to enforce the underlying authentication policy.
 	// It may return nil, if no authentication is needed.
 	AuthNFilter(forSidecar bool) *http_conn.HttpFilter
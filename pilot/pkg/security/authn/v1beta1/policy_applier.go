@@ -20,11 +20,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	envoy_jwt "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/jwt_authn/v3"
+	envoy_oauth2 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/oauth2/v3"
 	http_conn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 
@@ -41,6 +44,9 @@ import (
 	authn_utils "istio.io/istio/pilot/pkg/security/authn/utils"
 	authn_model "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/security"
+	protovalue "istio.io/istio/pkg/proto"
 	"istio.io/pkg/log"
 )
 
@@ -55,8 +61,14 @@ type v1beta1PolicyApplier struct {
 	// processedJwtRules is the consolidate JWT rules from all jwtPolicies.
 	processedJwtRules []*v1beta1.JWTRule
 
+	// jwtCacheSettings are the cache settings of each JWT rule, from the annotations of its policy.
+	jwtCacheSettings map[*v1beta1.JWTRule]jwtCacheSettings
+
 	consolidatedPeerPolicy *v1beta1.PeerAuthentication
 
+	// identityHeaders are the identity headers of the most specific peer policy configuring them.
+	identityHeaders []security.IdentityHeader
+
 	push *model.PushContext
 }
 
@@ -65,7 +77,7 @@ func (a *v1beta1PolicyApplier) JwtFilter() *http_conn.HttpFilter {
 		return nil
 	}
 
-	filterConfigProto := convertToEnvoyJwtConfig(a.processedJwtRules, a.push)
+	filterConfigProto := convertToEnvoyJwtConfig(a.processedJwtRules, a.push, a.jwtCacheSettings)
 
 	if filterConfigProto == nil {
 		return nil
@@ -76,6 +88,82 @@ func (a *v1beta1PolicyApplier) JwtFilter() *http_conn.HttpFilter {
 	}
 }
 
+// OAuth2Filter returns the OAuth2 filter configured by the first policy with the OAuth2 annotation.
+func (a *v1beta1PolicyApplier) OAuth2Filter() *http_conn.HttpFilter {
+	for _, policy := range a.jwtPolicies {
+		value, f := policy.Annotations[constants.OAuth2Annotation]
+		if !f {
+			continue
+		}
+		cfg, err := security.ParseOAuth2Config(value)
+		if err != nil {
+			authnLog.Debugf("ignoring invalid oauth2 config of %s/%s: %v", policy.Namespace, policy.Name, err)
+			continue
+		}
+		filterConfigProto := convertToEnvoyOAuth2Config(cfg, a.push)
+		if filterConfigProto == nil {
+			authnLog.Warnf("no cluster found for oauth2 token endpoint %s of %s/%s", cfg.TokenEndpoint, policy.Namespace, policy.Name)
+			return nil
+		}
+		return &http_conn.HttpFilter{
+			Name:       authn_model.EnvoyOAuth2FilterName,
+			ConfigType: &http_conn.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(filterConfigProto)},
+		}
+	}
+	return nil
+}
+
+func convertToEnvoyOAuth2Config(cfg *security.OAuth2Config, push *model.PushContext) *envoy_oauth2.OAuth2 {
+	tokenEndpoint, _ := security.ParseJwksURI(cfg.TokenEndpoint)
+	_, cluster, err := extensionproviders.LookupCluster(push, tokenEndpoint.Hostname.String(), tokenEndpoint.Port)
+	if err != nil || cluster == "" {
+		return nil
+	}
+	credentials := &envoy_oauth2.OAuth2Credentials{
+		ClientId:    cfg.ClientID,
+		TokenSecret: authn_model.ConstructSdsSecretConfigForCredential(cfg.CredentialName + authn_model.SdsOAuth2TokenSuffix),
+		TokenFormation: &envoy_oauth2.OAuth2Credentials_HmacSecret{
+			HmacSecret: authn_model.ConstructSdsSecretConfigForCredential(cfg.CredentialName + authn_model.SdsOAuth2HmacSuffix),
+		},
+	}
+	if cfg.Cookies != nil {
+		credentials.CookieNames = &envoy_oauth2.OAuth2Credentials_CookieNames{
+			BearerToken:  cfg.Cookies.BearerToken,
+			OauthHmac:    cfg.Cookies.Hmac,
+			OauthExpires: cfg.Cookies.Expires,
+		}
+	}
+	out := &envoy_oauth2.OAuth2Config{
+		TokenEndpoint: &core.HttpUri{
+			Uri: cfg.TokenEndpoint,
+			HttpUpstreamType: &core.HttpUri_Cluster{
+				Cluster: cluster,
+			},
+			Timeout: &durationpb.Duration{Seconds: 5},
+		},
+		AuthorizationEndpoint: cfg.AuthorizationEndpoint,
+		Credentials:           credentials,
+		RedirectUri:           cfg.RedirectURI,
+		RedirectPathMatcher:   exactPathMatcher(cfg.RedirectPath),
+		ForwardBearerToken:    cfg.ForwardBearerToken,
+		AuthScopes:            cfg.Scopes,
+	}
+	if cfg.SignoutPath != "" {
+		out.SignoutPath = exactPathMatcher(cfg.SignoutPath)
+	}
+	return &envoy_oauth2.OAuth2{Config: out}
+}
+
+func exactPathMatcher(path string) *matcher.PathMatcher {
+	return &matcher.PathMatcher{
+		Rule: &matcher.PathMatcher_Path{
+			Path: &matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_Exact{Exact: path},
+			},
+		},
+	}
+}
+
 func defaultAuthnFilter() *authn_filter.FilterConfig {
 	return &authn_filter.FilterConfig{
 		Policy: &authn_alpha.Policy{},
@@ -159,12 +247,18 @@ func NewPolicyApplier(rootNamespace string,
 	peerPolicies []*config.Config,
 	push *model.PushContext) authn.PolicyApplier {
 	processedJwtRules := []*v1beta1.JWTRule{}
+	cacheSettings := map[*v1beta1.JWTRule]jwtCacheSettings{}
 
 	// TODO(diemtvu) should we need to deduplicate JWT with the same issuer.
 	// https://github.com/istio/istio/issues/19245
 	for idx := range jwtPolicies {
 		spec := jwtPolicies[idx].Spec.(*v1beta1.RequestAuthentication)
 		processedJwtRules = append(processedJwtRules, spec.JwtRules...)
+		if settings, ok := parseJwtCacheSettings(jwtPolicies[idx].Annotations); ok {
+			for _, rule := range spec.JwtRules {
+				cacheSettings[rule] = settings
+			}
+		}
 	}
 
 	// Sort the jwt rules by the issuer alphabetically to make the later-on generated filter
@@ -178,16 +272,71 @@ func NewPolicyApplier(rootNamespace string,
 		jwtPolicies:            jwtPolicies,
 		peerPolices:            peerPolicies,
 		processedJwtRules:      processedJwtRules,
+		jwtCacheSettings:       cacheSettings,
 		consolidatedPeerPolicy: ComposePeerAuthentication(rootNamespace, peerPolicies),
+		identityHeaders:        composeIdentityHeaders(rootNamespace, peerPolicies),
 		push:                   push,
 	}
 }
 
+// jwtCacheSettings configure the caching of verified tokens and fetched JWKS of a JWT provider.
+type jwtCacheSettings struct {
+	jwtCacheSize      uint32
+	jwksCacheDuration *durationpb.Duration
+	jwksAsyncFetch    *envoy_jwt.JwksAsyncFetch
+}
+
+// parseJwtCacheSettings returns the cache settings from the annotations of a RequestAuthentication, and whether
+// there are any.
+func parseJwtCacheSettings(annotations map[string]string) (jwtCacheSettings, bool) {
+	var out jwtCacheSettings
+	found := false
+	if v, f := annotations[constants.JwtCacheSizeAnnotation]; f {
+		if n, err := strconv.ParseUint(v, 10, 32); err == nil && n > 0 {
+			out.jwtCacheSize = uint32(n)
+			found = true
+		} else {
+			authnLog.Debugf("ignoring invalid %s annotation %q", constants.JwtCacheSizeAnnotation, v)
+		}
+	}
+	if v, f := annotations[constants.JwksCacheDurationAnnotation]; f {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			out.jwksCacheDuration = durationpb.New(d)
+			found = true
+		} else {
+			authnLog.Debugf("ignoring invalid %s annotation %q", constants.JwksCacheDurationAnnotation, v)
+		}
+	}
+	switch annotations[constants.JwksAsyncFetchAnnotation] {
+	case "true":
+		out.jwksAsyncFetch = &envoy_jwt.JwksAsyncFetch{}
+		found = true
+	case "fast-listener":
+		out.jwksAsyncFetch = &envoy_jwt.JwksAsyncFetch{FastListener: true}
+		found = true
+	}
+	return out, found
+}
+
+func (s jwtCacheSettings) apply(provider *envoy_jwt.JwtProvider) {
+	if s.jwtCacheSize > 0 {
+		provider.JwtCacheConfig = &envoy_jwt.JwtCacheConfig{JwtCacheSize: s.jwtCacheSize}
+	}
+	// JWKS fetched by istiod are inlined in the config, so the JWKS settings only apply to remote JWKS.
+	if remote := provider.GetRemoteJwks(); remote != nil {
+		if s.jwksCacheDuration != nil {
+			remote.CacheDuration = s.jwksCacheDuration
+		}
+		remote.AsyncFetch = s.jwksAsyncFetch
+	}
+}
+
 // convertToEnvoyJwtConfig converts a list of JWT rules into Envoy JWT filter config to enforce it.
 // Each rule is expected corresponding to one JWT issuer (provider).
 // The behavior of the filter should reject all requests with invalid token. On the other hand,
 // if no token provided, the request is allowed.
-func convertToEnvoyJwtConfig(jwtRules []*v1beta1.JWTRule, push *model.PushContext) *envoy_jwt.JwtAuthentication {
+func convertToEnvoyJwtConfig(jwtRules []*v1beta1.JWTRule, push *model.PushContext,
+	cacheSettings map[*v1beta1.JWTRule]jwtCacheSettings) *envoy_jwt.JwtAuthentication {
 	if len(jwtRules) == 0 {
 		return nil
 	}
@@ -259,6 +408,9 @@ func convertToEnvoyJwtConfig(jwtRules []*v1beta1.JWTRule, push *model.PushContex
 			// Use inline jwks as existing flow, either jwtRule.jwks is non empty or let istiod to fetch the jwtRule.jwksUri
 			provider.JwksSourceSpecifier = push.JwtKeyResolver.BuildLocalJwks(jwtRule.JwksUri, jwtRule.Issuer, jwtRule.Jwks)
 		}
+		if settings, f := cacheSettings[jwtRule]; f {
+			settings.apply(provider)
+		}
 
 		name := fmt.Sprintf("origins-%d", i)
 		providers[name] = provider
@@ -449,6 +601,75 @@ func ComposePeerAuthentication(rootNamespace string, configs []*config.Config) *
 	return &outputPolicy
 }
 
+// composeIdentityHeaders returns the identity headers of the most specific policy with the identity headers
+// annotation, preferring the oldest policy at the same level like ComposePeerAuthentication.
+func composeIdentityHeaders(rootNamespace string, configs []*config.Config) []security.IdentityHeader {
+	var selected *config.Config
+	var selectedHeaders []security.IdentityHeader
+	selectedLevel := -1
+	for _, cfg := range configs {
+		value, f := cfg.Annotations[constants.IdentityHeadersAnnotation]
+		if !f {
+			continue
+		}
+		headers, err := security.ParseIdentityHeaders(value)
+		if err != nil {
+			authnLog.Debugf("ignoring invalid identity headers of %s.%s: %v", cfg.Name, cfg.Namespace, err)
+			continue
+		}
+		spec := cfg.Spec.(*v1beta1.PeerAuthentication)
+		level := 0
+		if spec.Selector == nil || len(spec.Selector.MatchLabels) == 0 {
+			if cfg.Namespace != rootNamespace {
+				level = 1
+			}
+		} else if cfg.Namespace != rootNamespace {
+			level = 2
+		} else {
+			continue
+		}
+		if level > selectedLevel || (level == selectedLevel && cfg.CreationTimestamp.Before(selected.CreationTimestamp)) {
+			selected, selectedHeaders, selectedLevel = cfg, headers, level
+		}
+	}
+	return selectedHeaders
+}
+
+func (a *v1beta1PolicyApplier) IdentityHeaders() ([]*core.HeaderValueOption, []string) {
+	if len(a.identityHeaders) == 0 {
+		return nil, nil
+	}
+	toAdd := make([]*core.HeaderValueOption, 0, len(a.identityHeaders))
+	toRemove := make([]string, 0, len(a.identityHeaders))
+	for _, h := range a.identityHeaders {
+		toAdd = append(toAdd, &core.HeaderValueOption{
+			Header: &core.HeaderValue{
+				Key:   h.Header,
+				Value: identityHeaderValue(h),
+			},
+			Append: protovalue.BoolFalse,
+		})
+		// Headers are removed before they are added, so a spoofed value never reaches the application, even
+		// when the attribute is missing.
+		toRemove = append(toRemove, h.Header)
+	}
+	return toAdd, toRemove
+}
+
+// identityHeaderValue returns the Envoy header formatter for the attribute. Request attributes are read from
+// the metadata of the Istio authn filter. Claims are stored there as lists, so their headers are JSON lists, such
+// as ["alice"].
+func identityHeaderValue(h security.IdentityHeader) string {
+	switch h.Attribute {
+	case security.IdentityAttrSourcePrincipal:
+		return "%DOWNSTREAM_PEER_URI_SAN%"
+	case security.IdentityAttrRequestPrincipal, security.IdentityAttrRequestAudiences, security.IdentityAttrRequestPresenter:
+		return fmt.Sprintf("%%DYNAMIC_METADATA(%s:%s)%%", authn_model.AuthnFilterName, h.Attribute)
+	default:
+		return fmt.Sprintf("%%DYNAMIC_METADATA(%s:request.auth.claims:%s)%%", authn_model.AuthnFilterName, h.Claim)
+	}
+}
+
 func isMtlsModeUnset(mtls *v1beta1.PeerAuthentication_MutualTLS) bool {
 	return mtls == nil || mtls.Mode == v1beta1.PeerAuthentication_MutualTLS_UNSET
 }
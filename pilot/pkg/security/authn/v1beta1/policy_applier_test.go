@@ -16,6 +16,7 @@ package v1beta1
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,8 +24,10 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	envoy_jwt "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/jwt_authn/v3"
+	envoy_oauth2 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/oauth2/v3"
 	http_conn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -40,7 +43,9 @@ import (
 	"istio.io/istio/pilot/pkg/model/test"
 	"istio.io/istio/pilot/pkg/networking/plugin"
 	pilotutil "istio.io/istio/pilot/pkg/networking/util"
+	authn_model "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	protovalue "istio.io/istio/pkg/proto"
 )
@@ -1020,13 +1025,82 @@ func TestConvertToEnvoyJwtConfig(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			if got := convertToEnvoyJwtConfig(c.in, push); !reflect.DeepEqual(c.expected, got) {
+			if got := convertToEnvoyJwtConfig(c.in, push, nil); !reflect.DeepEqual(c.expected, got) {
 				t.Errorf("got:\n%s\nwanted:\n%s\n", spew.Sdump(got), spew.Sdump(c.expected))
 			}
 		})
 	}
 }
 
+func TestJwtCacheSettings(t *testing.T) {
+	remoteJwks := func() *envoy_jwt.JwtProvider {
+		return &envoy_jwt.JwtProvider{
+			JwksSourceSpecifier: &envoy_jwt.JwtProvider_RemoteJwks{
+				RemoteJwks: &envoy_jwt.RemoteJwks{CacheDuration: &durationpb.Duration{Seconds: 5 * 60}},
+			},
+		}
+	}
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		provider    *envoy_jwt.JwtProvider
+		expected    *envoy_jwt.JwtProvider
+	}{
+		{
+			name:        "no annotations",
+			annotations: map[string]string{},
+			provider:    remoteJwks(),
+			expected:    remoteJwks(),
+		},
+		{
+			name: "remote jwks",
+			annotations: map[string]string{
+				constants.JwtCacheSizeAnnotation:      "100",
+				constants.JwksCacheDurationAnnotation: "10m",
+				constants.JwksAsyncFetchAnnotation:    "fast-listener",
+			},
+			provider: remoteJwks(),
+			expected: &envoy_jwt.JwtProvider{
+				JwksSourceSpecifier: &envoy_jwt.JwtProvider_RemoteJwks{
+					RemoteJwks: &envoy_jwt.RemoteJwks{
+						CacheDuration: &durationpb.Duration{Seconds: 10 * 60},
+						AsyncFetch:    &envoy_jwt.JwksAsyncFetch{FastListener: true},
+					},
+				},
+				JwtCacheConfig: &envoy_jwt.JwtCacheConfig{JwtCacheSize: 100},
+			},
+		},
+		{
+			name: "local jwks",
+			annotations: map[string]string{
+				constants.JwtCacheSizeAnnotation:   "100",
+				constants.JwksAsyncFetchAnnotation: "true",
+			},
+			provider: &envoy_jwt.JwtProvider{JwksSourceSpecifier: &envoy_jwt.JwtProvider_LocalJwks{}},
+			expected: &envoy_jwt.JwtProvider{
+				JwksSourceSpecifier: &envoy_jwt.JwtProvider_LocalJwks{},
+				JwtCacheConfig:      &envoy_jwt.JwtCacheConfig{JwtCacheSize: 100},
+			},
+		},
+		{
+			name:        "invalid values",
+			annotations: map[string]string{constants.JwtCacheSizeAnnotation: "-1", constants.JwksAsyncFetchAnnotation: "yes"},
+			provider:    remoteJwks(),
+			expected:    remoteJwks(),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if settings, ok := parseJwtCacheSettings(c.annotations); ok {
+				settings.apply(c.provider)
+			}
+			if diff := cmp.Diff(c.expected, c.provider, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected provider: %v", diff)
+			}
+		})
+	}
+}
+
 func humanReadableAuthnFilterDump(filter *http_conn.HttpFilter) string {
 	if filter == nil {
 		return "<nil>"
@@ -1036,6 +1110,195 @@ func humanReadableAuthnFilterDump(filter *http_conn.HttpFilter) string {
 	return spew.Sdump(config)
 }
 
+func TestOAuth2Filter(t *testing.T) {
+	oauth2 := `{"clientID": "gateway", "credentialName": "oauth2-credential",
+		"tokenEndpoint": "https://oauth.mesh:8443/token", "authorizationEndpoint": "https://oauth.example.com/authorize",
+		"redirectURI": "https://%REQ(:authority)%/callback", "redirectPath": "/callback", "signoutPath": "/signout",
+		"scopes": ["openid"], "cookies": {"bearerToken": "token"}}`
+	pathMatcher := func(path string) *matcher.PathMatcher {
+		return &matcher.PathMatcher{
+			Rule: &matcher.PathMatcher_Path{Path: &matcher.StringMatcher{MatchPattern: &matcher.StringMatcher_Exact{Exact: path}}},
+		}
+	}
+	cases := []struct {
+		name     string
+		in       []*config.Config
+		expected *http_conn.HttpFilter
+	}{
+		{
+			name: "no annotation",
+			in: []*config.Config{
+				{Spec: &v1beta1.RequestAuthentication{}},
+			},
+		},
+		{
+			name: "invalid annotation",
+			in: []*config.Config{
+				{
+					Meta: config.Meta{Annotations: map[string]string{constants.OAuth2Annotation: `{"clientID": "gateway"}`}},
+					Spec: &v1beta1.RequestAuthentication{},
+				},
+			},
+		},
+		{
+			name: "unknown token endpoint",
+			in: []*config.Config{
+				{
+					Meta: config.Meta{Annotations: map[string]string{
+						constants.OAuth2Annotation: strings.Replace(oauth2, "oauth.mesh", "unknown.mesh", 1),
+					}},
+					Spec: &v1beta1.RequestAuthentication{},
+				},
+			},
+		},
+		{
+			name: "oauth2",
+			in: []*config.Config{
+				{Spec: &v1beta1.RequestAuthentication{}},
+				{
+					Meta: config.Meta{Annotations: map[string]string{constants.OAuth2Annotation: oauth2}},
+					Spec: &v1beta1.RequestAuthentication{},
+				},
+			},
+			expected: &http_conn.HttpFilter{
+				Name: "envoy.filters.http.oauth2",
+				ConfigType: &http_conn.HttpFilter_TypedConfig{
+					TypedConfig: pilotutil.MessageToAny(&envoy_oauth2.OAuth2{
+						Config: &envoy_oauth2.OAuth2Config{
+							TokenEndpoint: &core.HttpUri{
+								Uri: "https://oauth.mesh:8443/token",
+								HttpUpstreamType: &core.HttpUri_Cluster{
+									Cluster: "outbound|8443||oauth.mesh.svc.cluster.local",
+								},
+								Timeout: &durationpb.Duration{Seconds: 5},
+							},
+							AuthorizationEndpoint: "https://oauth.example.com/authorize",
+							Credentials: &envoy_oauth2.OAuth2Credentials{
+								ClientId: "gateway",
+								TokenSecret: &tls.SdsSecretConfig{
+									Name:      "kubernetes://oauth2-credential-oauth2-token",
+									SdsConfig: authn_model.SDSAdsConfig,
+								},
+								TokenFormation: &envoy_oauth2.OAuth2Credentials_HmacSecret{
+									HmacSecret: &tls.SdsSecretConfig{
+										Name:      "kubernetes://oauth2-credential-oauth2-hmac",
+										SdsConfig: authn_model.SDSAdsConfig,
+									},
+								},
+								CookieNames: &envoy_oauth2.OAuth2Credentials_CookieNames{BearerToken: "token"},
+							},
+							RedirectUri:         "https://%REQ(:authority)%/callback",
+							RedirectPathMatcher: pathMatcher("/callback"),
+							SignoutPath:         pathMatcher("/signout"),
+							AuthScopes:          []string{"openid"},
+						},
+					}),
+				},
+			},
+		},
+	}
+
+	push := model.NewPushContext()
+	push.ServiceIndex.HostnameAndNamespace[host.Name("oauth.mesh")] = map[string]*model.Service{}
+	push.ServiceIndex.HostnameAndNamespace[host.Name("oauth.mesh")]["mesh"] = &model.Service{
+		Hostname: "oauth.mesh.svc.cluster.local",
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := NewPolicyApplier("root-namespace", c.in, nil, push).OAuth2Filter()
+			if diff := cmp.Diff(c.expected, got, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected filter, diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestIdentityHeaders(t *testing.T) {
+	now := time.Now()
+	policy := func(name, ns string, selector bool, headers string) *config.Config {
+		spec := &v1beta1.PeerAuthentication{}
+		if selector {
+			spec.Selector = &type_beta.WorkloadSelector{MatchLabels: map[string]string{"app": "foo"}}
+		}
+		cfg := &config.Config{
+			Meta: config.Meta{Name: name, Namespace: ns, CreationTimestamp: now},
+			Spec: spec,
+		}
+		if headers != "" {
+			cfg.Annotations = map[string]string{constants.IdentityHeadersAnnotation: headers}
+		}
+		return cfg
+	}
+	cases := []struct {
+		name           string
+		in             []*config.Config
+		expectedAdd    []*core.HeaderValueOption
+		expectedRemove []string
+	}{
+		{
+			name: "no annotation",
+			in:   []*config.Config{policy("mesh", "root-namespace", false, "")},
+		},
+		{
+			name: "mesh",
+			in: []*config.Config{
+				policy("mesh", "root-namespace", false, "principal=source.principal,user=request.auth.claims[sub]"),
+				policy("workload", "foo", true, ""),
+			},
+			expectedAdd: []*core.HeaderValueOption{
+				{
+					Header: &core.HeaderValue{Key: "x-istio-identity-principal", Value: "%DOWNSTREAM_PEER_URI_SAN%"},
+					Append: protovalue.BoolFalse,
+				},
+				{
+					Header: &core.HeaderValue{
+						Key:   "x-istio-identity-user",
+						Value: "%DYNAMIC_METADATA(istio_authn:request.auth.claims:sub)%",
+					},
+					Append: protovalue.BoolFalse,
+				},
+			},
+			expectedRemove: []string{"x-istio-identity-principal", "x-istio-identity-user"},
+		},
+		{
+			name: "most specific",
+			in: []*config.Config{
+				policy("mesh", "root-namespace", false, "principal=source.principal"),
+				policy("namespace", "foo", false, "request-principal=request.auth.principal"),
+				policy("invalid", "foo", true, "principal"),
+			},
+			expectedAdd: []*core.HeaderValueOption{
+				{
+					Header: &core.HeaderValue{
+						Key:   "x-istio-identity-request-principal",
+						Value: "%DYNAMIC_METADATA(istio_authn:request.auth.principal)%",
+					},
+					Append: protovalue.BoolFalse,
+				},
+			},
+			expectedRemove: []string{"x-istio-identity-request-principal"},
+		},
+		{
+			name: "disabled",
+			in: []*config.Config{
+				policy("mesh", "root-namespace", false, "principal=source.principal"),
+				policy("workload", "foo", true, ","),
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			toAdd, toRemove := NewPolicyApplier("root-namespace", nil, c.in, &model.PushContext{}).IdentityHeaders()
+			if diff := cmp.Diff(c.expectedAdd, toAdd, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected headers to add, diff: %v", diff)
+			}
+			if diff := cmp.Diff(c.expectedRemove, toRemove); diff != "" {
+				t.Errorf("unexpected headers to remove, diff: %v", diff)
+			}
+		})
+	}
+}
+
 func TestAuthnFilterConfig(t *testing.T) {
 	ms, err := test.StartNewServer()
 	if err != nil {
@@ -47,6 +47,10 @@ var rbacPolicyMatchNever = &rbacpb.Policy{
 // General setting to control behavior
 type Option struct {
 	IsCustomBuilder bool
+	// IsProxylessGrpc generates config for the gRPC RBAC filter, which only supports ALLOW and DENY
+	// policies without dry-run, and a subset of the attributes. The workloads selected by CUSTOM policies deny
+	// all requests, as gRPC can not enforce them. AUDIT policies are ignored.
+	IsProxylessGrpc bool
 	Logger          *AuthzLogger
 }
 
@@ -83,16 +87,21 @@ func New(trustDomainBundle trustdomain.Bundle, in *plugin.InputParams, option Op
 	}
 
 	option.Logger.AppendDebugf("found %d DENY actions, %d ALLOW actions, %d AUDIT actions", len(policies.Deny), len(policies.Allow), len(policies.Audit))
-	if len(policies.Deny) == 0 && len(policies.Allow) == 0 && len(policies.Audit) == 0 {
-		return nil
-	}
-	return &Builder{
+	b := &Builder{
 		denyPolicies:      policies.Deny,
 		allowPolicies:     policies.Allow,
 		auditPolicies:     policies.Audit,
 		trustDomainBundle: trustDomainBundle,
 		option:            option,
 	}
+	if option.IsProxylessGrpc {
+		// The CUSTOM policies are not built for proxyless gRPC, but they must deny the requests.
+		b.customPolicies = policies.Custom
+	}
+	if len(b.denyPolicies) == 0 && len(b.allowPolicies) == 0 && len(b.auditPolicies) == 0 && len(b.customPolicies) == 0 {
+		return nil
+	}
+	return b
 }
 
 // BuildHTTP returns the HTTP filters built from the authorization policy.
@@ -106,8 +115,18 @@ func (b Builder) BuildHTTP() []*httppb.HttpFilter {
 		return nil
 	}
 
+	if b.option.IsProxylessGrpc {
+		if filters := b.buildGrpcDenyAll(); filters != nil {
+			return filters
+		}
+	}
+
 	var filters []*httppb.HttpFilter
-	if configs := b.build(b.auditPolicies, rbacpb.RBAC_LOG, false); configs != nil {
+	if b.option.IsProxylessGrpc {
+		if len(b.auditPolicies) > 0 {
+			b.option.Logger.AppendDebugf("ignored %d AUDIT actions not supported by proxyless gRPC", len(b.auditPolicies))
+		}
+	} else if configs := b.build(b.auditPolicies, rbacpb.RBAC_LOG, false); configs != nil {
 		b.option.Logger.AppendDebugf("built %d HTTP filters for AUDIT action", len(configs.http))
 		filters = append(filters, configs.http...)
 	}
@@ -122,6 +141,33 @@ func (b Builder) BuildHTTP() []*httppb.HttpFilter {
 	return filters
 }
 
+// buildGrpcDenyAll returns a filter denying all the requests if CUSTOM policies select the proxyless gRPC workload:
+// gRPC can not enforce them, and ignoring them would allow the requests they are meant to check. AUDIT policies
+// never deny requests, so they do not fail closed.
+func (b Builder) buildGrpcDenyAll() []*httppb.HttpFilter {
+	var unsupported []string
+	for _, policy := range b.customPolicies {
+		unsupported = append(unsupported, policy.Namespace+"."+policy.Name)
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+	b.option.Logger.AppendError(fmt.Errorf("CUSTOM action is not supported by proxyless gRPC, "+
+		"will generate a deny all config for policies %v", unsupported))
+	rbac := &rbachttppb.RBAC{Rules: &rbacpb.RBAC{
+		Action: rbacpb.RBAC_DENY,
+		Policies: map[string]*rbacpb.Policy{
+			"default-deny-all-due-to-unsupported-action": rbacPolicyMatchAll,
+		},
+	}}
+	return []*httppb.HttpFilter{
+		{
+			Name:       wellknown.HTTPRoleBasedAccessControl,
+			ConfigType: &httppb.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(rbac)},
+		},
+	}
+}
+
 // BuildTCP returns the TCP filters built from the authorization policy.
 func (b Builder) BuildTCP() []*tcppb.Filter {
 	if b.option.IsCustomBuilder {
@@ -194,10 +240,16 @@ func (b Builder) build(policies []model.AuthorizationPolicy, action rbacpb.RBAC_
 	filterType := "HTTP"
 	if forTCP {
 		filterType = "TCP"
+	} else if b.option.IsProxylessGrpc {
+		filterType = "gRPC"
 	}
 	hasEnforcePolicy, hasDryRunPolicy := false, false
 	for _, policy := range policies {
 		var currentRule *rbacpb.RBAC
+		if b.option.IsProxylessGrpc && b.isDryRun(policy) {
+			b.option.Logger.AppendDebugf("ignored dry-run policy %s.%s not supported by proxyless gRPC", policy.Namespace, policy.Name)
+			continue
+		}
 		if b.isDryRun(policy) {
 			currentRule = shadowRules
 			hasDryRunPolicy = true
@@ -224,9 +276,14 @@ func (b Builder) build(policies []model.AuthorizationPolicy, action rbacpb.RBAC_
 			if len(b.trustDomainBundle.TrustDomains) > 1 {
 				b.option.Logger.AppendDebugf("patched source principal with trust domain aliases %v", b.trustDomainBundle.TrustDomains)
 			}
-			generated, err := m.Generate(forTCP, action)
+			var generated *rbacpb.Policy
+			if b.option.IsProxylessGrpc {
+				generated, err = m.GenerateForGrpc(action)
+			} else {
+				generated, err = m.Generate(forTCP, action)
+			}
 			if err != nil {
-				b.option.Logger.AppendDebugf("skipped rule %s on TCP filter chain: %v", name, err)
+				b.option.Logger.AppendDebugf("skipped rule %s on %s filter chain: %v", name, filterType, err)
 				continue
 			}
 			if generated != nil {
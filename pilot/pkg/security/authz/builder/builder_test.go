@@ -130,6 +130,7 @@ func TestGenerator_GenerateHTTP(t *testing.T) {
 		tdBundle   trustdomain.Bundle
 		meshConfig *meshconfig.MeshConfig
 		version    *model.IstioVersion
+		grpc       bool
 		input      string
 		want       []string
 	}{
@@ -188,6 +189,18 @@ func TestGenerator_GenerateHTTP(t *testing.T) {
 			input:      "custom-simple-http-in.yaml",
 			want:       []string{"custom-bad-out.yaml"},
 		},
+		{
+			name:  "grpc-custom",
+			grpc:  true,
+			input: "custom-simple-http-in.yaml",
+			want:  []string{"grpc-deny-all-out.yaml"},
+		},
+		{
+			name:  "grpc-audit",
+			grpc:  true,
+			input: "audit-full-rule-in.yaml",
+			want:  []string{},
+		},
 		{
 			name:  "deny-and-allow",
 			input: "deny-and-allow-in.yaml",
@@ -254,6 +267,7 @@ func TestGenerator_GenerateHTTP(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			option := Option{
 				IsCustomBuilder: tc.meshConfig != nil,
+				IsProxylessGrpc: tc.grpc,
 				Logger:          &AuthzLogger{},
 			}
 			in := inputParams(t, baseDir+tc.input, tc.meshConfig, tc.version)
@@ -192,6 +192,59 @@ func (m Model) Generate(forTCP bool, action rbacpb.RBAC_Action) (*rbacpb.Policy,
 	}, nil
 }
 
+// GenerateForGrpc generates the RBAC config for proxyless gRPC from the model. Attributes not supported by gRPC
+// are handled the same way as HTTP-only attributes on a TCP filter chain: the rule is skipped for an ALLOW policy,
+// and the attribute is ignored for a DENY policy, resulting in a wider deny.
+func (m Model) GenerateForGrpc(action rbacpb.RBAC_Action) (*rbacpb.Policy, error) {
+	supported := Model{}
+	for _, rl := range m.permissions {
+		filtered, err := rl.withoutGrpcUnsupported(action)
+		if err != nil {
+			return nil, err
+		}
+		supported.permissions = append(supported.permissions, filtered)
+	}
+	for _, rl := range m.principals {
+		filtered, err := rl.withoutGrpcUnsupported(action)
+		if err != nil {
+			return nil, err
+		}
+		supported.principals = append(supported.principals, filtered)
+	}
+	return supported.Generate(false, action)
+}
+
+// UnsupportedByGrpc returns the attributes used in the rule that can not be enforced by proxyless gRPC.
+func UnsupportedByGrpc(r *authzpb.Rule) []string {
+	m, err := New(r)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, rl := range append(m.permissions, m.principals...) {
+		for _, r := range rl.rules {
+			if isGrpcUnsupported(r.key) && !seen[r.key] {
+				seen[r.key] = true
+				out = append(out, r.key)
+			}
+		}
+	}
+	return out
+}
+
+// isGrpcUnsupported returns true if the attribute can not be enforced by the gRPC RBAC filter. gRPC has no request
+// authentication, does not expose the SNI or Envoy dynamic metadata, and does not derive the remote address from
+// headers.
+func isGrpcUnsupported(key string) bool {
+	switch {
+	case key == attrRemoteIP, key == attrConnSNI, key == attrRequestPrincipal, key == attrRequestAudiences,
+		key == attrRequestPresenter, strings.HasPrefix(key, attrRequestClaims), strings.HasPrefix(key, attrEnvoyFilter):
+		return true
+	}
+	return false
+}
+
 func generatePermission(rl ruleList, forTCP bool, action rbacpb.RBAC_Action) (*rbacpb.Permission, error) {
 	var and []*rbacpb.Permission
 	for _, r := range rl.rules {
@@ -298,6 +351,20 @@ func (r rule) checkError(action rbacpb.RBAC_Action, err error) error {
 	return nil
 }
 
+func (p *ruleList) withoutGrpcUnsupported(action rbacpb.RBAC_Action) (ruleList, error) {
+	out := ruleList{}
+	for _, r := range p.rules {
+		if isGrpcUnsupported(r.key) {
+			if err := r.checkError(action, fmt.Errorf("%s is not supported by proxyless gRPC", r.key)); err != nil {
+				return ruleList{}, err
+			}
+			continue
+		}
+		out.rules = append(out.rules, r)
+	}
+	return out, nil
+}
+
 func (p *ruleList) copy() ruleList {
 	r := ruleList{}
 	r.rules = append([]*rule{}, p.rules...)
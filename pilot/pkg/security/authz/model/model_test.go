@@ -15,6 +15,7 @@
 package model
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -284,6 +285,81 @@ when:
 	}
 }
 
+func TestModel_GenerateForGrpc(t *testing.T) {
+	rule := yamlRule(t, `
+from:
+- source:
+    requestPrincipals: ["td-1/ns/foo/sa/sleep-1"]
+    principals: ["td-1/ns/foo/sa/sleep-2"]
+to:
+- operation:
+    paths: ["/echo"]
+when:
+- key: "connection.sni"
+  values: ["echo.example.com"]
+`)
+
+	cases := []struct {
+		name    string
+		action  rbacpb.RBAC_Action
+		wantErr bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "allow",
+			action:  rbacpb.RBAC_ALLOW,
+			wantErr: true,
+		},
+		{
+			name:   "deny",
+			action: rbacpb.RBAC_DENY,
+			want: []string{
+				"td-1/ns/foo/sa/sleep-2",
+				"/echo",
+			},
+			notWant: []string{
+				"td-1/ns/foo/sa/sleep-1",
+				"echo.example.com",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := New(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := m.GenerateForGrpc(tc.action)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			var gotYaml string
+			if p != nil {
+				if gotYaml, err = protomarshal.ToYAML(p); err != nil {
+					t.Fatalf("%s: failed to parse yaml: %s", tc.name, err)
+				}
+			}
+
+			for _, want := range tc.want {
+				if !strings.Contains(gotYaml, want) {
+					t.Errorf("got:\n%s but not found %s", gotYaml, want)
+				}
+			}
+			for _, notWant := range tc.notWant {
+				if strings.Contains(gotYaml, notWant) {
+					t.Errorf("got:\n%s but not want %s", gotYaml, notWant)
+				}
+			}
+		})
+	}
+
+	if got := UnsupportedByGrpc(rule); !reflect.DeepEqual(got, []string{attrConnSNI, attrRequestPrincipal}) {
+		t.Errorf("UnsupportedByGrpc() = %v", got)
+	}
+}
+
 func yamlRule(t *testing.T, yaml string) *authzpb.Rule {
 	t.Helper()
 	p := &authzpb.Rule{}
@@ -76,3 +76,10 @@ func extractNameInNestedBrackets(s string) ([]string, error) {
 func MetadataMatcherForJWTClaims(claims []string, value *matcherpb.StringMatcher) *matcherpb.MetadataMatcher {
 	return matcher.MetadataListMatcher(authn.AuthnFilterName, append([]string{attrRequestClaims}, claims...), value)
 }
+
+// MetadataMatcherForJWTClaimsPresence is a convenient method for generating metadata matcher for the presence of JWT claims.
+func MetadataMatcherForJWTClaimsPresence(claims []string) *matcherpb.MetadataMatcher {
+	m := MetadataMatcherForJWTClaims(claims, nil)
+	m.Value = &matcherpb.ValueMatcher{MatchPattern: &matcherpb.ValueMatcher_PresentMatch{PresentMatch: true}}
+	return m
+}
@@ -52,10 +52,19 @@ const (
 	// SdsCaSuffix is the suffix of the sds resource name for root CA.
 	SdsCaSuffix = "-cacert"
 
+	// SdsOAuth2TokenSuffix is the suffix of the sds resource name for the OAuth2 client secret.
+	SdsOAuth2TokenSuffix = "-oauth2-token"
+
+	// SdsOAuth2HmacSuffix is the suffix of the sds resource name for the secret signing OAuth2 cookies.
+	SdsOAuth2HmacSuffix = "-oauth2-hmac"
+
 	// EnvoyJwtFilterName is the name of the Envoy JWT filter. This should be the same as the name defined
 	// in https://github.com/envoyproxy/envoy/blob/v1.9.1/source/extensions/filters/http/well_known_names.h#L48
 	EnvoyJwtFilterName = "envoy.filters.http.jwt_authn"
 
+	// EnvoyOAuth2FilterName is the name of the Envoy OAuth2 filter.
+	EnvoyOAuth2FilterName = "envoy.filters.http.oauth2"
+
 	// AuthnFilterName is the name for the Istio AuthN filter. This should be the same
 	// as the name defined in
 	// https://github.com/istio/proxy/blob/master/src/envoy/http/authn/http_filter_factory.cc#L30
@@ -27,8 +27,11 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	listerv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 
 	"istio.io/api/label"
 	"istio.io/istio/pilot/pkg/features"
@@ -46,6 +49,7 @@ import (
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/protocol"
 	kubelib "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/inject"
 	"istio.io/istio/pkg/network"
 	"istio.io/istio/pkg/queue"
 	istiolog "istio.io/pkg/log"
@@ -68,6 +72,9 @@ const (
 	// DefaultNetworkGatewayPort is the port used by default for cross-network traffic if not otherwise specified
 	// by meshNetworks or "networking.istio.io/gatewayPort"
 	DefaultNetworkGatewayPort = 15443
+
+	// UnsupportedProtocolEventReason is the reason of the events recorded on services with ports the proxy does not handle
+	UnsupportedProtocolEventReason = "UnsupportedProtocol"
 )
 
 var log = istiolog.RegisterScope("kube", "kubernetes service registry controller", 0)
@@ -151,6 +158,20 @@ type Options struct {
 
 	// If meshConfig.DiscoverySelectors are specified, the DiscoveryNamespacesFilter tracks the namespaces this controller watches.
 	DiscoveryNamespacesFilter filter.DiscoveryNamespacesFilter
+
+	// EventRecorder, if set, records events on the Kubernetes services whose ports are not handled by the proxy.
+	EventRecorder record.EventRecorder
+}
+
+// newEventRecorder returns a recorder of events on the objects of the cluster, which stops when stop is closed.
+func newEventRecorder(kubeClient kubelib.Client, stop <-chan struct{}) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.Kube().CoreV1().Events("")})
+	go func() {
+		<-stop
+		broadcaster.Shutdown()
+	}()
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "istiod"})
 }
 
 func (o Options) GetSyncInterval() time.Duration {
@@ -275,6 +296,9 @@ type Controller struct {
 	nodeInfoMap map[string]kubernetesNode
 	// externalNameSvcInstanceMap stores hostname ==> instance, is used to store instances for ExternalName k8s services
 	externalNameSvcInstanceMap map[host.Name][]*model.ServiceInstance
+	// unsupportedPortsGenerations stores hostname => generation of the service whose unsupported ports were reported,
+	// so that the events are recorded once per change of the service rather than on every resync.
+	unsupportedPortsGenerations map[host.Name]int64
 	// index over workload instances from workload entries
 	workloadInstancesIndex workloadinstances.Index
 
@@ -292,17 +316,18 @@ type Controller struct {
 // Created by bootstrap and multicluster (see multicluster.Controller).
 func NewController(kubeClient kubelib.Client, options Options) *Controller {
 	c := &Controller{
-		opts:                       options,
-		client:                     kubeClient,
-		queue:                      queue.NewQueueWithID(1*time.Second, string(options.ClusterID)),
-		servicesMap:                make(map[host.Name]*model.Service),
-		nodeSelectorsForServices:   make(map[host.Name]labels.Instance),
-		nodeInfoMap:                make(map[string]kubernetesNode),
-		externalNameSvcInstanceMap: make(map[host.Name][]*model.ServiceInstance),
-		workloadInstancesIndex:     workloadinstances.NewIndex(),
-		informerInit:               atomic.NewBool(false),
-		beginSync:                  atomic.NewBool(false),
-		initialSync:                atomic.NewBool(false),
+		opts:                        options,
+		client:                      kubeClient,
+		queue:                       queue.NewQueueWithID(1*time.Second, string(options.ClusterID)),
+		servicesMap:                 make(map[host.Name]*model.Service),
+		nodeSelectorsForServices:    make(map[host.Name]labels.Instance),
+		nodeInfoMap:                 make(map[string]kubernetesNode),
+		externalNameSvcInstanceMap:  make(map[host.Name][]*model.ServiceInstance),
+		unsupportedPortsGenerations: make(map[host.Name]int64),
+		workloadInstancesIndex:      workloadinstances.NewIndex(),
+		informerInit:                atomic.NewBool(false),
+		beginSync:                   atomic.NewBool(false),
+		initialSync:                 atomic.NewBool(false),
 
 		multinetwork: initMultinetwork(),
 	}
@@ -525,17 +550,41 @@ func (c *Controller) onServiceEvent(curr interface{}, event model.Event) error {
 	case model.EventDelete:
 		c.deleteService(svcConv)
 	default:
+		c.recordUnsupportedPorts(svc, svcConv.Hostname)
 		c.addOrUpdateService(svc, svcConv, event, false)
 	}
 
 	return nil
 }
 
+// recordUnsupportedPorts records a warning event on the service for each of its ports whose traffic is not handled by
+// the proxy, so that users know why nothing is generated for them. The events are recorded once per generation of
+// the service, and not for the services of the system namespaces, which are not part of the mesh.
+func (c *Controller) recordUnsupportedPorts(svc *v1.Service, hostname host.Name) {
+	if c.opts.EventRecorder == nil || inject.IgnoredNamespaces.Contains(svc.Namespace) {
+		return
+	}
+	c.Lock()
+	generation, recorded := c.unsupportedPortsGenerations[hostname]
+	c.unsupportedPortsGenerations[hostname] = svc.Generation
+	c.Unlock()
+	if recorded && generation == svc.Generation {
+		return
+	}
+	for _, port := range svc.Spec.Ports {
+		if reason := protocol.UnsupportedTransportReason(string(port.Protocol), features.EnableUDPProxy); reason != "" {
+			c.opts.EventRecorder.Eventf(svc, v1.EventTypeWarning, UnsupportedProtocolEventReason,
+				"Port %d uses protocol %s, which Istio does not handle: %s", port.Port, port.Protocol, reason)
+		}
+	}
+}
+
 func (c *Controller) deleteService(svc *model.Service) {
 	c.Lock()
 	delete(c.servicesMap, svc.Hostname)
 	delete(c.nodeSelectorsForServices, svc.Hostname)
 	delete(c.externalNameSvcInstanceMap, svc.Hostname)
+	delete(c.unsupportedPortsGenerations, svc.Hostname)
 	_, isNetworkGateway := c.networkGatewaysBySvc[svc.Hostname]
 	delete(c.networkGatewaysBySvc, svc.Hostname)
 	c.Unlock()
@@ -1119,7 +1168,7 @@ func (c *Controller) serviceInstancesFromWorkloadInstance(si *model.WorkloadInst
 			}
 
 			for _, servicePort := range service.Ports {
-				if servicePort.Protocol == protocol.UDP {
+				if servicePort.Protocol == protocol.UDP && !features.EnableUDPProxy {
 					continue
 				}
 
@@ -1178,7 +1227,7 @@ func (c *Controller) WorkloadInstanceHandler(si *model.WorkloadInstance, event m
 			// We need one endpoint object for each service port
 			endpoints := make([]*model.IstioEndpoint, 0)
 			for _, port := range service.Ports {
-				if port.Protocol == protocol.UDP {
+				if port.Protocol == protocol.UDP && !features.EnableUDPProxy {
 					continue
 				}
 				// Similar code as UpdateServiceShards in eds.go
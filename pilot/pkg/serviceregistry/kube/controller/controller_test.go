@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -34,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 
 	"istio.io/api/annotation"
 	"istio.io/api/label"
@@ -520,6 +522,7 @@ func TestGetProxyServiceInstances(t *testing.T) {
 					TLSMode:        model.DisabledTLSModeLabel,
 					WorkloadName:   "pod2",
 					Namespace:      "nsa",
+					NodeName:       "node1",
 				},
 			}
 			if len(podServices) != 1 {
@@ -588,6 +591,7 @@ func TestGetProxyServiceInstances(t *testing.T) {
 					TLSMode:        model.DisabledTLSModeLabel,
 					WorkloadName:   "pod3",
 					Namespace:      "nsa",
+					NodeName:       "node1",
 				},
 			}
 			if len(podServices) != 1 {
@@ -1706,6 +1710,70 @@ func TestExternalNameServiceInstances(t *testing.T) {
 	}
 }
 
+func TestUnsupportedProtocolEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	controller, fx := NewFakeControllerWithOptions(FakeControllerOptions{EventRecorder: recorder})
+	go controller.Run(controller.stop)
+	cache.WaitForCacheSync(controller.stop, controller.HasSynced)
+	defer controller.Stop()
+
+	createServiceWithTargetPorts(controller, "dns", "nsA", nil, []coreV1.ServicePort{
+		{Name: "tcp-dns", Port: 53, Protocol: coreV1.ProtocolTCP},
+		{Name: "udp-dns", Port: 53, Protocol: coreV1.ProtocolUDP},
+		{Name: "sctp", Port: 9899, Protocol: coreV1.ProtocolSCTP},
+	}, nil, t)
+	fx.WaitOrFail(t, "service")
+
+	drain := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		return events
+	}
+	events := drain()
+	if len(events) != 2 {
+		t.Fatalf("expected an event for the UDP and SCTP ports, got %v", events)
+	}
+	for i, port := range []string{"Port 53 uses protocol UDP", "Port 9899 uses protocol SCTP"} {
+		if !strings.HasPrefix(events[i], "Warning "+UnsupportedProtocolEventReason+" "+port) {
+			t.Errorf("unexpected event %q, want an event for %q", events[i], port)
+		}
+	}
+
+	// Updates that do not change the generation of the service, such as resyncs, do not record the events again.
+	svc, err := controller.client.CoreV1().Services("nsA").Get(context.TODO(), "dns", metaV1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.Annotations = map[string]string{"foo": "bar"}
+	if _, err := controller.client.CoreV1().Services("nsA").Update(context.TODO(), svc, metaV1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	fx.WaitOrFail(t, "service")
+	if events := drain(); len(events) != 0 {
+		t.Fatalf("expected no event for an unchanged generation, got %v", events)
+	}
+
+	svc.Generation++
+	if _, err := controller.client.CoreV1().Services("nsA").Update(context.TODO(), svc, metaV1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	fx.WaitOrFail(t, "service")
+	if events := drain(); len(events) != 2 {
+		t.Fatalf("expected the events to be recorded again for a new generation, got %v", events)
+	}
+
+	// The services of the system namespaces are not part of the mesh.
+	createServiceWithTargetPorts(controller, "kube-dns", "kube-system", nil, []coreV1.ServicePort{
+		{Name: "dns", Port: 53, Protocol: coreV1.ProtocolUDP},
+	}, nil, t)
+	fx.WaitOrFail(t, "service")
+	if events := drain(); len(events) != 0 {
+		t.Fatalf("expected no event in system namespaces, got %v", events)
+	}
+}
+
 func TestController_ExternalNameService(t *testing.T) {
 	for mode, name := range EndpointModeNames {
 		mode := mode
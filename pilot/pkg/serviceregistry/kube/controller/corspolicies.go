@@ -0,0 +1,100 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/controllers"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+)
+
+// NamespaceCORSPolicies watches the DefaultCorsPolicyAnnotation of the namespaces, and triggers a full push when
+// it changes.
+type NamespaceCORSPolicies struct {
+	xdsUpdater model.XDSUpdater
+	lister     listerv1.NamespaceLister
+
+	mu       sync.RWMutex
+	policies map[string]*networking.CorsPolicy
+}
+
+var _ model.NamespaceCORSPolicyProvider = &NamespaceCORSPolicies{}
+
+// NewNamespaceCORSPolicies returns the default CORS policies of the namespaces of the cluster.
+func NewNamespaceCORSPolicies(kubeClient kube.Client, xdsUpdater model.XDSUpdater) *NamespaceCORSPolicies {
+	p := &NamespaceCORSPolicies{
+		xdsUpdater: xdsUpdater,
+		lister:     kubeClient.KubeInformer().Core().V1().Namespaces().Lister(),
+		policies:   map[string]*networking.CorsPolicy{},
+	}
+	kubeClient.KubeInformer().Core().V1().Namespaces().Informer().AddEventHandler(controllers.ObjectHandler(p.onNamespace))
+	return p
+}
+
+// DefaultCORSPolicies returns the default CORS policies, by namespace. The caller must not modify the returned map.
+func (p *NamespaceCORSPolicies) DefaultCORSPolicies() map[string]*networking.CorsPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.policies
+}
+
+func (p *NamespaceCORSPolicies) onNamespace(o controllers.Object) {
+	var policy *networking.CorsPolicy
+	// The handler is also called on deletion, in which case the namespace is gone from the lister.
+	if ns, err := p.lister.Get(o.GetName()); err == nil {
+		policy = parseDefaultCORSPolicy(ns.Name, ns.Annotations[constants.DefaultCorsPolicyAnnotation])
+	}
+	p.mu.Lock()
+	current := p.policies[o.GetName()]
+	if proto.Equal(current, policy) {
+		p.mu.Unlock()
+		return
+	}
+	// Copy on write, as the previous map may be used by a push in progress.
+	policies := make(map[string]*networking.CorsPolicy, len(p.policies)+1)
+	for ns, pol := range p.policies {
+		policies[ns] = pol
+	}
+	if policy == nil {
+		delete(policies, o.GetName())
+	} else {
+		policies[o.GetName()] = policy
+	}
+	p.policies = policies
+	p.mu.Unlock()
+	p.xdsUpdater.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.NamespaceUpdate}})
+}
+
+// parseDefaultCORSPolicy returns the CORS policy set by the annotation of the namespace, or nil if there is none or
+// it is invalid.
+func parseDefaultCORSPolicy(namespace, value string) *networking.CorsPolicy {
+	if value == "" {
+		return nil
+	}
+	policy := &networking.CorsPolicy{}
+	if err := gogoprotomarshal.ApplyYAMLStrict(value, policy); err != nil {
+		log.Warnf("ignoring invalid %s annotation of namespace %s: %v", constants.DefaultCorsPolicyAnnotation, namespace, err)
+		return nil
+	}
+	return policy
+}
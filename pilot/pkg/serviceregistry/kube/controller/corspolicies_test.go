@@ -0,0 +1,89 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestNamespaceCORSPolicies(t *testing.T) {
+	client := kube.NewFakeClient()
+	fx := NewFakeXDS()
+	policies := NewNamespaceCORSPolicies(client, fx)
+	stop := make(chan struct{})
+	defer close(stop)
+	client.RunAndWait(stop)
+
+	expectAllowMethods := func(namespace string, want []string) {
+		t.Helper()
+		retry.UntilSuccessOrFail(t, func() error {
+			got := policies.DefaultCORSPolicies()[namespace].GetAllowMethods()
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				return fmt.Errorf("got allowed methods %v, want %v", got, want)
+			}
+			return nil
+		})
+	}
+	setAnnotation := func(value string) {
+		t.Helper()
+		ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "frontend",
+			Annotations: map[string]string{constants.DefaultCorsPolicyAnnotation: value},
+		}}
+		if _, err := client.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "frontend",
+		Annotations: map[string]string{constants.DefaultCorsPolicyAnnotation: `{"allowMethods": ["GET"]}`},
+	}}
+	if _, err := client.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectAllowMethods("frontend", []string{"GET"})
+	fx.WaitOrFail(t, "xds")
+
+	setAnnotation("allowMethods: [POST]")
+	expectAllowMethods("frontend", []string{"POST"})
+	fx.WaitOrFail(t, "xds")
+
+	// invalid policies are ignored.
+	setAnnotation(`{"allowMethod": ["GET"]}`)
+	expectAllowMethods("frontend", nil)
+	fx.WaitOrFail(t, "xds")
+
+	setAnnotation("allowMethods: [PUT]")
+	expectAllowMethods("frontend", []string{"PUT"})
+	if err := client.CoreV1().Namespaces().Delete(context.TODO(), "frontend", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	retry.UntilSuccessOrFail(t, func() error {
+		if _, f := policies.DefaultCORSPolicies()["frontend"]; f {
+			return fmt.Errorf("expected the policy of the deleted namespace to be removed")
+		}
+		return nil
+	})
+}
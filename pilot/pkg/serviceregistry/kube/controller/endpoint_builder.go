@@ -15,6 +15,8 @@
 package controller
 
 import (
+	"net"
+
 	v1 "k8s.io/api/core/v1"
 
 	"istio.io/api/label"
@@ -38,6 +40,9 @@ type EndpointBuilder struct {
 	tlsMode        string
 	workloadName   string
 	namespace      string
+	nodeName       string
+	// addresses are the addresses of the workload, of both IP families if it is dual-stack.
+	addresses []string
 
 	// Values used to build dns name tables per pod.
 	// The the hostname of the Pod, by default equals to pod name.
@@ -47,7 +52,7 @@ type EndpointBuilder struct {
 }
 
 func NewEndpointBuilder(c controllerInterface, pod *v1.Pod) *EndpointBuilder {
-	locality, sa, namespace, hostname, subdomain, ip := "", "", "", "", "", ""
+	locality, sa, namespace, hostname, subdomain, ip, node := "", "", "", "", "", "", ""
 	var podLabels labels.Instance
 	if pod != nil {
 		locality = c.getPodLocality(pod)
@@ -62,6 +67,13 @@ func NewEndpointBuilder(c controllerInterface, pod *v1.Pod) *EndpointBuilder {
 			}
 		}
 		ip = pod.Status.PodIP
+		node = pod.Spec.NodeName
+	}
+	var addresses []string
+	if pod != nil {
+		for _, podIP := range pod.Status.PodIPs {
+			addresses = append(addresses, podIP.IP)
+		}
 	}
 	dm, _ := kubeUtil.GetDeployMetaFromPod(pod)
 	out := &EndpointBuilder{
@@ -74,6 +86,8 @@ func NewEndpointBuilder(c controllerInterface, pod *v1.Pod) *EndpointBuilder {
 		tlsMode:      kube.PodTLSMode(pod),
 		workloadName: dm.Name,
 		namespace:    namespace,
+		nodeName:     node,
+		addresses:    addresses,
 		hostname:     hostname,
 		subDomain:    subdomain,
 	}
@@ -92,7 +106,8 @@ func NewEndpointBuilderFromMetadata(c controllerInterface, proxy *model.Proxy) *
 			Label:     locality,
 			ClusterID: c.Cluster(),
 		},
-		tlsMode: model.GetTLSModeFromEndpointLabels(proxy.Metadata.Labels),
+		tlsMode:   model.GetTLSModeFromEndpointLabels(proxy.Metadata.Labels),
+		addresses: proxy.IPAddresses,
 	}
 	var networkID network.ID
 	if len(proxy.IPAddresses) > 0 {
@@ -124,12 +139,14 @@ func (b *EndpointBuilder) buildIstioEndpoint(
 		Locality:              b.locality,
 		TLSMode:               b.tlsMode,
 		Address:               endpointAddress,
+		AdditionalAddresses:   b.additionalAddresses(endpointAddress),
 		EndpointPort:          uint32(endpointPort),
 		ServicePortName:       svcPortName,
 		Network:               networkID,
 		WorkloadName:          b.workloadName,
 		Namespace:             b.namespace,
 		HostName:              b.hostname,
+		NodeName:              b.nodeName,
 		SubDomain:             b.subDomain,
 		DiscoverabilityPolicy: discoverabilityPolicy,
 	}
@@ -144,3 +161,29 @@ func (b *EndpointBuilder) endpointNetwork(endpointIP string) network.ID {
 
 	return b.controller.Network(endpointIP, b.labels)
 }
+
+// additionalAddresses returns the addresses of the workload of the other IP family than the endpoint address, if the
+// workload is dual-stack.
+func (b *EndpointBuilder) additionalAddresses(endpointAddress string) []string {
+	ip := net.ParseIP(endpointAddress)
+	if ip == nil {
+		return nil
+	}
+	found := false
+	var out []string
+	for _, address := range b.addresses {
+		other := net.ParseIP(address)
+		switch {
+		case other == nil:
+		case other.Equal(ip):
+			found = true
+		case (other.To4() == nil) != (ip.To4() == nil):
+			out = append(out, address)
+		}
+	}
+	// The endpoint may not be the workload, such as for endpoints set manually on a service.
+	if !found {
+		return nil
+	}
+	return out
+}
@@ -240,3 +240,56 @@ func (c testController) Network(ip string, instance labels.Instance) network.ID
 func (c testController) Cluster() cluster2.ID {
 	return c.cluster
 }
+
+func TestEndpointBuilderAdditionalAddresses(t *testing.T) {
+	cases := []struct {
+		name     string
+		podIPs   []string
+		address  string
+		expected []string
+	}{
+		{
+			name:     "single stack",
+			podIPs:   []string{"10.0.0.1"},
+			address:  "10.0.0.1",
+			expected: nil,
+		},
+		{
+			name:     "dual stack",
+			podIPs:   []string{"10.0.0.1", "fd00::1"},
+			address:  "10.0.0.1",
+			expected: []string{"fd00::1"},
+		},
+		{
+			name:     "dual stack ipv6 endpoint",
+			podIPs:   []string{"10.0.0.1", "fd00::1"},
+			address:  "fd00::1",
+			expected: []string{"10.0.0.1"},
+		},
+		{
+			name:     "endpoint not of the pod",
+			podIPs:   []string{"10.0.0.1", "fd00::1"},
+			address:  "10.0.0.2",
+			expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			pod := v1.Pod{}
+			pod.Name = "testpod"
+			pod.Namespace = "testns"
+			for _, ip := range c.podIPs {
+				pod.Status.PodIPs = append(pod.Status.PodIPs, v1.PodIP{IP: ip})
+			}
+			pod.Status.PodIP = c.podIPs[0]
+
+			eb := NewEndpointBuilder(testController{}, &pod)
+			ep := eb.buildIstioEndpoint(c.address, 8080, "http", model.AlwaysDiscoverable)
+
+			g := NewGomegaWithT(t)
+			g.Expect(ep.AdditionalAddresses).Should(Equal(c.expected))
+		})
+	}
+}
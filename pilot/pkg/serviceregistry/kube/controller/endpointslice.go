@@ -253,6 +253,7 @@ func (esc *endpointSliceController) updateEndpointCacheForSlice(hostName host.Na
 				}
 
 				istioEndpoint := builder.buildIstioEndpoint(a, portNum, portName, discoverabilityPolicy)
+				istioEndpoint.ZoneHints = zoneHints(e)
 				if ready {
 					istioEndpoint.HealthStatus = model.Healthy
 				} else {
@@ -356,6 +357,18 @@ func (esc *endpointSliceController) InstancesByPort(c *Controller, svc *model.Se
 	return out
 }
 
+// zoneHints returns the zones the topology aware hints of the endpoint select, if any.
+func zoneHints(e v1.Endpoint) []string {
+	if e.Hints == nil || len(e.Hints.ForZones) == 0 {
+		return nil
+	}
+	zones := make([]string, 0, len(e.Hints.ForZones))
+	for _, fz := range e.Hints.ForZones {
+		zones = append(zones, fz.Name)
+	}
+	return zones
+}
+
 func (esc *endpointSliceController) newEndpointBuilder(pod *corev1.Pod) *EndpointBuilder {
 	if pod != nil {
 		// Respect pod "istio-locality" label
@@ -511,7 +524,7 @@ func (esw *endpointSliceWrapper) Endpoints() []v1.Endpoint {
 		var fz []v1.ForZone
 		if ep.Hints != nil {
 			fz = make([]v1.ForZone, len(ep.Hints.ForZones))
-			for i, el := range fz {
+			for i, el := range ep.Hints.ForZones {
 				fz[i] = v1.ForZone{Name: el.Name}
 			}
 		}
@@ -17,6 +17,8 @@ package controller
 import (
 	"time"
 
+	"k8s.io/client-go/tools/record"
+
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
@@ -167,6 +169,7 @@ type FakeControllerOptions struct {
 	DomainSuffix              string
 	XDSUpdater                model.XDSUpdater
 	DiscoveryNamespacesFilter filter.DiscoveryNamespacesFilter
+	EventRecorder             record.EventRecorder
 	Stop                      chan struct{}
 }
 
@@ -204,6 +207,7 @@ func NewFakeControllerWithOptions(opts FakeControllerOptions) (*FakeController,
 		SyncInterval:              time.Microsecond,
 		DiscoveryNamespacesFilter: opts.DiscoveryNamespacesFilter,
 		MeshServiceController:     meshServiceController,
+		EventRecorder:             opts.EventRecorder,
 	}
 	c := NewController(opts.Client, options)
 	meshServiceController.AddRegistry(c)
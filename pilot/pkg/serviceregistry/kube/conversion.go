@@ -43,6 +43,9 @@ const (
 	// It is used for multi-cluster scenario, and with nodePort type gateway service.
 	// TODO: move to API
 	NodeSelectorAnnotation = "traffic.istio.io/nodeSelector"
+
+	// TopologyAwareHintsAnnotation enables topology aware hints for a service when set to "auto".
+	TopologyAwareHintsAnnotation = "service.kubernetes.io/topology-aware-hints"
 )
 
 func convertPort(port coreV1.ServicePort) *model.Port {
@@ -152,9 +155,30 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID cluster.I
 
 	istioService.Attributes.ClusterExternalAddresses.AddAddressesFor(clusterID, svc.Spec.ExternalIPs)
 
+	if respectKubernetesTrafficPolicy(svc.Annotations) {
+		istioService.Attributes.NodeLocal = svc.Spec.InternalTrafficPolicy != nil &&
+			*svc.Spec.InternalTrafficPolicy == coreV1.ServiceInternalTrafficPolicyLocal
+		istioService.Attributes.TopologyAware = strings.EqualFold(svc.Annotations[TopologyAwareHintsAnnotation], "auto")
+	}
+
+	istioService.Attributes.HTTP3 = svc.Annotations[constants.HTTP3Annotation] == "true"
+
 	return istioService
 }
 
+// respectKubernetesTrafficPolicy returns whether in-mesh traffic to the service should honor the Kubernetes
+// internalTrafficPolicy and topology aware hints.
+func respectKubernetesTrafficPolicy(annotations map[string]string) bool {
+	switch annotations[constants.KubernetesTrafficPolicyAnnotation] {
+	case constants.KubernetesTrafficPolicyRespect:
+		return true
+	case constants.KubernetesTrafficPolicyIgnore:
+		return false
+	default:
+		return features.RespectKubernetesTrafficPolicy
+	}
+}
+
 func ExternalNameServiceInstances(k8sSvc *coreV1.Service, svc *model.Service) []*model.ServiceInstance {
 	if k8sSvc == nil || k8sSvc.Spec.Type != coreV1.ServiceTypeExternalName || k8sSvc.Spec.ExternalName == "" {
 		return nil
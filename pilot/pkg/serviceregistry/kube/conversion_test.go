@@ -26,6 +26,7 @@ import (
 
 	"istio.io/api/annotation"
 	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/kube"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/spiffe"
@@ -268,6 +269,74 @@ func TestServiceConversionWithEmptyServiceAccountsAnnotation(t *testing.T) {
 	}
 }
 
+func TestServiceConversionWithKubernetesTrafficPolicy(t *testing.T) {
+	local := coreV1.ServiceInternalTrafficPolicyLocal
+	cases := []struct {
+		name              string
+		annotations       map[string]string
+		trafficPolicy     *coreV1.ServiceInternalTrafficPolicyType
+		wantNodeLocal     bool
+		wantTopologyAware bool
+	}{
+		{
+			name:          "ignored by default",
+			trafficPolicy: &local,
+			annotations:   map[string]string{TopologyAwareHintsAnnotation: "auto"},
+		},
+		{
+			name:          "respect internal traffic policy",
+			trafficPolicy: &local,
+			annotations: map[string]string{
+				constants.KubernetesTrafficPolicyAnnotation: constants.KubernetesTrafficPolicyRespect,
+			},
+			wantNodeLocal: true,
+		},
+		{
+			name: "respect topology aware hints",
+			annotations: map[string]string{
+				constants.KubernetesTrafficPolicyAnnotation: constants.KubernetesTrafficPolicyRespect,
+				TopologyAwareHintsAnnotation:                "Auto",
+			},
+			wantTopologyAware: true,
+		},
+		{
+			name:          "ignore",
+			trafficPolicy: &local,
+			annotations: map[string]string{
+				constants.KubernetesTrafficPolicyAnnotation: constants.KubernetesTrafficPolicyIgnore,
+				TopologyAwareHintsAnnotation:                "auto",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := coreV1.Service{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:        "service1",
+					Namespace:   "default",
+					Annotations: tt.annotations,
+				},
+				Spec: coreV1.ServiceSpec{
+					ClusterIP:             "10.0.0.1",
+					InternalTrafficPolicy: tt.trafficPolicy,
+					Ports: []coreV1.ServicePort{{
+						Name:     "http",
+						Port:     8080,
+						Protocol: coreV1.ProtocolTCP,
+					}},
+				},
+			}
+			service := ConvertService(svc, domainSuffix, clusterID)
+			if service.Attributes.NodeLocal != tt.wantNodeLocal {
+				t.Errorf("NodeLocal: got %v, want %v", service.Attributes.NodeLocal, tt.wantNodeLocal)
+			}
+			if service.Attributes.TopologyAware != tt.wantTopologyAware {
+				t.Errorf("TopologyAware: got %v, want %v", service.Attributes.TopologyAware, tt.wantTopologyAware)
+			}
+		})
+	}
+}
+
 func TestExternalServiceConversion(t *testing.T) {
 	serviceName := "service1"
 	namespace := "default"
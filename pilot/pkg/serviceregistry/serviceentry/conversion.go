@@ -199,8 +199,34 @@ func convertServices(cfg config.Config) []*model.Service {
 		}
 	}
 
-	return buildServices(hostAddresses, cfg.Namespace, svcPorts, serviceEntry.Location, resolution,
+	services := buildServices(hostAddresses, cfg.Namespace, svcPorts, serviceEntry.Location, resolution,
 		exportTo, labelSelectors, serviceEntry.SubjectAltNames, creationTime, cfg.Labels)
+	if resolution == model.DNSLB || resolution == model.DNSRoundRobinLB {
+		applyDNSAnnotations(services, cfg.Annotations)
+	}
+	return services
+}
+
+// applyDNSAnnotations sets the DNS refresh attributes of the services from the service entry annotations. Invalid
+// values are rejected by validation, and ignored here.
+func applyDNSAnnotations(services []*model.Service, annotations map[string]string) {
+	var refreshRate, jitter time.Duration
+	if value, f := annotations[constants.DNSRefreshRateAnnotation]; f {
+		if d, err := time.ParseDuration(value); err == nil && d >= time.Millisecond {
+			refreshRate = d
+		}
+	}
+	if value, f := annotations[constants.DNSJitterAnnotation]; f {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			jitter = d
+		}
+	}
+	ignoreTTL := annotations[constants.DNSRespectTTLAnnotation] == "false"
+	for _, svc := range services {
+		svc.Attributes.DNSRefreshRate = refreshRate
+		svc.Attributes.DNSIgnoreTTL = ignoreTTL
+		svc.Attributes.DNSJitter = jitter
+	}
 }
 
 func buildServices(hostAddresses []*HostAddress, namespace string, ports model.PortList, location networking.ServiceEntry_Location,
@@ -683,6 +683,33 @@ func TestConvertService(t *testing.T) {
 	}
 }
 
+func TestConvertServiceDNSAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		constants.DNSRefreshRateAnnotation: "5s",
+		constants.DNSJitterAnnotation:      "1s",
+		constants.DNSRespectTTLAnnotation:  "false",
+	}
+	for _, tt := range []struct {
+		externalSvc *config.Config
+		annotated   bool
+	}{
+		{externalSvc: tcpDNS, annotated: true},
+		{externalSvc: httpDNSnoEndpoints, annotated: true},
+		// the annotations only apply to DNS resolution
+		{externalSvc: tcpStatic, annotated: false},
+	} {
+		cfg := tt.externalSvc.DeepCopy()
+		cfg.Annotations = annotations
+		for _, svc := range convertServices(cfg) {
+			got := svc.Attributes.DNSRefreshRate == 5*time.Second && svc.Attributes.DNSJitter == time.Second && svc.Attributes.DNSIgnoreTTL
+			if got != tt.annotated {
+				t.Errorf("%s: got DNS attributes refresh=%v jitter=%v ignoreTTL=%v", svc.Hostname,
+					svc.Attributes.DNSRefreshRate, svc.Attributes.DNSJitter, svc.Attributes.DNSIgnoreTTL)
+			}
+		}
+	}
+}
+
 func TestConvertInstances(t *testing.T) {
 	serviceInstanceTests := []struct {
 		externalSvc *config.Config
@@ -0,0 +1,97 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution
+
+import (
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pilot/pkg/xds"
+)
+
+// EndpointDrainUpdater keeps the endpoint drains reported by the other istiod replicas.
+type EndpointDrainUpdater interface {
+	UpdateReplicaEndpointDrains(replica string, report *xds.EndpointDrainReport)
+}
+
+var _ EndpointDrainUpdater = &xds.DiscoveryServer{}
+
+// EndpointDrainWatcher reads the endpoint drains in the distribution reports of the other replicas. Unlike the
+// Controller, it runs on every replica, as the proxies drain through the replica they are connected to.
+type EndpointDrainWatcher struct {
+	podName  string
+	updater  EndpointDrainUpdater
+	informer cache.SharedIndexInformer
+}
+
+func NewEndpointDrainWatcher(client kubernetes.Interface, namespace, podName string, updater EndpointDrainUpdater) *EndpointDrainWatcher {
+	w := &EndpointDrainWatcher{podName: podName, updater: updater}
+	w.informer = informers.NewSharedInformerFactoryWithOptions(client, 1*time.Minute,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+			listOptions.LabelSelector = labels.Set(map[string]string{labelKey: "true"}).AsSelector().String()
+		})).
+		Core().V1().ConfigMaps().Informer()
+	w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: w.handleReport,
+		UpdateFunc: func(_, cur interface{}) {
+			w.handleReport(cur)
+		},
+		DeleteFunc: w.handleDelete,
+	})
+	return w
+}
+
+func (w *EndpointDrainWatcher) Run(stop <-chan struct{}) {
+	w.informer.Run(stop)
+}
+
+func (w *EndpointDrainWatcher) handleReport(obj interface{}) {
+	cm, ok := obj.(*v1.ConfigMap)
+	if !ok {
+		return
+	}
+	dr, err := ReportFromYaml([]byte(cm.Data[dataField]))
+	if err != nil {
+		scope.Warnf("received malformed distributionReport %s, discarding: %v", cm.Name, err)
+		return
+	}
+	if dr.Reporter == "" || dr.Reporter == w.podName {
+		return
+	}
+	// Reporters not sharing their drains, such as older revisions, are forgotten rather than waited for.
+	w.updater.UpdateReplicaEndpointDrains(dr.Reporter, dr.EndpointDrains)
+}
+
+func (w *EndpointDrainWatcher) handleDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cm, ok := obj.(*v1.ConfigMap)
+	if !ok {
+		return
+	}
+	// Reports are named after their reporter.
+	if replica := strings.TrimSuffix(cm.Name, "-distribution"); replica != w.podName {
+		w.updater.UpdateReplicaEndpointDrains(replica, nil)
+	}
+}
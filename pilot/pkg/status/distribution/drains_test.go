@@ -0,0 +1,72 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pilot/pkg/xds"
+)
+
+type fakeDrainUpdater map[string]*xds.EndpointDrainReport
+
+func (f fakeDrainUpdater) UpdateReplicaEndpointDrains(replica string, report *xds.EndpointDrainReport) {
+	f[replica] = report
+}
+
+func reportConfigMap(t *testing.T, r Report) *v1.ConfigMap {
+	t.Helper()
+	b, err := yaml.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: r.Reporter + "-distribution"},
+		Data:       map[string]string{dataField: string(b)},
+	}
+}
+
+func TestEndpointDrainWatcher(t *testing.T) {
+	updater := fakeDrainUpdater{}
+	w := &EndpointDrainWatcher{podName: "istiod-1", updater: updater}
+
+	drains := &xds.EndpointDrainReport{
+		Drains: map[string]xds.EndpointDrain{"a.default": {
+			Started:   time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			Addresses: []string{"10.0.0.1"},
+			Services:  map[string]string{"a.default.svc.cluster.local": "default"},
+		}},
+		// Drains without pending proxies are still listed, as seen.
+		Pending: map[string][]string{"a.default": {}, "b.default": {"c.default"}},
+	}
+	w.handleReport(reportConfigMap(t, Report{Reporter: "istiod-1", EndpointDrains: drains}))
+	w.handleReport(reportConfigMap(t, Report{Reporter: "istiod-2", EndpointDrains: drains}))
+	w.handleReport(reportConfigMap(t, Report{Reporter: "istiod-old"}))
+	want := fakeDrainUpdater{"istiod-2": drains, "istiod-old": nil}
+	if !reflect.DeepEqual(updater, want) {
+		t.Fatalf("got drains %+v, want %+v", updater, want)
+	}
+
+	w.handleDelete(reportConfigMap(t, Report{Reporter: "istiod-2"}))
+	if got, f := updater["istiod-2"]; !f || got != nil {
+		t.Fatalf("expected the deleted reporter to be forgotten, got %+v", got)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution
+
+import (
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
+)
+
+var _ envoyfilter.PatchSource = &Controller{}
+
+// handleEnvoyFilterPatches records the EnvoyFilter patches applied by the reporter.
+// must have write lock before calling.
+func (c *Controller) handleEnvoyFilterPatches(d Report) {
+	if len(d.AppliedEnvoyFilterPatches) == 0 {
+		delete(c.EnvoyFilterPatches, d.Reporter)
+		return
+	}
+	c.EnvoyFilterPatches[d.Reporter] = d.AppliedEnvoyFilterPatches
+}
+
+// IsPatchApplied returns whether the patch of the EnvoyFilter at the given generation was applied by the proxies
+// of any istiod replica which reported recently.
+func (c *Controller) IsPatchApplied(key string, generation int64, index int) bool {
+	defer c.mu.RUnlock()
+	c.mu.RLock()
+	for reporter, patches := range c.EnvoyFilterPatches {
+		if c.clock.Since(c.ObservationTime[reporter]) > c.StaleInterval {
+			continue
+		}
+		applied, ok := patches[key]
+		if !ok || applied.Generation != generation {
+			continue
+		}
+		for _, i := range applied.Indexes {
+			if i == index {
+				return true
+			}
+		}
+	}
+	return false
+}
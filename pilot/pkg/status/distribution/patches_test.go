@@ -0,0 +1,78 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
+	"istio.io/istio/pilot/pkg/status"
+)
+
+func TestControllerEnvoyFilterPatches(t *testing.T) {
+	c := &Controller{
+		clock:              clock.RealClock{},
+		StaleInterval:      time.Minute,
+		CurrentState:       make(map[status.Resource]map[string]Progress),
+		ObservationTime:    make(map[string]time.Time),
+		Rejections:         make(map[status.Resource]map[string][]Rejection),
+		rejectionMessages:  make(map[status.Resource]string),
+		EnvoyFilterPatches: make(map[string]map[string]envoyfilter.AppliedPatches),
+	}
+	c.handleReport(Report{Reporter: "istiod-1", AppliedEnvoyFilterPatches: map[string]envoyfilter.AppliedPatches{
+		"default/ef": {Generation: 2, Indexes: []int{0}},
+	}})
+	c.handleReport(Report{Reporter: "istiod-2", AppliedEnvoyFilterPatches: map[string]envoyfilter.AppliedPatches{
+		"default/ef": {Generation: 2, Indexes: []int{1}},
+	}})
+	c.handleReport(Report{Reporter: "istiod-3", AppliedEnvoyFilterPatches: map[string]envoyfilter.AppliedPatches{
+		"default/ef": {Generation: 2, Indexes: []int{2}},
+	}})
+	// istiod-3 has not reported for longer than the stale interval.
+	c.ObservationTime["istiod-3"] = time.Now().Add(-2 * time.Minute)
+
+	cases := []struct {
+		name       string
+		generation int64
+		index      int
+		want       bool
+	}{
+		{name: "applied by the first replica", generation: 2, index: 0, want: true},
+		{name: "applied by the second replica", generation: 2, index: 1, want: true},
+		{name: "applied by a stale replica", generation: 2, index: 2, want: false},
+		{name: "applied by no replica", generation: 2, index: 3, want: false},
+		{name: "other generation", generation: 1, index: 0, want: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.IsPatchApplied("default/ef", tt.generation, tt.index); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// a report without patches clears the patches of the reporter.
+	c.handleReport(Report{Reporter: "istiod-1"})
+	if c.IsPatchApplied("default/ef", 2, 0) {
+		t.Fatalf("expected the patches of istiod-1 to be cleared")
+	}
+	c.removeStaleReporters([]string{"istiod-3"})
+	if _, f := c.EnvoyFilterPatches["istiod-3"]; f {
+		t.Fatalf("expected the patches of the stale reporter to be removed")
+	}
+}
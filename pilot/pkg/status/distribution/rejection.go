@@ -0,0 +1,250 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/api/meta/v1alpha1"
+	"istio.io/istio/pilot/pkg/status"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+)
+
+const (
+	// RejectedCondition is the type of the condition reporting the proxies which rejected the config.
+	RejectedCondition = "Rejected"
+
+	// maxRejectionsInStatus is the maximum number of rejections detailed in the message of the condition.
+	maxRejectionsInStatus = 5
+)
+
+// Rejection is the rejection of the config generated from a resource by a proxy.
+type Rejection struct {
+	Proxy   string `json:"proxy"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// rejection is the last rejection of a connection, with the resources it is attributed to.
+type rejection struct {
+	Rejection
+	resources []string
+}
+
+// RegisterRejection records that a dataplane rejected the config of a type, or accepted it again if rejection is nil.
+// Rejections are rare, so unlike events, they are recorded without going through the event queue.
+func (r *Reporter) RegisterRejection(conID string, distributionType xds.EventType, rej *xds.Rejection) {
+	key := GenStatusReporterMapKey(conID, distributionType)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rej == nil {
+		delete(r.rejections, key)
+		return
+	}
+	entry := rejection{
+		Rejection: Rejection{Proxy: rej.Proxy, Type: v3.GetShortType(distributionType), Message: rej.Message},
+	}
+	for _, m := range rej.Configs {
+		if res := status.ResourceFromModelConfig(config.Config{Meta: m}); res != (status.Resource{}) {
+			entry.resources = append(entry.resources, res.String())
+		}
+	}
+	if r.rejections == nil {
+		r.rejections = make(map[string]rejection)
+	}
+	r.rejections[key] = entry
+}
+
+// rejectedResources returns the rejections of each resource, or nil if there are none.
+// must have read lock before calling.
+func (r *Reporter) rejectedResources() map[string][]Rejection {
+	var out map[string][]Rejection
+	for _, rej := range r.rejections {
+		for _, res := range rej.resources {
+			if out == nil {
+				out = make(map[string][]Rejection)
+			}
+			out[res] = append(out[res], rej.Rejection)
+		}
+	}
+	return out
+}
+
+// handleRejections replaces the rejections previously reported by the reporter of the report.
+// must have write lock before calling.
+func (c *Controller) handleRejections(d Report) {
+	for res, reporters := range c.Rejections {
+		delete(reporters, d.Reporter)
+		if len(reporters) == 0 {
+			delete(c.Rejections, res)
+		}
+	}
+	for resstr, rejections := range d.RejectedResources {
+		res := status.ResourceFromString(resstr)
+		if res == nil {
+			continue
+		}
+		if _, ok := c.Rejections[*res]; !ok {
+			c.Rejections[*res] = make(map[string][]Rejection)
+		}
+		c.Rejections[*res][d.Reporter] = rejections
+	}
+}
+
+// changedRejections returns the rejections of the resources, with their current generation, whose rejections
+// changed since they were last returned. The rejections of other generations than the current one are ignored, as
+// the proxies either did not receive the current generation yet, or the current generation fixed the config.
+func (c *Controller) changedRejections() map[status.Resource][]Rejection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	generations := map[status.Resource]string{}
+	currentGeneration := func(res status.Resource) string {
+		if gen, ok := generations[res]; ok {
+			return gen
+		}
+		gen := ""
+		if c.configStore != nil {
+			if cfg := c.configStore.Get(status.GVRtoGVK(res.GroupVersionResource), res.Name, res.Namespace); cfg != nil {
+				gen = strconv.FormatInt(cfg.Generation, 10)
+			}
+		}
+		generations[res] = gen
+		return gen
+	}
+
+	// resources are keyed without their generation here.
+	current := map[status.Resource][]Rejection{}
+	for res := range c.rejectionMessages {
+		current[res] = nil
+	}
+	for res, reporters := range c.Rejections {
+		key := res
+		key.Generation = ""
+		if _, ok := current[key]; !ok {
+			current[key] = nil
+		}
+		if res.Generation != currentGeneration(key) {
+			continue
+		}
+		for reporter, rejections := range reporters {
+			if c.clock.Since(c.ObservationTime[reporter]) > c.StaleInterval {
+				continue
+			}
+			current[key] = append(current[key], rejections...)
+		}
+	}
+
+	changed := map[status.Resource][]Rejection{}
+	for key, rejections := range current {
+		sortRejections(rejections)
+		message := rejectionMessage(rejections)
+		if message == c.rejectionMessages[key] {
+			continue
+		}
+		if message == "" {
+			delete(c.rejectionMessages, key)
+		} else {
+			c.rejectionMessages[key] = message
+		}
+		// the resource was deleted.
+		if gen := currentGeneration(key); gen != "" {
+			key.Generation = gen
+			changed[key] = rejections
+		}
+	}
+	return changed
+}
+
+func (c *Controller) writeRejectionStatus() {
+	for res, rejections := range c.changedRejections() {
+		c.rejectionWorkers.EnqueueStatusUpdateResource(rejections, res)
+	}
+}
+
+func sortRejections(rejections []Rejection) {
+	sort.Slice(rejections, func(i, j int) bool {
+		if rejections[i].Proxy != rejections[j].Proxy {
+			return rejections[i].Proxy < rejections[j].Proxy
+		}
+		return rejections[i].Type < rejections[j].Type
+	})
+}
+
+// rejectionMessage returns the message of the Rejected condition for the sorted rejections, or an empty string if
+// there are none.
+func rejectionMessage(rejections []Rejection) string {
+	if len(rejections) == 0 {
+		return ""
+	}
+	proxies := map[string]struct{}{}
+	details := make([]string, 0, maxRejectionsInStatus)
+	for i, r := range rejections {
+		proxies[r.Proxy] = struct{}{}
+		if i < maxRejectionsInStatus {
+			details = append(details, fmt.Sprintf("%s (%s): %s", r.Proxy, r.Type, r.Message))
+		}
+	}
+	if len(rejections) > maxRejectionsInStatus {
+		details = append(details, fmt.Sprintf("and %d more", len(rejections)-maxRejectionsInStatus))
+	}
+	return fmt.Sprintf("%d proxies rejected the config: %s", len(proxies), strings.Join(details, "; "))
+}
+
+// ReconcileRejections returns the status with its Rejected condition set from the rejections of the config, and
+// whether it changed. The condition is only added once a proxy rejected the config.
+func ReconcileRejections(current *v1alpha1.IstioStatus, rejections []Rejection) (bool, *v1alpha1.IstioStatus) {
+	if current == nil {
+		current = &v1alpha1.IstioStatus{}
+	}
+	desiredCondition := v1alpha1.IstioCondition{
+		Type:               RejectedCondition,
+		Status:             boolToConditionStatus(len(rejections) > 0),
+		LastProbeTime:      types.TimestampNow(),
+		LastTransitionTime: types.TimestampNow(),
+		Message:            "No proxies rejected the config.",
+	}
+	if len(rejections) > 0 {
+		desiredCondition.Reason = "ProxyRejected"
+		desiredCondition.Message = rejectionMessage(rejections)
+	}
+	current = current.DeepCopy()
+	conditionIndex := -1
+	for i, c := range current.Conditions {
+		if c.Type == RejectedCondition {
+			conditionIndex = i
+			break
+		}
+	}
+	if conditionIndex == -1 {
+		if len(rejections) == 0 {
+			return false, current
+		}
+		current.Conditions = append(current.Conditions, &desiredCondition)
+		return true, current
+	}
+	currentCondition := current.Conditions[conditionIndex]
+	if currentCondition.Message == desiredCondition.Message && currentCondition.Status == desiredCondition.Status {
+		return false, current
+	}
+	current.Conditions[conditionIndex] = &desiredCondition
+	return true, current
+}
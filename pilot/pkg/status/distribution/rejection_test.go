@@ -0,0 +1,171 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/status"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+func TestReporterRejections(t *testing.T) {
+	r := initReporterWithoutStarting()
+	vs := config.Meta{GroupVersionKind: gvk.VirtualService, Namespace: "default", Name: "vs", Generation: 2}
+	dr := config.Meta{GroupVersionKind: gvk.DestinationRule, Namespace: "default", Name: "dr", Generation: 1}
+	vsKey := status.ResourceFromModelConfig(config.Config{Meta: vs}).String()
+	drKey := status.ResourceFromModelConfig(config.Config{Meta: dr}).String()
+
+	r.RegisterRejection("conA", v3.ListenerType, &xds.Rejection{Proxy: "a.default", Message: "bad listener", Configs: []config.Meta{vs}})
+	r.RegisterRejection("conA", v3.ClusterType, &xds.Rejection{Proxy: "a.default", Message: "bad cluster", Configs: []config.Meta{dr}})
+	r.RegisterRejection("conB", v3.RouteType, &xds.Rejection{Proxy: "b.default", Message: "bad route", Configs: []config.Meta{vs}})
+	rpt, _ := r.buildReport()
+	for _, rejections := range rpt.RejectedResources {
+		sortRejections(rejections)
+	}
+	want := map[string][]Rejection{
+		vsKey: {{Proxy: "a.default", Type: "LDS", Message: "bad listener"}, {Proxy: "b.default", Type: "RDS", Message: "bad route"}},
+		drKey: {{Proxy: "a.default", Type: "CDS", Message: "bad cluster"}},
+	}
+	if !reflect.DeepEqual(rpt.RejectedResources, want) {
+		t.Fatalf("got rejections %v, want %v", rpt.RejectedResources, want)
+	}
+
+	// conA accepts the clusters again, and conB disconnects.
+	r.RegisterRejection("conA", v3.ClusterType, nil)
+	r.RegisterDisconnect("conB", xds.AllEventTypesList)
+	rpt, _ = r.buildReport()
+	want = map[string][]Rejection{
+		vsKey: {{Proxy: "a.default", Type: "LDS", Message: "bad listener"}},
+	}
+	if !reflect.DeepEqual(rpt.RejectedResources, want) {
+		t.Fatalf("got rejections %v, want %v", rpt.RejectedResources, want)
+	}
+
+	r.RegisterRejection("conA", v3.ListenerType, nil)
+	if rpt, _ = r.buildReport(); rpt.RejectedResources != nil {
+		t.Fatalf("expected no rejections, got %v", rpt.RejectedResources)
+	}
+}
+
+func TestControllerRejections(t *testing.T) {
+	store := memory.Make(collections.Pilot)
+	vs := config.Config{
+		Meta: config.Meta{GroupVersionKind: gvk.VirtualService, Namespace: "default", Name: "vs", Generation: 2},
+		Spec: &networking.VirtualService{Hosts: []string{"foo"}, Http: []*networking.HTTPRoute{{
+			Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "foo"}}},
+		}}},
+	}
+	if _, err := store.Create(vs); err != nil {
+		t.Fatal(err)
+	}
+	res := status.ResourceFromModelConfig(vs)
+	old := res
+	old.Generation = "1"
+	key := res
+	key.Generation = ""
+
+	c := &Controller{
+		configStore:       store,
+		clock:             clock.RealClock{},
+		StaleInterval:     time.Minute,
+		CurrentState:      make(map[status.Resource]map[string]Progress),
+		ObservationTime:   make(map[string]time.Time),
+		Rejections:        make(map[status.Resource]map[string][]Rejection),
+		rejectionMessages: make(map[status.Resource]string),
+	}
+	rejection := Rejection{Proxy: "a.default", Type: "LDS", Message: "bad listener"}
+	c.handleReport(Report{Reporter: "istiod-1", RejectedResources: map[string][]Rejection{
+		res.String(): {rejection},
+		// rejections of a previous generation are ignored.
+		old.String(): {{Proxy: "b.default", Type: "RDS", Message: "bad route"}},
+	}})
+	if got, want := c.changedRejections(), map[status.Resource][]Rejection{res: {rejection}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// unchanged rejections are not written again.
+	c.handleReport(Report{Reporter: "istiod-1", RejectedResources: map[string][]Rejection{res.String(): {rejection}}})
+	if got := c.changedRejections(); len(got) != 0 {
+		t.Fatalf("expected no changes, got %v", got)
+	}
+	// the rejection is cleared once the reporter no longer reports it.
+	c.handleReport(Report{Reporter: "istiod-1"})
+	if got, want := c.changedRejections(), map[status.Resource][]Rejection{res: nil}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if len(c.Rejections) != 0 || len(c.rejectionMessages) != 0 {
+		t.Fatalf("expected the rejections to be cleaned up, got %v %v", c.Rejections, c.rejectionMessages)
+	}
+}
+
+func TestReconcileRejections(t *testing.T) {
+	rejections := []Rejection{
+		{Proxy: "a.default", Type: "LDS", Message: "bad listener"},
+		{Proxy: "a.default", Type: "RDS", Message: "bad route"},
+		{Proxy: "b.default", Type: "LDS", Message: "bad listener"},
+	}
+	needsReconcile, got := ReconcileRejections(statusStillPropagating, nil)
+	if needsReconcile || !reflect.DeepEqual(got, statusStillPropagating) {
+		t.Fatalf("expected no Rejected condition without rejections, got %v", got)
+	}
+
+	needsReconcile, got = ReconcileRejections(statusStillPropagating, rejections)
+	if !needsReconcile || len(got.Conditions) != 3 {
+		t.Fatalf("expected a Rejected condition, got %v", got)
+	}
+	cond := got.Conditions[2]
+	wantMessage := "2 proxies rejected the config: a.default (LDS): bad listener; a.default (RDS): bad route; b.default (LDS): bad listener"
+	if cond.Type != RejectedCondition || cond.Status != "True" || cond.Message != wantMessage {
+		t.Fatalf("unexpected condition %v", cond)
+	}
+	if len(statusStillPropagating.Conditions) != 2 {
+		t.Fatal("the current status was modified")
+	}
+
+	if needsReconcile, _ := ReconcileRejections(got, rejections); needsReconcile {
+		t.Fatal("expected no reconcile of the same rejections")
+	}
+
+	needsReconcile, got = ReconcileRejections(got, nil)
+	if !needsReconcile || got.Conditions[2].Status != "False" {
+		t.Fatalf("expected the Rejected condition to be cleared, got %v", got)
+	}
+
+	if _, got := ReconcileRejections(nil, rejections); len(got.Conditions) != 1 {
+		t.Fatalf("expected a Rejected condition in an empty status, got %v", got)
+	}
+}
+
+func TestRejectionMessage(t *testing.T) {
+	var rejections []Rejection
+	for _, p := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		rejections = append(rejections, Rejection{Proxy: p, Type: "CDS", Message: "bad cluster"})
+	}
+	got := rejectionMessage(rejections)
+	if !strings.HasPrefix(got, "7 proxies rejected the config: a (CDS): bad cluster;") || !strings.HasSuffix(got, "e (CDS): bad cluster; and 2 more") {
+		t.Fatalf("unexpected message %q", got)
+	}
+}
@@ -16,12 +16,23 @@ package distribution
 
 import (
 	"gopkg.in/yaml.v2"
+
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
+	"istio.io/istio/pilot/pkg/xds"
 )
 
 type Report struct {
 	Reporter            string         `json:"reporter"`
 	DataPlaneCount      int            `json:"dataPlaneCount"`
 	InProgressResources map[string]int `json:"inProgressResources"`
+	// RejectedResources are the rejections of the config generated from each resource by the dataplanes.
+	RejectedResources map[string][]Rejection `json:"rejectedResources,omitempty" yaml:",omitempty"`
+	// AppliedEnvoyFilterPatches are the patches of the EnvoyFilters applied to the config of the dataplanes, by
+	// EnvoyFilter namespace/name.
+	AppliedEnvoyFilterPatches map[string]envoyfilter.AppliedPatches `json:"appliedEnvoyFilterPatches,omitempty" yaml:",omitempty"`
+	// EndpointDrains are the endpoint drains of the dataplanes, shared with the other replicas. It is nil if the
+	// reporter does not share them.
+	EndpointDrains *xds.EndpointDrainReport `json:"endpointDrains,omitempty" yaml:",omitempty"`
 }
 
 func ReportFromYaml(content []byte) (Report, error) {
@@ -28,9 +28,12 @@ import (
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/utils/clock"
 
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
 	"istio.io/istio/pilot/pkg/status"
 	"istio.io/istio/pilot/pkg/xds"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/pkg/ledger"
 )
 
@@ -62,6 +65,10 @@ type Reporter struct {
 	ledger                 ledger.Ledger
 	distributionEventQueue chan distributionEvent
 	controller             *Controller
+	// map from connection id and type to the last rejection of the connection
+	rejections map[string]rejection
+	// EndpointDrains returns the endpoint drains to share with the other replicas, if set.
+	EndpointDrains func() *xds.EndpointDrainReport
 }
 
 var _ xds.DistributionStatusCache = &Reporter{}
@@ -82,6 +89,7 @@ func (r *Reporter) Init(ledger ledger.Ledger, stop <-chan struct{}) {
 	r.status = make(map[string]string)
 	r.reverseStatus = make(map[string]map[string]struct{})
 	r.inProgressResources = make(map[string]*inProgressEntry)
+	r.rejections = make(map[string]rejection)
 	go r.readFromEventQueue(stop)
 }
 
@@ -136,6 +144,13 @@ func (r *Reporter) buildReport() (Report, []status.Resource) {
 		Reporter:            r.PodName,
 		DataPlaneCount:      len(r.status),
 		InProgressResources: map[string]int{},
+		RejectedResources:   r.rejectedResources(),
+	}
+	if features.EnableEnvoyFilterPatchStatus {
+		out.AppliedEnvoyFilterPatches = envoyfilter.LocalAppliedPatches()
+	}
+	if r.EndpointDrains != nil {
+		out.EndpointDrains = r.EndpointDrains()
 	}
 	// for every resource in flight
 	for _, ipr := range r.inProgressResources {
@@ -217,6 +232,9 @@ func (r *Reporter) AddInProgressResource(res config.Config) {
 
 func (r *Reporter) DeleteInProgressResource(res config.Config) {
 	tryLedgerDelete(r.ledger, res)
+	if res.GroupVersionKind == gvk.EnvoyFilter {
+		envoyfilter.ForgetAppliedPatches(res.Namespace + "/" + res.Name)
+	}
 	if r.controller != nil {
 		r.controller.configDeleted(res)
 	}
@@ -342,6 +360,7 @@ func (r *Reporter) RegisterDisconnect(conID string, types []xds.EventType) {
 		key := GenStatusReporterMapKey(conID, xdsType)
 		r.deleteKeyFromReverseMap(key)
 		delete(r.status, key)
+		delete(r.rejections, key)
 	}
 }
 
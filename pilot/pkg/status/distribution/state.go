@@ -33,6 +33,7 @@ import (
 	"istio.io/api/meta/v1alpha1"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
 	"istio.io/istio/pilot/pkg/status"
 	"istio.io/istio/pkg/config"
 	"istio.io/pkg/log"
@@ -62,6 +63,14 @@ type Controller struct {
 	workers         *status.Controller
 	StaleInterval   time.Duration
 	cmInformer      cache.SharedIndexInformer
+	// Rejections holds the rejections of each resource reported by each reporter.
+	Rejections map[status.Resource]map[string][]Rejection
+	// rejectionMessages holds the rejection message last written to the status of each resource, keyed by the
+	// resource without its generation.
+	rejectionMessages map[status.Resource]string
+	rejectionWorkers  *status.Controller
+	// EnvoyFilterPatches holds the EnvoyFilter patches applied by each reporter.
+	EnvoyFilterPatches map[string]map[string]envoyfilter.AppliedPatches
 }
 
 func NewController(restConfig *rest.Config, namespace string, cs model.ConfigStore, m *status.Manager) *Controller {
@@ -79,6 +88,15 @@ func NewController(restConfig *rest.Config, namespace string, cs model.ConfigSto
 			}
 			return status
 		}),
+		Rejections:         make(map[status.Resource]map[string][]Rejection),
+		rejectionMessages:  make(map[status.Resource]string),
+		EnvoyFilterPatches: make(map[string]map[string]envoyfilter.AppliedPatches),
+		rejectionWorkers: m.CreateIstioStatusController(func(status *v1alpha1.IstioStatus, context interface{}) *v1alpha1.IstioStatus {
+			if needsReconcile, desiredStatus := ReconcileRejections(status, context.([]Rejection)); needsReconcile {
+				return desiredStatus
+			}
+			return status
+		}),
 	}
 
 	// client-go defaults to 5 QPS, with 10 Boost, which is insufficient for updating status on all the config
@@ -123,6 +141,7 @@ func (c *Controller) Start(stop <-chan struct{}) {
 				if len(staleReporters) > 0 {
 					c.removeStaleReporters(staleReporters)
 				}
+				c.writeRejectionStatus()
 			}
 		}
 	}()
@@ -138,6 +157,8 @@ func (c *Controller) handleReport(d Report) {
 		}
 		c.CurrentState[res][d.Reporter] = Progress{d.InProgressResources[resstr], d.DataPlaneCount}
 	}
+	c.handleRejections(d)
+	c.handleEnvoyFilterPatches(d)
 	c.ObservationTime[d.Reporter] = c.clock.Now()
 }
 
@@ -178,6 +199,14 @@ func (c *Controller) removeStaleReporters(staleReporters []string) {
 		}
 		c.CurrentState[key] = fractions
 	}
+	for _, reporters := range c.Rejections {
+		for _, staleReporter := range staleReporters {
+			delete(reporters, staleReporter)
+		}
+	}
+	for _, staleReporter := range staleReporters {
+		delete(c.EnvoyFilterPatches, staleReporter)
+	}
 }
 
 func (c *Controller) queueWriteStatus(config status.Resource, state Progress) {
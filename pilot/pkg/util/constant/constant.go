@@ -17,4 +17,7 @@ package constant
 const (
 	// HeaderJWTClaim is the special header name used in virtual service for routing based on JWT claims.
 	HeaderJWTClaim = "@request.auth.claims."
+	// HeaderJWTClaimBracket is the special header name used in virtual service for routing based on JWT claims whose
+	// names contain ".", with each claim name surrounded by brackets.
+	HeaderJWTClaimBracket = "@request.auth.claims["
 )
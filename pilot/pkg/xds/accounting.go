@@ -0,0 +1,92 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/pkg/monitoring"
+)
+
+const heapAllocsMetric = "/gc/heap/allocs:bytes"
+
+var (
+	// generatorCalls counts the generator calls that were started, and generatorsRunning the ones that are running,
+	// to tell whether a call overlapped with others.
+	generatorCalls    uint64
+	generatorsRunning int64
+)
+
+// generatorUsage is the CPU time and the memory allocated by a call of an xDS generator.
+type generatorUsage struct {
+	call      uint64
+	exclusive bool
+	cpu       time.Duration
+	cpuOK     bool
+	allocs    uint64
+}
+
+// startGeneratorUsage starts measuring a generator call, and returns nil if the accounting is disabled. The
+// goroutine is locked to its thread until recordGeneratorUsage, so that the CPU time of the thread is the CPU time
+// of the call.
+func startGeneratorUsage() *generatorUsage {
+	if !features.EnableXDSGeneratorAccounting {
+		return nil
+	}
+	runtime.LockOSThread()
+	u := &generatorUsage{
+		call:      atomic.AddUint64(&generatorCalls, 1),
+		exclusive: atomic.AddInt64(&generatorsRunning, 1) == 1,
+		allocs:    heapAllocs(),
+	}
+	u.cpu, u.cpuOK = threadCPUTime()
+	return u
+}
+
+// recordGeneratorUsage records the usage of a generator call started by startGeneratorUsage.
+func recordGeneratorUsage(u *generatorUsage, typeURL string, proxy *model.Proxy) {
+	if u == nil {
+		return
+	}
+	cpu, cpuOK := threadCPUTime()
+	allocs := heapAllocs()
+	runtime.UnlockOSThread()
+	running := atomic.AddInt64(&generatorsRunning, -1)
+
+	labels := []monitoring.LabelValue{typeTag.Value(v3.GetMetricType(typeURL)), proxyTypeTag.Value(string(proxy.Type))}
+	if cpuOK && u.cpuOK {
+		generatorCPUTime.With(labels...).Record((cpu - u.cpu).Seconds())
+	}
+	// The runtime only counts the allocations of the whole process, so calls that overlap with other calls are
+	// not recorded.
+	if u.exclusive && running == 0 && atomic.LoadUint64(&generatorCalls) == u.call {
+		generatorAllocBytes.With(labels...).Record(float64(allocs - u.allocs))
+	}
+}
+
+func heapAllocs() uint64 {
+	sample := []metrics.Sample{{Name: heapAllocsMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
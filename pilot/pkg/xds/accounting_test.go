@@ -0,0 +1,67 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	"go.opencensus.io/stats/view"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+func distributionCount(t *testing.T, name, xdsType, proxyType string) int64 {
+	t.Helper()
+	rows, err := view.RetrieveData(name)
+	if err != nil {
+		t.Fatalf("failed to get the rows of %s: %v", name, err)
+	}
+	for _, row := range rows {
+		tags := map[string]string{}
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["type"] == xdsType && tags["proxy_type"] == proxyType {
+			return row.Data.(*view.DistributionData).Count
+		}
+	}
+	return 0
+}
+
+func TestGeneratorUsage(t *testing.T) {
+	proxy := &model.Proxy{Type: model.Router}
+	cpu := distributionCount(t, "pilot_xds_generator_cpu_seconds", "rds", "router")
+	allocs := distributionCount(t, "pilot_xds_generator_alloc_bytes", "rds", "router")
+
+	recordGeneratorUsage(startGeneratorUsage(), v3.RouteType, proxy)
+	if got := distributionCount(t, "pilot_xds_generator_cpu_seconds", "rds", "router"); got != cpu {
+		t.Fatalf("expected no usage to be recorded when disabled, got %d samples", got-cpu)
+	}
+
+	defaultValue := features.EnableXDSGeneratorAccounting
+	features.EnableXDSGeneratorAccounting = true
+	defer func() { features.EnableXDSGeneratorAccounting = defaultValue }()
+
+	recordGeneratorUsage(startGeneratorUsage(), v3.RouteType, proxy)
+
+	if got := distributionCount(t, "pilot_xds_generator_cpu_seconds", "rds", "router"); got != cpu+1 {
+		t.Errorf("got %d cpu samples, want %d", got, cpu+1)
+	}
+	if got := distributionCount(t, "pilot_xds_generator_alloc_bytes", "rds", "router"); got != allocs+1 {
+		t.Errorf("got %d allocation samples, want %d", got, allocs+1)
+	}
+}
@@ -23,7 +23,9 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	uatomic "go.uber.org/atomic"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
@@ -36,6 +38,7 @@ import (
 	"istio.io/istio/pilot/pkg/util/sets"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/pkg/env"
 	istiolog "istio.io/pkg/log"
@@ -111,6 +114,13 @@ type Connection struct {
 	// (last push not ACKed). When we get an ACK from Envoy, if the type is populated here, we will trigger
 	// the push.
 	blockedPushes map[string]*model.PushRequest
+
+	// rejectedTypes are the nonces of the last responses rejected by the proxy, by type, until a later response of
+	// the type is accepted.
+	rejectedTypes map[string]string
+
+	// configHistory retains the config of the last pushes, if enabled with PILOT_CONFIG_HISTORY_SIZE.
+	configHistory *configHistory
 }
 
 // Event represents a config or registry event that results in a push.
@@ -133,6 +143,8 @@ func newConnection(peerAddr string, stream DiscoveryStream) *Connection {
 		Connect:       time.Now(),
 		stream:        stream,
 		blockedPushes: map[string]*model.PushRequest{},
+		rejectedTypes: map[string]string{},
+		configHistory: newConfigHistory(features.ConfigHistorySize),
 	}
 }
 
@@ -250,6 +262,7 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream discovery.AggregatedD
 }
 
 func (s *DiscoveryServer) Stream(stream DiscoveryStream) error {
+	setReconnectHint(stream)
 	if knativeEnv != "" && firstRequest.Load() {
 		// How scaling works in knative is the first request is the "loading" request. During
 		// loading request, concurrency=1. Once that request is done, concurrency is enabled.
@@ -269,6 +282,9 @@ func (s *DiscoveryServer) Stream(stream DiscoveryStream) error {
 	if !s.IsServerReady() {
 		return status.Error(codes.Unavailable, "server is not ready to serve discovery information")
 	}
+	if s.IsDraining() {
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
 
 	ctx := stream.Context()
 	peerAddr := "0.0.0.0"
@@ -339,6 +355,21 @@ func (s *DiscoveryServer) Stream(stream DiscoveryStream) error {
 	}
 }
 
+// setReconnectHint attaches the configured reconnect backoff and jitter to the stream trailers, so
+// clients can space out reconnects when the stream is closed.
+func setReconnectHint(stream grpc.ServerStream) {
+	md := metadata.MD{}
+	if features.XDSReconnectBackoff > 0 {
+		md.Set(constants.ReconnectBackoffHeader, features.XDSReconnectBackoff.String())
+	}
+	if features.XDSReconnectJitter > 0 {
+		md.Set(constants.ReconnectJitterHeader, features.XDSReconnectJitter.String())
+	}
+	if len(md) > 0 {
+		stream.SetTrailer(md)
+	}
+}
+
 // shouldRespond determines whether this request needs to be responded back. It applies the ack/nack rules as per xds protocol
 // using WatchedResource for previous state and discovery request for the current state.
 func (s *DiscoveryServer) shouldRespond(con *Connection, request *discovery.DiscoveryRequest) bool {
@@ -354,6 +385,7 @@ func (s *DiscoveryServer) shouldRespond(con *Connection, request *discovery.Disc
 		if s.StatusGen != nil {
 			s.StatusGen.OnNack(con.proxy, request)
 		}
+		s.reportRejection(con, request.TypeUrl, request.ResponseNonce, request.ErrorDetail)
 		con.proxy.Lock()
 		if w, f := con.proxy.WatchedResources[request.TypeUrl]; f {
 			w.NonceNacked = request.ResponseNonce
@@ -406,8 +438,12 @@ func (s *DiscoveryServer) shouldRespond(con *Connection, request *discovery.Disc
 	previousResources := con.proxy.WatchedResources[request.TypeUrl].ResourceNames
 	con.proxy.WatchedResources[request.TypeUrl].NonceAcked = request.ResponseNonce
 	con.proxy.WatchedResources[request.TypeUrl].NonceNacked = ""
+	if sent := con.proxy.WatchedResources[request.TypeUrl].LastSent; !sent.IsZero() {
+		con.proxy.WatchedResources[request.TypeUrl].LastAckLatency = time.Since(sent)
+	}
 	con.proxy.WatchedResources[request.TypeUrl].ResourceNames = request.ResourceNames
 	con.proxy.Unlock()
+	s.reportRejection(con, request.TypeUrl, request.ResponseNonce, nil)
 
 	// Envoy can send two DiscoveryRequests with same version and nonce
 	// when it detects a new resource. We should respond if they change.
@@ -525,6 +561,11 @@ func (s *DiscoveryServer) closeConnection(con *Connection) {
 		s.StatusReporter.RegisterDisconnect(con.ConID, AllEventTypesList)
 	}
 	s.WorkloadEntryController.QueueUnregisterWorkload(con.proxy, con.Connect)
+	if drain, f := s.endpointDrains.stop(con.proxy.ID); f {
+		// The endpoints are usually removed by the registry by now. If the proxy only reconnects, they are no
+		// longer reported as draining until it asks again.
+		s.pushEndpointDrain(drain)
+	}
 }
 
 func connectionID(node string) string {
@@ -611,6 +652,7 @@ func (s *DiscoveryServer) updateProxy(proxy *model.Proxy, request *model.PushReq
 
 func (s *DiscoveryServer) computeProxyState(proxy *model.Proxy, request *model.PushRequest) {
 	proxy.SetWorkloadLabels(s.Env)
+	proxy.SetFeatureGates()
 	proxy.SetServiceInstances(s.Env.ServiceDiscovery)
 	// Precompute the sidecar scope and merged gateways associated with this proxy.
 	// Saves compute cycles in networking code. Though this might be redundant sometimes, we still
@@ -893,7 +935,11 @@ func (conn *Connection) send(res *discovery.DiscoveryResponse) error {
 			conn.proxy.WatchedResources[res.TypeUrl].NonceSent = res.Nonce
 			conn.proxy.WatchedResources[res.TypeUrl].VersionSent = res.VersionInfo
 			conn.proxy.WatchedResources[res.TypeUrl].LastSent = time.Now()
+			previousSize := conn.proxy.WatchedResources[res.TypeUrl].LastSize
+			conn.proxy.WatchedResources[res.TypeUrl].LastSize = sz
+			conn.proxy.WatchedResources[res.TypeUrl].LastResourceCount = len(res.Resources)
 			conn.proxy.Unlock()
+			checkConfigSizeThreshold(conn.proxy.ID, res.TypeUrl, previousSize, sz)
 		}
 	} else if status.Convert(err).Code() == codes.DeadlineExceeded {
 		log.Infof("Timeout writing %s", conn.ConID)
@@ -22,6 +22,8 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
@@ -130,6 +132,25 @@ func TestAdsReconnect(t *testing.T) {
 	ads2.ExpectResponse(t)
 }
 
+func TestAdsDrain(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
+	ads := s.ConnectADS().WithType(v3.ClusterType)
+	ads.RequestResponseAck(t, nil)
+	ads2 := s.ConnectADS().WithType(v3.ClusterType)
+	ads2.RequestResponseAck(t, nil)
+
+	s.Discovery.Drain(time.Millisecond * 10)
+	ads.ExpectError(t)
+	ads2.ExpectError(t)
+
+	// new connections are rejected while draining
+	ads3 := s.ConnectADS().WithType(v3.ClusterType)
+	ads3.Request(t, nil)
+	if err := ads3.ExpectError(t); grpcstatus.Code(err) != codes.Unavailable {
+		t.Fatalf("expected unavailable error, got %v", err)
+	}
+}
+
 // Regression for connection with a bad ID
 func TestAdsBadId(t *testing.T) {
 	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
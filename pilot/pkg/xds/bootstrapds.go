@@ -78,7 +78,7 @@ func (e *BootstrapGenerator) applyPatches(bs *bootstrapv3.Bootstrap, proxy *mode
 	for _, patch := range patches.Patches[networking.EnvoyFilter_BOOTSTRAP] {
 		if patch.Operation == networking.EnvoyFilter_Patch_MERGE {
 			proto.Merge(bs, patch.Value)
-			envoyfilter.IncrementEnvoyFilterMetric(patch.Key(), envoyfilter.Bootstrap, true)
+			envoyfilter.IncrementEnvoyFilterMetric(patch, envoyfilter.Bootstrap, true)
 		} else {
 			envoyfilter.IncrementEnvoyFilterErrorMetric(envoyfilter.Bootstrap)
 		}
@@ -80,7 +80,7 @@ func (c CdsGenerator) Generate(proxy *model.Proxy, push *model.PushContext, w *m
 	return clusters, logs, nil
 }
 
-// GenerateDeltas for CDS currently only builds deltas when services change. todo implement changes for DestinationRule, etc
+// GenerateDeltas for CDS only builds the clusters affected by ServiceEntry and DestinationRule changes.
 func (c CdsGenerator) GenerateDeltas(proxy *model.Proxy, push *model.PushContext, updates *model.PushRequest,
 	w *model.WatchedResource) (model.Resources, model.DeletedResources, model.XdsLogDetails, bool, error) {
 	if !cdsNeedsPush(updates, proxy) {
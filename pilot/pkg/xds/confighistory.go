@@ -0,0 +1,270 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	any "google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+// ConfigHistory is the changes between the consecutive pushes of each type retained for a proxy.
+type ConfigHistory struct {
+	ProxyID string                  `json:"proxy"`
+	Types   map[string][]ConfigDiff `json:"types"`
+}
+
+// ConfigDiff is the changes of the config of a type between two pushes.
+type ConfigDiff struct {
+	Time        time.Time         `json:"time"`
+	FromVersion string            `json:"from_version"`
+	ToVersion   string            `json:"to_version"`
+	Reasons     []string          `json:"reasons,omitempty"`
+	Added       []string          `json:"added,omitempty"`
+	Removed     []string          `json:"removed,omitempty"`
+	Modified    []ResourceChanges `json:"modified,omitempty"`
+	// Unchanged is set when the push did not change the config, which usually means it was not necessary.
+	Unchanged bool `json:"unchanged,omitempty"`
+}
+
+// ResourceChanges is the changes of the fields of a resource, identified by their JSON path.
+type ResourceChanges struct {
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is the change of the value of a field, absent if unset.
+type FieldChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// configHistory retains the config of the last pushes of each type to a proxy.
+type configHistory struct {
+	mu    sync.Mutex
+	size  int
+	types map[string][]*configSnapshot
+}
+
+// configSnapshot is the config of a type known by a proxy after a push.
+type configSnapshot struct {
+	time      time.Time
+	version   string
+	reasons   []string
+	resources map[string]*any.Any
+}
+
+func newConfigHistory(size int) *configHistory {
+	if size <= 0 {
+		return nil
+	}
+	return &configHistory{size: size, types: map[string][]*configSnapshot{}}
+}
+
+// record records the resources of a push. Incremental pushes only contain the changed resources and the names of the
+// removed ones, so they are applied to the previous config of the type.
+func (h *configHistory) record(typeURL, version string, req *model.PushRequest, res model.Resources, removed []string,
+	incremental bool) {
+	if h == nil || strings.HasPrefix(typeURL, v3.DebugType) {
+		return
+	}
+	snapshot := &configSnapshot{time: time.Now(), version: version, resources: make(map[string]*any.Any, len(res))}
+	if req != nil {
+		for _, r := range req.Reason {
+			snapshot.reasons = append(snapshot.reasons, string(r))
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshots := h.types[typeURL]
+	if incremental && len(snapshots) > 0 {
+		for name, r := range snapshots[len(snapshots)-1].resources {
+			snapshot.resources[name] = r
+		}
+	}
+	for _, name := range removed {
+		delete(snapshot.resources, name)
+	}
+	for _, r := range res {
+		snapshot.resources[r.Name] = r.Resource
+	}
+	snapshots = append(snapshots, snapshot)
+	if len(snapshots) > h.size {
+		snapshots = snapshots[len(snapshots)-h.size:]
+	}
+	h.types[typeURL] = snapshots
+}
+
+// history returns the changes between the consecutive pushes of the types matching the filter, either a type URL or
+// its short name, or of all types if it is empty.
+func (h *configHistory) history(proxyID, typeFilter string) (ConfigHistory, error) {
+	h.mu.Lock()
+	types := make(map[string][]*configSnapshot, len(h.types))
+	for typeURL, snapshots := range h.types {
+		if typeFilter == "" || strings.EqualFold(typeFilter, typeURL) || strings.EqualFold(typeFilter, v3.GetShortType(typeURL)) {
+			// snapshots are never modified once recorded, so they can be diffed without holding the lock.
+			types[typeURL] = append([]*configSnapshot(nil), snapshots...)
+		}
+	}
+	h.mu.Unlock()
+
+	out := ConfigHistory{ProxyID: proxyID, Types: map[string][]ConfigDiff{}}
+	for typeURL, snapshots := range types {
+		diffs := make([]ConfigDiff, 0, len(snapshots))
+		for i := 1; i < len(snapshots); i++ {
+			diff, err := diffSnapshots(snapshots[i-1], snapshots[i])
+			if err != nil {
+				return ConfigHistory{}, fmt.Errorf("failed to diff the %s config: %v", v3.GetShortType(typeURL), err)
+			}
+			diffs = append(diffs, diff)
+		}
+		out.Types[v3.GetShortType(typeURL)] = diffs
+	}
+	return out, nil
+}
+
+func diffSnapshots(from, to *configSnapshot) (ConfigDiff, error) {
+	diff := ConfigDiff{Time: to.time, FromVersion: from.version, ToVersion: to.version, Reasons: to.reasons}
+	for name := range from.resources {
+		if _, f := to.resources[name]; !f {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	for name, r := range to.resources {
+		old, f := from.resources[name]
+		if !f {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+		if old == r || bytes.Equal(old.Value, r.Value) {
+			continue
+		}
+		changes, err := diffResourceFields(old, r)
+		if err != nil {
+			return ConfigDiff{}, fmt.Errorf("resource %s: %v", name, err)
+		}
+		// The serialization of equal resources may differ, for example in the order of map entries.
+		if len(changes) > 0 {
+			diff.Modified = append(diff.Modified, ResourceChanges{Name: name, Changes: changes})
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Modified, func(i, j int) bool {
+		return diff.Modified[i].Name < diff.Modified[j].Name
+	})
+	diff.Unchanged = len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Modified) == 0
+	return diff, nil
+}
+
+func diffResourceFields(from, to *any.Any) ([]FieldChange, error) {
+	fromJSON, err := resourceJSON(from)
+	if err != nil {
+		return nil, err
+	}
+	toJSON, err := resourceJSON(to)
+	if err != nil {
+		return nil, err
+	}
+	return diffJSON("", fromJSON, toJSON, nil), nil
+}
+
+func resourceJSON(r *any.Any) (interface{}, error) {
+	msg, err := r.UnmarshalNew()
+	if err != nil {
+		return nil, err
+	}
+	js, err := protomarshal.ToJSON(msg)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal([]byte(js), &out)
+	return out, err
+}
+
+// diffJSON appends the changes between two decoded JSON values to out. Objects are compared field by field and arrays
+// of the same length element by element, other values are compared as a whole.
+func diffJSON(path string, from, to interface{}, out []FieldChange) []FieldChange {
+	switch f := from.(type) {
+	case map[string]interface{}:
+		if t, ok := to.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(f)+len(t))
+			for k := range f {
+				keys = append(keys, k)
+			}
+			for k := range t {
+				if _, ok := f[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				p := k
+				if path != "" {
+					p = path + "." + k
+				}
+				out = diffJSON(p, f[k], t[k], out)
+			}
+			return out
+		}
+	case []interface{}:
+		if t, ok := to.([]interface{}); ok && len(t) == len(f) {
+			for i := range f {
+				out = diffJSON(fmt.Sprintf("%s[%d]", path, i), f[i], t[i], out)
+			}
+			return out
+		}
+	}
+	if !reflect.DeepEqual(from, to) {
+		out = append(out, FieldChange{Path: path, From: from, To: to})
+	}
+	return out
+}
+
+// configHistoryz reports the changes between the consecutive pushes to the proxy passed in proxyID, optionally
+// filtered by type.
+func (s *DiscoveryServer) configHistoryz(w http.ResponseWriter, req *http.Request) {
+	proxyID, con := s.getDebugConnection(req)
+	if con == nil {
+		s.errorHandler(w, proxyID, con)
+		return
+	}
+	if con.configHistory == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("Config history is disabled, set PILOT_CONFIG_HISTORY_SIZE to enable it\n"))
+		return
+	}
+	out, err := con.configHistory.history(con.proxy.ID, req.URL.Query().Get("type"))
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	writeJSON(w, out)
+}
@@ -0,0 +1,175 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+func testCluster(name string, timeout time.Duration) *discovery.Resource {
+	return &discovery.Resource{
+		Name:     name,
+		Resource: util.MessageToAny(&cluster.Cluster{Name: name, ConnectTimeout: durationpb.New(timeout)}),
+	}
+}
+
+func TestConfigHistory(t *testing.T) {
+	h := newConfigHistory(3)
+	req := &model.PushRequest{Reason: []model.TriggerReason{model.ConfigUpdate}}
+	h.record(v3.ClusterType, "1", req, model.Resources{testCluster("a", time.Second), testCluster("b", time.Second)}, nil, false)
+	h.record(v3.ClusterType, "2", req, model.Resources{testCluster("a", 2*time.Second), testCluster("c", time.Second)}, nil, false)
+	// Incremental pushes are applied to the previous config.
+	h.record(v3.ClusterType, "3", nil, model.Resources{testCluster("d", time.Second)}, []string{"c"}, true)
+	h.record(v3.ClusterType, "4", nil, model.Resources{testCluster("a", 2*time.Second)}, nil, true)
+	h.record(v3.ListenerType, "1", nil, nil, nil, false)
+	h.record(v3.DebugType+"/syncz", "1", nil, nil, nil, false)
+
+	got, err := h.history("proxy", "cds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first push is no longer retained.
+	want := ConfigHistory{ProxyID: "proxy", Types: map[string][]ConfigDiff{"CDS": {
+		{
+			FromVersion: "2",
+			ToVersion:   "3",
+			Added:       []string{"d"},
+			Removed:     []string{"c"},
+		},
+		{
+			FromVersion: "3",
+			ToVersion:   "4",
+			Unchanged:   true,
+		},
+	}}}
+	for _, diffs := range got.Types {
+		for i := range diffs {
+			diffs[i].Time = time.Time{}
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	got, err = h.history("proxy", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Types) != 2 || len(got.Types["LDS"]) != 0 {
+		t.Fatalf("expected the history of CDS and LDS, got %+v", got)
+	}
+}
+
+func TestConfigHistoryModified(t *testing.T) {
+	h := newConfigHistory(2)
+	h.record(v3.ClusterType, "1", nil, model.Resources{testCluster("a", time.Second)}, nil, false)
+	h.record(v3.ClusterType, "2", &model.PushRequest{Reason: []model.TriggerReason{model.EndpointUpdate}},
+		model.Resources{testCluster("a", 2*time.Second)}, nil, false)
+	got, err := h.history("proxy", v3.ClusterType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := got.Types["CDS"][0]
+	want := []ResourceChanges{{Name: "a", Changes: []FieldChange{{Path: "connectTimeout", From: "1s", To: "2s"}}}}
+	if !reflect.DeepEqual(diff.Modified, want) || !reflect.DeepEqual(diff.Reasons, []string{"endpoint"}) {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	from := map[string]interface{}{
+		"name":    "a",
+		"timeout": "1s",
+		"hosts":   []interface{}{"a", "b"},
+		"filters": []interface{}{map[string]interface{}{"name": "x"}},
+		"removed": true,
+	}
+	to := map[string]interface{}{
+		"name":    "a",
+		"timeout": "2s",
+		"hosts":   []interface{}{"a"},
+		"filters": []interface{}{map[string]interface{}{"name": "y"}},
+		"added":   1.0,
+	}
+	got := diffJSON("", from, to, nil)
+	want := []FieldChange{
+		{Path: "added", To: 1.0},
+		{Path: "filters[0].name", From: "x", To: "y"},
+		{Path: "hosts", From: []interface{}{"a", "b"}, To: []interface{}{"a"}},
+		{Path: "removed", From: true},
+		{Path: "timeout", From: "1s", To: "2s"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestConfigHistoryz(t *testing.T) {
+	original := features.ConfigHistorySize
+	features.ConfigHistorySize = 5
+	t.Cleanup(func() {
+		features.ConfigHistorySize = original
+	})
+	s := NewFakeDiscoveryServer(t, FakeOptions{})
+	ads := s.ConnectADS().WithType(v3.ClusterType)
+	ads.RequestResponseAck(t, nil)
+
+	se := config.Config{
+		Meta: config.Meta{GroupVersionKind: gvk.ServiceEntry, Name: "se", Namespace: "default"},
+		Spec: &networking.ServiceEntry{
+			Hosts:      []string{"example.com"},
+			Ports:      []*networking.Port{{Number: 80, Name: "http", Protocol: "HTTP"}},
+			Resolution: networking.ServiceEntry_DNS,
+		},
+	}
+	if _, err := s.Store().Create(se); err != nil {
+		t.Fatal(err)
+	}
+	s.Discovery.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.ConfigUpdate}})
+	res := ads.ExpectResponse(t)
+	ads.Request(t, &discovery.DiscoveryRequest{ResponseNonce: res.Nonce})
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/config_history?proxyID=test.default&type=CDS", nil)
+	rr := httptest.NewRecorder()
+	s.Discovery.configHistoryz(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %s", rr.Code, rr.Body.String())
+	}
+	got := ConfigHistory{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	diffs := got.Types["CDS"]
+	if len(diffs) != 1 || !reflect.DeepEqual(diffs[0].Added, []string{"outbound|80||example.com"}) {
+		t.Fatalf("unexpected history %+v", got)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"net"
 	"net/http"
 	"net/http/pprof"
@@ -27,6 +28,7 @@ import (
 
 	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -43,7 +45,9 @@ import (
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/validation"
 	"istio.io/istio/pkg/network"
 	"istio.io/istio/pkg/util/protomarshal"
 	istiolog "istio.io/pkg/log"
@@ -97,7 +101,9 @@ type AdsClient struct {
 	ConnectedAt  time.Time           `json:"connectedAt"`
 	PeerAddress  string              `json:"address"`
 	Metadata     *model.NodeMetadata `json:"metadata,omitempty"`
-	Watches      map[string][]string `json:"watches,omitempty"`
+	// FeatureGates are the feature gates set for the proxy, the others use their default.
+	FeatureGates map[model.FeatureGate]bool `json:"featureGates,omitempty"`
+	Watches      map[string][]string        `json:"watches,omitempty"`
 }
 
 // AdsClients is collection of AdsClient connected to this Istiod.
@@ -120,6 +126,39 @@ type SyncStatus struct {
 	RouteAcked    string `json:"route_acked,omitempty"`
 	EndpointSent  string `json:"endpoint_sent,omitempty"`
 	EndpointAcked string `json:"endpoint_acked,omitempty"`
+	// Nacked lists the types whose last push was rejected by the proxy.
+	Nacked []string `json:"nacked,omitempty"`
+	// AckLatency is the longest time the proxy took to ack the last accepted push of a type.
+	AckLatency time.Duration `json:"ack_latency,omitempty"`
+}
+
+// ConfigSize is the size of the last response of a type sent to a proxy.
+type ConfigSize struct {
+	Bytes     int `json:"bytes"`
+	Resources int `json:"resources"`
+}
+
+// ProxyConfigSizes is the size of the configuration last sent to a proxy, by type.
+type ProxyConfigSizes struct {
+	ProxyID string                `json:"proxy"`
+	Total   int                   `json:"total"`
+	Types   map[string]ConfigSize `json:"types"`
+}
+
+// ConfigSizePercentiles summarizes the size in bytes of the configuration of a type sent to the connected proxies.
+type ConfigSizePercentiles struct {
+	P50 int `json:"p50"`
+	P90 int `json:"p90"`
+	P99 int `json:"p99"`
+	Max int `json:"max"`
+}
+
+// ConfigSizez is the size of the configuration sent to the connected proxies.
+type ConfigSizez struct {
+	// Percentiles are keyed by the short type name, such as CDS, and include a "total" entry.
+	Percentiles map[string]ConfigSizePercentiles `json:"percentiles"`
+	// Proxies are sorted from the largest to the smallest total configuration size.
+	Proxies []ProxyConfigSizes `json:"proxies"`
 }
 
 // SyncedVersions shows what resourceVersion of a given resource has been acked by Envoy.
@@ -180,6 +219,9 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/adsz?push=true", "Initiates push of the current state to all connected endpoints", s.adsz)
 
 	s.addDebugHandler(mux, internalMux, "/debug/syncz", "Synchronization status of all Envoys connected to this Pilot instance", s.Syncz)
+	s.addDebugHandler(mux, internalMux, "/debug/config_sizez", "Size of the configuration sent to the connected proxies, by type", s.configSizez)
+	s.addDebugHandler(mux, internalMux, "/debug/config_history",
+		"Changes between the consecutive pushes to the passed in proxyID, if PILOT_CONFIG_HISTORY_SIZE is set", s.configHistoryz)
 	s.addDebugHandler(mux, internalMux, "/debug/config_distribution", "Version status of all Envoys connected to this Pilot instance", s.distributedVersions)
 
 	s.addDebugHandler(mux, internalMux, "/debug/registryz", "Debug support for registry", s.registryz)
@@ -187,6 +229,7 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/endpointShardz", "Info about the endpoint shards", s.endpointShardz)
 	s.addDebugHandler(mux, internalMux, "/debug/cachez", "Info about the internal XDS caches", s.cachez)
 	s.addDebugHandler(mux, internalMux, "/debug/cachez?sizes=true", "Info about the size of the internal XDS caches", s.cachez)
+	s.addDebugHandler(mux, internalMux, "/debug/cachez?routes=true", "Keys and sizes of the cached routes, by route name", s.cachez)
 	s.addDebugHandler(mux, internalMux, "/debug/cachez?clear=true", "Clear the XDS caches", s.cachez)
 	s.addDebugHandler(mux, internalMux, "/debug/configz", "Debug support for config", s.configz)
 	s.addDebugHandler(mux, internalMux, "/debug/sidecarz", "Debug sidecar scope for a proxy", s.sidecarz)
@@ -196,15 +239,24 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/authorizationz", "Internal authorization policies", s.authorizationz)
 	s.addDebugHandler(mux, internalMux, "/debug/telemetryz", "Debug Telemetry configuration", s.telemetryz)
 	s.addDebugHandler(mux, internalMux, "/debug/config_dump", "ConfigDump in the form of the Envoy admin config dump API for passed in proxyID", s.ConfigDump)
+	s.addDebugHandler(mux, internalMux, "/debug/rds_dryrun",
+		"Generates the passed in route for proxyID, or for the xDS node passed in the body, and explains its virtual hosts", s.RdsDryRun)
+	s.addDebugHandler(mux, internalMux, "/debug/envoyfilter_dryrun",
+		"Applies the EnvoyFilter passed in the body or the envoyfilter param to the config of proxyID, and diffs it", s.EnvoyFilterDryRun)
+	s.addDebugHandler(mux, internalMux, "/debug/config_gen_profile",
+		"Times the configuration generation of the passed in proxyID, by xDS type and builder", s.ConfigGenProfile)
 	s.addDebugHandler(mux, internalMux, "/debug/push_status", "Last PushContext Details", s.pushStatusHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/pushcontext", "Debug support for current push context", s.pushContextHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/connections", "Info about the connected XDS clients", s.connectionsHandler)
 
 	s.addDebugHandler(mux, internalMux, "/debug/inject", "Active inject template", s.injectTemplateHandler(webhook))
 	s.addDebugHandler(mux, internalMux, "/debug/mesh", "Active mesh config", s.meshHandler)
+	s.addDebugHandler(mux, internalMux, "/debug/snapshotz",
+		"Archive of the services, instances and configs the config is generated from, to reproduce it offline", s.snapshotz)
 	s.addDebugHandler(mux, internalMux, "/debug/clusterz", "List remote clusters where istiod reads endpoints", s.clusterz)
 	s.addDebugHandler(mux, internalMux, "/debug/networkz", "List cross-network gateways", s.networkz)
 	s.addDebugHandler(mux, internalMux, "/debug/mcsz", "List information about Kubernetes MCS services", s.mcsz)
+	s.addDebugHandler(mux, internalMux, "/debug/deprecationz", "Uses of deprecated features by configs and connected proxies", s.deprecationz)
 
 	s.addDebugHandler(mux, internalMux, "/debug/list", "List all supported debug commands in json", s.List)
 }
@@ -279,12 +331,110 @@ func (s *DiscoveryServer) Syncz(w http.ResponseWriter, _ *http.Request) {
 				RouteAcked:    con.NonceAcked(v3.RouteType),
 				EndpointSent:  con.NonceSent(v3.EndpointType),
 				EndpointAcked: con.NonceAcked(v3.EndpointType),
+				Nacked:        con.nackedTypes(),
+				AckLatency:    con.ackLatency(),
 			})
 		}
 	}
 	writeJSON(w, syncz)
 }
 
+var synczTypes = []string{v3.ClusterType, v3.ListenerType, v3.RouteType, v3.EndpointType}
+
+// nackedTypes returns the short names of the types whose last push was rejected by the proxy.
+func (conn *Connection) nackedTypes() []string {
+	conn.proxy.RLock()
+	defer conn.proxy.RUnlock()
+	var out []string
+	for _, typeURL := range synczTypes {
+		if w := conn.proxy.WatchedResources[typeURL]; w != nil && w.NonceNacked != "" {
+			out = append(out, v3.GetShortType(typeURL))
+		}
+	}
+	return out
+}
+
+// ackLatency returns the longest time the proxy took to ack the last accepted push of a type.
+func (conn *Connection) ackLatency() time.Duration {
+	conn.proxy.RLock()
+	defer conn.proxy.RUnlock()
+	var out time.Duration
+	for _, typeURL := range synczTypes {
+		if w := conn.proxy.WatchedResources[typeURL]; w != nil && w.LastAckLatency > out {
+			out = w.LastAckLatency
+		}
+	}
+	return out
+}
+
+// configSizes returns the size of the last responses of each type sent to the proxy.
+func (conn *Connection) configSizes() ProxyConfigSizes {
+	conn.proxy.RLock()
+	defer conn.proxy.RUnlock()
+	out := ProxyConfigSizes{ProxyID: conn.proxy.ID, Types: map[string]ConfigSize{}}
+	for _, typeURL := range synczTypes {
+		if w := conn.proxy.WatchedResources[typeURL]; w != nil && w.NonceSent != "" {
+			out.Types[v3.GetShortType(typeURL)] = ConfigSize{Bytes: w.LastSize, Resources: w.LastResourceCount}
+			out.Total += w.LastSize
+		}
+	}
+	return out
+}
+
+// configSizez reports the size of the configuration sent to the connected proxies, or to the proxy passed in proxyID,
+// along with percentiles of the sizes across proxies by type.
+func (s *DiscoveryServer) configSizez(w http.ResponseWriter, req *http.Request) {
+	proxyID, con := s.getDebugConnection(req)
+	if proxyID != "" && con == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("Proxy not connected to this Pilot instance. It may be connected to another instance.\n"))
+		return
+	}
+	connections := s.Clients()
+	if con != nil {
+		connections = []*Connection{con}
+	}
+
+	out := ConfigSizez{Percentiles: map[string]ConfigSizePercentiles{}, Proxies: make([]ProxyConfigSizes, 0, len(connections))}
+	sizes := map[string][]int{}
+	for _, c := range connections {
+		proxySizes := c.configSizes()
+		out.Proxies = append(out.Proxies, proxySizes)
+		for t, size := range proxySizes.Types {
+			sizes[t] = append(sizes[t], size.Bytes)
+		}
+		sizes["total"] = append(sizes["total"], proxySizes.Total)
+	}
+	for t, values := range sizes {
+		out.Percentiles[t] = configSizePercentiles(values)
+	}
+	sort.Slice(out.Proxies, func(i, j int) bool {
+		if out.Proxies[i].Total != out.Proxies[j].Total {
+			return out.Proxies[i].Total > out.Proxies[j].Total
+		}
+		return out.Proxies[i].ProxyID < out.Proxies[j].ProxyID
+	})
+	writeJSON(w, out)
+}
+
+// configSizePercentiles returns the nearest-rank percentiles of the sizes.
+func configSizePercentiles(sizes []int) ConfigSizePercentiles {
+	sort.Ints(sizes)
+	percentile := func(p int) int {
+		rank := (p*len(sizes) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return sizes[rank-1]
+	}
+	return ConfigSizePercentiles{
+		P50: percentile(50),
+		P90: percentile(90),
+		P99: percentile(99),
+		Max: sizes[len(sizes)-1],
+	}
+}
+
 // registryz providees debug support for registry - adding and listing model items.
 // Can be combined with the push debug interface to reproduce changes.
 func (s *DiscoveryServer) registryz(w http.ResponseWriter, req *http.Request) {
@@ -331,6 +481,10 @@ func (s *DiscoveryServer) cachez(w http.ResponseWriter, req *http.Request) {
 		writeJSON(w, res)
 		return
 	}
+	if req.Form.Get("routes") != "" {
+		writeJSON(w, routeCacheEntries(s.Cache.Snapshot()))
+		return
+	}
 	snapshot := s.Cache.Snapshot()
 	resources := make(map[string][]string, len(snapshot)) // Key is typeUrl and value is resource names.
 	for key, resource := range snapshot {
@@ -343,6 +497,33 @@ func (s *DiscoveryServer) cachez(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, resources)
 }
 
+// RouteCacheEntry is a cached route configuration.
+type RouteCacheEntry struct {
+	Key  string `json:"key"`
+	Size string `json:"size"`
+}
+
+// routeCacheEntries returns the cached route configurations, by route name.
+func routeCacheEntries(snapshot map[string]*discovery.Resource) map[string][]RouteCacheEntry {
+	keys := make([]string, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	res := map[string][]RouteCacheEntry{}
+	for _, key := range keys {
+		resource := snapshot[key]
+		if resource == nil || resource.Resource.GetTypeUrl() != v3.RouteType {
+			continue
+		}
+		res[resource.Name] = append(res[resource.Name], RouteCacheEntry{
+			Key:  key,
+			Size: util.ByteCount(len(resource.Resource.GetValue())),
+		})
+	}
+	return res
+}
+
 type endpointzResponse struct {
 	Service   string                   `json:"svc"`
 	Endpoints []*model.ServiceInstance `json:"ep"`
@@ -564,6 +745,7 @@ func (s *DiscoveryServer) adsz(w http.ResponseWriter, req *http.Request) {
 			Watches:      map[string][]string{},
 		}
 		c.proxy.RLock()
+		adsClient.FeatureGates = c.proxy.FeatureGates
 		for k, wr := range c.proxy.WatchedResources {
 			r := wr.ResourceNames
 			if r == nil {
@@ -751,6 +933,152 @@ func (s *DiscoveryServer) configDump(conn *Connection) (*adminapi.ConfigDump, er
 	return configDump, nil
 }
 
+// RdsDryRun holds a generated route configuration and the explanation of its virtual hosts.
+type RdsDryRun struct {
+	RouteConfiguration jsonMarshalProto         `json:"route_configuration"`
+	VirtualHosts       []VirtualHostExplanation `json:"virtual_hosts"`
+}
+
+// VirtualHostExplanation describes the configs a virtual host was generated from.
+type VirtualHostExplanation struct {
+	Name    string   `json:"name"`
+	Domains []string `json:"domains"`
+	// Service is the namespace/hostname of the service the domains were generated for, if any.
+	Service string             `json:"service,omitempty"`
+	Routes  []RouteExplanation `json:"routes"`
+}
+
+// RouteExplanation describes the config a route was generated from.
+type RouteExplanation struct {
+	Name string `json:"name,omitempty"`
+	// Config is the VirtualService the route was generated from. Routes without a config are the default routes of
+	// the service, or routes generated for the outbound traffic policy.
+	Config      string   `json:"config,omitempty"`
+	Destination []string `json:"destination,omitempty"`
+}
+
+// RdsDryRun generates the route configuration named by the route query param for the specified proxy, as it
+// would be sent over RDS, and explains which configs produced each virtual host and route. Instead of a connected
+// proxy, the xDS node of a hypothetical proxy can be posted in JSON, to troubleshoot a proxy that is not running.
+func (s *DiscoveryServer) RdsDryRun(w http.ResponseWriter, req *http.Request) {
+	var proxy *model.Proxy
+	proxyID := req.URL.Query().Get("proxyID")
+	if req.Method == http.MethodPost {
+		var err error
+		if proxy, err = s.dryRunProxy(req.Body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error() + "\n"))
+			return
+		}
+		proxyID = proxy.ID
+	} else {
+		var con *Connection
+		if proxyID, con = s.getDebugConnection(req); con == nil {
+			s.errorHandler(w, proxyID, con)
+			return
+		}
+		proxy = con.proxy
+	}
+	routeName := req.URL.Query().Get("route")
+	if routeName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("You must provide a route in the query string\n"))
+		return
+	}
+	push := s.globalPushContext()
+	routes, _ := s.ConfigGenerator.BuildHTTPRoutes(proxy, &model.PushRequest{Push: push, Start: time.Now()}, []string{routeName})
+	if len(routes) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(fmt.Sprintf("route %s not found, proxyID: %s\n", routeName, proxyID)))
+		return
+	}
+	rc := &route.RouteConfiguration{}
+	if err := routes[0].Resource.UnmarshalTo(rc); err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	writeJSON(w, RdsDryRun{
+		RouteConfiguration: jsonMarshalProto{rc},
+		VirtualHosts:       explainVirtualHosts(proxy, push, rc),
+	})
+}
+
+// maxDryRunNodeSize bounds the size of the xDS node passed to the RDS dry run.
+const maxDryRunNodeSize = 1 << 20
+
+// dryRunProxy initializes a proxy from an xDS node in JSON, as if it had connected. Its workload is not registered
+// and it is not tracked as a connection, so nothing is pushed to it.
+func (s *DiscoveryServer) dryRunProxy(body io.Reader) (*model.Proxy, error) {
+	b, err := io.ReadAll(io.LimitReader(body, maxDryRunNodeSize))
+	if err != nil {
+		return nil, err
+	}
+	node := &core.Node{}
+	if err := protomarshal.Unmarshal(b, node); err != nil {
+		return nil, fmt.Errorf("failed to parse the xDS node: %v", err)
+	}
+	proxy, err := s.initProxyMetadata(node)
+	if err != nil {
+		return nil, err
+	}
+	s.computeProxyState(proxy, nil)
+	if len(proxy.ServiceInstances) > 0 {
+		proxy.Locality = util.ConvertLocality(proxy.ServiceInstances[0].Endpoint.Locality.Label)
+	} else {
+		proxy.Locality = &core.Locality{
+			Region:  node.Locality.GetRegion(),
+			Zone:    node.Locality.GetZone(),
+			SubZone: node.Locality.GetSubZone(),
+		}
+	}
+	proxy.DiscoverIPVersions()
+	return proxy, nil
+}
+
+func explainVirtualHosts(proxy *model.Proxy, push *model.PushContext, rc *route.RouteConfiguration) []VirtualHostExplanation {
+	out := make([]VirtualHostExplanation, 0, len(rc.VirtualHosts))
+	for _, vh := range rc.VirtualHosts {
+		explanation := VirtualHostExplanation{
+			Name:    vh.Name,
+			Domains: vh.Domains,
+			Routes:  make([]RouteExplanation, 0, len(vh.Routes)),
+		}
+		if hostname, _, err := net.SplitHostPort(vh.Name); err == nil {
+			if svc := push.ServiceForHostname(proxy, host.Name(hostname)); svc != nil {
+				explanation.Service = svc.Attributes.Namespace + "/" + string(svc.Hostname)
+			}
+		}
+		for _, r := range vh.Routes {
+			explanation.Routes = append(explanation.Routes, RouteExplanation{
+				Name:        r.Name,
+				Config:      r.GetMetadata().GetFilterMetadata()[util.IstioMetadataKey].GetFields()["config"].GetStringValue(),
+				Destination: routeDestination(r),
+			})
+		}
+		out = append(out, explanation)
+	}
+	return out
+}
+
+// routeDestination returns the clusters a route forwards to, or a description of its redirect or direct response.
+func routeDestination(r *route.Route) []string {
+	switch {
+	case r.GetRoute().GetCluster() != "":
+		return []string{r.GetRoute().GetCluster()}
+	case r.GetRoute().GetWeightedClusters() != nil:
+		var clusters []string
+		for _, c := range r.GetRoute().GetWeightedClusters().Clusters {
+			clusters = append(clusters, fmt.Sprintf("%s (weight %d)", c.Name, c.GetWeight().GetValue()))
+		}
+		return clusters
+	case r.GetRedirect() != nil:
+		return []string{"redirect"}
+	case r.GetDirectResponse() != nil:
+		return []string{fmt.Sprintf("direct response %d", r.GetDirectResponse().Status)}
+	}
+	return nil
+}
+
 // injectTemplateHandler dumps the injection template
 // Replaces dumping the template at startup.
 func (s *DiscoveryServer) injectTemplateHandler(webhook func() map[string]string) func(http.ResponseWriter, *http.Request) {
@@ -952,6 +1280,24 @@ func sortMCSServices(svcs []model.MCSServiceInfo) []model.MCSServiceInfo {
 	return svcs
 }
 
+// deprecationz lists the uses of deprecated features by the configs, and by the pod annotations of the proxies
+// connected to this instance.
+func (s *DiscoveryServer) deprecationz(w http.ResponseWriter, _ *http.Request) {
+	usages := append([]model.DeprecatedUsage{}, s.globalPushContext().Deprecations()...)
+	for _, con := range s.Clients() {
+		proxy := con.proxy
+		for _, d := range validation.FindAnnotationDeprecations(proxy.Metadata.Annotations) {
+			usages = append(usages, model.DeprecatedUsage{
+				Kind:        "Pod",
+				Namespace:   proxy.ConfigNamespace,
+				Name:        strings.TrimSuffix(proxy.ID, "."+proxy.ConfigNamespace),
+				Deprecation: d,
+			})
+		}
+	}
+	writeJSON(w, usages)
+}
+
 func (s *DiscoveryServer) clusterz(w http.ResponseWriter, _ *http.Request) {
 	if s.ListRemoteClusters == nil {
 		w.WriteHeader(400)
@@ -1028,3 +1374,87 @@ func handleHTTPError(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusInternalServerError)
 	_, _ = w.Write([]byte(err.Error()))
 }
+
+// ProxyConfigGenProfile is the time spent generating the configuration of a proxy, by xDS type and by builder.
+type ProxyConfigGenProfile struct {
+	ProxyID string           `json:"proxy"`
+	Total   string           `json:"total"`
+	Types   []TypeGenProfile `json:"types"`
+}
+
+// TypeGenProfile is the time spent generating the resources of one xDS type.
+type TypeGenProfile struct {
+	Type      string `json:"type"`
+	Duration  string `json:"duration"`
+	Resources int    `json:"resources"`
+	// Details are the generator log details, such as the cache hits.
+	Details string `json:"details,omitempty"`
+	// Builders are sorted from the slowest to the fastest.
+	Builders []BuilderGenProfile `json:"builders,omitempty"`
+}
+
+// BuilderGenProfile is the time spent by a configuration builder, over all its calls.
+type BuilderGenProfile struct {
+	Builder  string `json:"builder"`
+	Duration string `json:"duration"`
+	Calls    int    `json:"calls"`
+}
+
+// ConfigGenProfile generates the configuration of the passed in proxyID, without pushing it, and reports the time
+// spent by type and by builder.
+func (s *DiscoveryServer) ConfigGenProfile(w http.ResponseWriter, req *http.Request) {
+	proxyID, con := s.getDebugConnection(req)
+	if con == nil {
+		s.errorHandler(w, proxyID, con)
+		return
+	}
+	push := s.globalPushContext()
+	out := ProxyConfigGenProfile{ProxyID: proxyID}
+	start := time.Now()
+	generate := func(typeURL string, gen func(*model.PushRequest) (model.Resources, model.XdsLogDetails)) {
+		profile := model.NewGenerationProfile()
+		typeStart := time.Now()
+		resources, logDetails := gen(&model.PushRequest{Push: push, Start: typeStart, Full: true, Profile: profile})
+		tp := TypeGenProfile{
+			Type:      v3.GetShortType(typeURL),
+			Duration:  time.Since(typeStart).String(),
+			Resources: len(resources),
+			Details:   logDetails.AdditionalInfo,
+		}
+		builders := profile.Builders()
+		sort.SliceStable(builders, func(i, j int) bool {
+			return builders[i].Duration > builders[j].Duration
+		})
+		for _, b := range builders {
+			tp.Builders = append(tp.Builders, BuilderGenProfile{Builder: b.Builder, Duration: b.Duration.String(), Calls: b.Calls})
+		}
+		out.Types = append(out.Types, tp)
+	}
+
+	generate(v3.ClusterType, func(r *model.PushRequest) (model.Resources, model.XdsLogDetails) {
+		return s.ConfigGenerator.BuildClusters(con.proxy, r)
+	})
+	// Endpoints are only generated for the clusters the proxy watches.
+	if gen, f := s.Generators[v3.EndpointType]; f && con.Watched(v3.EndpointType) != nil {
+		generate(v3.EndpointType, func(r *model.PushRequest) (model.Resources, model.XdsLogDetails) {
+			resources, logDetails, err := gen.Generate(con.proxy, push, con.Watched(v3.EndpointType), r)
+			if err != nil {
+				log.Warnf("config_gen_profile: failed to generate endpoints for %s: %v", proxyID, err)
+			}
+			return resources, logDetails
+		})
+	}
+	generate(v3.ListenerType, func(r *model.PushRequest) (model.Resources, model.XdsLogDetails) {
+		listeners := s.ConfigGenerator.ProfileListeners(con.proxy, push, r.Profile)
+		resources := make(model.Resources, 0, len(listeners))
+		for _, l := range listeners {
+			resources = append(resources, &discovery.Resource{Name: l.Name, Resource: util.MessageToAny(l)})
+		}
+		return resources, model.DefaultXdsLogDetails
+	})
+	generate(v3.RouteType, func(r *model.PushRequest) (model.Resources, model.XdsLogDetails) {
+		return s.ConfigGenerator.BuildHTTPRoutes(con.proxy, r, con.Routes())
+	})
+	out.Total = time.Since(start).String()
+	writeJSON(w, out)
+}
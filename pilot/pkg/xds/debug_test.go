@@ -17,8 +17,11 @@ package xds_test
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -123,6 +126,12 @@ func verifySyncStatus(t *testing.T, s *xds.DiscoveryServer, nodeID string, wantS
 				if (ss.EndpointAcked != "") != wantAcked {
 					errorHandler("wanted EndpointAcked set %v got %v for %v", wantAcked, ss.EndpointAcked, nodeID)
 				}
+				if wantSent && (len(ss.Nacked) == 0) != wantAcked {
+					errorHandler("wanted Nacked set %v got %v for %v", !wantAcked, ss.Nacked, nodeID)
+				}
+				if wantAcked && ss.AckLatency <= 0 {
+					errorHandler("wanted AckLatency set got %v for %v", ss.AckLatency, nodeID)
+				}
 				return
 			}
 		}
@@ -213,3 +222,319 @@ func TestDebugHandlers(t *testing.T) {
 		t.Errorf("Error in generatating debug endpoint list")
 	}
 }
+
+func TestConfigGenProfile(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`})
+	ads := s.ConnectADS()
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.RouteType, ResourceNames: []string{"80"}})
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+	}{
+		{name: "profiles proxy", query: "?proxyID=test.default", wantCode: 200},
+		{name: "no proxyID", query: "", wantCode: 400},
+		{name: "unknown proxy", query: "?proxyID=missing.default", wantCode: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/debug/config_gen_profile"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(s.Discovery.ConfigGenProfile).ServeHTTP(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Fatalf("wanted response code %v, got %v: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode != 200 {
+				return
+			}
+			got := xds.ProxyConfigGenProfile{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			builders := map[string][]string{}
+			for _, tp := range got.Types {
+				for _, b := range tp.Builders {
+					builders[tp.Type] = append(builders[tp.Type], b.Builder)
+				}
+			}
+			for typ, builder := range map[string]string{"CDS": "outbound_clusters", "LDS": "outbound_listeners", "RDS": "virtual_hosts"} {
+				found := false
+				for _, b := range builders[typ] {
+					found = found || b == builder
+				}
+				if !found {
+					t.Errorf("expected %s builder %s, got %v", typ, builder, builders[typ])
+				}
+			}
+		})
+	}
+}
+
+func TestRdsDryRun(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: vs
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  http:
+  - name: primary
+    route:
+    - destination:
+        host: example.com
+`})
+	ads := s.ConnectADS()
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+
+	tests := []struct {
+		name     string
+		query    string
+		node     string
+		wantCode int
+	}{
+		{name: "generates route", query: "?proxyID=test.default&route=80", wantCode: 200},
+		{name: "no route", query: "?proxyID=test.default", wantCode: 400},
+		{name: "no proxyID", query: "?route=80", wantCode: 400},
+		{
+			name:     "hypothetical proxy",
+			query:    "?route=80",
+			node:     `{"id": "sidecar~1.1.1.9~offline.default~default.svc.cluster.local", "metadata": {"NAMESPACE": "default"}}`,
+			wantCode: 200,
+		},
+		{name: "invalid node", query: "?route=80", node: `{"id": 1}`, wantCode: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, body := "GET", io.Reader(nil)
+			if tt.node != "" {
+				method, body = "POST", strings.NewReader(tt.node)
+			}
+			req, err := http.NewRequest(method, "/debug/rds_dryrun"+tt.query, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(s.Discovery.RdsDryRun).ServeHTTP(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Fatalf("wanted response code %v, got %v: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode != 200 {
+				return
+			}
+			got := struct {
+				VirtualHosts []xds.VirtualHostExplanation `json:"virtual_hosts"`
+			}{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			for _, vh := range got.VirtualHosts {
+				if vh.Name != "example.com:80" {
+					continue
+				}
+				if vh.Service != "default/example.com" || len(vh.Routes) != 1 ||
+					vh.Routes[0].Config != "/apis/networking.istio.io/v1alpha3/namespaces/default/virtual-service/vs" {
+					t.Fatalf("unexpected explanation %+v", vh)
+				}
+				return
+			}
+			t.Fatalf("virtual host example.com:80 not found in %+v", got.VirtualHosts)
+		})
+	}
+}
+
+func TestEnvoyFilterDryRun(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`})
+	ads := s.ConnectADS()
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.RouteType, ResourceNames: []string{"80"}})
+
+	envoyFilter := `
+apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: timeout
+spec:
+  configPatches:
+  - applyTo: CLUSTER
+    match:
+      cluster:
+        service: example.com
+    patch:
+      operation: MERGE
+      value:
+        connect_timeout: 7s
+`
+	tests := []struct {
+		name     string
+		method   string
+		query    string
+		body     string
+		wantCode int
+	}{
+		{name: "post", method: "POST", query: "?proxyID=test.default", body: envoyFilter, wantCode: 200},
+		{name: "query", method: "GET", query: "?proxyID=test.default&envoyfilter=" + url.QueryEscape(envoyFilter), wantCode: 200},
+		{name: "no envoy filter", method: "GET", query: "?proxyID=test.default", wantCode: 400},
+		{name: "not an envoy filter", method: "POST", query: "?proxyID=test.default", body: "kind: Foo", wantCode: 400},
+		{name: "no proxyID", method: "POST", body: envoyFilter, wantCode: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "/debug/envoyfilter_dryrun"+tt.query, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(s.Discovery.EnvoyFilterDryRun).ServeHTTP(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Fatalf("wanted response code %v, got %v: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode != 200 {
+				return
+			}
+			got := xds.EnvoyFilterDryRun{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.EnvoyFilter != "default/timeout" || len(got.Listeners) != 0 || len(got.Routes) != 0 {
+				t.Fatalf("unexpected dry run %+v", got)
+			}
+			if len(got.Clusters) != 1 || got.Clusters[0].Name != "outbound|80||example.com" ||
+				got.Clusters[0].Change != xds.ResourceModified || !strings.Contains(got.Clusters[0].Diff, `+  "connectTimeout": "7s"`) {
+				t.Fatalf("unexpected clusters %+v", got.Clusters)
+			}
+		})
+	}
+
+	// The EnvoyFilter is not pushed.
+	if s.PushContext().HasEnvoyFilters("timeout", "default") {
+		t.Fatal("the EnvoyFilter must not be added to the push context")
+	}
+}
+
+func TestCachezRoutes(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`})
+	ads := s.ConnectADS()
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.RouteType, ResourceNames: []string{"80"}})
+
+	mux, internalMux := http.NewServeMux(), http.NewServeMux()
+	s.Discovery.AddDebugHandlers(mux, internalMux, false, nil)
+	req, err := http.NewRequest("GET", "/debug/cachez?routes=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	internalMux.ServeHTTP(rr, req)
+	if rr.Code != 200 {
+		t.Fatalf("wanted response code 200, got %v: %s", rr.Code, rr.Body.String())
+	}
+	got := map[string][]xds.RouteCacheEntry{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || len(got["80"]) != 1 || got["80"][0].Key == "" || got["80"][0].Size == "" {
+		t.Fatalf("expected a single cached route 80, got %v", got)
+	}
+}
+
+func TestConfigSizez(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
+	ads := s.ConnectADS()
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType})
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+
+	mux, internalMux := http.NewServeMux(), http.NewServeMux()
+	s.Discovery.AddDebugHandlers(mux, internalMux, false, nil)
+	req, err := http.NewRequest("GET", "/debug/config_sizez", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	internalMux.ServeHTTP(rr, req)
+	if rr.Code != 200 {
+		t.Fatalf("wanted response code 200, got %v: %s", rr.Code, rr.Body.String())
+	}
+	got := xds.ConfigSizez{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Proxies) != 1 {
+		t.Fatalf("expected a single proxy, got %v", got.Proxies)
+	}
+	proxy := got.Proxies[0]
+	for _, typ := range []string{"CDS", "LDS"} {
+		if proxy.Types[typ].Bytes == 0 || proxy.Types[typ].Resources == 0 {
+			t.Errorf("expected a %s size, got %v", typ, proxy.Types)
+		}
+		if got.Percentiles[typ].Max != proxy.Types[typ].Bytes {
+			t.Errorf("expected %s percentiles for the proxy, got %v", typ, got.Percentiles[typ])
+		}
+	}
+	if proxy.Total != proxy.Types["CDS"].Bytes+proxy.Types["LDS"].Bytes || got.Percentiles["total"].P50 != proxy.Total {
+		t.Errorf("unexpected total size %d, percentiles %v", proxy.Total, got.Percentiles["total"])
+	}
+}
@@ -37,6 +37,7 @@ import (
 var deltaLog = istiolog.RegisterScope("delta", "delta xds debugging", 0)
 
 func (s *DiscoveryServer) StreamDeltas(stream DeltaDiscoveryStream) error {
+	setReconnectHint(stream)
 	if knativeEnv != "" && firstRequest.Load() {
 		// How scaling works in knative is the first request is the "loading" request. During
 		// loading request, concurrency=1. Once that request is done, concurrency is enabled.
@@ -56,6 +57,9 @@ func (s *DiscoveryServer) StreamDeltas(stream DeltaDiscoveryStream) error {
 	if !s.IsServerReady() {
 		return errors.New("server is not ready to serve discovery information")
 	}
+	if s.IsDraining() {
+		return errors.New("server is shutting down")
+	}
 
 	ctx := stream.Context()
 	peerAddr := "0.0.0.0"
@@ -335,6 +339,7 @@ func (s *DiscoveryServer) shouldRespondDelta(con *Connection, request *discovery
 		if s.StatusGen != nil {
 			s.StatusGen.OnNack(con.proxy, deltaToSotwRequest(request))
 		}
+		s.reportRejection(con, request.TypeUrl, request.ResponseNonce, request.ErrorDetail)
 		con.proxy.Lock()
 		if w, f := con.proxy.WatchedResources[request.TypeUrl]; f {
 			w.NonceNacked = request.ResponseNonce
@@ -385,8 +390,12 @@ func (s *DiscoveryServer) shouldRespondDelta(con *Connection, request *discovery
 	deltaResources := deltaWatchedResources(previousResources, request)
 	con.proxy.WatchedResources[request.TypeUrl].NonceAcked = request.ResponseNonce
 	con.proxy.WatchedResources[request.TypeUrl].NonceNacked = ""
+	if sent := con.proxy.WatchedResources[request.TypeUrl].LastSent; !sent.IsZero() {
+		con.proxy.WatchedResources[request.TypeUrl].LastAckLatency = time.Since(sent)
+	}
 	con.proxy.WatchedResources[request.TypeUrl].ResourceNames = deltaResources
 	con.proxy.Unlock()
+	s.reportRejection(con, request.TypeUrl, request.ResponseNonce, nil)
 
 	oldAck := listEqualUnordered(previousResources, deltaResources)
 	// Spontaneous DeltaDiscoveryRequests from the client.
@@ -440,12 +449,14 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, push *model.PushContext,
 	var logdata model.XdsLogDetails
 	var usedDelta bool
 	var err error
+	usage := startGeneratorUsage()
 	switch g := gen.(type) {
 	case model.XdsDeltaResourceGenerator:
 		res, deletedRes, logdata, usedDelta, err = g.GenerateDeltas(con.proxy, push, req, w)
 	case model.XdsResourceGenerator:
 		res, logdata, err = g.Generate(con.proxy, push, w, req)
 	}
+	recordGeneratorUsage(usage, w.TypeUrl, con.proxy)
 	if err != nil || (res == nil && deletedRes == nil) {
 		// If we have nothing to send, report that we got an ACK for this version.
 		if s.StatusReporter != nil {
@@ -476,9 +487,17 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, push *model.PushContext,
 	}
 	// normally wildcard xds `subscribe` is always nil, just in case there are some extended type not handled correctly.
 	if subscribe == nil && isWildcardTypeURL(w.TypeUrl) {
+		names := currentResources
+		if usedDelta {
+			// Deltas only contain the changed resources, the others are still known to the proxy.
+			known := sets.NewSet(w.ResourceNames...)
+			known.Delete(deletedRes...)
+			known.Insert(currentResources...)
+			names = known.SortedList()
+		}
 		// this is probably a bad idea...
 		con.proxy.Lock()
-		w.ResourceNames = currentResources
+		w.ResourceNames = names
 		con.proxy.Unlock()
 	}
 
@@ -501,6 +520,8 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, push *model.PushContext,
 		}
 		return err
 	}
+	// Delta responses only contain the changed resources.
+	con.configHistory.record(w.TypeUrl, resp.SystemVersionInfo, req, res, resp.RemovedResources, true)
 
 	switch {
 	case logdata.Incremental:
@@ -532,6 +553,8 @@ func newDeltaConnection(peerAddr string, stream DeltaDiscoveryStream) *Connectio
 		deltaReqChan:  make(chan *discovery.DeltaDiscoveryRequest, 1),
 		errorChan:     make(chan error, 1),
 		blockedPushes: map[string]*model.PushRequest{},
+		rejectedTypes: map[string]string{},
+		configHistory: newConfigHistory(features.ConfigHistorySize),
 	}
 }
 
@@ -20,7 +20,9 @@ import (
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
+	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/util/sets"
 	"istio.io/istio/pilot/pkg/xds"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pilot/test/xdstest"
@@ -135,3 +137,79 @@ func TestDeltaEDS(t *testing.T) {
 		t.Fatalf("received unexpected eds resource %v", resp.Resources)
 	}
 }
+
+func TestDeltaCDS(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts:
+  - a.example.com
+  - b.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: STATIC
+  location: MESH_INTERNAL
+  endpoints:
+  - address: 10.0.0.1
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: dr
+  namespace: default
+spec:
+  host: a.example.com
+  subsets:
+  - name: v1
+  - name: v2
+`})
+	ads := s.ConnectDeltaADS().WithType(v3.ClusterType)
+	ads.RequestResponseAck(nil)
+
+	updateSubsets := func(subsets ...string) {
+		t.Helper()
+		cfg := s.Store().Get(gvk.DestinationRule, "dr", "default").DeepCopy()
+		dr := cfg.Spec.(*networking.DestinationRule)
+		dr.Subsets = nil
+		for _, subset := range subsets {
+			dr.Subsets = append(dr.Subsets, &networking.Subset{Name: subset})
+		}
+		if _, err := s.Store().Update(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectDelta := func(resp *discovery.DeltaDiscoveryResponse, clusters, removed []string) {
+		t.Helper()
+		got := sets.NewSet()
+		for _, r := range resp.Resources {
+			got.Insert(r.Name)
+		}
+		for _, c := range clusters {
+			if !got.Contains(c) {
+				t.Fatalf("expected cluster %v, got %v", c, got.SortedList())
+			}
+		}
+		if got.Contains("outbound|80||b.example.com") {
+			t.Fatalf("expected only the clusters of a.example.com, got %v", got.SortedList())
+		}
+		if !reflect.DeepEqual(resp.RemovedResources, removed) {
+			t.Fatalf("expected removed clusters %v, got %v", removed, resp.RemovedResources)
+		}
+	}
+
+	// Only the clusters of the host of the destination rule are sent, and its removed subsets are removed.
+	updateSubsets("v1", "v3")
+	expectDelta(ads.ExpectResponse(), []string{"outbound|80||a.example.com", "outbound|80|v1|a.example.com",
+		"outbound|80|v3|a.example.com"}, []string{"outbound|80|v2|a.example.com"})
+
+	// The clusters not sent by the previous delta are still known.
+	updateSubsets()
+	expectDelta(ads.ExpectResponse(), []string{"outbound|80||a.example.com"},
+		[]string{"outbound|80|v1|a.example.com", "outbound|80|v3|a.example.com"})
+}
@@ -17,7 +17,9 @@ package xds
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -70,6 +72,15 @@ type debounceOptions struct {
 
 	// enableEDSDebounce indicates whether EDS pushes should be debounced.
 	enableEDSDebounce bool
+
+	// kindDebounce are the debounce windows of the kinds whose events are debounced separately from the others.
+	kindDebounce map[string]debounceWindow
+}
+
+// debounceWindow is the debounceAfter and debounceMax of the events of a kind.
+type debounceWindow struct {
+	after time.Duration
+	max   time.Duration
 }
 
 // DiscoveryServer is Pilot's gRPC implementation for Envoy's xds APIs
@@ -84,6 +95,10 @@ type DiscoveryServer struct {
 	// APIs and service registry info
 	ConfigGenerator core.ConfigGenerator
 
+	// uncachedConfigGenerator generates the configuration without the XDS cache, for dry runs which must neither read
+	// nor populate it.
+	uncachedConfigGenerator core.ConfigGenerator
+
 	// Generators allow customizing the generated config, based on the client metadata.
 	// Key is the generator type - will match the Generator metadata to set the per-connection
 	// default generator, or the combination of Generator metadata and TypeUrl to select a
@@ -141,9 +156,15 @@ type DiscoveryServer struct {
 	StatusGen               *StatusGen
 	WorkloadEntryController *workloadentry.Controller
 
+	// endpointDrains tracks the proxies that asked to drain their endpoints.
+	endpointDrains *endpointDrains
+
 	// serverReady indicates caches have been synced up and server is ready to process requests.
 	serverReady atomic.Bool
 
+	// draining indicates the server is shutting down and should not accept new XDS connections.
+	draining atomic.Bool
+
 	debounceOptions debounceOptions
 
 	instanceID string
@@ -195,13 +216,15 @@ func NewDiscoveryServer(env *model.Environment, plugins []string, instanceID str
 		InboundUpdates:          atomic.NewInt64(0),
 		CommittedUpdates:        atomic.NewInt64(0),
 		pushChannel:             make(chan *model.PushRequest, 10),
-		pushQueue:               NewPushQueue(),
+		pushQueue:               NewPriorityPushQueue(pushPriority(features.PushPriority)),
 		debugHandlers:           map[string]string{},
 		adsClients:              map[string]*Connection{},
+		endpointDrains:          newEndpointDrains(),
 		debounceOptions: debounceOptions{
 			debounceAfter:     features.DebounceAfter,
 			debounceMax:       features.DebounceMax,
 			enableEDSDebounce: features.EnableEDSDebounce,
+			kindDebounce:      parseKindDebounce(features.DebounceByKind, features.DebounceMax),
 		},
 		Cache:      model.DisabledCache{},
 		instanceID: instanceID,
@@ -219,6 +242,7 @@ func NewDiscoveryServer(env *model.Environment, plugins []string, instanceID str
 	}
 
 	out.ConfigGenerator = core.NewConfigGenerator(plugins, out.Cache)
+	out.uncachedConfigGenerator = core.NewConfigGenerator(plugins, model.DisabledCache{})
 
 	return out
 }
@@ -264,6 +288,35 @@ func (s *DiscoveryServer) IsServerReady() bool {
 	return s.serverReady.Load()
 }
 
+// IsDraining returns true once Drain has been called.
+func (s *DiscoveryServer) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// Drain stops accepting new XDS connections and closes the existing ones, spreading the
+// disconnects evenly, in random order, over the given period. This avoids all proxies
+// reconnecting to the remaining istiod instances at the same time during rollouts.
+// Drain blocks until every connection has been closed or the period has elapsed.
+func (s *DiscoveryServer) Drain(period time.Duration) {
+	s.draining.Store(true)
+	clients := s.AllClients()
+	if period <= 0 || len(clients) == 0 {
+		return
+	}
+	log.Infof("draining %d XDS connections over %v", len(clients), period)
+	rand.Shuffle(len(clients), func(i, j int) {
+		clients[i], clients[j] = clients[j], clients[i]
+	})
+	interval := period / time.Duration(len(clients))
+	for i, con := range clients {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		log.Debugf("draining XDS connection %s", con.ConID)
+		con.Stop()
+	}
+}
+
 func (s *DiscoveryServer) Start(stopCh <-chan struct{}) {
 	go s.WorkloadEntryController.Run(stopCh)
 	go s.handleUpdates(stopCh)
@@ -402,14 +455,19 @@ func (s *DiscoveryServer) handleUpdates(stopCh <-chan struct{}) {
 // The debounce helper function is implemented to enable mocking
 func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts debounceOptions, pushFn func(req *model.PushRequest), updateSent *atomic.Int64) {
 	var timeChan <-chan time.Time
-	var startDebounce time.Time
-	var lastConfigUpdateTime time.Time
+	// nextWake is when timeChan fires.
+	var nextWake time.Time
 
 	pushCounter := 0
-	debouncedEvents := 0
 
-	// Keeps track of the push requests. If updates are debounce they will be merged.
-	var req *model.PushRequest
+	// The push requests are debounced in lanes: one for each kind debounced separately, and a default one for the
+	// others. If updates are debounced they will be merged.
+	lanes := map[string]*debounceLane{
+		"": {window: debounceWindow{after: opts.debounceAfter, max: opts.debounceMax}},
+	}
+	for kind, window := range opts.kindDebounce {
+		lanes[kind] = &debounceLane{window: window}
+	}
 
 	free := true
 	freeCh := make(chan struct{}, 1)
@@ -420,30 +478,52 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts debounceO
 		freeCh <- struct{}{}
 	}
 
+	wakeAt := func(t time.Time) {
+		if timeChan == nil || t.Before(nextWake) {
+			nextWake = t
+			timeChan = time.After(time.Until(t))
+		}
+	}
+
 	pushWorker := func() {
-		eventDelay := time.Since(startDebounce)
-		quietTime := time.Since(lastConfigUpdateTime)
-		// it has been too long or quiet enough
-		if eventDelay >= opts.debounceMax || quietTime >= opts.debounceAfter {
-			if req != nil {
-				pushCounter++
-				if req.ConfigsUpdated == nil {
-					log.Infof("Push debounce stable[%d] %d for reason %s: %v since last change, %v since last push, full=%v",
-						pushCounter, debouncedEvents, reasonsUpdated(req),
-						quietTime, eventDelay, req.Full)
-				} else {
-					log.Infof("Push debounce stable[%d] %d for config %s: %v since last change, %v since last push, full=%v",
-						pushCounter, debouncedEvents, configsUpdated(req),
-						quietTime, eventDelay, req.Full)
-				}
-				free = false
-				go push(req, debouncedEvents)
-				req = nil
-				debouncedEvents = 0
+		now := time.Now()
+		// Push the requests of all the lanes that have been debounced for too long or are quiet enough at once.
+		var req *model.PushRequest
+		var eventDelay, quietTime time.Duration
+		debouncedEvents := 0
+		for _, lane := range lanes {
+			if lane.req == nil {
+				continue
+			}
+			if !lane.ready(now) {
+				wakeAt(lane.readyAt())
+				continue
+			}
+			if d := now.Sub(lane.startDebounce); d > eventDelay {
+				eventDelay = d
+			}
+			if d := now.Sub(lane.lastConfigUpdateTime); quietTime == 0 || d < quietTime {
+				quietTime = d
 			}
+			req = req.Merge(lane.req)
+			debouncedEvents += lane.debouncedEvents
+			lane.reset()
+		}
+		if req == nil {
+			return
+		}
+		pushCounter++
+		if req.ConfigsUpdated == nil {
+			log.Infof("Push debounce stable[%d] %d for reason %s: %v since last change, %v since last push, full=%v",
+				pushCounter, debouncedEvents, reasonsUpdated(req),
+				quietTime, eventDelay, req.Full)
 		} else {
-			timeChan = time.After(opts.debounceAfter - quietTime)
+			log.Infof("Push debounce stable[%d] %d for config %s: %v since last change, %v since last push, full=%v",
+				pushCounter, debouncedEvents, configsUpdated(req),
+				quietTime, eventDelay, req.Full)
 		}
+		free = false
+		go push(req, debouncedEvents)
 	}
 
 	for {
@@ -465,15 +545,17 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts debounceO
 				continue
 			}
 
-			lastConfigUpdateTime = time.Now()
-			if debouncedEvents == 0 {
-				timeChan = time.After(opts.debounceAfter)
-				startDebounce = lastConfigUpdateTime
+			lane := lanes[opts.debounceLane(r)]
+			lane.lastConfigUpdateTime = time.Now()
+			if lane.debouncedEvents == 0 {
+				lane.startDebounce = lane.lastConfigUpdateTime
+				wakeAt(lane.lastConfigUpdateTime.Add(lane.window.after))
 			}
-			debouncedEvents++
+			lane.debouncedEvents++
 
-			req = req.Merge(r)
+			lane.req = lane.req.Merge(r)
 		case <-timeChan:
+			timeChan = nil
 			if free {
 				pushWorker()
 			}
@@ -483,6 +565,123 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts debounceO
 	}
 }
 
+// debounceLane holds the push requests debounced together.
+type debounceLane struct {
+	window               debounceWindow
+	req                  *model.PushRequest
+	startDebounce        time.Time
+	lastConfigUpdateTime time.Time
+	debouncedEvents      int
+}
+
+// ready returns true if the requests have been debounced for too long or are quiet enough.
+func (l *debounceLane) ready(now time.Time) bool {
+	return now.Sub(l.startDebounce) >= l.window.max || now.Sub(l.lastConfigUpdateTime) >= l.window.after
+}
+
+// readyAt returns when the requests will be ready, if no further request is received.
+func (l *debounceLane) readyAt() time.Time {
+	t := l.lastConfigUpdateTime.Add(l.window.after)
+	if max := l.startDebounce.Add(l.window.max); max.Before(t) {
+		return max
+	}
+	return t
+}
+
+func (l *debounceLane) reset() {
+	l.req = nil
+	l.debouncedEvents = 0
+}
+
+// endpointDebounceLane is the lane of the endpoint updates, named after their trigger reason. The endpoint updates
+// are keyed by service, whichever the kind of the endpoints (Pod, WorkloadEntry...), so they are told apart from the
+// config updates by their reason rather than by kind.
+const endpointDebounceLane = string(model.EndpointUpdate)
+
+// debounceLane returns the kind whose lane debounces the push request, or an empty string for the default lane.
+// Requests updating configs of several kinds are debounced in the default lane.
+func (opts debounceOptions) debounceLane(req *model.PushRequest) string {
+	if len(opts.kindDebounce) == 0 {
+		return ""
+	}
+	if _, f := opts.kindDebounce[endpointDebounceLane]; f && onlyEndpointUpdates(req) {
+		return endpointDebounceLane
+	}
+	if len(req.ConfigsUpdated) == 0 {
+		return ""
+	}
+	lane := ""
+	for key := range req.ConfigsUpdated {
+		kind := key.Kind.Kind
+		if _, f := opts.kindDebounce[kind]; !f || (lane != "" && lane != kind) {
+			return ""
+		}
+		lane = kind
+	}
+	return lane
+}
+
+// onlyEndpointUpdates returns true if the push request is only triggered by endpoint updates.
+func onlyEndpointUpdates(req *model.PushRequest) bool {
+	if len(req.Reason) == 0 {
+		return false
+	}
+	for _, reason := range req.Reason {
+		if reason != model.EndpointUpdate {
+			return false
+		}
+	}
+	return true
+}
+
+// pushPriority parses the comma separated proxy types pushed first, ignoring unknown types.
+func pushPriority(value string) []model.NodeType {
+	var out []model.NodeType
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !model.IsApplicationNodeType(model.NodeType(t)) {
+			log.Warnf("ignoring unknown proxy type %q of the push priority", t)
+			continue
+		}
+		out = append(out, model.NodeType(t))
+	}
+	return out
+}
+
+// parseKindDebounce parses the comma separated kind=after[:max] debounce windows, max defaulting to debounceMax.
+// Invalid windows are ignored.
+func parseKindDebounce(value string, debounceMax time.Duration) map[string]debounceWindow {
+	windows := map[string]debounceWindow{}
+	for _, kw := range strings.Split(value, ",") {
+		kw = strings.TrimSpace(kw)
+		if kw == "" {
+			continue
+		}
+		parts := strings.SplitN(kw, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			log.Warnf("ignoring invalid debounce window %q: want kind=after[:max]", kw)
+			continue
+		}
+		durations := strings.SplitN(parts[1], ":", 2)
+		after, err := time.ParseDuration(durations[0])
+		if err != nil {
+			log.Warnf("ignoring invalid debounce window %q: %v", kw, err)
+			continue
+		}
+		window := debounceWindow{after: after, max: debounceMax}
+		if len(durations) == 2 {
+			if window.max, err = time.ParseDuration(durations[1]); err != nil {
+				log.Warnf("ignoring invalid debounce window %q: %v", kw, err)
+				continue
+			}
+		}
+		windows[parts[0]] = window
+	}
+	return windows
+}
+
 func configsUpdated(req *model.PushRequest) string {
 	configs := ""
 	for key := range req.ConfigsUpdated {
@@ -611,9 +810,24 @@ func (s *DiscoveryServer) InitGenerators(env *model.Environment, systemNameSpace
 
 	s.Generators["event"] = s.StatusGen
 	s.Generators[TypeDebug] = NewDebugGen(s, systemNameSpace)
+	s.Generators[v3.EndpointDrainType] = &EndpointDrainGen{Server: s}
 	s.Generators[v3.BootstrapType] = &BootstrapGenerator{Server: s}
 }
 
+// InitConfigExport registers the config-export generator if clients are allowed to export the config, returning an
+// error if the readers are invalid.
+func (s *DiscoveryServer) InitConfigExport(env *model.Environment, readers string) error {
+	if readers == "" {
+		return nil
+	}
+	parsed, err := apigen.ParseConfigExportReaders(readers)
+	if err != nil {
+		return err
+	}
+	s.Generators[apigen.ConfigExport] = apigen.NewConfigExportGenerator(env.IstioConfigStore, parsed)
+	return nil
+}
+
 // shutdown shuts down DiscoveryServer components.
 func (s *DiscoveryServer) Shutdown() {
 	s.closeJwksResolver()
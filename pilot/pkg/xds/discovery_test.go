@@ -27,8 +27,11 @@ import (
 	uatomic "go.uber.org/atomic"
 	"google.golang.org/grpc"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/apigen"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/test/util/retry"
 )
 
@@ -326,6 +329,175 @@ func TestDebounce(t *testing.T) {
 	}
 }
 
+func TestDebounceByKind(t *testing.T) {
+	opts := debounceOptions{
+		debounceAfter:     time.Millisecond * 50,
+		debounceMax:       time.Millisecond * 100,
+		enableEDSDebounce: true,
+		kindDebounce: map[string]debounceWindow{
+			endpointDebounceLane: {after: time.Millisecond * 300, max: time.Millisecond * 600},
+		},
+	}
+	// The endpoint updates of a WorkloadEntry are keyed by the service it selects, as done by EDSUpdate.
+	svc := model.ConfigKey{Kind: gvk.ServiceEntry, Name: "svc.ns.svc.cluster.local", Namespace: "ns"}
+	vs := model.ConfigKey{Kind: gvk.VirtualService, Name: "vs", Namespace: "ns"}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	updateCh := make(chan *model.PushRequest)
+	var mu sync.Mutex
+	var pushes [][]model.ConfigKey
+	fakePush := func(req *model.PushRequest) {
+		mu.Lock()
+		defer mu.Unlock()
+		var keys []model.ConfigKey
+		for key := range req.ConfigsUpdated {
+			keys = append(keys, key)
+		}
+		pushes = append(pushes, keys)
+	}
+	go debounce(updateCh, stopCh, opts, fakePush, uatomic.NewInt64(0))
+
+	expect := func(expected ...[]model.ConfigKey) {
+		t.Helper()
+		retry.UntilSuccessOrFail(t, func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(pushes, expected) {
+				return fmt.Errorf("got pushes %v, expected %v", pushes, expected)
+			}
+			return nil
+		}, retry.Timeout(time.Second), retry.Delay(10*time.Millisecond))
+	}
+
+	start := time.Now()
+	updateCh <- &model.PushRequest{
+		ConfigsUpdated: map[model.ConfigKey]struct{}{svc: {}},
+		Reason:         []model.TriggerReason{model.EndpointUpdate},
+	}
+	updateCh <- &model.PushRequest{
+		Full:           true,
+		ConfigsUpdated: map[model.ConfigKey]struct{}{vs: {}},
+		Reason:         []model.TriggerReason{model.ConfigUpdate},
+	}
+	// The VirtualService is pushed without waiting for the debounce window of the endpoints.
+	expect([]model.ConfigKey{vs})
+	if elapsed := time.Since(start); elapsed >= opts.kindDebounce[endpointDebounceLane].after {
+		t.Fatalf("VirtualService push delayed by %v", elapsed)
+	}
+	expect([]model.ConfigKey{vs}, []model.ConfigKey{svc})
+}
+
+func TestDebounceLane(t *testing.T) {
+	opts := debounceOptions{
+		kindDebounce: map[string]debounceWindow{
+			endpointDebounceLane:    {after: time.Second, max: time.Second},
+			gvk.VirtualService.Kind: {after: time.Millisecond, max: time.Second},
+		},
+	}
+	svc := model.ConfigKey{Kind: gvk.ServiceEntry, Name: "svc.ns.svc.cluster.local", Namespace: "ns"}
+	vs := model.ConfigKey{Kind: gvk.VirtualService, Name: "vs", Namespace: "ns"}
+	dr := model.ConfigKey{Kind: gvk.DestinationRule, Name: "dr", Namespace: "ns"}
+	cases := []struct {
+		name string
+		req  *model.PushRequest
+		want string
+	}{
+		{
+			name: "incremental endpoint update",
+			req: &model.PushRequest{
+				ConfigsUpdated: map[model.ConfigKey]struct{}{svc: {}},
+				Reason:         []model.TriggerReason{model.EndpointUpdate},
+			},
+			want: endpointDebounceLane,
+		},
+		{
+			name: "full endpoint update",
+			req: &model.PushRequest{
+				Full:           true,
+				ConfigsUpdated: map[model.ConfigKey]struct{}{svc: {}},
+				Reason:         []model.TriggerReason{model.EndpointUpdate, model.EndpointUpdate},
+			},
+			want: endpointDebounceLane,
+		},
+		{
+			name: "service update",
+			req: &model.PushRequest{
+				Full:           true,
+				ConfigsUpdated: map[model.ConfigKey]struct{}{svc: {}},
+				Reason:         []model.TriggerReason{model.ServiceUpdate},
+			},
+			want: "",
+		},
+		{
+			name: "endpoint and config updates",
+			req: &model.PushRequest{
+				Full:           true,
+				ConfigsUpdated: map[model.ConfigKey]struct{}{svc: {}, vs: {}},
+				Reason:         []model.TriggerReason{model.EndpointUpdate, model.ConfigUpdate},
+			},
+			want: "",
+		},
+		{
+			name: "virtual service update",
+			req: &model.PushRequest{
+				Full:           true,
+				ConfigsUpdated: map[model.ConfigKey]struct{}{vs: {}},
+				Reason:         []model.TriggerReason{model.ConfigUpdate},
+			},
+			want: gvk.VirtualService.Kind,
+		},
+		{
+			name: "virtual service and destination rule update",
+			req: &model.PushRequest{
+				Full:           true,
+				ConfigsUpdated: map[model.ConfigKey]struct{}{vs: {}, dr: {}},
+				Reason:         []model.TriggerReason{model.ConfigUpdate},
+			},
+			want: "",
+		},
+		{
+			name: "global update",
+			req:  &model.PushRequest{Full: true, Reason: []model.TriggerReason{model.GlobalUpdate}},
+			want: "",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := opts.debounceLane(tt.req); got != tt.want {
+				t.Errorf("got lane %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKindDebounce(t *testing.T) {
+	cases := []struct {
+		value string
+		want  map[string]debounceWindow
+	}{
+		{"", map[string]debounceWindow{}},
+		{
+			"WorkloadEntry=1s:30s, VirtualService=10ms",
+			map[string]debounceWindow{
+				"WorkloadEntry":  {after: time.Second, max: 30 * time.Second},
+				"VirtualService": {after: 10 * time.Millisecond, max: 10 * time.Second},
+			},
+		},
+		{
+			"WorkloadEntry,=1s,ServiceEntry=x,Gateway=1s:x,Sidecar=2s",
+			map[string]debounceWindow{"Sidecar": {after: 2 * time.Second, max: 10 * time.Second}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseKindDebounce(tt.value, 10*time.Second); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestShouldRespond(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -476,3 +648,33 @@ func TestShouldRespond(t *testing.T) {
 		})
 	}
 }
+
+func TestInitConfigExport(t *testing.T) {
+	for _, readers := range []string{"", "audit/auditor=*"} {
+		t.Run(readers, func(t *testing.T) {
+			defaultValue := features.ConfigExportReaders
+			features.ConfigExportReaders = readers
+			defer func() { features.ConfigExportReaders = defaultValue }()
+
+			s := NewFakeDiscoveryServer(t, FakeOptions{})
+			// The api generator is left to its existing clients.
+			if _, api := s.Discovery.Generators["api"]; !api {
+				t.Fatalf("expected the api generator")
+			}
+			_, export := s.Discovery.Generators[apigen.ConfigExport]
+			if want := readers != ""; export != want {
+				t.Fatalf("got config export generator %v, want %v", export, want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		s := NewFakeDiscoveryServer(t, FakeOptions{})
+		if err := s.Discovery.InitConfigExport(s.Discovery.Env, "audit/auditor"); err == nil {
+			t.Fatalf("expected an error for invalid readers")
+		}
+		if _, export := s.Discovery.Generators[apigen.ConfigExport]; export {
+			t.Fatalf("unexpected config export generator")
+		}
+	})
+}
@@ -0,0 +1,397 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/proto"
+	any "google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+// EndpointDrainGen reports the endpoints of the requesting proxy as draining, so the other proxies stop sending
+// new requests to them, and returns the proxies that have not acked the change yet. The agent requests it
+// before the workload is stopped, and polls it until no proxy is pending.
+//
+// The drains are shared with the other istiod replicas through their distribution reports, so the proxies connected
+// to any replica are drained and tracked as pending. Without reports, only the proxies connected to this replica are.
+type EndpointDrainGen struct {
+	Server *DiscoveryServer
+}
+
+var _ model.XdsResourceGenerator = &EndpointDrainGen{}
+
+// Generate starts draining the endpoints of the proxy on the first request, and returns the drain status.
+func (g *EndpointDrainGen) Generate(proxy *model.Proxy, _ *model.PushContext, _ *model.WatchedResource,
+	req *model.PushRequest) (model.Resources, model.XdsLogDetails, error) {
+	var drain EndpointDrain
+	if isProxyRequest(req) {
+		var started bool
+		drain, started = g.Server.endpointDrains.start(proxy)
+		if !g.Server.connected(proxy) {
+			// Requests may still be processed after the connection is closed, which ends the drain.
+			g.Server.endpointDrains.stop(proxy.ID)
+			return nil, model.DefaultXdsLogDetails, nil
+		}
+		if started {
+			log.Infof("EDS: draining endpoints %v of %s", proxy.IPAddresses, proxy.ID)
+			g.Server.pushEndpointDrain(drain)
+		}
+	} else {
+		// Pushes only report the status of a drain in progress, they may be queued after the proxy disconnected.
+		var f bool
+		if drain, f = g.Server.endpointDrains.get(proxy.ID); !f {
+			return nil, model.DefaultXdsLogDetails, nil
+		}
+	}
+	b, err := json.Marshal(model.EndpointDrainStatus{
+		Started: drain.Started,
+		Pending: g.Server.pendingEndpointDrain(proxy.ID, drain),
+	})
+	if err != nil {
+		return nil, model.DefaultXdsLogDetails, err
+	}
+	return model.Resources{&discovery.Resource{
+		Name:     proxy.ID,
+		Resource: &any.Any{TypeUrl: v3.EndpointDrainType, Value: b},
+	}}, model.DefaultXdsLogDetails, nil
+}
+
+func isProxyRequest(req *model.PushRequest) bool {
+	for _, reason := range req.Reason {
+		if reason == model.ProxyRequest {
+			return true
+		}
+	}
+	return false
+}
+
+// connected returns whether the connection of the proxy is still open.
+func (s *DiscoveryServer) connected(proxy *model.Proxy) bool {
+	for _, con := range s.Clients() {
+		if con.proxy == proxy {
+			return true
+		}
+	}
+	return false
+}
+
+// pushEndpointDrain pushes the endpoints of the services of a proxy that started or stopped draining.
+func (s *DiscoveryServer) pushEndpointDrain(drain EndpointDrain) {
+	configsUpdated := map[model.ConfigKey]struct{}{}
+	for hostname, namespace := range drain.Services {
+		configsUpdated[model.ConfigKey{Kind: gvk.ServiceEntry, Name: hostname, Namespace: namespace}] = struct{}{}
+	}
+	if len(configsUpdated) == 0 {
+		return
+	}
+	s.ConfigUpdate(&model.PushRequest{
+		Full:           false,
+		ConfigsUpdated: configsUpdated,
+		Reason:         []model.TriggerReason{model.EndpointUpdate},
+	})
+}
+
+// pendingEndpointDrain returns the proxies that watch the endpoints of a draining proxy connected to this istiod and
+// have not acked the drain yet, whichever replica they are connected to. The replicas that have not reported the
+// drain yet are pending as a whole, as "istiod/<replica>".
+func (s *DiscoveryServer) pendingEndpointDrain(proxyID string, drain EndpointDrain) []string {
+	pending := s.localPendingEndpointDrain(proxyID, drain)
+	s.endpointDrains.mu.RLock()
+	for replica, r := range s.endpointDrains.replicas {
+		if p, f := r.pending[proxyID]; f {
+			pending = append(pending, p...)
+		} else {
+			pending = append(pending, "istiod/"+replica)
+		}
+	}
+	s.endpointDrains.mu.RUnlock()
+	sort.Strings(pending)
+	return pending
+}
+
+// localPendingEndpointDrain returns the connected proxies that watch the endpoints of a draining proxy and have not
+// acked an EDS push generated after the drain started.
+func (s *DiscoveryServer) localPendingEndpointDrain(proxyID string, drain EndpointDrain) []string {
+	var pending []string
+	for _, con := range s.Clients() {
+		if con.proxy.ID == proxyID {
+			continue
+		}
+		con.proxy.RLock()
+		w := con.proxy.WatchedResources[v3.EndpointType]
+		watched := w != nil && watchesServices(w.ResourceNames, drain.Services)
+		acked := watched && w.NonceSent == w.NonceAcked && !w.LastSent.Before(drain.Started)
+		con.proxy.RUnlock()
+		if watched && !acked {
+			pending = append(pending, con.proxy.ID)
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
+
+func watchesServices(clusters []string, services map[string]string) bool {
+	for _, cluster := range clusters {
+		_, _, hostname, _ := model.ParseSubsetKey(cluster)
+		if _, f := services[string(hostname)]; f {
+			return true
+		}
+	}
+	return false
+}
+
+// EndpointDrainReport is the part of the distribution report of an istiod replica sharing its endpoint drains with
+// the other replicas.
+type EndpointDrainReport struct {
+	// Drains are the drains of the proxies connected to the replica, keyed by proxy ID.
+	Drains map[string]EndpointDrain `json:"drains,omitempty"`
+	// Pending are the proxies connected to the replica that have not acked the drains of any replica yet, keyed by
+	// the ID of the draining proxy. Every drain known by the replica is listed, even once no proxy is pending.
+	Pending map[string][]string `json:"pending,omitempty"`
+}
+
+// EndpointDrain is the drain of the endpoints of a proxy.
+type EndpointDrain struct {
+	Started time.Time `json:"started"`
+	// Addresses are the addresses of the endpoints of the proxy.
+	Addresses []string `json:"addresses"`
+	// Services are the namespaces of the services of the proxy, keyed by hostname.
+	Services map[string]string `json:"services"`
+}
+
+// LocalEndpointDrains returns the drains of the proxies connected to this istiod, and the connected proxies pending
+// on the drains of any replica, for the other replicas to read them in its distribution report. The report is never
+// nil, so the other replicas know this one shares its drains.
+func (s *DiscoveryServer) LocalEndpointDrains() *EndpointDrainReport {
+	drains := s.endpointDrains.all()
+	out := &EndpointDrainReport{Drains: s.endpointDrains.local()}
+	if len(drains) > 0 {
+		out.Pending = make(map[string][]string, len(drains))
+	}
+	for proxyID, drain := range drains {
+		// Lists are not nil, so the drain is known to be seen once no proxy is pending.
+		out.Pending[proxyID] = append([]string{}, s.localPendingEndpointDrain(proxyID, drain)...)
+	}
+	return out
+}
+
+// UpdateReplicaEndpointDrains records the endpoint drains last reported by another istiod replica, and pushes the
+// endpoints of the drains it started or stopped. A nil report forgets the replica.
+func (s *DiscoveryServer) UpdateReplicaEndpointDrains(replica string, report *EndpointDrainReport) {
+	if replica == s.instanceID {
+		return
+	}
+	for _, drain := range s.endpointDrains.updateReplica(replica, report) {
+		s.pushEndpointDrain(drain)
+	}
+}
+
+// endpointDrains tracks the proxies draining their endpoints. A drain ends when the proxy disconnects, as its
+// endpoints are then removed by the registry.
+type endpointDrains struct {
+	mu sync.RWMutex
+	// drains are the drains of the proxies connected to this istiod, keyed by proxy ID.
+	drains map[string]EndpointDrain
+	// replicas are the drains reported by the other istiod replicas, keyed by replica.
+	replicas map[string]*replicaEndpointDrains
+}
+
+// replicaEndpointDrains are the endpoint drains last reported by an istiod replica.
+type replicaEndpointDrains struct {
+	// drains are the drains of the proxies connected to the replica, keyed by proxy ID. They are started when this
+	// istiod first saw them, as they are compared with the pushes of this istiod.
+	drains map[string]EndpointDrain
+	// pending are the proxies connected to the replica that have not acked the drains yet, by draining proxy ID.
+	pending map[string][]string
+}
+
+func newEndpointDrains() *endpointDrains {
+	return &endpointDrains{
+		drains:   map[string]EndpointDrain{},
+		replicas: map[string]*replicaEndpointDrains{},
+	}
+}
+
+// start records the drain of the endpoints of the proxy, and returns whether it was not draining already.
+func (d *endpointDrains) start(proxy *model.Proxy) (EndpointDrain, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if drain, f := d.drains[proxy.ID]; f {
+		return drain, false
+	}
+	drain := EndpointDrain{
+		Started:   time.Now(),
+		Addresses: append([]string{}, proxy.IPAddresses...),
+		Services:  map[string]string{},
+	}
+	for _, si := range proxy.ServiceInstances {
+		drain.Services[string(si.Service.Hostname)] = si.Service.Attributes.Namespace
+	}
+	d.drains[proxy.ID] = drain
+	return drain, true
+}
+
+// updateReplica records the drains reported by a replica, and returns the drains it started or stopped.
+func (d *endpointDrains) updateReplica(replica string, report *EndpointDrainReport) []EndpointDrain {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var changed []EndpointDrain
+	prev := d.replicas[replica]
+	if report == nil {
+		delete(d.replicas, replica)
+		if prev != nil {
+			for _, drain := range prev.drains {
+				changed = append(changed, drain)
+			}
+		}
+		return changed
+	}
+	next := &replicaEndpointDrains{drains: map[string]EndpointDrain{}, pending: report.Pending}
+	for proxyID, drain := range report.Drains {
+		if seen, f := prev.get(proxyID); f {
+			next.drains[proxyID] = seen
+			continue
+		}
+		drain.Started = time.Now()
+		next.drains[proxyID] = drain
+		changed = append(changed, drain)
+	}
+	if prev != nil {
+		for proxyID, drain := range prev.drains {
+			if _, f := next.drains[proxyID]; !f {
+				changed = append(changed, drain)
+			}
+		}
+	}
+	d.replicas[replica] = next
+	return changed
+}
+
+func (r *replicaEndpointDrains) get(proxyID string) (EndpointDrain, bool) {
+	if r == nil {
+		return EndpointDrain{}, false
+	}
+	drain, f := r.drains[proxyID]
+	return drain, f
+}
+
+// local returns the drains of the proxies connected to this istiod.
+func (d *endpointDrains) local() map[string]EndpointDrain {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.drains) == 0 {
+		return nil
+	}
+	out := make(map[string]EndpointDrain, len(d.drains))
+	for proxyID, drain := range d.drains {
+		out[proxyID] = drain
+	}
+	return out
+}
+
+// all returns the drains of the proxies connected to any replica, keyed by proxy ID.
+func (d *endpointDrains) all() map[string]EndpointDrain {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make(map[string]EndpointDrain, len(d.drains))
+	for _, r := range d.replicas {
+		for proxyID, drain := range r.drains {
+			out[proxyID] = drain
+		}
+	}
+	for proxyID, drain := range d.drains {
+		out[proxyID] = drain
+	}
+	return out
+}
+
+// get returns the drain of the proxy, if any.
+func (d *endpointDrains) get(proxyID string) (EndpointDrain, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	drain, f := d.drains[proxyID]
+	return drain, f
+}
+
+// stop forgets the drain of the proxy, and returns whether it was draining.
+func (d *endpointDrains) stop(proxyID string) (EndpointDrain, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	drain, f := d.drains[proxyID]
+	delete(d.drains, proxyID)
+	return drain, f
+}
+
+// draining returns whether the address belongs to a proxy draining through any replica.
+func (d *endpointDrains) draining(address string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if hasAddress(d.drains, address) {
+		return true
+	}
+	for _, r := range d.replicas {
+		if hasAddress(r.drains, address) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAddress(drains map[string]EndpointDrain, address string) bool {
+	for _, drain := range drains {
+		for _, a := range drain.Addresses {
+			if a == address {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markDraining sets the health status of the endpoints of the draining proxies to DRAINING, so proxies stop sending
+// them new requests.
+func (d *endpointDrains) markDraining(llbOpts []*LocLbEndpointsAndOptions) {
+	d.mu.RLock()
+	empty := len(d.drains) == 0
+	for _, r := range d.replicas {
+		empty = empty && len(r.drains) == 0
+	}
+	d.mu.RUnlock()
+	if empty {
+		return
+	}
+	for _, llb := range llbOpts {
+		for i, ep := range llb.llbEndpoints.LbEndpoints {
+			if !d.draining(ep.GetEndpoint().GetAddress().GetSocketAddress().GetAddress()) {
+				continue
+			}
+			// The endpoint is shared with the other clusters of the service, so it is copied before being modified.
+			drained := proto.Clone(ep).(*endpoint.LbEndpoint)
+			drained.HealthStatus = core.HealthStatus_DRAINING
+			llb.llbEndpoints.LbEndpoints[i] = drained
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const drainedServiceEntry = `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts:
+  - drained.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: STATIC
+  location: MESH_INTERNAL
+  endpoints:
+  - address: 10.0.0.1
+  - address: 10.0.0.2
+`
+
+const drainedCluster = "outbound|80||drained.example.com"
+
+func endpointHealthStatus(t *testing.T, resp *discovery.DiscoveryResponse) map[string]core.HealthStatus {
+	t.Helper()
+	out := map[string]core.HealthStatus{}
+	for _, r := range resp.Resources {
+		cla := &endpoint.ClusterLoadAssignment{}
+		if err := r.UnmarshalTo(cla); err != nil {
+			t.Fatal(err)
+		}
+		for _, llb := range cla.Endpoints {
+			for _, ep := range llb.LbEndpoints {
+				out[ep.GetEndpoint().GetAddress().GetSocketAddress().GetAddress()] = ep.HealthStatus
+			}
+		}
+	}
+	return out
+}
+
+func expectHealthStatus(t *testing.T, resp *discovery.DiscoveryResponse, want core.HealthStatus) {
+	t.Helper()
+	got := endpointHealthStatus(t, resp)
+	if got["10.0.0.1"] != want || got["10.0.0.2"] != core.HealthStatus_HEALTHY {
+		t.Fatalf("expected 10.0.0.1 to be %v and 10.0.0.2 to be healthy, got %v", want, got)
+	}
+}
+
+func endpointDrainStatus(t *testing.T, drainer *xds.AdsTest) model.EndpointDrainStatus {
+	t.Helper()
+	resp := drainer.RequestResponseAck(t, nil)
+	status := model.EndpointDrainStatus{}
+	if err := json.Unmarshal(resp.Resources[0].Value, &status); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+func TestEndpointDrain(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: drainedServiceEntry})
+	cluster := drainedCluster
+	client := s.ConnectADS().WithType(v3.EndpointType)
+	expectHealthStatus(t, client.RequestResponseAck(t, &discovery.DiscoveryRequest{ResourceNames: []string{cluster}}), core.HealthStatus_HEALTHY)
+
+	drainer := s.ConnectADS().WithID("sidecar~10.0.0.1~drained.default~default.svc.cluster.local").WithType(v3.EndpointDrainType)
+	drainStatus := func() model.EndpointDrainStatus {
+		t.Helper()
+		return endpointDrainStatus(t, drainer)
+	}
+	if status := drainStatus(); status.Started.IsZero() {
+		t.Fatalf("expected the drain to be started, got %+v", status)
+	}
+
+	// The watching proxy gets the endpoint reported as draining, and is no longer pending once it acks it.
+	resp := client.ExpectResponse(t)
+	expectHealthStatus(t, resp, core.HealthStatus_DRAINING)
+	client.Request(t, &discovery.DiscoveryRequest{ResourceNames: []string{cluster}, ResponseNonce: resp.Nonce, VersionInfo: resp.VersionInfo})
+	retry.UntilSuccessOrFail(t, func() error {
+		if status := drainStatus(); len(status.Pending) != 0 {
+			return fmt.Errorf("expected no pending proxy, got %v", status.Pending)
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+
+	// Once the draining proxy disconnects, its endpoints are no longer reported as draining.
+	drainer.Cleanup()
+	expectHealthStatus(t, client.ExpectResponse(t), core.HealthStatus_HEALTHY)
+}
+
+func TestEndpointDrainAcrossReplicas(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: drainedServiceEntry})
+	client := s.ConnectADS().WithType(v3.EndpointType)
+	expectHealthStatus(t, client.RequestResponseAck(t, &discovery.DiscoveryRequest{ResourceNames: []string{drainedCluster}}),
+		core.HealthStatus_HEALTHY)
+
+	// A proxy connected to another replica drains: the proxies of this replica see it, and are reported pending.
+	remote := "sidecar~10.0.0.1~remote.default~default.svc.cluster.local"
+	s.Discovery.UpdateReplicaEndpointDrains("istiod-2", &xds.EndpointDrainReport{
+		Drains: map[string]xds.EndpointDrain{remote: {
+			Started:   time.Now(),
+			Addresses: []string{"10.0.0.1"},
+			Services:  map[string]string{"drained.example.com": "default"},
+		}},
+	})
+	resp := client.ExpectResponse(t)
+	expectHealthStatus(t, resp, core.HealthStatus_DRAINING)
+	if got := s.Discovery.LocalEndpointDrains().Pending[remote]; len(got) != 1 {
+		t.Fatalf("expected the watching proxy to be pending, got %v", got)
+	}
+	client.Request(t, &discovery.DiscoveryRequest{ResourceNames: []string{drainedCluster}, ResponseNonce: resp.Nonce, VersionInfo: resp.VersionInfo})
+	retry.UntilSuccessOrFail(t, func() error {
+		if got, f := s.Discovery.LocalEndpointDrains().Pending[remote]; !f || len(got) != 0 {
+			return fmt.Errorf("expected the drain to be acked, got %v", got)
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+
+	// The other replica stops reporting the drain once the proxy disconnected.
+	s.Discovery.UpdateReplicaEndpointDrains("istiod-2", &xds.EndpointDrainReport{})
+	expectHealthStatus(t, client.ExpectResponse(t), core.HealthStatus_HEALTHY)
+
+	// A drain through this replica is pending until the other replica reports it acked by its proxies.
+	drainer := s.ConnectADS().WithID("sidecar~10.0.0.1~drained.default~default.svc.cluster.local").WithType(v3.EndpointDrainType)
+	local := drainer.ID
+	status := endpointDrainStatus(t, drainer)
+	if !reflect.DeepEqual(status.Pending, []string{"istiod/istiod-2", client.ID}) {
+		t.Fatalf("expected the proxy and the other replica to be pending, got %v", status.Pending)
+	}
+	if _, f := s.Discovery.LocalEndpointDrains().Drains[local]; !f {
+		t.Fatalf("expected the drain to be shared with the other replicas")
+	}
+	resp = client.ExpectResponse(t)
+	client.Request(t, &discovery.DiscoveryRequest{ResourceNames: []string{drainedCluster}, ResponseNonce: resp.Nonce, VersionInfo: resp.VersionInfo})
+	s.Discovery.UpdateReplicaEndpointDrains("istiod-2", &xds.EndpointDrainReport{
+		Pending: map[string][]string{local: {"sidecar~10.0.0.3~other.default~default.svc.cluster.local"}},
+	})
+	retry.UntilSuccessOrFail(t, func() error {
+		want := []string{"sidecar~10.0.0.3~other.default~default.svc.cluster.local"}
+		if got := endpointDrainStatus(t, drainer).Pending; !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("expected %v to be pending, got %v", want, got)
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+	s.Discovery.UpdateReplicaEndpointDrains("istiod-2", &xds.EndpointDrainReport{Pending: map[string][]string{local: {}}})
+	if got := endpointDrainStatus(t, drainer).Pending; len(got) != 0 {
+		t.Fatalf("expected no pending proxy, got %v", got)
+	}
+}
@@ -68,7 +68,7 @@ func (s *DiscoveryServer) UpdateServiceShards(push *model.PushContext) error {
 			}
 			endpoints := make([]*model.IstioEndpoint, 0)
 			for _, port := range svc.Ports {
-				if port.Protocol == protocol.UDP {
+				if port.Protocol == protocol.UDP && !features.EnableUDPProxy {
 					continue
 				}
 
@@ -360,6 +360,10 @@ func (s *DiscoveryServer) llbEndpointAndOptionsForCluster(b EndpointBuilder) ([]
 	}
 
 	svcPort, f := b.service.Ports.GetByPort(b.port)
+	if !f && features.EnableUDPProxy {
+		// The cluster of a port only declared for UDP, used by UDP proxy listeners.
+		svcPort, f = b.service.Ports.GetUDPByPort(b.port)
+	}
 	if !f {
 		// Shouldn't happen here
 		log.Debugf("can not find the service port %d for cluster %s", b.port, b.clusterName)
@@ -395,6 +399,7 @@ func (s *DiscoveryServer) generateEndpoints(b EndpointBuilder) *endpoint.Cluster
 		llbOpts = b.EndpointsWithMTLSFilter(llbOpts)
 	}
 	llbOpts = b.ApplyTunnelSetting(llbOpts, b.tunnelType)
+	s.endpointDrains.markDraining(llbOpts)
 
 	l := b.createClusterLoadAssignment(llbOpts)
 
@@ -327,6 +327,105 @@ func TestEdsWeightedServiceEntry(t *testing.T) {
 	}
 }
 
+func TestWeightedWorkloadEntries(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: static
+spec:
+  hosts:
+  - weighted.static.svc.cluster.local
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: STATIC
+  workloadSelector:
+    labels:
+      app: weighted
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: dns
+spec:
+  hosts:
+  - weighted.dns.svc.cluster.local
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+  workloadSelector:
+    labels:
+      app: weighted
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: WorkloadEntry
+metadata:
+  name: a-weighted
+spec:
+  address: 1.1.1.1
+  locality: a
+  weight: 8
+  labels:
+    app: weighted
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: WorkloadEntry
+metadata:
+  name: a-unweighted
+spec:
+  address: 2.2.2.2
+  locality: a
+  labels:
+    app: weighted
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: WorkloadEntry
+metadata:
+  name: b-weighted
+spec:
+  address: 3.3.3.3
+  locality: b
+  weight: 3
+  labels:
+    app: weighted
+`})
+	adscConn := s.Connect(nil, nil, watchEds)
+	expected := map[string]uint32{
+		"a":       9, // sum of 8 and 1
+		"b":       3,
+		"1.1.1.1": 8,
+		"2.2.2.2": 1, // no weight provided is normalized to 1
+		"3.3.3.3": 3,
+	}
+	weights := func(cla *endpoint.ClusterLoadAssignment) map[string]uint32 {
+		got := make(map[string]uint32)
+		for _, lbe := range cla.GetEndpoints() {
+			got[lbe.Locality.Region] = lbe.LoadBalancingWeight.GetValue()
+			for _, e := range lbe.LbEndpoints {
+				got[e.GetEndpoint().Address.GetSocketAddress().Address] = e.LoadBalancingWeight.GetValue()
+			}
+		}
+		return got
+	}
+
+	// STATIC resolution uses EDS.
+	if got := weights(adscConn.GetEndpoints()["outbound|80||weighted.static.svc.cluster.local"]); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected EDS LB weights %v got %v", expected, got)
+	}
+	// DNS resolution inlines the endpoints in the cluster.
+	c := adscConn.GetClusters()["outbound|80||weighted.dns.svc.cluster.local"]
+	if c == nil {
+		t.Fatalf("No cluster for %v", "outbound|80||weighted.dns.svc.cluster.local")
+	}
+	if got := weights(c.LoadAssignment); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected DNS cluster LB weights %v got %v", expected, got)
+	}
+}
+
 var (
 	watchEds = []string{v3.ClusterType, v3.EndpointType}
 	watchAll = []string{v3.ClusterType, v3.EndpointType, v3.ListenerType, v3.RouteType}
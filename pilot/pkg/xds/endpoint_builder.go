@@ -17,6 +17,7 @@ package xds
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"net"
 	"sort"
 	"strconv"
 
@@ -29,8 +30,10 @@ import (
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/loadbalancer"
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/security/authn/factory"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/host"
@@ -70,6 +73,13 @@ type EndpointBuilder struct {
 	service         *model.Service
 	clusterLocal    bool
 	tunnelType      networking.TunnelType
+	// nodeName is only set when the service restricts clients to the endpoints on their node.
+	nodeName string
+	// slimTLSMode omits the tlsMode metadata of the endpoints, as the cluster does not use auto mTLS. It is the
+	// only metadata omitted: EDS is already built per cluster port, and the other metadata may be read by filters.
+	slimTLSMode bool
+	// supportsIPv4 and supportsIPv6 are the IP families of the proxy, only set with dual-stack support.
+	supportsIPv4, supportsIPv6 bool
 
 	// These fields are provided for convenience only
 	subsetName string
@@ -106,6 +116,17 @@ func NewEndpointBuilder(clusterName string, proxy *model.Proxy, push *model.Push
 		hostname:   hostname,
 		port:       port,
 	}
+	if svc != nil && svc.Attributes.NodeLocal {
+		b.nodeName = proxyNodeName(proxy)
+	}
+	if features.EnableDualStack {
+		b.supportsIPv4, b.supportsIPv6 = proxy.SupportsIPv4(), proxy.SupportsIPv6()
+	}
+	// Auto mTLS matches the transport socket on the tlsMode metadata of the endpoints, but it only applies
+	// to clusters without TLS settings. Otherwise the metadata is dead weight in every endpoint.
+	if features.EnableEDSTLSModeSlimming {
+		b.slimTLSMode = clusterTLSMode(dr, subsetName, port) != nil
+	}
 
 	// We need this for multi-network, or for clusters meant for use with AUTO_PASSTHROUGH.
 	if features.EnableAutomTLSCheckPolicies ||
@@ -131,6 +152,11 @@ func (b EndpointBuilder) Key() string {
 		strconv.FormatBool(b.clusterLocal),
 		util.LocalityToString(b.locality),
 		b.tunnelType.ToString(),
+		b.nodeName,
+		strconv.FormatBool(b.slimTLSMode),
+	}
+	if b.supportsIPv4 || b.supportsIPv6 {
+		params = append(params, strconv.FormatBool(b.supportsIPv4), strconv.FormatBool(b.supportsIPv6))
 	}
 	if b.push != nil && b.push.AuthnPolicies != nil {
 		params = append(params, b.push.AuthnPolicies.GetVersion())
@@ -181,6 +207,63 @@ func (b EndpointBuilder) DependentTypes() []config.GroupVersionKind {
 	return edsDependentTypes
 }
 
+func (b EndpointBuilder) ResourceType() string {
+	return v3.EndpointType
+}
+
+// proxyNodeName returns the Kubernetes node of the proxy, from its metadata or else from its endpoints.
+func proxyNodeName(proxy *model.Proxy) string {
+	if proxy.Metadata != nil && proxy.Metadata.NodeName != "" {
+		return proxy.Metadata.NodeName
+	}
+	for _, si := range proxy.ServiceInstances {
+		if si.Endpoint != nil && si.Endpoint.NodeName != "" {
+			return si.Endpoint.NodeName
+		}
+	}
+	return ""
+}
+
+// filterByTrafficPolicy applies the Kubernetes internalTrafficPolicy and topology aware hints of the
+// service to the endpoints of a shard.
+func (b *EndpointBuilder) filterByTrafficPolicy(endpoints []*model.IstioEndpoint) []*model.IstioEndpoint {
+	if b.service == nil {
+		return endpoints
+	}
+	if b.service.Attributes.NodeLocal {
+		// Without knowing the node of the proxy we cannot tell which endpoints are local.
+		if b.nodeName == "" {
+			return endpoints
+		}
+		out := make([]*model.IstioEndpoint, 0, len(endpoints))
+		for _, ep := range endpoints {
+			if ep.NodeName == b.nodeName && ep.Locality.ClusterID == b.clusterID {
+				out = append(out, ep)
+			}
+		}
+		return out
+	}
+	if b.service.Attributes.TopologyAware && b.locality.GetZone() != "" {
+		out := make([]*model.IstioEndpoint, 0, len(endpoints))
+		for _, ep := range endpoints {
+			// As kube-proxy does, the hints are ignored unless every endpoint has them.
+			if len(ep.ZoneHints) == 0 {
+				return endpoints
+			}
+			for _, zone := range ep.ZoneHints {
+				if zone == b.locality.GetZone() {
+					out = append(out, ep)
+					break
+				}
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+	return endpoints
+}
+
 func (b *EndpointBuilder) canViewNetwork(network network.ID) bool {
 	if b.networkView == nil {
 		return true
@@ -252,9 +335,7 @@ func (e *LocLbEndpointsAndOptions) refreshWeight() {
 		weight = nil
 	} else {
 		weight = &wrappers.UInt32Value{}
-		for _, lbEp := range e.llbEndpoints.LbEndpoints {
-			weight.Value += lbEp.GetLoadBalancingWeight().Value
-		}
+		weight.Value, _ = loadbalancer.LocalityWeight(e.llbEndpoints.LbEndpoints)
 	}
 	e.llbEndpoints.LoadBalancingWeight = weight
 }
@@ -293,7 +374,7 @@ func (b *EndpointBuilder) buildLocalityLbEndpointsFromShards(
 	}
 	// The shards are updated independently, now need to filter and merge for this cluster
 	for _, shardKey := range keys {
-		endpoints := shards.Shards[shardKey]
+		endpoints := b.filterByTrafficPolicy(shards.Shards[shardKey])
 		// If the downstream service is configured as cluster-local, only include endpoints that
 		// reside in the same cluster.
 		if isClusterLocal && (shardKey.Cluster() != b.clusterID) {
@@ -340,7 +421,7 @@ func (b *EndpointBuilder) buildLocalityLbEndpointsFromShards(
 					}
 				}
 			}
-			locLbEps.append(ep, ep.EnvoyEndpoint, ep.TunnelAbility)
+			locLbEps.append(ep, b.envoyEndpointForProxy(ep), ep.TunnelAbility)
 		}
 	}
 	shards.mutex.Unlock()
@@ -355,9 +436,9 @@ func (b *EndpointBuilder) buildLocalityLbEndpointsFromShards(
 	}
 	for _, k := range locs {
 		locLbEps := localityEpMap[k]
-		var weight uint32
-		for _, ep := range locLbEps.llbEndpoints.LbEndpoints {
-			weight += ep.LoadBalancingWeight.GetValue()
+		weight, overflow := loadbalancer.LocalityWeight(locLbEps.llbEndpoints.LbEndpoints)
+		if overflow {
+			log.Warnf("Sum of localityLbEndpoints weight is overflow: cluster:%s, locality:%s", b.clusterName, k)
 		}
 		locLbEps.llbEndpoints.LoadBalancingWeight = &wrappers.UInt32Value{
 			Value: weight,
@@ -391,6 +472,13 @@ func (b *EndpointBuilder) ApplyTunnelSetting(llbOpts []*LocLbEndpointsAndOptions
 func (b *EndpointBuilder) createClusterLoadAssignment(llbOpts []*LocLbEndpointsAndOptions) *endpoint.ClusterLoadAssignment {
 	llbEndpoints := make([]*endpoint.LocalityLbEndpoints, 0, len(llbOpts))
 	for _, l := range llbOpts {
+		if b.slimTLSMode {
+			for i, ep := range l.llbEndpoints.LbEndpoints {
+				if nep, modified := util.MaybeApplyTLSModeLabel(ep, ""); modified {
+					l.llbEndpoints.LbEndpoints[i] = nep
+				}
+			}
+		}
 		llbEndpoints = append(llbEndpoints, &l.llbEndpoints)
 	}
 	return &endpoint.ClusterLoadAssignment{
@@ -399,6 +487,35 @@ func (b *EndpointBuilder) createClusterLoadAssignment(llbOpts []*LocLbEndpointsA
 	}
 }
 
+// envoyEndpointForProxy returns the Envoy endpoint of a dual-stack endpoint on one of its additional addresses if the
+// proxy does not support the IP family of its address, but supports the family of the additional address.
+func (b *EndpointBuilder) envoyEndpointForProxy(ep *model.IstioEndpoint) *endpoint.LbEndpoint {
+	if len(ep.AdditionalAddresses) == 0 || (!b.supportsIPv4 && !b.supportsIPv6) || b.supportsAddress(ep.Address) {
+		return ep.EnvoyEndpoint
+	}
+	for _, address := range ep.AdditionalAddresses {
+		if b.supportsAddress(address) {
+			// The Envoy endpoint is shared by all the proxies, so it is copied before being modified.
+			lbEp := proto.Clone(ep.EnvoyEndpoint).(*endpoint.LbEndpoint)
+			lbEp.GetEndpoint().Address = util.BuildAddress(address, ep.EndpointPort)
+			return lbEp
+		}
+	}
+	return ep.EnvoyEndpoint
+}
+
+// supportsAddress returns whether the proxy supports the IP family of the address.
+func (b *EndpointBuilder) supportsAddress(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return true
+	}
+	if ip.To4() != nil {
+		return b.supportsIPv4
+	}
+	return b.supportsIPv6
+}
+
 // buildEnvoyLbEndpoint packs the endpoint based on istio info.
 func buildEnvoyLbEndpoint(e *model.IstioEndpoint) *endpoint.LbEndpoint {
 	addr := util.BuildAddress(e.Address, e.EndpointPort)
@@ -477,7 +594,7 @@ func (c *mtlsChecker) computeForEndpoint(ep *model.IstioEndpoint) {
 	if drMode := c.mtlsModeForDestinationRule(ep); drMode != nil {
 		switch *drMode {
 		case networkingapi.ClientTLSSettings_DISABLE:
-			c.mtlsDisabledHosts[lbEpKey(ep.EnvoyEndpoint)] = struct{}{}
+			c.disableMtls(ep)
 			return
 		case networkingapi.ClientTLSSettings_ISTIO_MUTUAL:
 			// don't mark this EP disabled, even if PA or tlsMode meta mark disabled
@@ -487,7 +604,7 @@ func (c *mtlsChecker) computeForEndpoint(ep *model.IstioEndpoint) {
 
 	// if endpoint has no sidecar or explicitly tls disabled by "security.istio.io/tlsMode" label.
 	if ep.TLSMode != model.IstioMutualTLSModeLabel {
-		c.mtlsDisabledHosts[lbEpKey(ep.EnvoyEndpoint)] = struct{}{}
+		c.disableMtls(ep)
 		return
 	}
 
@@ -506,7 +623,15 @@ func (c *mtlsChecker) computeForEndpoint(ep *model.IstioEndpoint) {
 
 	//  mtls disabled by PeerAuthentication
 	if mtlsDisabledByPeerAuthentication(ep) {
-		c.mtlsDisabledHosts[lbEpKey(ep.EnvoyEndpoint)] = struct{}{}
+		c.disableMtls(ep)
+	}
+}
+
+// disableMtls records that mTLS is disabled for the endpoint, on all its addresses.
+func (c *mtlsChecker) disableMtls(ep *model.IstioEndpoint) {
+	c.mtlsDisabledHosts[lbEpKey(ep.EnvoyEndpoint)] = struct{}{}
+	for _, address := range ep.AdditionalAddresses {
+		c.mtlsDisabledHosts[address+":"+strconv.Itoa(int(ep.EndpointPort))] = struct{}{}
 	}
 }
 
@@ -547,6 +672,24 @@ func mtlsModeForDefaultTrafficPolicy(destinationRule *config.Config, port int) *
 	return trafficPolicyTLSModeForPort(dr.GetTrafficPolicy(), port)
 }
 
+// clusterTLSMode returns the TLS mode the DestinationRule sets for the cluster of the subset and port, or nil
+// if the cluster has no TLS settings.
+func clusterTLSMode(destinationRule *config.Config, subsetName string, port int) *networkingapi.ClientTLSSettings_TLSmode {
+	mode := mtlsModeForDefaultTrafficPolicy(destinationRule, port)
+	if destinationRule == nil || subsetName == "" {
+		return mode
+	}
+	for _, subset := range destinationRule.Spec.(*networkingapi.DestinationRule).GetSubsets() {
+		if subset.Name == subsetName {
+			if subsetMode := trafficPolicyTLSModeForPort(subset.TrafficPolicy, port); subsetMode != nil {
+				mode = subsetMode
+			}
+			break
+		}
+	}
+	return mode
+}
+
 func trafficPolicyTLSModeForPort(tp *networkingapi.TrafficPolicy, port int) *networkingapi.ClientTLSSettings_TLSmode {
 	if tp == nil {
 		return nil
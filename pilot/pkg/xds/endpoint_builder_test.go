@@ -0,0 +1,222 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"reflect"
+	"testing"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	networkingapi "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config"
+)
+
+func TestFilterByTrafficPolicy(t *testing.T) {
+	ep := func(address, node string, zones ...string) *model.IstioEndpoint {
+		return &model.IstioEndpoint{
+			Address:   address,
+			NodeName:  node,
+			ZoneHints: zones,
+			Locality:  model.Locality{ClusterID: "cluster-1"},
+		}
+	}
+	endpoints := []*model.IstioEndpoint{
+		ep("10.0.0.1", "node-1", "zone-a"),
+		ep("10.0.0.2", "node-2", "zone-b"),
+		ep("10.0.0.3", "node-1", "zone-b"),
+	}
+
+	cases := []struct {
+		name          string
+		nodeLocal     bool
+		topologyAware bool
+		nodeName      string
+		zone          string
+		endpoints     []*model.IstioEndpoint
+		want          []string
+	}{
+		{
+			name:      "no policy",
+			nodeName:  "node-1",
+			zone:      "zone-a",
+			endpoints: endpoints,
+			want:      []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		{
+			name:      "node local",
+			nodeLocal: true,
+			nodeName:  "node-1",
+			endpoints: endpoints,
+			want:      []string{"10.0.0.1", "10.0.0.3"},
+		},
+		{
+			name:      "node local without local endpoints",
+			nodeLocal: true,
+			nodeName:  "node-3",
+			endpoints: endpoints,
+			want:      []string{},
+		},
+		{
+			name:      "node local with unknown node",
+			nodeLocal: true,
+			endpoints: endpoints,
+			want:      []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		{
+			name:          "topology aware",
+			topologyAware: true,
+			zone:          "zone-b",
+			endpoints:     endpoints,
+			want:          []string{"10.0.0.2", "10.0.0.3"},
+		},
+		{
+			name:          "topology aware without hints for the zone",
+			topologyAware: true,
+			zone:          "zone-c",
+			endpoints:     endpoints,
+			want:          []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		{
+			name:          "topology aware with endpoint missing hints",
+			topologyAware: true,
+			zone:          "zone-a",
+			endpoints:     append([]*model.IstioEndpoint{ep("10.0.0.4", "node-3")}, endpoints...),
+			want:          []string{"10.0.0.4", "10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &EndpointBuilder{
+				service:   &model.Service{Attributes: model.ServiceAttributes{NodeLocal: tt.nodeLocal, TopologyAware: tt.topologyAware}},
+				clusterID: cluster.ID("cluster-1"),
+				locality:  &core.Locality{Zone: tt.zone},
+				nodeName:  tt.nodeName,
+			}
+			got := []string{}
+			for _, ep := range b.filterByTrafficPolicy(tt.endpoints) {
+				got = append(got, ep.Address)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterTLSMode(t *testing.T) {
+	dr := &config.Config{
+		Meta: config.Meta{Name: "dr", Namespace: "default"},
+		Spec: &networkingapi.DestinationRule{
+			TrafficPolicy: &networkingapi.TrafficPolicy{
+				PortLevelSettings: []*networkingapi.TrafficPolicy_PortTrafficPolicy{{
+					Port: &networkingapi.PortSelector{Number: 8080},
+					Tls:  &networkingapi.ClientTLSSettings{Mode: networkingapi.ClientTLSSettings_DISABLE},
+				}},
+			},
+			Subsets: []*networkingapi.Subset{
+				{
+					Name: "v1",
+					TrafficPolicy: &networkingapi.TrafficPolicy{
+						Tls: &networkingapi.ClientTLSSettings{Mode: networkingapi.ClientTLSSettings_ISTIO_MUTUAL},
+					},
+				},
+				{Name: "v2"},
+			},
+		},
+	}
+	disable, istioMutual := networkingapi.ClientTLSSettings_DISABLE, networkingapi.ClientTLSSettings_ISTIO_MUTUAL
+
+	cases := []struct {
+		name   string
+		dr     *config.Config
+		subset string
+		port   int
+		want   *networkingapi.ClientTLSSettings_TLSmode
+	}{
+		{name: "no destination rule", port: 8080},
+		{name: "port without tls", dr: dr, port: 9090},
+		{name: "port level tls", dr: dr, port: 8080, want: &disable},
+		{name: "subset tls", dr: dr, subset: "v1", port: 9090, want: &istioMutual},
+		{name: "subset without tls", dr: dr, subset: "v2", port: 8080, want: &disable},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clusterTLSMode(tt.dr, tt.subset, tt.port)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateClusterLoadAssignmentSlimTLSMode(t *testing.T) {
+	ep := &model.IstioEndpoint{
+		Address:      "10.0.0.1",
+		EndpointPort: 8080,
+		TLSMode:      model.IstioMutualTLSModeLabel,
+	}
+	ep.EnvoyEndpoint = buildEnvoyLbEndpoint(ep)
+
+	for _, slim := range []bool{false, true} {
+		llb := &LocLbEndpointsAndOptions{}
+		llb.append(ep, ep.EnvoyEndpoint, ep.TunnelAbility)
+		b := &EndpointBuilder{clusterName: "outbound|8080||foo.default.svc.cluster.local", slimTLSMode: slim}
+		cla := b.createClusterLoadAssignment([]*LocLbEndpointsAndOptions{llb})
+
+		got := cla.Endpoints[0].LbEndpoints[0].GetMetadata().GetFilterMetadata()[util.EnvoyTransportSocketMetadataKey] != nil
+		if got == slim {
+			t.Fatalf("slimTLSMode %v: got tlsMode metadata %v", slim, got)
+		}
+	}
+	if ep.EnvoyEndpoint.Metadata.FilterMetadata[util.EnvoyTransportSocketMetadataKey] == nil {
+		t.Fatalf("shared endpoint was modified")
+	}
+}
+
+func TestEnvoyEndpointForProxy(t *testing.T) {
+	ep := &model.IstioEndpoint{
+		Address:             "10.0.0.1",
+		AdditionalAddresses: []string{"fd00::1"},
+		EndpointPort:        8080,
+	}
+	ep.EnvoyEndpoint = buildEnvoyLbEndpoint(ep)
+
+	cases := []struct {
+		name                       string
+		supportsIPv4, supportsIPv6 bool
+		want                       string
+	}{
+		{name: "dual stack disabled", want: "10.0.0.1"},
+		{name: "ipv4", supportsIPv4: true, want: "10.0.0.1"},
+		{name: "ipv6", supportsIPv6: true, want: "fd00::1"},
+		{name: "dual stack", supportsIPv4: true, supportsIPv6: true, want: "10.0.0.1"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &EndpointBuilder{supportsIPv4: tt.supportsIPv4, supportsIPv6: tt.supportsIPv6}
+			got := b.envoyEndpointForProxy(ep).GetEndpoint().GetAddress().GetSocketAddress()
+			if got.GetAddress() != tt.want || got.GetPortValue() != 8080 {
+				t.Fatalf("got %v, want %v:8080", got, tt.want)
+			}
+		})
+	}
+	if ep.EnvoyEndpoint.GetEndpoint().GetAddress().GetSocketAddress().GetAddress() != "10.0.0.1" {
+		t.Fatalf("shared endpoint was modified")
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/pmezard/go-difflib/difflib"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/validation"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+// maxEnvoyFilterDryRunSize bounds the size of the EnvoyFilter passed to the dry run.
+const maxEnvoyFilterDryRunSize = 1 << 20
+
+// The changes of a resource reported by the EnvoyFilter dry run.
+const (
+	ResourceAdded    = "added"
+	ResourceRemoved  = "removed"
+	ResourceModified = "modified"
+)
+
+// EnvoyFilterDryRun is the config of a proxy changed by applying an EnvoyFilter.
+type EnvoyFilterDryRun struct {
+	ProxyID string `json:"proxy"`
+	// EnvoyFilter is the namespace/name of the EnvoyFilter. It replaces the existing EnvoyFilter with the same name.
+	EnvoyFilter string         `json:"envoy_filter"`
+	Listeners   []ResourceDiff `json:"listeners"`
+	Routes      []ResourceDiff `json:"routes"`
+	Clusters    []ResourceDiff `json:"clusters"`
+}
+
+// ResourceDiff is a resource added, removed or modified by an EnvoyFilter, with the unified diff of its JSON.
+type ResourceDiff struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+	Diff   string `json:"diff"`
+}
+
+// EnvoyFilterDryRun applies the EnvoyFilter passed in the request body, or in the envoyfilter query param, to the
+// config generated for the specified proxy, and returns the listeners, routes and clusters it changes. The
+// EnvoyFilter is only applied in memory: neither the config store, the xDS cache nor the proxies see it.
+func (s *DiscoveryServer) EnvoyFilterDryRun(w http.ResponseWriter, req *http.Request) {
+	proxyID, con := s.getDebugConnection(req)
+	if con == nil {
+		s.errorHandler(w, proxyID, con)
+		return
+	}
+	input := req.URL.Query().Get("envoyfilter")
+	if req.Method == http.MethodPost {
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxEnvoyFilterDryRunSize))
+		if err != nil {
+			handleHTTPError(w, err)
+			return
+		}
+		input = string(body)
+	}
+	ef, err := parseDryRunEnvoyFilter(input, con.proxy.ConfigNamespace)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error() + "\n"))
+		return
+	}
+
+	push := s.globalPushContext()
+	patched, err := s.envoyFilterDryRunPushContext(push, ef)
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	before, err := s.dryRunResources(con, push)
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	after, err := s.dryRunResources(con, patched)
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	writeJSON(w, EnvoyFilterDryRun{
+		ProxyID:     proxyID,
+		EnvoyFilter: ef.Namespace + "/" + ef.Name,
+		Listeners:   diffResources(before.listeners, after.listeners),
+		Routes:      diffResources(before.routes, after.routes),
+		Clusters:    diffResources(before.clusters, after.clusters),
+	})
+}
+
+// parseDryRunEnvoyFilter parses and validates a single EnvoyFilter, in YAML or JSON. It defaults to the namespace of
+// the proxy.
+func parseDryRunEnvoyFilter(input, namespace string) (config.Config, error) {
+	if input == "" {
+		return config.Config{}, fmt.Errorf("you must provide an EnvoyFilter in the request body or the envoyfilter query string")
+	}
+	configs, _, err := crd.ParseInputs(input)
+	if err != nil {
+		return config.Config{}, fmt.Errorf("failed to parse the EnvoyFilter: %v", err)
+	}
+	if len(configs) != 1 || configs[0].GroupVersionKind != gvk.EnvoyFilter {
+		return config.Config{}, fmt.Errorf("expected a single EnvoyFilter")
+	}
+	ef := configs[0]
+	if ef.Namespace == "" {
+		ef.Namespace = namespace
+	}
+	if _, err := validation.ValidateEnvoyFilter(ef); err != nil {
+		return config.Config{}, fmt.Errorf("invalid EnvoyFilter: %v", err)
+	}
+	return ef, nil
+}
+
+// envoyFilterDryRunPushContext returns a push context updated from push as if the EnvoyFilter was added to the
+// config store, replacing the one with the same name, if any.
+func (s *DiscoveryServer) envoyFilterDryRunPushContext(push *model.PushContext, ef config.Config) (*model.PushContext, error) {
+	env := *s.Env
+	env.IstioConfigStore = &envoyFilterDryRunStore{IstioConfigStore: s.Env.IstioConfigStore, envoyFilter: ef}
+	out := model.NewPushContext()
+	out.PushVersion = push.PushVersion
+	out.JwtKeyResolver = push.JwtKeyResolver
+	req := &model.PushRequest{
+		Full:           true,
+		ConfigsUpdated: map[model.ConfigKey]struct{}{{Kind: gvk.EnvoyFilter, Name: ef.Name, Namespace: ef.Namespace}: {}},
+		Reason:         []model.TriggerReason{model.DebugTrigger},
+	}
+	if err := out.InitContext(&env, push, req); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// envoyFilterDryRunStore lists an EnvoyFilter with the EnvoyFilters of the config store.
+type envoyFilterDryRunStore struct {
+	model.IstioConfigStore
+	envoyFilter config.Config
+}
+
+func (s *envoyFilterDryRunStore) List(typ config.GroupVersionKind, namespace string) ([]config.Config, error) {
+	configs, err := s.IstioConfigStore.List(typ, namespace)
+	if err != nil || typ != gvk.EnvoyFilter || (namespace != model.NamespaceAll && namespace != s.envoyFilter.Namespace) {
+		return configs, err
+	}
+	out := make([]config.Config, 0, len(configs)+1)
+	for _, cfg := range configs {
+		if cfg.Name != s.envoyFilter.Name || cfg.Namespace != s.envoyFilter.Namespace {
+			out = append(out, cfg)
+		}
+	}
+	return append(out, s.envoyFilter), nil
+}
+
+type dryRunResources struct {
+	listeners, routes, clusters map[string]string
+}
+
+// dryRunResources generates the listeners, routes and clusters of the proxy with the push context, as indented JSON
+// keyed by name. It does not use the xDS cache, whose entries do not depend on the content of the EnvoyFilters.
+func (s *DiscoveryServer) dryRunResources(con *Connection, push *model.PushContext) (dryRunResources, error) {
+	out := dryRunResources{listeners: map[string]string{}, routes: map[string]string{}, clusters: map[string]string{}}
+	req := &model.PushRequest{Push: push, Start: time.Now()}
+	for _, l := range s.uncachedConfigGenerator.BuildListeners(con.proxy, push) {
+		if err := addDryRunResource(out.listeners, l.Name, l); err != nil {
+			return out, err
+		}
+	}
+	routes, _ := s.uncachedConfigGenerator.BuildHTTPRoutes(con.proxy, req, con.Routes())
+	if err := addDryRunResources(out.routes, routes); err != nil {
+		return out, err
+	}
+	clusters, _ := s.uncachedConfigGenerator.BuildClusters(con.proxy, req)
+	if err := addDryRunResources(out.clusters, clusters); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+func addDryRunResources(out map[string]string, resources []*discovery.Resource) error {
+	for _, r := range resources {
+		if err := addDryRunResource(out, r.Name, r.Resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addDryRunResource(out map[string]string, name string, resource proto.Message) error {
+	js, err := protomarshal.ToJSONWithIndent(resource, "  ")
+	if err != nil {
+		return err
+	}
+	out[name] = js
+	return nil
+}
+
+// diffResources returns the resources added, removed or modified between before and after, sorted by name.
+func diffResources(before, after map[string]string) []ResourceDiff {
+	out := []ResourceDiff{}
+	for name, b := range before {
+		a, f := after[name]
+		switch {
+		case !f:
+			out = append(out, ResourceDiff{Name: name, Change: ResourceRemoved, Diff: unifiedDiff(name, b, "")})
+		case a != b:
+			out = append(out, ResourceDiff{Name: name, Change: ResourceModified, Diff: unifiedDiff(name, b, a)})
+		}
+	}
+	for name, a := range after {
+		if _, f := before[name]; !f {
+			out = append(out, ResourceDiff{Name: name, Change: ResourceAdded, Diff: unifiedDiff(name, "", a)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func unifiedDiff(name, before, after string) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: name + " (current)",
+		ToFile:   name + " (with EnvoyFilter)",
+		Context:  3,
+	})
+	return diff
+}
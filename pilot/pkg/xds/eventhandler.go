@@ -35,5 +35,8 @@ type DistributionStatusCache interface {
 	// RegisterEvent notifies the implementer of an xDS ACK, and must be non-blocking
 	RegisterEvent(conID string, eventType EventType, nonce string)
 	RegisterDisconnect(s string, types []EventType)
+	// RegisterRejection notifies the implementer of an xDS NACK, or of an ACK following a NACK of the same type
+	// when rejection is nil, and must be non-blocking
+	RegisterRejection(conID string, eventType EventType, rejection *Rejection)
 	QueryLastNonce(conID string, eventType EventType) (noncePrefix string)
 }
@@ -101,6 +101,7 @@ type FakeOptions struct {
 	EnableFakeXDSUpdater       bool
 	DisableSecretAuthorization bool
 	Services                   []*model.Service
+	Instances                  []*model.ServiceInstance
 	Gateways                   []model.NetworkGateway
 }
 
@@ -131,6 +132,9 @@ func NewFakeDiscoveryServer(t test.Failer, opts FakeOptions) *FakeDiscoveryServe
 	s := NewDiscoveryServer(&model.Environment{PushContext: model.NewPushContext()}, []string{plugin.AuthzCustom, plugin.Authn, plugin.Authz},
 		"pilot-123", "istio-system", map[string]string{})
 	s.InitGenerators(s.Env, "istio-system")
+	if err := s.InitConfigExport(s.Env, features.ConfigExportReaders); err != nil {
+		t.Fatal(err)
+	}
 	t.Cleanup(func() {
 		s.JwtKeyResolver.Close()
 		s.pushQueue.ShutDown()
@@ -222,6 +226,7 @@ func NewFakeDiscoveryServer(t test.Failer, opts FakeOptions) *FakeDiscoveryServe
 		SkipRun:             true,
 		ClusterID:           defaultKubeController.Cluster(),
 		Services:            opts.Services,
+		Instances:           opts.Instances,
 		Gateways:            opts.Gateways,
 	})
 	cg.ServiceEntryRegistry.AppendServiceHandler(serviceHandler)
@@ -345,6 +350,20 @@ func NewFakeDiscoveryServer(t test.Failer, opts FakeOptions) *FakeDiscoveryServe
 	return fake
 }
 
+// FakeOptions returns the options of a fake server reproducing the snapshot, to generate the config of any proxy
+// offline, for example:
+//
+//	s := NewFakeDiscoveryServer(t, snap.FakeOptions())
+//	listeners := s.Listeners(s.SetupProxy(&model.Proxy{...}))
+func (snap *Snapshot) FakeOptions() FakeOptions {
+	return FakeOptions{
+		MeshConfig: snap.Mesh,
+		Configs:    snap.Configs,
+		Services:   snap.Services,
+		Instances:  snap.Instances,
+	}
+}
+
 func (f *FakeDiscoveryServer) KubeClient() kubelib.Client {
 	return f.kubeClient
 }
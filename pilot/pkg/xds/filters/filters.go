@@ -15,12 +15,19 @@
 package filters
 
 import (
+	"net"
+	"strconv"
+
 	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	ratelimitconfig "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
 	cors "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/cors/v3"
 	fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
 	grpcstats "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_stats/v3"
 	grpcweb "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_web/v3"
+	localratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
+	ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
 	router "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
 	httpwasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
 	httpinspector "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/http_inspector/v3"
@@ -30,12 +37,16 @@ import (
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/wasm/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	alpn "istio.io/api/envoy/config/filter/http/alpn/v2alpha1"
 	"istio.io/api/envoy/config/filter/network/metadata_exchange"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/pkg/log"
 )
 
 const (
@@ -48,6 +59,12 @@ const (
 	MxFilterName          = "istio.metadata_exchange"
 	StatsFilterName       = "istio.stats"
 	StackdriverFilterName = "istio.stackdriver"
+
+	// LocalRateLimitStatPrefix is the prefix of the statistics of the local rate limit filter.
+	LocalRateLimitStatPrefix = "http_local_rate_limiter"
+	// GlobalRateLimitStage is the stage of the rate limits of the routes sent to the global rate limit service,
+	// which keeps them apart from the rate limits of the local rate limit filter.
+	GlobalRateLimitStage = 1
 )
 
 // Define static filters to be reused across the codebase. This avoids duplicate marshaling/unmarshaling
@@ -65,6 +82,13 @@ var (
 			TypedConfig: util.MessageToAny(&fault.HTTPFault{}),
 		},
 	}
+	// LocalRateLimit does not limit requests by itself, only the routes configuring it do.
+	LocalRateLimit = &hcm.HttpFilter{
+		Name: util.LocalRateLimitFilter,
+		ConfigType: &hcm.HttpFilter_TypedConfig{
+			TypedConfig: util.MessageToAny(&localratelimit.LocalRateLimit{StatPrefix: LocalRateLimitStatPrefix}),
+		},
+	}
 	Router = &hcm.HttpFilter{
 		Name: wellknown.Router,
 		ConfigType: &hcm.HttpFilter_TypedConfig{
@@ -166,6 +190,77 @@ func BuildRouterFilter(ctx *RouterFilterContext) *hcm.HttpFilter {
 	}
 }
 
+// rateLimitService is the host and port of a rate limit service.
+type rateLimitService struct {
+	hostname host.Name
+	port     int
+}
+
+// globalRateLimitService is PILOT_GLOBAL_RATE_LIMIT_SERVICE, parsed once so that an invalid value is only logged
+// once. Its hostname is empty if the service is not set or invalid.
+var globalRateLimitService = parseRateLimitService(features.GlobalRateLimitService)
+
+func parseRateLimitService(value string) rateLimitService {
+	if value == "" {
+		return rateLimitService{}
+	}
+	h, p, err := net.SplitHostPort(value)
+	if err != nil {
+		log.Warnf("invalid global rate limit service %q: %v", value, err)
+		return rateLimitService{}
+	}
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		log.Warnf("invalid global rate limit service port %q: %v", p, err)
+		return rateLimitService{}
+	}
+	return rateLimitService{hostname: host.Name(h), port: port}
+}
+
+// BuildGlobalRateLimitFilter builds the rate limit filter sending the descriptors of the routes to the rate limit
+// service at the host:port of PILOT_GLOBAL_RATE_LIMIT_SERVICE, through its outbound cluster. It returns nil if the
+// service is not set or invalid, or if the proxy does not import the service port, as it would reject the filter
+// without its cluster.
+func BuildGlobalRateLimitFilter(node *model.Proxy, push *model.PushContext) *hcm.HttpFilter {
+	return buildRateLimitFilter(globalRateLimitService, node, push)
+}
+
+func buildRateLimitFilter(rls rateLimitService, node *model.Proxy, push *model.PushContext) *hcm.HttpFilter {
+	if rls.hostname == "" {
+		return nil
+	}
+	svc := push.ServiceForHostname(node, rls.hostname)
+	if svc == nil {
+		log.Debugf("global rate limit service %s is not visible to the proxy", rls.hostname)
+		return nil
+	}
+	if _, f := svc.Ports.GetByPort(rls.port); !f {
+		log.Debugf("global rate limit service %s has no port %d", rls.hostname, rls.port)
+		return nil
+	}
+	return &hcm.HttpFilter{
+		Name: wellknown.HTTPRateLimit,
+		ConfigType: &hcm.HttpFilter_TypedConfig{
+			TypedConfig: util.MessageToAny(&ratelimit.RateLimit{
+				Domain:          features.GlobalRateLimitDomain,
+				Stage:           GlobalRateLimitStage,
+				FailureModeDeny: features.GlobalRateLimitFailClosed,
+				Timeout:         durationpb.New(features.GlobalRateLimitTimeout),
+				RateLimitService: &ratelimitconfig.RateLimitServiceConfig{
+					GrpcService: &core.GrpcService{
+						TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+								ClusterName: model.BuildSubsetKey(model.TrafficDirectionOutbound, "", svc.Hostname, rls.port),
+							},
+						},
+					},
+					TransportApiVersion: core.ApiVersion_V3,
+				},
+			}),
+		},
+	}
+}
+
 var (
 	// These ALPNs are injected in the client side by the ALPN filter.
 	// "istio" is added for each upstream protocol in order to make it
@@ -0,0 +1,81 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filters
+
+import (
+	"testing"
+
+	ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+func TestParseRateLimitService(t *testing.T) {
+	cases := []struct {
+		value string
+		want  rateLimitService
+	}{
+		{value: ""},
+		{value: "ratelimit.istio-system.svc.cluster.local:8081", want: rateLimitService{
+			hostname: "ratelimit.istio-system.svc.cluster.local",
+			port:     8081,
+		}},
+		{value: "ratelimit.istio-system.svc.cluster.local"},
+		{value: "ratelimit.istio-system.svc.cluster.local:grpc"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRateLimitService(tt.value); got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildRateLimitFilter(t *testing.T) {
+	push := model.NewPushContext()
+	hostname := host.Name("ratelimit.istio-system.svc.cluster.local")
+	push.ServiceIndex.HostnameAndNamespace[hostname] = map[string]*model.Service{
+		"istio-system": {
+			Hostname: hostname,
+			Ports:    model.PortList{{Name: "grpc", Port: 8081, Protocol: protocol.GRPC}},
+		},
+	}
+	proxy := &model.Proxy{ID: "app.default"}
+
+	filter := buildRateLimitFilter(rateLimitService{hostname: hostname, port: 8081}, proxy, push)
+	if filter == nil {
+		t.Fatal("expected a rate limit filter")
+	}
+	cfg := &ratelimit.RateLimit{}
+	if err := filter.GetTypedConfig().UnmarshalTo(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.RateLimitService.GrpcService.GetEnvoyGrpc().ClusterName, "outbound|8081||"+string(hostname); got != want {
+		t.Fatalf("got cluster %s, want %s", got, want)
+	}
+
+	for name, rls := range map[string]rateLimitService{
+		"not set":      {},
+		"unknown host": {hostname: "other.istio-system.svc.cluster.local", port: 8081},
+		"unknown port": {hostname: hostname, port: 8082},
+	} {
+		if filter := buildRateLimitFilter(rls, proxy, push); filter != nil {
+			t.Errorf("%s: expected no filter, got %v", name, filter)
+		}
+	}
+}
@@ -39,6 +39,8 @@ var skippedLdsConfigs = map[model.NodeType]map[config.GroupVersionKind]struct{}{
 		gvk.ProxyConfig:   {},
 	},
 	model.SidecarProxy: {
+		// destination rules select the hash policy and the protocol filters of the outbound tcp filter chains, whose
+		// changes are flagged by ListenersUpdated
 		gvk.Gateway:         {},
 		gvk.DestinationRule: {},
 		gvk.WorkloadGroup:   {},
@@ -57,7 +59,7 @@ func ldsNeedsPush(proxy *model.Proxy, req *model.PushRequest) bool {
 		return false
 	}
 	// If none set, we will always push
-	if len(req.ConfigsUpdated) == 0 {
+	if len(req.ConfigsUpdated) == 0 || req.ListenersUpdated {
 		return true
 	}
 	for config := range req.ConfigsUpdated {
@@ -14,13 +14,17 @@
 package xds
 
 import (
+	"strings"
 	"sync"
 	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/pkg/monitoring"
 )
@@ -31,6 +35,8 @@ var (
 	typeTag    = monitoring.MustCreateLabel("type")
 	versionTag = monitoring.MustCreateLabel("version")
 
+	proxyTypeTag = monitoring.MustCreateLabel("proxy_type")
+
 	// pilot_total_xds_rejects should be used instead. This is for backwards compatibility
 	cdsReject = monitoring.NewGauge(
 		"pilot_xds_cds_reject",
@@ -91,6 +97,11 @@ var (
 		"Total services known to pilot.",
 	)
 
+	retriesReduced = monitoring.NewGauge(
+		"pilot_retries_reduced",
+		"Whether the default retries are reduced, as the mesh-wide rate of 5xx responses is high.",
+	)
+
 	// TODO: Update all the resource stats in separate routine
 	// virtual services, destination rules, gateways, etc.
 	xdsClients = monitoring.NewGauge(
@@ -189,8 +200,34 @@ var (
 		// Important boundaries: 10K, 1M, 4M, 10M, 40M
 		// 4M default limit for gRPC, 10M config will start to strain system,
 		// 40M is likely upper-bound on config sizes supported.
-		[]float64{1, 10000, 1000000, 4000000, 10000000, 40000000},
+		// Intermediate boundaries give usable percentiles for typical sizes.
+		[]float64{1, 10000, 100000, 500000, 1000000, 2000000, 4000000, 10000000, 20000000, 40000000},
+		monitoring.WithLabels(typeTag),
+		monitoring.WithUnit(monitoring.Bytes),
+	)
+
+	configSizeThresholdExceeded = monitoring.NewSum(
+		"pilot_xds_config_size_threshold_exceeded",
+		"Number of times the size of the configuration pushed to a proxy exceeded the threshold of its type.",
 		monitoring.WithLabels(typeTag),
+	)
+
+	// configSizeThresholds are the PILOT_XDS_CONFIG_SIZE_THRESHOLDS, in bytes, by type URL.
+	configSizeThresholds = parseConfigSizeThresholds(features.XDSConfigSizeThresholds)
+
+	generatorCPUTime = monitoring.NewDistribution(
+		"pilot_xds_generator_cpu_seconds",
+		"CPU time in seconds spent by an xDS generator to generate the configuration of a proxy.",
+		[]float64{.001, .01, .05, .1, .5, 1, 5},
+		monitoring.WithLabels(typeTag, proxyTypeTag),
+	)
+
+	generatorAllocBytes = monitoring.NewDistribution(
+		"pilot_xds_generator_alloc_bytes",
+		"Memory allocated by an xDS generator to generate the configuration of a proxy. Only generations that did "+
+			"not overlap with others are recorded.",
+		[]float64{10000, 100000, 1000000, 10000000, 100000000},
+		monitoring.WithLabels(typeTag, proxyTypeTag),
 		monitoring.WithUnit(monitoring.Bytes),
 	)
 )
@@ -271,6 +308,53 @@ func incrementXDSRejects(xdsType string, node, errCode string) {
 	}
 }
 
+// parseConfigSizeThresholds parses the PILOT_XDS_CONFIG_SIZE_THRESHOLDS value, in the form
+// "lds=4Mi,cds=10Mi". Invalid thresholds are logged and ignored.
+func parseConfigSizeThresholds(spec string) map[string]int {
+	thresholds := map[string]int{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			log.Warnf("ignoring invalid config size threshold %q: expected type=quantity", item)
+			continue
+		}
+		typeURL := ""
+		for _, t := range synczTypes {
+			if v3.GetMetricType(t) == strings.ToLower(strings.TrimSpace(kv[0])) {
+				typeURL = t
+			}
+		}
+		if typeURL == "" {
+			log.Warnf("ignoring invalid config size threshold %q: unsupported type", item)
+			continue
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(kv[1]))
+		if err != nil || q.Sign() <= 0 {
+			log.Warnf("ignoring invalid config size threshold %q: expected a positive quantity", item)
+			continue
+		}
+		thresholds[typeURL] = int(q.Value())
+	}
+	return thresholds
+}
+
+// checkConfigSizeThreshold reports a response to a proxy whose size exceeds the threshold of its type. Only
+// responses crossing the threshold are reported, so a proxy with a large configuration is not reported on every push.
+func checkConfigSizeThreshold(proxyID, typeURL string, previousSize, size int) bool {
+	threshold, f := configSizeThresholds[typeURL]
+	if !f || size <= threshold || previousSize > threshold {
+		return false
+	}
+	log.Warnf("%s: config size %s for node:%s exceeds the threshold of %s",
+		v3.GetShortType(typeURL), util.ByteCount(size), proxyID, util.ByteCount(threshold))
+	configSizeThresholdExceeded.With(typeTag.Value(v3.GetMetricType(typeURL))).Increment()
+	return true
+}
+
 func recordSendTime(duration time.Duration) {
 	sendTime.Record(duration.Seconds())
 }
@@ -289,6 +373,7 @@ func init() {
 		xdsExpiredNonce,
 		totalXDSRejects,
 		monServices,
+		retriesReduced,
 		xdsClients,
 		xdsResponseWriteTimeouts,
 		pushes,
@@ -304,5 +389,8 @@ func init() {
 		totalDelayedPushTimeouts,
 		pilotSDSCertificateErrors,
 		configSizeBytes,
+		configSizeThresholdExceeded,
+		generatorCPUTime,
+		generatorAllocBytes,
 	)
 }
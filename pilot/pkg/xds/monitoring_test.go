@@ -0,0 +1,83 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"reflect"
+	"testing"
+
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+func TestParseConfigSizeThresholds(t *testing.T) {
+	cases := []struct {
+		spec string
+		want map[string]int
+	}{
+		{"", map[string]int{}},
+		{"lds=4Mi", map[string]int{v3.ListenerType: 4 * 1024 * 1024}},
+		{" CDS = 10M , rds=500k", map[string]int{v3.ClusterType: 10000000, v3.RouteType: 500000}},
+		{"lds=4Mi,foo=1Mi,cds,eds=-1,rds=bad", map[string]int{v3.ListenerType: 4 * 1024 * 1024}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.spec, func(t *testing.T) {
+			if got := parseConfigSizeThresholds(tt.spec); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckConfigSizeThreshold(t *testing.T) {
+	defaultValue := configSizeThresholds
+	configSizeThresholds = map[string]int{v3.ListenerType: 1000}
+	defer func() { configSizeThresholds = defaultValue }()
+
+	cases := []struct {
+		name         string
+		typeURL      string
+		previousSize int
+		size         int
+		want         bool
+	}{
+		{"below threshold", v3.ListenerType, 0, 1000, false},
+		{"crosses threshold", v3.ListenerType, 0, 1001, true},
+		{"already above threshold", v3.ListenerType, 2000, 3000, false},
+		{"crosses threshold again", v3.ListenerType, 500, 3000, true},
+		{"no threshold", v3.ClusterType, 0, 3000, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkConfigSizeThreshold("proxy", tt.typeURL, tt.previousSize, tt.size); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigSizePercentiles(t *testing.T) {
+	sizes := make([]int, 0, 100)
+	for i := 100; i > 0; i-- {
+		sizes = append(sizes, i)
+	}
+	want := ConfigSizePercentiles{P50: 50, P90: 90, P99: 99, Max: 100}
+	if got := configSizePercentiles(sizes); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	want = ConfigSizePercentiles{P50: 7, P90: 7, P99: 7, Max: 7}
+	if got := configSizePercentiles([]int{7}); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
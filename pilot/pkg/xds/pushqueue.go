@@ -27,8 +27,11 @@ type PushQueue struct {
 	// the PushRequest will be merged.
 	pending map[*Connection]*model.PushRequest
 
-	// queue maintains ordering of the queue
-	queue []*Connection
+	// queues maintain ordering of the queue, one per priority class, highest priority first.
+	queues [][]*Connection
+
+	// priority returns the priority class of a connection, the index of its queue.
+	priority func(con *Connection) int
 
 	// processing stores all connections that have been Dequeue(), but not MarkDone().
 	// The value stored will be initially be nil, but may be populated if the connection is Enqueue().
@@ -39,11 +42,59 @@ type PushQueue struct {
 }
 
 func NewPushQueue() *PushQueue {
+	return NewPriorityPushQueue(nil)
+}
+
+// NewPriorityPushQueue returns a queue which dequeues the connections of the proxies of the given types first, in
+// the order of the types, so that proxies such as gateways converge before the others during large pushes.
+// Connections of the same priority are dequeued in order. Lower priorities are not guaranteed to make progress: they
+// are only dequeued while no connection of a higher priority is queued.
+func NewPriorityPushQueue(types []model.NodeType) *PushQueue {
 	return &PushQueue{
 		pending:    make(map[*Connection]*model.PushRequest),
 		processing: make(map[*Connection]*model.PushRequest),
 		cond:       sync.NewCond(&sync.Mutex{}),
+		queues:     make([][]*Connection, len(types)+1),
+		priority: func(con *Connection) int {
+			if con.proxy != nil {
+				for i, t := range types {
+					if con.proxy.Type == t {
+						return i
+					}
+				}
+			}
+			return len(types)
+		},
+	}
+}
+
+func (p *PushQueue) push(con *Connection) {
+	priority := p.priority(con)
+	p.queues[priority] = append(p.queues[priority], con)
+}
+
+// pop removes the first connection of the highest priority, or returns nil if the queue is empty.
+func (p *PushQueue) pop() *Connection {
+	for i, queue := range p.queues {
+		if len(queue) == 0 {
+			continue
+		}
+		con := queue[0]
+		// The underlying array will still exist, despite the slice changing, so the object may not GC without this
+		// See https://github.com/grpc/grpc-go/issues/4758
+		queue[0] = nil
+		p.queues[i] = queue[1:]
+		return con
+	}
+	return nil
+}
+
+func (p *PushQueue) len() int {
+	n := 0
+	for _, queue := range p.queues {
+		n += len(queue)
 	}
+	return n
 }
 
 // Enqueue will mark a proxy as pending a push. If it is already pending, pushInfo will be merged.
@@ -68,7 +119,7 @@ func (p *PushQueue) Enqueue(con *Connection, pushRequest *model.PushRequest) {
 	}
 
 	p.pending[con] = pushRequest
-	p.queue = append(p.queue, con)
+	p.push(con)
 	// Signal waiters on Dequeue that a new item is available
 	p.cond.Signal()
 }
@@ -79,21 +130,16 @@ func (p *PushQueue) Dequeue() (con *Connection, request *model.PushRequest, shut
 	defer p.cond.L.Unlock()
 
 	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.queue) == 0 && !p.shuttingDown {
+	for p.len() == 0 && !p.shuttingDown {
 		p.cond.Wait()
 	}
 
-	if len(p.queue) == 0 {
+	con = p.pop()
+	if con == nil {
 		// We must be shutting down.
 		return nil, nil, true
 	}
 
-	con = p.queue[0]
-	// The underlying array will still exist, despite the slice changing, so the object may not GC without this
-	// See https://github.com/grpc/grpc-go/issues/4758
-	p.queue[0] = nil
-	p.queue = p.queue[1:]
-
 	request = p.pending[con]
 	delete(p.pending, con)
 
@@ -113,7 +159,7 @@ func (p *PushQueue) MarkDone(con *Connection) {
 	// This means we need to add it back to the queue.
 	if request != nil {
 		p.pending[con] = request
-		p.queue = append(p.queue, con)
+		p.push(con)
 		p.cond.Signal()
 	}
 }
@@ -122,7 +168,7 @@ func (p *PushQueue) MarkDone(con *Connection) {
 func (p *PushQueue) Pending() int {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	return len(p.queue)
+	return p.len()
 }
 
 // ShutDown will cause queue to ignore all new items added to it. As soon as the
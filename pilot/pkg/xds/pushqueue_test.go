@@ -371,6 +371,61 @@ func TestProxyQueue(t *testing.T) {
 	})
 }
 
+func TestPriorityPushQueue(t *testing.T) {
+	sidecar := func(id string) *Connection {
+		return &Connection{ConID: id, proxy: &model.Proxy{Type: model.SidecarProxy}}
+	}
+	router := func(id string) *Connection {
+		return &Connection{ConID: id, proxy: &model.Proxy{Type: model.Router}}
+	}
+	s1, s2, r1, r2 := sidecar("s1"), sidecar("s2"), router("r1"), router("r2")
+
+	t.Run("routers first", func(t *testing.T) {
+		p := NewPriorityPushQueue([]model.NodeType{model.Router})
+		defer p.ShutDown()
+		for _, con := range []*Connection{s1, r1, s2, r2} {
+			p.Enqueue(con, &model.PushRequest{})
+		}
+		if p.Pending() != 4 {
+			t.Fatalf("expected 4 pending, got %d", p.Pending())
+		}
+		for _, con := range []*Connection{r1, r2, s1, s2} {
+			ExpectDequeue(t, p, con)
+		}
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("requeued router before queued sidecars", func(t *testing.T) {
+		p := NewPriorityPushQueue([]model.NodeType{model.Router})
+		defer p.ShutDown()
+		p.Enqueue(r1, &model.PushRequest{})
+		ExpectDequeue(t, p, r1)
+		p.Enqueue(s1, &model.PushRequest{})
+		p.Enqueue(r1, &model.PushRequest{})
+		p.MarkDone(r1)
+		ExpectDequeue(t, p, r1)
+		ExpectDequeue(t, p, s1)
+	})
+
+	t.Run("no priority", func(t *testing.T) {
+		p := NewPushQueue()
+		defer p.ShutDown()
+		for _, con := range []*Connection{s1, r1, s2, r2} {
+			p.Enqueue(con, &model.PushRequest{})
+		}
+		for _, con := range []*Connection{s1, r1, s2, r2} {
+			ExpectDequeue(t, p, con)
+		}
+	})
+}
+
+func TestPushPriority(t *testing.T) {
+	got := pushPriority("router, gateway,,sidecar")
+	if want := []model.NodeType{model.Router, model.SidecarProxy}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
 // TestPushQueueLeak is a regression test for https://github.com/grpc/grpc-go/issues/4758
 func TestPushQueueLeak(t *testing.T) {
 	ds := NewFakeDiscoveryServer(t, FakeOptions{})
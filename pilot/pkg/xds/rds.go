@@ -24,7 +24,7 @@ type RdsGenerator struct {
 	Server *DiscoveryServer
 }
 
-var _ model.XdsResourceGenerator = &RdsGenerator{}
+var _ model.XdsDeltaResourceGenerator = &RdsGenerator{}
 
 // Map of all configs that do not impact RDS
 var skippedRdsConfigs = map[config.GroupVersionKind]struct{}{
@@ -35,7 +35,6 @@ var skippedRdsConfigs = map[config.GroupVersionKind]struct{}{
 	gvk.PeerAuthentication:    {},
 	gvk.Secret:                {},
 	gvk.WasmPlugin:            {},
-	gvk.Telemetry:             {},
 	gvk.ProxyConfig:           {},
 }
 
@@ -67,3 +66,13 @@ func (c RdsGenerator) Generate(proxy *model.Proxy, push *model.PushContext, w *m
 	resources, logDetails := c.Server.ConfigGenerator.BuildHTTPRoutes(proxy, req, w.ResourceNames)
 	return resources, logDetails, nil
 }
+
+// GenerateDeltas for RDS only builds the routes affected by ServiceEntry changes; other routes are left unchanged.
+func (c RdsGenerator) GenerateDeltas(proxy *model.Proxy, push *model.PushContext, updates *model.PushRequest,
+	w *model.WatchedResource) (model.Resources, model.DeletedResources, model.XdsLogDetails, bool, error) {
+	if !rdsNeedsPush(updates) {
+		return nil, nil, model.DefaultXdsLogDetails, false, nil
+	}
+	resources, logDetails, usedDelta := c.Server.ConfigGenerator.BuildDeltaHTTPRoutes(proxy, updates, w)
+	return resources, nil, logDetails, usedDelta, nil
+}
@@ -0,0 +1,171 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+// Rejection is a response of the control plane rejected by a proxy.
+type Rejection struct {
+	// Proxy is the ID of the proxy which rejected the response.
+	Proxy string
+	// Message is the error detail of the NACK.
+	Message string
+	// Configs are the configs the rejected resources were generated from.
+	Configs []config.Meta
+}
+
+// reportRejection notifies the StatusReporter of a NACK of the response with the nonce if errorDetail is set, or of
+// an ACK of a type the connection rejected before otherwise. The proxy keeps sending the nonce of the rejected response
+// in its requests until it receives another response, and delta requests may have no nonce at all, so only the ACK of
+// another nonce clears the rejection.
+func (s *DiscoveryServer) reportRejection(con *Connection, typeURL, nonce string, errorDetail *rpcstatus.Status) {
+	if s.StatusReporter == nil {
+		return
+	}
+	if _, f := AllEventTypes[typeURL]; !f {
+		return
+	}
+	if errorDetail == nil {
+		if rejected, f := con.rejectedTypes[typeURL]; f && nonce != "" && rejected != nonce {
+			delete(con.rejectedTypes, typeURL)
+			s.StatusReporter.RegisterRejection(con.ConID, typeURL, nil)
+		}
+		return
+	}
+	con.rejectedTypes[typeURL] = nonce
+	s.StatusReporter.RegisterRejection(con.ConID, typeURL, &Rejection{
+		Proxy:   con.proxy.ID,
+		Message: errorDetail.GetMessage(),
+		Configs: rejectedConfigs(con.proxy, s.globalPushContext(), typeURL, errorDetail.GetMessage()),
+	})
+}
+
+// rejectedConfigs returns the configs the rejected resources of the type pushed to the proxy were generated from:
+// the virtual services and gateways for listeners and routes, and the destination rules for clusters and endpoints.
+// Envoy names the rejected resources in the error message, so only the configs of the hosts named there are
+// returned. Nothing is returned when the message names none of them, rather than blaming unrelated configs.
+func rejectedConfigs(proxy *model.Proxy, push *model.PushContext, typeURL string, message string) []config.Meta {
+	var configs []config.Config
+	switch typeURL {
+	case v3.ListenerType, v3.RouteType:
+		if proxy.Type == model.Router {
+			if proxy.MergedGateway == nil {
+				return nil
+			}
+			gateways := map[string]struct{}{}
+			for _, name := range proxy.MergedGateway.GatewayNameForServer {
+				gateways[name] = struct{}{}
+			}
+			for name := range gateways {
+				if gw := push.Gateway(name); gw != nil {
+					configs = append(configs, *gw)
+				}
+				configs = append(configs, push.VirtualServicesForGateway(proxy.ConfigNamespace, name)...)
+			}
+		} else if proxy.SidecarScope != nil {
+			for _, l := range proxy.SidecarScope.EgressListeners {
+				configs = append(configs, l.VirtualServices()...)
+			}
+		}
+	case v3.ClusterType, v3.EndpointType:
+		if proxy.SidecarScope == nil {
+			return nil
+		}
+		for _, svc := range proxy.SidecarScope.Services() {
+			if dr := proxy.SidecarScope.DestinationRule(svc.Hostname); dr != nil {
+				configs = append(configs, *dr)
+			}
+		}
+	}
+	if len(configs) == 0 {
+		return nil
+	}
+
+	hosts := rejectedHosts(message)
+	var matched []config.Meta
+	seen := map[model.ConfigKey]struct{}{}
+	for _, cfg := range configs {
+		key := model.ConfigKey{Kind: cfg.GroupVersionKind, Name: cfg.Name, Namespace: cfg.Namespace}
+		if _, f := seen[key]; f {
+			continue
+		}
+		seen[key] = struct{}{}
+		for _, h := range configHosts(cfg) {
+			if _, f := hosts[h]; f {
+				matched = append(matched, cfg.Meta)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// rejectedHosts returns the hosts of the resources named in the error message of a NACK: the host of the cluster
+// names, such as outbound|80||foo.example.com, and of the route and virtual host names, such as foo.example.com:80.
+func rejectedHosts(message string) map[string]struct{} {
+	hosts := map[string]struct{}{}
+	words := strings.FieldsFunc(message, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",;'\"()[]{}", r)
+	})
+	for _, w := range words {
+		w = strings.TrimRight(w, ":.")
+		if parts := strings.Split(w, "|"); len(parts) == 4 {
+			w = parts[3]
+		} else if i := strings.LastIndex(w, ":"); i > 0 {
+			if _, err := strconv.Atoi(w[i+1:]); err == nil {
+				w = w[:i]
+			}
+		}
+		if w != "" {
+			hosts[w] = struct{}{}
+		}
+	}
+	return hosts
+}
+
+// configHosts returns the hosts of a virtual service, gateway or destination rule.
+func configHosts(cfg config.Config) []string {
+	switch cfg.GroupVersionKind {
+	case gvk.VirtualService:
+		return cfg.Spec.(*networking.VirtualService).Hosts
+	case gvk.DestinationRule:
+		return []string{cfg.Spec.(*networking.DestinationRule).Host}
+	case gvk.Gateway:
+		var hosts []string
+		for _, server := range cfg.Spec.(*networking.Gateway).Servers {
+			for _, h := range server.Hosts {
+				// Gateway hosts may be prefixed with the namespace they import virtual services from.
+				if i := strings.Index(h, "/"); i >= 0 {
+					h = h[i+1:]
+				}
+				hosts = append(hosts, h)
+			}
+		}
+		return hosts
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+)
+
+const rejectionConfig = `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: services
+  namespace: default
+spec:
+  hosts:
+  - foo.example.com
+  - bar.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: foo
+  namespace: default
+spec:
+  hosts:
+  - foo.example.com
+  gateways:
+  - gateway
+  - mesh
+  http:
+  - route:
+    - destination:
+        host: foo.example.com
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: bar
+  namespace: default
+spec:
+  hosts:
+  - bar.example.com
+  http:
+  - route:
+    - destination:
+        host: bar.example.com
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: foo
+  namespace: default
+spec:
+  host: foo.example.com
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: bar
+  namespace: default
+spec:
+  host: bar.example.com
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: Gateway
+metadata:
+  name: gateway
+  namespace: default
+spec:
+  selector:
+    istio: ingressgateway
+  servers:
+  - port:
+      number: 80
+      name: http
+      protocol: HTTP
+    hosts:
+    - "*/foo.example.com"
+`
+
+func configNames(configs []config.Meta) []string {
+	var names []string
+	for _, c := range configs {
+		names = append(names, c.GroupVersionKind.Kind+"/"+c.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRejectedConfigs(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{ConfigString: rejectionConfig})
+	sidecar := s.SetupProxy(nil)
+	gateway := s.SetupProxy(&model.Proxy{
+		Type:     model.Router,
+		Metadata: &model.NodeMetadata{Labels: map[string]string{"istio": "ingressgateway"}},
+	})
+	cases := []struct {
+		name    string
+		proxy   *model.Proxy
+		typeURL string
+		message string
+		want    []string
+	}{
+		{
+			name:    "sidecar listeners",
+			proxy:   sidecar,
+			typeURL: v3.ListenerType,
+			message: "Error adding/updating listener(s) 0.0.0.0_80: invalid",
+		},
+		{
+			name:    "sidecar route of a host suffix",
+			proxy:   sidecar,
+			typeURL: v3.RouteType,
+			message: "Proto constraint validation failed: route www.foo.example.com:80 is invalid",
+		},
+		{
+			name:    "sidecar route of a host",
+			proxy:   sidecar,
+			typeURL: v3.RouteType,
+			message: "Proto constraint validation failed: route foo.example.com:80 is invalid",
+			want:    []string{"VirtualService/foo"},
+		},
+		{
+			name:    "sidecar cluster of a host",
+			proxy:   sidecar,
+			typeURL: v3.ClusterType,
+			message: "Error adding/updating cluster(s) outbound|80||bar.example.com: invalid",
+			want:    []string{"DestinationRule/bar"},
+		},
+		{
+			name:    "gateway listeners",
+			proxy:   gateway,
+			typeURL: v3.ListenerType,
+			message: "Error adding/updating listener(s) 0.0.0.0_8080: invalid",
+		},
+		{
+			name:    "gateway route of a host",
+			proxy:   gateway,
+			typeURL: v3.RouteType,
+			message: "Error adding/updating route(s) http.8080: virtual host 'foo.example.com:80' is invalid",
+			want:    []string{"Gateway/gateway", "VirtualService/foo"},
+		},
+		{
+			name:    "secrets",
+			proxy:   sidecar,
+			typeURL: v3.SecretType,
+			message: "invalid secret",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := configNames(rejectedConfigs(tt.proxy, s.PushContext(), tt.typeURL, tt.message))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got configs %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeRejectionReporter struct {
+	DistributionStatusCache
+	rejections map[string]*Rejection
+	calls      int
+}
+
+func (f *fakeRejectionReporter) RegisterRejection(conID string, eventType EventType, rejection *Rejection) {
+	f.calls++
+	f.rejections[conID+"~"+eventType] = rejection
+}
+
+func TestReportRejection(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{ConfigString: rejectionConfig})
+	reporter := &fakeRejectionReporter{rejections: map[string]*Rejection{}}
+	s.Discovery.StatusReporter = reporter
+	con := &Connection{ConID: "con", proxy: s.SetupProxy(nil), rejectedTypes: map[string]string{}}
+
+	// ACKs are not reported until a NACK.
+	s.Discovery.reportRejection(con, v3.ClusterType, "1", nil)
+	if reporter.calls != 0 {
+		t.Fatalf("expected no report of an ACK, got %v", reporter.rejections)
+	}
+
+	s.Discovery.reportRejection(con, v3.ClusterType, "2", &rpcstatus.Status{Message: "outbound|80||foo.example.com: invalid"})
+	got := reporter.rejections["con~"+v3.ClusterType]
+	if got == nil || got.Proxy != "app.test" || !reflect.DeepEqual(configNames(got.Configs), []string{"DestinationRule/foo"}) {
+		t.Fatalf("unexpected rejection %+v", got)
+	}
+
+	// Requests without error detail still carrying the rejected nonce, such as subscription changes, do not accept
+	// the rejected response.
+	s.Discovery.reportRejection(con, v3.ClusterType, "2", nil)
+	s.Discovery.reportRejection(con, v3.ClusterType, "", nil)
+	if reporter.calls != 1 || reporter.rejections["con~"+v3.ClusterType] == nil {
+		t.Fatalf("expected the rejection to be kept, got %v", reporter.rejections)
+	}
+
+	s.Discovery.reportRejection(con, v3.ClusterType, "3", nil)
+	if reporter.calls != 2 || reporter.rejections["con~"+v3.ClusterType] != nil {
+		t.Fatalf("expected the ACK to clear the rejection, got %v", reporter.rejections)
+	}
+	s.Discovery.reportRejection(con, v3.ClusterType, "3", nil)
+	if reporter.calls != 2 {
+		t.Fatal("expected a single report of the ACK")
+	}
+
+	// Types which are not tracked are not reported.
+	s.Discovery.reportRejection(con, v3.SecretType, "4", &rpcstatus.Status{Message: "invalid secret"})
+	if reporter.calls != 2 {
+		t.Fatalf("expected no report of a secret NACK, got %v", reporter.rejections)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/route/retry"
+)
+
+// meshErrorRateQuery is the mesh-wide ratio of 5xx responses, as reported by the clients.
+const meshErrorRateQuery = `sum(rate(istio_requests_total{reporter="source",response_code=~"5.."}[1m])) / ` +
+	`sum(rate(istio_requests_total{reporter="source"}[1m]))`
+
+// RetrySafety reduces the default retries of the mesh while the mesh-wide rate of 5xx responses is high, so that
+// retries do not amplify upstream failures into retry storms. Retry policies set on VirtualServices are not changed.
+type RetrySafety struct {
+	server *DiscoveryServer
+	// errorRate returns the mesh-wide ratio of 5xx responses.
+	errorRate func(ctx context.Context) (float64, error)
+	// threshold is the error rate above which the retries are reduced. They are restored below half of it, so that
+	// they do not flap around the threshold.
+	threshold float64
+	interval  time.Duration
+}
+
+// NewRetrySafety creates a RetrySafety querying the error rate from the Prometheus server at the address.
+func NewRetrySafety(s *DiscoveryServer, prometheusAddress string) (*RetrySafety, error) {
+	client, err := api.NewClient(api.Config{Address: prometheusAddress})
+	if err != nil {
+		return nil, fmt.Errorf("could not build prometheus client: %v", err)
+	}
+	promAPI := promv1.NewAPI(client)
+	return &RetrySafety{
+		server: s,
+		errorRate: func(ctx context.Context) (float64, error) {
+			val, _, err := promAPI.Query(ctx, meshErrorRateQuery, time.Now())
+			if err != nil {
+				return 0, err
+			}
+			v, ok := val.(prommodel.Vector)
+			if !ok {
+				return 0, fmt.Errorf("unexpected result type %v", val.Type())
+			}
+			if len(v) == 0 {
+				return 0, nil
+			}
+			return float64(v[0].Value), nil
+		},
+		threshold: features.RetrySafetyErrorRate,
+		interval:  features.RetrySafetyInterval,
+	}, nil
+}
+
+// Run checks the error rate periodically until stop is closed.
+func (r *RetrySafety) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+			r.check(ctx)
+			cancel()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check queries the error rate, and pushes the routes again if the default retries are reduced or restored.
+func (r *RetrySafety) check(ctx context.Context) {
+	rate, err := r.errorRate(ctx)
+	if err != nil {
+		log.Warnf("failed to query the mesh error rate: %v", err)
+		return
+	}
+	if math.IsNaN(rate) {
+		// There was no request.
+		rate = 0
+	}
+	reduce := retry.Reduced()
+	switch {
+	case rate > r.threshold:
+		reduce = true
+	case rate < r.threshold/2:
+		reduce = false
+	}
+	if !retry.SetReduced(reduce) {
+		return
+	}
+	if reduce {
+		log.Warnf("mesh error rate %.3f is above %.3f, reducing the default retries to %d",
+			rate, r.threshold, features.RetrySafetyAttempts)
+		retriesReduced.Record(1)
+	} else {
+		log.Infof("mesh error rate %.3f is below %.3f, restoring the default retries", rate, r.threshold/2)
+		retriesReduced.Record(0)
+	}
+	// The routes are cached, so the push clears the cache.
+	r.server.ConfigUpdate(&model.PushRequest{
+		Full:   true,
+		Reason: []model.TriggerReason{model.GlobalUpdate},
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/route/retry"
+)
+
+func TestRetrySafetyCheck(t *testing.T) {
+	defer retry.SetReduced(false)
+	s := NewFakeDiscoveryServer(t, FakeOptions{})
+	var rate float64
+	var rateErr error
+	r := &RetrySafety{
+		server: s.Discovery,
+		errorRate: func(ctx context.Context) (float64, error) {
+			return rate, rateErr
+		},
+		threshold: 0.2,
+		interval:  time.Second,
+	}
+	steps := []struct {
+		rate    float64
+		err     error
+		reduced bool
+	}{
+		{rate: 0.05, reduced: false},
+		{rate: math.NaN(), reduced: false},
+		{rate: 0.3, reduced: true},
+		// Between half the threshold and the threshold, the retries stay reduced.
+		{rate: 0.15, reduced: true},
+		{rate: 0, err: fmt.Errorf("unavailable"), reduced: true},
+		{rate: 0.05, reduced: false},
+		{rate: 0.15, reduced: false},
+	}
+	for i, step := range steps {
+		rate, rateErr = step.rate, step.err
+		r.check(context.Background())
+		if got := retry.Reduced(); got != step.reduced {
+			t.Fatalf("step %d: expected reduced %v, got %v", i, step.reduced, got)
+		}
+	}
+}
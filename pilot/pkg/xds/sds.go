@@ -30,9 +30,11 @@ import (
 	"istio.io/istio/pilot/pkg/model/credentials"
 	"istio.io/istio/pilot/pkg/networking/util"
 	securitymodel "istio.io/istio/pilot/pkg/security/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/security"
 )
 
 // SecretResource wraps the authnmodel type with cache functions implemented
@@ -55,6 +57,10 @@ func (sr SecretResource) Cacheable() bool {
 	return true
 }
 
+func (sr SecretResource) ResourceType() string {
+	return v3.SecretType
+}
+
 func sdsNeedsPush(proxy *model.Proxy, updates model.XdsUpdates) bool {
 	if proxy.Type != model.Router {
 		return false
@@ -155,6 +161,16 @@ func (s *SecretGen) generate(sr SecretResource, configClusterSecrets, proxyClust
 		secretController = proxyClusterSecrets
 	}
 
+	if name, key, ok := oauth2SecretKey(sr.Name); ok {
+		value, err := secretController.GetSecretValue(name, sr.Namespace, key)
+		if err != nil {
+			pilotSDSCertificateErrors.Increment()
+			log.Warnf("failed to fetch oauth2 secret for %s: %v", sr.ResourceName, err)
+			return nil
+		}
+		return toEnvoyGenericSecret(sr.ResourceName, value)
+	}
+
 	isCAOnlySecret := strings.HasSuffix(sr.Name, securitymodel.SdsCaSuffix)
 	if isCAOnlySecret {
 		caCert, err := secretController.GetCaCert(sr.Name, sr.Namespace)
@@ -189,6 +205,17 @@ func (s *SecretGen) generate(sr SecretResource, configClusterSecrets, proxyClust
 	return res
 }
 
+// oauth2SecretKey returns the Secret name and key referenced by an OAuth2 sds resource name.
+func oauth2SecretKey(name string) (string, string, bool) {
+	if strings.HasSuffix(name, securitymodel.SdsOAuth2TokenSuffix) {
+		return strings.TrimSuffix(name, securitymodel.SdsOAuth2TokenSuffix), security.OAuth2ClientSecretKey, true
+	}
+	if strings.HasSuffix(name, securitymodel.SdsOAuth2HmacSuffix) {
+		return strings.TrimSuffix(name, securitymodel.SdsOAuth2HmacSuffix), security.OAuth2HmacSecretKey, true
+	}
+	return "", "", false
+}
+
 func validateCertificate(data []byte) error {
 	block, _ := pem.Decode(data)
 	if block == nil {
@@ -304,6 +331,25 @@ func toEnvoyCaSecret(name string, cert []byte) *discovery.Resource {
 	}
 }
 
+func toEnvoyGenericSecret(name string, value []byte) *discovery.Resource {
+	res := util.MessageToAny(&envoytls.Secret{
+		Name: name,
+		Type: &envoytls.Secret_GenericSecret{
+			GenericSecret: &envoytls.GenericSecret{
+				Secret: &core.DataSource{
+					Specifier: &core.DataSource_InlineBytes{
+						InlineBytes: value,
+					},
+				},
+			},
+		},
+	})
+	return &discovery.Resource{
+		Name:     name,
+		Resource: res,
+	}
+}
+
 func toEnvoyKeyCertSecret(name string, key, cert []byte) *discovery.Resource {
 	res := util.MessageToAny(&envoytls.Secret{
 		Name: name,
@@ -342,8 +388,13 @@ func containsAny(mp map[model.ConfigKey]struct{}, keys []model.ConfigKey) bool {
 // the -cacert suffix. By including this dependency we ensure we do not miss any updates.
 // This is important for cases where we have a compound secret. In this case, the `foo` secret may update,
 // but we need to push both the `foo` and `foo-cacert` resource name, or they will fall out of sync.
+// OAuth2 resource names are always read from the Secret without their suffix.
 func relatedConfigs(k model.ConfigKey) []model.ConfigKey {
 	related := []model.ConfigKey{k}
+	if name, _, ok := oauth2SecretKey(k.Name); ok {
+		k.Name = name
+		return append(related, k)
+	}
 	// For secret without -cacert suffix, add the suffix
 	if !strings.HasSuffix(k.Name, securitymodel.SdsCaSuffix) {
 		k.Name += securitymodel.SdsCaSuffix
@@ -35,6 +35,7 @@ import (
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/security"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/spiffe"
 	"istio.io/istio/pkg/test/env"
@@ -72,6 +73,10 @@ var (
 	genericMtlsCertSplitCa = makeSecret("generic-mtls-split-cacert", map[string]string{
 		credentials.GenericScrtCaCert: readFile(filepath.Join(certDir, "mountedcerts-client/root-cert.pem")),
 	})
+	oauth2Secret = makeSecret("oauth2", map[string]string{
+		security.OAuth2ClientSecretKey: "client-secret",
+		security.OAuth2HmacSecretKey:   "hmac-secret",
+	})
 )
 
 func readFile(name string) string {
@@ -81,9 +86,10 @@ func readFile(name string) string {
 
 func TestGenerate(t *testing.T) {
 	type Expected struct {
-		Key    string
-		Cert   string
-		CaCert string
+		Key     string
+		Cert    string
+		CaCert  string
+		Generic string
 	}
 	allResources := []string{
 		"kubernetes://generic", "kubernetes://generic-mtls", "kubernetes://generic-mtls-cacert",
@@ -248,6 +254,16 @@ func TestGenerate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "oauth2",
+			proxy:     &model.Proxy{VerifiedIdentity: &spiffe.Identity{Namespace: "istio-system"}, Type: model.Router},
+			resources: []string{"kubernetes://oauth2-oauth2-token", "kubernetes://oauth2-oauth2-hmac", "kubernetes://generic-oauth2-hmac"},
+			request:   &model.PushRequest{Full: true},
+			expect: map[string]Expected{
+				"kubernetes://oauth2-oauth2-token": {Generic: "client-secret"},
+				"kubernetes://oauth2-oauth2-hmac":  {Generic: "hmac-secret"},
+			},
+		},
 		{
 			// proxy without authorization
 			name:      "unauthorized",
@@ -268,7 +284,7 @@ func TestGenerate(t *testing.T) {
 			}
 			tt.proxy.Metadata.ClusterID = "Kubernetes"
 			s := NewFakeDiscoveryServer(t, FakeOptions{
-				KubernetesObjects: []runtime.Object{genericCert, genericMtlsCert, genericMtlsCertSplit, genericMtlsCertSplitCa},
+				KubernetesObjects: []runtime.Object{genericCert, genericMtlsCert, genericMtlsCertSplit, genericMtlsCertSplitCa, oauth2Secret},
 			})
 			cc := s.KubeClient().Kube().(*fake.Clientset)
 
@@ -289,9 +305,10 @@ func TestGenerate(t *testing.T) {
 			got := map[string]Expected{}
 			for _, scrt := range raw {
 				got[scrt.Name] = Expected{
-					Key:    string(scrt.GetTlsCertificate().GetPrivateKey().GetInlineBytes()),
-					Cert:   string(scrt.GetTlsCertificate().GetCertificateChain().GetInlineBytes()),
-					CaCert: string(scrt.GetValidationContext().GetTrustedCa().GetInlineBytes()),
+					Key:     string(scrt.GetTlsCertificate().GetPrivateKey().GetInlineBytes()),
+					Cert:    string(scrt.GetTlsCertificate().GetCertificateChain().GetInlineBytes()),
+					CaCert:  string(scrt.GetValidationContext().GetTrustedCa().GetInlineBytes()),
+					Generic: string(scrt.GetGenericSecret().GetSecret().GetInlineBytes()),
 				}
 			}
 			if diff := cmp.Diff(got, tt.expect); diff != "" {
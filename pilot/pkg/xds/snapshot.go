@@ -0,0 +1,207 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/provider"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+)
+
+// The files of a snapshot archive.
+const (
+	snapshotMeshFile      = "mesh.yaml"
+	snapshotServicesFile  = "services.json"
+	snapshotInstancesFile = "instances.json"
+	snapshotConfigsFile   = "configs.yaml"
+)
+
+// Snapshot is the state istiod generates the config of the proxies from: the mesh config, the services and
+// instances of the service registries, and the Istio configs. It is exported by /debug/snapshotz as a gzipped
+// tarball, so that the config generation for any proxy can be reproduced offline, see NewFakeDiscoveryServer and
+// Snapshot.FakeOptions.
+//
+// The services and instances derived from ServiceEntries and WorkloadEntries are not part of the snapshot, since
+// they are derived again from the configs.
+type Snapshot struct {
+	Mesh      *meshconfig.MeshConfig
+	Services  []*model.Service
+	Instances []*model.ServiceInstance
+	Configs   []config.Config
+}
+
+// snapshot captures the current state of the server.
+func (s *DiscoveryServer) snapshot() *Snapshot {
+	snap := &Snapshot{Mesh: s.Env.Mesh()}
+	for _, svc := range s.Env.ServiceDiscovery.Services() {
+		if svc.Attributes.ServiceRegistry == provider.External {
+			continue
+		}
+		snap.Services = append(snap.Services, svc)
+		for _, port := range svc.Ports {
+			for _, instance := range s.Env.ServiceDiscovery.InstancesByPort(svc, port.Port, nil) {
+				// The Envoy endpoint is a cache of the endpoint, built again from it.
+				ep := instance.Endpoint.DeepCopy()
+				ep.EnvoyEndpoint = nil
+				snap.Instances = append(snap.Instances, &model.ServiceInstance{
+					Service:     instance.Service,
+					ServicePort: instance.ServicePort,
+					Endpoint:    ep,
+				})
+			}
+		}
+	}
+	for _, schema := range collections.Pilot.All() {
+		if _, f := s.Env.IstioConfigStore.Schemas().FindByGroupVersionKind(schema.Resource().GroupVersionKind()); !f {
+			continue
+		}
+		configs, _ := s.Env.IstioConfigStore.List(schema.Resource().GroupVersionKind(), "")
+		snap.Configs = append(snap.Configs, configs...)
+	}
+	return snap
+}
+
+// snapshotz exports the state the config of the proxies is generated from as a gzipped tarball.
+func (s *DiscoveryServer) snapshotz(w http.ResponseWriter, _ *http.Request) {
+	var out bytes.Buffer
+	if err := s.snapshot().Write(&out); err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="istiod-snapshot.tar.gz"`)
+	_, _ = w.Write(out.Bytes())
+}
+
+// Write writes the snapshot as a gzipped tarball.
+func (snap *Snapshot) Write(w io.Writer) error {
+	meshYAML, err := gogoprotomarshal.ToYAML(snap.Mesh)
+	if err != nil {
+		return err
+	}
+	services, err := json.MarshalIndent(snap.Services, "", "  ")
+	if err != nil {
+		return err
+	}
+	instances, err := json.MarshalIndent(snap.Instances, "", "  ")
+	if err != nil {
+		return err
+	}
+	var configs bytes.Buffer
+	for _, cfg := range snap.Configs {
+		obj, err := crd.ConvertConfig(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s %s/%s: %v", cfg.GroupVersionKind.Kind, cfg.Namespace, cfg.Name, err)
+		}
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		configs.WriteString("---\n")
+		configs.Write(b)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{snapshotMeshFile, []byte(meshYAML)},
+		{snapshotServicesFile, services},
+		{snapshotInstancesFile, instances},
+		{snapshotConfigsFile, configs.Bytes()},
+	} {
+		hdr := &tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadSnapshot reads a snapshot exported by /debug/snapshotz.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if files[hdr.Name], err = io.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range []string{snapshotMeshFile, snapshotServicesFile, snapshotInstancesFile, snapshotConfigsFile} {
+		if _, f := files[name]; !f {
+			return nil, fmt.Errorf("invalid snapshot: missing %s", name)
+		}
+	}
+
+	snap := &Snapshot{}
+	if snap.Mesh, err = mesh.ApplyMeshConfigDefaults(string(files[snapshotMeshFile])); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", snapshotMeshFile, err)
+	}
+	if err := json.Unmarshal(files[snapshotServicesFile], &snap.Services); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", snapshotServicesFile, err)
+	}
+	if err := json.Unmarshal(files[snapshotInstancesFile], &snap.Instances); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", snapshotInstancesFile, err)
+	}
+	for _, instance := range snap.Instances {
+		// The discoverability policy is not serialized.
+		instance.Endpoint.DiscoverabilityPolicy = model.AlwaysDiscoverable
+	}
+	if snap.Configs, _, err = crd.ParseInputs(string(files[snapshotConfigsFile])); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", snapshotConfigsFile, err)
+	}
+	return snap, nil
+}
@@ -0,0 +1,216 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/provider"
+	"istio.io/istio/pilot/test/xdstest"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+const snapshotConfigs = `
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - reviews.default.svc.cluster.local
+  http:
+  - route:
+    - destination:
+        host: reviews.default.svc.cluster.local
+    timeout: 3s
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: Sidecar
+metadata:
+  name: default
+  namespace: default
+spec:
+  egress:
+  - hosts:
+    - "./*"
+    - "istio-system/*"
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: external
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 443
+    name: tls
+    protocol: TLS
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: buffer
+  namespace: default
+spec:
+  configPatches:
+  - applyTo: LISTENER
+    match:
+      context: SIDECAR_OUTBOUND
+    patch:
+      operation: MERGE
+      value:
+        per_connection_buffer_limit_bytes: 1024
+`
+
+func snapshotServices() ([]*model.Service, []*model.ServiceInstance) {
+	svc := &model.Service{
+		Hostname:       host.Name("reviews.default.svc.cluster.local"),
+		DefaultAddress: "10.10.0.1",
+		Ports:          model.PortList{{Name: "http", Port: 9080, Protocol: protocol.HTTP}},
+		Attributes: model.ServiceAttributes{
+			ServiceRegistry: provider.Kubernetes,
+			Name:            "reviews",
+			Namespace:       "default",
+		},
+	}
+	instance := &model.ServiceInstance{
+		Service:     svc,
+		ServicePort: svc.Ports[0],
+		Endpoint: &model.IstioEndpoint{
+			Address:               "10.20.0.1",
+			EndpointPort:          9080,
+			ServicePortName:       "http",
+			Labels:                map[string]string{"app": "reviews"},
+			Namespace:             "default",
+			DiscoverabilityPolicy: model.AlwaysDiscoverable,
+		},
+	}
+	return []*model.Service{svc}, []*model.ServiceInstance{instance}
+}
+
+// dumpConfig returns the listeners, clusters and routes generated for the proxy as JSON.
+func dumpConfig(t *testing.T, s *FakeDiscoveryServer, p *model.Proxy) map[string][]string {
+	p = s.SetupProxy(p)
+	return map[string][]string{
+		"listeners": xdstest.DumpList(t, xdstest.InterfaceSlice(s.Listeners(p))),
+		"clusters":  xdstest.DumpList(t, xdstest.InterfaceSlice(s.Clusters(p))),
+		"routes":    xdstest.DumpList(t, xdstest.InterfaceSlice(s.Routes(p))),
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	services, instances := snapshotServices()
+	s := NewFakeDiscoveryServer(t, FakeOptions{ConfigString: snapshotConfigs, Services: services, Instances: instances})
+
+	var archive bytes.Buffer
+	if err := s.Discovery.snapshot().Write(&archive); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := ReadSnapshot(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The services of the ServiceEntries are derived again from the configs.
+	if len(snap.Services) != 1 || snap.Services[0].Hostname != "reviews.default.svc.cluster.local" {
+		t.Fatalf("unexpected services %v", snap.Services)
+	}
+	if len(snap.Instances) != 1 || snap.Instances[0].Endpoint.Address != "10.20.0.1" {
+		t.Fatalf("unexpected instances %v", snap.Instances)
+	}
+	if len(snap.Configs) != 4 {
+		t.Fatalf("expected 4 configs, got %d", len(snap.Configs))
+	}
+
+	replayed := NewFakeDiscoveryServer(t, snap.FakeOptions())
+	for _, ip := range []string{"10.20.0.1", "10.20.0.2"} {
+		// SetupProxy initializes the proxy for the server, so each server gets its own.
+		proxy := func() *model.Proxy {
+			return &model.Proxy{IPAddresses: []string{ip}, Metadata: &model.NodeMetadata{Labels: map[string]string{"app": "ratings"}}}
+		}
+		if got, want := dumpConfig(t, replayed, proxy()), dumpConfig(t, s, proxy()); !reflect.DeepEqual(got, want) {
+			t.Errorf("config generated from the snapshot for %s differs from the original", ip)
+		}
+	}
+}
+
+// TestSnapshotReproduction generates the config of a proxy from a snapshot exported by /debug/snapshotz, for
+// example:
+//
+//	ISTIO_SNAPSHOT=istiod-snapshot.tar.gz ISTIO_SNAPSHOT_PROXY=sidecar~10.20.0.1~reviews-v1-7d8f.default~default.svc.cluster.local \
+//	  ISTIO_SNAPSHOT_PROXY_LABELS=app=reviews,version=v1 ISTIO_SNAPSHOT_OUTPUT=/tmp/reviews \
+//	  go test ./pilot/pkg/xds -run TestSnapshotReproduction
+//
+// The listeners, clusters and routes are written to ISTIO_SNAPSHOT_OUTPUT, or logged if it is not set.
+func TestSnapshotReproduction(t *testing.T) {
+	path := os.Getenv("ISTIO_SNAPSHOT")
+	if path == "" {
+		t.Skip("ISTIO_SNAPSHOT is not set")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	snap, err := ReadSnapshot(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := &model.NodeMetadata{Labels: map[string]string{}}
+	for _, kv := range strings.Split(os.Getenv("ISTIO_SNAPSHOT_PROXY_LABELS"), ",") {
+		if k, v, ok := splitLabel(kv); ok {
+			meta.Labels[k] = v
+		}
+	}
+	p, err := model.ParseServiceNodeWithMetadata(os.Getenv("ISTIO_SNAPSHOT_PROXY"), meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := strings.Index(p.ID, "."); i >= 0 {
+		p.ConfigNamespace = p.ID[i+1:]
+	}
+
+	s := NewFakeDiscoveryServer(t, snap.FakeOptions())
+	out := os.Getenv("ISTIO_SNAPSHOT_OUTPUT")
+	for typ, resources := range dumpConfig(t, s, p) {
+		dump := "[" + strings.Join(resources, ",\n") + "]\n"
+		if out == "" {
+			t.Logf("%s:\n%s", typ, dump)
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(out, typ+".json"), []byte(dump), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func splitLabel(kv string) (string, string, bool) {
+	i := strings.Index(kv, "=")
+	if i < 0 {
+		return "", "", false
+	}
+	return kv[:i], kv[i+1:], true
+}
@@ -38,6 +38,10 @@ const (
 	DebugType     = "istio.io/debug"
 	BootstrapType = apiTypePrefix + "envoy.config.bootstrap.v3.Bootstrap"
 
+	// EndpointDrainType asks istiod to report the endpoints of the requesting proxy as draining. The responses
+	// carry a JSON model.EndpointDrainStatus, tracking the proxies that have not acked the change yet.
+	EndpointDrainType = "istio.io/endpoint-drain"
+
 	// nolint
 	HttpProtocolOptionsType = "envoy.extensions.upstreams.http.v3.HttpProtocolOptions"
 )
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,7 +26,9 @@ import (
 	"go.uber.org/atomic"
 	any "google.golang.org/protobuf/types/known/anypb"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/model/credentials"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/test/util/retry"
@@ -253,4 +256,62 @@ func TestXdsCache(t *testing.T) {
 			t.Fatalf("unexpected result: %v, want %v", got, any1)
 		}
 	})
+	// sized returns a resource of roughly n bytes.
+	sized := func(n int) *discovery.Resource {
+		return &discovery.Resource{Name: strings.Repeat("x", n), Resource: &any.Any{TypeUrl: "foo"}}
+	}
+	ep3 := EndpointBuilder{
+		clusterName: "outbound|3||foo.com",
+		service: &model.Service{
+			Hostname: "foo.com",
+		},
+	}
+	sds1 := SecretResource{credentials.SecretResource{Type: credentials.KubernetesSecretType, Name: "foo", Namespace: "default"}}
+
+	t.Run("evict least recently used beyond max bytes", func(t *testing.T) {
+		defaultValue := features.XDSCacheMaxBytes
+		features.XDSCacheMaxBytes = 2500
+		defer func() { features.XDSCacheMaxBytes = defaultValue }()
+		c := model.NewLenientXdsCache()
+
+		c.Add(ep1, &model.PushRequest{Start: time.Now()}, sized(1000))
+		c.Add(ep2, &model.PushRequest{Start: time.Now()}, sized(1000))
+		// Mark ep1 as recently used, so ep2 is evicted instead.
+		if _, f := c.Get(ep1); !f {
+			t.Fatalf("expected ep1 to be cached: %v", c.Keys())
+		}
+		c.Add(ep3, &model.PushRequest{Start: time.Now()}, sized(1000))
+		if want := []string{ep1.Key(), ep3.Key()}; !reflect.DeepEqual(c.Keys(), want) {
+			t.Fatalf("unexpected keys: %v, want %v", c.Keys(), want)
+		}
+	})
+
+	t.Run("entry larger than max bytes is not cached", func(t *testing.T) {
+		defaultValue := features.XDSCacheMaxBytes
+		features.XDSCacheMaxBytes = 2500
+		defer func() { features.XDSCacheMaxBytes = defaultValue }()
+		c := model.NewLenientXdsCache()
+
+		c.Add(ep1, &model.PushRequest{Start: time.Now()}, sized(1000))
+		c.Add(ep2, &model.PushRequest{Start: time.Now()}, sized(3000))
+		if want := []string{ep1.Key()}; !reflect.DeepEqual(c.Keys(), want) {
+			t.Fatalf("unexpected keys: %v, want %v", c.Keys(), want)
+		}
+	})
+
+	t.Run("evict least recently used of type beyond quota", func(t *testing.T) {
+		defaultValue := features.XDSCacheTypeQuotas
+		features.XDSCacheTypeQuotas = "eds=2500"
+		defer func() { features.XDSCacheTypeQuotas = defaultValue }()
+		c := model.NewLenientXdsCache()
+
+		c.Add(sds1, &model.PushRequest{Start: time.Now()}, sized(1000))
+		c.Add(ep1, &model.PushRequest{Start: time.Now()}, sized(1000))
+		c.Add(ep2, &model.PushRequest{Start: time.Now()}, sized(1000))
+		c.Add(ep3, &model.PushRequest{Start: time.Now()}, sized(1000))
+		// The secret is the least recently used entry, but it is not subject to the endpoints quota.
+		if want := []string{sds1.Key(), ep2.Key(), ep3.Key()}; !reflect.DeepEqual(c.Keys(), want) {
+			t.Fatalf("unexpected keys: %v, want %v", c.Keys(), want)
+		}
+	})
 }
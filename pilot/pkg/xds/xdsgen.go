@@ -23,6 +23,7 @@ import (
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/apigen"
 	"istio.io/istio/pilot/pkg/networking/util"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/pkg/env"
@@ -61,6 +62,14 @@ func init() {
 }
 
 func (s *DiscoveryServer) findGenerator(typeURL string, con *Connection) model.XdsResourceGenerator {
+	// Config export clients are read-only: they are only served config, whatever the type they watch.
+	if con.proxy.Metadata.Generator == apigen.ConfigExport {
+		if g, f := s.Generators[apigen.ConfigExport]; f {
+			return g
+		}
+		return nil
+	}
+
 	if g, f := s.Generators[con.proxy.Metadata.Generator+"/"+typeURL]; f {
 		return g
 	}
@@ -98,7 +107,9 @@ func (s *DiscoveryServer) pushXds(con *Connection, push *model.PushContext,
 
 	t0 := time.Now()
 
+	usage := startGeneratorUsage()
 	res, logdata, err := gen.Generate(con.proxy, push, w, req)
+	recordGeneratorUsage(usage, w.TypeUrl, con.proxy)
 	if err != nil || res == nil {
 		// If we have nothing to send, report that we got an ACK for this version.
 		if s.StatusReporter != nil {
@@ -136,6 +147,7 @@ func (s *DiscoveryServer) pushXds(con *Connection, push *model.PushContext,
 		}
 		return err
 	}
+	con.configHistory.record(w.TypeUrl, resp.VersionInfo, req, res, nil, logdata.Incremental)
 
 	switch {
 	case logdata.Incremental:
@@ -0,0 +1,245 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admission runs the config analyzers on a config that is being applied, so that the validation webhook
+// can warn about, or deny, the misconfigurations that are otherwise only found by "istioctl analyze".
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/local"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// None disables a threshold: no message reaches it.
+const None = "None"
+
+// Threshold is the lowest severity of the messages of an analyzer that make the config be applied with a warning,
+// or be denied. Severities are Info, Warning, Error or None; an empty severity is inherited from the default.
+type Threshold struct {
+	Warn string `json:"warn,omitempty"`
+	Deny string `json:"deny,omitempty"`
+}
+
+// Policy configures the thresholds of the analyzers at admission.
+type Policy struct {
+	// Default applies to the analyzers that are not listed in Analyzers.
+	Default Threshold `json:"default,omitempty"`
+	// Analyzers overrides the default, by analyzer name, e.g. virtualservice.GatewayAnalyzer.
+	Analyzers map[string]Threshold `json:"analyzers,omitempty"`
+}
+
+// ParsePolicy parses a policy in JSON format, e.g.
+// {"default": {"warn": "Warning"}, "analyzers": {"virtualservice.GatewayAnalyzer": {"deny": "Error"}}}.
+// An empty value returns a nil policy, which disables the analysis at admission.
+func ParsePolicy(value string) (*Policy, error) {
+	if value == "" {
+		return nil, nil
+	}
+	p := &Policy{}
+	if err := json.Unmarshal([]byte(value), p); err != nil {
+		return nil, fmt.Errorf("invalid admission analysis policy: %v", err)
+	}
+	if _, err := p.Default.resolve(Threshold{}); err != nil {
+		return nil, fmt.Errorf("invalid default threshold: %v", err)
+	}
+	known := map[string]bool{}
+	for _, a := range analyzers.All() {
+		known[a.Metadata().Name] = true
+	}
+	for name, t := range p.Analyzers {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown analyzer %q", name)
+		}
+		if _, err := t.resolve(p.Default); err != nil {
+			return nil, fmt.Errorf("invalid threshold of analyzer %s: %v", name, err)
+		}
+	}
+	return p, nil
+}
+
+// levels is a resolved Threshold; a nil level is never reached.
+type levels struct {
+	warn *diag.Level
+	deny *diag.Level
+}
+
+func (t Threshold) resolve(def Threshold) (levels, error) {
+	warn, deny := t.Warn, t.Deny
+	if warn == "" {
+		warn = def.Warn
+	}
+	if deny == "" {
+		deny = def.Deny
+	}
+	var out levels
+	var err error
+	if out.warn, err = parseLevel(warn); err != nil {
+		return out, err
+	}
+	if out.deny, err = parseLevel(deny); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+func parseLevel(s string) (*diag.Level, error) {
+	if s == "" || strings.EqualFold(s, None) {
+		return nil, nil
+	}
+	l, f := diag.GetUppercaseStringToLevelMap()[strings.ToUpper(s)]
+	if !f {
+		return nil, fmt.Errorf("unknown severity %q, expected one of %s or %s",
+			s, strings.Join(diag.GetAllLevelStrings(), ", "), None)
+	}
+	return &l, nil
+}
+
+type analyzer struct {
+	analysis.Analyzer
+	levels
+}
+
+// Admission analyzes configs at admission against the configs of a store.
+type Admission struct {
+	store     model.ConfigStore
+	analyzers []analyzer
+}
+
+// New returns an Admission that runs the analyzers of the policy whose inputs are all in the store, since the
+// others would report the configs they cannot see as missing.
+func New(p *Policy, store model.ConfigStore) *Admission {
+	inputs := map[collection.Name]bool{}
+	for _, s := range store.Schemas().All() {
+		inputs[s.Name()] = true
+	}
+	a := &Admission{store: store}
+outer:
+	for _, an := range analyzers.All() {
+		for _, in := range an.Metadata().Inputs {
+			if !inputs[in] {
+				continue outer
+			}
+		}
+		l, _ := p.Analyzers[an.Metadata().Name].resolve(p.Default)
+		if l.warn == nil && l.deny == nil {
+			continue
+		}
+		a.analyzers = append(a.analyzers, analyzer{Analyzer: an, levels: l})
+	}
+	return a
+}
+
+// Analyzers returns the names of the analyzers that run at admission.
+func (a *Admission) Analyzers() []string {
+	out := make([]string, 0, len(a.analyzers))
+	for _, an := range a.analyzers {
+		out = append(out, an.Metadata().Name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Result is the messages of the analysis of a config, by outcome. Messages below both thresholds of their
+// analyzer are dropped.
+type Result struct {
+	Denied   diag.Messages
+	Warnings diag.Messages
+}
+
+// Analyze runs the analyzers on the store as if cfg had been applied, and returns the messages about cfg.
+// Messages about other configs are ignored, so that an existing misconfiguration does not block unrelated changes.
+func (a *Admission) Analyze(cfg config.Config) Result {
+	store := &overlay{ConfigStore: a.store, cfg: cfg}
+	var res Result
+	for _, an := range a.analyzers {
+		ctx := &reporter{Context: local.NewContext(store, nil, func(collection.Name) {})}
+		an.Analyze(ctx)
+		for _, m := range ctx.messages {
+			if !about(m, cfg) {
+				continue
+			}
+			switch level := m.Type.Level(); {
+			case an.deny != nil && level.IsWorseThanOrEqualTo(*an.deny):
+				res.Denied = append(res.Denied, m)
+			case an.warn != nil && level.IsWorseThanOrEqualTo(*an.warn):
+				res.Warnings = append(res.Warnings, m)
+			}
+		}
+	}
+	return res
+}
+
+func about(m diag.Message, cfg config.Config) bool {
+	if m.Resource == nil || m.Resource.Metadata.Schema == nil {
+		return false
+	}
+	gvk := m.Resource.Metadata.Schema.GroupVersionKind()
+	return gvk.Group == cfg.GroupVersionKind.Group && gvk.Kind == cfg.GroupVersionKind.Kind &&
+		m.Resource.Metadata.FullName == resource.NewFullName(resource.Namespace(cfg.Namespace), resource.LocalName(cfg.Name))
+}
+
+// reporter collects the messages of an analyzer.
+type reporter struct {
+	analysis.Context
+	messages diag.Messages
+}
+
+func (r *reporter) Report(_ collection.Name, m diag.Message) {
+	r.messages.Add(m)
+}
+
+// overlay is a read only view of a store in which cfg has been created or updated.
+type overlay struct {
+	model.ConfigStore
+	cfg config.Config
+}
+
+func (o *overlay) matches(typ config.GroupVersionKind, name, namespace string) bool {
+	return typ == o.cfg.GroupVersionKind && name == o.cfg.Name && namespace == o.cfg.Namespace
+}
+
+func (o *overlay) Get(typ config.GroupVersionKind, name, namespace string) *config.Config {
+	if o.matches(typ, name, namespace) {
+		return &o.cfg
+	}
+	return o.ConfigStore.Get(typ, name, namespace)
+}
+
+func (o *overlay) List(typ config.GroupVersionKind, namespace string) ([]config.Config, error) {
+	configs, err := o.ConfigStore.List(typ, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if typ != o.cfg.GroupVersionKind || (namespace != "" && namespace != o.cfg.Namespace) {
+		return configs, nil
+	}
+	out := make([]config.Config, 0, len(configs)+1)
+	for _, c := range configs {
+		if !o.matches(c.GroupVersionKind, c.Name, c.Namespace) {
+			out = append(out, c)
+		}
+	}
+	return append(out, o.cfg), nil
+}
@@ -0,0 +1,156 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+func TestParsePolicy(t *testing.T) {
+	if p, err := ParsePolicy(""); p != nil || err != nil {
+		t.Fatalf("expected no policy, got %v %v", p, err)
+	}
+	p, err := ParsePolicy(`{"default": {"warn": "warning"}, "analyzers": {"virtualservice.GatewayAnalyzer": {"deny": "Error"}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, _ := p.Analyzers["virtualservice.GatewayAnalyzer"].resolve(p.Default)
+	if l.warn == nil || l.warn.String() != "Warning" || l.deny == nil || l.deny.String() != "Error" {
+		t.Fatalf("unexpected thresholds %+v", l)
+	}
+
+	for _, invalid := range []string{
+		`{"default": {"warn": "Critical"}}`,
+		`{"analyzers": {"virtualservice.UnknownAnalyzer": {"deny": "Error"}}}`,
+		`{"analyzers": {"virtualservice.GatewayAnalyzer": {"deny": "Fatal"}}}`,
+		`not json`,
+	} {
+		if _, err := ParsePolicy(invalid); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+}
+
+func virtualService(name string, gateways ...string) config.Config {
+	return config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: collections.IstioNetworkingV1Alpha3Virtualservices.Resource().GroupVersionKind(),
+			Name:             name,
+			Namespace:        "default",
+		},
+		Spec: &networking.VirtualService{
+			Hosts:    []string{name + ".example.com"},
+			Gateways: gateways,
+			Http: []*networking.HTTPRoute{{
+				Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: name}}},
+			}},
+		},
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	store := memory.Make(collections.Pilot)
+	gw := config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: collections.IstioNetworkingV1Alpha3Gateways.Resource().GroupVersionKind(),
+			Name:             "ingress",
+			Namespace:        "default",
+		},
+		Spec: &networking.Gateway{
+			Servers: []*networking.Server{{
+				Port:  &networking.Port{Number: 80, Name: "http", Protocol: "HTTP"},
+				Hosts: []string{"*"},
+			}},
+		},
+	}
+	// An existing misconfiguration, which must not block other configs.
+	for _, cfg := range []config.Config{gw, virtualService("broken", "missing")} {
+		if _, err := store.Create(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		policy   string
+		cfg      config.Config
+		denied   int
+		warnings int
+	}{
+		{
+			name:   "deny a missing gateway",
+			policy: `{"analyzers": {"virtualservice.GatewayAnalyzer": {"deny": "Error"}}}`,
+			cfg:    virtualService("reviews", "missing"),
+			denied: 1,
+		},
+		{
+			name:   "warn about a missing gateway",
+			policy: `{"default": {"warn": "Warning"}}`,
+			cfg:    virtualService("reviews", "missing"),
+			// The gateway analyzer also reports that the hosts are not in the gateway.
+			warnings: 2,
+		},
+		{
+			name:   "below the thresholds",
+			policy: `{"default": {"deny": "Error"}, "analyzers": {"virtualservice.GatewayAnalyzer": {"deny": "None"}}}`,
+			cfg:    virtualService("reviews", "missing"),
+		},
+		{
+			name:   "existing gateway",
+			policy: `{"default": {"deny": "Error"}}`,
+			cfg:    virtualService("reviews", "ingress"),
+		},
+		{
+			name:   "fixing the broken config",
+			policy: `{"default": {"deny": "Error"}}`,
+			cfg:    virtualService("broken", "ingress"),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePolicy(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := New(p, store).Analyze(tt.cfg)
+			if len(res.Denied) != tt.denied || len(res.Warnings) != tt.warnings {
+				t.Fatalf("got denied %v and warnings %v, want %d and %d", res.Denied, res.Warnings, tt.denied, tt.warnings)
+			}
+			for _, m := range append(res.Denied, res.Warnings...) {
+				if m.Resource.Metadata.FullName.Name != "reviews" {
+					t.Errorf("unexpected message %s", m.String())
+				}
+			}
+		})
+	}
+}
+
+func TestNewSkipsMissingInputs(t *testing.T) {
+	p, err := ParsePolicy(`{"default": {"deny": "Error"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range New(p, memory.Make(collections.Pilot)).Analyzers() {
+		// The service analyzers need the Kubernetes services, which are not Istio configs.
+		if name == "service.PortNameAnalyzer" {
+			t.Fatalf("expected %s to be skipped", name)
+		}
+	}
+}
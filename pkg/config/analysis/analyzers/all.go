@@ -21,6 +21,7 @@ import (
 	"istio.io/istio/pkg/config/analysis/analyzers/deployment"
 	"istio.io/istio/pkg/config/analysis/analyzers/deprecation"
 	"istio.io/istio/pkg/config/analysis/analyzers/destinationrule"
+	"istio.io/istio/pkg/config/analysis/analyzers/envoyfilter"
 	"istio.io/istio/pkg/config/analysis/analyzers/gateway"
 	"istio.io/istio/pkg/config/analysis/analyzers/injection"
 	"istio.io/istio/pkg/config/analysis/analyzers/multicluster"
@@ -40,7 +41,10 @@ func All() []analysis.Analyzer {
 		&authz.AuthorizationPoliciesAnalyzer{},
 		&deployment.ServiceAssociationAnalyzer{},
 		&deployment.ApplicationUIDAnalyzer{},
+		&deployment.ReservedPortAnalyzer{},
+		&deployment.OutboundUDPPortAnalyzer{},
 		&deprecation.FieldAnalyzer{},
+		&envoyfilter.APIVersionAnalyzer{},
 		&gateway.IngressGatewayPortAnalyzer{},
 		&gateway.CertificateAnalyzer{},
 		&gateway.SecretAnalyzer{},
@@ -50,6 +54,7 @@ func All() []analysis.Analyzer {
 		&injection.ImageAutoAnalyzer{},
 		&multicluster.MeshNetworksAnalyzer{},
 		&service.PortNameAnalyzer{},
+		&service.UnsupportedProtocolAnalyzer{},
 		&sidecar.DefaultSelectorAnalyzer{},
 		&sidecar.SelectorAnalyzer{},
 		&virtualservice.ConflictingMeshGatewayHostsAnalyzer{},
@@ -58,7 +63,10 @@ func All() []analysis.Analyzer {
 		&virtualservice.GatewayAnalyzer{},
 		&virtualservice.JWTClaimRouteAnalyzer{},
 		&virtualservice.RegexAnalyzer{},
+		&virtualservice.UDPRouteAnalyzer{},
 		&destinationrule.CaCertificateAnalyzer{},
+		&destinationrule.HostAnalyzer{},
+		&destinationrule.SubsetAnalyzer{},
 		&serviceentry.ProtocolAdressesAnalyzer{},
 		&webhook.Analyzer{},
 	}
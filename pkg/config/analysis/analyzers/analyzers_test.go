@@ -30,6 +30,7 @@ import (
 	"istio.io/istio/pkg/config/analysis/analyzers/deployment"
 	"istio.io/istio/pkg/config/analysis/analyzers/deprecation"
 	"istio.io/istio/pkg/config/analysis/analyzers/destinationrule"
+	"istio.io/istio/pkg/config/analysis/analyzers/envoyfilter"
 	"istio.io/istio/pkg/config/analysis/analyzers/gateway"
 	"istio.io/istio/pkg/config/analysis/analyzers/injection"
 	"istio.io/istio/pkg/config/analysis/analyzers/maturity"
@@ -253,6 +254,27 @@ var testGrid = []testCase{
 		analyzer:   &service.PortNameAnalyzer{},
 		expected:   []message{},
 	},
+	{
+		name:       "unsupportedProtocol",
+		inputFiles: []string{"testdata/service-unsupported-protocol.yaml"},
+		analyzer:   &service.UnsupportedProtocolAnalyzer{},
+		expected: []message{
+			{msg.UnsupportedPortProtocol, "Service default/dns"},
+			{msg.UnsupportedPortProtocol, "Service default/signaling"},
+			{msg.UnsupportedPortProtocol, "ServiceEntry default/syslog"},
+		},
+	},
+	{
+		name: "unsupportedProtocol with UDP proxy",
+		inputFiles: []string{
+			"testdata/service-unsupported-protocol.yaml",
+			"testdata/service-unsupported-protocol-udp-proxy.yaml",
+		},
+		analyzer: &service.UnsupportedProtocolAnalyzer{},
+		expected: []message{
+			{msg.UnsupportedPortProtocol, "Service default/signaling"},
+		},
+	},
 	{
 		name:       "sidecarDefaultSelector",
 		inputFiles: []string{"testdata/sidecar-default-selector.yaml"},
@@ -393,6 +415,17 @@ var testGrid = []testCase{
 			{msg.ReferencedResourceNotFound, "AuthorizationPolicy httpbin/httpbin-bogus-not-ns"},
 		},
 	},
+	{
+		name: "authorizationpolicies proxyless grpc",
+		inputFiles: []string{
+			"testdata/authorizationpolicies-proxyless-grpc.yaml",
+		},
+		analyzer: &authz.AuthorizationPoliciesAnalyzer{},
+		expected: []message{
+			{msg.AuthorizationPolicyUnsupportedByProxylessGrpc, "AuthorizationPolicy echo/echo-jwt"},
+			{msg.AuthorizationPolicyUnsupportedByProxylessGrpc, "AuthorizationPolicy echo/echo-jwt"},
+		},
+	},
 	{
 		name: "destinationrule with no cacert, simple at destinationlevel",
 		inputFiles: []string{
@@ -463,6 +496,42 @@ var testGrid = []testCase{
 		analyzer: &destinationrule.CaCertificateAnalyzer{},
 		expected: []message{},
 	},
+	{
+		name:       "destinationrule host not found",
+		inputFiles: []string{"testdata/destinationrule-orphaned.yaml"},
+		analyzer:   &destinationrule.HostAnalyzer{},
+		expected: []message{
+			{msg.DestinationRuleHostNotFound, "DestinationRule default/ratings"},
+		},
+	},
+	{
+		name:       "destinationrule subset not referenced",
+		inputFiles: []string{"testdata/destinationrule-orphaned.yaml"},
+		analyzer:   &destinationrule.SubsetAnalyzer{},
+		expected: []message{
+			{msg.DestinationRuleSubsetNotReferenced, "DestinationRule default/reviews"},
+		},
+	},
+	{
+		name:       "envoyfilter api version",
+		inputFiles: []string{"testdata/envoyfilter-api-version.yaml"},
+		analyzer:   &envoyfilter.APIVersionAnalyzer{},
+		expected: []message{
+			{msg.EnvoyFilterUnsupportedByProxyVersion, "EnvoyFilter default/lua-v2"},
+			{msg.EnvoyFilterUnsupportedByProxyVersion, "EnvoyFilter istio-system/cluster-tls-context"},
+			{msg.EnvoyFilterUnsupportedByProxyVersion, "EnvoyFilter istio-system/ratings-lua-v2"},
+		},
+	},
+	{
+		name:       "shadowed routes",
+		inputFiles: []string{"testdata/virtualservice_shadowed.yaml"},
+		analyzer:   schemaValidation.CollectionValidationAnalyzer(collections.IstioNetworkingV1Alpha3Virtualservices),
+		expected: []message{
+			{msg.VirtualServiceRouteShadowed, "VirtualService shadowed-by-prefix"},
+			{msg.VirtualServiceRouteShadowed, "VirtualService shadowed-by-default"},
+			{msg.VirtualServiceRouteShadowed, "VirtualService shadowed-tcp"},
+		},
+	},
 	{
 		name: "dupmatches",
 		inputFiles: []string{
@@ -601,6 +670,47 @@ var testGrid = []testCase{
 			{msg.InvalidApplicationUID, "Deployment deploy-con-sec-uid"},
 		},
 	},
+	{
+		name:       "Application container ports reserved for the sidecar",
+		inputFiles: []string{"testdata/reserved-ports.yaml"},
+		analyzer:   &deployment.ReservedPortAnalyzer{},
+		expected: []message{
+			{msg.ReservedPortConflict, "Pod default/reserved-port"},
+			{msg.ReservedPortConflict, "Deployment default/reserved-port"},
+			{msg.ReservedPortConflict, "Deployment default/reserved-port"},
+		},
+	},
+	{
+		name: "Outbound UDP ports of several services",
+		inputFiles: []string{
+			"testdata/outbound-udp-ports.yaml",
+			"testdata/service-unsupported-protocol-udp-proxy.yaml",
+		},
+		analyzer: &deployment.OutboundUDPPortAnalyzer{},
+		expected: []message{
+			{msg.AmbiguousOutboundUDPPort, "Pod default/ambiguous-port"},
+			{msg.AmbiguousOutboundUDPPort, "Deployment default/ambiguous-port"},
+		},
+	},
+	{
+		name: "Outbound UDP ports without the UDP proxy",
+		inputFiles: []string{
+			"testdata/outbound-udp-ports.yaml",
+		},
+		analyzer: &deployment.OutboundUDPPortAnalyzer{},
+		expected: []message{},
+	},
+	{
+		name: "Gateway UDP routes with several destinations",
+		inputFiles: []string{
+			"testdata/gateway-udp-routes.yaml",
+			"testdata/service-unsupported-protocol-udp-proxy.yaml",
+		},
+		analyzer: &virtualservice.UDPRouteAnalyzer{},
+		expected: []message{
+			{msg.GatewayUDPRouteMultipleDestinations, "VirtualService default/syslog"},
+		},
+	},
 	{
 		name: "Detect `image: auto` in non-injected pods",
 		inputFiles: []string{
@@ -20,8 +20,10 @@ import (
 
 	k8s_labels "k8s.io/apimachinery/pkg/labels"
 
+	"istio.io/api/annotation"
 	"istio.io/api/mesh/v1alpha1"
 	"istio.io/api/security/v1beta1"
+	authzmodel "istio.io/istio/pilot/pkg/security/authz/model"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
 	"istio.io/istio/pkg/config/analysis/msg"
@@ -53,14 +55,54 @@ func (a *AuthorizationPoliciesAnalyzer) Metadata() analysis.Metadata {
 
 func (a *AuthorizationPoliciesAnalyzer) Analyze(c analysis.Context) {
 	podLabelsMap := initPodLabelsMap(c)
+	grpcPods := initProxylessGrpcPods(c)
 
 	c.ForEach(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(), func(r *resource.Instance) bool {
 		a.analyzeNoMatchingWorkloads(r, c, podLabelsMap)
 		a.analyzeNamespaceNotFound(r, c)
+		a.analyzeProxylessGrpcUnsupported(r, c, grpcPods)
 		return true
 	})
 }
 
+// analyzeProxylessGrpcUnsupported warns about rules using attributes that can not be enforced by the proxyless
+// gRPC workloads selected by the policy.
+func (a *AuthorizationPoliciesAnalyzer) analyzeProxylessGrpcUnsupported(r *resource.Instance, c analysis.Context,
+	grpcPods map[string][]proxylessPod) {
+	ap := r.Message.(*v1beta1.AuthorizationPolicy)
+	apNs := r.Metadata.FullName.Namespace.String()
+
+	var candidates []proxylessPod
+	if meshWidePolicy(apNs, c) {
+		for _, pods := range grpcPods {
+			candidates = append(candidates, pods...)
+		}
+	} else {
+		candidates = grpcPods[apNs]
+	}
+	selector := k8s_labels.Everything()
+	if ap.Selector != nil {
+		selector = k8s_labels.SelectorFromSet(ap.Selector.MatchLabels)
+	}
+	pod := ""
+	for _, p := range candidates {
+		if selector.Matches(p.labels) {
+			pod = p.name
+			break
+		}
+	}
+	if pod == "" {
+		return
+	}
+
+	for i, rule := range ap.Rules {
+		if unsupported := authzmodel.UnsupportedByGrpc(rule); len(unsupported) > 0 {
+			c.Report(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+				msg.NewAuthorizationPolicyUnsupportedByProxylessGrpc(r, pod, i, strings.Join(unsupported, ", ")))
+		}
+	}
+}
+
 func (a *AuthorizationPoliciesAnalyzer) analyzeNoMatchingWorkloads(r *resource.Instance, c analysis.Context, podLabelsMap map[string][]k8s_labels.Set) {
 	ap := r.Message.(*v1beta1.AuthorizationPolicy)
 	apNs := r.Metadata.FullName.Namespace.String()
@@ -208,3 +250,29 @@ func initPodLabelsMap(c analysis.Context) map[string][]k8s_labels.Set {
 
 	return podLabelsMap
 }
+
+type proxylessPod struct {
+	name   string
+	labels k8s_labels.Set
+}
+
+// Build a map indexed by namespace with the proxyless gRPC pods, identified by their injection template.
+func initProxylessGrpcPods(c analysis.Context) map[string][]proxylessPod {
+	pods := make(map[string][]proxylessPod)
+
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		for _, t := range strings.Split(r.Metadata.Annotations[annotation.InjectTemplates.Name], ",") {
+			if strings.HasPrefix(strings.TrimSpace(t), "grpc-") {
+				ns := r.Metadata.FullName.Namespace.String()
+				pods[ns] = append(pods[ns], proxylessPod{
+					name:   r.Metadata.FullName.String(),
+					labels: k8s_labels.Set(r.Metadata.Labels),
+				})
+				break
+			}
+		}
+		return true
+	})
+
+	return pods
+}
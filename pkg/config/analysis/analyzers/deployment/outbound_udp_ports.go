@@ -0,0 +1,151 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployment
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/annotation"
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// OutboundUDPPortAnalyzer checks the outbound UDP ports of the pods in the mesh against the UDP ports of the services
+// visible to them: the sidecar cannot tell apart the traffic to several services declaring the same UDP port, so it
+// does not proxy it.
+type OutboundUDPPortAnalyzer struct{}
+
+var _ analysis.Analyzer = &OutboundUDPPortAnalyzer{}
+
+func (a *OutboundUDPPortAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "deployment.OutboundUDPPortAnalyzer",
+		Description: "Checks outbound UDP ports of pods declared by several services",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SAppsV1Deployments.Name(),
+			collections.K8SCoreV1Namespaces.Name(),
+			collections.K8SCoreV1Services.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+		},
+	}
+}
+
+func (a *OutboundUDPPortAnalyzer) Analyze(c analysis.Context) {
+	if !util.UDPProxyEnabled(c) {
+		return
+	}
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		if util.PodInMesh(r, c) {
+			reportAmbiguousUDPPorts(r, c, collections.K8SCoreV1Pods.Name(), r.Metadata.Annotations)
+		}
+		return true
+	})
+	c.ForEach(collections.K8SAppsV1Deployments.Name(), func(r *resource.Instance) bool {
+		if util.DeploymentInMesh(r, c) {
+			reportAmbiguousUDPPorts(r, c, collections.K8SAppsV1Deployments.Name(),
+				r.Message.(*apps_v1.DeploymentSpec).Template.Annotations)
+		}
+		return true
+	})
+}
+
+func reportAmbiguousUDPPorts(r *resource.Instance, c analysis.Context, col collection.Name, annotations map[string]string) {
+	value, f := annotations[constants.OutboundUDPPortsAnnotation]
+	if !f {
+		return
+	}
+	namespace := r.Metadata.FullName.Namespace.String()
+	reported := map[int]bool{}
+	for _, item := range strings.Split(value, ",") {
+		port, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || reported[port] {
+			continue
+		}
+		reported[port] = true
+		if services := udpServices(c, namespace, port); len(services) > 1 {
+			c.Report(col, msg.NewAmbiguousOutboundUDPPort(r, port, services))
+		}
+	}
+}
+
+// udpServices returns the services and service entries exported to the namespace declaring a UDP port with the number.
+func udpServices(c analysis.Context, namespace string, port int) []string {
+	var services []string
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		exportTo := strings.Split(r.Metadata.Annotations[annotation.NetworkingExportTo.Name], ",")
+		if !exportedTo(exportTo, r.Metadata.FullName.Namespace.String(), namespace) {
+			return true
+		}
+		for _, p := range r.Message.(*v1.ServiceSpec).Ports {
+			if int(p.Port) == port && p.Protocol == v1.ProtocolUDP {
+				services = append(services, r.Metadata.FullName.String())
+				break
+			}
+		}
+		return true
+	})
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		if !exportedTo(se.ExportTo, r.Metadata.FullName.Namespace.String(), namespace) {
+			return true
+		}
+		for _, p := range se.Ports {
+			if int(p.Number) == port && protocol.Parse(p.Protocol) == protocol.UDP {
+				services = append(services, r.Metadata.FullName.String())
+				break
+			}
+		}
+		return true
+	})
+	sort.Strings(services)
+	return services
+}
+
+// exportedTo returns true if a service of the namespace with the exportTo is visible from the other namespace. No
+// exportTo exports the service to all namespaces.
+func exportedTo(exportTo []string, namespace, other string) bool {
+	exported := true
+	for _, e := range exportTo {
+		switch e = strings.TrimSpace(e); e {
+		case "":
+			continue
+		case util.ExportToAllNamespaces:
+			return true
+		case util.ExportToNamespaceLocal:
+			if namespace == other {
+				return true
+			}
+		default:
+			if e == other {
+				return true
+			}
+		}
+		exported = false
+	}
+	return exported
+}
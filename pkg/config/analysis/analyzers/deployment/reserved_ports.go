@@ -0,0 +1,67 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployment
+
+import (
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/kube/inject"
+)
+
+// ReservedPortAnalyzer checks the ports of the application containers of the pods in the mesh against the ports
+// reserved for the sidecar.
+type ReservedPortAnalyzer struct{}
+
+var _ analysis.Analyzer = &ReservedPortAnalyzer{}
+
+func (a *ReservedPortAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "deployment.ReservedPortAnalyzer",
+		Description: "Checks application container ports in the range reserved for the sidecar",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SAppsV1Deployments.Name(),
+			collections.K8SCoreV1Namespaces.Name(),
+		},
+	}
+}
+
+func (a *ReservedPortAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		if !util.IsIstioControlPlane(r) && util.PodInMesh(r, c) {
+			reportReservedPorts(r, c, collections.K8SCoreV1Pods.Name(), r.Message.(*v1.PodSpec))
+		}
+		return true
+	})
+	c.ForEach(collections.K8SAppsV1Deployments.Name(), func(r *resource.Instance) bool {
+		if !util.IsIstioControlPlane(r) && util.DeploymentInMesh(r, c) {
+			reportReservedPorts(r, c, collections.K8SAppsV1Deployments.Name(), &r.Message.(*apps_v1.DeploymentSpec).Template.Spec)
+		}
+		return true
+	})
+}
+
+func reportReservedPorts(r *resource.Instance, c analysis.Context, col collection.Name, spec *v1.PodSpec) {
+	for _, conflict := range inject.ReservedPortConflicts(spec) {
+		c.Report(col, msg.NewReservedPortConflict(r, conflict.Container, int(conflict.Port)))
+	}
+}
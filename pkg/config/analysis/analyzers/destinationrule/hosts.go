@@ -0,0 +1,68 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// HostAnalyzer checks that the host of each destination rule matches a service or a service entry
+type HostAnalyzer struct{}
+
+var _ analysis.Analyzer = &HostAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *HostAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.HostAnalyzer",
+		Description: "Checks that the host of each destination rule matches a service or a service entry",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *HostAnalyzer) Analyze(ctx analysis.Context) {
+	serviceEntryHosts := util.InitServiceEntryHostMap(ctx)
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		// Wildcard hosts apply to any matching host, including the ones created later.
+		if strings.HasPrefix(dr.GetHost(), util.Wildcard) {
+			return true
+		}
+		if util.GetDestinationHost(r.Metadata.FullName.Namespace, dr.GetHost(), serviceEntryHosts) != nil {
+			return true
+		}
+
+		m := msg.NewDestinationRuleHostNotFound(r, dr.GetHost())
+		if line, ok := util.ErrorLine(r, util.DestinationRuleHost); ok {
+			m.Line = line
+		}
+		ctx.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), m)
+		return true
+	})
+}
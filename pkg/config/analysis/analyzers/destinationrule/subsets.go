@@ -0,0 +1,112 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SubsetAnalyzer checks that the subsets of each destination rule are referenced by a virtual service
+type SubsetAnalyzer struct{}
+
+var _ analysis.Analyzer = &SubsetAnalyzer{}
+
+type hostAndSubset struct {
+	host   resource.FullName
+	subset string
+}
+
+// Metadata implements Analyzer
+func (a *SubsetAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.SubsetAnalyzer",
+		Description: "Checks that the subsets of each destination rule are referenced by a virtual service",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *SubsetAnalyzer) Analyze(ctx analysis.Context) {
+	referenced := initReferencedSubsets(ctx)
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		// The subsets of wildcard hosts can be referenced with any of the hosts they match.
+		if strings.HasPrefix(dr.GetHost(), util.Wildcard) {
+			return true
+		}
+		host := util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, dr.GetHost())
+		for i, ss := range dr.GetSubsets() {
+			if referenced[hostAndSubset{host: host, subset: ss.GetName()}] {
+				continue
+			}
+
+			m := msg.NewDestinationRuleSubsetNotReferenced(r, ss.GetName(), dr.GetHost())
+			if line, ok := util.ErrorLine(r, fmt.Sprintf(util.DestinationRuleSubset, i)); ok {
+				m.Line = line
+			}
+			ctx.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), m)
+		}
+		return true
+	})
+}
+
+// initReferencedSubsets returns the host and subset combinations of the route and mirror destinations of all
+// virtual services
+func initReferencedSubsets(ctx analysis.Context) map[hostAndSubset]bool {
+	referenced := make(map[hostAndSubset]bool)
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		add := func(d *v1alpha3.Destination) {
+			if d.GetSubset() == "" {
+				return
+			}
+			referenced[hostAndSubset{
+				host:   util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, d.GetHost()),
+				subset: d.GetSubset(),
+			}] = true
+		}
+		for _, http := range vs.GetHttp() {
+			for _, rd := range http.GetRoute() {
+				add(rd.GetDestination())
+			}
+			add(http.GetMirror())
+		}
+		for _, tcp := range vs.GetTcp() {
+			for _, rd := range tcp.GetRoute() {
+				add(rd.GetDestination())
+			}
+		}
+		for _, tls := range vs.GetTls() {
+			for _, rd := range tls.GetRoute() {
+				add(rd.GetDestination())
+			}
+		}
+		return true
+	})
+	return referenced
+}
@@ -0,0 +1,277 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+	v1 "k8s.io/api/core/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/mesh/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/injection"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// APIVersionAnalyzer checks the Envoy API used by the patches of EnvoyFilters against the versions of the
+// proxies they apply to, including the version of the sidecar injector that the proxies are upgraded to.
+type APIVersionAnalyzer struct{}
+
+var _ analysis.Analyzer = &APIVersionAnalyzer{}
+
+// version is the minor version of a proxy, e.g. 1.10.
+type version struct {
+	major, minor int
+}
+
+func (v version) atLeast(o version) bool {
+	return v.major > o.major || (v.major == o.major && v.minor >= o.minor)
+}
+
+var versionPattern = regexp.MustCompile(`^(\d+)\.(\d+)(\.\d+)?`)
+
+// parseImageVersion returns the version of a proxy image from its tag, e.g. 1.12.0 for docker.io/istio/proxyv2:1.12.0.
+func parseImageVersion(image string) (string, version, bool) {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return "", version{}, false
+	}
+	m := versionPattern.FindStringSubmatch(image[i+1:])
+	if m == nil {
+		return "", version{}, false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return m[0], version{major, minor}, true
+}
+
+// removedAPI is a part of the Envoy API that proxies reject from a version on.
+type removedAPI struct {
+	since version
+	// typeURL matches the names of the removed types, in @type and type_url fields.
+	typeURL *regexp.Regexp
+	// fields are the removed top level fields of patch values, by applyTo.
+	fields map[networking.EnvoyFilter_ApplyTo][]string
+	name   string
+}
+
+// removedAPIs are the Envoy API versions that proxies no longer support. Proxy 1.10 ships Envoy 1.18, which removed
+// the v2 API along with the fields that have no v3 equivalent.
+var removedAPIs = []removedAPI{
+	{
+		since:   version{1, 10},
+		typeURL: regexp.MustCompile(`^envoy\.(.+\.)?v2(alpha\d*)?\.`),
+		fields: map[networking.EnvoyFilter_ApplyTo][]string{
+			networking.EnvoyFilter_NETWORK_FILTER: {"config"},
+			networking.EnvoyFilter_HTTP_FILTER:    {"config"},
+			networking.EnvoyFilter_CLUSTER:        {"hosts", "tls_context"},
+		},
+		name: "the Envoy v2 API",
+	},
+}
+
+// proxy is a deployed proxy, or the version the proxies are injected with.
+type proxy struct {
+	name      string
+	namespace string
+	labels    k8s_labels.Set
+	version   string
+	minor     version
+}
+
+// Metadata implements Analyzer
+func (a *APIVersionAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "envoyfilter.APIVersionAnalyzer",
+		Description: "Checks that EnvoyFilter patches use an Envoy API supported by the versions of the proxies",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Configmaps.Name(),
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *APIVersionAnalyzer) Analyze(c analysis.Context) {
+	proxies := collectProxies(c)
+	if len(proxies) == 0 {
+		return
+	}
+	rootNamespace := rootNamespace(c)
+	c.ForEach(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), func(r *resource.Instance) bool {
+		a.analyzeEnvoyFilter(r, c, proxies, rootNamespace)
+		return true
+	})
+}
+
+func (a *APIVersionAnalyzer) analyzeEnvoyFilter(r *resource.Instance, c analysis.Context, proxies []proxy, rootNamespace string) {
+	ef := r.Message.(*networking.EnvoyFilter)
+	selector := k8s_labels.Everything()
+	if ef.WorkloadSelector != nil {
+		selector = k8s_labels.SelectorFromSet(ef.WorkloadSelector.Labels)
+	}
+	ns := r.Metadata.FullName.Namespace.String()
+
+	for i, cp := range ef.ConfigPatches {
+		if cp.GetPatch().GetValue() == nil {
+			continue
+		}
+		var proxyVersion *regexp.Regexp
+		if v := cp.GetMatch().GetProxy().GetProxyVersion(); v != "" {
+			var err error
+			if proxyVersion, err = regexp.Compile(v); err != nil {
+				// Reported by the validation.
+				continue
+			}
+		}
+		for _, api := range removedAPIs {
+			uses := usedAPI(api, cp.ApplyTo, cp.Patch.Value)
+			if len(uses) == 0 {
+				continue
+			}
+			// Report each version once, for the first proxy of the version the patch applies to.
+			reported := map[string]bool{}
+			for _, p := range proxies {
+				if !p.minor.atLeast(api.since) || reported[p.version] {
+					continue
+				}
+				// Filters in the root namespace apply to the proxies of all the namespaces, still honoring the
+				// workload selector. The labels of the pods injected later are unknown.
+				if p.namespace != "" && ((ns != rootNamespace && ns != p.namespace) || !selector.Matches(p.labels)) {
+					continue
+				}
+				if proxyVersion != nil && !proxyVersion.MatchString(p.version) {
+					continue
+				}
+				reported[p.version] = true
+				m := msg.NewEnvoyFilterUnsupportedByProxyVersion(r, i, fmt.Sprintf("%s (%s)", api.name, strings.Join(uses, ", ")),
+					p.version, p.name)
+				if line, ok := util.ErrorLine(r, fmt.Sprintf(util.EnvoyFilterConfigPatch, i)); ok {
+					m.Line = line
+				}
+				c.Report(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), m)
+			}
+		}
+	}
+}
+
+// usedAPI returns the types and fields of the API that are used by a patch value.
+func usedAPI(api removedAPI, applyTo networking.EnvoyFilter_ApplyTo, value *types.Struct) []string {
+	var out []string
+	for _, f := range api.fields[applyTo] {
+		if _, ok := value.Fields[f]; ok {
+			out = append(out, "field "+f)
+		}
+	}
+	seen := map[string]bool{}
+	walkTypeURLs(value, func(typeURL string) {
+		name := typeURL[strings.LastIndex(typeURL, "/")+1:]
+		if api.typeURL.MatchString(name) && !seen[name] {
+			seen[name] = true
+			out = append(out, "type "+name)
+		}
+	})
+	sort.Strings(out)
+	return out
+}
+
+// walkTypeURLs calls fn with the type URLs of the Any values in a struct.
+func walkTypeURLs(s *types.Struct, fn func(string)) {
+	for k, v := range s.GetFields() {
+		if k == "@type" || k == "type_url" || k == "typeUrl" {
+			if url := v.GetStringValue(); url != "" {
+				fn(url)
+			}
+		}
+		walkValue(v, fn)
+	}
+}
+
+func walkValue(v *types.Value, fn func(string)) {
+	switch k := v.GetKind().(type) {
+	case *types.Value_StructValue:
+		walkTypeURLs(k.StructValue, fn)
+	case *types.Value_ListValue:
+		for _, item := range k.ListValue.GetValues() {
+			walkValue(item, fn)
+		}
+	}
+}
+
+// collectProxies returns the proxies of the pods and of the sidecar injectors, sorted by name. The injectors have no
+// namespace, as the version applies to any pod injected after an upgrade.
+func collectProxies(c analysis.Context) []proxy {
+	var out []proxy
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		pod := r.Message.(*v1.PodSpec)
+		for _, container := range pod.Containers {
+			if container.Name != util.IstioProxyName {
+				continue
+			}
+			if v, minor, ok := parseImageVersion(container.Image); ok {
+				out = append(out, proxy{
+					name:      "pod " + r.Metadata.FullName.String(),
+					namespace: r.Metadata.FullName.Namespace.String(),
+					labels:    k8s_labels.Set(r.Metadata.Labels),
+					version:   v,
+					minor:     minor,
+				})
+			}
+		}
+		return true
+	})
+	c.ForEach(collections.K8SCoreV1Configmaps.Name(), func(r *resource.Instance) bool {
+		if !strings.HasPrefix(r.Metadata.FullName.Name.String(), util.InjectionConfigMap) {
+			return true
+		}
+		if v, minor, ok := parseImageVersion(injection.GetIstioProxyImage(r.Message.(*v1.ConfigMap))); ok {
+			out = append(out, proxy{
+				name:    "sidecar injector " + r.Metadata.FullName.String(),
+				version: v,
+				minor:   minor,
+			})
+		}
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].name < out[j].name
+	})
+	return out
+}
+
+func rootNamespace(c analysis.Context) string {
+	ns := constants.IstioSystemNamespace
+	c.ForEach(collections.IstioMeshV1Alpha1MeshConfig.Name(), func(r *resource.Instance) bool {
+		if root := r.Message.(*v1alpha1.MeshConfig).GetRootNamespace(); root != "" {
+			ns = root
+		}
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+	return ns
+}
@@ -104,6 +104,8 @@ func morePreciseMessage(r *resource.Instance, err error, isError bool) diag.Mess
 			return msg.NewVirtualServiceUnreachableRule(r, aae.Parameters[0].(string), aae.Parameters[1].(string))
 		case "VirtualServiceIneffectiveMatch":
 			return msg.NewVirtualServiceIneffectiveMatch(r, aae.Parameters[0].(string), aae.Parameters[1].(string), aae.Parameters[2].(string))
+		case "VirtualServiceRouteShadowed":
+			return msg.NewVirtualServiceRouteShadowed(r, aae.Parameters[0].(string), aae.Parameters[1].(string))
 		}
 	}
 	if !isError {
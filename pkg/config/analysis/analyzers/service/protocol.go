@@ -0,0 +1,86 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// UnsupportedProtocolAnalyzer checks for service and service entry ports whose traffic the proxy does not handle
+type UnsupportedProtocolAnalyzer struct{}
+
+var _ analysis.Analyzer = &UnsupportedProtocolAnalyzer{}
+
+// Metadata implements Analyzer
+func (s *UnsupportedProtocolAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "service.UnsupportedProtocolAnalyzer",
+		Description: "Checks for service ports using a protocol the proxy does not handle",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Services.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.K8SAppsV1Deployments.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (s *UnsupportedProtocolAnalyzer) Analyze(c analysis.Context) {
+	udpProxy := util.UDPProxyEnabled(c)
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		// Skip system namespaces entirely
+		if util.IsSystemNamespace(r.Metadata.FullName.Namespace) {
+			return true
+		}
+		svc := r.Message.(*v1.ServiceSpec)
+		for i, port := range svc.Ports {
+			s.analyzePort(c, r, collections.K8SCoreV1Services.Name(), fmt.Sprintf(util.PortInPorts, i), int(port.Port),
+				string(port.Protocol), udpProxy)
+		}
+		return true
+	})
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		for i, port := range se.Ports {
+			s.analyzePort(c, r, collections.IstioNetworkingV1Alpha3Serviceentries.Name(), fmt.Sprintf(util.ServiceEntryPort, i),
+				int(port.Number), port.Protocol, udpProxy)
+		}
+		return true
+	})
+}
+
+func (s *UnsupportedProtocolAnalyzer) analyzePort(c analysis.Context, r *resource.Instance, col collection.Name,
+	path string, port int, proto string, udpProxy bool) {
+	reason := protocol.UnsupportedTransportReason(proto, udpProxy)
+	if reason == "" {
+		return
+	}
+	m := msg.NewUnsupportedPortProtocol(r, port, proto, reason)
+	if line, ok := util.ErrorLine(r, path); ok {
+		m.Line = line
+	}
+	c.Report(col, m)
+}
@@ -15,9 +15,14 @@
 package util
 
 import (
+	"strconv"
 	"strings"
 
+	apps_v1 "k8s.io/api/apps/v1"
+
+	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/kube/inject"
 )
 
@@ -53,3 +58,27 @@ func GetInjectorConfigMapName(revision string) string {
 	}
 	return name + "-" + revision
 }
+
+// UDPProxyEnabled returns true if an istiod deployment of the cluster enables PILOT_ENABLE_UDP_PROXY.
+func UDPProxyEnabled(c analysis.Context) bool {
+	enabled := false
+	c.ForEach(collections.K8SAppsV1Deployments.Name(), func(r *resource.Instance) bool {
+		if r.Metadata.Labels["app"] != "istiod" {
+			return true
+		}
+		for _, container := range r.Message.(*apps_v1.DeploymentSpec).Template.Spec.Containers {
+			if container.Name != "discovery" {
+				continue
+			}
+			for _, env := range container.Env {
+				if env.Name == "PILOT_ENABLE_UDP_PROXY" {
+					if v, err := strconv.ParseBool(env.Value); err == nil && v {
+						enabled = true
+					}
+				}
+			}
+		}
+		return !enabled
+	})
+	return enabled
+}
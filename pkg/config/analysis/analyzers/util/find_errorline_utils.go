@@ -101,6 +101,18 @@ const (
 	// Path for DestinationRule port-level tls certificate.
 	// Required parameters: portLevelSettings index.
 	DestinationRuleTLSPortLevelCert = "{.spec.trafficPolicy.portLevelSettings[%d].tls.caCertificates}"
+
+	// Path for the patch of an EnvoyFilter config patch.
+	// Required parameters: configPatches index.
+	EnvoyFilterConfigPatch = "{.spec.configPatches[%d].patch}"
+
+	// Path for the host of a DestinationRule.
+	// Required parameters: none.
+	DestinationRuleHost = "{.spec.host}"
+
+	// Path for the name of a DestinationRule subset.
+	// Required parameters: subsets index.
+	DestinationRuleSubset = "{.spec.subsets[%d].name}"
 )
 
 // ErrorLine returns the line number of the input path key in the resource
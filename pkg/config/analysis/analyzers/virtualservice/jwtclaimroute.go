@@ -15,13 +15,11 @@
 package virtualservice
 
 import (
-	"strings"
-
 	k8s_labels "k8s.io/apimachinery/pkg/labels"
 
 	"istio.io/api/networking/v1alpha3"
 	"istio.io/api/security/v1beta1"
-	"istio.io/istio/pilot/pkg/util/constant"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
 	"istio.io/istio/pkg/config/analysis/msg"
@@ -125,12 +123,12 @@ func routeBasedOnJWTClaimKey(vs *v1alpha3.VirtualService) string {
 	for _, httpRoute := range vs.GetHttp() {
 		for _, match := range httpRoute.GetMatch() {
 			for key := range match.GetHeaders() {
-				if strings.HasPrefix(key, constant.HeaderJWTClaim) {
+				if istionetworking.IsJWTClaimHeader(key) {
 					return key
 				}
 			}
 			for key := range match.GetWithoutHeaders() {
-				if strings.HasPrefix(key, constant.HeaderJWTClaim) {
+				if istionetworking.IsJWTClaimHeader(key) {
 					return key
 				}
 			}
@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// UDPRouteAnalyzer checks the TCP routes of the gateway servers with the UDP protocol, which are served by the UDP
+// proxy: it supports a single destination, so the servers whose route has several destinations are not served.
+type UDPRouteAnalyzer struct{}
+
+var _ analysis.Analyzer = &UDPRouteAnalyzer{}
+
+// Metadata implements Analyzer
+func (s *UDPRouteAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.UDPRouteAnalyzer",
+		Description: "Checks the routes of UDP gateway servers have a single destination",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			collections.K8SAppsV1Deployments.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (s *UDPRouteAnalyzer) Analyze(c analysis.Context) {
+	if !util.UDPProxyEnabled(c) {
+		return
+	}
+	c.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		s.analyzeVirtualService(r, c)
+		return true
+	})
+}
+
+func (s *UDPRouteAnalyzer) analyzeVirtualService(r *resource.Instance, c analysis.Context) {
+	vs := r.Message.(*v1alpha3.VirtualService)
+	vsNs := r.Metadata.FullName.Namespace
+	for _, gwName := range vs.Gateways {
+		if gwName == util.MeshGateway {
+			continue
+		}
+		gwFullName := resource.NewShortOrFullName(vsNs, gwName)
+		gw := c.Find(collections.IstioNetworkingV1Alpha3Gateways.Name(), gwFullName)
+		if gw == nil {
+			continue
+		}
+		for _, server := range gw.Message.(*v1alpha3.Gateway).Servers {
+			if server.Port == nil || protocol.Parse(server.Port.Protocol) != protocol.UDP ||
+				!serverHostsMatch(server, gw.Metadata.FullName.Namespace.String(), vs.Hosts, vsNs.String()) {
+				continue
+			}
+			for i, tcp := range vs.Tcp {
+				if !tcpRouteMatchesServer(tcp, server, gwName, gwFullName.String()) {
+					continue
+				}
+				// Only the first route matching the server is used.
+				if len(tcp.Route) > 1 {
+					m := msg.NewGatewayUDPRouteMultipleDestinations(r, i, int(server.Port.Number), gwFullName.String(), len(tcp.Route))
+					if line, ok := util.ErrorLine(r, fmt.Sprintf(util.DestinationHost, "tcp", i, 1)); ok {
+						m.Line = line
+					}
+					c.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), m)
+				}
+				break
+			}
+		}
+	}
+}
+
+func serverHostsMatch(server *v1alpha3.Server, gatewayNs string, vsHosts []string, vsNamespace string) bool {
+	// The server hosts are sanitized on a copy, as the gateway is shared with the other analyzers.
+	s := &v1alpha3.Server{Hosts: append([]string(nil), server.Hosts...)}
+	sanitizeServerHostNamespace(s, gatewayNs)
+	for _, gh := range host.NamesForNamespace(s.Hosts, vsNamespace) {
+		for _, vsh := range vsHosts {
+			if gh.Matches(host.Name(vsh)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tcpRouteMatchesServer returns true if the TCP route applies to the server of the gateway, as the gateway matches
+// the routes in pilot.
+func tcpRouteMatchesServer(tcp *v1alpha3.TCPRoute, server *v1alpha3.Server, gwName, gwFullName string) bool {
+	if len(tcp.Match) == 0 {
+		return true
+	}
+	for _, match := range tcp.Match {
+		if match.Port != 0 && match.Port != server.Port.Number {
+			continue
+		}
+		if len(match.Gateways) == 0 {
+			return true
+		}
+		for _, g := range match.Gateways {
+			if g == gwName || g == gwFullName {
+				return true
+			}
+		}
+	}
+	return false
+}
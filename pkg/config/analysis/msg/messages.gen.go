@@ -196,6 +196,42 @@ var (
 	// ExternalNameServiceTypeInvalidPortName defines a diag.MessageType for message "ExternalNameServiceTypeInvalidPortName".
 	// Description: Proxy may prevent tcp named ports and unmatched traffic for ports serving TCP protocol from being forwarded correctly for ExternalName services.
 	ExternalNameServiceTypeInvalidPortName = diag.NewMessageType(diag.Warning, "IST0150", "Port name for ExternalName service is invalid. Proxy may prevent tcp named ports and unmatched traffic for ports serving TCP protocol from being forwarded correctly")
+
+	// AuthorizationPolicyUnsupportedByProxylessGrpc defines a diag.MessageType for message "AuthorizationPolicyUnsupportedByProxylessGrpc".
+	// Description: The authorization policy applies to proxyless gRPC workloads but uses attributes gRPC can not enforce.
+	AuthorizationPolicyUnsupportedByProxylessGrpc = diag.NewMessageType(diag.Warning, "IST0151", "The authorization policy applies to proxyless gRPC pod %s but rule %d uses %s, which gRPC does not support. ALLOW rules using these are ignored and DENY rules ignore these conditions.")
+
+	// EnvoyFilterUnsupportedByProxyVersion defines a diag.MessageType for message "EnvoyFilterUnsupportedByProxyVersion".
+	// Description: The EnvoyFilter patch uses an Envoy API that the proxies it applies to no longer support.
+	EnvoyFilterUnsupportedByProxyVersion = diag.NewMessageType(diag.Error, "IST0152", "Config patch %d uses %s, which is not supported by proxy version %s of %s. The proxy will reject the configuration.")
+
+	// DestinationRuleHostNotFound defines a diag.MessageType for message "DestinationRuleHostNotFound".
+	// Description: The host of a DestinationRule matches no service or service entry, so the DestinationRule is not used.
+	DestinationRuleHostNotFound = diag.NewMessageType(diag.Warning, "IST0153", "The host %s of the DestinationRule matches no service or service entry.")
+
+	// DestinationRuleSubsetNotReferenced defines a diag.MessageType for message "DestinationRuleSubsetNotReferenced".
+	// Description: A subset of a DestinationRule is not the destination of any VirtualService route.
+	DestinationRuleSubsetNotReferenced = diag.NewMessageType(diag.Info, "IST0154", "Subset %s of host %s is not referenced by any VirtualService.")
+
+	// VirtualServiceRouteShadowed defines a diag.MessageType for message "VirtualServiceRouteShadowed".
+	// Description: A VirtualService rule will never be used because a previous rule matches all requests.
+	VirtualServiceRouteShadowed = diag.NewMessageType(diag.Warning, "IST0155", "VirtualService rule %v not used (rule %v before it matches all requests).")
+
+	// UnsupportedPortProtocol defines a diag.MessageType for message "UnsupportedPortProtocol".
+	// Description: A port of a service uses a protocol the proxy does not handle, so its traffic bypasses the mesh.
+	UnsupportedPortProtocol = diag.NewMessageType(diag.Warning, "IST0156", "Port %v uses protocol %v, which Istio does not handle: %v.")
+
+	// ReservedPortConflict defines a diag.MessageType for message "ReservedPortConflict".
+	// Description: An application container declares a port in the range reserved for the sidecar proxy.
+	ReservedPortConflict = diag.NewMessageType(diag.Warning, "IST0157", "Container %v declares port %v, which is in the range 15000-15090 reserved for the sidecar proxy.")
+
+	// AmbiguousOutboundUDPPort defines a diag.MessageType for message "AmbiguousOutboundUDPPort".
+	// Description: An outbound UDP port of a pod is declared by several services, so the sidecar cannot tell their traffic apart.
+	AmbiguousOutboundUDPPort = diag.NewMessageType(diag.Warning, "IST0158", "Outbound UDP port %v is declared by several services %v, so the sidecar does not proxy its traffic.")
+
+	// GatewayUDPRouteMultipleDestinations defines a diag.MessageType for message "GatewayUDPRouteMultipleDestinations".
+	// Description: A VirtualService routes a UDP gateway port to several destinations, but the UDP proxy supports a single one.
+	GatewayUDPRouteMultipleDestinations = diag.NewMessageType(diag.Warning, "IST0159", "TCP route %v for UDP port %v of gateway %v has %v destinations, but the UDP proxy supports a single one, so the port is not served.")
 )
 
 // All returns a list of all known message types.
@@ -248,6 +284,15 @@ func All() []*diag.MessageType {
 		NamespaceInjectionEnabledByDefault,
 		JwtClaimBasedRoutingWithoutRequestAuthN,
 		ExternalNameServiceTypeInvalidPortName,
+		AuthorizationPolicyUnsupportedByProxylessGrpc,
+		EnvoyFilterUnsupportedByProxyVersion,
+		DestinationRuleHostNotFound,
+		DestinationRuleSubsetNotReferenced,
+		VirtualServiceRouteShadowed,
+		UnsupportedPortProtocol,
+		ReservedPortConflict,
+		AmbiguousOutboundUDPPort,
+		GatewayUDPRouteMultipleDestinations,
 	}
 }
 
@@ -719,3 +764,98 @@ func NewExternalNameServiceTypeInvalidPortName(r *resource.Instance) diag.Messag
 		r,
 	)
 }
+
+// NewAuthorizationPolicyUnsupportedByProxylessGrpc returns a new diag.Message based on AuthorizationPolicyUnsupportedByProxylessGrpc.
+func NewAuthorizationPolicyUnsupportedByProxylessGrpc(r *resource.Instance, pod string, rule int, attributes string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyUnsupportedByProxylessGrpc,
+		r,
+		pod,
+		rule,
+		attributes,
+	)
+}
+
+// NewEnvoyFilterUnsupportedByProxyVersion returns a new diag.Message based on EnvoyFilterUnsupportedByProxyVersion.
+func NewEnvoyFilterUnsupportedByProxyVersion(r *resource.Instance, patch int, api string, version string, proxy string) diag.Message {
+	return diag.NewMessage(
+		EnvoyFilterUnsupportedByProxyVersion,
+		r,
+		patch,
+		api,
+		version,
+		proxy,
+	)
+}
+
+// NewDestinationRuleHostNotFound returns a new diag.Message based on DestinationRuleHostNotFound.
+func NewDestinationRuleHostNotFound(r *resource.Instance, host string) diag.Message {
+	return diag.NewMessage(
+		DestinationRuleHostNotFound,
+		r,
+		host,
+	)
+}
+
+// NewDestinationRuleSubsetNotReferenced returns a new diag.Message based on DestinationRuleSubsetNotReferenced.
+func NewDestinationRuleSubsetNotReferenced(r *resource.Instance, subset string, host string) diag.Message {
+	return diag.NewMessage(
+		DestinationRuleSubsetNotReferenced,
+		r,
+		subset,
+		host,
+	)
+}
+
+// NewVirtualServiceRouteShadowed returns a new diag.Message based on VirtualServiceRouteShadowed.
+func NewVirtualServiceRouteShadowed(r *resource.Instance, ruleno string, catchall string) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceRouteShadowed,
+		r,
+		ruleno,
+		catchall,
+	)
+}
+
+// NewUnsupportedPortProtocol returns a new diag.Message based on UnsupportedPortProtocol.
+func NewUnsupportedPortProtocol(r *resource.Instance, port int, protocol string, reason string) diag.Message {
+	return diag.NewMessage(
+		UnsupportedPortProtocol,
+		r,
+		port,
+		protocol,
+		reason,
+	)
+}
+
+// NewReservedPortConflict returns a new diag.Message based on ReservedPortConflict.
+func NewReservedPortConflict(r *resource.Instance, container string, port int) diag.Message {
+	return diag.NewMessage(
+		ReservedPortConflict,
+		r,
+		container,
+		port,
+	)
+}
+
+// NewAmbiguousOutboundUDPPort returns a new diag.Message based on AmbiguousOutboundUDPPort.
+func NewAmbiguousOutboundUDPPort(r *resource.Instance, port int, services []string) diag.Message {
+	return diag.NewMessage(
+		AmbiguousOutboundUDPPort,
+		r,
+		port,
+		services,
+	)
+}
+
+// NewGatewayUDPRouteMultipleDestinations returns a new diag.Message based on GatewayUDPRouteMultipleDestinations.
+func NewGatewayUDPRouteMultipleDestinations(r *resource.Instance, route int, port int, gateway string, destinations int) diag.Message {
+	return diag.NewMessage(
+		GatewayUDPRouteMultipleDestinations,
+		r,
+		route,
+		port,
+		gateway,
+		destinations,
+	)
+}
@@ -125,6 +125,285 @@ const (
 	// InternalParentName declares the original resource of an internally-generate config. This is used by the gateway-api.
 	InternalParentName = "internal.istio.io/parent"
 
+	// RetryBudgetPercentAnnotation on a DestinationRule configures a retry budget for the destination: the
+	// percentage of active requests that may be retries, across all the routes to the destination. When set, the
+	// maxRetries connection pool setting is ignored.
+	RetryBudgetPercentAnnotation = "experimental.istio.io/retry-budget-percent"
+	// RetryBudgetMinRetryConcurrencyAnnotation on a DestinationRule sets the number of concurrent retries always
+	// allowed by the retry budget, regardless of the percentage. Requires RetryBudgetPercentAnnotation.
+	RetryBudgetMinRetryConcurrencyAnnotation = "experimental.istio.io/retry-budget-min-retry-concurrency"
+
+	// LoadBalancingPolicyAnnotation on a DestinationRule configures an Envoy load balancing policy extension for its
+	// clusters, overriding the load balancer settings. The value is the JSON form of the extension config, such as
+	// {"@type": "type.googleapis.com/envoy.extensions.load_balancing_policies.round_robin.v3.RoundRobin"}.
+	LoadBalancingPolicyAnnotation = "experimental.istio.io/load-balancing-policy"
+
+	// OriginalDstAnnotation on a DestinationRule forces the ORIGINAL_DST discovery type for the outbound clusters of its
+	// host, so connections go to their original destination address rather than to the service endpoints. The value is
+	// "true", or OriginalDstUseHTTPHeader to also let the x-envoy-original-dst-host request header select the destination.
+	OriginalDstAnnotation = "experimental.istio.io/original-dst"
+	// OriginalDstUseHTTPHeader is the OriginalDstAnnotation value enabling the x-envoy-original-dst-host header.
+	OriginalDstUseHTTPHeader = "use-http-header"
+
+	// SlowStartAggressionAnnotation on a DestinationRule sets the aggression of the slow start mode enabled by the
+	// warmupDurationSecs load balancer setting: the weight of new endpoints grows with (time/window)^(1/aggression),
+	// so values above 1 warm them faster at first. Envoy defaults to 1, a linear increase.
+	SlowStartAggressionAnnotation = "experimental.istio.io/slow-start-aggression"
+
+	// LeastRequestChoiceCountAnnotation on a DestinationRule sets the number of random endpoints the LEAST_REQUEST
+	// load balancer picks from when endpoint weights are equal, choosing the one with the fewest active requests.
+	// Envoy defaults to 2, the power of two choices.
+	LeastRequestChoiceCountAnnotation = "experimental.istio.io/least-request-choice-count"
+	// LeastRequestActiveRequestBiasAnnotation on a DestinationRule sets how strongly the LEAST_REQUEST load balancer
+	// reduces the weight of endpoints with active requests when endpoint weights differ: weights are divided by
+	// (active requests + 1)^bias. Envoy defaults to 1, and 0 disables the bias.
+	LeastRequestActiveRequestBiasAnnotation = "experimental.istio.io/least-request-active-request-bias"
+
+	// OutlierFailurePercentageThresholdAnnotation on a DestinationRule with outlierDetection enables failure percentage
+	// based ejection: endpoints whose percentage of failed requests reaches this threshold, between 0 and 100, are
+	// ejected. gRPC responses count as failures when their status maps to a 5xx HTTP status, such as UNAVAILABLE,
+	// INTERNAL or DEADLINE_EXCEEDED. With splitExternalLocalOriginErrors, local origin failures are tracked separately.
+	OutlierFailurePercentageThresholdAnnotation = "experimental.istio.io/outlier-failure-percentage-threshold"
+	// OutlierFailurePercentageRequestVolumeAnnotation sets the minimum number of requests an endpoint must receive
+	// during an outlier detection interval to be considered for failure percentage based ejection. Envoy defaults to 50.
+	OutlierFailurePercentageRequestVolumeAnnotation = "experimental.istio.io/outlier-failure-percentage-request-volume"
+	// OutlierFailurePercentageMinimumHostsAnnotation sets the minimum number of endpoints a cluster must have for
+	// failure percentage based ejection to happen. Envoy defaults to 5.
+	OutlierFailurePercentageMinimumHostsAnnotation = "experimental.istio.io/outlier-failure-percentage-minimum-hosts"
+
+	// ProtocolFilterAnnotation on a DestinationRule enables the protocol aware filters of the sidecars and gateways
+	// for the Mongo, MySQL, Kafka and Redis ports of its host, without the mesh wide PILOT_ENABLE_*_FILTER flags. With
+	// ProtocolFilterStats, Mongo, MySQL and Kafka connections go through the filter of their protocol for protocol
+	// stats, and are proxied as TCP; Redis ports are left alone, as the Redis proxy terminates connections.
+	// ProtocolFilterRouting also terminates Redis connections in the Redis proxy, which routes the commands to the
+	// destination of the route, including its subset. Only the outbound traffic to the host is affected.
+	ProtocolFilterAnnotation = "experimental.istio.io/protocol-filter"
+	// ProtocolFilterStats is the ProtocolFilterAnnotation value enabling the stats only filters.
+	ProtocolFilterStats = "stats"
+	// ProtocolFilterRouting is the ProtocolFilterAnnotation value also enabling the Redis proxy.
+	ProtocolFilterRouting = "routing"
+	// RedisOpTimeoutAnnotation on a DestinationRule sets the timeout of the Redis operations proxied to its host, as a
+	// duration such as 500ms. It defaults to 5s.
+	RedisOpTimeoutAnnotation = "experimental.istio.io/redis-op-timeout"
+
+	// JwtCacheSizeAnnotation on a RequestAuthentication enables caching of verified tokens for its JWT rules, up to
+	// the given number of tokens, so repeated requests with the same token skip signature verification.
+	JwtCacheSizeAnnotation = "experimental.istio.io/jwt-cache-size"
+	// JwksCacheDurationAnnotation on a RequestAuthentication sets how long the proxy caches the JWKS it fetches
+	// itself, as a duration such as 10m. It applies only when PILOT_JWT_ENABLE_REMOTE_JWKS is enabled.
+	JwksCacheDurationAnnotation = "experimental.istio.io/jwks-cache-duration"
+	// JwksAsyncFetchAnnotation on a RequestAuthentication makes the proxy fetch the JWKS in the background instead of
+	// on the first request: "true", or "fast-listener" to also not wait for the initial fetch before accepting
+	// connections. It applies only when PILOT_JWT_ENABLE_REMOTE_JWKS is enabled.
+	JwksAsyncFetchAnnotation = "experimental.istio.io/jwks-async-fetch"
+	// OAuth2Annotation on a RequestAuthentication selecting gateways makes them authenticate browser sessions with
+	// the OAuth2 authorization code flow, as the JSON form of security.OAuth2Config. The resulting access token is
+	// validated by the JWT rules of the policy, if any.
+	OAuth2Annotation = "experimental.istio.io/oauth2"
+
+	// IdentityHeadersAnnotation on a PeerAuthentication makes the inbound sidecars of the workloads it applies to
+	// pass the authenticated identity to the application in request headers, as a comma separated list of
+	// <name>=<attribute>, for example principal=source.principal,user=request.auth.claims[sub]. The headers are named
+	// x-istio-identity-<name>. The values sent by clients for the configured headers are always removed; the other
+	// x-istio-identity- headers are passed through unchanged. Claims are set as JSON lists, such as ["alice"], since
+	// a claim can have several values. The most specific policy with the annotation applies: workload, then
+	// namespace, then mesh. An empty value disables the headers.
+	IdentityHeadersAnnotation = "experimental.istio.io/identity-headers"
+
+	// RegexRewriteAnnotation on a VirtualService rewrites the path of requests matching its HTTP routes with a
+	// regular expression, as a JSON object keyed by route name, for example
+	// {"reviews": {"pattern": "^/v1/(.*)$", "substitution": "/\1"}}. It cannot be combined with rewrite.uri.
+	RegexRewriteAnnotation = "experimental.istio.io/regex-rewrite"
+
+	// InternalRedirectsAnnotation on a VirtualService enables internal redirects for its HTTP routes: 3xx responses
+	// are followed by the proxy instead of being returned to the client, up to maxRedirects times. It is a JSON object
+	// keyed by route name, for example {"login": {"maxRedirects": 2, "responseCodes": [301, 302]}}, where "*" applies
+	// to the routes without an entry of their own. Supported response codes are 301, 302, 303, 307 and 308; Envoy
+	// defaults to 302 only. allowCrossScheme allows internal redirects between http and https.
+	InternalRedirectsAnnotation = "experimental.istio.io/internal-redirects"
+
+	// RouteIdleTimeoutAnnotation on a VirtualService sets the idle timeout of its HTTP routes, as a JSON object of
+	// durations keyed by route name, for example {"events": "1h", "*": "5m"}. "*" applies to the routes without an
+	// entry of their own, and 0s disables the idle timeout. Unlike timeout, which bounds the whole request, the idle
+	// timeout only fires when no bytes flow, which suits long polling and streaming.
+	RouteIdleTimeoutAnnotation = "experimental.istio.io/idle-timeout"
+
+	// DirectResponseAnnotation on a VirtualService makes its HTTP routes respond directly from the proxy, without
+	// forwarding requests, as a JSON object keyed by route name, for example
+	// {"maintenance": {"status": 503, "body": "down for maintenance", "headers": {"content-type": "text/plain"}}}.
+	// The routes must have no destination, redirect or delegate. It is not supported on delegate VirtualServices.
+	DirectResponseAnnotation = "experimental.istio.io/direct-response"
+
+	// MirrorsAnnotation on a VirtualService mirrors the requests of its HTTP routes to several destinations, each with
+	// its own percentage, as a JSON object of lists keyed by route name, for example
+	// {"reviews": [{"host": "reviews.staging", "subset": "v2", "port": 9080, "percentage": 10}, {"host": "reviews.qa"}]}.
+	// The percentage defaults to 100. The mirrors are added to the mirror of the route, if any.
+	MirrorsAnnotation = "experimental.istio.io/mirrors"
+
+	// DefaultCorsPolicyAnnotation on a Namespace sets the CORS policy of the virtual hosts of its services and
+	// VirtualServices, in the YAML or JSON form of the VirtualService corsPolicy field, for example
+	// {"allowOrigins": [{"exact": "https://example.com"}], "allowMethods": ["GET"]}. Routes with a corsPolicy of their
+	// own override it. The annotation of the root namespace applies to the namespaces without one.
+	DefaultCorsPolicyAnnotation = "experimental.istio.io/default-cors-policy"
+
+	// LocalRateLimitAnnotation on a VirtualService limits the rate of the requests of its HTTP routes in each proxy,
+	// as a JSON object keyed by route name, for example {"reviews": {"maxTokens": 100, "tokensPerFill": 10,
+	// "fillInterval": "1s", "descriptors": [{"header": "x-plan", "value": "free", "maxTokens": 10,
+	// "fillInterval": "1s"}]}}. "*" applies to the routes without an entry of their own. tokensPerFill defaults to
+	// maxTokens, and the requests with the header value of a descriptor are limited by its bucket instead. Requires
+	// PILOT_ENABLE_LOCAL_RATE_LIMIT.
+	LocalRateLimitAnnotation = "experimental.istio.io/local-rate-limit"
+
+	// GlobalRateLimitAnnotation on a VirtualService lists the descriptors its HTTP routes send to the global rate
+	// limit service, as a JSON object keyed by route name, for example {"*": [["route"], ["route",
+	// "header:x-user-id"]]}. "*" applies to the routes without an entry of their own. Each descriptor is a list of
+	// entries: route (<namespace>/<name>/<route> of the VirtualService), path, destination (the cluster), source
+	// (the client address) or header:<name>. Requires PILOT_GLOBAL_RATE_LIMIT_SERVICE.
+	GlobalRateLimitAnnotation = "experimental.istio.io/global-rate-limit"
+
+	// ExtProcAnnotation on a WasmPlugin makes it send the requests and responses of the workloads it selects to an
+	// external processing gRPC service, instead of running a Wasm module, as a JSON object, for example
+	// {"service": "ext-proc.foo.svc.cluster.local", "port": 9000, "timeout": "100ms", "failOpen": true,
+	// "processingMode": {"requestBodyMode": "BUFFERED", "responseHeaderMode": "SKIP"}}. The processing mode has the
+	// fields of the Envoy ProcessingMode. The selector, phase and priority of the WasmPlugin apply, and its url is
+	// ignored. The port of the service must be a gRPC port, visible to the selected proxies.
+	ExtProcAnnotation = "experimental.istio.io/ext-proc"
+	// ExtProcOverridesAnnotation on a VirtualService changes the external processing of its HTTP routes on gateways,
+	// as a JSON object keyed by route name, then by the <namespace>/<name> of the WasmPlugin declaring the processor,
+	// for example {"health": {"istio-system/ext-proc": {"disabled": true}}}. "*" applies to the routes without an
+	// entry of their own. Each override either disables the processor or sets another processingMode.
+	ExtProcOverridesAnnotation = "experimental.istio.io/ext-proc-overrides"
+	// WasmPluginTypeAnnotation on a WasmPlugin sets the type of the filter it injects: HTTP, the default, or NETWORK
+	// for a Wasm network filter in the TCP filter chains of the workloads it selects. Network filters of the AUTHN and
+	// AUTHZ phases go before the network RBAC and ext_authz filters, the others after them, and all of them before the
+	// TCP proxy. The ext-proc annotation requires the HTTP type.
+	WasmPluginTypeAnnotation = "experimental.istio.io/wasm-plugin-type"
+
+	// TraceOperationAnnotation on a pod, or on a Telemetry, sets the operation name of the spans of the inbound
+	// requests of the workloads, instead of the default <service>:<port>/*. The name may refer to the service
+	// hostname and port as {service} and {port}. The pod annotation wins over the Telemetry, and the most specific
+	// Telemetry with the annotation applies: workload, then namespace, then mesh.
+	TraceOperationAnnotation = "experimental.istio.io/trace-operation"
+
+	// RequestAttemptCountAnnotation on a pod, or on a Telemetry, selects where the outbound virtual hosts of the
+	// sidecars report the attempt count in the x-envoy-attempt-count header: a comma separated list of "request",
+	// for the requests sent upstream, and "response", for the responses sent downstream, or "none". The default is
+	// "request". The pod annotation wins over the Telemetry, and the most specific Telemetry with the annotation
+	// applies: workload, then namespace, then mesh.
+	RequestAttemptCountAnnotation = "experimental.istio.io/request-attempt-count"
+
+	// InboundPortRangesAnnotation on a pod is a comma separated list of ports and inclusive port ranges, such as
+	// "8080,10000-20000", whose inbound traffic is passed through to the workload by a single filter chain instead of
+	// a filter chain and a cluster per port. When the pod lists its inbound ports in
+	// traffic.sidecar.istio.io/includeInboundPorts, the ranges must be listed there too; each is captured by a
+	// single iptables rule.
+	InboundPortRangesAnnotation = "experimental.istio.io/inbound-port-ranges"
+
+	// KubernetesTrafficPolicyAnnotation on a Kubernetes Service selects whether in-mesh traffic honors its
+	// internalTrafficPolicy and topology aware hints: "respect" or "ignore". When unset, the
+	// PILOT_RESPECT_KUBERNETES_TRAFFIC_POLICY default applies.
+	KubernetesTrafficPolicyAnnotation = "experimental.istio.io/kubernetes-traffic-policy"
+	KubernetesTrafficPolicyRespect    = "respect"
+	KubernetesTrafficPolicyIgnore     = "ignore"
+
+	// HTTP3Annotation on a Kubernetes Service, when "true", advertises HTTP/3 support for its HTTP ports to clients
+	// of sidecars whose outbound listeners bind to their ports. Those sidecars get a QUIC listener mirroring the
+	// outbound HTTP listener, terminated with the workload certificate, and add an alt-svc header to the responses
+	// of the service. Requires PILOT_ENABLE_QUIC_LISTENERS.
+	HTTP3Annotation = "experimental.istio.io/http3"
+
+	// EgressCatchAllAnnotation on a Sidecar overrides the catch-all virtual host of its egress listeners, which
+	// otherwise follows the outbound traffic policy. The value is a comma separated list of <port>=<action>, where
+	// <port> is the port of the egress listener, or * for the listener without a port, and <action> is
+	// passthrough, blackhole, blackhole:<status> or cluster:<name>.
+	EgressCatchAllAnnotation = "experimental.istio.io/egress-catch-all"
+
+	// EgressConnectTimeoutAnnotation on a Sidecar sets the connect timeout of the outbound clusters reached through
+	// its egress listeners, unless a DestinationRule sets one. The value is a comma separated list of
+	// <port>=<duration>, where <port> is the port of the egress listener, or * for the listener without a port.
+	EgressConnectTimeoutAnnotation = "experimental.istio.io/egress-connect-timeout"
+
+	// EgressIdleTimeoutAnnotation on a Sidecar sets the idle timeout of the connections egressing through its
+	// listeners, in the format of EgressConnectTimeoutAnnotation.
+	EgressIdleTimeoutAnnotation = "experimental.istio.io/egress-idle-timeout"
+
+	// PassthroughWildcardNamespacesAnnotation on a Sidecar restricts the wildcard domains, such as *.foo.ns.svc,
+	// generated for headless Kubernetes services to the services of the given comma separated namespaces, where .
+	// is the namespace of the Sidecar. An empty value disables the wildcard domains. By default they are generated
+	// for the services of all namespaces, which can shadow the domains of other services.
+	PassthroughWildcardNamespacesAnnotation = "experimental.istio.io/passthrough-wildcard-namespaces"
+
+	// InboundConnectionLimitAnnotation on a Sidecar limits the number of connections the inbound listeners of its
+	// workloads accept for each port, protecting them from connection floods. The value is a comma separated list of
+	// <port>=<max>, where <port> is an inbound port, or * for the ports without an entry of their own. Connections
+	// beyond the limit are closed as soon as they are accepted. Passthrough traffic to other ports is not limited.
+	// The limit applies to each filter chain of the port separately: in PERMISSIVE mode the mTLS and plaintext
+	// connections are counted apart, as are the HTTP and TCP connections of the ports whose protocol is sniffed, so a
+	// port may accept up to a few times <max> connections in total.
+	InboundConnectionLimitAnnotation = "experimental.istio.io/inbound-connection-limit"
+
+	// InboundLocalRateLimitAnnotation on a Sidecar limits the rate of the inbound HTTP requests of the workloads in
+	// each proxy, as a JSON object of local rate limits keyed by inbound port, or * for the ports without an entry of
+	// their own, in the format of LocalRateLimitAnnotation. Requires PILOT_ENABLE_LOCAL_RATE_LIMIT.
+	InboundLocalRateLimitAnnotation = "experimental.istio.io/inbound-local-rate-limit"
+
+	// OutboundUDPPortsAnnotation on a pod is a comma separated list of UDP ports whose outbound traffic is redirected
+	// to the sidecar, which proxies it to the UDP service declaring the port. Requires PILOT_ENABLE_UDP_PROXY.
+	// Port 53 is not redirected when the agent captures DNS.
+	OutboundUDPPortsAnnotation = "experimental.istio.io/outbound-udp-ports"
+
+	// OutboundUDPPrivilegedPortOffset is added to the privileged ports of OutboundUDPPortsAnnotation, below 1024, to
+	// get the port the sidecar listens on for them, as it runs without NET_BIND_SERVICE. Port 514 is redirected to
+	// port 16514, for example.
+	OutboundUDPPrivilegedPortOffset = 16000
+
+	// DrainOnPreStopAnnotation on a pod, when "true", adds a preStop hook to the sidecar that reports the endpoints of
+	// the pod as draining to the other proxies, and waits until they acked it before draining the listeners. The
+	// proxies connected to other istiod replicas are only drained if PILOT_ENABLE_STATUS is set, as the replicas
+	// share the drains in their distribution reports.
+	DrainOnPreStopAnnotation = "experimental.istio.io/drain-on-prestop"
+
+	// ProxyResourceTierAnnotation is set by istiod on the pods of the workloads whose sidecars it autoscales, to the
+	// CPU/memory requests recommended for the sidecars of the workload, such as "500m/256Mi".
+	ProxyResourceTierAnnotation = "experimental.istio.io/proxy-resource-tier"
+
+	// ForwardClientCertDetailsAnnotation on a pod sets how its proxy handles the x-forwarded-client-cert header of
+	// the inbound requests, or of the requests to the gateway: one of SANITIZE, FORWARD_ONLY, APPEND_FORWARD,
+	// SANITIZE_SET and ALWAYS_FORWARD_ONLY.
+	ForwardClientCertDetailsAnnotation = "experimental.istio.io/forward-client-cert-details"
+
+	// SetCurrentClientCertDetailsAnnotation on a pod is a comma separated list of the details of the client
+	// certificate its proxy sets in the x-forwarded-client-cert header: Subject, Cert, Chain, DNS and URI.
+	SetCurrentClientCertDetailsAnnotation = "experimental.istio.io/set-current-client-cert-details"
+
+	// DNSRefreshRateAnnotation on a ServiceEntry with DNS resolution overrides the mesh-wide dnsRefreshRate of its
+	// clusters, such as "5s".
+	DNSRefreshRateAnnotation = "experimental.istio.io/dns-refresh-rate"
+
+	// DNSRespectTTLAnnotation on a ServiceEntry with DNS resolution, when "false", makes proxies refresh its hosts at
+	// the DNS refresh rate instead of the TTL of the DNS records.
+	DNSRespectTTLAnnotation = "experimental.istio.io/dns-respect-ttl"
+
+	// DNSJitterAnnotation on a ServiceEntry with DNS resolution, such as "2s", adds an offset of up to the duration
+	// to the DNS refresh rate of each proxy, so that the proxies do not all query the DNS servers at once. The offset
+	// is derived from the proxy and the cluster, and is stable across pushes.
+	DNSJitterAnnotation = "experimental.istio.io/dns-jitter"
+
+	// ReservedPortPolicyAnnotation on a pod selects what sidecar injection does when its application containers
+	// declare ports in the range 15000-15090 reserved for the sidecar: "warn", the default, admits the pod with a
+	// warning, and "reject" rejects it.
+	ReservedPortPolicyAnnotation = "experimental.istio.io/reserved-port-policy"
+	ReservedPortPolicyWarn       = "warn"
+	ReservedPortPolicyReject     = "reject"
+
+	// RevisionVersionAnnotation is set by istiod on the injection MutatingWebhookConfigurations of its revision to
+	// its version, such as "1.14.0", registering the version of the revision for the other revisions.
+	RevisionVersionAnnotation = "experimental.istio.io/revision-version"
+
+	// FeatureGateLabelPrefix prefixes the workload labels enabling or disabling a config generation feature for
+	// its proxies, such as feature-gates.experimental.istio.io/DeltaClusters: "false".
+	FeatureGateLabelPrefix = "feature-gates.experimental.istio.io/"
+
 	// TrustworthyJWTPath is the default 3P token to authenticate with third party services
 	TrustworthyJWTPath = "./var/run/secrets/tokens/istio-token"
 
@@ -139,4 +418,11 @@ const (
 	// CertProviderNone does not create any certificates for the control plane. It is assumed that some external
 	// load balancer, such as an Istio Gateway, is terminating the TLS.
 	CertProviderNone = "none"
+
+	// ReconnectBackoffHeader is the XDS stream trailer istiod uses to ask clients to wait before reconnecting.
+	// The value is a duration, such as "5s".
+	ReconnectBackoffHeader = "x-istio-reconnect-backoff"
+	// ReconnectJitterHeader is the XDS stream trailer istiod uses to ask clients to add a random delay,
+	// up to the given duration, on top of the reconnect backoff.
+	ReconnectJitterHeader = "x-istio-reconnect-jitter"
 )
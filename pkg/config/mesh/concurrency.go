@@ -0,0 +1,45 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mesh
+
+import (
+	"math"
+
+	"istio.io/pkg/env"
+)
+
+// ProxyConcurrencyHeadroom is the factor applied to the CPU of a proxy to compute its concurrency, both by the
+// injector from the CPU resources of the sidecar, and by the agent from the CPU limit of its container.
+var ProxyConcurrencyHeadroom = env.RegisterFloatVar("PROXY_CONCURRENCY_HEADROOM", 1.0,
+	"The factor applied to the CPU of the proxies when their concurrency is computed from it: by istiod at "+
+		"injection, from the CPU resources of the sidecars, and by the agent, from the CPU limit of its container "+
+		"when the concurrency is 0. Values below 1 leave part of the CPU to the other threads of the proxy, values "+
+		"above 1 oversubscribe the CPU.").Get()
+
+// MilliCPUToConcurrency returns the concurrency of a proxy with the given milli CPU, scaled by the headroom and
+// rounded up to whole threads, so that a 1500m CPU runs 2 worker threads. Envoy treats 0 as all the cores of the
+// node, so the concurrency is at least 1. A headroom which is not positive is ignored.
+func MilliCPUToConcurrency(milli int64, headroom float64) int {
+	if headroom <= 0 {
+		headroom = 1
+	}
+	// Round to the milli CPU first, so that floating point errors do not add a thread.
+	scaled := math.Round(float64(milli) * headroom)
+	concurrency := int(math.Ceil(scaled / 1000))
+	if concurrency < 1 {
+		return 1
+	}
+	return concurrency
+}
@@ -0,0 +1,36 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mesh
+
+import "testing"
+
+func TestMilliCPUToConcurrency(t *testing.T) {
+	for _, tt := range []struct {
+		milli    int64
+		headroom float64
+		out      int
+	}{
+		{milli: 4000, headroom: 1, out: 4},
+		{milli: 4000, headroom: 0.75, out: 3},
+		{milli: 10000, headroom: 0.3, out: 3},
+		{milli: 1500, headroom: 2, out: 3},
+		{milli: 100, headroom: 0.5, out: 1},
+		{milli: 2000, headroom: 0, out: 2},
+	} {
+		if got := MilliCPUToConcurrency(tt.milli, tt.headroom); got != tt.out {
+			t.Errorf("MilliCPUToConcurrency(%d, %v) = %d, want %d", tt.milli, tt.headroom, got, tt.out)
+		}
+	}
+}
@@ -50,6 +50,8 @@ const (
 	Redis Instance = "Redis"
 	// MySQL declares that the port carries MySQL traffic.
 	MySQL Instance = "MySQL"
+	// Kafka declares that the port carries Kafka traffic.
+	Kafka Instance = "Kafka"
 	// Unsupported - value to signify that the protocol is unsupported.
 	Unsupported Instance = "UnsupportedProtocol"
 )
@@ -81,6 +83,8 @@ func Parse(s string) Instance {
 		return Redis
 	case "mysql":
 		return MySQL
+	case "kafka":
+		return Kafka
 	}
 
 	return Unsupported
@@ -109,7 +113,7 @@ func (i Instance) IsHTTP() bool {
 // IsTCP is true for protocols that use TCP as transport protocol
 func (i Instance) IsTCP() bool {
 	switch i {
-	case TCP, HTTPS, TLS, Mongo, Redis, MySQL:
+	case TCP, HTTPS, TLS, Mongo, Redis, MySQL, Kafka:
 		return true
 	default:
 		return false
@@ -149,3 +153,18 @@ func (i Instance) IsGRPC() bool {
 func (i Instance) IsUnsupported() bool {
 	return i == Unsupported
 }
+
+// UnsupportedTransportReason explains what happens to the traffic of a port using a transport protocol the proxy does
+// not handle, such as the protocol of a Kubernetes service port. It returns an empty string if the proxy handles the
+// transport protocol. UDP is only handled when udpProxy is set.
+func UnsupportedTransportReason(transport string, udpProxy bool) string {
+	switch strings.ToLower(transport) {
+	case "sctp":
+		return "SCTP is not supported by the proxy, the traffic of the port is not captured and bypasses the proxy"
+	case "udp":
+		if !udpProxy {
+			return "UDP is only proxied when PILOT_ENABLE_UDP_PROXY is enabled, the port is ignored and its traffic bypasses the proxy"
+		}
+	}
+	return ""
+}
@@ -58,6 +58,8 @@ func TestParse(t *testing.T) {
 		{"mysql", protocol.MySQL},
 		{"MYSQL", protocol.MySQL},
 		{"MySQL", protocol.MySQL},
+		{"kafka", protocol.Kafka},
+		{"Kafka", protocol.Kafka},
 		{"", protocol.Unsupported},
 		{"SMTP", protocol.Unsupported},
 	}
@@ -75,3 +77,25 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestUnsupportedTransportReason(t *testing.T) {
+	cases := []struct {
+		transport   string
+		udpProxy    bool
+		unsupported bool
+	}{
+		{"TCP", false, false},
+		{"", false, false},
+		{"SCTP", false, true},
+		{"SCTP", true, true},
+		{"UDP", false, true},
+		{"udp", false, true},
+		{"UDP", true, false},
+	}
+	for _, tt := range cases {
+		reason := protocol.UnsupportedTransportReason(tt.transport, tt.udpProxy)
+		if (reason != "") != tt.unsupported {
+			t.Errorf("UnsupportedTransportReason(%q, %v) => %q, want unsupported %v", tt.transport, tt.udpProxy, reason, tt.unsupported)
+		}
+	}
+}
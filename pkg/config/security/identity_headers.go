@@ -0,0 +1,86 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IdentityHeaderPrefix is the prefix of the headers carrying the authenticated identity to the workload.
+// The values sent by clients are removed for the configured headers only, not for every header with the prefix.
+const IdentityHeaderPrefix = "x-istio-identity-"
+
+// Identity attributes that can be mapped to headers.
+const (
+	IdentityAttrSourcePrincipal   = "source.principal"
+	IdentityAttrRequestPrincipal  = "request.auth.principal"
+	IdentityAttrRequestAudiences  = "request.auth.audiences"
+	IdentityAttrRequestPresenter  = "request.auth.presenter"
+	identityAttrRequestClaimsOpen = "request.auth.claims["
+)
+
+var identityHeaderNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// IdentityHeader maps an attribute of the authenticated identity to a request header.
+type IdentityHeader struct {
+	// Header is the full header name, including IdentityHeaderPrefix.
+	Header string
+	// Attribute is the identity attribute, such as source.principal or request.auth.claims[sub].
+	Attribute string
+	// Claim is the claim name for request.auth.claims attributes.
+	Claim string
+}
+
+// ParseIdentityHeaders parses a comma separated list of <name>=<attribute>, where the header
+// IdentityHeaderPrefix+<name> is set to the value of <attribute>. Supported attributes are source.principal,
+// request.auth.principal, request.auth.audiences, request.auth.presenter and request.auth.claims[<claim>].
+func ParseIdentityHeaders(value string) ([]IdentityHeader, error) {
+	var out []IdentityHeader
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid identity header %q, expected <name>=<attribute>", entry)
+		}
+		name, attr := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if !identityHeaderNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid identity header name %q, must be lowercase alphanumeric or '-'", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate identity header name %q", name)
+		}
+		seen[name] = true
+		h := IdentityHeader{Header: IdentityHeaderPrefix + name, Attribute: attr}
+		switch attr {
+		case IdentityAttrSourcePrincipal, IdentityAttrRequestPrincipal, IdentityAttrRequestAudiences, IdentityAttrRequestPresenter:
+		default:
+			if !strings.HasPrefix(attr, identityAttrRequestClaimsOpen) || !strings.HasSuffix(attr, "]") {
+				return nil, fmt.Errorf("unsupported identity attribute %q", attr)
+			}
+			h.Claim = strings.TrimSuffix(strings.TrimPrefix(attr, identityAttrRequestClaimsOpen), "]")
+			if h.Claim == "" || strings.ContainsAny(h.Claim, "[]:") {
+				return nil, fmt.Errorf("invalid claim in identity attribute %q", attr)
+			}
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
@@ -0,0 +1,97 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// OAuth2ClientSecretKey is the key of the OAuth2 client secret in the credential Secret.
+	OAuth2ClientSecretKey = "client-secret"
+	// OAuth2HmacSecretKey is the key of the secret used to sign the OAuth2 cookies in the credential Secret.
+	OAuth2HmacSecretKey = "hmac-secret"
+)
+
+// OAuth2Config configures the OAuth2 authorization code flow at a gateway, so that browser sessions are
+// authenticated by the gateway.
+type OAuth2Config struct {
+	// ClientID is the OAuth2 client ID of the gateway.
+	ClientID string `json:"clientID"`
+	// CredentialName is the Secret, in the namespace of the gateway, holding the client secret in
+	// OAuth2ClientSecretKey and the secret used to sign cookies in OAuth2HmacSecretKey.
+	CredentialName string `json:"credentialName"`
+	// TokenEndpoint is the URI of the token endpoint of the authorization server. It must be the host of
+	// a service of the mesh, such as a ServiceEntry.
+	TokenEndpoint string `json:"tokenEndpoint"`
+	// AuthorizationEndpoint is the URI users are redirected to for authorization.
+	AuthorizationEndpoint string `json:"authorizationEndpoint"`
+	// RedirectURI is the callback URI passed to the authorization server, for example
+	// https://%REQ(:authority)%/oauth2/callback.
+	RedirectURI string `json:"redirectURI"`
+	// RedirectPath is the path of RedirectURI, where the gateway handles the callback.
+	RedirectPath string `json:"redirectPath"`
+	// SignoutPath is the path where the gateway clears the session cookies, if set.
+	SignoutPath string `json:"signoutPath,omitempty"`
+	// Scopes are the requested scopes. The authorization server default is used if empty.
+	Scopes []string `json:"scopes,omitempty"`
+	// ForwardBearerToken forwards the access token to the backends in the Authorization header.
+	ForwardBearerToken bool `json:"forwardBearerToken,omitempty"`
+	// Cookies overrides the names of the session cookies.
+	Cookies *OAuth2Cookies `json:"cookies,omitempty"`
+}
+
+// OAuth2Cookies are the names of the OAuth2 session cookies. Envoy defaults are used for empty names.
+type OAuth2Cookies struct {
+	BearerToken string `json:"bearerToken,omitempty"`
+	Hmac        string `json:"hmac,omitempty"`
+	Expires     string `json:"expires,omitempty"`
+}
+
+// ParseOAuth2Config parses the JSON form of OAuth2Config.
+func ParseOAuth2Config(value string) (*OAuth2Config, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	out := &OAuth2Config{}
+	if err := decoder.Decode(out); err != nil {
+		return nil, fmt.Errorf("invalid oauth2 config: %v", err)
+	}
+	if out.ClientID == "" {
+		return nil, fmt.Errorf("oauth2 config must have a clientID")
+	}
+	if out.CredentialName == "" {
+		return nil, fmt.Errorf("oauth2 config must have a credentialName")
+	}
+	if _, err := ParseJwksURI(out.TokenEndpoint); err != nil {
+		return nil, fmt.Errorf("invalid oauth2 tokenEndpoint %q: %v", out.TokenEndpoint, err)
+	}
+	if u, err := url.Parse(out.AuthorizationEndpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid oauth2 authorizationEndpoint %q", out.AuthorizationEndpoint)
+	}
+	if out.RedirectURI == "" {
+		return nil, fmt.Errorf("oauth2 config must have a redirectURI")
+	}
+	if !strings.HasPrefix(out.RedirectPath, "/") {
+		return nil, fmt.Errorf("oauth2 redirectPath must start with /, got %q", out.RedirectPath)
+	}
+	if out.SignoutPath != "" && !strings.HasPrefix(out.SignoutPath, "/") {
+		return nil, fmt.Errorf("oauth2 signoutPath must start with /, got %q", out.SignoutPath)
+	}
+	return out, nil
+}
@@ -16,6 +16,7 @@ package security_test
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"istio.io/istio/pkg/config/security"
@@ -218,3 +219,65 @@ func TestValidateCondition(t *testing.T) {
 		}
 	}
 }
+
+func TestParseOAuth2Config(t *testing.T) {
+	valid := `"clientID": "gateway", "credentialName": "oauth2", "tokenEndpoint": "https://oauth.example.com/token",
+		"authorizationEndpoint": "https://oauth.example.com/authorize", "redirectURI": "https://%REQ(:authority)%/callback",
+		"redirectPath": "/callback"`
+	cases := []struct {
+		name      string
+		in        string
+		wantError bool
+	}{
+		{name: "valid", in: "{" + valid + "}"},
+		{name: "full", in: "{" + valid + `, "signoutPath": "/signout", "scopes": ["openid"], "forwardBearerToken": true,
+			"cookies": {"bearerToken": "token", "hmac": "hmac", "expires": "expires"}}`},
+		{name: "not json", in: "gateway", wantError: true},
+		{name: "unknown field", in: "{" + valid + `, "clientSecret": "secret"}`, wantError: true},
+		{name: "missing client id", in: strings.Replace("{"+valid+"}", `"gateway"`, `""`, 1), wantError: true},
+		{name: "missing credential", in: strings.Replace("{"+valid+"}", `"oauth2"`, `""`, 1), wantError: true},
+		{name: "invalid token endpoint", in: strings.Replace("{"+valid+"}", "https://oauth.example.com/token", "oauth", 1), wantError: true},
+		{name: "relative redirect path", in: strings.Replace("{"+valid+"}", `"/callback"`, `"callback"`, 1), wantError: true},
+		{name: "relative signout path", in: "{" + valid + `, "signoutPath": "signout"}`, wantError: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := security.ParseOAuth2Config(c.in)
+			if c.wantError == (err == nil) {
+				t.Fatalf("ParseOAuth2Config(%s): want error (%v) but got (%v)", c.in, c.wantError, err)
+			}
+		})
+	}
+}
+
+func TestParseIdentityHeaders(t *testing.T) {
+	cases := []struct {
+		in        string
+		expected  []security.IdentityHeader
+		wantError bool
+	}{
+		{in: ""},
+		{
+			in: "principal=source.principal, user=request.auth.claims[sub]",
+			expected: []security.IdentityHeader{
+				{Header: "x-istio-identity-principal", Attribute: "source.principal"},
+				{Header: "x-istio-identity-user", Attribute: "request.auth.claims[sub]", Claim: "sub"},
+			},
+		},
+		{in: "principal", wantError: true},
+		{in: "Principal=source.principal", wantError: true},
+		{in: "principal=source.principal,principal=request.auth.principal", wantError: true},
+		{in: "namespace=source.namespace", wantError: true},
+		{in: "user=request.auth.claims[]", wantError: true},
+		{in: "user=request.auth.claims[a][b]", wantError: true},
+	}
+	for _, c := range cases {
+		got, err := security.ParseIdentityHeaders(c.in)
+		if c.wantError == (err == nil) {
+			t.Fatalf("ParseIdentityHeaders(%s): want error (%v) but got (%v)", c.in, c.wantError, err)
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("ParseIdentityHeaders(%s): got %v, want %v", c.in, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/api/annotation"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/xds"
+)
+
+// alphaAnnotationPrefix is the prefix of the annotations superseded by the istio.io API fields.
+const alphaAnnotationPrefix = "alpha.istio.io/"
+
+// Deprecation is a use of a deprecated field, annotation or name, which a future release may stop supporting.
+type Deprecation struct {
+	// Feature identifies the deprecated feature, for example VirtualService.http.mirrorPercent.
+	Feature string `json:"feature"`
+	Message string `json:"message"`
+}
+
+var deprecatedAnnotations = func() map[string]bool {
+	out := map[string]bool{}
+	for _, a := range annotation.AllResourceAnnotations() {
+		if a.Deprecated {
+			out[a.Name] = true
+		}
+	}
+	return out
+}()
+
+// FindDeprecations returns the deprecated annotations of the config, and the deprecated fields and names of its
+// spec, sorted by feature.
+func FindDeprecations(cfg config.Config) []Deprecation {
+	out := FindAnnotationDeprecations(cfg.Annotations)
+	switch spec := cfg.Spec.(type) {
+	case *networking.VirtualService:
+		out = append(out, virtualServiceDeprecations(spec)...)
+	case *networking.DestinationRule:
+		out = append(out, destinationRuleDeprecations(spec)...)
+	case *networking.EnvoyFilter:
+		out = append(out, envoyFilterDeprecations(spec)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Feature < out[j].Feature
+	})
+	return out
+}
+
+// FindAnnotationDeprecations returns the deprecated and alpha annotations, of a config or of a pod.
+func FindAnnotationDeprecations(annotations map[string]string) []Deprecation {
+	var out []Deprecation
+	for name := range annotations {
+		if deprecatedAnnotations[name] || strings.HasPrefix(name, alphaAnnotationPrefix) {
+			out = append(out, Deprecation{
+				Feature: "annotation:" + name,
+				Message: fmt.Sprintf("annotation %s is deprecated", name),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Feature < out[j].Feature
+	})
+	return out
+}
+
+// nolint: staticcheck
+func virtualServiceDeprecations(vs *networking.VirtualService) []Deprecation {
+	var out []Deprecation
+	for _, r := range vs.Http {
+		if r.MirrorPercent != nil {
+			out = append(out, Deprecation{
+				Feature: "VirtualService.http.mirrorPercent",
+				Message: fmt.Sprintf("http route %q uses mirrorPercent; use mirrorPercentage instead", r.Name),
+			})
+		}
+		if len(r.GetCorsPolicy().GetAllowOrigin()) > 0 {
+			out = append(out, Deprecation{
+				Feature: "VirtualService.http.corsPolicy.allowOrigin",
+				Message: fmt.Sprintf("http route %q uses corsPolicy.allowOrigin; use allowOrigins instead", r.Name),
+			})
+		}
+		if r.GetFault().GetDelay().GetPercent() != 0 {
+			out = append(out, Deprecation{
+				Feature: "VirtualService.http.fault.delay.percent",
+				Message: fmt.Sprintf("http route %q uses fault.delay.percent; use percentage instead", r.Name),
+			})
+		}
+	}
+	return out
+}
+
+func destinationRuleDeprecations(dr *networking.DestinationRule) []Deprecation {
+	var out []Deprecation
+	policies := []*networking.TrafficPolicy{dr.TrafficPolicy}
+	for _, s := range dr.Subsets {
+		policies = append(policies, s.TrafficPolicy)
+	}
+	for _, p := range policies {
+		out = append(out, trafficPolicyDeprecations(p.GetLoadBalancer(), p.GetOutlierDetection())...)
+		for _, pl := range p.GetPortLevelSettings() {
+			out = append(out, trafficPolicyDeprecations(pl.LoadBalancer, pl.OutlierDetection)...)
+		}
+	}
+	return out
+}
+
+// nolint: staticcheck
+func trafficPolicyDeprecations(lb *networking.LoadBalancerSettings, outlier *networking.OutlierDetection) []Deprecation {
+	var out []Deprecation
+	if lb.GetSimple() == networking.LoadBalancerSettings_LEAST_CONN {
+		out = append(out, Deprecation{
+			Feature: "DestinationRule.trafficPolicy.loadBalancer.LEAST_CONN",
+			Message: "load balancer LEAST_CONN is deprecated; use LEAST_REQUEST instead",
+		})
+	}
+	if outlier.GetConsecutiveErrors() != 0 {
+		out = append(out, Deprecation{
+			Feature: "DestinationRule.trafficPolicy.outlierDetection.consecutiveErrors",
+			Message: "outlier detection consecutiveErrors is deprecated; use consecutiveGatewayErrors or consecutive5xxErrors instead",
+		})
+	}
+	return out
+}
+
+func envoyFilterDeprecations(ef *networking.EnvoyFilter) []Deprecation {
+	var out []Deprecation
+	for _, cp := range ef.ConfigPatches {
+		filter := cp.GetMatch().GetListener().GetFilterChain().GetFilter()
+		for _, name := range []string{filter.GetName(), filter.GetSubFilter().GetName()} {
+			if newName, f := xds.ReverseDeprecatedFilterNames[name]; f {
+				out = append(out, Deprecation{
+					Feature: "EnvoyFilter.filterName",
+					Message: fmt.Sprintf("filter name %q is deprecated; use %q instead", name, newName),
+				})
+			}
+		}
+		if cp.GetPatch().GetValue() == nil {
+			continue
+		}
+		obj, err := xds.BuildXDSObjectFromStruct(cp.ApplyTo, cp.Patch.Value, false)
+		if err != nil || obj == nil {
+			continue
+		}
+		// Types that cannot be resolved are rejected by validation, so errors are not reported here.
+		types, _ := recurseDeprecatedTypes(obj.ProtoReflect())
+		for _, t := range types {
+			out = append(out, Deprecation{
+				Feature: "EnvoyFilter.typeUrl",
+				Message: fmt.Sprintf("type %s is deprecated", t),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/api/annotation"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+)
+
+func TestFindDeprecations(t *testing.T) {
+	cases := []struct {
+		name     string
+		cfg      config.Config
+		expected []string
+	}{
+		{
+			name: "annotations",
+			cfg: config.Config{
+				Meta: config.Meta{Annotations: map[string]string{
+					annotation.AlphaIdentity.Name:                 "foo",
+					annotation.SidecarStatsInclusionPrefixes.Name: "foo",
+					annotation.SidecarInject.Name:                 "true",
+				}},
+				Spec: &networking.Sidecar{},
+			},
+			expected: []string{"annotation:alpha.istio.io/identity", "annotation:sidecar.istio.io/statsInclusionPrefixes"},
+		},
+		{
+			name: "virtual service",
+			cfg: config.Config{
+				Spec: &networking.VirtualService{Http: []*networking.HTTPRoute{{
+					MirrorPercent: &types.UInt32Value{Value: 10},
+					CorsPolicy:    &networking.CorsPolicy{AllowOrigin: []string{"foo"}},
+					Fault: &networking.HTTPFaultInjection{Delay: &networking.HTTPFaultInjection_Delay{
+						Percent:       10,
+						HttpDelayType: &networking.HTTPFaultInjection_Delay_FixedDelay{FixedDelay: &types.Duration{Seconds: 1}},
+					}},
+				}}},
+			},
+			expected: []string{
+				"VirtualService.http.corsPolicy.allowOrigin",
+				"VirtualService.http.fault.delay.percent",
+				"VirtualService.http.mirrorPercent",
+			},
+		},
+		{
+			name: "destination rule",
+			cfg: config.Config{
+				Spec: &networking.DestinationRule{
+					TrafficPolicy: &networking.TrafficPolicy{
+						OutlierDetection: &networking.OutlierDetection{ConsecutiveErrors: 5},
+					},
+					Subsets: []*networking.Subset{{
+						Name: "v1",
+						TrafficPolicy: &networking.TrafficPolicy{PortLevelSettings: []*networking.TrafficPolicy_PortTrafficPolicy{{
+							LoadBalancer: &networking.LoadBalancerSettings{LbPolicy: &networking.LoadBalancerSettings_Simple{
+								Simple: networking.LoadBalancerSettings_LEAST_CONN,
+							}},
+						}}},
+					}},
+				},
+			},
+			expected: []string{
+				"DestinationRule.trafficPolicy.loadBalancer.LEAST_CONN",
+				"DestinationRule.trafficPolicy.outlierDetection.consecutiveErrors",
+			},
+		},
+		{
+			name: "envoy filter",
+			cfg: config.Config{
+				Spec: &networking.EnvoyFilter{ConfigPatches: []*networking.EnvoyFilter_EnvoyConfigObjectPatch{{
+					ApplyTo: networking.EnvoyFilter_HTTP_FILTER,
+					Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+						ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+							Listener: &networking.EnvoyFilter_ListenerMatch{
+								FilterChain: &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+									Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+										Name:      "envoy.http_connection_manager",
+										SubFilter: &networking.EnvoyFilter_ListenerMatch_SubFilterMatch{Name: "envoy.filters.http.router"},
+									},
+								},
+							},
+						},
+					},
+				}}},
+			},
+			expected: []string{"EnvoyFilter.filterName"},
+		},
+		{
+			name: "none",
+			cfg: config.Config{
+				Spec: &networking.VirtualService{Http: []*networking.HTTPRoute{{
+					MirrorPercentage: &networking.Percent{Value: 10},
+				}}},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range FindDeprecations(tt.cfg) {
+				got = append(got, d.Feature)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
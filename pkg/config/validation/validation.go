@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -47,7 +48,7 @@ import (
 	telemetry "istio.io/api/telemetry/v1alpha1"
 	type_beta "istio.io/api/type/v1beta1"
 	"istio.io/istio/pilot/pkg/features"
-	"istio.io/istio/pilot/pkg/util/constant"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/util/sets"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/constants"
@@ -529,7 +530,8 @@ func validateServerPort(port *networking.Port) (errs error) {
 	if port == nil {
 		return appendErrors(errs, fmt.Errorf("port is required"))
 	}
-	if protocol.Parse(port.Protocol) == protocol.Unsupported {
+	// Gateway servers do not support the Kafka protocol, only the kafka ports of services do.
+	if p := protocol.Parse(port.Protocol); p == protocol.Unsupported || p == protocol.Kafka {
 		errs = appendErrors(errs, fmt.Errorf("invalid protocol %q, supported protocols are HTTP, HTTP2, GRPC, GRPC-WEB, MONGO, REDIS, MYSQL, TCP", port.Protocol))
 	}
 	if port.Number > 0 {
@@ -680,9 +682,437 @@ var ValidateDestinationRule = registerValidateFunc("ValidateDestinationRule",
 		}
 
 		v = appendValidation(v, validateExportTo(cfg.Namespace, rule.ExportTo, false))
+		v = appendValidation(v, validateRetryBudgetAnnotations(cfg.Annotations))
+		v = appendValidation(v, validateLoadBalancingPolicyAnnotation(cfg.Annotations, rule))
+		v = appendValidation(v, validateOriginalDstAnnotation(cfg.Annotations, rule))
+		v = appendValidation(v, validateSlowStartAggressionAnnotation(cfg.Annotations, rule))
+		v = appendValidation(v, validateLeastRequestAnnotations(cfg.Annotations))
+		v = appendValidation(v, validateOutlierFailurePercentageAnnotations(cfg.Annotations, rule))
+		v = appendValidation(v, validateProtocolFilterAnnotations(cfg.Annotations))
 		return v.Unwrap()
 	})
 
+func validateLoadBalancingPolicyAnnotation(annotations map[string]string, rule *networking.DestinationRule) (errs Validation) {
+	value, f := annotations[constants.LoadBalancingPolicyAnnotation]
+	if !f {
+		return
+	}
+	if _, err := istionetworking.ParseLoadBalancingPolicy(value); err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.LoadBalancingPolicyAnnotation, err))
+	}
+	overridden := rule.GetTrafficPolicy().GetLoadBalancer() != nil
+	for _, subset := range rule.Subsets {
+		overridden = overridden || subset.GetTrafficPolicy().GetLoadBalancer() != nil
+	}
+	if overridden {
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s overrides the loadBalancer settings of the destination rule",
+			constants.LoadBalancingPolicyAnnotation)))
+	}
+	return
+}
+
+func validateOriginalDstAnnotation(annotations map[string]string, rule *networking.DestinationRule) (errs Validation) {
+	value, f := annotations[constants.OriginalDstAnnotation]
+	if !f {
+		return
+	}
+	switch value {
+	case "false":
+		return
+	case "true":
+	case constants.OriginalDstUseHTTPHeader:
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: %s lets clients send requests to arbitrary addresses "+
+			"with the x-envoy-original-dst-host header", constants.OriginalDstAnnotation, value)))
+	default:
+		return appendValidation(errs, fmt.Errorf("%s must be one of true, false or %s, got %q",
+			constants.OriginalDstAnnotation, constants.OriginalDstUseHTTPHeader, value))
+	}
+	overridden := rule.GetTrafficPolicy().GetLoadBalancer() != nil
+	for _, subset := range rule.Subsets {
+		overridden = overridden || subset.GetTrafficPolicy().GetLoadBalancer() != nil
+	}
+	if overridden {
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: the loadBalancer settings of the destination rule are ignored",
+			constants.OriginalDstAnnotation)))
+	}
+	return
+}
+
+func validateSlowStartAggressionAnnotation(annotations map[string]string, rule *networking.DestinationRule) (errs Validation) {
+	value, f := annotations[constants.SlowStartAggressionAnnotation]
+	if !f {
+		return
+	}
+	if aggression, err := strconv.ParseFloat(value, 64); err != nil || aggression <= 0 {
+		return appendValidation(errs, fmt.Errorf("%s must be a number greater than 0, got %q",
+			constants.SlowStartAggressionAnnotation, value))
+	}
+	hasWarmup := func(policy *networking.TrafficPolicy) bool {
+		if policy.GetLoadBalancer().GetWarmupDurationSecs() != nil {
+			return true
+		}
+		for _, pls := range policy.GetPortLevelSettings() {
+			if pls.GetLoadBalancer().GetWarmupDurationSecs() != nil {
+				return true
+			}
+		}
+		return false
+	}
+	warmup := hasWarmup(rule.TrafficPolicy)
+	for _, subset := range rule.Subsets {
+		warmup = warmup || hasWarmup(subset.GetTrafficPolicy())
+	}
+	if !warmup {
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s has no effect without warmupDurationSecs",
+			constants.SlowStartAggressionAnnotation)))
+	}
+	return
+}
+
+func validateLeastRequestAnnotations(annotations map[string]string) (errs error) {
+	if value, f := annotations[constants.LeastRequestChoiceCountAnnotation]; f {
+		if n, err := strconv.ParseUint(value, 10, 32); err != nil || n < 2 {
+			errs = appendErrors(errs, fmt.Errorf("%s must be an integer of at least 2, got %q",
+				constants.LeastRequestChoiceCountAnnotation, value))
+		}
+	}
+	if value, f := annotations[constants.LeastRequestActiveRequestBiasAnnotation]; f {
+		if bias, err := strconv.ParseFloat(value, 64); err != nil || bias < 0 {
+			errs = appendErrors(errs, fmt.Errorf("%s must be a non-negative number, got %q",
+				constants.LeastRequestActiveRequestBiasAnnotation, value))
+		}
+	}
+	return
+}
+
+func validateOutlierFailurePercentageAnnotations(annotations map[string]string, rule *networking.DestinationRule) (errs Validation) {
+	threshold, hasThreshold := annotations[constants.OutlierFailurePercentageThresholdAnnotation]
+	if hasThreshold {
+		if p, err := strconv.ParseUint(threshold, 10, 32); err != nil || p > 100 {
+			errs = appendValidation(errs, fmt.Errorf("%s must be a percentage between 0 and 100, got %q",
+				constants.OutlierFailurePercentageThresholdAnnotation, threshold))
+		}
+	}
+	for _, key := range []string{constants.OutlierFailurePercentageRequestVolumeAnnotation, constants.OutlierFailurePercentageMinimumHostsAnnotation} {
+		value, f := annotations[key]
+		if !f {
+			continue
+		}
+		if !hasThreshold {
+			errs = appendValidation(errs, fmt.Errorf("%s requires %s", key, constants.OutlierFailurePercentageThresholdAnnotation))
+		}
+		if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+			errs = appendValidation(errs, fmt.Errorf("%s must be a non-negative integer, got %q", key, value))
+		}
+	}
+	if !hasThreshold {
+		return
+	}
+	hasOutlierDetection := func(policy *networking.TrafficPolicy) bool {
+		if policy.GetOutlierDetection() != nil {
+			return true
+		}
+		for _, pls := range policy.GetPortLevelSettings() {
+			if pls.GetOutlierDetection() != nil {
+				return true
+			}
+		}
+		return false
+	}
+	outlierDetection := hasOutlierDetection(rule.TrafficPolicy)
+	for _, subset := range rule.Subsets {
+		outlierDetection = outlierDetection || hasOutlierDetection(subset.GetTrafficPolicy())
+	}
+	if !outlierDetection {
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s has no effect without outlierDetection",
+			constants.OutlierFailurePercentageThresholdAnnotation)))
+	}
+	return
+}
+
+func validateProtocolFilterAnnotations(annotations map[string]string) (errs Validation) {
+	mode, hasMode := annotations[constants.ProtocolFilterAnnotation]
+	if hasMode && mode != constants.ProtocolFilterStats && mode != constants.ProtocolFilterRouting {
+		errs = appendValidation(errs, fmt.Errorf("%s must be %s or %s, got %q",
+			constants.ProtocolFilterAnnotation, constants.ProtocolFilterStats, constants.ProtocolFilterRouting, mode))
+	}
+	value, f := annotations[constants.RedisOpTimeoutAnnotation]
+	if !f {
+		return
+	}
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		errs = appendValidation(errs, fmt.Errorf("%s must be a positive duration, got %q", constants.RedisOpTimeoutAnnotation, value))
+	}
+	if mode != constants.ProtocolFilterRouting && !features.EnableRedisFilter {
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s has no effect without %s: %s",
+			constants.RedisOpTimeoutAnnotation, constants.ProtocolFilterAnnotation, constants.ProtocolFilterRouting)))
+	}
+	return
+}
+
+func validateRetryBudgetAnnotations(annotations map[string]string) (errs error) {
+	percent, hasPercent := annotations[constants.RetryBudgetPercentAnnotation]
+	if hasPercent {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || p < 0 || p > 100 {
+			errs = appendErrors(errs, fmt.Errorf("%s must be a percentage between 0 and 100, got %q",
+				constants.RetryBudgetPercentAnnotation, percent))
+		}
+	}
+	if concurrency, f := annotations[constants.RetryBudgetMinRetryConcurrencyAnnotation]; f {
+		if !hasPercent {
+			errs = appendErrors(errs, fmt.Errorf("%s requires %s",
+				constants.RetryBudgetMinRetryConcurrencyAnnotation, constants.RetryBudgetPercentAnnotation))
+		}
+		if _, err := strconv.ParseUint(concurrency, 10, 32); err != nil {
+			errs = appendErrors(errs, fmt.Errorf("%s must be a non-negative integer, got %q",
+				constants.RetryBudgetMinRetryConcurrencyAnnotation, concurrency))
+		}
+	}
+	return
+}
+
+// httpRoute returns the HTTP route of the VirtualService with the name, or nil.
+func httpRoute(vs *networking.VirtualService, name string) *networking.HTTPRoute {
+	for _, r := range vs.Http {
+		if r.GetName() == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// validateRouteNames warns about the sorted names of the routes configured by the annotation of the VirtualService
+// which are not HTTP routes of the VirtualService. allRoutes is set if the annotation supports the AllRoutes key.
+func validateRouteNames(annotation string, names []string, vs *networking.VirtualService, allRoutes bool) (errs Validation) {
+	for _, name := range names {
+		if allRoutes && name == istionetworking.AllRoutes {
+			continue
+		}
+		if httpRoute(vs, name) == nil {
+			errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: no http route named %s", annotation, name)))
+		}
+	}
+	return
+}
+
+func validateRegexRewriteAnnotation(annotations map[string]string, vs *networking.VirtualService) (errs Validation) {
+	value, f := annotations[constants.RegexRewriteAnnotation]
+	if !f {
+		return
+	}
+	rewrites, err := istionetworking.ParseRegexRewrites(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.RegexRewriteAnnotation, err))
+	}
+	names := make([]string, 0, len(rewrites))
+	for name := range rewrites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs = validateRouteNames(constants.RegexRewriteAnnotation, names, vs, false)
+	for _, name := range names {
+		r := httpRoute(vs, name)
+		switch {
+		case r == nil:
+		case r.Rewrite.GetUri() != "":
+			errs = appendValidation(errs, fmt.Errorf("%s: http route %s cannot have both a regex rewrite and rewrite.uri",
+				constants.RegexRewriteAnnotation, name))
+		case r.Redirect != nil:
+			errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: http route %s is a redirect and is not rewritten",
+				constants.RegexRewriteAnnotation, name)))
+		}
+	}
+	return
+}
+
+// directResponseRoutes returns the names of the HTTP routes with a direct response, or nil if the annotation is
+// invalid, which is reported by validateDirectResponseAnnotation.
+func directResponseRoutes(annotations map[string]string) map[string]bool {
+	value, f := annotations[constants.DirectResponseAnnotation]
+	if !f {
+		return nil
+	}
+	responses, err := istionetworking.ParseDirectResponses(value)
+	if err != nil {
+		return nil
+	}
+	out := make(map[string]bool, len(responses))
+	for name := range responses {
+		out[name] = true
+	}
+	return out
+}
+
+func validateDirectResponseAnnotation(annotations map[string]string, vs *networking.VirtualService) (errs Validation) {
+	value, f := annotations[constants.DirectResponseAnnotation]
+	if !f {
+		return
+	}
+	if len(vs.Hosts) == 0 {
+		// The annotations of delegate VirtualServices are not merged into the root VirtualService.
+		return appendValidation(errs, fmt.Errorf("%s is not supported on delegate virtual services",
+			constants.DirectResponseAnnotation))
+	}
+	responses, err := istionetworking.ParseDirectResponses(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.DirectResponseAnnotation, err))
+	}
+	names := make([]string, 0, len(responses))
+	for name := range responses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs = validateRouteNames(constants.DirectResponseAnnotation, names, vs, false)
+	for _, name := range names {
+		response := responses[name]
+		headers := make([]string, 0, len(response.Headers))
+		for header := range response.Headers {
+			headers = append(headers, header)
+		}
+		sort.Strings(headers)
+		for _, header := range headers {
+			errs = appendValidation(errs, ValidateHTTPHeaderOperationName(header))
+			errs = appendValidation(errs, ValidateHTTPHeaderValue(response.Headers[header]))
+		}
+	}
+	return
+}
+
+func validateMirrorsAnnotation(annotations map[string]string, vs *networking.VirtualService) (errs Validation) {
+	value, f := annotations[constants.MirrorsAnnotation]
+	if !f {
+		return
+	}
+	mirrors, err := istionetworking.ParseMirrors(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.MirrorsAnnotation, err))
+	}
+	names := make([]string, 0, len(mirrors))
+	for name := range mirrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs = validateRouteNames(constants.MirrorsAnnotation, names, vs, false)
+	directResponses := directResponseRoutes(annotations)
+	for _, name := range names {
+		if r := httpRoute(vs, name); r != nil && (r.Redirect != nil || directResponses[name]) {
+			errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: http route %s does not forward requests and is not mirrored",
+				constants.MirrorsAnnotation, name)))
+		}
+		for _, m := range mirrors[name] {
+			destination := &networking.Destination{Host: m.Host, Subset: m.Subset}
+			if m.Port != 0 {
+				destination.Port = &networking.PortSelector{Number: m.Port}
+			}
+			if err := validateDestination(destination); err != nil {
+				errs = appendValidation(errs, fmt.Errorf("%s: invalid mirror of route %s: %v", constants.MirrorsAnnotation, name, err))
+			}
+		}
+	}
+	return
+}
+
+func validateIdleTimeoutAnnotation(annotations map[string]string, vs *networking.VirtualService) (errs Validation) {
+	value, f := annotations[constants.RouteIdleTimeoutAnnotation]
+	if !f {
+		return
+	}
+	timeouts, err := istionetworking.ParseRouteIdleTimeouts(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.RouteIdleTimeoutAnnotation, err))
+	}
+	names := make([]string, 0, len(timeouts))
+	for name := range timeouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs = validateRouteNames(constants.RouteIdleTimeoutAnnotation, names, vs, true)
+	for _, name := range names {
+		if r := httpRoute(vs, name); r != nil && r.Redirect != nil {
+			errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: http route %s is a redirect and has no idle timeout",
+				constants.RouteIdleTimeoutAnnotation, name)))
+		}
+	}
+	return
+}
+
+func validateLocalRateLimitAnnotation(annotations map[string]string, vs *networking.VirtualService) (errs Validation) {
+	value, f := annotations[constants.LocalRateLimitAnnotation]
+	if !f {
+		return
+	}
+	limits, err := istionetworking.ParseLocalRateLimits(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.LocalRateLimitAnnotation, err))
+	}
+	names := make([]string, 0, len(limits))
+	for name := range limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return validateRouteNames(constants.LocalRateLimitAnnotation, names, vs, true)
+}
+
+func validateGlobalRateLimitAnnotation(annotations map[string]string, vs *networking.VirtualService) (errs Validation) {
+	value, f := annotations[constants.GlobalRateLimitAnnotation]
+	if !f {
+		return
+	}
+	descriptors, err := istionetworking.ParseGlobalRateLimits(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.GlobalRateLimitAnnotation, err))
+	}
+	names := make([]string, 0, len(descriptors))
+	for name := range descriptors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return validateRouteNames(constants.GlobalRateLimitAnnotation, names, vs, true)
+}
+
+func validateExtProcOverridesAnnotation(annotations map[string]string, vs *networking.VirtualService) (errs Validation) {
+	value, f := annotations[constants.ExtProcOverridesAnnotation]
+	if !f {
+		return
+	}
+	overrides, err := istionetworking.ParseExtProcOverrides(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.ExtProcOverridesAnnotation, err))
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return validateRouteNames(constants.ExtProcOverridesAnnotation, names, vs, true)
+}
+
+func validateInternalRedirectsAnnotation(annotations map[string]string, vs *networking.VirtualService) (errs Validation) {
+	value, f := annotations[constants.InternalRedirectsAnnotation]
+	if !f {
+		return
+	}
+	redirects, err := istionetworking.ParseInternalRedirects(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.InternalRedirectsAnnotation, err))
+	}
+	names := make([]string, 0, len(redirects))
+	for name := range redirects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs = validateRouteNames(constants.InternalRedirectsAnnotation, names, vs, true)
+	directResponses := directResponseRoutes(annotations)
+	for _, name := range names {
+		if r := httpRoute(vs, name); r != nil && (r.Redirect != nil || directResponses[name]) {
+			errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: http route %s does not forward requests and has no internal redirects",
+				constants.InternalRedirectsAnnotation, name)))
+		}
+	}
+	return
+}
+
 func validateExportTo(namespace string, exportTo []string, isServiceEntry bool) (errs error) {
 	if len(exportTo) > 0 {
 		// Make sure there are no duplicates
@@ -1159,10 +1589,116 @@ var ValidateSidecar = registerValidateFunc("ValidateSidecar",
 		}
 
 		errs = appendValidation(errs, validateSidecarOutboundTrafficPolicy(rule.OutboundTrafficPolicy))
+		errs = appendValidation(errs, validateSidecarEgressCatchAll(cfg.Annotations, rule.Egress))
+		errs = appendValidation(errs, validateSidecarEgressTimeout(cfg.Annotations, constants.EgressConnectTimeoutAnnotation, rule.Egress))
+		errs = appendValidation(errs, validateSidecarEgressTimeout(cfg.Annotations, constants.EgressIdleTimeoutAnnotation, rule.Egress))
+		errs = appendValidation(errs, validateSidecarPassthroughWildcardNamespaces(cfg.Annotations))
+		errs = appendValidation(errs, validateSidecarInboundConnectionLimit(cfg.Annotations))
+		errs = appendValidation(errs, validateSidecarInboundLocalRateLimit(cfg.Annotations))
 
 		return errs.Unwrap()
 	})
 
+func validateSidecarEgressCatchAll(annotations map[string]string, egress []*networking.IstioEgressListener) (errs Validation) {
+	value, f := annotations[constants.EgressCatchAllAnnotation]
+	if !f {
+		return
+	}
+	actions, err := istionetworking.ParseCatchAllActions(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", constants.EgressCatchAllAnnotation, err))
+	}
+	ports := sidecarEgressPorts(egress)
+	for port := range actions {
+		if !ports.Contains(port) {
+			errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: no egress listener for port %s",
+				constants.EgressCatchAllAnnotation, port)))
+		}
+	}
+	return
+}
+
+func validateSidecarEgressTimeout(annotations map[string]string, annotation string,
+	egress []*networking.IstioEgressListener) (errs Validation) {
+	value, f := annotations[annotation]
+	if !f {
+		return
+	}
+	timeouts, err := istionetworking.ParseEgressTimeouts(value)
+	if err != nil {
+		return appendValidation(errs, fmt.Errorf("%s: %v", annotation, err))
+	}
+	ports := sidecarEgressPorts(egress)
+	for port := range timeouts {
+		if !ports.Contains(port) {
+			errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s: no egress listener for port %s", annotation, port)))
+		}
+	}
+	return
+}
+
+// sidecarEgressPorts returns the ports of the egress listeners of a Sidecar, with * for the listener without a port.
+func sidecarEgressPorts(egress []*networking.IstioEgressListener) sets.Set {
+	ports := sets.NewSet()
+	if len(egress) == 0 {
+		// Without egress listeners the sidecar has a single listener without a port.
+		ports.Insert("*")
+	}
+	for _, e := range egress {
+		if e.Port == nil {
+			ports.Insert("*")
+		} else {
+			ports.Insert(strconv.Itoa(int(e.Port.Number)))
+		}
+	}
+	return ports
+}
+
+func validateSidecarPassthroughWildcardNamespaces(annotations map[string]string) (errs error) {
+	value, f := annotations[constants.PassthroughWildcardNamespacesAnnotation]
+	if !f || value == "" {
+		return
+	}
+	for _, ns := range strings.Split(value, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns != "." && !labels.IsDNS1123Label(ns) {
+			errs = appendErrors(errs, fmt.Errorf("%s: invalid namespace %q", constants.PassthroughWildcardNamespacesAnnotation, ns))
+		}
+	}
+	return
+}
+
+func validateSidecarInboundConnectionLimit(annotations map[string]string) (errs error) {
+	value, f := annotations[constants.InboundConnectionLimitAnnotation]
+	if !f {
+		return
+	}
+	if _, err := istionetworking.ParseConnectionLimits(value); err != nil {
+		errs = appendErrors(errs, fmt.Errorf("%s: %v", constants.InboundConnectionLimitAnnotation, err))
+	}
+	return
+}
+
+func validateSidecarInboundLocalRateLimit(annotations map[string]string) (errs error) {
+	value, f := annotations[constants.InboundLocalRateLimitAnnotation]
+	if !f {
+		return
+	}
+	limits, err := istionetworking.ParseLocalRateLimits(value)
+	if err != nil {
+		return appendErrors(errs, fmt.Errorf("%s: %v", constants.InboundLocalRateLimitAnnotation, err))
+	}
+	for port := range limits {
+		if port == "*" {
+			continue
+		}
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			errs = appendErrors(errs, fmt.Errorf("%s: invalid port %q", constants.InboundLocalRateLimitAnnotation, port))
+		}
+	}
+	return
+}
+
 func validateSidecarOutboundTrafficPolicy(tp *networking.OutboundTrafficPolicy) (errs error) {
 	if tp == nil {
 		return
@@ -1314,11 +1850,31 @@ func validateConnectionPool(settings *networking.ConnectionPoolSettings) (errs e
 		if tcp.ConnectTimeout != nil {
 			errs = appendErrors(errs, ValidateDuration(tcp.ConnectTimeout))
 		}
+		errs = appendErrors(errs, validateTCPKeepalive(tcp.TcpKeepalive))
 	}
 
 	return
 }
 
+// validateTCPKeepalive checks that the keepalive time and interval, if set, are well-formed durations.
+// Sub-second values are rounded up to the next second when applied to the cluster.
+func validateTCPKeepalive(keepalive *networking.ConnectionPoolSettings_TCPSettings_TcpKeepalive) (errs error) {
+	if keepalive == nil {
+		return
+	}
+	if keepalive.Time != nil {
+		if err := ValidateDuration(keepalive.Time); err != nil {
+			errs = appendErrors(errs, multierror.Prefix(err, "tcp keepalive time:"))
+		}
+	}
+	if keepalive.Interval != nil {
+		if err := ValidateDuration(keepalive.Interval); err != nil {
+			errs = appendErrors(errs, multierror.Prefix(err, "tcp keepalive interval:"))
+		}
+	}
+	return
+}
+
 func validateLoadBalancer(settings *networking.LoadBalancerSettings) (errs error) {
 	if settings == nil {
 		return
@@ -1585,6 +2141,10 @@ func ValidateMeshConfig(mesh *meshconfig.MeshConfig) (errs error) {
 		errs = multierror.Append(errs, err)
 	}
 
+	if err := validateTCPKeepalive(mesh.TcpKeepalive); err != nil {
+		errs = multierror.Append(errs, multierror.Prefix(err, "invalid tcp keepalive:"))
+	}
+
 	if err := validateServiceSettings(mesh); err != nil {
 		errs = multierror.Append(errs, err)
 	}
@@ -1899,15 +2459,57 @@ var ValidateRequestAuthentication = registerValidateFunc("ValidateRequestAuthent
 			return nil, errors.New("cannot cast to RequestAuthentication")
 		}
 
-		var errs error
-		errs = appendErrors(errs, validateWorkloadSelector(in.Selector))
+		errs := Validation{}
+		errs = appendValidation(errs, validateWorkloadSelector(in.Selector))
 
 		for _, rule := range in.JwtRules {
-			errs = appendErrors(errs, validateJwtRule(rule))
+			errs = appendValidation(errs, validateJwtRule(rule))
 		}
-		return nil, errs
+		errs = appendValidation(errs, validateJwtCacheAnnotations(cfg.Annotations))
+		errs = appendValidation(errs, validateOAuth2Annotation(cfg.Annotations, in.Selector))
+		return errs.Unwrap()
 	})
 
+func validateOAuth2Annotation(annotations map[string]string, selector *type_beta.WorkloadSelector) (errs Validation) {
+	v, f := annotations[constants.OAuth2Annotation]
+	if !f {
+		return
+	}
+	if _, err := security.ParseOAuth2Config(v); err != nil {
+		errs = appendValidation(errs, fmt.Errorf("invalid %s: %v", constants.OAuth2Annotation, err))
+	}
+	if len(selector.GetMatchLabels()) == 0 {
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s only applies to gateways, and should be used with a "+
+			"selector matching them", constants.OAuth2Annotation)))
+	}
+	return
+}
+
+func validateJwtCacheAnnotations(annotations map[string]string) (errs Validation) {
+	if v, f := annotations[constants.JwtCacheSizeAnnotation]; f {
+		if n, err := strconv.ParseUint(v, 10, 32); err != nil || n == 0 {
+			errs = appendValidation(errs, fmt.Errorf("%s must be a positive integer, got %q", constants.JwtCacheSizeAnnotation, v))
+		}
+	}
+	_, hasDuration := annotations[constants.JwksCacheDurationAnnotation]
+	if hasDuration {
+		v := annotations[constants.JwksCacheDurationAnnotation]
+		if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+			errs = appendValidation(errs, fmt.Errorf("%s must be a positive duration, got %q", constants.JwksCacheDurationAnnotation, v))
+		}
+	}
+	v, hasAsyncFetch := annotations[constants.JwksAsyncFetchAnnotation]
+	if hasAsyncFetch && v != "true" && v != "false" && v != "fast-listener" {
+		errs = appendValidation(errs, fmt.Errorf("%s must be one of true, false or fast-listener, got %q",
+			constants.JwksAsyncFetchAnnotation, v))
+	}
+	if (hasDuration || hasAsyncFetch) && !features.EnableRemoteJwks {
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("%s and %s only apply when the proxy fetches the JWKS, "+
+			"which requires PILOT_JWT_ENABLE_REMOTE_JWKS", constants.JwksCacheDurationAnnotation, constants.JwksAsyncFetchAnnotation)))
+	}
+	return
+}
+
 func validateJwtRule(rule *security_beta.JWTRule) (errs error) {
 	if rule == nil {
 		return nil
@@ -1981,6 +2583,12 @@ var ValidatePeerAuthentication = registerValidateFunc("ValidatePeerAuthenticatio
 
 		errs = appendErrors(errs, validateWorkloadSelector(in.Selector))
 
+		if v, f := cfg.Annotations[constants.IdentityHeadersAnnotation]; f {
+			if _, err := security.ParseIdentityHeaders(v); err != nil {
+				errs = appendErrors(errs, fmt.Errorf("invalid %s: %v", constants.IdentityHeadersAnnotation, err))
+			}
+		}
+
 		return nil, errs
 	})
 
@@ -2023,22 +2631,26 @@ var ValidateVirtualService = registerValidateFunc("ValidateVirtualService",
 			}
 		}
 
-		if !appliesToGateway {
-			validateJWTClaimRoute := func(headers map[string]*networking.StringMatch) {
-				for key := range headers {
-					if strings.HasPrefix(key, constant.HeaderJWTClaim) {
-						msg := fmt.Sprintf("JWT claim based routing (key: %s) is only supported for gateway, found no gateways: %v", key, virtualService.Gateways)
-						errs = appendValidation(errs, errors.New(msg))
-					}
+		validateJWTClaimRoute := func(headers map[string]*networking.StringMatch) {
+			for key := range headers {
+				if !istionetworking.IsJWTClaimHeader(key) {
+					continue
 				}
-			}
-			for _, http := range virtualService.GetHttp() {
-				for _, m := range http.GetMatch() {
-					validateJWTClaimRoute(m.GetHeaders())
-					validateJWTClaimRoute(m.GetWithoutHeaders())
+				if _, err := istionetworking.ParseJWTClaimHeader(key); err != nil {
+					errs = appendValidation(errs, err)
+				}
+				if !appliesToGateway {
+					msg := fmt.Sprintf("JWT claim based routing (key: %s) is only supported for gateway, found no gateways: %v", key, virtualService.Gateways)
+					errs = appendValidation(errs, errors.New(msg))
 				}
 			}
 		}
+		for _, http := range virtualService.GetHttp() {
+			for _, m := range http.GetMatch() {
+				validateJWTClaimRoute(m.GetHeaders())
+				validateJWTClaimRoute(m.GetWithoutHeaders())
+			}
+		}
 
 		allHostsValid := true
 		for _, virtualHost := range virtualService.Hosts {
@@ -2072,12 +2684,14 @@ var ValidateVirtualService = registerValidateFunc("ValidateVirtualService",
 		if len(virtualService.Http) == 0 && len(virtualService.Tcp) == 0 && len(virtualService.Tls) == 0 {
 			errs = appendValidation(errs, errors.New("http, tcp or tls must be provided in virtual service"))
 		}
+		directResponses := directResponseRoutes(cfg.Annotations)
 		for _, httpRoute := range virtualService.Http {
 			if httpRoute == nil {
 				errs = appendValidation(errs, errors.New("http route may not be null"))
 				continue
 			}
-			errs = appendValidation(errs, validateHTTPRoute(httpRoute, len(virtualService.Hosts) == 0))
+			errs = appendValidation(errs, validateHTTPRoute(httpRoute, len(virtualService.Hosts) == 0,
+				httpRoute.Name != "" && directResponses[httpRoute.Name]))
 		}
 		for _, tlsRoute := range virtualService.Tls {
 			errs = appendValidation(errs, validateTLSRoute(tlsRoute, virtualService))
@@ -2087,6 +2701,14 @@ var ValidateVirtualService = registerValidateFunc("ValidateVirtualService",
 		}
 
 		errs = appendValidation(errs, validateExportTo(cfg.Namespace, virtualService.ExportTo, false))
+		errs = appendValidation(errs, validateInternalRedirectsAnnotation(cfg.Annotations, virtualService))
+		errs = appendValidation(errs, validateRegexRewriteAnnotation(cfg.Annotations, virtualService))
+		errs = appendValidation(errs, validateIdleTimeoutAnnotation(cfg.Annotations, virtualService))
+		errs = appendValidation(errs, validateDirectResponseAnnotation(cfg.Annotations, virtualService))
+		errs = appendValidation(errs, validateMirrorsAnnotation(cfg.Annotations, virtualService))
+		errs = appendValidation(errs, validateLocalRateLimitAnnotation(cfg.Annotations, virtualService))
+		errs = appendValidation(errs, validateGlobalRateLimitAnnotation(cfg.Annotations, virtualService))
+		errs = appendValidation(errs, validateExtProcOverridesAnnotation(cfg.Annotations, virtualService))
 
 		warnUnused := func(ruleno, reason string) {
 			errs = appendValidation(errs, WrapWarning(&AnalysisAwareError{
@@ -2103,9 +2725,17 @@ var ValidateVirtualService = registerValidateFunc("ValidateVirtualService",
 			}))
 		}
 
-		analyzeUnreachableHTTPRules(virtualService.Http, warnUnused, warnIneffective)
-		analyzeUnreachableTCPRules(virtualService.Tcp, warnUnused, warnIneffective)
-		analyzeUnreachableTLSRules(virtualService.Tls, warnUnused, warnIneffective)
+		warnShadowed := func(ruleno, catchall string) {
+			errs = appendValidation(errs, WrapWarning(&AnalysisAwareError{
+				Type:       "VirtualServiceRouteShadowed",
+				Msg:        fmt.Sprintf("virtualService rule %v not used (rule %v before it matches all requests)", ruleno, catchall),
+				Parameters: []interface{}{ruleno, catchall},
+			}))
+		}
+
+		analyzeUnreachableHTTPRules(virtualService.Http, warnUnused, warnIneffective, warnShadowed)
+		analyzeUnreachableTCPRules(virtualService.Tcp, warnUnused, warnIneffective, warnShadowed)
+		analyzeUnreachableTLSRules(virtualService.Tls, warnUnused, warnIneffective, warnShadowed)
 
 		return errs.Unwrap()
 	})
@@ -2274,9 +2904,11 @@ func coveredValidation(vA, vB *OverlappingMatchValidationForHTTPRoute) bool {
 }
 
 func analyzeUnreachableHTTPRules(routes []*networking.HTTPRoute,
-	reportUnreachable func(ruleno, reason string), reportIneffective func(ruleno, matchno, dupno string)) {
+	reportUnreachable func(ruleno, reason string), reportIneffective func(ruleno, matchno, dupno string),
+	reportShadowed func(ruleno, catchall string)) {
 	matchesEncountered := make(map[string]int)
 	emptyMatchEncountered := -1
+	catchAllEncountered := -1
 	var matchHTTPRoutes []*OverlappingMatchValidationForHTTPRoute
 	for rulen, route := range routes {
 		if route == nil {
@@ -2285,8 +2917,17 @@ func analyzeUnreachableHTTPRules(routes []*networking.HTTPRoute,
 		if len(route.Match) == 0 {
 			if emptyMatchEncountered >= 0 {
 				reportUnreachable(routeName(route, rulen), "only the last rule can have no matches")
+			} else if catchAllEncountered >= 0 {
+				reportShadowed(routeName(route, rulen), routeName(routes[catchAllEncountered], catchAllEncountered))
 			}
 			emptyMatchEncountered = rulen
+			if catchAllEncountered < 0 {
+				catchAllEncountered = rulen
+			}
+			continue
+		}
+		if catchAllEncountered >= 0 {
+			reportShadowed(routeName(route, rulen), routeName(routes[catchAllEncountered], catchAllEncountered))
 			continue
 		}
 
@@ -2301,6 +2942,9 @@ func analyzeUnreachableHTTPRules(routes []*networking.HTTPRoute,
 			} else {
 				matchesEncountered[asJSON(match)] = rulen
 			}
+			if isCatchAllHTTPMatch(match) {
+				catchAllEncountered = rulen
+			}
 			// build the match rules into struct OverlappingMatchValidationForHTTPRoute based on current match
 			matchHTTPRoute := genMatchHTTPRoutes(route, match, rulen, matchn)
 			if matchHTTPRoute != nil {
@@ -2334,9 +2978,11 @@ func analyzeUnreachableHTTPRules(routes []*networking.HTTPRoute,
 
 // NOTE: This method identical to analyzeUnreachableHTTPRules.
 func analyzeUnreachableTCPRules(routes []*networking.TCPRoute,
-	reportUnreachable func(ruleno, reason string), reportIneffective func(ruleno, matchno, dupno string)) {
+	reportUnreachable func(ruleno, reason string), reportIneffective func(ruleno, matchno, dupno string),
+	reportShadowed func(ruleno, catchall string)) {
 	matchesEncountered := make(map[string]int)
 	emptyMatchEncountered := -1
+	catchAllEncountered := -1
 	for rulen, route := range routes {
 		if route == nil {
 			continue
@@ -2344,8 +2990,17 @@ func analyzeUnreachableTCPRules(routes []*networking.TCPRoute,
 		if len(route.Match) == 0 {
 			if emptyMatchEncountered >= 0 {
 				reportUnreachable(routeName(route, rulen), "only the last rule can have no matches")
+			} else if catchAllEncountered >= 0 {
+				reportShadowed(routeName(route, rulen), routeName(routes[catchAllEncountered], catchAllEncountered))
 			}
 			emptyMatchEncountered = rulen
+			if catchAllEncountered < 0 {
+				catchAllEncountered = rulen
+			}
+			continue
+		}
+		if catchAllEncountered >= 0 {
+			reportShadowed(routeName(route, rulen), routeName(routes[catchAllEncountered], catchAllEncountered))
 			continue
 		}
 
@@ -2367,9 +3022,11 @@ func analyzeUnreachableTCPRules(routes []*networking.TCPRoute,
 
 // NOTE: This method identical to analyzeUnreachableHTTPRules.
 func analyzeUnreachableTLSRules(routes []*networking.TLSRoute,
-	reportUnreachable func(ruleno, reason string), reportIneffective func(ruleno, matchno, dupno string)) {
+	reportUnreachable func(ruleno, reason string), reportIneffective func(ruleno, matchno, dupno string),
+	reportShadowed func(ruleno, catchall string)) {
 	matchesEncountered := make(map[string]int)
 	emptyMatchEncountered := -1
+	catchAllEncountered := -1
 	for rulen, route := range routes {
 		if route == nil {
 			continue
@@ -2377,8 +3034,17 @@ func analyzeUnreachableTLSRules(routes []*networking.TLSRoute,
 		if len(route.Match) == 0 {
 			if emptyMatchEncountered >= 0 {
 				reportUnreachable(routeName(route, rulen), "only the last rule can have no matches")
+			} else if catchAllEncountered >= 0 {
+				reportShadowed(routeName(route, rulen), routeName(routes[catchAllEncountered], catchAllEncountered))
 			}
 			emptyMatchEncountered = rulen
+			if catchAllEncountered < 0 {
+				catchAllEncountered = rulen
+			}
+			continue
+		}
+		if catchAllEncountered >= 0 {
+			reportShadowed(routeName(route, rulen), routeName(routes[catchAllEncountered], catchAllEncountered))
 			continue
 		}
 
@@ -2398,6 +3064,16 @@ func analyzeUnreachableTLSRules(routes []*networking.TLSRoute,
 	}
 }
 
+var catchAllHTTPMatches = map[string]bool{
+	asJSON(&networking.HTTPMatchRequest{}): true,
+	asJSON(&networking.HTTPMatchRequest{Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: "/"}}}): true,
+}
+
+// isCatchAllHTTPMatch returns true if the match matches all requests, so that the rules after it are never used.
+func isCatchAllHTTPMatch(match *networking.HTTPMatchRequest) bool {
+	return catchAllHTTPMatches[asJSON(match)]
+}
+
 // asJSON() creates a JSON serialization of a match, to use for match comparison.  We don't use the JSON itself.
 func asJSON(data interface{}) string {
 	// Remove the name, so we can create a serialization that only includes traffic routing config
@@ -3170,9 +3846,34 @@ var ValidateServiceEntry = registerValidateFunc("ValidateServiceEntry",
 		}
 
 		errs = appendValidation(errs, validateExportTo(cfg.Namespace, serviceEntry.ExportTo, true))
+		errs = appendValidation(errs, validateDNSAnnotations(cfg.Annotations, serviceEntry))
 		return errs.Unwrap()
 	})
 
+func validateDNSAnnotations(annotations map[string]string, serviceEntry *networking.ServiceEntry) (errs Validation) {
+	found := false
+	for _, name := range []string{constants.DNSRefreshRateAnnotation, constants.DNSJitterAnnotation} {
+		value, f := annotations[name]
+		if !f {
+			continue
+		}
+		found = true
+		if d, err := time.ParseDuration(value); err != nil || d < time.Millisecond {
+			errs = appendValidation(errs, fmt.Errorf("%s must be a duration of at least 1ms, got %q", name, value))
+		}
+	}
+	if value, f := annotations[constants.DNSRespectTTLAnnotation]; f {
+		found = true
+		if value != "true" && value != "false" {
+			errs = appendValidation(errs, fmt.Errorf("%s must be true or false, got %q", constants.DNSRespectTTLAnnotation, value))
+		}
+	}
+	if found && serviceEntry.Resolution != networking.ServiceEntry_DNS && serviceEntry.Resolution != networking.ServiceEntry_DNS_ROUND_ROBIN {
+		errs = appendValidation(errs, WrapWarning(fmt.Errorf("DNS annotations have no effect with resolution %s", serviceEntry.Resolution)))
+	}
+	return
+}
+
 // ValidatePortName validates a port name to DNS-1123
 func ValidatePortName(name string) error {
 	if !labels.IsDNS1123Label(name) {
@@ -3467,10 +4168,22 @@ var ValidateTelemetry = registerValidateFunc("ValidateTelemetry",
 			validateTelemetryMetrics(spec.Metrics),
 			validateTelemetryTracing(spec.Tracing),
 			validateTelemetryAccessLogging(spec.AccessLogging),
+			validateRequestAttemptCountAnnotation(cfg.Annotations),
 		)
 		return errs.Unwrap()
 	})
 
+func validateRequestAttemptCountAnnotation(annotations map[string]string) error {
+	value, f := annotations[constants.RequestAttemptCountAnnotation]
+	if !f {
+		return nil
+	}
+	if _, err := istionetworking.ParseAttemptCount(value); err != nil {
+		return fmt.Errorf("%s: %v", constants.RequestAttemptCountAnnotation, err)
+	}
+	return nil
+}
+
 func validateTelemetryAccessLogging(logging []*telemetry.AccessLogging) (v Validation) {
 	if len(logging) > 1 {
 		v = appendWarningf(v, "multiple accessLogging is not currently supported")
@@ -3589,6 +4302,22 @@ var ValidateWasmPlugin = registerValidateFunc("ValidateWasmPlugin",
 		}
 
 		errs := Validation{}
+		pluginType, err := istionetworking.ParseWasmPluginType(cfg.Annotations[constants.WasmPluginTypeAnnotation])
+		if err != nil {
+			errs = appendValidation(errs, fmt.Errorf("%s: %v", constants.WasmPluginTypeAnnotation, err))
+		}
+		if value, f := cfg.Annotations[constants.ExtProcAnnotation]; f {
+			// The WasmPlugin declares an external processor, and its Wasm fields are ignored.
+			if pluginType != istionetworking.WasmPluginTypeHTTP {
+				errs = appendValidation(errs, fmt.Errorf("%s requires the HTTP %s",
+					constants.ExtProcAnnotation, constants.WasmPluginTypeAnnotation))
+			}
+			if _, err := istionetworking.ParseExtProc(value); err != nil {
+				errs = appendValidation(errs, fmt.Errorf("%s: %v", constants.ExtProcAnnotation, err))
+			}
+			errs = appendValidation(errs, validateWorkloadSelector(spec.Selector))
+			return errs.Unwrap()
+		}
 		errs = appendValidation(errs,
 			validateWorkloadSelector(spec.Selector),
 			validateWasmPluginURL(spec.Url),
@@ -427,6 +427,9 @@ func TestValidateMeshConfig(t *testing.T) {
 		DefaultConfig:      &meshconfig.ProxyConfig{},
 		TrustDomain:        "",
 		TrustDomainAliases: []string{"a.$b", "a/b", ""},
+		TcpKeepalive: &networking.ConnectionPoolSettings_TCPSettings_TcpKeepalive{
+			Time: &types.Duration{Seconds: -1},
+		},
 		ExtensionProviders: []*meshconfig.MeshConfig_ExtensionProvider{
 			{
 				Name: "default",
@@ -456,6 +459,7 @@ func TestValidateMeshConfig(t *testing.T) {
 			"discovery address must be set to the proxy discovery service",
 			"invalid proxy admin port",
 			"invalid status port",
+			"invalid tcp keepalive: tcp keepalive time:",
 			"trustDomain: empty domain name not allowed",
 			"trustDomainAliases[0]",
 			"trustDomainAliases[1]",
@@ -2446,7 +2450,7 @@ func TestValidateHTTPRoute(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			if err := validateHTTPRoute(tc.route, false); (err.Err == nil) != tc.valid {
+			if err := validateHTTPRoute(tc.route, false, false); (err.Err == nil) != tc.valid {
 				t.Fatalf("got valid=%v but wanted valid=%v: %v", err.Err == nil, tc.valid, err)
 			}
 		})
@@ -2786,6 +2790,42 @@ func TestValidateVirtualService(t *testing.T) {
 				},
 			}},
 		}, valid: false, warning: false},
+		{name: "jwt claim route with bracketed claims", in: &networking.VirtualService{
+			Hosts:    []string{"foo.bar"},
+			Gateways: []string{"ns1/gateway"},
+			Http: []*networking.HTTPRoute{{
+				Route: []*networking.HTTPRouteDestination{{
+					Destination: &networking.Destination{Host: "foo.baz"},
+				}},
+				Match: []*networking.HTTPMatchRequest{
+					{
+						Headers: map[string]*networking.StringMatch{
+							"@request.auth.claims[example.com/groups][id]": {
+								MatchType: &networking.StringMatch_Exact{Exact: "bar"},
+							},
+						},
+					},
+				},
+			}},
+		}, valid: true, warning: false},
+		{name: "jwt claim route with invalid bracketed claims", in: &networking.VirtualService{
+			Hosts:    []string{"foo.bar"},
+			Gateways: []string{"ns1/gateway"},
+			Http: []*networking.HTTPRoute{{
+				Route: []*networking.HTTPRouteDestination{{
+					Destination: &networking.Destination{Host: "foo.baz"},
+				}},
+				Match: []*networking.HTTPMatchRequest{
+					{
+						WithoutHeaders: map[string]*networking.StringMatch{
+							"@request.auth.claims[example.com/groups": {
+								MatchType: &networking.StringMatch_Exact{Exact: "bar"},
+							},
+						},
+					},
+				},
+			}},
+		}, valid: false, warning: false},
 	}
 
 	for _, tc := range testCases {
@@ -2796,6 +2836,277 @@ func TestValidateVirtualService(t *testing.T) {
 	}
 }
 
+func TestValidateVirtualServiceInternalRedirect(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		valid bool
+		warn  bool
+	}{
+		{name: "max", value: `{"login": {"maxRedirects": 2}}`, valid: true},
+		{name: "all", value: `{"login": {"maxRedirects": 2, "responseCodes": [301, 302, 303, 307, 308], "allowCrossScheme": true}}`, valid: true},
+		{name: "all routes", value: `{"*": {"maxRedirects": 2}}`, valid: true},
+		{name: "zero max", value: `{"login": {"maxRedirects": 0}}`, valid: false},
+		{name: "unsupported code", value: `{"login": {"maxRedirects": 2, "responseCodes": [301, 304]}}`, valid: false},
+		{name: "invalid cross scheme", value: `{"login": {"maxRedirects": 2, "allowCrossScheme": "yes"}}`, valid: false},
+		{name: "unknown route", value: `{"logout": {"maxRedirects": 2}}`, valid: true, warn: true},
+		{name: "redirect route", value: `{"moved": {"maxRedirects": 2}}`, valid: true, warn: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			warn, err := ValidateVirtualService(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: map[string]string{constants.InternalRedirectsAnnotation: c.value},
+				},
+				Spec: &networking.VirtualService{
+					Hosts: []string{"foo.bar"},
+					Http: []*networking.HTTPRoute{
+						{
+							Name: "login",
+							Route: []*networking.HTTPRouteDestination{{
+								Destination: &networking.Destination{Host: "foo.baz"},
+							}},
+						},
+						{
+							Name:     "moved",
+							Redirect: &networking.HTTPRedirect{Uri: "/new"},
+						},
+					},
+				},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
+		})
+	}
+}
+
+func TestValidateVirtualServiceRouteAnnotations(t *testing.T) {
+	forward := []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "foo.baz"}}}
+	redirect := &networking.HTTPRedirect{Uri: "/"}
+	cases := []struct {
+		name       string
+		annotation string
+		value      string
+		// route is named reviews, and forwards to foo.baz by default.
+		route    *networking.HTTPRoute
+		delegate bool
+		valid    bool
+		warn     bool
+	}{
+		{
+			name:       "regex rewrite",
+			annotation: constants.RegexRewriteAnnotation,
+			value:      `{"reviews": {"pattern": "^/v1/(.*)$", "substitution": "/\\1"}}`,
+			valid:      true,
+		},
+		{
+			name:       "regex rewrite with authority rewrite",
+			annotation: constants.RegexRewriteAnnotation,
+			value:      `{"reviews": {"pattern": "^/v1/(.*)$", "substitution": "/\\1"}}`,
+			route:      &networking.HTTPRoute{Rewrite: &networking.HTTPRewrite{Authority: "foo"}, Route: forward},
+			valid:      true,
+		},
+		{
+			name:       "regex rewrite with uri rewrite",
+			annotation: constants.RegexRewriteAnnotation,
+			value:      `{"reviews": {"pattern": "^/v1/(.*)$", "substitution": "/\\1"}}`,
+			route:      &networking.HTTPRoute{Rewrite: &networking.HTTPRewrite{Uri: "/"}, Route: forward},
+			valid:      false,
+		},
+		{
+			name:       "regex rewrite with invalid pattern",
+			annotation: constants.RegexRewriteAnnotation,
+			value:      `{"reviews": {"pattern": "(", "substitution": "/"}}`,
+			valid:      false,
+		},
+		{
+			name:       "regex rewrite of unknown route",
+			annotation: constants.RegexRewriteAnnotation,
+			value:      `{"ratings": {"pattern": "^/v1/", "substitution": "/"}}`,
+			valid:      true,
+			warn:       true,
+		},
+		{name: "idle timeout", annotation: constants.RouteIdleTimeoutAnnotation, value: `{"reviews": "1h", "*": "5m"}`, valid: true},
+		{name: "disabled idle timeout", annotation: constants.RouteIdleTimeoutAnnotation, value: `{"reviews": "0s"}`, valid: true},
+		{name: "invalid idle timeout", annotation: constants.RouteIdleTimeoutAnnotation, value: `{"reviews": "1x"}`, valid: false},
+		{name: "negative idle timeout", annotation: constants.RouteIdleTimeoutAnnotation, value: `{"*": "-1s"}`, valid: false},
+		{name: "idle timeout of unknown route", annotation: constants.RouteIdleTimeoutAnnotation, value: `{"ratings": "1h"}`, valid: true, warn: true},
+		{
+			name:       "idle timeout of redirect",
+			annotation: constants.RouteIdleTimeoutAnnotation,
+			value:      `{"reviews": "1h"}`,
+			route:      &networking.HTTPRoute{Redirect: redirect},
+			valid:      true,
+			warn:       true,
+		},
+		{
+			name:       "local rate limit",
+			annotation: constants.LocalRateLimitAnnotation,
+			value:      `{"reviews": {"maxTokens": 10, "fillInterval": "1s"}, "*": {"maxTokens": 100, "fillInterval": "1s"}}`,
+			valid:      true,
+		},
+		{
+			name:       "invalid local rate limit",
+			annotation: constants.LocalRateLimitAnnotation,
+			value:      `{"reviews": {"maxTokens": 0, "fillInterval": "1s"}}`,
+			valid:      false,
+		},
+		{
+			name:       "local rate limit of unknown route",
+			annotation: constants.LocalRateLimitAnnotation,
+			value:      `{"ratings": {"maxTokens": 10, "fillInterval": "1s"}}`,
+			valid:      true,
+			warn:       true,
+		},
+		{
+			name:       "global rate limit",
+			annotation: constants.GlobalRateLimitAnnotation,
+			value:      `{"reviews": [["route", "header:x-user-id"]], "*": [["destination"]]}`,
+			valid:      true,
+		},
+		{name: "invalid global rate limit", annotation: constants.GlobalRateLimitAnnotation, value: `{"reviews": [["method"]]}`, valid: false},
+		{
+			name:       "global rate limit of unknown route",
+			annotation: constants.GlobalRateLimitAnnotation,
+			value:      `{"ratings": [["route"]]}`,
+			valid:      true,
+			warn:       true,
+		},
+		{
+			name:       "ext proc overrides",
+			annotation: constants.ExtProcOverridesAnnotation,
+			value:      `{"reviews": {"istio-system/ext-proc": {"disabled": true}}}`,
+			valid:      true,
+		},
+		{
+			name:       "invalid ext proc overrides",
+			annotation: constants.ExtProcOverridesAnnotation,
+			value:      `{"reviews": {"ext-proc": {"disabled": true}}}`,
+			valid:      false,
+		},
+		{
+			name:       "ext proc overrides of unknown route",
+			annotation: constants.ExtProcOverridesAnnotation,
+			value:      `{"ratings": {"istio-system/ext-proc": {"disabled": true}}}`,
+			valid:      true,
+			warn:       true,
+		},
+		{
+			name:       "direct response",
+			annotation: constants.DirectResponseAnnotation,
+			value:      `{"reviews": {"status": 503, "body": "down", "headers": {"retry-after": "60"}}}`,
+			route:      &networking.HTTPRoute{},
+			valid:      true,
+		},
+		{
+			name:       "direct response with route",
+			annotation: constants.DirectResponseAnnotation,
+			value:      `{"reviews": {"status": 503, "body": "down", "headers": {"retry-after": "60"}}}`,
+			valid:      false,
+		},
+		{
+			name:       "direct response of delegate",
+			annotation: constants.DirectResponseAnnotation,
+			value:      `{"reviews": {"status": 503, "body": "down", "headers": {"retry-after": "60"}}}`,
+			route:      &networking.HTTPRoute{},
+			delegate:   true,
+			valid:      false,
+		},
+		{
+			name:       "direct response with invalid status",
+			annotation: constants.DirectResponseAnnotation,
+			value:      `{"reviews": {"status": 99}}`,
+			route:      &networking.HTTPRoute{},
+			valid:      false,
+		},
+		{
+			name:       "direct response with invalid header",
+			annotation: constants.DirectResponseAnnotation,
+			value:      `{"reviews": {"status": 503, "headers": {"host": "foo"}}}`,
+			route:      &networking.HTTPRoute{},
+			valid:      false,
+		},
+		{
+			name:       "direct response of unknown route",
+			annotation: constants.DirectResponseAnnotation,
+			value:      `{"reviews": {"status": 503}, "ratings": {"status": 503}}`,
+			route:      &networking.HTTPRoute{},
+			valid:      true,
+			warn:       true,
+		},
+		{
+			name:       "mirrors",
+			annotation: constants.MirrorsAnnotation,
+			value:      `{"reviews": [{"host": "reviews.staging", "percentage": 10}, {"host": "reviews.qa", "subset": "v2"}]}`,
+			valid:      true,
+		},
+		{
+			name:       "mirror with invalid percentage",
+			annotation: constants.MirrorsAnnotation,
+			value:      `{"reviews": [{"host": "reviews.staging", "percentage": 110}]}`,
+			valid:      false,
+		},
+		{name: "mirror with invalid host", annotation: constants.MirrorsAnnotation, value: `{"reviews": [{"host": "*"}]}`, valid: false},
+		{
+			name:       "mirror with invalid subset",
+			annotation: constants.MirrorsAnnotation,
+			value:      `{"reviews": [{"host": "reviews.qa", "subset": "V_2"}]}`,
+			valid:      false,
+		},
+		{
+			name:       "mirrors of unknown route",
+			annotation: constants.MirrorsAnnotation,
+			value:      `{"ratings": [{"host": "reviews.qa"}]}`,
+			valid:      true,
+			warn:       true,
+		},
+		{
+			name:       "mirrors of redirect",
+			annotation: constants.MirrorsAnnotation,
+			value:      `{"reviews": [{"host": "reviews.qa"}]}`,
+			route:      &networking.HTTPRoute{Redirect: redirect},
+			valid:      true,
+			warn:       true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			route := c.route
+			if route == nil {
+				route = &networking.HTTPRoute{Route: forward}
+			}
+			route.Name = "reviews"
+			hosts := []string{"foo.bar"}
+			if c.delegate {
+				hosts = nil
+			}
+			warn, err := ValidateVirtualService(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: map[string]string{c.annotation: c.value},
+				},
+				Spec: &networking.VirtualService{
+					Hosts: hosts,
+					Http:  []*networking.HTTPRoute{route},
+				},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
+		})
+	}
+}
+
 func TestValidateWorkloadEntry(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -2963,48 +3274,407 @@ func TestValidateWorkloadGroup(t *testing.T) {
 			valid: true,
 		},
 	}
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			warn, err := ValidateWorkloadGroup(config.Config{Spec: tc.in})
-			checkValidation(t, warn, err, tc.valid, tc.warning)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			warn, err := ValidateWorkloadGroup(config.Config{Spec: tc.in})
+			checkValidation(t, warn, err, tc.valid, tc.warning)
+		})
+	}
+}
+
+func checkValidation(t *testing.T, gotWarning Warning, gotError error, valid bool, warning bool) {
+	t.Helper()
+	if (gotError == nil) != valid {
+		t.Fatalf("got valid=%v but wanted valid=%v: %v", gotError == nil, valid, gotError)
+	}
+	if (gotWarning == nil) == warning {
+		t.Fatalf("got warning=%v but wanted warning=%v", gotWarning, warning)
+	}
+}
+
+func stringOrEmpty(v error) string {
+	if v == nil {
+		return ""
+	}
+	return v.Error()
+}
+
+func checkValidationMessage(t *testing.T, gotWarning Warning, gotError error, wantWarning string, wantError string) {
+	t.Helper()
+	if (gotError == nil) != (wantError == "") {
+		t.Fatalf("got err=%v but wanted err=%v", gotError, wantError)
+	}
+	if !strings.Contains(stringOrEmpty(gotError), wantError) {
+		t.Fatalf("got err=%v but wanted err=%v", gotError, wantError)
+	}
+
+	if (gotWarning == nil) != (wantWarning == "") {
+		t.Fatalf("got warning=%v but wanted warning=%v", gotWarning, wantWarning)
+	}
+	if !strings.Contains(stringOrEmpty(gotWarning), wantWarning) {
+		t.Fatalf("got warning=%v but wanted warning=%v", gotWarning, wantWarning)
+	}
+}
+
+func TestValidateDestinationRuleRetryBudget(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		valid       bool
+	}{
+		{name: "no annotations", valid: true},
+		{name: "percent", annotations: map[string]string{constants.RetryBudgetPercentAnnotation: "20"}, valid: true},
+		{name: "percent and concurrency", annotations: map[string]string{
+			constants.RetryBudgetPercentAnnotation:             "20.5",
+			constants.RetryBudgetMinRetryConcurrencyAnnotation: "3",
+		}, valid: true},
+		{name: "invalid percent", annotations: map[string]string{constants.RetryBudgetPercentAnnotation: "101"}, valid: false},
+		{name: "non numeric percent", annotations: map[string]string{constants.RetryBudgetPercentAnnotation: "abc"}, valid: false},
+		{name: "concurrency without percent", annotations: map[string]string{
+			constants.RetryBudgetMinRetryConcurrencyAnnotation: "3",
+		}, valid: false},
+		{name: "negative concurrency", annotations: map[string]string{
+			constants.RetryBudgetPercentAnnotation:             "20",
+			constants.RetryBudgetMinRetryConcurrencyAnnotation: "-1",
+		}, valid: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, got := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: c.annotations,
+				},
+				Spec: &networking.DestinationRule{Host: "reviews"},
+			})
+			if (got == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", got == nil, c.valid, got)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRuleLoadBalancingPolicy(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy string
+		lb     *networking.LoadBalancerSettings
+		valid  bool
+		warn   bool
+	}{
+		{
+			name:   "round robin",
+			policy: `{"@type": "type.googleapis.com/envoy.extensions.load_balancing_policies.round_robin.v3.RoundRobin"}`,
+			valid:  true,
+		},
+		{
+			name: "wrr locality with child policy",
+			policy: `{"@type": "type.googleapis.com/envoy.extensions.load_balancing_policies.wrr_locality.v3.WrrLocality",
+				"endpointPickingPolicy": {"policies": [{"typedExtensionConfig": {"name": "rr", "typedConfig":
+				{"@type": "type.googleapis.com/envoy.extensions.load_balancing_policies.round_robin.v3.RoundRobin"}}}]}}`,
+			valid: true,
+		},
+		{
+			name:   "unknown field",
+			policy: `{"@type": "type.googleapis.com/envoy.extensions.load_balancing_policies.round_robin.v3.RoundRobin", "foo": 1}`,
+			valid:  false,
+		},
+		{
+			name:   "not allowlisted",
+			policy: `{"@type": "type.googleapis.com/envoy.config.cluster.v3.Cluster"}`,
+			valid:  false,
+		},
+		{
+			name:   "overrides load balancer",
+			policy: `{"@type": "type.googleapis.com/envoy.extensions.load_balancing_policies.round_robin.v3.RoundRobin"}`,
+			lb: &networking.LoadBalancerSettings{
+				LbPolicy: &networking.LoadBalancerSettings_Simple{Simple: networking.LoadBalancerSettings_RANDOM},
+			},
+			valid: true,
+			warn:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dr := &networking.DestinationRule{Host: "reviews"}
+			if c.lb != nil {
+				dr.TrafficPolicy = &networking.TrafficPolicy{LoadBalancer: c.lb}
+			}
+			warn, err := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: map[string]string{constants.LoadBalancingPolicyAnnotation: c.policy},
+				},
+				Spec: dr,
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRuleOriginalDst(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		lb    *networking.LoadBalancerSettings
+		valid bool
+		warn  bool
+	}{
+		{name: "enabled", value: "true", valid: true},
+		{name: "disabled", value: "false", valid: true},
+		{name: "use http header", value: constants.OriginalDstUseHTTPHeader, valid: true, warn: true},
+		{name: "invalid", value: "yes", valid: false},
+		{
+			name:  "ignores load balancer",
+			value: "true",
+			lb: &networking.LoadBalancerSettings{
+				LbPolicy: &networking.LoadBalancerSettings_Simple{Simple: networking.LoadBalancerSettings_RANDOM},
+			},
+			valid: true,
+			warn:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dr := &networking.DestinationRule{Host: "reviews"}
+			if c.lb != nil {
+				dr.TrafficPolicy = &networking.TrafficPolicy{LoadBalancer: c.lb}
+			}
+			warn, err := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: map[string]string{constants.OriginalDstAnnotation: c.value},
+				},
+				Spec: dr,
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRuleSlowStartAggression(t *testing.T) {
+	warmup := &networking.LoadBalancerSettings{WarmupDurationSecs: &types.Duration{Seconds: 10}}
+	cases := []struct {
+		name   string
+		value  string
+		policy *networking.TrafficPolicy
+		subset *networking.TrafficPolicy
+		valid  bool
+		warn   bool
+	}{
+		{name: "valid", value: "1.5", policy: &networking.TrafficPolicy{LoadBalancer: warmup}, valid: true},
+		{name: "subset warmup", value: "2", subset: &networking.TrafficPolicy{LoadBalancer: warmup}, valid: true},
+		{
+			name:  "port warmup",
+			value: "0.5",
+			policy: &networking.TrafficPolicy{PortLevelSettings: []*networking.TrafficPolicy_PortTrafficPolicy{{
+				Port:         &networking.PortSelector{Number: 80},
+				LoadBalancer: warmup,
+			}}},
+			valid: true,
+		},
+		{name: "without warmup", value: "1", valid: true, warn: true},
+		{name: "zero", value: "0", policy: &networking.TrafficPolicy{LoadBalancer: warmup}, valid: false},
+		{name: "negative", value: "-1", policy: &networking.TrafficPolicy{LoadBalancer: warmup}, valid: false},
+		{name: "not a number", value: "fast", policy: &networking.TrafficPolicy{LoadBalancer: warmup}, valid: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dr := &networking.DestinationRule{Host: "reviews", TrafficPolicy: c.policy}
+			if c.subset != nil {
+				dr.Subsets = []*networking.Subset{{Name: "v1", Labels: map[string]string{"version": "v1"}, TrafficPolicy: c.subset}}
+			}
+			warn, err := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: map[string]string{constants.SlowStartAggressionAnnotation: c.value},
+				},
+				Spec: dr,
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRuleLeastRequest(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		valid       bool
+	}{
+		{name: "choice count", annotations: map[string]string{constants.LeastRequestChoiceCountAnnotation: "5"}, valid: true},
+		{name: "choice count too low", annotations: map[string]string{constants.LeastRequestChoiceCountAnnotation: "1"}, valid: false},
+		{name: "invalid choice count", annotations: map[string]string{constants.LeastRequestChoiceCountAnnotation: "two"}, valid: false},
+		{name: "bias", annotations: map[string]string{constants.LeastRequestActiveRequestBiasAnnotation: "1.5"}, valid: true},
+		{name: "zero bias", annotations: map[string]string{constants.LeastRequestActiveRequestBiasAnnotation: "0"}, valid: true},
+		{name: "negative bias", annotations: map[string]string{constants.LeastRequestActiveRequestBiasAnnotation: "-1"}, valid: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: c.annotations,
+				},
+				Spec: &networking.DestinationRule{Host: "reviews"},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRuleOutlierFailurePercentage(t *testing.T) {
+	outlier := &networking.TrafficPolicy{OutlierDetection: &networking.OutlierDetection{}}
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		policy      *networking.TrafficPolicy
+		valid       bool
+		warn        bool
+	}{
+		{
+			name: "valid",
+			annotations: map[string]string{
+				constants.OutlierFailurePercentageThresholdAnnotation:     "20",
+				constants.OutlierFailurePercentageRequestVolumeAnnotation: "10",
+				constants.OutlierFailurePercentageMinimumHostsAnnotation:  "3",
+			},
+			policy: outlier,
+			valid:  true,
+		},
+		{
+			name:        "threshold above 100",
+			annotations: map[string]string{constants.OutlierFailurePercentageThresholdAnnotation: "101"},
+			policy:      outlier,
+			valid:       false,
+		},
+		{
+			name:        "request volume without threshold",
+			annotations: map[string]string{constants.OutlierFailurePercentageRequestVolumeAnnotation: "10"},
+			policy:      outlier,
+			valid:       false,
+		},
+		{
+			name: "invalid minimum hosts",
+			annotations: map[string]string{
+				constants.OutlierFailurePercentageThresholdAnnotation:    "20",
+				constants.OutlierFailurePercentageMinimumHostsAnnotation: "-1",
+			},
+			policy: outlier,
+			valid:  false,
+		},
+		{
+			name:        "without outlier detection",
+			annotations: map[string]string{constants.OutlierFailurePercentageThresholdAnnotation: "20"},
+			valid:       true,
+			warn:        true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			warn, err := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: c.annotations,
+				},
+				Spec: &networking.DestinationRule{Host: "reviews", TrafficPolicy: c.policy},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRuleProtocolFilter(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		valid       bool
+		warn        bool
+	}{
+		{
+			name:        "stats",
+			annotations: map[string]string{constants.ProtocolFilterAnnotation: constants.ProtocolFilterStats},
+			valid:       true,
+		},
+		{
+			name: "routing with op timeout",
+			annotations: map[string]string{
+				constants.ProtocolFilterAnnotation: constants.ProtocolFilterRouting,
+				constants.RedisOpTimeoutAnnotation: "500ms",
+			},
+			valid: true,
+		},
+		{
+			name:        "unknown mode",
+			annotations: map[string]string{constants.ProtocolFilterAnnotation: "all"},
+			valid:       false,
+		},
+		{
+			name: "invalid op timeout",
+			annotations: map[string]string{
+				constants.ProtocolFilterAnnotation: constants.ProtocolFilterRouting,
+				constants.RedisOpTimeoutAnnotation: "0s",
+			},
+			valid: false,
+		},
+		{
+			name: "op timeout without routing",
+			annotations: map[string]string{
+				constants.ProtocolFilterAnnotation: constants.ProtocolFilterStats,
+				constants.RedisOpTimeoutAnnotation: "1s",
+			},
+			valid: true,
+			warn:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			warn, err := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: c.annotations,
+				},
+				Spec: &networking.DestinationRule{Host: "redis"},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
 		})
 	}
 }
 
-func checkValidation(t *testing.T, gotWarning Warning, gotError error, valid bool, warning bool) {
-	t.Helper()
-	if (gotError == nil) != valid {
-		t.Fatalf("got valid=%v but wanted valid=%v: %v", gotError == nil, valid, gotError)
-	}
-	if (gotWarning == nil) == warning {
-		t.Fatalf("got warning=%v but wanted warning=%v", gotWarning, warning)
-	}
-}
-
-func stringOrEmpty(v error) string {
-	if v == nil {
-		return ""
-	}
-	return v.Error()
-}
-
-func checkValidationMessage(t *testing.T, gotWarning Warning, gotError error, wantWarning string, wantError string) {
-	t.Helper()
-	if (gotError == nil) != (wantError == "") {
-		t.Fatalf("got err=%v but wanted err=%v", gotError, wantError)
-	}
-	if !strings.Contains(stringOrEmpty(gotError), wantError) {
-		t.Fatalf("got err=%v but wanted err=%v", gotError, wantError)
-	}
-
-	if (gotWarning == nil) != (wantWarning == "") {
-		t.Fatalf("got warning=%v but wanted warning=%v", gotWarning, wantWarning)
-	}
-	if !strings.Contains(stringOrEmpty(gotWarning), wantWarning) {
-		t.Fatalf("got warning=%v but wanted warning=%v", gotWarning, wantWarning)
-	}
-}
-
 func TestValidateDestinationRule(t *testing.T) {
 	cases := []struct {
 		name  string
@@ -3340,6 +4010,29 @@ func TestValidateConnectionPool(t *testing.T) {
 			valid: false,
 		},
 
+		{
+			name: "valid connection pool, sub-second tcp keepalive", in: networking.ConnectionPoolSettings{
+				Tcp: &networking.ConnectionPoolSettings_TCPSettings{
+					TcpKeepalive: &networking.ConnectionPoolSettings_TCPSettings_TcpKeepalive{
+						Time:     &types.Duration{Nanos: 500000000},
+						Interval: &types.Duration{Seconds: 10},
+					},
+				},
+			},
+			valid: true,
+		},
+
+		{
+			name: "invalid connection pool, bad tcp keepalive interval", in: networking.ConnectionPoolSettings{
+				Tcp: &networking.ConnectionPoolSettings_TCPSettings{
+					TcpKeepalive: &networking.ConnectionPoolSettings_TCPSettings_TcpKeepalive{
+						Interval: &types.Duration{Seconds: -1},
+					},
+				},
+			},
+			valid: false,
+		},
+
 		{
 			name: "invalid connection pool, bad connect timeout", in: networking.ConnectionPoolSettings{
 				Tcp: &networking.ConnectionPoolSettings_TCPSettings{
@@ -4482,6 +5175,81 @@ func TestValidateServiceEntries(t *testing.T) {
 	}
 }
 
+func TestValidateServiceEntryDNSAnnotations(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		resolution  networking.ServiceEntry_Resolution
+		valid       bool
+		warn        bool
+	}{
+		{
+			name: "valid",
+			annotations: map[string]string{
+				constants.DNSRefreshRateAnnotation: "5s",
+				constants.DNSJitterAnnotation:      "500ms",
+				constants.DNSRespectTTLAnnotation:  "false",
+			},
+			resolution: networking.ServiceEntry_DNS,
+			valid:      true,
+		},
+		{
+			name:        "round robin",
+			annotations: map[string]string{constants.DNSRefreshRateAnnotation: "1m"},
+			resolution:  networking.ServiceEntry_DNS_ROUND_ROBIN,
+			valid:       true,
+		},
+		{
+			name:        "static resolution",
+			annotations: map[string]string{constants.DNSRefreshRateAnnotation: "5s"},
+			resolution:  networking.ServiceEntry_STATIC,
+			valid:       true,
+			warn:        true,
+		},
+		{
+			name:        "invalid refresh rate",
+			annotations: map[string]string{constants.DNSRefreshRateAnnotation: "often"},
+			resolution:  networking.ServiceEntry_DNS,
+			valid:       false,
+		},
+		{
+			name:        "sub millisecond jitter",
+			annotations: map[string]string{constants.DNSJitterAnnotation: "10us"},
+			resolution:  networking.ServiceEntry_DNS,
+			valid:       false,
+		},
+		{
+			name:        "invalid respect ttl",
+			annotations: map[string]string{constants.DNSRespectTTLAnnotation: "no"},
+			resolution:  networking.ServiceEntry_DNS,
+			valid:       false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			warn, err := ValidateServiceEntry(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: c.annotations,
+				},
+				Spec: &networking.ServiceEntry{
+					Hosts:      []string{"api.example.com"},
+					Ports:      []*networking.Port{{Number: 443, Protocol: "TLS", Name: "tls"}},
+					Endpoints:  []*networking.WorkloadEntry{{Address: "1.1.1.1"}},
+					Resolution: c.resolution,
+				},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
+		})
+	}
+}
+
 func TestValidateAuthorizationPolicy(t *testing.T) {
 	cases := []struct {
 		name        string
@@ -5314,6 +6082,142 @@ func TestValidateAuthorizationPolicy(t *testing.T) {
 	}
 }
 
+func TestValidateSidecarEgressCatchAll(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		valid bool
+		warn  bool
+	}{
+		{name: "valid", value: "9000=blackhole:404,*=passthrough", valid: true},
+		{name: "invalid action", value: "9000=drop", valid: false},
+		{name: "unknown port", value: "9100=passthrough", valid: true, warn: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			warn, err := ValidateSidecar(config.Config{
+				Meta: config.Meta{
+					Name:        "foo",
+					Namespace:   "bar",
+					Annotations: map[string]string{constants.EgressCatchAllAnnotation: c.value},
+				},
+				Spec: &networking.Sidecar{
+					Egress: []*networking.IstioEgressListener{
+						{Port: &networking.Port{Number: 9000, Protocol: "HTTP", Name: "http"}, Hosts: []string{"*/*"}},
+						{Hosts: []string{"*/*"}},
+					},
+				},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+			if (warn != nil) != c.warn {
+				t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+			}
+		})
+	}
+}
+
+func TestValidateSidecarEgressTimeout(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		valid bool
+		warn  bool
+	}{
+		{name: "valid", value: "9000=5s,*=1m", valid: true},
+		{name: "invalid duration", value: "9000=5", valid: false},
+		{name: "negative duration", value: "9000=-1s", valid: false},
+		{name: "duplicate port", value: "9000=1s,9000=2s", valid: false},
+		{name: "unknown port", value: "9100=1s", valid: true, warn: true},
+	}
+	for _, c := range cases {
+		for _, annotation := range []string{constants.EgressConnectTimeoutAnnotation, constants.EgressIdleTimeoutAnnotation} {
+			t.Run(c.name+"/"+annotation, func(t *testing.T) {
+				warn, err := ValidateSidecar(config.Config{
+					Meta: config.Meta{
+						Name:        "foo",
+						Namespace:   "bar",
+						Annotations: map[string]string{annotation: c.value},
+					},
+					Spec: &networking.Sidecar{
+						Egress: []*networking.IstioEgressListener{
+							{Port: &networking.Port{Number: 9000, Protocol: "HTTP", Name: "http"}, Hosts: []string{"*/*"}},
+							{Hosts: []string{"*/*"}},
+						},
+					},
+				})
+				if (err == nil) != c.valid {
+					t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+				}
+				if (warn != nil) != c.warn {
+					t.Errorf("got warn=%v but wanted warn=%v: %v", warn != nil, c.warn, warn)
+				}
+			})
+		}
+	}
+}
+
+func TestValidateSidecarPassthroughWildcardNamespaces(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{name: "own namespace", value: ".", valid: true},
+		{name: "namespaces", value: "., foo,bar", valid: true},
+		{name: "no namespaces", value: "", valid: true},
+		{name: "invalid namespace", value: "foo,bar_baz", valid: false},
+		{name: "wildcard", value: "*", valid: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ValidateSidecar(config.Config{
+				Meta: config.Meta{
+					Name:        "foo",
+					Namespace:   "bar",
+					Annotations: map[string]string{constants.PassthroughWildcardNamespacesAnnotation: c.value},
+				},
+				Spec: &networking.Sidecar{
+					Egress: []*networking.IstioEgressListener{{Hosts: []string{"*/*"}}},
+				},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateSidecarInboundConnectionLimit(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{name: "limits", value: "8080=100,*=1000", valid: true},
+		{name: "zero limit", value: "8080=0", valid: false},
+		{name: "missing limit", value: "8080", valid: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ValidateSidecar(config.Config{
+				Meta: config.Meta{
+					Name:        "foo",
+					Namespace:   "bar",
+					Annotations: map[string]string{constants.InboundConnectionLimitAnnotation: c.value},
+				},
+				Spec: &networking.Sidecar{
+					Egress: []*networking.IstioEgressListener{{Hosts: []string{"*/*"}}},
+				},
+			})
+			if (err == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", err == nil, c.valid, err)
+			}
+		})
+	}
+}
+
 func TestValidateSidecar(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -6445,6 +7349,58 @@ func TestValidateRequestAuthentication(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			name:       "jwt cache annotations",
+			configName: someName,
+			annotations: map[string]string{
+				constants.JwtCacheSizeAnnotation:      "100",
+				constants.JwksCacheDurationAnnotation: "10m",
+				constants.JwksAsyncFetchAnnotation:    "fast-listener",
+			},
+			in:    &security_beta.RequestAuthentication{},
+			valid: true,
+		},
+		{
+			name:        "invalid jwt cache size",
+			configName:  someName,
+			annotations: map[string]string{constants.JwtCacheSizeAnnotation: "0"},
+			in:          &security_beta.RequestAuthentication{},
+			valid:       false,
+		},
+		{
+			name:        "invalid jwks cache duration",
+			configName:  someName,
+			annotations: map[string]string{constants.JwksCacheDurationAnnotation: "10"},
+			in:          &security_beta.RequestAuthentication{},
+			valid:       false,
+		},
+		{
+			name:        "invalid jwks async fetch",
+			configName:  someName,
+			annotations: map[string]string{constants.JwksAsyncFetchAnnotation: "yes"},
+			in:          &security_beta.RequestAuthentication{},
+			valid:       false,
+		},
+		{
+			name:       "oauth2",
+			configName: someName,
+			annotations: map[string]string{constants.OAuth2Annotation: `{"clientID": "gateway", "credentialName": "oauth2",
+				"tokenEndpoint": "https://oauth.example.com/token", "authorizationEndpoint": "https://oauth.example.com/authorize",
+				"redirectURI": "https://%REQ(:authority)%/callback", "redirectPath": "/callback"}`},
+			in: &security_beta.RequestAuthentication{
+				Selector: &api.WorkloadSelector{MatchLabels: map[string]string{"istio": "ingressgateway"}},
+			},
+			valid: true,
+		},
+		{
+			name:        "invalid oauth2",
+			configName:  someName,
+			annotations: map[string]string{constants.OAuth2Annotation: `{"clientID": "gateway"}`},
+			in: &security_beta.RequestAuthentication{
+				Selector: &api.WorkloadSelector{MatchLabels: map[string]string{"istio": "ingressgateway"}},
+			},
+			valid: false,
+		},
 	}
 
 	for _, c := range cases {
@@ -6465,10 +7421,11 @@ func TestValidateRequestAuthentication(t *testing.T) {
 
 func TestValidatePeerAuthentication(t *testing.T) {
 	cases := []struct {
-		name       string
-		configName string
-		in         proto.Message
-		valid      bool
+		name        string
+		configName  string
+		annotations map[string]string
+		in          proto.Message
+		valid       bool
 	}{
 		{
 			name:       "empty spec",
@@ -6566,14 +7523,29 @@ func TestValidatePeerAuthentication(t *testing.T) {
 			},
 			valid: true,
 		},
+		{
+			name:        "identity headers",
+			configName:  someName,
+			annotations: map[string]string{constants.IdentityHeadersAnnotation: "principal=source.principal,user=request.auth.claims[sub]"},
+			in:          &security_beta.PeerAuthentication{},
+			valid:       true,
+		},
+		{
+			name:        "invalid identity headers",
+			configName:  someName,
+			annotations: map[string]string{constants.IdentityHeadersAnnotation: "principal=source.namespace"},
+			in:          &security_beta.PeerAuthentication{},
+			valid:       false,
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			if _, got := ValidatePeerAuthentication(config.Config{
 				Meta: config.Meta{
-					Name:      c.configName,
-					Namespace: someNamespace,
+					Name:        c.configName,
+					Namespace:   someNamespace,
+					Annotations: c.annotations,
 				},
 				Spec: c.in,
 			}); (got == nil) != c.valid {
@@ -7117,3 +8089,59 @@ func TestValidateWasmPlugin(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateWasmPluginExtProc(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		out   string
+	}{
+		{"valid", `{"service": "ext-proc.foo.svc.cluster.local", "port": 9000}`, ""},
+		{"missing port", `{"service": "ext-proc.foo.svc.cluster.local"}`, "invalid ext_proc port"},
+		{"invalid mode", `{"service": "ext-proc.foo.svc.cluster.local", "port": 9000, "processingMode": {"requestBodyMode": "ALL"}}`,
+			"invalid ext_proc processingMode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The url of the WasmPlugin is not required for an external processor.
+			warn, err := ValidateWasmPlugin(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: map[string]string{constants.ExtProcAnnotation: tt.value},
+				},
+				Spec: &extensions.WasmPlugin{},
+			})
+			checkValidationMessage(t, warn, err, "", tt.out)
+		})
+	}
+}
+
+func TestValidateWasmPluginType(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		out         string
+	}{
+		{"http", map[string]string{constants.WasmPluginTypeAnnotation: "HTTP"}, ""},
+		{"network", map[string]string{constants.WasmPluginTypeAnnotation: "NETWORK"}, ""},
+		{"invalid", map[string]string{constants.WasmPluginTypeAnnotation: "UDP"}, `invalid type "UDP"`},
+		{"network ext_proc", map[string]string{
+			constants.WasmPluginTypeAnnotation: "NETWORK",
+			constants.ExtProcAnnotation:        `{"service": "ext-proc.foo.svc.cluster.local", "port": 9000}`,
+		}, "requires the HTTP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warn, err := ValidateWasmPlugin(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: tt.annotations,
+				},
+				Spec: &extensions.WasmPlugin{Url: "oci://hub/plugin:v1"},
+			})
+			checkValidationMessage(t, warn, err, "", tt.out)
+		})
+	}
+}
@@ -42,10 +42,10 @@ func getHTTPRouteType(http *networking.HTTPRoute, isDelegate bool) HTTPRouteType
 	return IndependentRoute
 }
 
-func validateHTTPRoute(http *networking.HTTPRoute, delegate bool) (errs Validation) {
+func validateHTTPRoute(http *networking.HTTPRoute, delegate, directResponse bool) (errs Validation) {
 	routeType := getHTTPRouteType(http, delegate)
 	// check for conflicts
-	errs = WrapError(validateHTTPRouteConflict(http, routeType))
+	errs = WrapError(validateHTTPRouteConflict(http, routeType, directResponse))
 
 	// check http route match requests
 	errs = appendValidation(errs, validateHTTPRouteMatchRequest(http, routeType))
@@ -217,7 +217,16 @@ func validateHTTPRouteMatchRequest(http *networking.HTTPRoute, routeType HTTPRou
 	return
 }
 
-func validateHTTPRouteConflict(http *networking.HTTPRoute, routeType HTTPRouteType) (errs error) {
+func validateHTTPRouteConflict(http *networking.HTTPRoute, routeType HTTPRouteType, directResponse bool) (errs error) {
+	if directResponse {
+		// The route responds from the proxy, set by the DirectResponseAnnotation of the VirtualService.
+		if http.Redirect != nil || len(http.Route) > 0 || http.Delegate != nil {
+			errs = appendErrors(errs, fmt.Errorf("HTTP route %s has a direct response and must not specify route, redirect or delegate",
+				http.Name))
+		}
+		return errs
+	}
+
 	if routeType == RootRoute {
 		// This is to check root conflict
 		// only delegate can be specified
@@ -0,0 +1,266 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcmx
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pkg/kube/labels"
+	"istio.io/pkg/log"
+	"istio.io/pkg/monitoring"
+)
+
+var mxLog = log.RegisterScope("grpcmx", "gRPC metadata exchange", 0)
+
+const (
+	sourceReporter      = "source"
+	destinationReporter = "destination"
+	unknown             = "unknown"
+)
+
+type peerKey struct{}
+
+// PeerFromContext returns the metadata sent by the client of a server call, or nil if it sent none.
+func PeerFromContext(ctx context.Context) *PeerMetadata {
+	peer, _ := ctx.Value(peerKey{}).(*PeerMetadata)
+	return peer
+}
+
+// Interceptors exchange the workload metadata with peers and record the Istio standard metrics for each call.
+type Interceptors struct {
+	local    *PeerMetadata
+	outgoing metadata.MD
+}
+
+// New returns the interceptors for the local workload.
+func New(local *PeerMetadata) (*Interceptors, error) {
+	encoded, err := local.encode()
+	if err != nil {
+		return nil, err
+	}
+	return &Interceptors{
+		local:    local,
+		outgoing: metadata.Pairs(MetadataHeader, encoded, MetadataIDHeader, local.ID),
+	}, nil
+}
+
+// UnaryServerInterceptor returns the interceptor for unary calls served by the workload.
+func (i *Interceptors) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		peer, ctx := i.serverExchange(ctx)
+		if err := grpc.SetHeader(ctx, i.outgoing); err != nil {
+			mxLog.Debugf("failed to send metadata: %v", err)
+		}
+		resp, err := handler(ctx, req)
+		i.record(destinationReporter, peer, i.local, authority(ctx), err, start)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns the interceptor for streaming calls served by the workload.
+func (i *Interceptors) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		peer, ctx := i.serverExchange(ss.Context())
+		if err := ss.SetHeader(i.outgoing); err != nil {
+			mxLog.Debugf("failed to send metadata: %v", err)
+		}
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		i.record(destinationReporter, peer, i.local, authority(ctx), err, start)
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns the interceptor for unary calls made by the workload.
+func (i *Interceptors) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		var header metadata.MD
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Join(outgoingMD(ctx), i.outgoing))
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		i.record(sourceReporter, i.local, fromMD(header), targetHost(cc.Target()), err, start)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns the interceptor for streaming calls made by the workload.
+func (i *Interceptors) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Join(outgoingMD(ctx), i.outgoing))
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			i.record(sourceReporter, i.local, nil, targetHost(cc.Target()), err, start)
+			return nil, err
+		}
+		s := &clientStream{ClientStream: cs, serverStreams: desc.ServerStreams, finished: make(chan struct{}), done: func(err error) {
+			header, _ := cs.Header()
+			i.record(sourceReporter, i.local, fromMD(header), targetHost(cc.Target()), err, start)
+		}}
+		// Streams abandoned by cancelling their context are never received from again.
+		go func() {
+			select {
+			case <-ctx.Done():
+				s.finish(status.FromContextError(ctx.Err()).Err())
+			case <-s.finished:
+			}
+		}()
+		return s, nil
+	}
+}
+
+func (i *Interceptors) serverExchange(ctx context.Context) (*PeerMetadata, context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	peer := fromMD(md)
+	if peer != nil {
+		ctx = context.WithValue(ctx, peerKey{}, peer)
+	}
+	return peer, ctx
+}
+
+func (i *Interceptors) record(reporter string, source, destination *PeerMetadata, destinationService string,
+	err error, start time.Time) {
+	srcService, srcRevision := canonicalService(source)
+	dstService, dstRevision := canonicalService(destination)
+	tags := []monitoring.LabelValue{
+		reporterTag.Value(reporter),
+		sourceWorkloadTag.Value(workloadName(source)),
+		sourceWorkloadNamespaceTag.Value(namespace(source)),
+		sourceCanonicalServiceTag.Value(srcService),
+		sourceCanonicalRevisionTag.Value(srcRevision),
+		sourceClusterTag.Value(clusterID(source)),
+		destinationWorkloadTag.Value(workloadName(destination)),
+		destinationWorkloadNamespaceTag.Value(namespace(destination)),
+		destinationCanonicalServiceTag.Value(dstService),
+		destinationCanonicalRevisionTag.Value(dstRevision),
+		destinationClusterTag.Value(clusterID(destination)),
+		destinationServiceTag.Value(destinationService),
+		requestProtocolTag.Value("grpc"),
+		// gRPC responses are always sent with HTTP status 200; the outcome is in grpc-status.
+		responseCodeTag.Value("200"),
+		grpcResponseStatusTag.Value(strconv.Itoa(int(status.Code(err)))),
+	}
+	requestsTotal.With(tags...).Increment()
+	requestDuration.With(tags...).Record(float64(time.Since(start).Milliseconds()))
+}
+
+func canonicalService(md *PeerMetadata) (string, string) {
+	if md == nil {
+		return unknown, unknown
+	}
+	return labels.CanonicalService(md.Labels, md.WorkloadName)
+}
+
+func workloadName(md *PeerMetadata) string {
+	if md == nil || md.WorkloadName == "" {
+		return unknown
+	}
+	return md.WorkloadName
+}
+
+func namespace(md *PeerMetadata) string {
+	if md == nil || md.Namespace == "" {
+		return unknown
+	}
+	return md.Namespace
+}
+
+func clusterID(md *PeerMetadata) string {
+	if md == nil || md.ClusterID == "" {
+		return unknown
+	}
+	return md.ClusterID
+}
+
+func outgoingMD(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md
+}
+
+// authority returns the host the client called, without the port.
+func authority(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(":authority"); len(values) > 0 {
+		return stripPort(values[0])
+	}
+	return unknown
+}
+
+// targetHost returns the host of a dial target such as xds:///echo.default.svc.cluster.local:7070.
+func targetHost(target string) string {
+	if i := strings.Index(target, "://"); i != -1 {
+		target = strings.TrimLeft(target[i+3:], "/")
+	}
+	return stripPort(target)
+}
+
+func stripPort(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return hostport
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientStream records a streaming call when RecvMsg returns its status, when the single response of a client
+// streaming call is received after CloseSend, or when its context is cancelled, whichever comes first.
+type clientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	once          sync.Once
+	finished      chan struct{}
+	done          func(err error)
+}
+
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() {
+		close(s.finished)
+		s.done(err)
+	})
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	case !s.serverStreams:
+		s.finish(nil)
+	}
+	return err
+}
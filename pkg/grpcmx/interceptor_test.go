@@ -0,0 +1,198 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcmx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.opencensus.io/stats/view"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestServerExchange(t *testing.T) {
+	client := &PeerMetadata{ID: "client", Namespace: "client-ns", WorkloadName: "client", Labels: map[string]string{}}
+	clientMx, err := New(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverMx, err := New(testMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), clientMx.outgoing)
+	var got *PeerMetadata
+	_, err = serverMx.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			got = PeerFromContext(ctx)
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, client) {
+		t.Fatalf("got peer %+v, want %+v", got, client)
+	}
+}
+
+func TestClientExchange(t *testing.T) {
+	server := &PeerMetadata{ID: "server", Namespace: "server-ns", WorkloadName: "server", Labels: map[string]string{}}
+	serverMx, err := New(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientMx, err := New(testMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, err := grpc.Dial("passthrough:///echo.echo.svc.cluster.local:7070", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	var sent metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		for _, o := range opts {
+			if h, ok := o.(grpc.HeaderCallOption); ok {
+				*h.HeaderAddr = serverMx.outgoing
+			}
+		}
+		return nil
+	}
+	if err := clientMx.UnaryClientInterceptor()(context.Background(), "/echo", nil, nil, cc, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if got := fromMD(sent); got == nil || got.WorkloadName != testMetadata.WorkloadName || got.ID != testMetadata.ID {
+		t.Fatalf("client sent %+v", got)
+	}
+}
+
+type fakeClientStream struct {
+	grpc.ClientStream
+}
+
+func (f *fakeClientStream) Header() (metadata.MD, error) {
+	return nil, nil
+}
+
+func (f *fakeClientStream) CloseSend() error {
+	return nil
+}
+
+func (f *fakeClientStream) RecvMsg(interface{}) error {
+	return nil
+}
+
+func requestCount(t *testing.T, destinationService string, code codes.Code) float64 {
+	t.Helper()
+	rows, err := view.RetrieveData("istio_requests_total")
+	if err != nil {
+		t.Fatalf("failed to get the rows of istio_requests_total: %v", err)
+	}
+	for _, row := range rows {
+		tags := map[string]string{}
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["destination_service"] == destinationService && tags["grpc_response_status"] == strconv.Itoa(int(code)) {
+			return row.Data.(*view.SumData).Value
+		}
+	}
+	return 0
+}
+
+func TestStreamClientRecord(t *testing.T) {
+	clientMx, err := New(testMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &fakeClientStream{}, nil
+	}
+	stream := func(ctx context.Context, t *testing.T, host string, desc *grpc.StreamDesc) grpc.ClientStream {
+		t.Helper()
+		cc, err := grpc.Dial("passthrough:///"+host+":7070", grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { cc.Close() })
+		cs, err := clientMx.StreamClientInterceptor()(ctx, desc, cc, "/echo", streamer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cs
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		before := requestCount(t, "cancelled.echo.svc.cluster.local", codes.Canceled)
+		ctx, cancel := context.WithCancel(context.Background())
+		stream(ctx, t, "cancelled.echo.svc.cluster.local", &grpc.StreamDesc{ServerStreams: true, ClientStreams: true})
+		cancel()
+		retry.UntilSuccessOrFail(t, func() error {
+			if got := requestCount(t, "cancelled.echo.svc.cluster.local", codes.Canceled); got != before+1 {
+				return fmt.Errorf("got %v cancelled calls, want %v", got, before+1)
+			}
+			return nil
+		}, retry.Timeout(time.Second), retry.Delay(10*time.Millisecond))
+	})
+
+	t.Run("client streaming", func(t *testing.T) {
+		ok := requestCount(t, "upload.echo.svc.cluster.local", codes.OK)
+		cancelled := requestCount(t, "upload.echo.svc.cluster.local", codes.Canceled)
+		ctx, cancel := context.WithCancel(context.Background())
+		cs := stream(ctx, t, "upload.echo.svc.cluster.local", &grpc.StreamDesc{ClientStreams: true})
+		if err := cs.CloseSend(); err != nil {
+			t.Fatal(err)
+		}
+		if err := cs.RecvMsg(nil); err != nil {
+			t.Fatal(err)
+		}
+		// Cancelling the completed call is not recorded again.
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		if got := requestCount(t, "upload.echo.svc.cluster.local", codes.OK); got != ok+1 {
+			t.Fatalf("got %v completed calls, want %v", got, ok+1)
+		}
+		if got := requestCount(t, "upload.echo.svc.cluster.local", codes.Canceled); got != cancelled {
+			t.Fatalf("got %v cancelled calls, want %v", got, cancelled)
+		}
+	})
+}
+
+func TestTargetHost(t *testing.T) {
+	cases := map[string]string{
+		"xds:///echo.echo.svc.cluster.local:7070": "echo.echo.svc.cluster.local",
+		"dns:///echo:7070":                        "echo",
+		"echo.echo:7070":                          "echo.echo",
+		"echo":                                    "echo",
+	}
+	for in, want := range cases {
+		if got := targetHost(in); got != want {
+			t.Errorf("targetHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpcmx implements the Istio metadata exchange for proxyless gRPC workloads.
+//
+// Sidecars exchange workload metadata with their peers and use it to label the standard Istio metrics.
+// Proxyless gRPC workloads have no sidecar in the request path, so this package provides gRPC interceptors
+// that exchange the same metadata, using the same headers as the Envoy metadata exchange, and record
+// istio_requests_total and istio_request_duration_milliseconds with the standard labels:
+//
+//	local, err := grpcmx.FromBootstrap(os.Getenv("GRPC_XDS_BOOTSTRAP"))
+//	mx, err := grpcmx.New(local)
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(mx.UnaryServerInterceptor()),
+//		grpc.ChainStreamInterceptor(mx.StreamServerInterceptor()))
+//	conn, err := grpc.Dial(target,
+//		grpc.WithChainUnaryInterceptor(mx.UnaryClientInterceptor()),
+//		grpc.WithChainStreamInterceptor(mx.StreamClientInterceptor()))
+//
+// The metrics are registered with istio.io/pkg/monitoring and must be exported by the application, for
+// example with the Prometheus exporter.
+package grpcmx
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// MetadataHeader carries the base64 encoded google.protobuf.Struct with the workload metadata.
+	MetadataHeader = "x-envoy-peer-metadata"
+	// MetadataIDHeader carries the node ID of the workload.
+	MetadataIDHeader = "x-envoy-peer-metadata-id"
+)
+
+// Keys of the exchanged metadata, matching the proxy node metadata.
+const (
+	nameKey         = "NAME"
+	namespaceKey    = "NAMESPACE"
+	workloadNameKey = "WORKLOAD_NAME"
+	clusterIDKey    = "CLUSTER_ID"
+	meshIDKey       = "MESH_ID"
+	labelsKey       = "LABELS"
+)
+
+// PeerMetadata is the workload metadata exchanged with peers.
+type PeerMetadata struct {
+	ID           string
+	Name         string
+	Namespace    string
+	WorkloadName string
+	ClusterID    string
+	MeshID       string
+	Labels       map[string]string
+}
+
+// FromBootstrap reads the local workload metadata from the node in the gRPC xDS bootstrap file
+// generated by istio-agent.
+func FromBootstrap(path string) (*PeerMetadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bootstrap struct {
+		Node struct {
+			ID       string                 `json:"id"`
+			Metadata map[string]interface{} `json:"metadata"`
+		} `json:"node"`
+	}
+	if err := json.Unmarshal(data, &bootstrap); err != nil {
+		return nil, fmt.Errorf("failed to parse bootstrap %s: %v", path, err)
+	}
+	md := fromMap(bootstrap.Node.Metadata)
+	md.ID = bootstrap.Node.ID
+	return md, nil
+}
+
+func fromMap(m map[string]interface{}) *PeerMetadata {
+	str := func(key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	md := &PeerMetadata{
+		Name:         str(nameKey),
+		Namespace:    str(namespaceKey),
+		WorkloadName: str(workloadNameKey),
+		ClusterID:    str(clusterIDKey),
+		MeshID:       str(meshIDKey),
+		Labels:       map[string]string{},
+	}
+	if labels, ok := m[labelsKey].(map[string]interface{}); ok {
+		for k, v := range labels {
+			if s, ok := v.(string); ok {
+				md.Labels[k] = s
+			}
+		}
+	}
+	return md
+}
+
+// encode serializes the metadata for MetadataHeader.
+func (md *PeerMetadata) encode() (string, error) {
+	labels := make(map[string]interface{}, len(md.Labels))
+	for k, v := range md.Labels {
+		labels[k] = v
+	}
+	s, err := structpb.NewStruct(map[string]interface{}{
+		nameKey:         md.Name,
+		namespaceKey:    md.Namespace,
+		workloadNameKey: md.WorkloadName,
+		clusterIDKey:    md.ClusterID,
+		meshIDKey:       md.MeshID,
+		labelsKey:       labels,
+	})
+	if err != nil {
+		return "", err
+	}
+	b, err := proto.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// decode parses a MetadataHeader value.
+func decode(value string) (*PeerMetadata, error) {
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err := proto.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return fromMap(s.AsMap()), nil
+}
+
+// fromMD returns the peer metadata sent in md, or nil if the peer did not send any.
+func fromMD(md metadata.MD) *PeerMetadata {
+	values := md.Get(MetadataHeader)
+	if len(values) == 0 {
+		return nil
+	}
+	peer, err := decode(values[0])
+	if err != nil {
+		mxLog.Debugf("failed to decode peer metadata: %v", err)
+		return nil
+	}
+	if ids := md.Get(MetadataIDHeader); len(ids) > 0 {
+		peer.ID = ids[0]
+	}
+	return peer
+}
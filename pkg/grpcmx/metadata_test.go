@@ -0,0 +1,80 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcmx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testMetadata = &PeerMetadata{
+	ID:           "sidecar~10.0.0.1~echo-7b6d8c5f4-q2x8z.echo~echo.svc.cluster.local",
+	Name:         "echo-7b6d8c5f4-q2x8z",
+	Namespace:    "echo",
+	WorkloadName: "echo",
+	ClusterID:    "Kubernetes",
+	MeshID:       "cluster.local",
+	Labels:       map[string]string{"app": "echo", "version": "v1"},
+}
+
+func TestFromBootstrap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grpc-bootstrap.json")
+	bootstrap := `{
+  "node": {
+    "id": "sidecar~10.0.0.1~echo-7b6d8c5f4-q2x8z.echo~echo.svc.cluster.local",
+    "metadata": {
+      "NAME": "echo-7b6d8c5f4-q2x8z",
+      "NAMESPACE": "echo",
+      "WORKLOAD_NAME": "echo",
+      "CLUSTER_ID": "Kubernetes",
+      "MESH_ID": "cluster.local",
+      "LABELS": {"app": "echo", "version": "v1"},
+      "PILOT_SAN": ["istiod.istio-system.svc"]
+    }
+  }
+}`
+	if err := os.WriteFile(path, []byte(bootstrap), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := FromBootstrap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, testMetadata) {
+		t.Fatalf("got %+v, want %+v", got, testMetadata)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	encoded, err := testMetadata.encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decode(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ID is exchanged in its own header.
+	want := *testMetadata
+	want.ID = ""
+	if !reflect.DeepEqual(got, &want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if _, err := decode("not base64!"); err == nil {
+		t.Fatalf("expected error decoding invalid metadata")
+	}
+}
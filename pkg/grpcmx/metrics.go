@@ -0,0 +1,73 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcmx
+
+import (
+	"istio.io/pkg/monitoring"
+)
+
+// Labels of the Istio standard metrics, see https://istio.io/latest/docs/reference/config/metrics/.
+var (
+	reporterTag                     = monitoring.MustCreateLabel("reporter")
+	sourceWorkloadTag               = monitoring.MustCreateLabel("source_workload")
+	sourceWorkloadNamespaceTag      = monitoring.MustCreateLabel("source_workload_namespace")
+	sourceCanonicalServiceTag       = monitoring.MustCreateLabel("source_canonical_service")
+	sourceCanonicalRevisionTag      = monitoring.MustCreateLabel("source_canonical_revision")
+	sourceClusterTag                = monitoring.MustCreateLabel("source_cluster")
+	destinationWorkloadTag          = monitoring.MustCreateLabel("destination_workload")
+	destinationWorkloadNamespaceTag = monitoring.MustCreateLabel("destination_workload_namespace")
+	destinationCanonicalServiceTag  = monitoring.MustCreateLabel("destination_canonical_service")
+	destinationCanonicalRevisionTag = monitoring.MustCreateLabel("destination_canonical_revision")
+	destinationClusterTag           = monitoring.MustCreateLabel("destination_cluster")
+	destinationServiceTag           = monitoring.MustCreateLabel("destination_service")
+	requestProtocolTag              = monitoring.MustCreateLabel("request_protocol")
+	responseCodeTag                 = monitoring.MustCreateLabel("response_code")
+	grpcResponseStatusTag           = monitoring.MustCreateLabel("grpc_response_status")
+
+	standardLabels = monitoring.WithLabels(
+		reporterTag,
+		sourceWorkloadTag,
+		sourceWorkloadNamespaceTag,
+		sourceCanonicalServiceTag,
+		sourceCanonicalRevisionTag,
+		sourceClusterTag,
+		destinationWorkloadTag,
+		destinationWorkloadNamespaceTag,
+		destinationCanonicalServiceTag,
+		destinationCanonicalRevisionTag,
+		destinationClusterTag,
+		destinationServiceTag,
+		requestProtocolTag,
+		responseCodeTag,
+		grpcResponseStatusTag,
+	)
+
+	requestsTotal = monitoring.NewSum(
+		"istio_requests_total",
+		"Total number of gRPC calls handled by the workload.",
+		standardLabels,
+	)
+
+	requestDuration = monitoring.NewDistribution(
+		"istio_request_duration_milliseconds",
+		"Duration of gRPC calls in milliseconds.",
+		[]float64{0.5, 1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000},
+		standardLabels,
+	)
+)
+
+func init() {
+	monitoring.MustRegister(requestsTotal, requestDuration)
+}
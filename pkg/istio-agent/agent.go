@@ -197,6 +197,9 @@ type AgentOptions struct {
 	IstiodSAN string
 
 	WASMInsecureRegistries []string
+
+	// WASMPinModuleChecksums pins the Wasm modules fetched without checksum to the checksum of their first download.
+	WASMPinModuleChecksums bool
 }
 
 // NewAgent hosts the functionality for local SDS and XDS. This consists of the local SDS server and
@@ -547,6 +550,15 @@ func (a *Agent) GetDNSTable() *dnsProto.NameTable {
 	return nil
 }
 
+// DrainEndpoint asks istiod to report the endpoints of the proxy as draining, and waits until the proxies sending
+// them traffic have acked the change or the context is done.
+func (a *Agent) DrainEndpoint(ctx context.Context) (*model.EndpointDrainStatus, error) {
+	if a.xdsProxy == nil {
+		return nil, fmt.Errorf("xds proxy is not running")
+	}
+	return a.xdsProxy.DrainEndpoint(ctx)
+}
+
 func (a *Agent) Close() {
 	if a.xdsProxy != nil {
 		a.xdsProxy.close()
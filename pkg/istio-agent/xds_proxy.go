@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
@@ -47,6 +48,7 @@ import (
 	"istio.io/istio/pilot/cmd/pilot-agent/status/ready"
 	"istio.io/istio/pilot/pkg/features"
 	istiogrpc "istio.io/istio/pilot/pkg/grpc"
+	"istio.io/istio/pilot/pkg/model"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config/constants"
 	dnsProto "istio.io/istio/pkg/dns/proto"
@@ -67,6 +69,9 @@ const (
 	defaultClientMaxReceiveMessageSize = math.MaxInt32
 	defaultInitialConnWindowSize       = 1024 * 1024 // default gRPC InitialWindowSize
 	defaultInitialWindowSize           = 1024 * 1024 // default gRPC ConnWindowSize
+
+	// maxReconnectDelay bounds the reconnect delay requested by istiod.
+	maxReconnectDelay = 5 * time.Minute
 )
 
 var connectionNumber = atomic.NewUint32(0)
@@ -120,6 +125,9 @@ type XdsProxy struct {
 	ecdsLastNonce         atomic.String
 	downstreamGrpcOptions []grpc.ServerOption
 	istiodSAN             string
+
+	// endpointDrainStatus holds the last drain status received from istiod, not read yet.
+	endpointDrainStatus chan *model.EndpointDrainStatus
 }
 
 var proxyLog = log.RegisterScope("xdsproxy", "XDS Proxy in Istio Agent", 0)
@@ -143,7 +151,8 @@ func initXdsProxy(ia *Agent) (*XdsProxy, error) {
 		}
 	}
 
-	cache := wasm.NewLocalFileCache(constants.IstioDataDir, wasm.DefaultWasmModulePurgeInterval, wasm.DefaultWasmModuleExpiry, ia.cfg.WASMInsecureRegistries)
+	cache := wasm.NewLocalFileCache(constants.IstioDataDir, wasm.DefaultWasmModulePurgeInterval, wasm.DefaultWasmModuleExpiry,
+		ia.cfg.WASMInsecureRegistries, ia.cfg.WASMPinModuleChecksums)
 	proxy := &XdsProxy{
 		istiodAddress:         ia.proxyConfig.DiscoveryAddress,
 		istiodSAN:             ia.cfg.IstiodSAN,
@@ -156,7 +165,9 @@ func initXdsProxy(ia *Agent) (*XdsProxy, error) {
 		wasmCache:             cache,
 		proxyAddresses:        ia.cfg.ProxyIPAddresses,
 		downstreamGrpcOptions: ia.cfg.DownstreamGrpcOptions,
+		endpointDrainStatus:   make(chan *model.EndpointDrainStatus, 1),
 	}
+	proxy.handlers[v3.EndpointDrainType] = proxy.handleEndpointDrainStatus
 
 	if ia.localDNSServer != nil {
 		proxy.handlers[v3.NameTableType] = func(resp *any.Any) error {
@@ -406,6 +417,7 @@ func (p *XdsProxy) HandleUpstream(ctx context.Context, con *ProxyConnection, xds
 				proxyLog.Warnf("upstream [%d] terminated with unexpected error %v", con.conID, err)
 				metrics.IstiodConnectionErrors.Increment()
 			}
+			p.waitForReconnect(con, con.upstream.Trailer())
 			return err
 		case err := <-con.downstreamError:
 			// error from downstream Envoy.
@@ -425,6 +437,47 @@ func (p *XdsProxy) HandleUpstream(ctx context.Context, con *ProxyConnection, xds
 	}
 }
 
+// reconnectDelay returns how long to wait before reconnecting, based on the backoff and jitter
+// hints istiod sent in the stream trailers.
+func reconnectDelay(md metadata.MD) time.Duration {
+	parse := func(key string) time.Duration {
+		v := md.Get(key)
+		if len(v) == 0 {
+			return 0
+		}
+		d, err := time.ParseDuration(v[0])
+		if err != nil || d < 0 {
+			proxyLog.Warnf("ignoring invalid %s %q", key, v[0])
+			return 0
+		}
+		return d
+	}
+	delay := parse(constants.ReconnectBackoffHeader)
+	if jitter := parse(constants.ReconnectJitterHeader); jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	if delay > maxReconnectDelay {
+		delay = maxReconnectDelay
+	}
+	return delay
+}
+
+// waitForReconnect delays the termination of a connection, and with it Envoy's reconnect, as requested by istiod.
+func (p *XdsProxy) waitForReconnect(con *ProxyConnection, trailer metadata.MD) {
+	delay := reconnectDelay(trailer)
+	if delay == 0 {
+		return
+	}
+	proxyLog.Infof("upstream [%d] requested reconnect backoff, waiting %v", con.conID, delay)
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-con.stopChan:
+	case <-p.stopChan:
+	}
+}
+
 func (p *XdsProxy) handleUpstreamRequest(con *ProxyConnection) {
 	initialRequestsSent := atomic.NewBool(false)
 	go func() {
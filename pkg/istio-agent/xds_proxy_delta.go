@@ -168,6 +168,7 @@ func (p *XdsProxy) HandleDeltaUpstream(ctx context.Context, con *ProxyConnection
 				proxyLog.Warnf("upstream terminated with unexpected error %v", err)
 				metrics.IstiodConnectionErrors.Increment()
 			}
+			p.waitForReconnect(con, con.upstreamDeltas.Trailer())
 			return err
 		case err := <-con.downstreamError:
 			// error from downstream Envoy.
@@ -0,0 +1,89 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istioagent
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	any "google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+// endpointDrainPollInterval is how often the drain status is requested from istiod while proxies are pending.
+var endpointDrainPollInterval = time.Second
+
+// handleEndpointDrainStatus keeps the last drain status received from istiod.
+func (p *XdsProxy) handleEndpointDrainStatus(resp *any.Any) error {
+	status := &model.EndpointDrainStatus{}
+	if err := json.Unmarshal(resp.Value, status); err != nil {
+		proxyLog.Errorf("failed to unmarshal endpoint drain status: %v", err)
+		return err
+	}
+	// Only the last status matters; replace the one not read yet, if any.
+	select {
+	case <-p.endpointDrainStatus:
+	default:
+	}
+	select {
+	case p.endpointDrainStatus <- status:
+	default:
+	}
+	return nil
+}
+
+// requestEndpointDrain asks istiod for the drain status of the endpoints of the proxy, starting the drain on the
+// first request.
+func (p *XdsProxy) requestEndpointDrain() {
+	p.connectedMutex.RLock()
+	con := p.connected
+	p.connectedMutex.RUnlock()
+	switch {
+	case con == nil:
+		proxyLog.Debugf("not connected to istiod, delaying the endpoint drain request")
+	case con.deltaRequestsChan != nil:
+		con.sendDeltaRequest(&discovery.DeltaDiscoveryRequest{TypeUrl: v3.EndpointDrainType})
+	default:
+		con.sendRequest(&discovery.DiscoveryRequest{TypeUrl: v3.EndpointDrainType})
+	}
+}
+
+// DrainEndpoint asks istiod to report the endpoints of the proxy as draining, and waits until the proxies watching
+// them have acked the change, so they no longer send new requests to this proxy. It returns the last status received,
+// which is nil if istiod did not answer, and the context error if it is done first.
+func (p *XdsProxy) DrainEndpoint(ctx context.Context) (*model.EndpointDrainStatus, error) {
+	ticker := time.NewTicker(endpointDrainPollInterval)
+	defer ticker.Stop()
+	var last *model.EndpointDrainStatus
+	p.requestEndpointDrain()
+	for {
+		select {
+		case status := <-p.endpointDrainStatus:
+			last = status
+			if len(status.Pending) == 0 {
+				return last, nil
+			}
+			proxyLog.Debugf("endpoint drain pending on %d proxies", len(status.Pending))
+		case <-ticker.C:
+			p.requestEndpointDrain()
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+	}
+}
@@ -45,6 +45,7 @@ import (
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/envoy"
@@ -447,6 +448,32 @@ func TestXdsProxyReconnects(t *testing.T) {
 	})
 }
 
+func TestReconnectDelay(t *testing.T) {
+	cases := []struct {
+		name     string
+		md       metadata.MD
+		min, max time.Duration
+	}{
+		{"no hint", metadata.MD{}, 0, 0},
+		{"backoff", metadata.Pairs(constants.ReconnectBackoffHeader, "2s"), 2 * time.Second, 2 * time.Second},
+		{
+			"backoff and jitter",
+			metadata.Pairs(constants.ReconnectBackoffHeader, "2s", constants.ReconnectJitterHeader, "1s"),
+			2 * time.Second, 3 * time.Second,
+		},
+		{"invalid", metadata.Pairs(constants.ReconnectBackoffHeader, "bad"), 0, 0},
+		{"capped", metadata.Pairs(constants.ReconnectBackoffHeader, "1h"), maxReconnectDelay, maxReconnectDelay},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reconnectDelay(tt.md)
+			if got < tt.min || got > tt.max {
+				t.Fatalf("expected delay in [%v, %v], got %v", tt.min, tt.max, got)
+			}
+		})
+	}
+}
+
 type fakeAckCache struct{}
 
 func (f *fakeAckCache) Get(string, string, time.Duration) (string, error) {
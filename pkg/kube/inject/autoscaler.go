@@ -0,0 +1,406 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube"
+	"istio.io/pkg/log"
+)
+
+const (
+	// proxyCPUQuery is the CPU usage of each sidecar, in cores.
+	proxyCPUQuery = `sum by (namespace, pod) (rate(container_cpu_usage_seconds_total{container="istio-proxy"}[5m]))`
+	// proxyMemoryQuery is the peak memory usage of each sidecar over the last hour, in bytes.
+	proxyMemoryQuery = `max by (namespace, pod) (max_over_time(container_memory_working_set_bytes{container="istio-proxy"}[1h]))`
+
+	// tierHeadroom is the ratio between the requests of the tier picked for a workload and the usage of its sidecars.
+	tierHeadroom = 1.2
+)
+
+// ResourceTier is the CPU and memory requests of a sidecar.
+type ResourceTier struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// String formats the tier as "<cpu>/<memory>", as accepted by ParseResourceTier.
+func (t ResourceTier) String() string {
+	return t.CPU.String() + "/" + t.Memory.String()
+}
+
+// ParseResourceTier parses a tier formatted as "<cpu>/<memory>", such as "500m/256Mi".
+func ParseResourceTier(s string) (ResourceTier, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 {
+		return ResourceTier{}, fmt.Errorf("invalid resource tier %q, expected <cpu>/<memory>", s)
+	}
+	cpu, err := resource.ParseQuantity(parts[0])
+	if err != nil {
+		return ResourceTier{}, fmt.Errorf("invalid cpu of resource tier %q: %v", s, err)
+	}
+	memory, err := resource.ParseQuantity(parts[1])
+	if err != nil {
+		return ResourceTier{}, fmt.Errorf("invalid memory of resource tier %q: %v", s, err)
+	}
+	return ResourceTier{CPU: cpu, Memory: memory}, nil
+}
+
+// ParseResourceTiers parses a comma separated list of tiers, ordered from the smallest to the largest CPU.
+func ParseResourceTiers(s string) ([]ResourceTier, error) {
+	tiers := make([]ResourceTier, 0)
+	for _, t := range strings.Split(s, ",") {
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		tier, err := ParseResourceTier(t)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, tier)
+	}
+	if len(tiers) == 0 {
+		return nil, fmt.Errorf("no resource tier")
+	}
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[i].CPU.Cmp(tiers[j].CPU) < 0
+	})
+	return tiers, nil
+}
+
+// ResourceRecommender recommends the resources of the sidecars injected into the pods of a workload.
+type ResourceRecommender interface {
+	// Recommendation returns the tier recommended for the sidecars of the workload, if any.
+	Recommendation(namespace, workload string) (ResourceTier, bool)
+}
+
+// proxyUsage is the resource usage of a sidecar.
+type proxyUsage struct {
+	// cpu is in cores.
+	cpu float64
+	// memory is in bytes.
+	memory float64
+}
+
+// SidecarAutoscalerOptions configures a SidecarAutoscaler.
+type SidecarAutoscalerOptions struct {
+	// PrometheusAddress is the address of the Prometheus server queried for the usage of the sidecars.
+	PrometheusAddress string
+	// Tiers are the resources the sidecars are scaled between.
+	Tiers []ResourceTier
+	// Interval is how often the usage is queried.
+	Interval time.Duration
+	// InPlaceResize enables resizing the running sidecars.
+	InPlaceResize bool
+}
+
+// SidecarAutoscaler keeps the resource requests of the sidecars right-sized. It picks, for each workload, the
+// smallest tier fitting the peak usage of its sidecars, and records it on the pods of the workload with the
+// experimental.istio.io/proxy-resource-tier annotation. The sidecars of the pods created later are injected with the
+// requests of the tier, and, if enabled, the running sidecars are resized in place.
+//
+// Every istiod learns the tiers from the pod annotations, so that any of them injects the recommended requests, while
+// only the leader updates them.
+type SidecarAutoscaler struct {
+	client        kube.Client
+	pods          listerv1.PodLister
+	tiers         []ResourceTier
+	interval      time.Duration
+	inPlaceResize bool
+	// usage returns the usage of the sidecars, keyed by pod.
+	usage func(ctx context.Context) (map[types.NamespacedName]proxyUsage, error)
+
+	mu sync.RWMutex
+	// recommendations are the tiers recorded on the pods, keyed by workload.
+	recommendations map[types.NamespacedName]ResourceTier
+}
+
+var _ ResourceRecommender = &SidecarAutoscaler{}
+
+// NewSidecarAutoscaler creates a SidecarAutoscaler and registers its pod and Deployment handlers. The tiers are only updated once
+// Run is called.
+func NewSidecarAutoscaler(client kube.Client, opts SidecarAutoscalerOptions) (*SidecarAutoscaler, error) {
+	if len(opts.Tiers) == 0 {
+		return nil, fmt.Errorf("no resource tier")
+	}
+	promClient, err := api.NewClient(api.Config{Address: opts.PrometheusAddress})
+	if err != nil {
+		return nil, fmt.Errorf("could not build prometheus client: %v", err)
+	}
+	promAPI := promv1.NewAPI(promClient)
+	informer := client.KubeInformer().Core().V1().Pods()
+	a := &SidecarAutoscaler{
+		client:        client,
+		pods:          informer.Lister(),
+		tiers:         opts.Tiers,
+		interval:      opts.Interval,
+		inPlaceResize: opts.InPlaceResize,
+		usage: func(ctx context.Context) (map[types.NamespacedName]proxyUsage, error) {
+			return queryProxyUsage(ctx, promAPI)
+		},
+		recommendations: map[types.NamespacedName]ResourceTier{},
+	}
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				a.recordTier(pod)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				a.recordTier(pod)
+			}
+		},
+	})
+	client.KubeInformer().Apps().V1().Deployments().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if deploy, ok := obj.(*appsv1.Deployment); ok {
+				a.forgetTier(deploy)
+			}
+		},
+	})
+	return a, nil
+}
+
+// Recommendation implements ResourceRecommender.
+func (a *SidecarAutoscaler) Recommendation(namespace, workload string) (ResourceTier, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	tier, f := a.recommendations[types.NamespacedName{Namespace: namespace, Name: workload}]
+	return tier, f
+}
+
+// recordTier records the tier annotated on the pod as the recommendation of its workload. The recommendations are
+// kept when the pods are deleted, since the pods replacing them are injected after the deletion, and only dropped with
+// their Deployment.
+func (a *SidecarAutoscaler) recordTier(pod *corev1.Pod) {
+	value, f := pod.Annotations[constants.ProxyResourceTierAnnotation]
+	if !f {
+		return
+	}
+	tier, err := ParseResourceTier(value)
+	if err != nil {
+		log.Debugf("ignoring resource tier of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	a.mu.Lock()
+	a.recommendations[workloadKey(pod)] = tier
+	a.mu.Unlock()
+}
+
+// forgetTier drops the recommendation of the deleted Deployment, so that a Deployment later created with the same
+// name starts from the default resources.
+func (a *SidecarAutoscaler) forgetTier(deploy *appsv1.Deployment) {
+	a.mu.Lock()
+	delete(a.recommendations, types.NamespacedName{Namespace: deploy.Namespace, Name: deploy.Name})
+	a.mu.Unlock()
+}
+
+// Run updates the tiers periodically until stop is closed. It should only run on the leader.
+func (a *SidecarAutoscaler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), a.interval)
+			a.reconcile(ctx)
+			cancel()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reconcile picks the tier of each workload from the usage of its sidecars, and updates the pods recording another
+// tier. The pods whose sidecar resources are set by the user are left alone.
+func (a *SidecarAutoscaler) reconcile(ctx context.Context) {
+	usage, err := a.usage(ctx)
+	if err != nil {
+		log.Warnf("failed to query the usage of the sidecars: %v", err)
+		return
+	}
+	pods, err := a.pods.List(klabels.Everything())
+	if err != nil {
+		log.Warnf("failed to list pods: %v", err)
+		return
+	}
+	workloads := map[types.NamespacedName]proxyUsage{}
+	podsByWorkload := map[types.NamespacedName][]*corev1.Pod{}
+	for _, pod := range pods {
+		if FindSidecar(pod.Spec.Containers) == nil || userSetProxyResources(pod.Annotations) {
+			continue
+		}
+		key := workloadKey(pod)
+		podsByWorkload[key] = append(podsByWorkload[key], pod)
+		u, f := usage[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}]
+		if !f {
+			continue
+		}
+		peak := workloads[key]
+		if u.cpu > peak.cpu {
+			peak.cpu = u.cpu
+		}
+		if u.memory > peak.memory {
+			peak.memory = u.memory
+		}
+		workloads[key] = peak
+	}
+	for key, peak := range workloads {
+		tier := a.tierFor(peak)
+		for _, pod := range podsByWorkload[key] {
+			if pod.Annotations[constants.ProxyResourceTierAnnotation] == tier.String() {
+				continue
+			}
+			if err := a.updateTier(ctx, pod, tier); err != nil {
+				log.Warnf("failed to update the resource tier of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			}
+		}
+	}
+}
+
+// tierFor returns the smallest tier fitting the usage with some headroom, or the largest tier if none fits.
+func (a *SidecarAutoscaler) tierFor(u proxyUsage) ResourceTier {
+	for _, tier := range a.tiers {
+		if tier.CPU.AsApproximateFloat64() >= u.cpu*tierHeadroom && tier.Memory.AsApproximateFloat64() >= u.memory*tierHeadroom {
+			return tier
+		}
+	}
+	return a.tiers[len(a.tiers)-1]
+}
+
+// updateTier records the tier on the pod, and resizes its sidecar if enabled. If the resize is rejected, for example
+// because the cluster does not support it, the tier applies once the pod is recreated.
+func (a *SidecarAutoscaler) updateTier(ctx context.Context, pod *corev1.Pod, tier ResourceTier) error {
+	pods := a.client.CoreV1().Pods(pod.Namespace)
+	metadata := map[string]interface{}{
+		"annotations": map[string]string{constants.ProxyResourceTierAnnotation: tier.String()},
+	}
+	if a.inPlaceResize {
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": metadata,
+			"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{
+					"name": ProxyContainerName,
+					"resources": map[string]interface{}{
+						"requests": corev1.ResourceList{corev1.ResourceCPU: tier.CPU, corev1.ResourceMemory: tier.Memory},
+					},
+				}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = pods.Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+		if err == nil {
+			log.Infof("resized sidecar of pod %s/%s to %v", pod.Namespace, pod.Name, tier)
+			return nil
+		}
+		log.Debugf("failed to resize sidecar of pod %s/%s in place: %v", pod.Namespace, pod.Name, err)
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return err
+	}
+	if _, err := pods.Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return err
+	}
+	log.Infof("recorded resource tier %v on pod %s/%s", tier, pod.Namespace, pod.Name)
+	return nil
+}
+
+// userSetProxyResources returns whether the annotations set the sidecar requests, other than from a resource tier.
+func userSetProxyResources(annotations map[string]string) bool {
+	if _, f := annotations[constants.ProxyResourceTierAnnotation]; f {
+		return false
+	}
+	_, cpu := annotations[annotation.SidecarProxyCPU.Name]
+	_, memory := annotations[annotation.SidecarProxyMemory.Name]
+	return cpu || memory
+}
+
+// applyResourceTier sets the sidecar requests of the pod to the tier, unless the user set them.
+func applyResourceTier(pod *corev1.Pod, tier *ResourceTier) {
+	if tier == nil || userSetProxyResources(pod.Annotations) {
+		return
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[annotation.SidecarProxyCPU.Name] = tier.CPU.String()
+	pod.Annotations[annotation.SidecarProxyMemory.Name] = tier.Memory.String()
+	pod.Annotations[constants.ProxyResourceTierAnnotation] = tier.String()
+}
+
+// workloadKey returns the namespace and name of the workload of the pod.
+func workloadKey(pod *corev1.Pod) types.NamespacedName {
+	deploy, _ := kube.GetDeployMetaFromPod(pod)
+	return types.NamespacedName{Namespace: pod.Namespace, Name: deploy.Name}
+}
+
+// queryProxyUsage queries the CPU and memory usage of the sidecars.
+func queryProxyUsage(ctx context.Context, promAPI promv1.API) (map[types.NamespacedName]proxyUsage, error) {
+	usage := map[types.NamespacedName]proxyUsage{}
+	for _, q := range []struct {
+		query string
+		set   func(u *proxyUsage, v float64)
+	}{
+		{proxyCPUQuery, func(u *proxyUsage, v float64) { u.cpu = v }},
+		{proxyMemoryQuery, func(u *proxyUsage, v float64) { u.memory = v }},
+	} {
+		val, _, err := promAPI.Query(ctx, q.query, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		vector, ok := val.(prommodel.Vector)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result type %v", val.Type())
+		}
+		for _, sample := range vector {
+			key := types.NamespacedName{
+				Namespace: string(sample.Metric["namespace"]),
+				Name:      string(sample.Metric["pod"]),
+			}
+			u := usage[key]
+			q.set(&u, float64(sample.Value))
+			usage[key] = u
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,161 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestParseResourceTiers(t *testing.T) {
+	tiers, err := ParseResourceTiers("500m/256Mi, 100m/128Mi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, tier := range tiers {
+		got = append(got, tier.String())
+	}
+	if fmt.Sprint(got) != "[100m/128Mi 500m/256Mi]" {
+		t.Fatalf("unexpected tiers %v", got)
+	}
+	for _, invalid := range []string{"", "100m", "100m/abc", "abc/128Mi"} {
+		if _, err := ParseResourceTiers(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func autoscaledPod(name string, annotations map[string]string) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:         name,
+			Namespace:    "default",
+			GenerateName: "app-7d4b9c-",
+			Labels:       map[string]string{"pod-template-hash": "7d4b9c"},
+			Annotations:  annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       "app-7d4b9c",
+				Controller: &controller,
+			}},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app"}, {Name: ProxyContainerName}},
+		},
+	}
+}
+
+func TestSidecarAutoscaler(t *testing.T) {
+	client := kube.NewFakeClient(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}},
+		autoscaledPod("app-7d4b9c-a", nil),
+		autoscaledPod("app-7d4b9c-b", nil),
+		autoscaledPod("app-7d4b9c-c", map[string]string{annotation.SidecarProxyCPU.Name: "2"}),
+	)
+	tiers, _ := ParseResourceTiers("100m/128Mi,500m/256Mi,1000m/512Mi")
+	a, err := NewSidecarAutoscaler(client, SidecarAutoscalerOptions{
+		PrometheusAddress: "http://prometheus:9090",
+		Tiers:             tiers,
+		Interval:          time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.usage = func(ctx context.Context) (map[types.NamespacedName]proxyUsage, error) {
+		return map[types.NamespacedName]proxyUsage{
+			{Namespace: "default", Name: "app-7d4b9c-a"}: {cpu: 0.05, memory: 100 << 20},
+			{Namespace: "default", Name: "app-7d4b9c-b"}: {cpu: 0.3, memory: 50 << 20},
+			{Namespace: "default", Name: "app-7d4b9c-c"}: {cpu: 1.5, memory: 50 << 20},
+		}, nil
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	client.RunAndWait(stop)
+
+	if _, f := a.Recommendation("default", "app"); f {
+		t.Fatalf("unexpected recommendation before reconciling")
+	}
+	a.reconcile(context.Background())
+
+	// The peak CPU of the pods without user set resources fits the second tier.
+	for name, expected := range map[string]string{
+		"app-7d4b9c-a": "500m/256Mi",
+		"app-7d4b9c-b": "500m/256Mi",
+		"app-7d4b9c-c": "",
+	} {
+		pod, err := client.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pod.Annotations[constants.ProxyResourceTierAnnotation]; got != expected {
+			t.Errorf("pod %s: expected tier %q, got %q", name, expected, got)
+		}
+	}
+	retry.UntilSuccessOrFail(t, func() error {
+		tier, f := a.Recommendation("default", "app")
+		if !f || tier.String() != "500m/256Mi" {
+			return fmt.Errorf("unexpected recommendation %v", tier)
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+
+	// The recommendation is dropped with the Deployment.
+	if err := client.AppsV1().Deployments("default").Delete(context.Background(), "app", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	retry.UntilSuccessOrFail(t, func() error {
+		if tier, f := a.Recommendation("default", "app"); f {
+			return fmt.Errorf("unexpected recommendation %v after deleting the deployment", tier)
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+}
+
+func TestApplyResourceTier(t *testing.T) {
+	tier, _ := ParseResourceTier("500m/256Mi")
+
+	pod := &corev1.Pod{}
+	applyResourceTier(pod, &tier)
+	expected := map[string]string{
+		annotation.SidecarProxyCPU.Name:       "500m",
+		annotation.SidecarProxyMemory.Name:    "256Mi",
+		constants.ProxyResourceTierAnnotation: "500m/256Mi",
+	}
+	if fmt.Sprint(pod.Annotations) != fmt.Sprint(expected) {
+		t.Fatalf("expected annotations %v, got %v", expected, pod.Annotations)
+	}
+
+	// The requests set by the user are kept.
+	pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{annotation.SidecarProxyMemory.Name: "1Gi"}}}
+	applyResourceTier(pod, &tier)
+	if len(pod.Annotations) != 1 {
+		t.Fatalf("unexpected annotations %v", pod.Annotations)
+	}
+}
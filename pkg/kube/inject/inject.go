@@ -20,7 +20,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math"
 	"reflect"
 	"sort"
 	"strconv"
@@ -916,13 +915,14 @@ func estimateConcurrency(cfg *meshconfig.ProxyConfig, annotations map[string]str
 	return 2
 }
 
-// Convert k8s quantity to its milli value (e.g. ceil(quantity * 1000)) and then to concurrency.
-// With the resource setting, we round up to single integer number; for example, if we have a 500m limit
-// the pod will get concurrency=1. With 6500m, it will get concurrency=7.
+// Convert k8s quantity to its milli value (e.g. ceil(quantity * 1000)), scaled by the concurrency headroom,
+// and then to concurrency. With the resource setting, we round up to single integer number; for example, if we
+// have a 500m limit the pod will get concurrency=1. With 6500m, it will get concurrency=7. Envoy treats 0 as
+// all the cores of the node, so the concurrency is at least 1.
 func quantityToConcurrency(quantity string) (int, error) {
 	q, err := resource.ParseQuantity(quantity)
 	if err != nil {
 		return 0, err
 	}
-	return int(math.Ceil(float64(q.MilliValue()) / 1000)), nil
+	return mesh.MilliCPUToConcurrency(q.MilliValue(), mesh.ProxyConcurrencyHeadroom), nil
 }
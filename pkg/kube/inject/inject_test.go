@@ -251,6 +251,10 @@ func TestInjection(t *testing.T) {
 			in:            "traffic-annotations-bad-excludeoutboundports.yaml",
 			expectedError: "excludeoutboundports",
 		},
+		{
+			in:            "traffic-annotations-bad-outboundudpports.yaml",
+			expectedError: "outboundudpports",
+		},
 		{
 			in:   "hello.yaml",
 			want: "hello-no-seccontext.yaml.injected",
@@ -931,6 +935,14 @@ func TestQuantityConversion(t *testing.T) {
 			in:  "6500m",
 			out: 7,
 		},
+		{
+			in:  "0.5",
+			out: 1,
+		},
+		{
+			in:  "0",
+			out: 1,
+		},
 		{
 			in:  "200mi",
 			err: errors.New("unable to parse"),
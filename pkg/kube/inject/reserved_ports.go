@@ -0,0 +1,90 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/config/constants"
+)
+
+const (
+	// ReservedPortStart and ReservedPortEnd bound the ports reserved for the sidecar, such as 15001 and 15006, where
+	// the outbound and inbound traffic is redirected to, and 15090, where its stats are exposed.
+	ReservedPortStart = 15000
+	ReservedPortEnd   = 15090
+)
+
+// ReservedPortConflict is a port of an application container in the range reserved for the sidecar.
+type ReservedPortConflict struct {
+	Container string
+	Port      int32
+}
+
+func (c ReservedPortConflict) String() string {
+	return fmt.Sprintf("%s:%d", c.Container, c.Port)
+}
+
+// ReservedPortConflicts returns the ports of the application containers of the pod in the range reserved for the
+// sidecar. The sidecar listens on some of these ports, so the applications binding them fail to start, or their
+// traffic is intercepted by the sidecar.
+func ReservedPortConflicts(spec *corev1.PodSpec) []ReservedPortConflict {
+	var conflicts []ReservedPortConflict
+	for _, c := range spec.Containers {
+		if c.Name == ProxyContainerName {
+			continue
+		}
+		for _, p := range c.Ports {
+			if p.ContainerPort >= ReservedPortStart && p.ContainerPort <= ReservedPortEnd {
+				conflicts = append(conflicts, ReservedPortConflict{Container: c.Name, Port: p.ContainerPort})
+			}
+		}
+	}
+	return conflicts
+}
+
+// checkReservedPorts returns a warning if the application containers of the pod declare ports reserved for the
+// sidecar, or an error if the pod rejects such ports with the ReservedPortPolicyAnnotation.
+func checkReservedPorts(pod *corev1.Pod) ([]string, error) {
+	conflicts := ReservedPortConflicts(&pod.Spec)
+	if len(conflicts) == 0 {
+		return nil, nil
+	}
+	ports := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		ports = append(ports, c.String())
+	}
+	msg := fmt.Sprintf("container ports %s are in the range %d-%d reserved for the Istio sidecar, "+
+		"their traffic will not reach the application", strings.Join(ports, ", "), ReservedPortStart, ReservedPortEnd)
+	if pod.Annotations[constants.ReservedPortPolicyAnnotation] == constants.ReservedPortPolicyReject {
+		return nil, errors.New(msg)
+	}
+	return []string{msg}, nil
+}
+
+// validateReservedPortPolicy validates the reserved port policy annotation
+func validateReservedPortPolicy(policy string) error {
+	switch policy {
+	case constants.ReservedPortPolicyWarn, constants.ReservedPortPolicyReject:
+		return nil
+	default:
+		return fmt.Errorf("invalid reserved port policy %q, want %s or %s", policy,
+			constants.ReservedPortPolicyWarn, constants.ReservedPortPolicyReject)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/config/constants"
+)
+
+func reservedPortsPod(annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "app", Ports: []corev1.ContainerPort{{ContainerPort: 8080}, {ContainerPort: 15006}}},
+				{Name: "metrics", Ports: []corev1.ContainerPort{{ContainerPort: 15090}, {ContainerPort: 15091}}},
+				{Name: ProxyContainerName, Ports: []corev1.ContainerPort{{ContainerPort: 15090}}},
+			},
+		},
+	}
+}
+
+func TestReservedPortConflicts(t *testing.T) {
+	got := ReservedPortConflicts(&reservedPortsPod(nil).Spec)
+	want := []ReservedPortConflict{{Container: "app", Port: 15006}, {Container: "metrics", Port: 15090}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := ReservedPortConflicts(&corev1.PodSpec{}); got != nil {
+		t.Fatalf("expected no conflicts, got %v", got)
+	}
+}
+
+func TestCheckReservedPorts(t *testing.T) {
+	warnings, err := checkReservedPorts(reservedPortsPod(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "app:15006, metrics:15090") {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+
+	_, err = checkReservedPorts(reservedPortsPod(map[string]string{
+		constants.ReservedPortPolicyAnnotation: constants.ReservedPortPolicyReject,
+	}))
+	if err == nil || !strings.Contains(err.Error(), "reserved for the Istio sidecar") {
+		t.Fatalf("expected the pod to be rejected, got %v", err)
+	}
+
+	warnings, err = checkReservedPorts(&corev1.Pod{})
+	if warnings != nil || err != nil {
+		t.Fatalf("expected no warning, got %v, %v", warnings, err)
+	}
+}
+
+func TestValidateReservedPortPolicy(t *testing.T) {
+	for policy, valid := range map[string]bool{"warn": true, "reject": true, "remap": false, "": false} {
+		if err := validateReservedPortPolicy(policy); (err == nil) != valid {
+			t.Errorf("policy %q: got error %v, want valid %v", policy, err, valid)
+		}
+	}
+}
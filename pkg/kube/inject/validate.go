@@ -25,6 +25,7 @@ import (
 	"istio.io/api/annotation"
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/validation"
 	"istio.io/istio/pkg/util/gogoprotomarshal"
@@ -48,6 +49,12 @@ var (
 		annotation.SidecarTrafficExcludeOutboundPorts.Name:        ValidateExcludeOutboundPorts,
 		annotation.PrometheusMergeMetrics.Name:                    validateBool,
 		annotation.ProxyConfig.Name:                               validateProxyConfig,
+		constants.InboundPortRangesAnnotation:                     validateInboundPortRanges,
+		constants.OutboundUDPPortsAnnotation:                      validateOutboundUDPPorts,
+		constants.DrainOnPreStopAnnotation:                        validateBool,
+		constants.ForwardClientCertDetailsAnnotation:              validateForwardClientCertDetails,
+		constants.SetCurrentClientCertDetailsAnnotation:           validateSetCurrentClientCertDetails,
+		constants.ReservedPortPolicyAnnotation:                    validateReservedPortPolicy,
 	}
 )
 
@@ -77,6 +84,49 @@ func validatePortList(parameterName, ports string) error {
 	return nil
 }
 
+// validatePortRangeList validates a list of ports and inclusive port ranges, such as "8080,10000-20000".
+func validatePortRangeList(parameterName, ports string) error {
+	if _, err := model.ParsePortRanges(ports); err != nil {
+		return fmt.Errorf("%s invalid: %v", parameterName, err)
+	}
+	return nil
+}
+
+// validateInboundPortRanges validates the inbound port ranges annotation
+func validateInboundPortRanges(ports string) error {
+	return validatePortRangeList("inboundPortRanges", ports)
+}
+
+// validateOutboundUDPPorts validates the outbound UDP ports annotation, rejecting ports redirected to the same port
+// of the sidecar, such as 53 and 16053, as their traffic could not be told apart.
+func validateOutboundUDPPorts(ports string) error {
+	parsed, err := parsePorts(ports)
+	if err != nil {
+		return fmt.Errorf("outboundUDPPorts invalid: %v", err)
+	}
+	redirected := make(map[int]int, len(parsed))
+	for _, port := range parsed {
+		listenerPort := model.OutboundUDPListenerPort(port)
+		if other, f := redirected[listenerPort]; f && other != port {
+			return fmt.Errorf("outboundUDPPorts invalid: ports %d and %d are both redirected to port %d", other, port, listenerPort)
+		}
+		redirected[listenerPort] = port
+	}
+	return nil
+}
+
+// validateForwardClientCertDetails validates the forward client cert details annotation
+func validateForwardClientCertDetails(mode string) error {
+	_, err := model.ParseForwardClientCertDetails(mode)
+	return err
+}
+
+// validateSetCurrentClientCertDetails validates the set current client cert details annotation
+func validateSetCurrentClientCertDetails(details string) error {
+	_, err := model.ParseClientCertDetails(details)
+	return err
+}
+
 // validateInterceptionMode validates the interceptionMode annotation
 func validateInterceptionMode(mode string) error {
 	switch mode {
@@ -110,14 +160,14 @@ func ValidateExcludeIPRanges(ipRanges string) error {
 // ValidateIncludeInboundPorts validates the includeInboundPorts parameter
 func ValidateIncludeInboundPorts(ports string) error {
 	if ports != "*" {
-		return validatePortList("includeInboundPorts", ports)
+		return validatePortRangeList("includeInboundPorts", ports)
 	}
 	return nil
 }
 
 // ValidateExcludeInboundPorts validates the excludeInboundPorts parameter
 func ValidateExcludeInboundPorts(ports string) error {
-	return validatePortList("excludeInboundPorts", ports)
+	return validatePortRangeList("excludeInboundPorts", ports)
 }
 
 // ValidateExcludeOutboundPorts validates the excludeOutboundPorts parameter
@@ -88,8 +88,9 @@ type Webhook struct {
 
 	watcher Watcher
 
-	env      *model.Environment
-	revision string
+	env       *model.Environment
+	revision  string
+	resources ResourceRecommender
 }
 
 // nolint directives: interfacer
@@ -142,6 +143,9 @@ type WebhookParameters struct {
 
 	// The istio.io/rev this injector is responsible for
 	Revision string
+
+	// ResourceRecommender, if set, recommends the sidecar requests of the pods that do not set them.
+	ResourceRecommender ResourceRecommender
 }
 
 // NewWebhook creates a new instance of a mutating webhook for automatic sidecar injection.
@@ -155,6 +159,7 @@ func NewWebhook(p WebhookParameters) (*Webhook, error) {
 		meshConfig: p.Env.Mesh(),
 		env:        p.Env,
 		revision:   p.Revision,
+		resources:  p.ResourceRecommender,
 	}
 
 	p.Watcher.SetHandler(wh.updateConfig)
@@ -258,6 +263,10 @@ type InjectionParameters struct {
 	revision            string
 	proxyEnvs           map[string]string
 	injectedAnnotations map[string]string
+	// resourceTier, if set, is the sidecar requests recommended for the workload.
+	resourceTier *ResourceTier
+	// upstreamEnvs are the env vars added to the application containers of the pods in whitebox mode.
+	upstreamEnvs []corev1.EnvVar
 }
 
 func checkPreconditions(params InjectionParameters) {
@@ -326,6 +335,8 @@ func injectPod(req InjectionParameters) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The tier is applied to the annotations of the pod, so that the patch records it.
+	applyResourceTier(req.pod, req.resourceTier)
 
 	// Run the injection template, giving us a partial pod spec
 	mergedPod, injectedPodData, err := RunTemplate(req)
@@ -504,6 +515,8 @@ func postProcessPod(pod *corev1.Pod, injectedPod corev1.Pod, req InjectionParame
 
 	applyMetadata(pod, injectedPod, req)
 
+	applyWhiteboxUpstreamEnvs(pod, req.upstreamEnvs)
+
 	if err := reorderPod(pod, req); err != nil {
 		return err
 	}
@@ -803,8 +816,25 @@ func (wh *Webhook) inject(ar *kube.AdmissionReview, path string) *kube.Admission
 		injectedAnnotations: wh.Config.InjectedAnnotations,
 		proxyEnvs:           parseInjectEnvs(path),
 	}
+	if wh.resources != nil {
+		if tier, f := wh.resources.Recommendation(pod.Namespace, deploy.Name); f {
+			params.resourceTier = &tier
+		}
+	}
+	if wh.env.PushContext != nil && whiteboxMode(&pod, &proxyConfig) {
+		params.upstreamEnvs = whiteboxUpstreamEnvs(wh.env.PushContext.SidecarForWorkload(pod.Namespace, pod.Labels))
+	}
 	wh.mu.RUnlock()
 
+	warnings, err := checkReservedPorts(&pod)
+	if err != nil {
+		handleError(fmt.Sprintf("Pod injection rejected: %v", err))
+		return toAdmissionResponse(err)
+	}
+	for _, w := range warnings {
+		log.Warnf("Pod %s/%s: %s", pod.Namespace, podName, w)
+	}
+
 	patchBytes, err := injectPod(params)
 	if err != nil {
 		handleError(fmt.Sprintf("Pod injection failed: %v", err))
@@ -818,6 +848,7 @@ func (wh *Webhook) inject(ar *kube.AdmissionReview, path string) *kube.Admission
 			pt := "JSONPatch"
 			return &pt
 		}(),
+		Warnings: warnings,
 	}
 	totalSuccessfulInjections.Increment()
 	return &reviewResponse
@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/api/annotation"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/pkg/log"
+)
+
+// WhiteboxUpstreamEnvPrefix prefixes the env vars injected into the application containers of the pods in whitebox
+// mode, holding the local addresses of the sidecar their upstream services are reachable at.
+const WhiteboxUpstreamEnvPrefix = "ISTIO_UPSTREAM_"
+
+// whiteboxMode returns whether the traffic of the pod is not intercepted, i.e. its interception mode is NONE, so its
+// applications have to talk to the sidecar on the local ports of the egress listeners of its Sidecar config.
+func whiteboxMode(pod *corev1.Pod, proxyConfig *meshconfig.ProxyConfig) bool {
+	if mode, f := pod.Annotations[annotation.SidecarInterceptionMode.Name]; f {
+		return mode == meshconfig.ProxyConfig_NONE.String()
+	}
+	return proxyConfig.GetInterceptionMode() == meshconfig.ProxyConfig_NONE
+}
+
+// whiteboxUpstreamEnvs returns the env vars configuring the applications of a pod in whitebox mode to talk to their
+// upstream services through the sidecar: one for each egress listener of the Sidecar config with an explicit port,
+// holding its local address, such as ISTIO_UPSTREAM_REVIEWS=localhost:9080. The sidecar binds the egress listeners
+// without a bind address to the loopback address of its IP family, so their address is localhost. Upstreams whose
+// name is shared by several egress listeners are ambiguous, so they get no env var.
+func whiteboxUpstreamEnvs(sidecar *networking.Sidecar) []corev1.EnvVar {
+	names := map[*networking.IstioEgressListener]string{}
+	listeners := map[string]int{}
+	for _, egress := range sidecar.GetEgress() {
+		if egress.GetPort() == nil {
+			continue
+		}
+		if name := whiteboxUpstreamName(egress); name != "" {
+			names[egress] = name
+			listeners[name]++
+		}
+	}
+	for name, n := range listeners {
+		if n > 1 {
+			log.Warnf("not setting %s%s: %d egress listeners of the Sidecar config have this upstream name",
+				WhiteboxUpstreamEnvPrefix, name, n)
+		}
+	}
+	var envs []corev1.EnvVar
+	for _, egress := range sidecar.GetEgress() {
+		name, f := names[egress]
+		if !f || listeners[name] > 1 {
+			continue
+		}
+		address := egress.Bind
+		if address == "" {
+			address = "localhost"
+		}
+		if !strings.HasPrefix(address, "unix://") {
+			address = net.JoinHostPort(address, strconv.Itoa(int(egress.Port.Number)))
+		}
+		envs = append(envs, corev1.EnvVar{Name: WhiteboxUpstreamEnvPrefix + name, Value: address})
+	}
+	return envs
+}
+
+// whiteboxUpstreamName returns the name of the upstream of an egress listener as an env var name, from its host if
+// it has a single one, such as REVIEWS for default/reviews.default.svc.cluster.local, or from the name of its port
+// otherwise.
+func whiteboxUpstreamName(egress *networking.IstioEgressListener) string {
+	name := ""
+	if len(egress.Hosts) == 1 {
+		hostname := egress.Hosts[0]
+		if i := strings.Index(hostname, "/"); i >= 0 {
+			hostname = hostname[i+1:]
+		}
+		if name = strings.SplitN(hostname, ".", 2)[0]; name == "*" {
+			name = ""
+		}
+	}
+	if name == "" {
+		name = egress.Port.Name
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// applyWhiteboxUpstreamEnvs adds the upstream env vars to the application containers, keeping the env vars they
+// already set.
+func applyWhiteboxUpstreamEnvs(pod *corev1.Pod, envs []corev1.EnvVar) {
+	for i, c := range pod.Spec.Containers {
+		if c.Name == ProxyContainerName {
+			continue
+		}
+		existing := map[string]bool{}
+		for _, e := range c.Env {
+			existing[e.Name] = true
+		}
+		for _, e := range envs {
+			if !existing[e.Name] {
+				pod.Spec.Containers[i].Env = append(pod.Spec.Containers[i].Env, e)
+			}
+		}
+	}
+}
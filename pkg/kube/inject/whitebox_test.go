@@ -0,0 +1,120 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/annotation"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func TestWhiteboxMode(t *testing.T) {
+	none := &meshconfig.ProxyConfig{InterceptionMode: meshconfig.ProxyConfig_NONE}
+	redirect := &meshconfig.ProxyConfig{InterceptionMode: meshconfig.ProxyConfig_REDIRECT}
+	annotated := func(mode string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{annotation.SidecarInterceptionMode.Name: mode}}}
+	}
+	if !whiteboxMode(&corev1.Pod{}, none) || whiteboxMode(&corev1.Pod{}, redirect) {
+		t.Error("expected the interception mode of the proxy config")
+	}
+	if !whiteboxMode(annotated("NONE"), redirect) || whiteboxMode(annotated("TPROXY"), none) {
+		t.Error("expected the interception mode of the annotation to take precedence")
+	}
+}
+
+func TestWhiteboxUpstreamEnvs(t *testing.T) {
+	sidecar := &networking.Sidecar{
+		Egress: []*networking.IstioEgressListener{
+			{
+				Port:  &networking.Port{Number: 9080, Protocol: "HTTP", Name: "http"},
+				Hosts: []string{"default/reviews.default.svc.cluster.local"},
+			},
+			{
+				Port:  &networking.Port{Number: 3306, Protocol: "TCP", Name: "mysql-db"},
+				Bind:  "127.0.0.2",
+				Hosts: []string{"db/mysql.db.svc.cluster.local"},
+			},
+			{
+				Port:  &networking.Port{Number: 5432, Protocol: "TCP", Name: "postgres-db"},
+				Bind:  "::1",
+				Hosts: []string{"db/pg.db.svc.cluster.local", "db/pg-replica.db.svc.cluster.local"},
+			},
+			{
+				Port:  &networking.Port{Number: 9091, Protocol: "HTTP"},
+				Hosts: []string{"default/ratings.default.svc.cluster.local"},
+			},
+			{
+				Port:  &networking.Port{Number: 9092, Protocol: "HTTP"},
+				Hosts: []string{"other/ratings.other.svc.cluster.local"},
+			},
+			{
+				Port:  &networking.Port{Number: 8080, Protocol: "HTTP"},
+				Hosts: []string{"*/*"},
+			},
+			{
+				Port:  &networking.Port{Number: 7070, Protocol: "GRPC", Name: "grpc"},
+				Bind:  "unix:///var/run/upstream.sock",
+				Hosts: []string{"default/echo.default.svc.cluster.local"},
+			},
+			{
+				Hosts: []string{"istio-system/*"},
+			},
+		},
+	}
+	// the ratings listeners are ambiguous, and the wildcard listener has no name.
+	want := []corev1.EnvVar{
+		{Name: "ISTIO_UPSTREAM_REVIEWS", Value: "localhost:9080"},
+		{Name: "ISTIO_UPSTREAM_MYSQL", Value: "127.0.0.2:3306"},
+		{Name: "ISTIO_UPSTREAM_POSTGRES_DB", Value: "[::1]:5432"},
+		{Name: "ISTIO_UPSTREAM_ECHO", Value: "unix:///var/run/upstream.sock"},
+	}
+	if got := whiteboxUpstreamEnvs(sidecar); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := whiteboxUpstreamEnvs(nil); got != nil {
+		t.Fatalf("expected no env vars without sidecar, got %v", got)
+	}
+}
+
+func TestApplyWhiteboxUpstreamEnvs(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "app", Env: []corev1.EnvVar{{Name: "ISTIO_UPSTREAM_REVIEWS", Value: "reviews:9080"}}},
+				{Name: ProxyContainerName},
+			},
+		},
+	}
+	applyWhiteboxUpstreamEnvs(pod, []corev1.EnvVar{
+		{Name: "ISTIO_UPSTREAM_REVIEWS", Value: "127.0.0.1:9080"},
+		{Name: "ISTIO_UPSTREAM_RATINGS", Value: "127.0.0.1:9081"},
+	})
+	want := []corev1.EnvVar{
+		{Name: "ISTIO_UPSTREAM_REVIEWS", Value: "reviews:9080"},
+		{Name: "ISTIO_UPSTREAM_RATINGS", Value: "127.0.0.1:9081"},
+	}
+	if got := pod.Spec.Containers[0].Env; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if env := pod.Spec.Containers[1].Env; len(env) != 0 {
+		t.Fatalf("expected no env vars in the proxy, got %v", env)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revisions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/api/label"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube"
+)
+
+// VersionWatcher keeps track of the versions of the revisions in use, which istiod registers on the injection
+// MutatingWebhookConfigurations of its revision.
+type VersionWatcher interface {
+	HasSynced() bool
+	// GetVersions returns the versions of the revisions, by revision. The version of the revisions whose istiod did
+	// not register it, such as the releases predating the registration, is empty.
+	GetVersions() map[string]string
+}
+
+type versionWatcher struct {
+	webhookInformer cache.SharedIndexInformer
+}
+
+// NewVersionWatcher returns a VersionWatcher. It relies on the informer factory of the client being started.
+func NewVersionWatcher(client kube.Client) VersionWatcher {
+	return &versionWatcher{
+		webhookInformer: client.KubeInformer().Admissionregistration().V1().MutatingWebhookConfigurations().Informer(),
+	}
+}
+
+func (w *versionWatcher) HasSynced() bool {
+	return w.webhookInformer.HasSynced()
+}
+
+func (w *versionWatcher) GetVersions() map[string]string {
+	out := map[string]string{}
+	for _, obj := range w.webhookInformer.GetStore().List() {
+		wh, ok := obj.(metav1.Object)
+		if !ok {
+			continue
+		}
+		revision, f := wh.GetLabels()[label.IoIstioRev.Name]
+		if !f {
+			continue
+		}
+		// A revision may have several webhooks, e.g. for its tags; only the unregistered ones are left empty.
+		v := wh.GetAnnotations()[constants.RevisionVersionAnnotation]
+		if _, f := out[revision]; !f || v != "" {
+			out[revision] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revisions
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/label"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestVersionWatcher(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	client := kube.NewFakeClient()
+	w := NewVersionWatcher(client)
+	client.RunAndWait(stop)
+
+	for _, wh := range []struct {
+		name     string
+		revision string
+		version  string
+	}{
+		{"istio-sidecar-injector", "default", "1.14.0"},
+		{"istio-sidecar-injector-1-13", "1-13", "1.13.3"},
+		// Not registered: the istiod of the revision does not set its version.
+		{"istio-sidecar-injector-1-12", "1-12", ""},
+		// Tag of a registered revision, not registered yet.
+		{"istio-revision-tag-prod", "1-13", ""},
+		// Not a revision.
+		{"other-injector", "", "1.0.0"},
+	} {
+		cfg := &v1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: wh.name}}
+		if wh.revision != "" {
+			cfg.Labels = map[string]string{label.IoIstioRev.Name: wh.revision}
+		}
+		if wh.version != "" {
+			cfg.Annotations = map[string]string{constants.RevisionVersionAnnotation: wh.version}
+		}
+		if _, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().
+			Create(context.Background(), cfg, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{"default": "1.14.0", "1-13": "1.13.3", "1-12": ""}
+	retry.UntilSuccessOrFail(t, func() error {
+		if got := w.GetVersions(); !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("got versions %v, want %v", got, want)
+		}
+		return nil
+	}, retry.Timeout(time.Second*10), retry.BackoffDelay(time.Millisecond*10))
+}
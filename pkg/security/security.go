@@ -110,6 +110,10 @@ const (
 
 	// CertSigner info
 	CertSigner = "CertSigner"
+
+	// ImpersonatedIdentity is the CSR metadata key a node-level caller uses to request
+	// a certificate for an identity other than its own.
+	ImpersonatedIdentity = "ImpersonatedIdentity"
 )
 
 // Options provides all of the configuration parameters for secret discovery service
@@ -20,10 +20,14 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
 
 	"istio.io/istio/pkg/test/echo"
+	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/framework/components/cluster"
 	"istio.io/istio/pkg/util/istiomultierror"
 )
@@ -191,6 +195,23 @@ func MTLSForHTTP() Checker {
 	})
 }
 
+// XFCCURI checks that the X-Forwarded-Client-Cert header received has the URI SAN of the last client certificate
+// verified.
+func XFCCURI(expected string) Checker {
+	return Each(func(r echo.Response) error {
+		if len(r.XFCC) == 0 {
+			return fmt.Errorf("expected X-Forwarded-Client-Cert but not found: %v", r)
+		}
+		uris := r.XFCC[len(r.XFCC)-1].URI
+		for _, uri := range uris {
+			if uri == expected {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected X-Forwarded-Client-Cert URI %s, received %v", expected, uris)
+	})
+}
+
 func Port(expected int) Checker {
 	return Each(func(r echo.Response) error {
 		expectedStr := strconv.Itoa(expected)
@@ -201,6 +222,43 @@ func Port(expected int) Checker {
 	})
 }
 
+// LoadErrorRate checks that the rate of failed requests sent in load generation mode is at most max.
+func LoadErrorRate(max float64) Checker {
+	return Each(func(r echo.Response) error {
+		stats := r.LoadStatistics
+		if stats == nil {
+			return fmt.Errorf("expected load statistics but not found: %v", r)
+		}
+		if stats.Requests == 0 {
+			return errors.New("no requests were sent")
+		}
+		if rate := float64(stats.Errors) / float64(stats.Requests); rate > max {
+			return fmt.Errorf("expected error rate of at most %v, received %v (%v)", max, rate, stats.ErrorClasses)
+		}
+		return nil
+	})
+}
+
+// LoadLatency checks that the given latency percentile of the requests sent in load generation mode is at most max.
+func LoadLatency(percentile float64, max time.Duration) Checker {
+	return Each(func(r echo.Response) error {
+		stats := r.LoadStatistics
+		if stats == nil {
+			return fmt.Errorf("expected load statistics but not found: %v", r)
+		}
+		for _, p := range stats.Percentiles {
+			if p.Percentile != percentile {
+				continue
+			}
+			if latency := time.Duration(p.LatencyMicros) * time.Microsecond; latency > max {
+				return fmt.Errorf("expected p%v latency of at most %v, received %v", percentile, max, latency)
+			}
+			return nil
+		}
+		return fmt.Errorf("latency percentile %v not found in %v", percentile, stats.Percentiles)
+	})
+}
+
 func requestHeader(r echo.Response, key, expected string) error {
 	actual := r.RequestHeaders.Get(key)
 	if actual != expected {
@@ -229,6 +287,50 @@ func ResponseHeader(key, expected string) Checker {
 	})
 }
 
+// GRPCStatus checks that the responses to gRPC requests have the expected status code.
+func GRPCStatus(expected codes.Code) Checker {
+	expectedStr := strconv.Itoa(int(expected))
+	return Each(func(r echo.Response) error {
+		if r.GRPCStatus != expectedStr {
+			return fmt.Errorf("expected gRPC status %s, got %q (message %q)", expectedStr, r.GRPCStatus, r.GRPCMessage)
+		}
+		return nil
+	})
+}
+
+// GRPCDetails checks that the non-OK gRPC status of the responses has the expected details.
+func GRPCDetails(expected ...proto.Message) Checker {
+	return Each(func(r echo.Response) error {
+		st, err := common.ParseGRPCDetails(r.GRPCDetails)
+		if err != nil {
+			return fmt.Errorf("invalid gRPC details %q: %v", r.GRPCDetails, err)
+		}
+		if len(st.GetDetails()) != len(expected) {
+			return fmt.Errorf("expected %d gRPC details, got %d", len(expected), len(st.GetDetails()))
+		}
+		for i, d := range st.GetDetails() {
+			actual, err := d.UnmarshalNew()
+			if err != nil {
+				return fmt.Errorf("invalid gRPC detail %s: %v", d.GetTypeUrl(), err)
+			}
+			if !proto.Equal(actual, expected[i]) {
+				return fmt.Errorf("gRPC detail %d: expected %v, got %v", i, expected[i], actual)
+			}
+		}
+		return nil
+	})
+}
+
+// ResponseTrailer checks that the trailer of the response to a gRPC request has the expected value.
+func ResponseTrailer(key, expected string) Checker {
+	return Each(func(r echo.Response) error {
+		if actual := r.ResponseTrailers.Get(key); actual != expected {
+			return fmt.Errorf("response trailer %s: expected `%s`, received `%s`", key, expected, actual)
+		}
+		return nil
+	})
+}
+
 func RequestHeaders(expected map[string]string) Checker {
 	return Each(func(r echo.Response) error {
 		outErr := istiomultierror.New()
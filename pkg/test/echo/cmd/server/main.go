@@ -18,6 +18,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/spf13/cobra"
@@ -49,6 +50,7 @@ var (
 	key              string
 	istioVersion     string
 	disableALPN      bool
+	clientIdentities []string
 
 	loggingOptions = log.DefaultOptions()
 
@@ -113,6 +115,17 @@ var (
 				localhostIPByPort[p] = struct{}{}
 			}
 
+			identities := map[string]common.ClientIdentityFiles{}
+			for _, identity := range clientIdentities {
+				parts := strings.SplitN(identity, "=", 2)
+				if len(parts) != 2 || parts[0] == "" || len(strings.SplitN(parts[1], ":", 2)) != 2 {
+					log.Errorf("invalid client identity %q, must be name=certFile:keyFile", identity)
+					os.Exit(-1)
+				}
+				files := strings.SplitN(parts[1], ":", 2)
+				identities[parts[0]] = common.ClientIdentityFiles{CertFile: files[0], KeyFile: files[1]}
+			}
+
 			s := server.New(server.Config{
 				Ports:                 ports,
 				Metrics:               metricsPort,
@@ -125,6 +138,7 @@ var (
 				IstioVersion:          istioVersion,
 				UDSServer:             uds,
 				DisableALPN:           disableALPN,
+				ClientIdentities:      identities,
 			})
 
 			if err := s.Start(); err != nil {
@@ -167,6 +181,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&key, "key", "", "gRPC TLS server-side key")
 	rootCmd.PersistentFlags().StringVar(&istioVersion, "istio-version", "", "Istio sidecar version")
 	rootCmd.PersistentFlags().BoolVar(&disableALPN, "disable-alpn", disableALPN, "disable ALPN negotiation")
+	rootCmd.PersistentFlags().StringArrayVar(&clientIdentities, "client-identity", nil,
+		"Client identity the forwarded requests may be made with, as name=certFile:keyFile. May be repeated.")
 
 	loggingOptions.AttachCobraFlags(rootCmd)
 
@@ -0,0 +1,76 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"encoding/base64"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Request metadata controlling the response of the gRPC Echo server.
+const (
+	// GRPCStatusHeader holds the comma-separated gRPC status codes to respond with, each optionally followed by a
+	// colon and its relative chance, as the HTTP codes parameter. For example, "14:1,0:1" responds with UNAVAILABLE
+	// half of the time.
+	GRPCStatusHeader = "x-echo-grpc-status"
+	// GRPCMessageHeader holds the message of the non-OK gRPC status responded with.
+	GRPCMessageHeader = "x-echo-grpc-message"
+	// GRPCDetailsHeader holds the base64 encoded google.rpc.Status whose details are added to the non-OK gRPC
+	// status responded with. See GRPCDetails.
+	GRPCDetailsHeader = "x-echo-grpc-details"
+	// GRPCHeadersHeader holds the comma-separated name:value pairs of the headers to respond with.
+	GRPCHeadersHeader = "x-echo-grpc-headers"
+	// GRPCTrailersHeader holds the comma-separated name:value pairs of the trailers to respond with.
+	GRPCTrailersHeader = "x-echo-grpc-trailers"
+)
+
+// GRPCDetails returns the value of the GRPCDetailsHeader for the given details.
+func GRPCDetails(details ...proto.Message) (string, error) {
+	var anys []*anypb.Any
+	for _, d := range details {
+		a, err := anypb.New(d)
+		if err != nil {
+			return "", err
+		}
+		anys = append(anys, a)
+	}
+	return EncodeGRPCDetails(anys)
+}
+
+// EncodeGRPCDetails returns the base64 encoded google.rpc.Status holding the details, as used by the
+// GRPCDetailsHeader and the GRPCDetails field of the responses.
+func EncodeGRPCDetails(details []*anypb.Any) (string, error) {
+	b, err := proto.Marshal(&spb.Status{Details: details})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// ParseGRPCDetails returns the details held by the value of the GRPCDetailsHeader.
+func ParseGRPCDetails(value string) (*spb.Status, error) {
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	st := &spb.Status{}
+	if err := proto.Unmarshal(b, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
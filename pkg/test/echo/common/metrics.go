@@ -15,6 +15,9 @@
 package common
 
 import (
+	"crypto/tls"
+	"net/http"
+
 	"istio.io/pkg/monitoring"
 )
 
@@ -22,11 +25,30 @@ type EchoMetrics struct {
 	HTTPRequests monitoring.Metric
 	GrpcRequests monitoring.Metric
 	TCPRequests  monitoring.Metric
+	// Requests counts the requests by port, protocol and security of the connection.
+	Requests monitoring.Metric
+	// RequestHeaders is the histogram of the number of headers of the HTTP and gRPC requests.
+	RequestHeaders monitoring.Metric
+	// RequestHeaderBytes is the histogram of the size of the headers of the HTTP and gRPC requests.
+	RequestHeaderBytes monitoring.Metric
 }
 
+// Values of the SecurityLabel.
+const (
+	// SecurityMTLS is set when the client presented a certificate, either to the echo server or to its sidecar, which
+	// then forwards the X-Forwarded-Client-Cert header.
+	SecurityMTLS = "mtls"
+	// SecurityTLS is set when the echo server terminated TLS without a client certificate.
+	SecurityTLS = "tls"
+	// SecurityPlaintext is set otherwise.
+	SecurityPlaintext = "plaintext"
+)
+
 var (
-	PortLabel = monitoring.MustCreateLabel("port")
-	Metrics   = &EchoMetrics{
+	PortLabel     = monitoring.MustCreateLabel("port")
+	ProtocolLabel = monitoring.MustCreateLabel("protocol")
+	SecurityLabel = monitoring.MustCreateLabel("security")
+	Metrics       = &EchoMetrics{
 		HTTPRequests: monitoring.NewSum(
 			"istio_echo_http_requests_total",
 			"The number of http requests total",
@@ -39,9 +61,57 @@ var (
 			"istio_echo_tcp_requests_total",
 			"The number of tcp requests total",
 		),
+		Requests: monitoring.NewSum(
+			"istio_echo_requests_total",
+			"The number of requests total, by port, protocol and security of the connection",
+			monitoring.WithLabels(PortLabel, ProtocolLabel, SecurityLabel),
+		),
+		RequestHeaders: monitoring.NewDistribution(
+			"istio_echo_request_headers",
+			"The number of headers of the http and grpc requests",
+			[]float64{5, 10, 20, 30, 50, 100},
+			monitoring.WithLabels(PortLabel, ProtocolLabel),
+		),
+		RequestHeaderBytes: monitoring.NewDistribution(
+			"istio_echo_request_header_bytes",
+			"The size in bytes of the headers of the http and grpc requests",
+			[]float64{256, 512, 1024, 2048, 4096, 8192, 16384},
+			monitoring.WithLabels(PortLabel, ProtocolLabel),
+		),
 	}
 )
 
 func init() {
-	monitoring.MustRegister(Metrics.HTTPRequests, Metrics.GrpcRequests, Metrics.TCPRequests)
+	monitoring.MustRegister(Metrics.HTTPRequests, Metrics.GrpcRequests, Metrics.TCPRequests,
+		Metrics.Requests, Metrics.RequestHeaders, Metrics.RequestHeaderBytes)
+}
+
+// ConnectionSecurity returns the SecurityLabel value for a request with the TLS state and the
+// X-Forwarded-Client-Cert header.
+func ConnectionSecurity(state *tls.ConnectionState, xfcc string) string {
+	switch {
+	case xfcc != "" || (state != nil && len(state.PeerCertificates) > 0):
+		return SecurityMTLS
+	case state != nil:
+		return SecurityTLS
+	default:
+		return SecurityPlaintext
+	}
+}
+
+// RecordRequest records the metrics of a request. Headers may be nil for TCP requests.
+func RecordRequest(port, protocol, security string, headers http.Header) {
+	Metrics.Requests.With(PortLabel.Value(port), ProtocolLabel.Value(protocol), SecurityLabel.Value(security)).Increment()
+	if headers == nil {
+		return
+	}
+	count, size := 0, 0
+	for k, values := range headers {
+		for _, v := range values {
+			count++
+			size += len(k) + len(v)
+		}
+	}
+	Metrics.RequestHeaders.With(PortLabel.Value(port), ProtocolLabel.Value(protocol)).Record(float64(count))
+	Metrics.RequestHeaderBytes.With(PortLabel.Value(port), ProtocolLabel.Value(protocol)).Record(float64(size))
 }
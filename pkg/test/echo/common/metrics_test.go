@@ -0,0 +1,125 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"testing"
+
+	"go.opencensus.io/stats/view"
+)
+
+func TestConnectionSecurity(t *testing.T) {
+	cases := []struct {
+		name  string
+		state *tls.ConnectionState
+		xfcc  string
+		want  string
+	}{
+		{
+			name: "plaintext",
+			want: SecurityPlaintext,
+		},
+		{
+			name:  "tls",
+			state: &tls.ConnectionState{},
+			want:  SecurityTLS,
+		},
+		{
+			name:  "client certificate",
+			state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}},
+			want:  SecurityMTLS,
+		},
+		{
+			name: "forwarded client certificate",
+			xfcc: "By=spiffe://cluster.local/ns/default/sa/b;URI=spiffe://cluster.local/ns/default/sa/a",
+			want: SecurityMTLS,
+		},
+		{
+			name:  "forwarded client certificate over tls",
+			state: &tls.ConnectionState{},
+			xfcc:  "URI=spiffe://cluster.local/ns/default/sa/a",
+			want:  SecurityMTLS,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConnectionSecurity(tt.state, tt.xfcc); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// metricRow returns the data of the row of the metric with the tags, or nil if there is none.
+func metricRow(t *testing.T, name string, tags map[string]string) view.AggregationData {
+	t.Helper()
+	rows, err := view.RetrieveData(name)
+	if err != nil {
+		t.Fatalf("failed to get the rows of %s: %v", name, err)
+	}
+	for _, row := range rows {
+		got := map[string]string{}
+		for _, tag := range row.Tags {
+			got[tag.Key.Name()] = tag.Value
+		}
+		if len(got) != len(tags) {
+			continue
+		}
+		match := true
+		for k, v := range tags {
+			if got[k] != v {
+				match = false
+			}
+		}
+		if match {
+			return row.Data
+		}
+	}
+	return nil
+}
+
+func TestRecordRequest(t *testing.T) {
+	RecordRequest("8080", "HTTP", SecurityMTLS, http.Header{
+		"Host":   {"a"},
+		"X-Test": {"b", "cd"},
+	})
+	RecordRequest("9090", "TCP", SecurityPlaintext, nil)
+
+	requests := metricRow(t, "istio_echo_requests_total", map[string]string{"port": "8080", "protocol": "HTTP", "security": SecurityMTLS})
+	if requests == nil || requests.(*view.SumData).Value != 1 {
+		t.Fatalf("expected one HTTP request, got %v", requests)
+	}
+	headers := metricRow(t, "istio_echo_request_headers", map[string]string{"port": "8080", "protocol": "HTTP"})
+	if headers == nil || headers.(*view.DistributionData).Count != 1 || headers.(*view.DistributionData).Mean != 3 {
+		t.Fatalf("expected one sample of 3 headers, got %v", headers)
+	}
+	// Each header value is counted with its name: len("Host")+len("a")+2*len("X-Test")+len("b")+len("cd").
+	size := metricRow(t, "istio_echo_request_header_bytes", map[string]string{"port": "8080", "protocol": "HTTP"})
+	if size == nil || size.(*view.DistributionData).Count != 1 || size.(*view.DistributionData).Mean != 20 {
+		t.Fatalf("expected one sample of 20 bytes, got %v", size)
+	}
+
+	tcp := metricRow(t, "istio_echo_requests_total", map[string]string{"port": "9090", "protocol": "TCP", "security": SecurityPlaintext})
+	if tcp == nil || tcp.(*view.SumData).Value != 1 {
+		t.Fatalf("expected one TCP request, got %v", tcp)
+	}
+	// The headers of TCP requests are not recorded.
+	if got := metricRow(t, "istio_echo_request_headers", map[string]string{"port": "9090", "protocol": "TCP"}); got != nil {
+		t.Fatalf("expected no headers for TCP requests, got %v", got)
+	}
+}
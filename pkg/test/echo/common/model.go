@@ -35,6 +35,13 @@ type TLSSettings struct {
 	AcceptAnyALPN bool
 }
 
+// ClientIdentityFiles are the files of a client certificate and key preloaded by the echo server, which the
+// ForwardEchoRequests may refer to by name.
+type ClientIdentityFiles struct {
+	CertFile string
+	KeyFile  string
+}
+
 // Port represents a network port where a service is listening for
 // connections. The port should be annotated with the type of protocol
 // used by the port.
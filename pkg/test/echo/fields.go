@@ -34,7 +34,20 @@ const (
 	AlpnField           Field = "Alpn"
 	RequestHeaderField  Field = "RequestHeader"
 	ResponseHeaderField Field = "ResponseHeader"
-	ClusterField        Field = "Cluster"
-	IstioVersionField   Field = "IstioVersion"
-	IPField             Field = "IP" // The Requester’s IP Address.
+	// ResponseTrailerField are the trailers of a forwarded gRPC request.
+	ResponseTrailerField Field = "ResponseTrailer"
+	ClusterField         Field = "Cluster"
+	IstioVersionField    Field = "IstioVersion"
+	IPField              Field = "IP" // The Requester’s IP Address.
+	// ClientIdentityField is the client identity a forwarded request was made with.
+	ClientIdentityField Field = "ClientIdentity"
+	// ResolvedAddressesField are the addresses the host of a forwarded request was resolved to, the first being used.
+	ResolvedAddressesField Field = "ResolvedAddresses"
+	// GRPCStatusField is the numeric gRPC status code of a forwarded gRPC request.
+	GRPCStatusField Field = "GRPCStatus"
+	// GRPCMessageField is the message of the non-OK gRPC status of a forwarded gRPC request.
+	GRPCMessageField Field = "GRPCMessage"
+	// GRPCDetailsField holds the details of the non-OK gRPC status of a forwarded gRPC request, as a base64 encoded
+	// google.rpc.Status.
+	GRPCDetailsField Field = "GRPCDetails"
 )
@@ -23,24 +23,34 @@ import (
 )
 
 var (
-	requestIDFieldRegex      = regexp.MustCompile("(?i)" + string(RequestIDField) + "=(.*)")
-	serviceVersionFieldRegex = regexp.MustCompile(string(ServiceVersionField) + "=(.*)")
-	servicePortFieldRegex    = regexp.MustCompile(string(ServicePortField) + "=(.*)")
-	statusCodeFieldRegex     = regexp.MustCompile(string(StatusCodeField) + "=(.*)")
-	hostFieldRegex           = regexp.MustCompile(string(HostField) + "=(.*)")
-	hostnameFieldRegex       = regexp.MustCompile(string(HostnameField) + "=(.*)")
-	requestHeaderFieldRegex  = regexp.MustCompile(string(RequestHeaderField) + "=(.*)")
-	responseHeaderFieldRegex = regexp.MustCompile(string(ResponseHeaderField) + "=(.*)")
-	URLFieldRegex            = regexp.MustCompile(string(URLField) + "=(.*)")
-	ClusterFieldRegex        = regexp.MustCompile(string(ClusterField) + "=(.*)")
-	IstioVersionFieldRegex   = regexp.MustCompile(string(IstioVersionField) + "=(.*)")
-	IPFieldRegex             = regexp.MustCompile(string(IPField) + "=(.*)")
-	methodFieldRegex         = regexp.MustCompile(string(MethodField) + "=(.*)")
-	protocolFieldRegex       = regexp.MustCompile(string(ProtocolField) + "=(.*)")
-	alpnFieldRegex           = regexp.MustCompile(string(AlpnField) + "=(.*)")
+	requestIDFieldRegex       = regexp.MustCompile("(?i)" + string(RequestIDField) + "=(.*)")
+	serviceVersionFieldRegex  = regexp.MustCompile(string(ServiceVersionField) + "=(.*)")
+	servicePortFieldRegex     = regexp.MustCompile(string(ServicePortField) + "=(.*)")
+	statusCodeFieldRegex      = regexp.MustCompile(string(StatusCodeField) + "=(.*)")
+	hostFieldRegex            = regexp.MustCompile(string(HostField) + "=(.*)")
+	hostnameFieldRegex        = regexp.MustCompile(string(HostnameField) + "=(.*)")
+	requestHeaderFieldRegex   = regexp.MustCompile(string(RequestHeaderField) + "=(.*)")
+	responseHeaderFieldRegex  = regexp.MustCompile(string(ResponseHeaderField) + "=(.*)")
+	responseTrailerFieldRegex = regexp.MustCompile(string(ResponseTrailerField) + "=(.*)")
+	grpcStatusFieldRegex      = regexp.MustCompile(string(GRPCStatusField) + "=(.*)")
+	grpcMessageFieldRegex     = regexp.MustCompile(string(GRPCMessageField) + "=(.*)")
+	grpcDetailsFieldRegex     = regexp.MustCompile(string(GRPCDetailsField) + "=(.*)")
+	URLFieldRegex             = regexp.MustCompile(string(URLField) + "=(.*)")
+	ClusterFieldRegex         = regexp.MustCompile(string(ClusterField) + "=(.*)")
+	IstioVersionFieldRegex    = regexp.MustCompile(string(IstioVersionField) + "=(.*)")
+	IPFieldRegex              = regexp.MustCompile(string(IPField) + "=(.*)")
+	methodFieldRegex          = regexp.MustCompile(string(MethodField) + "=(.*)")
+	protocolFieldRegex        = regexp.MustCompile(string(ProtocolField) + "=(.*)")
+	alpnFieldRegex            = regexp.MustCompile(string(AlpnField) + "=(.*)")
+	clientIdentityFieldRegex  = regexp.MustCompile(string(ClientIdentityField) + "=(.*)")
+	resolvedAddressesRegex    = regexp.MustCompile(string(ResolvedAddressesField) + "=(.*)")
 )
 
 func ParseResponses(req *proto.ForwardEchoRequest, resp *proto.ForwardEchoResponse) Responses {
+	if resp.LoadStatistics != nil {
+		// In load generation mode, the statistics of the requests are reported instead of their output.
+		return Responses{{RequestURL: req.Url, LoadStatistics: resp.LoadStatistics}}
+	}
 	responses := make([]Response, len(resp.Output))
 	for i, output := range resp.Output {
 		responses[i] = parseResponse(output)
@@ -51,9 +61,10 @@ func ParseResponses(req *proto.ForwardEchoRequest, resp *proto.ForwardEchoRespon
 
 func parseResponse(output string) Response {
 	out := Response{
-		RawContent:      output,
-		RequestHeaders:  make(http.Header),
-		ResponseHeaders: make(http.Header),
+		RawContent:       output,
+		RequestHeaders:   make(http.Header),
+		ResponseHeaders:  make(http.Header),
+		ResponseTrailers: make(http.Header),
 	}
 
 	match := requestIDFieldRegex.FindStringSubmatch(output)
@@ -121,14 +132,45 @@ func parseResponse(output string) Response {
 		out.IP = match[1]
 	}
 
+	match = clientIdentityFieldRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.ClientIdentity = match[1]
+	}
+
+	match = resolvedAddressesRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.ResolvedAddresses = strings.Split(match[1], ",")
+	}
+
+	match = grpcStatusFieldRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.GRPCStatus = match[1]
+	}
+
+	match = grpcMessageFieldRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.GRPCMessage = match[1]
+	}
+
+	match = grpcDetailsFieldRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.GRPCDetails = match[1]
+	}
+
 	out.rawBody = map[string]string{}
 
+	// Each proxy may append its own X-Forwarded-Client-Cert header rather than append to the value of the
+	// previous one, so the values of the headers are joined into one list of elements.
+	var xfcc []string
 	matches := requestHeaderFieldRegex.FindAllStringSubmatch(output, -1)
 	for _, kv := range matches {
 		sl := strings.SplitN(kv[1], ":", 2)
 		if len(sl) != 2 {
 			continue
 		}
+		if http.CanonicalHeaderKey(sl[0]) == xfccHeader {
+			xfcc = append(xfcc, sl[1])
+		}
 		out.RequestHeaders.Set(sl[0], sl[1])
 	}
 
@@ -141,6 +183,20 @@ func parseResponse(output string) Response {
 		out.ResponseHeaders.Set(sl[0], sl[1])
 	}
 
+	matches = responseTrailerFieldRegex.FindAllStringSubmatch(output, -1)
+	for _, kv := range matches {
+		sl := strings.SplitN(kv[1], ":", 2)
+		if len(sl) != 2 {
+			continue
+		}
+		out.ResponseTrailers.Set(sl[0], sl[1])
+	}
+
+	out.XFCC = parseXFCC(strings.Join(xfcc, ","))
+	out.TraceParent = parseTraceParent(out.RequestHeaders.Get(traceParentHeader))
+	out.EnvoyRequestHeaders = envoyHeaders(out.RequestHeaders)
+	out.EnvoyResponseHeaders = envoyHeaders(out.ResponseHeaders)
+
 	for _, l := range strings.Split(output, "\n") {
 		prefixSplit := strings.Split(l, "body] ")
 		if len(prefixSplit) != 2 {
@@ -226,6 +226,17 @@ type ForwardEchoRequest struct {
 	// Expected response determines what string to look for in the response to validate TCP requests succeeded.
 	// If not set, defaults to "StatusCode=200"
 	ExpectedResponse *wrappers.StringValue `protobuf:"bytes,21,opt,name=expectedResponse,proto3" json:"expectedResponse,omitempty"`
+	// If non-empty, the requests are made with these client identities in turn, instead of cert and key: the request i
+	// is made with the identity i modulo the number of identities. The response of each request reports the identity
+	// used in the ClientIdentity field.
+	ClientIdentities []*ClientIdentity `protobuf:"bytes,22,rep,name=clientIdentities,proto3" json:"clientIdentities,omitempty"`
+	// If set, the host of the url is resolved with this resolution, and the requests are sent to the first address
+	// resolved instead. The response of each request reports the addresses resolved in the ResolvedAddresses field.
+	DnsResolution *DNSResolution `protobuf:"bytes,23,opt,name=dnsResolution,proto3" json:"dnsResolution,omitempty"`
+	// If set, the requests are sent in load generation mode: count is ignored, the requests are sent at the qps for
+	// the duration of the load generation, or as fast as possible if qps is not set, and the response reports the
+	// statistics of the requests instead of their output. The timeout applies to each request.
+	LoadGeneration *LoadGeneration `protobuf:"bytes,24,opt,name=loadGeneration,proto3" json:"loadGeneration,omitempty"`
 }
 
 func (x *ForwardEchoRequest) Reset() {
@@ -407,6 +418,211 @@ func (x *ForwardEchoRequest) GetExpectedResponse() *wrappers.StringValue {
 	return nil
 }
 
+func (x *ForwardEchoRequest) GetClientIdentities() []*ClientIdentity {
+	if x != nil {
+		return x.ClientIdentities
+	}
+	return nil
+}
+
+func (x *ForwardEchoRequest) GetDnsResolution() *DNSResolution {
+	if x != nil {
+		return x.DnsResolution
+	}
+	return nil
+}
+
+func (x *ForwardEchoRequest) GetLoadGeneration() *LoadGeneration {
+	if x != nil {
+		return x.LoadGeneration
+	}
+	return nil
+}
+
+// A client certificate and key to make requests with.
+type ClientIdentity struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name of an identity preloaded by the echo server, with the --client-identity flag. Reported as the identity
+	// used, or as the index of the identity if not set.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// PEM encoded certificate and key, used if the name does not refer to a preloaded identity.
+	Cert string `protobuf:"bytes,2,opt,name=cert,proto3" json:"cert,omitempty"`
+	Key  string `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (x *ClientIdentity) Reset() {
+	*x = ClientIdentity{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_echo_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClientIdentity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientIdentity) ProtoMessage() {}
+
+func (x *ClientIdentity) ProtoReflect() protoreflect.Message {
+	mi := &file_echo_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientIdentity.ProtoReflect.Descriptor instead.
+func (*ClientIdentity) Descriptor() ([]byte, []int) {
+	return file_echo_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ClientIdentity) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClientIdentity) GetCert() string {
+	if x != nil {
+		return x.Cert
+	}
+	return ""
+}
+
+func (x *ClientIdentity) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// How the host of a request is resolved.
+type DNSResolution struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Address of the DNS server the host is resolved with, for example the DNS proxy of the sidecar at
+	// localhost:15053. If not set, the system resolver is used, which usually queries the cluster DNS.
+	Server string `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	// If non-empty, the host is resolved to these IP addresses, without any DNS query.
+	Addresses []string `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+}
+
+func (x *DNSResolution) Reset() {
+	*x = DNSResolution{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_echo_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DNSResolution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSResolution) ProtoMessage() {}
+
+func (x *DNSResolution) ProtoReflect() protoreflect.Message {
+	mi := &file_echo_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSResolution.ProtoReflect.Descriptor instead.
+func (*DNSResolution) Descriptor() ([]byte, []int) {
+	return file_echo_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DNSResolution) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *DNSResolution) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+// How load is generated.
+type LoadGeneration struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Duration the load is sustained for.
+	DurationMicros int64 `protobuf:"varint,1,opt,name=duration_micros,json=durationMicros,proto3" json:"duration_micros,omitempty"`
+	// Number of connections the requests are sent over, each sending a request at a time. Defaults to 1.
+	Connections int32 `protobuf:"varint,2,opt,name=connections,proto3" json:"connections,omitempty"`
+}
+
+func (x *LoadGeneration) Reset() {
+	*x = LoadGeneration{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_echo_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadGeneration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadGeneration) ProtoMessage() {}
+
+func (x *LoadGeneration) ProtoReflect() protoreflect.Message {
+	mi := &file_echo_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadGeneration.ProtoReflect.Descriptor instead.
+func (*LoadGeneration) Descriptor() ([]byte, []int) {
+	return file_echo_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LoadGeneration) GetDurationMicros() int64 {
+	if x != nil {
+		return x.DurationMicros
+	}
+	return 0
+}
+
+func (x *LoadGeneration) GetConnections() int32 {
+	if x != nil {
+		return x.Connections
+	}
+	return 0
+}
+
 type Alpn struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -418,7 +634,7 @@ type Alpn struct {
 func (x *Alpn) Reset() {
 	*x = Alpn{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_echo_proto_msgTypes[4]
+		mi := &file_echo_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -431,7 +647,7 @@ func (x *Alpn) String() string {
 func (*Alpn) ProtoMessage() {}
 
 func (x *Alpn) ProtoReflect() protoreflect.Message {
-	mi := &file_echo_proto_msgTypes[4]
+	mi := &file_echo_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -444,7 +660,7 @@ func (x *Alpn) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Alpn.ProtoReflect.Descriptor instead.
 func (*Alpn) Descriptor() ([]byte, []int) {
-	return file_echo_proto_rawDescGZIP(), []int{4}
+	return file_echo_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Alpn) GetValue() []string {
@@ -460,12 +676,14 @@ type ForwardEchoResponse struct {
 	unknownFields protoimpl.UnknownFields
 
 	Output []string `protobuf:"bytes,1,rep,name=output,proto3" json:"output,omitempty"`
+	// Statistics of the requests sent in load generation mode.
+	LoadStatistics *LoadStatistics `protobuf:"bytes,2,opt,name=loadStatistics,proto3" json:"loadStatistics,omitempty"`
 }
 
 func (x *ForwardEchoResponse) Reset() {
 	*x = ForwardEchoResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_echo_proto_msgTypes[5]
+		mi := &file_echo_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -478,7 +696,7 @@ func (x *ForwardEchoResponse) String() string {
 func (*ForwardEchoResponse) ProtoMessage() {}
 
 func (x *ForwardEchoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_echo_proto_msgTypes[5]
+	mi := &file_echo_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -491,7 +709,7 @@ func (x *ForwardEchoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ForwardEchoResponse.ProtoReflect.Descriptor instead.
 func (*ForwardEchoResponse) Descriptor() ([]byte, []int) {
-	return file_echo_proto_rawDescGZIP(), []int{5}
+	return file_echo_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ForwardEchoResponse) GetOutput() []string {
@@ -501,6 +719,311 @@ func (x *ForwardEchoResponse) GetOutput() []string {
 	return nil
 }
 
+func (x *ForwardEchoResponse) GetLoadStatistics() *LoadStatistics {
+	if x != nil {
+		return x.LoadStatistics
+	}
+	return nil
+}
+
+// Statistics of the requests sent in load generation mode.
+type LoadStatistics struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of requests sent, and of requests which failed.
+	Requests int64 `protobuf:"varint,1,opt,name=requests,proto3" json:"requests,omitempty"`
+	Errors   int64 `protobuf:"varint,2,opt,name=errors,proto3" json:"errors,omitempty"`
+	// Duration the requests were sent for, and the rate they were sent at.
+	DurationMicros int64   `protobuf:"varint,3,opt,name=duration_micros,json=durationMicros,proto3" json:"duration_micros,omitempty"`
+	Qps            float64 `protobuf:"fixed64,4,opt,name=qps,proto3" json:"qps,omitempty"`
+	// Latencies of the successful requests.
+	MinMicros   int64                `protobuf:"varint,5,opt,name=min_micros,json=minMicros,proto3" json:"min_micros,omitempty"`
+	MeanMicros  int64                `protobuf:"varint,6,opt,name=mean_micros,json=meanMicros,proto3" json:"mean_micros,omitempty"`
+	MaxMicros   int64                `protobuf:"varint,7,opt,name=max_micros,json=maxMicros,proto3" json:"max_micros,omitempty"`
+	Percentiles []*LatencyPercentile `protobuf:"bytes,8,rep,name=percentiles,proto3" json:"percentiles,omitempty"`
+	Histogram   []*LatencyBucket     `protobuf:"bytes,9,rep,name=histogram,proto3" json:"histogram,omitempty"`
+	// Number of failed requests by class of error, such as timeout or status_503.
+	ErrorClasses []*ErrorClass `protobuf:"bytes,10,rep,name=errorClasses,proto3" json:"errorClasses,omitempty"`
+}
+
+func (x *LoadStatistics) Reset() {
+	*x = LoadStatistics{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_echo_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadStatistics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadStatistics) ProtoMessage() {}
+
+func (x *LoadStatistics) ProtoReflect() protoreflect.Message {
+	mi := &file_echo_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadStatistics.ProtoReflect.Descriptor instead.
+func (*LoadStatistics) Descriptor() ([]byte, []int) {
+	return file_echo_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *LoadStatistics) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *LoadStatistics) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *LoadStatistics) GetDurationMicros() int64 {
+	if x != nil {
+		return x.DurationMicros
+	}
+	return 0
+}
+
+func (x *LoadStatistics) GetQps() float64 {
+	if x != nil {
+		return x.Qps
+	}
+	return 0
+}
+
+func (x *LoadStatistics) GetMinMicros() int64 {
+	if x != nil {
+		return x.MinMicros
+	}
+	return 0
+}
+
+func (x *LoadStatistics) GetMeanMicros() int64 {
+	if x != nil {
+		return x.MeanMicros
+	}
+	return 0
+}
+
+func (x *LoadStatistics) GetMaxMicros() int64 {
+	if x != nil {
+		return x.MaxMicros
+	}
+	return 0
+}
+
+func (x *LoadStatistics) GetPercentiles() []*LatencyPercentile {
+	if x != nil {
+		return x.Percentiles
+	}
+	return nil
+}
+
+func (x *LoadStatistics) GetHistogram() []*LatencyBucket {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (x *LoadStatistics) GetErrorClasses() []*ErrorClass {
+	if x != nil {
+		return x.ErrorClasses
+	}
+	return nil
+}
+
+type LatencyPercentile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Percentile    float64 `protobuf:"fixed64,1,opt,name=percentile,proto3" json:"percentile,omitempty"`
+	LatencyMicros int64   `protobuf:"varint,2,opt,name=latency_micros,json=latencyMicros,proto3" json:"latency_micros,omitempty"`
+}
+
+func (x *LatencyPercentile) Reset() {
+	*x = LatencyPercentile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_echo_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LatencyPercentile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencyPercentile) ProtoMessage() {}
+
+func (x *LatencyPercentile) ProtoReflect() protoreflect.Message {
+	mi := &file_echo_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencyPercentile.ProtoReflect.Descriptor instead.
+func (*LatencyPercentile) Descriptor() ([]byte, []int) {
+	return file_echo_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *LatencyPercentile) GetPercentile() float64 {
+	if x != nil {
+		return x.Percentile
+	}
+	return 0
+}
+
+func (x *LatencyPercentile) GetLatencyMicros() int64 {
+	if x != nil {
+		return x.LatencyMicros
+	}
+	return 0
+}
+
+// Number of successful requests whose latency is in [start, end).
+type LatencyBucket struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	StartMicros int64 `protobuf:"varint,1,opt,name=start_micros,json=startMicros,proto3" json:"start_micros,omitempty"`
+	EndMicros   int64 `protobuf:"varint,2,opt,name=end_micros,json=endMicros,proto3" json:"end_micros,omitempty"`
+	Count       int64 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *LatencyBucket) Reset() {
+	*x = LatencyBucket{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_echo_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LatencyBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencyBucket) ProtoMessage() {}
+
+func (x *LatencyBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_echo_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencyBucket.ProtoReflect.Descriptor instead.
+func (*LatencyBucket) Descriptor() ([]byte, []int) {
+	return file_echo_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *LatencyBucket) GetStartMicros() int64 {
+	if x != nil {
+		return x.StartMicros
+	}
+	return 0
+}
+
+func (x *LatencyBucket) GetEndMicros() int64 {
+	if x != nil {
+		return x.EndMicros
+	}
+	return 0
+}
+
+func (x *LatencyBucket) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ErrorClass struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Class string `protobuf:"bytes,1,opt,name=class,proto3" json:"class,omitempty"`
+	Count int64  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *ErrorClass) Reset() {
+	*x = ErrorClass{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_echo_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrorClass) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorClass) ProtoMessage() {}
+
+func (x *ErrorClass) ProtoReflect() protoreflect.Message {
+	mi := &file_echo_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorClass.ProtoReflect.Descriptor instead.
+func (*ErrorClass) Descriptor() ([]byte, []int) {
+	return file_echo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ErrorClass) GetClass() string {
+	if x != nil {
+		return x.Class
+	}
+	return ""
+}
+
+func (x *ErrorClass) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
 var File_echo_proto protoreflect.FileDescriptor
 
 var file_echo_proto_rawDesc = []byte{
@@ -515,7 +1038,7 @@ var file_echo_proto_rawDesc = []byte{
 	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x30, 0x0a, 0x06, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
 	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
 	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x97, 0x05, 0x0a, 0x12, 0x46, 0x6f, 0x72,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0xd5, 0x06, 0x0a, 0x12, 0x46, 0x6f, 0x72,
 	0x77, 0x61, 0x72, 0x64, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
 	0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
 	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x71, 0x70, 0x73, 0x18, 0x02, 0x20, 0x01,
@@ -557,21 +1080,93 @@ var file_echo_proto_rawDesc = []byte{
 	0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75, 0x65,
 	0x52, 0x10, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x22, 0x1c, 0x0a, 0x04, 0x41, 0x6c, 0x70, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x22, 0x2d, 0x0a, 0x13, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x45, 0x63, 0x68, 0x6f, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75,
-	0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x32,
-	0x88, 0x01, 0x0a, 0x0f, 0x45, 0x63, 0x68, 0x6f, 0x54, 0x65, 0x73, 0x74, 0x53, 0x65, 0x72, 0x76,
-	0x69, 0x63, 0x65, 0x12, 0x2f, 0x0a, 0x04, 0x45, 0x63, 0x68, 0x6f, 0x12, 0x12, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x44, 0x0a, 0x0b, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x45,
-	0x63, 0x68, 0x6f, 0x12, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x6f, 0x72, 0x77,
-	0x61, 0x72, 0x64, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x45, 0x63,
-	0x68, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0a, 0x5a, 0x08, 0x2e, 0x2e,
-	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x65, 0x12, 0x41, 0x0a, 0x10, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x16, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x52, 0x10, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x3a, 0x0a, 0x0d, 0x64, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x6f,
+	0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x4e, 0x53, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0d, 0x64, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x3d, 0x0a, 0x0e, 0x6c, 0x6f, 0x61, 0x64, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x18, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x0e, 0x6c, 0x6f, 0x61, 0x64, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0x4a, 0x0a, 0x0e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x74, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x65, 0x72, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x65, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x45, 0x0a, 0x0d,
+	0x44, 0x4e, 0x53, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x73, 0x22, 0x5b, 0x0a, 0x0e, 0x4c, 0x6f, 0x61, 0x64, 0x47, 0x65, 0x6e, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x27, 0x0a, 0x0f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e,
+	0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x20,
+	0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0b, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x22, 0x1c, 0x0a, 0x04, 0x41, 0x6c, 0x70, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x6c,
+	0x0a, 0x13, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x3d, 0x0a,
+	0x0e, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4c, 0x6f,
+	0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x52, 0x0e, 0x6c, 0x6f,
+	0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x22, 0x85, 0x03, 0x0a,
+	0x0e, 0x4c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x64, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x10, 0x0a, 0x03,
+	0x71, 0x70, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x71, 0x70, 0x73, 0x12, 0x1d,
+	0x0a, 0x0a, 0x6d, 0x69, 0x6e, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x6d, 0x69, 0x6e, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x1f, 0x0a,
+	0x0b, 0x6d, 0x65, 0x61, 0x6e, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x6d, 0x65, 0x61, 0x6e, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x1d,
+	0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x3a, 0x0a,
+	0x0b, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4c, 0x61, 0x74, 0x65, 0x6e,
+	0x63, 0x79, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x69, 0x6c, 0x65, 0x52, 0x0b, 0x70, 0x65,
+	0x72, 0x63, 0x65, 0x6e, 0x74, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x32, 0x0a, 0x09, 0x68, 0x69, 0x73,
+	0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x42, 0x75, 0x63, 0x6b,
+	0x65, 0x74, 0x52, 0x09, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x12, 0x35, 0x0a,
+	0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x65, 0x73, 0x18, 0x0a, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x72, 0x72, 0x6f,
+	0x72, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6c, 0x61,
+	0x73, 0x73, 0x65, 0x73, 0x22, 0x5a, 0x0a, 0x11, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x50,
+	0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x69, 0x6c, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x65, 0x72,
+	0x63, 0x65, 0x6e, 0x74, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x70,
+	0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x69, 0x6c, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x6c, 0x61, 0x74,
+	0x65, 0x6e, 0x63, 0x79, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0d, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73,
+	0x22, 0x67, 0x0a, 0x0d, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x42, 0x75, 0x63, 0x6b, 0x65,
+	0x74, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x69,
+	0x63, 0x72, 0x6f, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x64, 0x5f, 0x6d, 0x69, 0x63, 0x72,
+	0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x4d, 0x69, 0x63,
+	0x72, 0x6f, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x38, 0x0a, 0x0a, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6c, 0x61, 0x73, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x14, 0x0a,
+	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x32, 0x88, 0x01, 0x0a, 0x0f, 0x45, 0x63, 0x68, 0x6f, 0x54, 0x65, 0x73, 0x74,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x2f, 0x0a, 0x04, 0x45, 0x63, 0x68, 0x6f, 0x12,
+	0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x63, 0x68, 0x6f,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x44, 0x0a, 0x0b, 0x46, 0x6f, 0x72, 0x77,
+	0x61, 0x72, 0x64, 0x45, 0x63, 0x68, 0x6f, 0x12, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x6f, 0x72, 0x77, 0x61,
+	0x72, 0x64, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0a,
+	0x5a, 0x08, 0x2e, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -586,29 +1181,43 @@ func file_echo_proto_rawDescGZIP() []byte {
 	return file_echo_proto_rawDescData
 }
 
-var file_echo_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_echo_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_echo_proto_goTypes = []interface{}{
 	(*EchoRequest)(nil),          // 0: proto.EchoRequest
 	(*EchoResponse)(nil),         // 1: proto.EchoResponse
 	(*Header)(nil),               // 2: proto.Header
 	(*ForwardEchoRequest)(nil),   // 3: proto.ForwardEchoRequest
-	(*Alpn)(nil),                 // 4: proto.Alpn
-	(*ForwardEchoResponse)(nil),  // 5: proto.ForwardEchoResponse
-	(*wrappers.StringValue)(nil), // 6: google.protobuf.StringValue
+	(*ClientIdentity)(nil),       // 4: proto.ClientIdentity
+	(*DNSResolution)(nil),        // 5: proto.DNSResolution
+	(*LoadGeneration)(nil),       // 6: proto.LoadGeneration
+	(*Alpn)(nil),                 // 7: proto.Alpn
+	(*ForwardEchoResponse)(nil),  // 8: proto.ForwardEchoResponse
+	(*LoadStatistics)(nil),       // 9: proto.LoadStatistics
+	(*LatencyPercentile)(nil),    // 10: proto.LatencyPercentile
+	(*LatencyBucket)(nil),        // 11: proto.LatencyBucket
+	(*ErrorClass)(nil),           // 12: proto.ErrorClass
+	(*wrappers.StringValue)(nil), // 13: google.protobuf.StringValue
 }
 var file_echo_proto_depIdxs = []int32{
-	2, // 0: proto.ForwardEchoRequest.headers:type_name -> proto.Header
-	4, // 1: proto.ForwardEchoRequest.alpn:type_name -> proto.Alpn
-	6, // 2: proto.ForwardEchoRequest.expectedResponse:type_name -> google.protobuf.StringValue
-	0, // 3: proto.EchoTestService.Echo:input_type -> proto.EchoRequest
-	3, // 4: proto.EchoTestService.ForwardEcho:input_type -> proto.ForwardEchoRequest
-	1, // 5: proto.EchoTestService.Echo:output_type -> proto.EchoResponse
-	5, // 6: proto.EchoTestService.ForwardEcho:output_type -> proto.ForwardEchoResponse
-	5, // [5:7] is the sub-list for method output_type
-	3, // [3:5] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	2,  // 0: proto.ForwardEchoRequest.headers:type_name -> proto.Header
+	7,  // 1: proto.ForwardEchoRequest.alpn:type_name -> proto.Alpn
+	13, // 2: proto.ForwardEchoRequest.expectedResponse:type_name -> google.protobuf.StringValue
+	4,  // 3: proto.ForwardEchoRequest.clientIdentities:type_name -> proto.ClientIdentity
+	5,  // 4: proto.ForwardEchoRequest.dnsResolution:type_name -> proto.DNSResolution
+	6,  // 5: proto.ForwardEchoRequest.loadGeneration:type_name -> proto.LoadGeneration
+	9,  // 6: proto.ForwardEchoResponse.loadStatistics:type_name -> proto.LoadStatistics
+	10, // 7: proto.LoadStatistics.percentiles:type_name -> proto.LatencyPercentile
+	11, // 8: proto.LoadStatistics.histogram:type_name -> proto.LatencyBucket
+	12, // 9: proto.LoadStatistics.errorClasses:type_name -> proto.ErrorClass
+	0,  // 10: proto.EchoTestService.Echo:input_type -> proto.EchoRequest
+	3,  // 11: proto.EchoTestService.ForwardEcho:input_type -> proto.ForwardEchoRequest
+	1,  // 12: proto.EchoTestService.Echo:output_type -> proto.EchoResponse
+	8,  // 13: proto.EchoTestService.ForwardEcho:output_type -> proto.ForwardEchoResponse
+	12, // [12:14] is the sub-list for method output_type
+	10, // [10:12] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_echo_proto_init() }
@@ -666,7 +1275,7 @@ func file_echo_proto_init() {
 			}
 		}
 		file_echo_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Alpn); i {
+			switch v := v.(*ClientIdentity); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -678,6 +1287,42 @@ func file_echo_proto_init() {
 			}
 		}
 		file_echo_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DNSResolution); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_echo_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoadGeneration); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_echo_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Alpn); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_echo_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ForwardEchoResponse); i {
 			case 0:
 				return &v.state
@@ -689,6 +1334,54 @@ func file_echo_proto_init() {
 				return nil
 			}
 		}
+		file_echo_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoadStatistics); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_echo_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LatencyPercentile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_echo_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LatencyBucket); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_echo_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ErrorClass); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -696,7 +1389,7 @@ func file_echo_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_echo_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
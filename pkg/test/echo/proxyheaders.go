@@ -0,0 +1,141 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	xfccHeader        = "X-Forwarded-Client-Cert"
+	traceParentHeader = "Traceparent"
+	envoyHeaderPrefix = "X-Envoy-"
+)
+
+// XFCCElement is an element of the X-Forwarded-Client-Cert header, added by a proxy which verified a client
+// certificate.
+type XFCCElement struct {
+	// By is the URI SAN of the certificate of the proxy.
+	By string
+	// Hash is the hex encoded SHA256 digest of the client certificate.
+	Hash string
+	// Cert and Chain are the URL encoded PEM client certificate and chain, if the proxy forwards them.
+	Cert  string
+	Chain string
+	// Subject is the subject of the client certificate.
+	Subject string
+	// URI and DNS are the SANs of the client certificate.
+	URI []string
+	DNS []string
+}
+
+// TraceParent is the W3C trace context of a request, from its traceparent header.
+type TraceParent struct {
+	Version  string
+	TraceID  string
+	ParentID string
+	Flags    string
+}
+
+// Sampled returns true if the trace of the request is sampled.
+func (t TraceParent) Sampled() bool {
+	flags, err := strconv.ParseUint(t.Flags, 16, 8)
+	return err == nil && flags&1 == 1
+}
+
+// parseXFCC parses the elements of the value of a X-Forwarded-Client-Cert header. Malformed pairs are ignored.
+func parseXFCC(value string) []XFCCElement {
+	if value == "" {
+		return nil
+	}
+	var elements []XFCCElement
+	for _, e := range splitUnquoted(value, ',') {
+		element := XFCCElement{}
+		for _, pair := range splitUnquoted(e, ';') {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			v := unquote(strings.TrimSpace(kv[1]))
+			switch strings.ToLower(strings.TrimSpace(kv[0])) {
+			case "by":
+				element.By = v
+			case "hash":
+				element.Hash = v
+			case "cert":
+				element.Cert = v
+			case "chain":
+				element.Chain = v
+			case "subject":
+				element.Subject = v
+			case "uri":
+				element.URI = append(element.URI, v)
+			case "dns":
+				element.DNS = append(element.DNS, v)
+			}
+		}
+		elements = append(elements, element)
+	}
+	return elements
+}
+
+// splitUnquoted splits the value around the separator, except in double quoted strings.
+func splitUnquoted(value string, sep byte) []string {
+	var parts []string
+	quoted, escaped, begin := false, false, 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, value[begin:i])
+			begin = i + 1
+		}
+	}
+	return append(parts, value[begin:])
+}
+
+func unquote(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	return strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+}
+
+// parseTraceParent parses the value of a traceparent header, or returns nil if it is not valid.
+func parseTraceParent(value string) *TraceParent {
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil
+	}
+	return &TraceParent{Version: parts[0], TraceID: parts[1], ParentID: parts[2], Flags: parts[3]}
+}
+
+// envoyHeaders returns the x-envoy-* headers, which are added by the proxies.
+func envoyHeaders(headers http.Header) http.Header {
+	out := http.Header{}
+	for k, v := range headers {
+		if strings.HasPrefix(http.CanonicalHeaderKey(k), envoyHeaderPrefix) {
+			out[k] = v
+		}
+	}
+	return out
+}
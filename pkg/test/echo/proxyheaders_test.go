@@ -0,0 +1,142 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitUnquoted(t *testing.T) {
+	cases := []struct {
+		value string
+		want  []string
+	}{
+		{value: "", want: []string{""}},
+		{value: "a;b", want: []string{"a", "b"}},
+		{value: "a;", want: []string{"a", ""}},
+		{value: `a="b;c";d`, want: []string{`a="b;c"`, "d"}},
+		{value: `a="b\";c";d`, want: []string{`a="b\";c"`, "d"}},
+		{value: `a="b;c`, want: []string{`a="b;c`}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := splitUnquoted(tt.value, ';'); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseXFCC(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		want  []XFCCElement
+	}{
+		{name: "empty"},
+		{
+			name:  "single element",
+			value: `By=spiffe://cluster.local/ns/b/sa/b;Hash=abc;Subject="";URI=spiffe://cluster.local/ns/a/sa/a`,
+			want: []XFCCElement{{
+				By:   "spiffe://cluster.local/ns/b/sa/b",
+				Hash: "abc",
+				URI:  []string{"spiffe://cluster.local/ns/a/sa/a"},
+			}},
+		},
+		{
+			name:  "quoted subject",
+			value: `Hash=abc;Subject="CN=a,O=\"b;c\"";DNS=a.com;dns=b.com`,
+			want: []XFCCElement{{
+				Hash:    "abc",
+				Subject: `CN=a,O="b;c"`,
+				DNS:     []string{"a.com", "b.com"},
+			}},
+		},
+		{
+			name:  "several elements",
+			value: `By=spiffe://b;Hash=abc, By=spiffe://c;Hash=def;Cert="-----BEGIN%20CERTIFICATE-----"`,
+			want: []XFCCElement{
+				{By: "spiffe://b", Hash: "abc"},
+				{By: "spiffe://c", Hash: "def", Cert: "-----BEGIN%20CERTIFICATE-----"},
+			},
+		},
+		{
+			name:  "malformed pairs",
+			value: `Hash;By=spiffe://b;Unknown=x`,
+			want:  []XFCCElement{{By: "spiffe://b"}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseXFCC(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		want    *TraceParent
+		sampled bool
+	}{
+		{
+			name:    "sampled",
+			value:   "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			want:    &TraceParent{Version: "00", TraceID: "0af7651916cd43dd8448eb211c80319c", ParentID: "b7ad6b7169203331", Flags: "01"},
+			sampled: true,
+		},
+		{
+			name:  "not sampled",
+			value: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00",
+			want:  &TraceParent{Version: "00", TraceID: "0af7651916cd43dd8448eb211c80319c", ParentID: "b7ad6b7169203331", Flags: "00"},
+		},
+		{name: "empty"},
+		{name: "short trace id", value: "00-0af7651916cd43dd-b7ad6b7169203331-01"},
+		{name: "missing flags", value: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTraceParent(tt.value)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			if got != nil && got.Sampled() != tt.sampled {
+				t.Fatalf("got sampled %v, want %v", got.Sampled(), tt.sampled)
+			}
+		})
+	}
+}
+
+func TestParseResponseXFCC(t *testing.T) {
+	out := parseResponse(`[1] RequestHeader=X-Forwarded-Client-Cert:By=spiffe://b;Hash=abc
+[1] RequestHeader=X-Forwarded-Client-Cert:By=spiffe://c;Hash=def
+[1] RequestHeader=Traceparent:00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01
+[1] RequestHeader=X-Envoy-Attempt-Count:1
+`)
+	want := []XFCCElement{{By: "spiffe://b", Hash: "abc"}, {By: "spiffe://c", Hash: "def"}}
+	if !reflect.DeepEqual(out.XFCC, want) {
+		t.Fatalf("got XFCC %+v, want %+v", out.XFCC, want)
+	}
+	if out.TraceParent == nil || !out.TraceParent.Sampled() {
+		t.Fatalf("got trace parent %+v, want a sampled trace", out.TraceParent)
+	}
+	if got := out.EnvoyRequestHeaders.Get("X-Envoy-Attempt-Count"); got != "1" {
+		t.Fatalf("got attempt count %q, want 1", got)
+	}
+}
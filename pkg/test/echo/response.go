@@ -19,6 +19,8 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+
+	"istio.io/istio/pkg/test/echo/proto"
 )
 
 // HeaderType is a helper enum for retrieving Headers from a Response.
@@ -62,10 +64,35 @@ type Response struct {
 	IstioVersion string
 	// IP is the requester's ip address
 	IP string
+	// ClientIdentity is the name of the client identity the request was made with, if the request set any.
+	ClientIdentity string
+	// ResolvedAddresses are the addresses the host was resolved to, if the request set a DNS resolution. The request
+	// was sent to the first one.
+	ResolvedAddresses []string
+	// XFCC are the elements of the X-Forwarded-Client-Cert headers received, one per proxy which verified a client
+	// certificate, in order.
+	XFCC []XFCCElement
+	// TraceParent is the trace context received in the traceparent header, if any.
+	TraceParent *TraceParent
+	// EnvoyRequestHeaders and EnvoyResponseHeaders are the x-envoy-* headers added to the request and the response
+	// by the proxies.
+	EnvoyRequestHeaders  http.Header
+	EnvoyResponseHeaders http.Header
+	// LoadStatistics are the statistics of the requests sent in load generation mode, reported by a single response.
+	LoadStatistics *proto.LoadStatistics
 	// rawBody gives a map of all key/values in the body of the response.
 	rawBody         map[string]string
 	RequestHeaders  http.Header
 	ResponseHeaders http.Header
+	// ResponseTrailers are the trailers of the response to a gRPC request.
+	ResponseTrailers http.Header
+	// GRPCStatus is the numeric gRPC status code of the response to a gRPC request.
+	GRPCStatus string
+	// GRPCMessage is the message of the non-OK gRPC status of the response to a gRPC request.
+	GRPCMessage string
+	// GRPCDetails holds the details of the non-OK gRPC status of the response to a gRPC request, as a base64
+	// encoded google.rpc.Status. See common.ParseGRPCDetails.
+	GRPCDetails string
 }
 
 // Count occurrences of the given text within the body of this response.
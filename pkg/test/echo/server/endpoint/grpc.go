@@ -17,6 +17,7 @@ package endpoint
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -27,8 +28,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/admin"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	xdscreds "google.golang.org/grpc/credentials/xds"
@@ -37,9 +40,11 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/xds"
 	"k8s.io/utils/env"
 
+	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/istio-agent/grpcxds"
 	"istio.io/istio/pkg/test/echo"
 	"istio.io/istio/pkg/test/echo/common"
@@ -237,6 +242,7 @@ func (h *grpcHandler) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.
 	defer common.Metrics.GrpcRequests.With(common.PortLabel.Value(strconv.Itoa(h.Port.Port))).Increment()
 	body := bytes.Buffer{}
 	md, ok := metadata.FromIncomingContext(ctx)
+	h.recordRequest(ctx, md)
 	if ok {
 		for key, values := range md {
 			if strings.HasSuffix(key, "-bin") {
@@ -261,6 +267,18 @@ func (h *grpcHandler) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.
 	id := uuid.New()
 	epLog.WithLabels("message", req.GetMessage(), "headers", md, "id", id).Infof("GRPC Request")
 
+	if err := setGRPCResponseMetadata(ctx, md); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	st, err := grpcResponseStatus(md)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if st.Code() != codes.OK {
+		epLog.WithLabels("id", id, "code", st.Code()).Infof("GRPC Response")
+		return nil, st.Err()
+	}
+
 	portNumber := 0
 	if h.Port != nil {
 		portNumber = h.Port.Port
@@ -288,14 +306,115 @@ func (h *grpcHandler) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.
 	return &proto.EchoResponse{Message: body.String()}, nil
 }
 
+// setGRPCResponseMetadata sets the headers and trailers of the response requested by the request metadata.
+func setGRPCResponseMetadata(ctx context.Context, md metadata.MD) error {
+	header, err := parseGRPCResponseMetadata(md, common.GRPCHeadersHeader)
+	if err != nil {
+		return err
+	}
+	if len(header) > 0 {
+		if err := grpc.SetHeader(ctx, header); err != nil {
+			return err
+		}
+	}
+	trailer, err := parseGRPCResponseMetadata(md, common.GRPCTrailersHeader)
+	if err != nil {
+		return err
+	}
+	if len(trailer) > 0 {
+		return grpc.SetTrailer(ctx, trailer)
+	}
+	return nil
+}
+
+func parseGRPCResponseMetadata(md metadata.MD, key string) (metadata.MD, error) {
+	out := metadata.MD{}
+	for _, value := range md.Get(key) {
+		for _, kv := range strings.Split(value, ",") {
+			parts := strings.Split(kv, ":")
+			// require name:value format
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid %s %q (want name:value)", key, kv)
+			}
+			out.Append(parts[0], parts[1])
+		}
+	}
+	return out, nil
+}
+
+// grpcResponseStatus returns the status of the response requested by the request metadata, OK by default.
+func grpcResponseStatus(md metadata.MD) (*status.Status, error) {
+	values := md.Get(common.GRPCStatusHeader)
+	if len(values) == 0 {
+		return status.New(codes.OK, ""), nil
+	}
+	var flavors []codeAndSlices
+	for _, codecount := range strings.Split(values[0], ",") {
+		flavor := strings.Split(codecount, ":")
+		// Demand code or code:number
+		if len(flavor) > 2 {
+			return nil, fmt.Errorf("invalid %q (want code or code:count)", codecount)
+		}
+		code, err := strconv.Atoi(flavor[0])
+		if err != nil {
+			return nil, err
+		}
+		if code < int(codes.OK) || code > int(codes.Unauthenticated) {
+			return nil, fmt.Errorf("invalid gRPC status code %v", code)
+		}
+		count := 1
+		if len(flavor) > 1 {
+			if count, err = strconv.Atoi(flavor[1]); err != nil {
+				return nil, err
+			}
+			if count <= 0 {
+				return nil, fmt.Errorf("invalid count %v", count)
+			}
+		}
+		flavors = append(flavors, codeAndSlices{code, count})
+	}
+
+	code := codes.Code(chooseCode(flavors))
+	if code == codes.OK {
+		return status.New(codes.OK, ""), nil
+	}
+	st := &spb.Status{Code: int32(code)}
+	if values := md.Get(common.GRPCMessageHeader); len(values) > 0 {
+		st.Message = values[0]
+	}
+	if values := md.Get(common.GRPCDetailsHeader); len(values) > 0 {
+		details, err := common.ParseGRPCDetails(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", common.GRPCDetailsHeader, err)
+		}
+		st.Details = details.Details
+	}
+	return status.FromProto(st), nil
+}
+
+func (h *grpcHandler) recordRequest(ctx context.Context, md metadata.MD) {
+	var state *tls.ConnectionState
+	if peerInfo, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := peerInfo.AuthInfo.(credentials.TLSInfo); ok {
+			state = &tlsInfo.State
+		}
+	}
+	var xfcc string
+	if values := md.Get("x-forwarded-client-cert"); len(values) > 0 {
+		xfcc = values[0]
+	}
+	common.RecordRequest(strconv.Itoa(h.Port.Port), string(protocol.GRPC), common.ConnectionSecurity(state, xfcc), http.Header(md))
+}
+
 func (h *grpcHandler) ForwardEcho(ctx context.Context, req *proto.ForwardEchoRequest) (*proto.ForwardEchoResponse, error) {
 	id := uuid.New()
 	l := epLog.WithLabels("url", req.Url, "id", id)
 	l.Infof("ForwardEcho request")
 	t0 := time.Now()
 	instance, err := forwarder.New(forwarder.Config{
-		Request: req,
-		Dialer:  h.Dialer,
+		Request:          req,
+		Dialer:           h.Dialer,
+		ClientIdentities: h.ClientIdentities,
 	})
 	if err != nil {
 		return nil, err
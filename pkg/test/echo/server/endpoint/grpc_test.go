@@ -0,0 +1,187 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"istio.io/istio/pkg/test/echo/common"
+)
+
+func TestGRPCResponseStatus(t *testing.T) {
+	details, err := common.GRPCDetails(wrapperspb.String("retry later"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		md      metadata.MD
+		code    codes.Code
+		message string
+		details []proto.Message
+		wantErr bool
+	}{
+		{
+			name: "default",
+			md:   metadata.MD{},
+			code: codes.OK,
+		},
+		{
+			name: "ok",
+			md:   metadata.Pairs(common.GRPCStatusHeader, "0", common.GRPCMessageHeader, "ignored"),
+			code: codes.OK,
+		},
+		{
+			name:    "code and message",
+			md:      metadata.Pairs(common.GRPCStatusHeader, "14", common.GRPCMessageHeader, "try again"),
+			code:    codes.Unavailable,
+			message: "try again",
+		},
+		{
+			name: "code with count",
+			md:   metadata.Pairs(common.GRPCStatusHeader, "7:1"),
+			code: codes.PermissionDenied,
+		},
+		{
+			name:    "details",
+			md:      metadata.Pairs(common.GRPCStatusHeader, "8", common.GRPCDetailsHeader, details),
+			code:    codes.ResourceExhausted,
+			details: []proto.Message{wrapperspb.String("retry later")},
+		},
+		{
+			name:    "invalid code",
+			md:      metadata.Pairs(common.GRPCStatusHeader, "17"),
+			wantErr: true,
+		},
+		{
+			name:    "not a number",
+			md:      metadata.Pairs(common.GRPCStatusHeader, "unavailable"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid count",
+			md:      metadata.Pairs(common.GRPCStatusHeader, "14:0"),
+			wantErr: true,
+		},
+		{
+			name:    "too many parts",
+			md:      metadata.Pairs(common.GRPCStatusHeader, "14:1:1"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid details",
+			md:      metadata.Pairs(common.GRPCStatusHeader, "14", common.GRPCDetailsHeader, "not base64!"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := grpcResponseStatus(tt.md)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got status %v", st)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.Code() != tt.code || st.Message() != tt.message {
+				t.Errorf("expected code %v message %q, got code %v message %q", tt.code, tt.message, st.Code(), st.Message())
+			}
+			got := st.Proto().GetDetails()
+			if len(got) != len(tt.details) {
+				t.Fatalf("expected %d details, got %v", len(tt.details), got)
+			}
+			for i, d := range got {
+				m, err := d.UnmarshalNew()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !proto.Equal(m, tt.details[i]) {
+					t.Errorf("expected detail %v, got %v", tt.details[i], m)
+				}
+			}
+		})
+	}
+}
+
+func TestParseGRPCResponseMetadata(t *testing.T) {
+	cases := []struct {
+		name    string
+		md      metadata.MD
+		want    metadata.MD
+		wantErr bool
+	}{
+		{
+			name: "none",
+			md:   metadata.MD{},
+			want: metadata.MD{},
+		},
+		{
+			name: "pairs",
+			md:   metadata.Pairs(common.GRPCTrailersHeader, "foo:bar,baz:qux"),
+			want: metadata.MD{"foo": {"bar"}, "baz": {"qux"}},
+		},
+		{
+			name: "repeated",
+			md:   metadata.Pairs(common.GRPCTrailersHeader, "foo:bar", common.GRPCTrailersHeader, "foo:baz"),
+			want: metadata.MD{"foo": {"bar", "baz"}},
+		},
+		{
+			name: "names are lower cased",
+			md:   metadata.Pairs(common.GRPCTrailersHeader, "Foo:Bar"),
+			want: metadata.MD{"foo": {"Bar"}},
+		},
+		{
+			name: "other key",
+			md:   metadata.Pairs(common.GRPCHeadersHeader, "foo:bar"),
+			want: metadata.MD{},
+		},
+		{
+			name:    "missing value",
+			md:      metadata.Pairs(common.GRPCTrailersHeader, "foo"),
+			wantErr: true,
+		},
+		{
+			name:    "too many colons",
+			md:      metadata.Pairs(common.GRPCTrailersHeader, "foo:bar:baz"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGRPCResponseMetadata(tt.md, common.GRPCTrailersHeader)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
+	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/echo"
 	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/util/retry"
@@ -198,21 +199,22 @@ type httpHandler struct {
 }
 
 // Imagine a pie of different flavors.
-// The flavors are the HTTP response codes.
+// The flavors are the HTTP or gRPC response codes.
 // The chance of a particular flavor is ( slices / sum of slices ).
 type codeAndSlices struct {
-	httpResponseCode int
-	slices           int
+	code   int
+	slices int
 }
 
 func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	id := uuid.New()
 	epLog.WithLabels("method", r.Method, "url", r.URL, "host", r.Host, "headers", r.Header, "id", id).Infof("HTTP Request")
-	if h.Port == nil {
-		defer common.Metrics.HTTPRequests.With(common.PortLabel.Value("uds")).Increment()
-	} else {
-		defer common.Metrics.HTTPRequests.With(common.PortLabel.Value(strconv.Itoa(h.Port.Port))).Increment()
+	port, proto := "uds", string(protocol.HTTP)
+	if h.Port != nil {
+		port, proto = strconv.Itoa(h.Port.Port), string(h.Port.Protocol)
 	}
+	defer common.Metrics.HTTPRequests.With(common.PortLabel.Value(port)).Increment()
+	common.RecordRequest(port, proto, common.ConnectionSecurity(r.TLS, r.Header.Get("X-Forwarded-Client-Cert")), r.Header)
 	if !h.IsServerReady() {
 		// Handle readiness probe failure.
 		epLog.Infof("HTTP service not ready, returning 503")
@@ -387,6 +389,12 @@ func setResponseFromCodes(request *http.Request, response http.ResponseWriter) (
 		return 0, err
 	}
 
+	responseCode := chooseCode(codes)
+	response.WriteHeader(responseCode)
+	return responseCode, nil
+}
+
+func chooseCode(codes []codeAndSlices) int {
 	// Choose a random "slice" from a pie
 	totalSlices := 0
 	for _, flavor := range codes {
@@ -395,18 +403,16 @@ func setResponseFromCodes(request *http.Request, response http.ResponseWriter) (
 	slice := rand.Intn(totalSlices)
 
 	// What flavor is that slice?
-	responseCode := codes[len(codes)-1].httpResponseCode // Assume the last slice
+	responseCode := codes[len(codes)-1].code // Assume the last slice
 	position := 0
 	for n, flavor := range codes {
 		if position > slice {
-			responseCode = codes[n-1].httpResponseCode // No, use an earlier slice
+			responseCode = codes[n-1].code // No, use an earlier slice
 			break
 		}
 		position += flavor.slices
 	}
-
-	response.WriteHeader(responseCode)
-	return responseCode, nil
+	return responseCode
 }
 
 // codes must be comma-separated HTTP response code, colon, positive integer
@@ -41,6 +41,8 @@ type Config struct {
 	ListenerIP    string
 	IstioVersion  string
 	DisableALPN   bool
+	// ClientIdentities are the client identities preloaded for the forwarded requests, by name.
+	ClientIdentities map[string]common.ClientIdentityFiles
 }
 
 // Instance of an endpoint that serves the Echo application on a single port/protocol.
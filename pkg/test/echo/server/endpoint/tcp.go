@@ -24,6 +24,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/echo"
 	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/util/retry"
@@ -99,6 +100,15 @@ func (s *tcpInstance) Start(onReady OnReadyFunc) error {
 // Handles incoming connection.
 func (s *tcpInstance) echo(conn net.Conn) {
 	defer common.Metrics.TCPRequests.With(common.PortLabel.Value(strconv.Itoa(s.Port.Port))).Increment()
+	defer func() {
+		// The TLS handshake is done on the first read, so the state is only known once the connection was served.
+		var state *tls.ConnectionState
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			cs := tlsConn.ConnectionState()
+			state = &cs
+		}
+		common.RecordRequest(strconv.Itoa(s.Port.Port), string(protocol.TCP), common.ConnectionSecurity(state, ""), nil)
+	}()
 	defer func() {
 		_ = conn.Close()
 	}()
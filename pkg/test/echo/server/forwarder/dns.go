@@ -21,6 +21,7 @@ import (
 	"net"
 	"net/url"
 	"strings"
+	"time"
 )
 
 var _ protocol = &dnsProtocol{}
@@ -59,9 +60,7 @@ func parseRequest(inputURL string) (dnsRequest, error) {
 	}
 	req.dnsServer = qp.Get("server")
 	if req.dnsServer != "" {
-		if _, _, err := net.SplitHostPort(req.dnsServer); err != nil && strings.Contains(err.Error(), "missing port in address") {
-			req.dnsServer += ":53"
-		}
+		req.dnsServer = withDefaultDNSPort(req.dnsServer)
 	}
 	req.hostname = u.Host
 	req.query = qp.Get("query")
@@ -71,28 +70,39 @@ func parseRequest(inputURL string) (dnsRequest, error) {
 	return req, nil
 }
 
-func (c *dnsProtocol) makeRequest(ctx context.Context, rreq *request) (string, error) {
-	req, err := parseRequest(rreq.URL)
-	if err != nil {
-		return "", err
-	}
-	r := &net.Resolver{
+// newResolver returns a resolver querying the DNS server with the network, or the system DNS servers if not set.
+func newResolver(network, dnsServer string, timeout time.Duration) *net.Resolver {
+	return &net.Resolver{
 		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		Dial: func(ctx context.Context, nt, address string) (net.Conn, error) {
 			d := net.Dialer{
-				Timeout: rreq.Timeout,
+				Timeout: timeout,
 			}
-			nt := req.protocol
-			if nt == "" {
+			if network != "" {
 				nt = network
 			}
-			addr := req.dnsServer
-			if addr == "" {
-				addr = address
+			if dnsServer != "" {
+				address = dnsServer
 			}
-			return d.DialContext(ctx, nt, addr)
+			return d.DialContext(ctx, nt, address)
 		},
 	}
+}
+
+// withDefaultDNSPort adds the DNS port to the address of a DNS server, if it has no port.
+func withDefaultDNSPort(dnsServer string) string {
+	if _, _, err := net.SplitHostPort(dnsServer); err != nil && strings.Contains(err.Error(), "missing port in address") {
+		return dnsServer + ":53"
+	}
+	return dnsServer
+}
+
+func (c *dnsProtocol) makeRequest(ctx context.Context, rreq *request) (string, error) {
+	req, err := parseRequest(rreq.URL)
+	if err != nil {
+		return "", err
+	}
+	r := newResolver(req.protocol, req.dnsServer, rreq.Timeout)
 	nt := func() string {
 		switch req.query {
 		case "A":
@@ -23,7 +23,10 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	"istio.io/istio/pkg/test/echo"
+	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/echo/proto"
 )
 
@@ -56,10 +59,27 @@ func (c *grpcProtocol) makeRequest(ctx context.Context, req *request) (string, e
 	}
 	outBuffer.WriteString(fmt.Sprintf("[%d] grpcecho.Echo(%v)\n", req.RequestID, req))
 
-	resp, err := c.client.Echo(ctx, grpcReq)
-	if err != nil {
+	var header, trailer metadata.MD
+	resp, err := c.client.Echo(ctx, grpcReq, grpc.Header(&header), grpc.Trailer(&trailer))
+	st, ok := status.FromError(err)
+	if err != nil && (!ok || (header == nil && len(trailer) == 0)) {
+		// No response was received, e.g. the connection failed.
 		return "", err
 	}
+	// As the HTTP status codes, the non-OK statuses responded by the server or the proxies are recorded rather
+	// than failing the request, so that they can be checked.
+	if werr := writeGRPCStatus(&outBuffer, req.RequestID, st); werr != nil {
+		return "", werr
+	}
+	if err != nil {
+		// Trailers-only responses carry the content type in the trailers.
+		trailer.Delete("content-type")
+	}
+	writeGRPCMetadata(&outBuffer, req.RequestID, echo.ResponseHeaderField, header)
+	writeGRPCMetadata(&outBuffer, req.RequestID, echo.ResponseTrailerField, trailer)
+	if err != nil {
+		return outBuffer.String(), nil
+	}
 
 	// when the underlying HTTP2 request returns status 404, GRPC
 	// request does not return an error in grpc-go.
@@ -72,6 +92,33 @@ func (c *grpcProtocol) makeRequest(ctx context.Context, req *request) (string, e
 	return outBuffer.String(), nil
 }
 
+func writeGRPCStatus(out *bytes.Buffer, requestID int, st *status.Status) error {
+	out.WriteString(fmt.Sprintf("[%d] %s=%d\n", requestID, echo.GRPCStatusField, st.Code()))
+	if st.Message() != "" {
+		out.WriteString(fmt.Sprintf("[%d] %s=%s\n", requestID, echo.GRPCMessageField, strings.ReplaceAll(st.Message(), "\n", " ")))
+	}
+	if details := st.Proto().GetDetails(); len(details) > 0 {
+		encoded, err := common.EncodeGRPCDetails(details)
+		if err != nil {
+			return err
+		}
+		out.WriteString(fmt.Sprintf("[%d] %s=%s\n", requestID, echo.GRPCDetailsField, encoded))
+	}
+	return nil
+}
+
+func writeGRPCMetadata(out *bytes.Buffer, requestID int, field echo.Field, md metadata.MD) {
+	for key, values := range md {
+		if strings.HasSuffix(key, "-bin") {
+			// Skip binary metadata.
+			continue
+		}
+		for _, value := range values {
+			out.WriteString(fmt.Sprintf("[%d] %s=%s:%s\n", requestID, field, key, value))
+		}
+	}
+}
+
 func (c *grpcProtocol) Close() error {
 	return c.conn.Close()
 }
@@ -19,13 +19,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"golang.org/x/sync/semaphore"
+	protobuf "google.golang.org/protobuf/proto"
 	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 
+	"istio.io/istio/pkg/test/echo"
 	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/echo/proto"
 )
@@ -44,6 +48,8 @@ type Config struct {
 	XDSTestBootstrap []byte
 	// Http proxy used for connection
 	Proxy string
+	// ClientIdentities are the client identities preloaded by the echo server, by name.
+	ClientIdentities map[string]common.ClientIdentityFiles
 }
 
 func (c Config) fillInDefaults() Config {
@@ -54,7 +60,10 @@ func (c Config) fillInDefaults() Config {
 
 // Instance processes a single proto.ForwardEchoRequest, sending individual echo requests to the destination URL.
 type Instance struct {
-	p           protocol
+	// protocols are used in turn by the requests, one per client identity.
+	protocols []protocol
+	// identities are the names of the client identities reported in the responses, if any.
+	identities  []string
 	url         string
 	serverFirst bool
 	timeout     time.Duration
@@ -65,19 +74,22 @@ type Instance struct {
 	// Method for the request. Only valid for HTTP
 	method           string
 	expectedResponse *wrappers.StringValue
+
+	// resolvedAddresses are the addresses the host was resolved to, reported in the responses, if any.
+	resolvedAddresses []string
+	// load is set in load generation mode, where the protocols are the connections the requests are sent over.
+	load *proto.LoadGeneration
 }
 
 // New creates a new forwarder Instance.
 func New(cfg Config) (*Instance, error) {
 	cfg = cfg.fillInDefaults()
-
-	p, err := newProtocol(cfg)
+	cfg, resolvedAddresses, err := cfg.withDNSResolution()
 	if err != nil {
 		return nil, err
 	}
 
-	return &Instance{
-		p:                p,
+	i := &Instance{
 		url:              cfg.Request.Url,
 		serverFirst:      cfg.Request.ServerFirst,
 		method:           cfg.Request.Method,
@@ -87,11 +99,84 @@ func New(cfg Config) (*Instance, error) {
 		header:           common.GetHeaders(cfg.Request),
 		message:          cfg.Request.Message,
 		expectedResponse: cfg.Request.ExpectedResponse,
-	}, nil
+	}
+	i.resolvedAddresses = resolvedAddresses
+	if load := cfg.Request.LoadGeneration; load != nil {
+		return i.withLoadGeneration(cfg, load)
+	}
+	if len(cfg.Request.ClientIdentities) == 0 {
+		p, err := newProtocol(cfg)
+		if err != nil {
+			return nil, err
+		}
+		i.protocols = []protocol{p}
+		return i, nil
+	}
+	for index, identity := range cfg.Request.ClientIdentities {
+		identityCfg, name, err := cfg.withClientIdentity(index, identity)
+		if err == nil {
+			var p protocol
+			if p, err = newProtocol(identityCfg); err == nil {
+				i.protocols = append(i.protocols, p)
+				i.identities = append(i.identities, name)
+				continue
+			}
+		}
+		_ = i.Close()
+		return nil, fmt.Errorf("client identity %s: %v", name, err)
+	}
+	return i, nil
+}
+
+// withLoadGeneration sets up the forwarder in load generation mode, with a protocol per connection.
+func (i *Instance) withLoadGeneration(cfg Config, load *proto.LoadGeneration) (*Instance, error) {
+	if load.DurationMicros <= 0 {
+		return nil, fmt.Errorf("load generation duration must be positive")
+	}
+	if len(cfg.Request.ClientIdentities) > 0 {
+		return nil, fmt.Errorf("client identities are not supported in load generation mode")
+	}
+	i.load = load
+	connections := int(load.Connections)
+	if connections <= 0 {
+		connections = 1
+	}
+	for c := 0; c < connections; c++ {
+		p, err := newProtocol(cfg)
+		if err != nil {
+			_ = i.Close()
+			return nil, err
+		}
+		i.protocols = append(i.protocols, p)
+	}
+	return i, nil
+}
+
+// withClientIdentity returns the config making the requests with the client identity, and the name of the identity.
+func (c Config) withClientIdentity(index int, identity *proto.ClientIdentity) (Config, string, error) {
+	request := protobuf.Clone(c.Request).(*proto.ForwardEchoRequest)
+	request.ClientIdentities = nil
+	request.CertFile, request.KeyFile = "", ""
+	request.Cert, request.Key = identity.Cert, identity.Key
+	name := identity.Name
+	if name == "" {
+		name = strconv.Itoa(index)
+	} else if files, f := c.ClientIdentities[name]; f {
+		request.Cert, request.Key = "", ""
+		request.CertFile, request.KeyFile = files.CertFile, files.KeyFile
+	} else if identity.Cert == "" || identity.Key == "" {
+		return c, name, fmt.Errorf("not a preloaded identity, and no certificate and key set")
+	}
+	c.Request = request
+	return c, name, nil
 }
 
 // Run the forwarder and collect the responses.
 func (i *Instance) Run(ctx context.Context) (*proto.ForwardEchoResponse, error) {
+	if i.load != nil {
+		return i.runLoad(ctx)
+	}
+
 	g := multierror.Group{}
 	responsesMu := sync.RWMutex{}
 	responses, responseTimes := make([]string, i.count), make([]time.Duration, i.count)
@@ -114,6 +199,7 @@ func (i *Instance) Run(ctx context.Context) (*proto.ForwardEchoResponse, error)
 
 	sem := semaphore.NewWeighted(maxConcurrency)
 	for reqIndex := 0; reqIndex < i.count; reqIndex++ {
+		p := i.protocols[reqIndex%len(i.protocols)]
 		r := request{
 			RequestID:        reqIndex,
 			URL:              i.url,
@@ -139,11 +225,17 @@ func (i *Instance) Run(ctx context.Context) (*proto.ForwardEchoResponse, error)
 				return fmt.Errorf("request set timed out")
 			}
 			st := time.Now()
-			resp, err := i.p.makeRequest(ctx, &r)
+			resp, err := p.makeRequest(ctx, &r)
 			rt := time.Since(st)
 			if err != nil {
 				return err
 			}
+			if len(i.resolvedAddresses) > 0 {
+				resp += fmt.Sprintf("[%d] %s=%s\n", r.RequestID, echo.ResolvedAddressesField, strings.Join(i.resolvedAddresses, ","))
+			}
+			if len(i.identities) > 0 {
+				resp += fmt.Sprintf("[%d] %s=%s\n", r.RequestID, echo.ClientIdentityField, i.identities[r.RequestID%len(i.identities)])
+			}
 			responsesMu.Lock()
 			responses[r.RequestID] = resp
 			responseTimes[r.RequestID] = rt
@@ -186,8 +278,14 @@ func (i *Instance) Run(ctx context.Context) (*proto.ForwardEchoResponse, error)
 }
 
 func (i *Instance) Close() error {
-	if i != nil && i.p != nil {
-		return i.p.Close()
+	if i == nil {
+		return nil
+	}
+	var errs *multierror.Error
+	for _, p := range i.protocols {
+		if err := p.Close(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
 	}
-	return nil
+	return errs.ErrorOrNil()
 }
@@ -0,0 +1,228 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"istio.io/istio/pkg/test/echo"
+	"istio.io/istio/pkg/test/echo/proto"
+)
+
+var (
+	// loadPercentiles are the latency percentiles reported in load generation mode.
+	loadPercentiles = []float64{50, 75, 90, 99, 99.9}
+
+	// loadHistogramBounds are the bounds of the buckets of the latency histogram. The last bucket holds the latencies
+	// above the last bound.
+	loadHistogramBounds = []time.Duration{
+		0, 250 * time.Microsecond, 500 * time.Microsecond, time.Millisecond, 2500 * time.Microsecond,
+		5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+		100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second,
+		2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+	}
+
+	statusCodeRegex = regexp.MustCompile(string(echo.StatusCodeField) + "=(\\d+)")
+	grpcStatusRegex = regexp.MustCompile(string(echo.GRPCStatusField) + "=(\\d+)")
+)
+
+// loadRecorder records the outcome of the requests sent in load generation mode.
+type loadRecorder struct {
+	mu           sync.Mutex
+	latencies    []time.Duration
+	errorClasses map[string]int64
+}
+
+func (r *loadRecorder) record(resp string, err error, latency time.Duration) {
+	class := errorClass(resp, err)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if class != "" {
+		r.errorClasses[class]++
+		return
+	}
+	r.latencies = append(r.latencies, latency)
+}
+
+// errorClass returns the class of error of a request, or an empty string if it succeeded.
+func errorClass(resp string, err error) string {
+	if err == nil {
+		if match := grpcStatusRegex.FindStringSubmatch(resp); match != nil && match[1] != "0" {
+			return "grpc_status_" + match[1]
+		}
+		match := statusCodeRegex.FindStringSubmatch(resp)
+		if match == nil {
+			return ""
+		}
+		if code, _ := strconv.Atoi(match[1]); code >= 200 && code < 300 {
+			return ""
+		}
+		return "status_" + match[1]
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection_reset"
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "eof"
+	default:
+		return "other"
+	}
+}
+
+// runLoad sends the requests at the target QPS for the duration of the load generation, over the connections of
+// the load generation, and reports their statistics.
+func (i *Instance) runLoad(ctx context.Context) (*proto.ForwardEchoResponse, error) {
+	duration := time.Duration(i.load.DurationMicros) * time.Microsecond
+	// The timeout applies to each request, so the last requests may complete after the duration.
+	ctx, cancel := context.WithTimeout(ctx, duration+i.timeout)
+	defer cancel()
+
+	var throttle *time.Ticker
+	if i.qps > 0 {
+		throttle = time.NewTicker(time.Second / time.Duration(i.qps))
+		defer throttle.Stop()
+	}
+
+	recorder := &loadRecorder{errorClasses: map[string]int64{}}
+	var requestID int
+	var requestIDMu sync.Mutex
+	start := time.Now()
+	deadline := start.Add(duration)
+	wg := sync.WaitGroup{}
+	for _, p := range i.protocols {
+		p := p
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				if throttle != nil {
+					select {
+					case <-throttle.C:
+					case <-ctx.Done():
+						return
+					}
+					// The tick may have been waited for past the deadline.
+					if !time.Now().Before(deadline) {
+						return
+					}
+				}
+				requestIDMu.Lock()
+				r := request{
+					RequestID:        requestID,
+					URL:              i.url,
+					Message:          i.message,
+					ExpectedResponse: i.expectedResponse,
+					Header:           i.header,
+					Timeout:          i.timeout,
+					ServerFirst:      i.serverFirst,
+					Method:           i.method,
+				}
+				requestID++
+				requestIDMu.Unlock()
+
+				reqCtx, reqCancel := context.WithTimeout(ctx, i.timeout)
+				st := time.Now()
+				resp, err := p.makeRequest(reqCtx, &r)
+				recorder.record(resp, err, time.Since(st))
+				reqCancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := newLoadStatistics(recorder.latencies, recorder.errorClasses, time.Since(start))
+	fwLog.Debugf("Sent %d requests in %v, with %d errors", stats.Requests, time.Since(start), stats.Errors)
+	return &proto.ForwardEchoResponse{LoadStatistics: stats}, nil
+}
+
+// newLoadStatistics returns the statistics of the requests sent for the duration, from the latencies of the
+// successful requests and the number of failed requests by class of error.
+func newLoadStatistics(latencies []time.Duration, errorClasses map[string]int64, duration time.Duration) *proto.LoadStatistics {
+	stats := &proto.LoadStatistics{
+		Requests:       int64(len(latencies)),
+		DurationMicros: duration.Microseconds(),
+	}
+	classes := make([]string, 0, len(errorClasses))
+	for class, count := range errorClasses {
+		classes = append(classes, class)
+		stats.Errors += count
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		stats.ErrorClasses = append(stats.ErrorClasses, &proto.ErrorClass{Class: class, Count: errorClasses[class]})
+	}
+	stats.Requests += stats.Errors
+	if duration > 0 {
+		stats.Qps = float64(stats.Requests) / duration.Seconds()
+	}
+	if len(latencies) == 0 {
+		return stats
+	}
+
+	sort.Slice(latencies, func(a, b int) bool { return latencies[a] < latencies[b] })
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	stats.MinMicros = latencies[0].Microseconds()
+	stats.MaxMicros = latencies[len(latencies)-1].Microseconds()
+	stats.MeanMicros = (total / time.Duration(len(latencies))).Microseconds()
+	for _, p := range loadPercentiles {
+		// Nearest rank percentile.
+		rank := int(math.Ceil(p/100*float64(len(latencies)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		stats.Percentiles = append(stats.Percentiles, &proto.LatencyPercentile{
+			Percentile:    p,
+			LatencyMicros: latencies[rank].Microseconds(),
+		})
+	}
+
+	var bucket *proto.LatencyBucket
+	b := 0
+	for _, l := range latencies {
+		// Latencies are sorted, so the buckets are filled in order.
+		for b+1 < len(loadHistogramBounds) && l >= loadHistogramBounds[b+1] {
+			b++
+			bucket = nil
+		}
+		if bucket == nil {
+			end := latencies[len(latencies)-1] + time.Microsecond
+			if b+1 < len(loadHistogramBounds) {
+				end = loadHistogramBounds[b+1]
+			}
+			bucket = &proto.LatencyBucket{StartMicros: loadHistogramBounds[b].Microseconds(), EndMicros: end.Microseconds()}
+			stats.Histogram = append(stats.Histogram, bucket)
+		}
+		bucket.Count++
+	}
+	return stats
+}
@@ -0,0 +1,122 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"istio.io/istio/pkg/test/echo/proto"
+)
+
+func percentiles(micros ...int64) []*proto.LatencyPercentile {
+	out := make([]*proto.LatencyPercentile, 0, len(micros))
+	for i, m := range micros {
+		out = append(out, &proto.LatencyPercentile{Percentile: loadPercentiles[i], LatencyMicros: m})
+	}
+	return out
+}
+
+func TestNewLoadStatistics(t *testing.T) {
+	ms := time.Millisecond
+	cases := []struct {
+		name         string
+		latencies    []time.Duration
+		errorClasses map[string]int64
+		want         *proto.LoadStatistics
+	}{
+		{
+			name:         "only errors",
+			errorClasses: map[string]int64{"timeout": 1, "status_503": 2},
+			want: &proto.LoadStatistics{
+				Requests:       3,
+				Errors:         3,
+				DurationMicros: 1e6,
+				Qps:            3,
+				ErrorClasses: []*proto.ErrorClass{
+					{Class: "status_503", Count: 2},
+					{Class: "timeout", Count: 1},
+				},
+			},
+		},
+		{
+			name:      "single request",
+			latencies: []time.Duration{100 * time.Microsecond},
+			want: &proto.LoadStatistics{
+				Requests:       1,
+				DurationMicros: 1e6,
+				Qps:            1,
+				MinMicros:      100,
+				MeanMicros:     100,
+				MaxMicros:      100,
+				Percentiles:    percentiles(100, 100, 100, 100, 100),
+				Histogram:      []*proto.LatencyBucket{{StartMicros: 0, EndMicros: 250, Count: 1}},
+			},
+		},
+		{
+			name:         "unsorted latencies",
+			latencies:    []time.Duration{7 * ms, 3 * ms, 10 * ms, 1 * ms, 5 * ms, 9 * ms, 2 * ms, 8 * ms, 4 * ms, 6 * ms},
+			errorClasses: map[string]int64{"eof": 2},
+			want: &proto.LoadStatistics{
+				Requests:       12,
+				Errors:         2,
+				DurationMicros: 1e6,
+				Qps:            12,
+				MinMicros:      1000,
+				MeanMicros:     5500,
+				MaxMicros:      10000,
+				// Nearest rank: the 5th, 8th, 9th, 10th and 10th latencies.
+				Percentiles: percentiles(5000, 8000, 9000, 10000, 10000),
+				// Buckets start at their lower bound inclusive, and empty buckets are omitted.
+				Histogram: []*proto.LatencyBucket{
+					{StartMicros: 1000, EndMicros: 2500, Count: 2},
+					{StartMicros: 2500, EndMicros: 5000, Count: 2},
+					{StartMicros: 5000, EndMicros: 10000, Count: 5},
+					{StartMicros: 10000, EndMicros: 25000, Count: 1},
+				},
+				ErrorClasses: []*proto.ErrorClass{{Class: "eof", Count: 2}},
+			},
+		},
+		{
+			name:      "latency above the last bound",
+			latencies: []time.Duration{9 * time.Second, 20 * time.Second},
+			want: &proto.LoadStatistics{
+				Requests:       2,
+				DurationMicros: 1e6,
+				Qps:            2,
+				MinMicros:      9e6,
+				MeanMicros:     14500000,
+				MaxMicros:      20e6,
+				Percentiles:    percentiles(9e6, 20e6, 20e6, 20e6, 20e6),
+				// The last bucket ends just after the maximum latency.
+				Histogram: []*proto.LatencyBucket{
+					{StartMicros: 5e6, EndMicros: 10e6, Count: 1},
+					{StartMicros: 10e6, EndMicros: 20e6 + 1, Count: 1},
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newLoadStatistics(tt.latencies, tt.errorClasses, time.Second)
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Fatalf("unexpected statistics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	protobuf "google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/echo/proto"
+)
+
+// withDNSResolution resolves the host of the request URL with the DNS resolution of the request, if any. It returns
+// the config sending the requests to the first address resolved, and all the addresses resolved.
+func (c Config) withDNSResolution() (Config, []string, error) {
+	resolution := c.Request.GetDnsResolution()
+	if resolution == nil {
+		return c, nil, nil
+	}
+
+	// Do not use url.Parse() as it will fail to parse paths with invalid encoding that we intentionally used in the test.
+	rawURL := c.Request.Url
+	schemeEnd := strings.Index(rawURL, "://")
+	if schemeEnd < 0 {
+		return c, nil, fmt.Errorf("missing protocol scheme in the request URL: %s", rawURL)
+	}
+	switch s := scheme.Instance(strings.ToLower(rawURL[:schemeEnd])); s {
+	case scheme.DNS, scheme.XDS:
+		return c, nil, fmt.Errorf("DNS resolution is not supported with the %s scheme", s)
+	}
+	hostBegin := schemeEnd + len("://")
+	hostEnd := len(rawURL)
+	if i := strings.IndexAny(rawURL[hostBegin:], "/?"); i >= 0 {
+		hostEnd = hostBegin + i
+	}
+	hostPort := rawURL[hostBegin:hostEnd]
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host, port = strings.Trim(hostPort, "[]"), ""
+	}
+
+	addresses := resolution.Addresses
+	if len(addresses) == 0 {
+		var dnsServer string
+		if resolution.Server != "" {
+			dnsServer = withDefaultDNSPort(resolution.Server)
+		}
+		timeout := common.GetTimeout(c.Request)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if addresses, err = newResolver("", dnsServer, timeout).LookupHost(ctx, host); err != nil {
+			return c, nil, fmt.Errorf("failed to resolve %s: %v", host, err)
+		}
+	}
+	if len(addresses) == 0 {
+		return c, nil, fmt.Errorf("no address resolved for %s", host)
+	}
+
+	target := addresses[0]
+	if port != "" {
+		target = net.JoinHostPort(target, port)
+	} else if strings.Contains(target, ":") {
+		target = "[" + target + "]"
+	}
+	request := protobuf.Clone(c.Request).(*proto.ForwardEchoRequest)
+	request.Url = rawURL[:hostBegin] + target + rawURL[hostEnd:]
+	// Keep addressing the host, even though the requests are sent to the address.
+	if common.GetHeaders(request).Get(hostHeader) == "" {
+		request.Headers = append(request.Headers, &proto.Header{Key: hostHeader, Value: hostPort})
+	}
+	if request.ServerName == "" {
+		request.ServerName = host
+	}
+	c.Request = request
+	return c, addresses, nil
+}
@@ -51,6 +51,8 @@ type Config struct {
 	Dialer                common.Dialer
 	IstioVersion          string
 	DisableALPN           bool
+	// ClientIdentities are the client identities preloaded for the forwarded requests, by name.
+	ClientIdentities map[string]common.ClientIdentityFiles
 }
 
 func (c Config) String() string {
@@ -65,6 +67,7 @@ func (c Config) String() string {
 	b.WriteString(fmt.Sprintf("UDSServer:             %v\n", c.UDSServer))
 	b.WriteString(fmt.Sprintf("Cluster:               %v\n", c.Cluster))
 	b.WriteString(fmt.Sprintf("IstioVersion:          %v\n", c.IstioVersion))
+	b.WriteString(fmt.Sprintf("ClientIdentities:      %v\n", c.ClientIdentities))
 
 	return b.String()
 }
@@ -196,17 +199,18 @@ func (s *Instance) getListenerIP(port *common.Port) (string, error) {
 
 func (s *Instance) newEndpoint(port *common.Port, listenerIP string, udsServer string) (endpoint.Instance, error) {
 	return endpoint.New(endpoint.Config{
-		Port:          port,
-		UDSServer:     udsServer,
-		IsServerReady: s.isReady,
-		Version:       s.Version,
-		Cluster:       s.Cluster,
-		TLSCert:       s.TLSCert,
-		TLSKey:        s.TLSKey,
-		Dialer:        s.Dialer,
-		ListenerIP:    listenerIP,
-		DisableALPN:   s.DisableALPN,
-		IstioVersion:  s.IstioVersion,
+		Port:             port,
+		UDSServer:        udsServer,
+		IsServerReady:    s.isReady,
+		Version:          s.Version,
+		Cluster:          s.Cluster,
+		TLSCert:          s.TLSCert,
+		TLSKey:           s.TLSKey,
+		Dialer:           s.Dialer,
+		ListenerIP:       listenerIP,
+		DisableALPN:      s.DisableALPN,
+		IstioVersion:     s.IstioVersion,
+		ClientIdentities: s.ClientIdentities,
 	})
 }
 
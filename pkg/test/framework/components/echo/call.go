@@ -22,6 +22,7 @@ import (
 
 	"istio.io/istio/pkg/test/echo/check"
 	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/echo/proto"
 )
 
 // CallOptions defines options for calling a Endpoint.
@@ -80,6 +81,21 @@ type CallOptions struct {
 	// Use the custom certificates file to make the call.
 	CertFile, KeyFile, CaCertFile string
 
+	// ClientIdentities, if set, are used in turn by the requests instead of Cert and Key, so that a single call
+	// can test several identities. The identity of each response is reported in its ClientIdentity.
+	ClientIdentities []*proto.ClientIdentity
+
+	// DNSResolution, if set, overrides how the host is resolved, for example to query the DNS proxy of the
+	// sidecar or to use static addresses. The addresses resolved are reported in the ResolvedAddresses of each response.
+	DNSResolution *proto.DNSResolution
+
+	// QPS is the rate the requests are sent at. If QPS <= 0, they are sent as fast as possible.
+	QPS int
+
+	// LoadGeneration, if set, sends requests for its duration instead of Count requests, and returns a single
+	// response with the LoadStatistics of the requests. Timeout applies to each request.
+	LoadGeneration *proto.LoadGeneration
+
 	// Skip verify peer's certificate.
 	InsecureSkipVerify bool
 
@@ -69,6 +69,7 @@ func callInternal(srcName string, opts *echo.CallOptions, send sendFunc,
 	req := &proto.ForwardEchoRequest{
 		Url:                targetURL,
 		Count:              int32(opts.Count),
+		Qps:                int32(opts.QPS),
 		Headers:            protoHeaders,
 		TimeoutMicros:      common.DurationToMicros(opts.Timeout),
 		Message:            opts.Message,
@@ -83,6 +84,9 @@ func callInternal(srcName string, opts *echo.CallOptions, send sendFunc,
 		CertFile:           opts.CertFile,
 		KeyFile:            opts.KeyFile,
 		CaCertFile:         opts.CaCertFile,
+		ClientIdentities:   opts.ClientIdentities,
+		DnsResolution:      opts.DNSResolution,
+		LoadGeneration:     opts.LoadGeneration,
 		InsecureSkipVerify: opts.InsecureSkipVerify,
 		FollowRedirects:    opts.FollowRedirects,
 		ServerName:         opts.ServerName,
@@ -141,7 +145,11 @@ func CallEcho(opts *echo.CallOptions, retry bool, retryOptions ...retry.Option)
 			return nil, err
 		}
 		defer instance.Close()
-		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+		timeout := opts.Timeout
+		if load := opts.LoadGeneration; load != nil {
+			timeout += time.Duration(load.DurationMicros) * time.Microsecond
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 		ret, err := instance.Run(ctx)
 		if err != nil {
@@ -259,6 +267,11 @@ func fillInCallOptions(opts *echo.CallOptions) error {
 		opts.Count = common.DefaultCount
 	}
 
+	if opts.LoadGeneration != nil {
+		// The statistics of the requests are returned in a single response.
+		opts.Count = 1
+	}
+
 	// If no Check was specified, assume no error.
 	if opts.Check == nil {
 		opts.Check = check.None()
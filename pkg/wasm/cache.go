@@ -34,6 +34,8 @@ import (
 
 var wasmLog = log.RegisterScope("wasm", "", 0)
 
+var errWasmChecksumMismatch = errors.New("downloaded module's checksum does not match the expected one")
+
 const (
 	// DefaultWasmModulePurgeInterval is the default interval for periodic stale Wasm module clean up.
 	DefaultWasmModulePurgeInterval = 10 * time.Minute
@@ -53,6 +55,10 @@ type LocalFileCache struct {
 	// Map from Wasm module checksum to cache entry.
 	modules map[cacheKey]*cacheEntry
 
+	// Map from Wasm module download URL to the checksum its module is pinned to, when fetched without checksum.
+	pinnedChecksums map[string]string
+	pinChecksums    bool
+
 	// http fetcher fetches Wasm module with HTTP get.
 	httpFetcher *HTTPFetcher
 
@@ -88,10 +94,14 @@ type cacheEntry struct {
 }
 
 // NewLocalFileCache create a new Wasm module cache which downloads and stores Wasm module files locally.
-func NewLocalFileCache(dir string, purgeInterval, moduleExpiry time.Duration, insecureRegistries []string) *LocalFileCache {
+// If pinChecksums is set, a module fetched without checksum is pinned to the checksum of its first download,
+// and served from the cache until it expires instead of being fetched again.
+func NewLocalFileCache(dir string, purgeInterval, moduleExpiry time.Duration, insecureRegistries []string, pinChecksums bool) *LocalFileCache {
 	cache := &LocalFileCache{
 		httpFetcher:        NewHTTPFetcher(),
 		modules:            make(map[cacheKey]*cacheEntry),
+		pinnedChecksums:    make(map[string]string),
+		pinChecksums:       pinChecksums,
 		dir:                dir,
 		purgeInterval:      purgeInterval,
 		wasmModuleExpiry:   moduleExpiry,
@@ -111,11 +121,18 @@ func (c *LocalFileCache) Get(downloadURL, checksum string, timeout time.Duration
 		downloadURL: downloadURL,
 		checksum:    checksum,
 	}
+	if checksum == "" {
+		key.checksum = c.getPinnedChecksum(downloadURL)
+	}
 
 	// First check if the cache entry is already downloaded.
 	if modulePath := c.getEntry(key); modulePath != "" {
 		return modulePath, nil
 	}
+	// The module may also have been stored by a previous run of the agent.
+	if modulePath := c.loadEntry(key); modulePath != "" {
+		return modulePath, nil
+	}
 
 	// If not, fetch images.
 	u, err := url.Parse(downloadURL)
@@ -141,7 +158,7 @@ func (c *LocalFileCache) Get(downloadURL, checksum string, timeout time.Duration
 		dChecksum = hex.EncodeToString(sha[:])
 		if checksum != "" && dChecksum != checksum {
 			wasmRemoteFetchCount.With(resultTag.Value(checksumMismatch)).Increment()
-			return "", fmt.Errorf("module downloaded from %v has checksum %v, which does not match: %v", downloadURL, dChecksum, checksum)
+			return "", fmt.Errorf("%w: module downloaded from %v has checksum %v, but want %v", errWasmChecksumMismatch, downloadURL, dChecksum, checksum)
 		}
 	case "oci":
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
@@ -164,7 +181,7 @@ func (c *LocalFileCache) Get(downloadURL, checksum string, timeout time.Duration
 			} else {
 				wasmRemoteFetchCount.With(resultTag.Value(downloadFailure)).Increment()
 			}
-			return "", fmt.Errorf("could not fetch Wasm OCI image: %v", err)
+			return "", fmt.Errorf("could not fetch Wasm OCI image: %w", err)
 		}
 		sha := sha256.Sum256(b)
 		dChecksum = hex.EncodeToString(sha[:])
@@ -185,6 +202,9 @@ func (c *LocalFileCache) Get(downloadURL, checksum string, timeout time.Duration
 	if err := c.addEntry(key, b, f); err != nil {
 		return "", err
 	}
+	if checksum == "" && c.pinChecksums {
+		c.pinChecksum(downloadURL, dChecksum)
+	}
 	return f, nil
 }
 
@@ -218,6 +238,45 @@ func (c *LocalFileCache) addEntry(key cacheKey, wasmModule []byte, f string) err
 	return nil
 }
 
+// loadEntry adds the module file of the key stored in the cache directory, if its content matches the checksum.
+func (c *LocalFileCache) loadEntry(key cacheKey) string {
+	if key.checksum == "" {
+		return ""
+	}
+	f := filepath.Join(c.dir, fmt.Sprintf("%s.wasm", key.checksum))
+	b, err := os.ReadFile(f)
+	if err != nil {
+		return ""
+	}
+	sha := sha256.Sum256(b)
+	if hex.EncodeToString(sha[:]) != key.checksum || !isValidWasmBinary(b) {
+		wasmLog.Warnf("ignoring Wasm module %v, which does not match its checksum", f)
+		return ""
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if _, ok := c.modules[key]; !ok {
+		c.modules[key] = &cacheEntry{modulePath: f}
+		wasmCacheEntries.Record(float64(len(c.modules)))
+	}
+	c.modules[key].last = time.Now()
+	wasmLog.Debugf("loaded Wasm module %v of %v from the cache directory", f, key.downloadURL)
+	return f
+}
+
+func (c *LocalFileCache) getPinnedChecksum(downloadURL string) string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.pinnedChecksums[downloadURL]
+}
+
+func (c *LocalFileCache) pinChecksum(downloadURL, checksum string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.pinnedChecksums[downloadURL] = checksum
+}
+
 func (c *LocalFileCache) getEntry(key cacheKey) string {
 	modulePath := ""
 	cacheHit := false
@@ -248,6 +307,9 @@ func (c *LocalFileCache) purge() {
 						wasmLog.Errorf("failed to purge Wasm module %v: %v", m.modulePath, err)
 					} else {
 						delete(c.modules, k)
+						if c.pinnedChecksums[k.downloadURL] == k.checksum {
+							delete(c.pinnedChecksums, k.downloadURL)
+						}
 						wasmLog.Debugf("successfully removed stale Wasm module %v", m.modulePath)
 					}
 				}
@@ -261,6 +323,11 @@ func (c *LocalFileCache) purge() {
 	}
 }
 
+// isChecksumMismatch returns true if a fetched module did not match the checksum it was configured with.
+func isChecksumMismatch(err error) bool {
+	return errors.Is(err, errWasmChecksumMismatch) || errors.Is(err, errWasmOCIImageDigestMismatch)
+}
+
 // Expired returns true if the module has not been touched for Wasm module Expiry.
 func (ce *cacheEntry) expired(expiry time.Duration) bool {
 	now := time.Now()
@@ -15,6 +15,7 @@
 package wasm
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -82,6 +83,7 @@ func TestWasmCache(t *testing.T) {
 		requestTimeout       time.Duration
 		wantFileName         string
 		wantErrorMsgPrefix   string
+		wantChecksumMismatch bool
 		wantServerReqNum     int
 	}{
 		{
@@ -133,7 +135,8 @@ func TestWasmCache(t *testing.T) {
 			purgeInterval:        DefaultWasmModulePurgeInterval,
 			wasmModuleExpiry:     DefaultWasmModuleExpiry,
 			checksum:             "wrongchecksum\n",
-			wantErrorMsgPrefix:   fmt.Sprintf("module downloaded from %v has checksum %s, which does not match", ts.URL, httpDataCheckSum),
+			wantErrorMsgPrefix:   fmt.Sprintf("%v: module downloaded from %v has checksum %s, but want", errWasmChecksumMismatch, ts.URL, httpDataCheckSum),
+			wantChecksumMismatch: true,
 			wantServerReqNum:     1,
 		},
 		{
@@ -143,12 +146,13 @@ func TestWasmCache(t *testing.T) {
 			initialCachedModules: map[cacheKey]cacheEntry{
 				{downloadURL: ts.URL, checksum: httpDataCheckSum}: {modulePath: fmt.Sprintf("%s.wasm", httpDataCheckSum)},
 			},
-			fetchURL:           ts.URL + "/different-url",
-			purgeInterval:      DefaultWasmModulePurgeInterval,
-			wasmModuleExpiry:   DefaultWasmModuleExpiry,
-			checksum:           httpDataCheckSum,
-			wantErrorMsgPrefix: fmt.Sprintf("module downloaded from %v/different-url has checksum", ts.URL),
-			wantServerReqNum:   1,
+			fetchURL:             ts.URL + "/different-url",
+			purgeInterval:        DefaultWasmModulePurgeInterval,
+			wasmModuleExpiry:     DefaultWasmModuleExpiry,
+			checksum:             httpDataCheckSum,
+			wantErrorMsgPrefix:   fmt.Sprintf("%v: module downloaded from %v/different-url has checksum", errWasmChecksumMismatch, ts.URL),
+			wantChecksumMismatch: true,
+			wantServerReqNum:     1,
 		},
 		{
 			name: "invalid wasm header",
@@ -214,6 +218,7 @@ func TestWasmCache(t *testing.T) {
 			wantErrorMsgPrefix: fmt.Sprintf(
 				"could not fetch Wasm OCI image: fetched image's digest does not match the expected one: got %s, but want wrongdigest", dockerImageDigest,
 			),
+			wantChecksumMismatch: true,
 		},
 		{
 			name:                 "fetch invalid oci",
@@ -232,7 +237,7 @@ func TestWasmCache(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			tmpDir := t.TempDir()
-			cache := NewLocalFileCache(tmpDir, c.purgeInterval, c.wasmModuleExpiry, nil)
+			cache := NewLocalFileCache(tmpDir, c.purgeInterval, c.wasmModuleExpiry, nil, false)
 			defer close(cache.stopChan)
 			tsNumRequest = 0
 
@@ -282,6 +287,9 @@ func TestWasmCache(t *testing.T) {
 				} else if !strings.HasPrefix(gotErr.Error(), c.wantErrorMsgPrefix) {
 					t.Errorf("Wasm module cache lookup got error `%v`, want error prefix `%v`", gotErr, c.wantErrorMsgPrefix)
 				}
+				if isChecksumMismatch(gotErr) != c.wantChecksumMismatch {
+					t.Errorf("Wasm module cache lookup got checksum mismatch %v, want %v", isChecksumMismatch(gotErr), c.wantChecksumMismatch)
+				}
 			} else if gotFilePath != wantFilePath {
 				t.Errorf("Wasm module local file path got %v, want %v", gotFilePath, wantFilePath)
 				if gotErr != nil {
@@ -357,7 +365,7 @@ func setupOCIRegistry(t *testing.T, host string) (wantBinaryCheckSum, dockerImag
 
 func TestWasmCacheMissChecksum(t *testing.T) {
 	tmpDir := t.TempDir()
-	cache := NewLocalFileCache(tmpDir, DefaultWasmModulePurgeInterval, DefaultWasmModuleExpiry, nil)
+	cache := NewLocalFileCache(tmpDir, DefaultWasmModulePurgeInterval, DefaultWasmModuleExpiry, nil, false)
 	defer close(cache.stopChan)
 
 	gotNumRequest := 0
@@ -409,3 +417,97 @@ func TestWasmCacheMissChecksum(t *testing.T) {
 		t.Errorf("wasm download call got %v want %v", gotNumRequest, wantNumRequest)
 	}
 }
+
+func TestWasmCachePinChecksum(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := NewLocalFileCache(tmpDir, DefaultWasmModulePurgeInterval, DefaultWasmModuleExpiry, nil, true)
+	defer close(cache.stopChan)
+
+	gotNumRequest := 0
+	binary1 := append(wasmHeader, 1)
+	binary2 := append(wasmHeader, 2)
+	// Create a test server which returns 1 for the first call, and returns 2 for the following calls.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gotNumRequest == 0 {
+			w.Write(binary1)
+		} else {
+			w.Write(binary2)
+		}
+		gotNumRequest++
+	}))
+	defer ts.Close()
+	wantFilePath1 := filepath.Join(tmpDir, fmt.Sprintf("%x.wasm", sha256.Sum256(binary1)))
+	wantFilePath2 := filepath.Join(tmpDir, fmt.Sprintf("%x.wasm", sha256.Sum256(binary2)))
+
+	// The module fetched without checksum is pinned to its first download.
+	for i := 0; i < 2; i++ {
+		gotFilePath, err := cache.Get(ts.URL, "", 0)
+		if err != nil {
+			t.Fatalf("failed to download Wasm module: %v", err)
+		}
+		if gotFilePath != wantFilePath1 {
+			t.Errorf("wasm download path got %v want %v", gotFilePath, wantFilePath1)
+		}
+	}
+	if gotNumRequest != 1 {
+		t.Errorf("wasm download call got %v want 1", gotNumRequest)
+	}
+
+	// An explicit checksum bypasses the pinned one.
+	gotFilePath, err := cache.Get(ts.URL, fmt.Sprintf("%x", sha256.Sum256(binary2)), 0)
+	if err != nil {
+		t.Fatalf("failed to download Wasm module: %v", err)
+	}
+	if gotFilePath != wantFilePath2 {
+		t.Errorf("wasm download path got %v want %v", gotFilePath, wantFilePath2)
+	}
+	if gotNumRequest != 2 {
+		t.Errorf("wasm download call got %v want 2", gotNumRequest)
+	}
+}
+
+func TestWasmCacheLoadFromDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := NewLocalFileCache(tmpDir, DefaultWasmModulePurgeInterval, DefaultWasmModuleExpiry, nil, false)
+	defer close(cache.stopChan)
+
+	gotNumRequest := 0
+	binary := append(wasmHeader, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+		gotNumRequest++
+	}))
+	defer ts.Close()
+	checksum := fmt.Sprintf("%x", sha256.Sum256(binary))
+	wantFilePath := filepath.Join(tmpDir, checksum+".wasm")
+
+	// A module stored by a previous run is served without being fetched.
+	if err := os.WriteFile(wantFilePath, binary, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gotFilePath, err := cache.Get(ts.URL, checksum, 0)
+	if err != nil {
+		t.Fatalf("failed to get Wasm module: %v", err)
+	}
+	if gotFilePath != wantFilePath || gotNumRequest != 0 {
+		t.Errorf("got path %v after %v downloads, want %v after 0 downloads", gotFilePath, gotNumRequest, wantFilePath)
+	}
+
+	// A stored module which does not match its checksum is fetched again.
+	cache.mux.Lock()
+	cache.modules = map[cacheKey]*cacheEntry{}
+	cache.mux.Unlock()
+	if err := os.WriteFile(wantFilePath, append(wasmHeader, 2), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gotFilePath, err = cache.Get(ts.URL, checksum, 0)
+	if err != nil {
+		t.Fatalf("failed to download Wasm module: %v", err)
+	}
+	if gotFilePath != wantFilePath || gotNumRequest != 1 {
+		t.Errorf("got path %v after %v downloads, want %v after 1 download", gotFilePath, gotNumRequest, wantFilePath)
+	}
+	if b, _ := os.ReadFile(wantFilePath); !bytes.Equal(b, binary) {
+		t.Errorf("got module %v, want %v", b, binary)
+	}
+}
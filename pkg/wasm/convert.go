@@ -21,17 +21,39 @@ import (
 	udpa "github.com/cncf/xds/go/udpa/type/v1"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	wasmnetwork "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
+	wasmv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/wasm/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/conversion"
 	"go.uber.org/atomic"
+	"google.golang.org/protobuf/proto"
 	any "google.golang.org/protobuf/types/known/anypb"
 )
 
 const (
-	apiTypePrefix      = "type.googleapis.com/"
-	typedStructType    = apiTypePrefix + "udpa.type.v1.TypedStruct"
-	wasmHTTPFilterType = apiTypePrefix + "envoy.extensions.filters.http.wasm.v3.Wasm"
+	apiTypePrefix         = "type.googleapis.com/"
+	typedStructType       = apiTypePrefix + "udpa.type.v1.TypedStruct"
+	wasmHTTPFilterType    = apiTypePrefix + "envoy.extensions.filters.http.wasm.v3.Wasm"
+	wasmNetworkFilterType = apiTypePrefix + "envoy.extensions.filters.network.wasm.v3.Wasm"
 )
 
+// wasmFilter is a Wasm HTTP or network filter.
+type wasmFilter interface {
+	proto.Message
+	GetConfig() *wasmv3.PluginConfig
+}
+
+// newWasmFilter returns an empty Wasm filter of the type, or nil if the type is not a Wasm filter.
+func newWasmFilter(typeURL string) wasmFilter {
+	switch typeURL {
+	case wasmHTTPFilterType:
+		return &wasm.Wasm{}
+	case wasmNetworkFilterType:
+		return &wasmnetwork.Wasm{}
+	default:
+		return nil
+	}
+}
+
 // MaybeConvertWasmExtensionConfig converts any presence of module remote download to local file.
 // It downloads the Wasm module and stores the module locally in the file system.
 func MaybeConvertWasmExtensionConfig(resources []*any.Any, cache Cache) bool {
@@ -76,13 +98,13 @@ func convert(resource *any.Any, cache Cache) (newExtensionConfig *any.Any, sendN
 		return
 	}
 
-	wasmHTTPFilterConfig := &wasm.Wasm{}
+	var wasmFilterConfig wasmFilter
 	// Wasm filter can be configured using typed struct and Wasm filter type
 	wasmLog.Debugf("original extension config resource %+v", ec)
-	if ec.GetTypedConfig() != nil && ec.GetTypedConfig().TypeUrl == wasmHTTPFilterType {
-		err := ec.GetTypedConfig().UnmarshalTo(wasmHTTPFilterConfig)
+	if wasmFilterConfig = newWasmFilter(ec.GetTypedConfig().GetTypeUrl()); wasmFilterConfig != nil {
+		err := ec.GetTypedConfig().UnmarshalTo(wasmFilterConfig)
 		if err != nil {
-			wasmLog.Debugf("failed to unmarshal extension config resource into Wasm HTTP filter: %v", err)
+			wasmLog.Debugf("failed to unmarshal extension config resource into Wasm filter: %v", err)
 			return
 		}
 	} else if ec.GetTypedConfig() == nil || ec.GetTypedConfig().TypeUrl != typedStructType {
@@ -96,29 +118,29 @@ func convert(resource *any.Any, cache Cache) (newExtensionConfig *any.Any, sendN
 			return
 		}
 
-		if wasmStruct.TypeUrl != wasmHTTPFilterType {
-			wasmLog.Debugf("typed extension config %+v does not contain wasm http filter", wasmStruct)
+		if wasmFilterConfig = newWasmFilter(wasmStruct.TypeUrl); wasmFilterConfig == nil {
+			wasmLog.Debugf("typed extension config %+v does not contain wasm filter", wasmStruct)
 			return
 		}
 
-		if err := conversion.StructToMessage(wasmStruct.Value, wasmHTTPFilterConfig); err != nil {
-			wasmLog.Debugf("failed to convert extension config struct %+v to Wasm HTTP filter", wasmStruct)
+		if err := conversion.StructToMessage(wasmStruct.Value, wasmFilterConfig); err != nil {
+			wasmLog.Debugf("failed to convert extension config struct %+v to Wasm filter", wasmStruct)
 			return
 		}
 	}
 
-	if wasmHTTPFilterConfig.Config.GetVmConfig().GetCode().GetRemote() == nil {
-		wasmLog.Debugf("no remote load found in Wasm HTTP filter %+v", wasmHTTPFilterConfig)
+	if wasmFilterConfig.GetConfig().GetVmConfig().GetCode().GetRemote() == nil {
+		wasmLog.Debugf("no remote load found in Wasm filter %+v", wasmFilterConfig)
 		return
 	}
 
 	// Wasm plugin configuration has remote load. From this point, any failure should result as a Nack,
 	// unless the plugin is marked as fail open.
-	failOpen := wasmHTTPFilterConfig.Config.GetFailOpen()
+	failOpen := wasmFilterConfig.GetConfig().GetFailOpen()
 	sendNack = !failOpen
 	status = conversionSuccess
 
-	vm := wasmHTTPFilterConfig.Config.GetVmConfig()
+	vm := wasmFilterConfig.GetConfig().GetVmConfig()
 	remote := vm.GetCode().GetRemote()
 	httpURI := remote.GetHttpUri()
 	if httpURI == nil {
@@ -138,6 +160,11 @@ func convert(resource *any.Any, cache Cache) (newExtensionConfig *any.Any, sendN
 	if err != nil {
 		status = fetchFailure
 		wasmLog.Errorf("cannot fetch Wasm module %v: %v", remote.GetHttpUri().GetUri(), err)
+		// A module not matching its configured checksum may have been tampered with, so it is rejected
+		// even if the plugin fails open.
+		if isChecksumMismatch(err) {
+			sendNack = true
+		}
 		return
 	}
 
@@ -152,10 +179,10 @@ func convert(resource *any.Any, cache Cache) (newExtensionConfig *any.Any, sendN
 		},
 	}
 
-	wasmTypedConfig, err := any.New(wasmHTTPFilterConfig)
+	wasmTypedConfig, err := any.New(wasmFilterConfig)
 	if err != nil {
 		status = marshalFailure
-		wasmLog.Errorf("failed to marshal new wasm HTTP filter %+v to protobuf Any: %v", wasmHTTPFilterConfig, err)
+		wasmLog.Errorf("failed to marshal new wasm filter %+v to protobuf Any: %v", wasmFilterConfig, err)
 		return
 	}
 	ec.TypedConfig = wasmTypedConfig
@@ -23,6 +23,7 @@ import (
 	udpa "github.com/cncf/xds/go/udpa/type/v1"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	wasmnetwork "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
 	v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/wasm/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/conversion"
 	"google.golang.org/protobuf/proto"
@@ -41,7 +42,11 @@ func (c *mockCache) Get(downloadURL, checksum string, timeout time.Duration) (st
 	module := query.Get("module")
 	errMsg := query.Get("error")
 	var err error
-	if errMsg != "" {
+	switch errMsg {
+	case "":
+	case "checksum-mismatch":
+		err = errWasmChecksumMismatch
+	default:
 		err = errors.New(errMsg)
 	}
 
@@ -66,6 +71,16 @@ func TestWasmConvert(t *testing.T) {
 			},
 			wantNack: false,
 		},
+		{
+			name: "remote load success network filter",
+			input: []*core.TypedExtensionConfig{
+				extensionConfigMap["remote-load-success-network"],
+			},
+			wantOutput: []*core.TypedExtensionConfig{
+				extensionConfigMap["remote-load-success-network-local-file"],
+			},
+			wantNack: false,
+		},
 		{
 			name: "remote load fail",
 			input: []*core.TypedExtensionConfig{
@@ -98,6 +113,16 @@ func TestWasmConvert(t *testing.T) {
 			},
 			wantNack: false,
 		},
+		{
+			name: "remote load checksum mismatch fail open",
+			input: []*core.TypedExtensionConfig{
+				extensionConfigMap["remote-load-checksum-mismatch-fail-open"],
+			},
+			wantOutput: []*core.TypedExtensionConfig{
+				extensionConfigMap["remote-load-checksum-mismatch-fail-open"],
+			},
+			wantNack: true,
+		},
 		{
 			name: "no typed struct",
 			input: []*core.TypedExtensionConfig{
@@ -257,6 +282,42 @@ var extensionConfigMap = map[string]*core.TypedExtensionConfig{
 			},
 		},
 	}),
+	"remote-load-success-network": {
+		Name: "remote-load-success-network",
+		TypedConfig: util.MessageToAny(&wasmnetwork.Wasm{
+			Config: &v3.PluginConfig{
+				Vm: &v3.PluginConfig_VmConfig{
+					VmConfig: &v3.VmConfig{
+						Code: &core.AsyncDataSource{Specifier: &core.AsyncDataSource_Remote{
+							Remote: &core.RemoteDataSource{
+								HttpUri: &core.HttpUri{
+									Uri: "http://test?module=test.wasm",
+								},
+							},
+						}},
+					},
+				},
+			},
+		}),
+	},
+	"remote-load-success-network-local-file": {
+		Name: "remote-load-success-network",
+		TypedConfig: util.MessageToAny(&wasmnetwork.Wasm{
+			Config: &v3.PluginConfig{
+				Vm: &v3.PluginConfig_VmConfig{
+					VmConfig: &v3.VmConfig{
+						Code: &core.AsyncDataSource{Specifier: &core.AsyncDataSource_Local{
+							Local: &core.DataSource{
+								Specifier: &core.DataSource_Filename{
+									Filename: "test.wasm",
+								},
+							},
+						}},
+					},
+				},
+			},
+		}),
+	},
 	"remote-load-fail": buildTypedStructExtensionConfig("remote-load-fail", &wasm.Wasm{
 		Config: &v3.PluginConfig{
 			Vm: &v3.PluginConfig_VmConfig{
@@ -288,4 +349,21 @@ var extensionConfigMap = map[string]*core.TypedExtensionConfig{
 			FailOpen: true,
 		},
 	}),
+	"remote-load-checksum-mismatch-fail-open": buildTypedStructExtensionConfig("remote-load-checksum-mismatch", &wasm.Wasm{
+		Config: &v3.PluginConfig{
+			Vm: &v3.PluginConfig_VmConfig{
+				VmConfig: &v3.VmConfig{
+					Code: &core.AsyncDataSource{Specifier: &core.AsyncDataSource_Remote{
+						Remote: &core.RemoteDataSource{
+							HttpUri: &core.HttpUri{
+								Uri: "http://test?module=test.wasm&error=checksum-mismatch",
+							},
+							Sha256: "checksum",
+						},
+					}},
+				},
+			},
+			FailOpen: true,
+		},
+	}),
 }
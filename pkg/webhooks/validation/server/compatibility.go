@@ -0,0 +1,195 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+// compatibilityRule is a feature of the config that the revisions older than a minor version do not support.
+type compatibilityRule struct {
+	// kind the rule applies to, or any kind if unset.
+	kind config.GroupVersionKind
+	// feature is reported in the warnings, e.g. the path of a field.
+	feature string
+	// since is the first minor version supporting the feature, e.g. "1.14". It is left empty for the features
+	// introduced by the release under development, which are only supported by the revisions of the same minor
+	// version as this istiod, and set to that release once it is branched.
+	since string
+	// uses returns true if the config uses the feature.
+	uses func(cfg config.Config) bool
+}
+
+var compatibilityRules = []compatibilityRule{
+	{
+		kind:    gvk.Telemetry,
+		feature: "Telemetry",
+		since:   "1.12",
+		uses:    func(config.Config) bool { return true },
+	},
+	{
+		kind:    gvk.WasmPlugin,
+		feature: "WasmPlugin",
+		since:   "1.12",
+		uses:    func(config.Config) bool { return true },
+	},
+	{
+		kind:    gvk.ProxyConfig,
+		feature: "ProxyConfig",
+		since:   "1.13",
+		uses:    func(config.Config) bool { return true },
+	},
+	{
+		kind:    gvk.DestinationRule,
+		feature: "trafficPolicy.loadBalancer.warmupDurationSecs",
+		since:   "1.14",
+		uses:    usesWarmupDuration,
+	},
+	{
+		feature: "experimental.istio.io annotations",
+		uses: func(cfg config.Config) bool {
+			for k := range cfg.Annotations {
+				if strings.HasPrefix(k, "experimental.istio.io/") {
+					return true
+				}
+			}
+			return false
+		},
+	},
+}
+
+func usesWarmupDuration(cfg config.Config) bool {
+	dr, ok := cfg.Spec.(*networking.DestinationRule)
+	if !ok {
+		return false
+	}
+	policies := []*networking.TrafficPolicy{dr.TrafficPolicy}
+	for _, ss := range dr.Subsets {
+		policies = append(policies, ss.TrafficPolicy)
+	}
+	for _, p := range policies {
+		if p.GetLoadBalancer().GetWarmupDurationSecs() != nil {
+			return true
+		}
+		for _, pp := range p.GetPortLevelSettings() {
+			if pp.GetLoadBalancer().GetWarmupDurationSecs() != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compatibilityChecker warns about the configs using features that older revisions in use do not support, so that a
+// canary upgrade does not silently make the data planes of the revisions diverge.
+type compatibilityChecker struct {
+	revision string
+	version  *goversion.Version
+	// since are the first minor versions supporting the features of the compatibilityRules.
+	since []*goversion.Version
+	// versions returns the versions of the revisions in use, by revision, empty if unknown.
+	versions func() map[string]string
+}
+
+func newCompatibilityChecker(revision, version string, versions func() map[string]string) *compatibilityChecker {
+	v, err := minorVersion(version)
+	if err != nil {
+		scope.Warnf("not checking the compatibility of the configs with the other revisions: invalid version %q: %v", version, err)
+		return nil
+	}
+	c := &compatibilityChecker{revision: revision, version: v, versions: versions}
+	for _, rule := range compatibilityRules {
+		since := v
+		if rule.since != "" {
+			since = goversion.Must(goversion.NewVersion(rule.since))
+		}
+		c.since = append(c.since, since)
+	}
+	return c
+}
+
+// check returns the warnings for the features of the config that older revisions do not support. Only the newest
+// revision checks the configs. The revisions of unknown version predate the registration of the versions, so they
+// are assumed not to support any of the features.
+func (c *compatibilityChecker) check(cfg config.Config) []string {
+	// older are the versions of the older revisions, nil if unknown.
+	older := map[string]*goversion.Version{}
+	for revision, version := range c.versions() {
+		if revision == c.revision {
+			continue
+		}
+		if version == "" {
+			older[revision] = nil
+			continue
+		}
+		v, err := minorVersion(version)
+		if err != nil {
+			continue
+		}
+		if v.GreaterThan(c.version) {
+			return nil
+		}
+		if v.LessThan(c.version) {
+			older[revision] = v
+		}
+	}
+	if len(older) == 0 {
+		return nil
+	}
+	revisions := make([]string, 0, len(older))
+	for revision := range older {
+		revisions = append(revisions, revision)
+	}
+	sort.Strings(revisions)
+
+	var warnings []string
+	for i, rule := range compatibilityRules {
+		if (rule.kind != config.GroupVersionKind{} && rule.kind != cfg.GroupVersionKind) || !rule.uses(cfg) {
+			continue
+		}
+		since := c.since[i]
+		var unsupported []string
+		for _, revision := range revisions {
+			if v := older[revision]; v == nil {
+				unsupported = append(unsupported, fmt.Sprintf("%s (unknown version)", revision))
+			} else if v.LessThan(since) {
+				unsupported = append(unsupported, fmt.Sprintf("%s (%s)", revision, v.Original()))
+			}
+		}
+		if len(unsupported) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s requires Istio %s or later, and is not supported by revisions %s",
+				rule.feature, since.Original(), strings.Join(unsupported, ", ")))
+		}
+	}
+	return warnings
+}
+
+// minorVersion parses the major and minor version of an Istio version, such as 1.14 for 1.14.2 or 1.14-dev.
+func minorVersion(version string) (*goversion.Version, error) {
+	v, err := goversion.NewVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	segments := v.Segments()
+	return goversion.NewVersion(fmt.Sprintf("%d.%d", segments[0], segments[1]))
+}
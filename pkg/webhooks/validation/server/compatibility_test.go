@@ -0,0 +1,119 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+func TestCompatibilityChecker(t *testing.T) {
+	warmup := config.Config{
+		Meta: config.Meta{GroupVersionKind: gvk.DestinationRule, Name: "reviews", Namespace: "default"},
+		Spec: &networking.DestinationRule{
+			Host: "reviews",
+			Subsets: []*networking.Subset{{
+				Name: "v1",
+				TrafficPolicy: &networking.TrafficPolicy{
+					LoadBalancer: &networking.LoadBalancerSettings{WarmupDurationSecs: &types.Duration{Seconds: 10}},
+				},
+			}},
+		},
+	}
+	annotated := config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: gvk.VirtualService,
+			Name:             "reviews",
+			Namespace:        "default",
+			Annotations:      map[string]string{"experimental.istio.io/idle-timeout": `{"*": "30s"}`},
+		},
+		Spec: &networking.VirtualService{Hosts: []string{"reviews"}},
+	}
+	plain := config.Config{
+		Meta: config.Meta{GroupVersionKind: gvk.DestinationRule, Name: "ratings", Namespace: "default"},
+		Spec: &networking.DestinationRule{Host: "ratings"},
+	}
+
+	cases := []struct {
+		name     string
+		versions map[string]string
+		cfg      config.Config
+		want     []string
+	}{
+		{
+			name:     "older revision",
+			versions: map[string]string{"canary": "1.14.0", "1-13": "1.13.4", "1-12": "1.12.1"},
+			cfg:      warmup,
+			want: []string{"trafficPolicy.loadBalancer.warmupDurationSecs requires Istio 1.14 or later, " +
+				"and is not supported by revisions 1-12 (1.12), 1-13 (1.13)"},
+		},
+		{
+			name:     "annotations",
+			versions: map[string]string{"canary": "1.14.0", "1-13": "1.13.4"},
+			cfg:      annotated,
+			want:     []string{"experimental.istio.io annotations requires Istio 1.14 or later, and is not supported by revisions 1-13 (1.13)"},
+		},
+		{
+			name:     "supported feature",
+			versions: map[string]string{"canary": "1.14.0", "1-13": "1.13.4"},
+			cfg:      plain,
+		},
+		{
+			name:     "same version",
+			versions: map[string]string{"canary": "1.14.0", "default": "1.14.2"},
+			cfg:      warmup,
+		},
+		{
+			name:     "not the newest revision",
+			versions: map[string]string{"canary": "1.14.0", "1-13": "1.13.4", "1-15": "1.15.0"},
+			cfg:      warmup,
+		},
+		{
+			name:     "unknown version",
+			versions: map[string]string{"canary": "1.14.0", "1-11": ""},
+			cfg:      annotated,
+			want: []string{"experimental.istio.io annotations requires Istio 1.14 or later, " +
+				"and is not supported by revisions 1-11 (unknown version)"},
+		},
+		{
+			name:     "unknown version without the feature",
+			versions: map[string]string{"canary": "1.14.0", "1-11": ""},
+			cfg:      plain,
+		},
+		{
+			name:     "invalid version",
+			versions: map[string]string{"canary": "1.14.0", "old": "unknown"},
+			cfg:      warmup,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCompatibilityChecker("canary", "1.14-dev", func() map[string]string { return tt.versions })
+			if got := c.check(tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if c := newCompatibilityChecker("canary", "unknown", nil); c != nil {
+		t.Fatalf("expected no checker for an invalid version")
+	}
+}
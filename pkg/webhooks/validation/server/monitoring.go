@@ -101,4 +101,5 @@ const (
 	reasonUnknownType          = "unknown_type"
 	reasonCRDConversionError   = "crd_conversion_error"
 	reasonInvalidConfig        = "invalid_resource"
+	reasonAnalysisDenied       = "analysis_denied"
 )
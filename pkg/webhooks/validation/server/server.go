@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	multierror "github.com/hashicorp/go-multierror"
 	kubeApiAdmissionv1 "k8s.io/api/admission/v1"
@@ -31,12 +32,15 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 
 	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pkg/config/analysis/admission"
+	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/config/schema/resource"
 	"istio.io/istio/pkg/config/validation"
 	"istio.io/istio/pkg/kube"
 	"istio.io/pkg/log"
+	istioversion "istio.io/pkg/version"
 )
 
 var scope = log.RegisterScope("validationServer", "validation webhook server", 0)
@@ -79,6 +83,16 @@ type Options struct {
 
 	// Use an existing mux instead of creating our own.
 	Mux *http.ServeMux
+
+	// Analysis, if set, runs the config analyzers on the valid configs, to warn about or deny them.
+	Analysis *admission.Admission
+
+	// Revision of istiod.
+	Revision string
+
+	// RevisionVersions, if set, returns the versions of the revisions in use, by revision. The newest revision then
+	// warns about the configs using features that the older revisions do not support.
+	RevisionVersions func() map[string]string
 }
 
 // String produces a stringified version of the arguments for debugging.
@@ -101,8 +115,10 @@ func DefaultArgs() Options {
 // Webhook implements the validating admission webhook for validating Istio configuration.
 type Webhook struct {
 	// pilot
-	schemas      collection.Schemas
-	domainSuffix string
+	schemas       collection.Schemas
+	domainSuffix  string
+	analysis      *admission.Admission
+	compatibility *compatibilityChecker
 }
 
 // New creates a new instance of the admission webhook server.
@@ -114,6 +130,10 @@ func New(o Options) (*Webhook, error) {
 	wh := &Webhook{
 		schemas:      o.Schemas,
 		domainSuffix: o.DomainSuffix,
+		analysis:     o.Analysis,
+	}
+	if o.RevisionVersions != nil {
+		wh.compatibility = newCompatibilityChecker(o.Revision, istioversion.Info.Version, o.RevisionVersions)
 	}
 
 	o.Mux.HandleFunc("/validate", wh.serveValidate)
@@ -244,8 +264,34 @@ func (wh *Webhook) validate(request *kube.AdmissionRequest) *kube.AdmissionRespo
 		return toAdmissionResponse(err)
 	}
 
+	kubeWarnings := toKubeWarnings(warnings)
+	if wh.analysis != nil {
+		res := wh.analysis.Analyze(*out)
+		if len(res.Denied) > 0 {
+			msgs := analysisMessages(res.Denied)
+			scope.Infof("configuration is denied by analysis: %s", msgs)
+			reportValidationFailed(request, reasonAnalysisDenied)
+			return toAdmissionResponse(fmt.Errorf("configuration is denied by analysis: %s", msgs))
+		}
+		for _, m := range res.Warnings {
+			kubeWarnings = append(kubeWarnings, m.String())
+		}
+	}
+
+	if wh.compatibility != nil {
+		kubeWarnings = append(kubeWarnings, wh.compatibility.check(*out)...)
+	}
+
 	reportValidationPass(request)
-	return &kube.AdmissionResponse{Allowed: true, Warnings: toKubeWarnings(warnings)}
+	return &kube.AdmissionResponse{Allowed: true, Warnings: kubeWarnings}
+}
+
+func analysisMessages(msgs diag.Messages) string {
+	out := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, m.String())
+	}
+	return strings.Join(out, "; ")
 }
 
 func toKubeWarnings(warn validation.Warning) []string {
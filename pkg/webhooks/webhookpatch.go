@@ -32,10 +32,12 @@ import (
 
 	"istio.io/api/label"
 	"istio.io/istio/pilot/pkg/keycertbundle"
+	"istio.io/istio/pkg/config/constants"
 	kubelib "istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/kube/controllers"
 	"istio.io/istio/pkg/webhooks/util"
 	"istio.io/pkg/log"
+	"istio.io/pkg/version"
 )
 
 var (
@@ -51,6 +53,8 @@ type WebhookCertPatcher struct {
 	// revision to patch webhooks for
 	revision    string
 	webhookName string
+	// version of istiod, registered on the webhooks of the revision.
+	version string
 
 	queue controllers.Queue
 
@@ -69,6 +73,7 @@ func NewWebhookCertPatcher(
 		client:          client,
 		revision:        revision,
 		webhookName:     webhookName,
+		version:         version.Info.Version,
 		CABundleWatcher: caBundleWatcher,
 	}
 	p.queue = controllers.NewQueue("webhook patcher",
@@ -115,7 +120,8 @@ func (w *WebhookCertPatcher) webhookPatchTask(o types.NamespacedName) error {
 	return err
 }
 
-// patchMutatingWebhookConfig takes a webhookConfigName and patches the CA bundle for that webhook configuration
+// patchMutatingWebhookConfig takes a webhookConfigName and patches the CA bundle for that webhook configuration,
+// and registers the version of istiod on it.
 func (w *WebhookCertPatcher) patchMutatingWebhookConfig(
 	client admissionregistrationv1client.MutatingWebhookConfigurationInterface,
 	webhookConfigName string) error {
@@ -124,7 +130,7 @@ func (w *WebhookCertPatcher) patchMutatingWebhookConfig(
 		reportWebhookPatchFailure(webhookConfigName, reasonWebhookConfigNotFound)
 		return errNotFound
 	}
-	config := raw.(*v1.MutatingWebhookConfiguration)
+	config := raw.(*v1.MutatingWebhookConfiguration).DeepCopy()
 	// prevents a race condition between multiple istiods when the revision is changed or modified
 	v, ok := config.Labels[label.IoIstioRev.Name]
 	if v != w.revision || !ok {
@@ -153,6 +159,13 @@ func (w *WebhookCertPatcher) patchMutatingWebhookConfig(
 		reportWebhookPatchFailure(webhookConfigName, reasonWebhookEntryNotFound)
 		return errNoWebhookWithName
 	}
+	if config.Annotations[constants.RevisionVersionAnnotation] != w.version {
+		if config.Annotations == nil {
+			config.Annotations = map[string]string{}
+		}
+		config.Annotations[constants.RevisionVersionAnnotation] = w.version
+		updated = true
+	}
 
 	if updated {
 		_, err = client.Update(context.Background(), config, metav1.UpdateOptions{})
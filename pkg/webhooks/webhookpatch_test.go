@@ -25,8 +25,10 @@ import (
 
 	"istio.io/api/label"
 	"istio.io/istio/pilot/pkg/keycertbundle"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/pkg/version"
 )
 
 var caBundle0 = []byte(`-----BEGIN CERTIFICATE-----
@@ -255,6 +257,9 @@ func TestMutatingWebhookPatch(t *testing.T) {
 				if err != nil {
 					t.Fatal(err)
 				}
+				if got := obj.Annotations[constants.RevisionVersionAnnotation]; got != version.Info.Version {
+					t.Fatalf("Incorrect revision version: expect %s got %s", version.Info.Version, got)
+				}
 				for _, w := range obj.Webhooks {
 					if strings.HasSuffix(w.Name, tc.webhookName) {
 						if !bytes.Equal(w.ClientConfig.CABundle, tc.pemData) {
@@ -19,11 +19,13 @@ import (
 )
 
 const (
-	errorlabel = "error"
+	errorlabel  = "error"
+	resultlabel = "result"
 )
 
 var (
-	errorTag = monitoring.MustCreateLabel(errorlabel)
+	errorTag  = monitoring.MustCreateLabel(errorlabel)
+	resultTag = monitoring.MustCreateLabel(resultlabel)
 
 	csrCounts = monitoring.NewSum(
 		"citadel_server_csr_count",
@@ -51,6 +53,12 @@ var (
 		monitoring.WithLabels(errorTag),
 	)
 
+	impersonationCounts = monitoring.NewSum(
+		"citadel_server_impersonation_count",
+		"The number of CSRs where the requested identity differs from the authenticated identity.",
+		monitoring.WithLabels(resultTag),
+	)
+
 	successCounts = monitoring.NewSum(
 		"citadel_server_success_cert_issuance_count",
 		"The number of certificates issuances that have succeeded.",
@@ -75,6 +83,7 @@ func init() {
 		csrParsingErrorCounts,
 		idExtractionErrorCounts,
 		certSignErrorCounts,
+		impersonationCounts,
 		successCounts,
 		rootCertExpiryTimestamp,
 		certChainExpiryTimestamp,
@@ -89,6 +98,7 @@ type monitoringMetrics struct {
 	CSRError          monitoring.Metric
 	IDExtractionError monitoring.Metric
 	certSignErrors    monitoring.Metric
+	impersonations    monitoring.Metric
 }
 
 // newMonitoringMetrics creates a new monitoringMetrics.
@@ -100,9 +110,14 @@ func newMonitoringMetrics() monitoringMetrics {
 		CSRError:          csrParsingErrorCounts,
 		IDExtractionError: idExtractionErrorCounts,
 		certSignErrors:    certSignErrorCounts,
+		impersonations:    impersonationCounts,
 	}
 }
 
 func (m *monitoringMetrics) GetCertSignError(err string) monitoring.Metric {
 	return m.certSignErrors.With(errorTag.Value(err))
 }
+
+func (m *monitoringMetrics) GetImpersonation(result string) monitoring.Metric {
+	return m.impersonations.With(resultTag.Value(result))
+}
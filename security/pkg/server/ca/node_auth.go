@@ -0,0 +1,158 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/spiffe"
+)
+
+const wildcard = "*"
+
+// serviceAccount identifies a Kubernetes service account. Either field may be the wildcard "*".
+type serviceAccount struct {
+	namespace string
+	name      string
+}
+
+func (sa serviceAccount) matches(id spiffe.Identity) bool {
+	return (sa.namespace == wildcard || sa.namespace == id.Namespace) &&
+		(sa.name == wildcard || sa.name == id.ServiceAccount)
+}
+
+func (sa serviceAccount) String() string {
+	return sa.namespace + "/" + sa.name
+}
+
+func parseServiceAccount(s string) (serviceAccount, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return serviceAccount{}, fmt.Errorf("invalid service account %q, expected namespace/name", s)
+	}
+	return serviceAccount{namespace: parts[0], name: parts[1]}, nil
+}
+
+// NodeAuthorizer decides whether a node-level caller (for example an agent serving
+// host network or agent-less workloads) may request a certificate for an identity
+// other than its own.
+type NodeAuthorizer struct {
+	// trustedNodeAccounts maps a node account to the identities it may request.
+	// An empty list means the node account may request any identity.
+	trustedNodeAccounts map[serviceAccount][]serviceAccount
+	// audit logs every CSR where the requested identity differs from the caller identity.
+	audit bool
+	// trustDomains returns the trust domain of the mesh and its aliases, the only trust domains
+	// of the identities which may be requested.
+	trustDomains func() []string
+}
+
+// NewNodeAuthorizer builds a NodeAuthorizer from a policy string. The policy is a comma
+// separated list of node accounts in the form namespace/serviceaccount, each optionally
+// followed by ':' and a '|' separated list of namespace/serviceaccount patterns it may
+// request certificates for. Either part of a pattern may be "*".
+// For example: "istio-system/ztunnel,kube-system/node-agent:app/*|db/postgres".
+// trustDomains returns the trust domain of the mesh and its aliases; requests for identities
+// of other trust domains are denied.
+func NewNodeAuthorizer(policy string, audit bool, trustDomains func() []string) (*NodeAuthorizer, error) {
+	na := &NodeAuthorizer{
+		trustedNodeAccounts: map[serviceAccount][]serviceAccount{},
+		audit:               audit,
+		trustDomains:        trustDomains,
+	}
+	for _, entry := range strings.Split(policy, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		nodeAccount, err := parseServiceAccount(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		if nodeAccount.namespace == wildcard || nodeAccount.name == wildcard {
+			return nil, fmt.Errorf("node account %v must not contain wildcards", nodeAccount)
+		}
+		allowed := []serviceAccount{}
+		if len(parts) == 2 && parts[1] != "" {
+			for _, t := range strings.Split(parts[1], "|") {
+				target, err := parseServiceAccount(t)
+				if err != nil {
+					return nil, err
+				}
+				allowed = append(allowed, target)
+			}
+		}
+		na.trustedNodeAccounts[nodeAccount] = append(na.trustedNodeAccounts[nodeAccount], allowed...)
+	}
+	return na, nil
+}
+
+// authorize checks whether caller may request a certificate for the requested identity.
+func (na *NodeAuthorizer) authorize(caller *security.Caller, requested string) error {
+	err := na.authorizeIdentity(caller, requested)
+	if na.audit {
+		if err != nil {
+			serverCaLog.Infof("impersonation audit: %v requested certificate for %v: denied: %v",
+				caller.Identities, requested, err)
+		} else {
+			serverCaLog.Infof("impersonation audit: %v requested certificate for %v: allowed",
+				caller.Identities, requested)
+		}
+	}
+	return err
+}
+
+func (na *NodeAuthorizer) authorizeIdentity(caller *security.Caller, requested string) error {
+	target, err := spiffe.ParseIdentity(requested)
+	if err != nil {
+		return fmt.Errorf("failed to parse requested identity: %v", err)
+	}
+	if !na.trustedDomain(target.TrustDomain) {
+		return fmt.Errorf("requested identity %v is not in the trust domains %v", requested, na.trustDomains())
+	}
+	for _, id := range caller.Identities {
+		callerID, err := spiffe.ParseIdentity(id)
+		if err != nil {
+			continue
+		}
+		allowed, f := na.trustedNodeAccounts[serviceAccount{namespace: callerID.Namespace, name: callerID.ServiceAccount}]
+		if !f {
+			continue
+		}
+		if len(allowed) == 0 {
+			return nil
+		}
+		for _, sa := range allowed {
+			if sa.matches(target) {
+				return nil
+			}
+		}
+		return fmt.Errorf("node account %v/%v is not allowed to request identity %v",
+			callerID.Namespace, callerID.ServiceAccount, requested)
+	}
+	return fmt.Errorf("caller %v is not a trusted node account", caller.Identities)
+}
+
+func (na *NodeAuthorizer) trustedDomain(td string) bool {
+	for _, t := range na.trustDomains() {
+		if t == td {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/security"
+)
+
+func testTrustDomains() []string {
+	return []string{"cluster.local", "old-td"}
+}
+
+func TestNewNodeAuthorizer(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{name: "empty", policy: ""},
+		{name: "single", policy: "kube-system/node-agent"},
+		{name: "restricted", policy: "kube-system/node-agent:app/*|db/postgres, istio-system/ztunnel"},
+		{name: "missing name", policy: "kube-system", wantErr: true},
+		{name: "wildcard node", policy: "kube-system/*", wantErr: true},
+		{name: "bad target", policy: "kube-system/node-agent:app", wantErr: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewNodeAuthorizer(tt.policy, false, testTrustDomains)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNodeAuthorizerAuthorize(t *testing.T) {
+	authorizer, err := NewNodeAuthorizer("kube-system/node-agent:app/*|db/postgres,istio-system/ztunnel", false, testTrustDomains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name      string
+		caller    string
+		requested string
+		allowed   bool
+	}{
+		{"unrestricted node", "spiffe://cluster.local/ns/istio-system/sa/ztunnel", "spiffe://cluster.local/ns/any/sa/any", true},
+		{"namespace wildcard", "spiffe://cluster.local/ns/kube-system/sa/node-agent", "spiffe://cluster.local/ns/app/sa/foo", true},
+		{"exact match", "spiffe://cluster.local/ns/kube-system/sa/node-agent", "spiffe://cluster.local/ns/db/sa/postgres", true},
+		{"not allowed", "spiffe://cluster.local/ns/kube-system/sa/node-agent", "spiffe://cluster.local/ns/db/sa/mysql", false},
+		{"untrusted caller", "spiffe://cluster.local/ns/app/sa/foo", "spiffe://cluster.local/ns/app/sa/bar", false},
+		{"invalid requested identity", "spiffe://cluster.local/ns/istio-system/sa/ztunnel", "not-spiffe", false},
+		{"trust domain alias", "spiffe://cluster.local/ns/istio-system/sa/ztunnel", "spiffe://old-td/ns/any/sa/any", true},
+		{"foreign trust domain", "spiffe://cluster.local/ns/istio-system/sa/ztunnel", "spiffe://other-mesh/ns/any/sa/any", false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.authorize(&security.Caller{Identities: []string{tt.caller}}, tt.requested)
+			if (err == nil) != tt.allowed {
+				t.Fatalf("expected allowed=%v, got error %v", tt.allowed, err)
+			}
+		})
+	}
+}
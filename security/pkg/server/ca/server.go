@@ -49,6 +49,9 @@ type CertificateAuthority interface {
 type Server struct {
 	monitoring     monitoringMetrics
 	Authenticators []security.Authenticator
+	// NodeAuthorizer authorizes requests for an identity other than the caller's own.
+	// If nil, such requests are rejected.
+	NodeAuthorizer *NodeAuthorizer
 	ca             CertificateAuthority
 	serverCertTTL  time.Duration
 }
@@ -76,13 +79,20 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 		s.monitoring.AuthnError.Increment()
 		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
 	}
-	// TODO: Call authorizer.
 	crMetadata := request.Metadata.GetFields()
+	sans := caller.Identities
+	if impersonated := crMetadata[security.ImpersonatedIdentity].GetStringValue(); impersonated != "" &&
+		!containsIdentity(caller.Identities, impersonated) {
+		if err := s.authorizeImpersonation(caller, impersonated); err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		sans = []string{impersonated}
+	}
 	certSigner := crMetadata[security.CertSigner].GetStringValue()
 	log.Debugf("cert signer from workload %s", certSigner)
 	_, _, certChainBytes, rootCertBytes := s.ca.GetCAKeyCertBundle().GetAll()
 	certOpts := ca.CertOpts{
-		SubjectIDs: caller.Identities,
+		SubjectIDs: sans,
 		TTL:        time.Duration(request.ValidityDuration) * time.Second,
 		ForCA:      false,
 		CertSigner: certSigner,
@@ -117,6 +127,30 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	return response, nil
 }
 
+// authorizeImpersonation checks whether caller may request a certificate for the impersonated identity.
+func (s *Server) authorizeImpersonation(caller *security.Caller, impersonated string) error {
+	if s.NodeAuthorizer == nil {
+		s.monitoring.GetImpersonation("denied").Increment()
+		return fmt.Errorf("caller %v is not authorized to request identity %v", caller.Identities, impersonated)
+	}
+	if err := s.NodeAuthorizer.authorize(caller, impersonated); err != nil {
+		s.monitoring.GetImpersonation("denied").Increment()
+		serverCaLog.Warnf("rejected impersonation request: %v", err)
+		return err
+	}
+	s.monitoring.GetImpersonation("allowed").Increment()
+	return nil
+}
+
+func containsIdentity(identities []string, id string) bool {
+	for _, i := range identities {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
 func recordCertsExpiry(keyCertBundle *util.KeyCertBundle) {
 	rootCertExpiry, err := keyCertBundle.ExtractRootCertExpiryTimestamp()
 	if err != nil {
@@ -22,8 +22,10 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"reflect"
 	"testing"
 
+	"github.com/gogo/protobuf/types"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
@@ -263,3 +265,70 @@ func TestCreateCertificate(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateCertificateImpersonation(t *testing.T) {
+	nodeID := "spiffe://cluster.local/ns/kube-system/sa/node-agent"
+	testCases := map[string]struct {
+		policy       string
+		impersonated string
+		code         codes.Code
+		receivedIDs  []string
+	}{
+		"No impersonation": {
+			code:        codes.OK,
+			receivedIDs: []string{nodeID},
+		},
+		"Own identity": {
+			impersonated: nodeID,
+			code:         codes.OK,
+			receivedIDs:  []string{nodeID},
+		},
+		"Untrusted node": {
+			impersonated: "spiffe://cluster.local/ns/app/sa/default",
+			code:         codes.PermissionDenied,
+		},
+		"Trusted node": {
+			policy:       "kube-system/node-agent",
+			impersonated: "spiffe://cluster.local/ns/app/sa/default",
+			code:         codes.OK,
+			receivedIDs:  []string{"spiffe://cluster.local/ns/app/sa/default"},
+		},
+		"Trusted node restricted": {
+			policy:       "kube-system/node-agent:app/*",
+			impersonated: "spiffe://cluster.local/ns/db/sa/default",
+			code:         codes.PermissionDenied,
+		},
+	}
+
+	for id, c := range testCases {
+		t.Run(id, func(t *testing.T) {
+			authorizer, err := NewNodeAuthorizer(c.policy, true, testTrustDomains)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fakeCA := &mockca.FakeCA{
+				SignedCert:    []byte("cert"),
+				KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte("cert_chain"), []byte("root_cert")),
+			}
+			server := &Server{
+				ca:             fakeCA,
+				Authenticators: []security.Authenticator{&mockAuthenticator{identities: []string{nodeID}}},
+				NodeAuthorizer: authorizer,
+				monitoring:     newMonitoringMetrics(),
+			}
+			request := &pb.IstioCertificateRequest{Csr: "dumb CSR", Metadata: &types.Struct{
+				Fields: map[string]*types.Value{
+					security.ImpersonatedIdentity: {Kind: &types.Value_StringValue{StringValue: c.impersonated}},
+				},
+			}}
+			_, err = server.CreateCertificate(context.Background(), request)
+			s, _ := status.FromError(err)
+			if s.Code() != c.code {
+				t.Fatalf("expecting code to be (%d) but got (%d): %s", c.code, s.Code(), s.Message())
+			}
+			if c.code == codes.OK && !reflect.DeepEqual(fakeCA.ReceivedIDs, c.receivedIDs) {
+				t.Errorf("expected identities %v, got %v", c.receivedIDs, fakeCA.ReceivedIDs)
+			}
+		})
+	}
+}
@@ -40,6 +40,7 @@ var protocols = []protocol.Instance{
 	protocol.Mongo,
 	protocol.Redis,
 	protocol.MySQL,
+	protocol.Kafka,
 }
 
 // Creates a new fuzzed ServiceInstance
@@ -23,6 +23,8 @@ import (
 	"sort"
 	"strings"
 
+	"google.golang.org/grpc/codes"
+
 	echoClient "istio.io/istio/pkg/test/echo"
 	"istio.io/istio/pkg/test/echo/check"
 	"istio.io/istio/pkg/test/echo/common/scheme"
@@ -39,7 +41,9 @@ func ReachedClusters(to echo.Instances, opts *echo.CallOptions) check.Checker {
 
 func RBACFailure(opts *echo.CallOptions) check.Checker {
 	if opts.PortName == "grpc" {
-		return check.ErrorContains("rpc error: code = PermissionDenied desc = RBAC: access denied")
+		return check.And(
+			check.NoError(),
+			check.GRPCStatus(codes.PermissionDenied))
 	}
 
 	if strings.HasPrefix(opts.PortName, "tcp") {
@@ -65,6 +65,12 @@ func removeOldChains(cfg *config.Config, ext dep.Dependencies, cmd string) {
 			cfg.DNSServersV4, cfg.DNSServersV6, cfg.CaptureAllDNS, ownerGroupsFilter)
 	}
 
+	// Remove the outbound UDP port rules
+	if cfg.OutboundUDPPorts != "" {
+		common.HandleOutboundUDPPorts(common.DeleteOps, builder.NewIptablesBuilder(nil), ext, cmd, cfg.ProxyUID, cfg.ProxyGID,
+			cfg.OutboundUDPPorts, redirectDNS)
+	}
+
 	// Flush and delete the istio chains from NAT table.
 	chains := []string{constants.ISTIOOUTPUT, constants.ISTIOINBOUND}
 	flushAndDeleteChains(ext, cmd, constants.NAT, chains)
@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"bytes"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -59,6 +60,12 @@ func TestIptables(t *testing.T) {
 				cfg.ProxyUID = "3,4"
 			},
 		},
+		{
+			"outbound-udp-ports",
+			func(cfg *config.Config) {
+				cfg.OutboundUDPPorts = "514,5353"
+			},
+		},
 		{
 			"outbound-owner-groups",
 			func(cfg *config.Config) {
@@ -127,6 +134,11 @@ func (s *DependenciesStub) RunQuietlyAndIgnore(cmd string, args ...string) {
 	s.execute(true /*quietly*/, cmd, args...)
 }
 
+func (s *DependenciesStub) RunWithOutput(cmd string, args ...string) (*bytes.Buffer, error) {
+	s.execute(true /*quietly*/, cmd, args...)
+	return &bytes.Buffer{}, nil
+}
+
 func (s *DependenciesStub) execute(quietly bool, cmd string, args ...string) {
 	cmdline := strings.Join(append([]string{cmd}, args...), " ")
 	s.ExecutedAll = append(s.ExecutedAll, cmdline)
@@ -64,6 +64,7 @@ func constructConfig() *config.Config {
 		CaptureAllDNS:      viper.GetBool(constants.CaptureAllDNS),
 		OwnerGroupsInclude: viper.GetString(constants.OwnerGroupsInclude.Name),
 		OwnerGroupsExclude: viper.GetString(constants.OwnerGroupsExclude.Name),
+		OutboundUDPPorts:   viper.GetString(constants.OutboundUDPPorts),
 	}
 
 	// TODO: Make this more configurable, maybe with an allowlist of users to be captured for output instead of a denylist.
@@ -133,6 +134,11 @@ func bindFlags(cmd *cobra.Command, args []string) {
 		handleError(err)
 	}
 	viper.SetDefault(constants.OwnerGroupsExclude.Name, constants.OwnerGroupsExclude.DefaultValue)
+
+	if err := viper.BindPFlag(constants.OutboundUDPPorts, cmd.Flags().Lookup(constants.OutboundUDPPorts)); err != nil {
+		handleError(err)
+	}
+	viper.SetDefault(constants.OutboundUDPPorts, "")
 }
 
 // https://github.com/spf13/viper/issues/233.
@@ -148,6 +154,9 @@ func init() {
 		"Specify the GID of the user for which the redirection is not applied. (same default value as -u param)")
 
 	rootCmd.Flags().Bool(constants.RedirectDNS, dnsCaptureByAgent, "Enable capture of dns traffic by istio-agent")
+
+	rootCmd.Flags().String(constants.OutboundUDPPorts, "",
+		"Comma separated list of outbound UDP ports to be redirected to the same port of Envoy on localhost")
 }
 
 func GetCommand() *cobra.Command {
@@ -34,6 +34,7 @@ type Config struct {
 	CaptureAllDNS      bool     `json:"CAPTURE_ALL_DNS"`
 	OwnerGroupsInclude string   `json:"OUTBOUND_OWNER_GROUPS_INCLUDE"`
 	OwnerGroupsExclude string   `json:"OUTBOUND_OWNER_GROUPS_EXCLUDE"`
+	OutboundUDPPorts   string   `json:"OUTBOUND_UDP_PORTS"`
 }
 
 func (c *Config) String() string {
@@ -54,6 +55,7 @@ func (c *Config) Print() {
 	fmt.Printf("DNS_SERVERS=%s,%s\n", c.DNSServersV4, c.DNSServersV6)
 	fmt.Printf("OUTBOUND_OWNER_GROUPS_INCLUDE=%s\n", c.OwnerGroupsInclude)
 	fmt.Printf("OUTBOUND_OWNER_GROUPS_EXCLUDE=%s\n", c.OwnerGroupsExclude)
+	fmt.Printf("OUTBOUND_UDP_PORTS=%s\n", c.OutboundUDPPorts)
 	fmt.Println("")
 }
 
@@ -224,6 +224,104 @@ func (rb *IptablesBuilder) BuildV6Restore() string {
 	return rb.buildRestore(rb.rules.rulesv6)
 }
 
+// Chain is a chain created by the builder, with the number of rules it holds.
+type Chain struct {
+	Table string
+	Name  string
+	Rules int
+}
+
+// spec returns the rule specification of a rule, without its append or insert command.
+func (r *Rule) spec() []string {
+	switch r.params[0] {
+	case "-A":
+		return r.params[2:]
+	case "-I":
+		return r.params[3:]
+	}
+	return r.params
+}
+
+func (rb *IptablesBuilder) buildCheck(command string, rules []*Rule) [][]string {
+	output := [][]string{}
+	for _, r := range rules {
+		output = append(output, append([]string{command, "-t", r.table, "-C", r.chain}, r.spec()...))
+	}
+	return output
+}
+
+// BuildCheckV4 returns the commands checking that each of the IPv4 rules is in place.
+func (rb *IptablesBuilder) BuildCheckV4() [][]string {
+	return rb.buildCheck(constants.IPTABLES, rb.rules.rulesv4)
+}
+
+// BuildCheckV6 returns the commands checking that each of the IPv6 rules is in place.
+func (rb *IptablesBuilder) BuildCheckV6() [][]string {
+	return rb.buildCheck(constants.IP6TABLES, rb.rules.rulesv6)
+}
+
+func (rb *IptablesBuilder) buildChains(rules []*Rule) []Chain {
+	chains := []Chain{}
+	chainIndex := map[string]int{}
+	for _, r := range rules {
+		if _, present := constants.BuiltInChainsMap[r.chain]; present {
+			continue
+		}
+		chainTable := fmt.Sprintf("%s:%s", r.chain, r.table)
+		idx, present := chainIndex[chainTable]
+		if !present {
+			idx = len(chains)
+			chainIndex[chainTable] = idx
+			chains = append(chains, Chain{Table: r.table, Name: r.chain})
+		}
+		chains[idx].Rules++
+	}
+	return chains
+}
+
+// ChainsV4 returns the IPv4 chains created by the builder, in the order they are created.
+func (rb *IptablesBuilder) ChainsV4() []Chain {
+	return rb.buildChains(rb.rules.rulesv4)
+}
+
+// ChainsV6 returns the IPv6 chains created by the builder, in the order they are created.
+func (rb *IptablesBuilder) ChainsV6() []Chain {
+	return rb.buildChains(rb.rules.rulesv6)
+}
+
+// buildRepair returns the iptables-restore --noflush input repairing the rules in place: the chains created by the
+// builder are declared, which flushes them, and written again, while the rules of the built-in chains are only added
+// if they are not in place. As iptables-restore commits each table atomically, the traffic is redirected at all times.
+func (rb *IptablesBuilder) buildRepair(command string, rules []*Rule, inPlace func(check []string) bool) string {
+	tableRulesMap := map[string][]string{
+		constants.FILTER: {},
+		constants.NAT:    {},
+		constants.MANGLE: {},
+	}
+	for _, c := range rb.buildChains(rules) {
+		tableRulesMap[c.Table] = append(tableRulesMap[c.Table], fmt.Sprintf(":%s - [0:0]", c.Name))
+	}
+	for _, r := range rules {
+		if _, present := constants.BuiltInChainsMap[r.chain]; present &&
+			inPlace(append([]string{command, "-t", r.table, "-C", r.chain}, r.spec()...)) {
+			continue
+		}
+		tableRulesMap[r.table] = append(tableRulesMap[r.table], strings.Join(r.params, " "))
+	}
+	return rb.constructIptablesRestoreContents(tableRulesMap)
+}
+
+// BuildRepairV4 returns the iptables-restore --noflush input repairing the IPv4 rules. inPlace is called with the
+// check command of each rule of a built-in chain, as returned by BuildCheckV4.
+func (rb *IptablesBuilder) BuildRepairV4(inPlace func(check []string) bool) string {
+	return rb.buildRepair(constants.IPTABLES, rb.rules.rulesv4, inPlace)
+}
+
+// BuildRepairV6 returns the ip6tables-restore --noflush input repairing the IPv6 rules.
+func (rb *IptablesBuilder) BuildRepairV6(inPlace func(check []string) bool) string {
+	return rb.buildRepair(constants.IP6TABLES, rb.rules.rulesv6, inPlace)
+}
+
 // AppendVersionedRule is a wrapper around AppendRule that substitutes an ipv4/ipv6 specific value
 // in place in the params. This allows appending a dual-stack rule that has an IP value in it.
 func (rb *IptablesBuilder) AppendVersionedRule(ipv4 string, ipv6 string, command log.Command, chain string, table string, params ...string) {
@@ -16,6 +16,7 @@ package builder
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"istio.io/istio/tools/istio-iptables/pkg/config"
@@ -312,3 +313,52 @@ func TestBuildV4V6MultipleRulesWithNewChain(t *testing.T) {
 		t.Errorf("Actual and expected output mismatch; but instead got Actual: %#v ; Expected: %#v", actualV6, expectedV6)
 	}
 }
+
+func TestBuildV4CheckAndRepair(t *testing.T) {
+	iptables := NewIptablesBuilder(nil)
+	iptables.AppendRuleV4(iptableslog.UndefinedCommand, constants.OUTPUT, "nat", "-p", "tcp", "-j", "chain")
+	iptables.AppendRuleV4(iptableslog.UndefinedCommand, "chain", "nat", "-f", "foo", "-b", "bar")
+	iptables.InsertRuleV4(iptableslog.UndefinedCommand, "chain", "nat", 1, "-b", "baz")
+	iptables.AppendRuleV4(iptableslog.UndefinedCommand, "other", "nat", "-f", "foo")
+
+	actual := iptables.BuildCheckV4()
+	expected := [][]string{
+		{"iptables", "-t", "nat", "-C", constants.OUTPUT, "-p", "tcp", "-j", "chain"},
+		{"iptables", "-t", "nat", "-C", "chain", "-f", "foo", "-b", "bar"},
+		{"iptables", "-t", "nat", "-C", "chain", "-b", "baz"},
+		{"iptables", "-t", "nat", "-C", "other", "-f", "foo"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Actual and expected check commands mismatch; Actual: %#v ; Expected: %#v", actual, expected)
+	}
+
+	chains := iptables.ChainsV4()
+	expectedChains := []Chain{{Table: "nat", Name: "chain", Rules: 2}, {Table: "nat", Name: "other", Rules: 1}}
+	if !reflect.DeepEqual(chains, expectedChains) {
+		t.Errorf("Actual and expected chains mismatch; Actual: %#v ; Expected: %#v", chains, expectedChains)
+	}
+
+	inPlace := func(check []string) bool {
+		return reflect.DeepEqual(check, expected[0])
+	}
+	repair := iptables.BuildRepairV4(inPlace)
+	expectedRepair := `* nat
+:chain - [0:0]
+:other - [0:0]
+-A chain -f foo -b bar
+-I chain 1 -b baz
+-A other -f foo
+COMMIT
+`
+	if repair != expectedRepair {
+		t.Errorf("Actual and expected repair mismatch; Actual: %q ; Expected: %q", repair, expectedRepair)
+	}
+	// The missing rules of the built-in chains are added.
+	repair = iptables.BuildRepairV4(func([]string) bool { return false })
+	if !strings.Contains(repair, "-A OUTPUT -p tcp -j chain\n") {
+		t.Errorf("Expected the missing rule of the OUTPUT chain to be added; Actual: %q", repair)
+	}
+	if actual := iptables.BuildRepairV6(inPlace); actual != "" {
+		t.Errorf("Expected V6 repair to be empty; but instead got Actual: %q", actual)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"istio.io/pkg/monitoring"
+)
+
+var (
+	typeLabel      = monitoring.MustCreateLabel("type")
+	missingType    = "missing"
+	unexpectedType = "unexpected"
+
+	resultLabel   = monitoring.MustCreateLabel("result")
+	resultSuccess = "success"
+	resultFail    = "fail"
+
+	driftDetected = monitoring.NewSum(
+		"istio_iptables_drift_detected_total",
+		"Total number of times the iptables rules were found to have drifted from the expected rules, "+
+			"by rules missing or unexpected rules in the Istio chains",
+		monitoring.WithLabels(typeLabel),
+	)
+
+	driftRepaired = monitoring.NewSum(
+		"istio_iptables_drift_repaired_total",
+		"Total number of attempts to repair drifted iptables rules",
+		monitoring.WithLabels(resultLabel),
+	)
+)
+
+func init() {
+	monitoring.MustRegister(driftDetected, driftRepaired)
+}
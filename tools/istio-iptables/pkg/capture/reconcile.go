@@ -0,0 +1,172 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"istio.io/istio/tools/istio-iptables/pkg/builder"
+	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	"istio.io/pkg/log"
+)
+
+// drift is the difference between the expected rules and the rules in place.
+type drift struct {
+	// present is the number of expected rules in place.
+	present int
+	// missing is the number of expected rules not in place.
+	missing int
+	// unexpected is the number of rules in the chains created by istio-iptables which are not expected.
+	unexpected int
+}
+
+func (d drift) drifted() bool {
+	return d.missing > 0 || d.unexpected > 0
+}
+
+// Reconcile applies the rules idempotently, as opposed to Run which applies them unconditionally: the rules are
+// applied if none are in place yet, left untouched if they are all in place, and repaired otherwise, as they
+// drifted, e.g. because a node agent or another CNI plugin removed or added some of them. It returns whether
+// drift was detected.
+func (cfg *IptablesConfigurator) Reconcile() bool {
+	if cfg.cfg.DryRun {
+		// Nothing is in place in a dry run, so the rules are only printed.
+		cfg.Run()
+		return false
+	}
+	cfg.build()
+	d := cfg.detectDrift()
+	if !d.drifted() {
+		cfg.applied = true
+		return false
+	}
+	if !cfg.applied && d.present == 0 && d.unexpected == 0 {
+		log.Info("No iptables rules in place, applying them")
+		cfg.Run()
+		return false
+	}
+
+	log.Warnf("Detected drift of the iptables rules, %d rules missing and %d unexpected rules, repairing them",
+		d.missing, d.unexpected)
+	if d.missing > 0 {
+		driftDetected.With(typeLabel.Value(missingType)).Increment()
+	}
+	if d.unexpected > 0 {
+		driftDetected.With(typeLabel.Value(unexpectedType)).Increment()
+	}
+	if err := cfg.repair(); err != nil {
+		log.Errorf("Failed to repair the iptables rules: %v", err)
+		driftRepaired.With(resultLabel.Value(resultFail)).Increment()
+	} else if d := cfg.detectDrift(); d.drifted() {
+		log.Errorf("Failed to repair the iptables rules, %d rules missing and %d unexpected rules", d.missing, d.unexpected)
+		driftRepaired.With(resultLabel.Value(resultFail)).Increment()
+	} else {
+		log.Info("Repaired the iptables rules")
+		driftRepaired.With(resultLabel.Value(resultSuccess)).Increment()
+	}
+	return true
+}
+
+// ReconcileLoop reconciles the rules every interval, until stop is closed. It is run by the long-lived components
+// applying the rules, pilot-agent and the CNI node agent, rather than istio-iptables which exits once they are applied.
+func (cfg *IptablesConfigurator) ReconcileLoop(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			cfg.Reconcile()
+		}
+	}
+}
+
+func (cfg *IptablesConfigurator) detectDrift() drift {
+	v4 := cfg.detectTablesDrift(constants.IPTABLES, cfg.iptables.BuildCheckV4(), cfg.iptables.ChainsV4())
+	v6 := cfg.detectTablesDrift(constants.IP6TABLES, cfg.iptables.BuildCheckV6(), cfg.iptables.ChainsV6())
+	return drift{
+		present:    v4.present + v6.present,
+		missing:    v4.missing + v6.missing,
+		unexpected: v4.unexpected + v6.unexpected,
+	}
+}
+
+// detectTablesDrift checks that each of the expected rules is in place, and counts the rules of the chains created by
+// istio-iptables to find unexpected rules added to them. Rules added to the built-in chains are not reported as drift,
+// as they are shared with other components.
+func (cfg *IptablesConfigurator) detectTablesDrift(cmd string, checks [][]string, chains []builder.Chain) drift {
+	d := drift{}
+	for _, check := range checks {
+		if _, err := cfg.ext.RunWithOutput(check[0], check[1:]...); err != nil {
+			d.missing++
+		} else {
+			d.present++
+		}
+	}
+	for _, c := range chains {
+		out, err := cfg.ext.RunWithOutput(cmd, "-t", c.Table, "-S", c.Name)
+		if err != nil {
+			// The chain does not exist, its rules are reported as missing.
+			continue
+		}
+		rules := 0
+		for _, line := range strings.Split(out.String(), "\n") {
+			if strings.HasPrefix(line, "-A "+c.Name+" ") {
+				rules++
+			}
+		}
+		if rules > c.Rules {
+			d.unexpected += rules - c.Rules
+		}
+	}
+	return d
+}
+
+// repair rewrites the chains created by istio-iptables and adds the missing rules of the built-in chains with a single
+// iptables-restore --noflush per IP version, rather than removing the rules and applying them again, so that the
+// traffic of the pod is redirected to the sidecar at all times.
+func (cfg *IptablesConfigurator) repair() error {
+	inPlace := func(check []string) bool {
+		_, err := cfg.ext.RunWithOutput(check[0], check[1:]...)
+		return err == nil
+	}
+	if err := cfg.restore(constants.IPTABLESRESTORE, cfg.iptables.BuildRepairV4(inPlace)); err != nil {
+		return err
+	}
+	return cfg.restore(constants.IP6TABLESRESTORE, cfg.iptables.BuildRepairV6(inPlace))
+}
+
+// restore runs iptables-restore --noflush with the given input, if any.
+func (cfg *IptablesConfigurator) restore(cmd string, data string) error {
+	if data == "" {
+		return nil
+	}
+	rulesFile, err := os.CreateTemp("", cmd+"-repair-*.txt")
+	if err != nil {
+		return fmt.Errorf("unable to create %s file: %v", cmd, err)
+	}
+	defer os.Remove(rulesFile.Name())
+	if err := cfg.createRulesFile(rulesFile, data); err != nil {
+		return err
+	}
+	if _, err := cfg.ext.RunWithOutput(cmd, "--noflush", rulesFile.Name()); err != nil {
+		return fmt.Errorf("%s failed: %v", cmd, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/tools/istio-iptables/pkg/constants"
+)
+
+// iptablesState fakes the rules in place, as the specifications of the rules checked by istio-iptables.
+type iptablesState struct {
+	mu       sync.Mutex
+	rules    map[string]bool
+	extra    string
+	executed []string
+}
+
+func newIptablesState(cfg *IptablesConfigurator) *iptablesState {
+	s := &iptablesState{rules: map[string]bool{}}
+	for _, check := range cfg.iptables.BuildCheckV4() {
+		s.rules[strings.Join(check[3:], " ")] = false
+	}
+	return s
+}
+
+func (s *iptablesState) setAll(present bool) {
+	for r := range s.rules {
+		s.rules[r] = present
+	}
+}
+
+// applied returns whether the rules were applied.
+func (s *iptablesState) applied() bool {
+	for _, cmd := range s.executed {
+		if strings.HasPrefix(cmd, constants.IPTABLESRESTORE) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *iptablesState) RunOrFail(cmd string, args ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executed = append(s.executed, strings.Join(append([]string{cmd}, args...), " "))
+	if cmd == constants.IPTABLESRESTORE || cmd == constants.IPTABLES && args[2] == "-A" {
+		s.setAll(true)
+	}
+}
+
+func (s *iptablesState) Run(cmd string, args ...string) error {
+	return nil
+}
+
+func (s *iptablesState) RunQuietlyAndIgnore(cmd string, args ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executed = append(s.executed, strings.Join(append([]string{cmd}, args...), " "))
+}
+
+func (s *iptablesState) RunWithOutput(cmd string, args ...string) (*bytes.Buffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case cmd == constants.IPTABLESRESTORE:
+		// The repair rewrites the chains created by istio-iptables.
+		s.executed = append(s.executed, strings.Join(append([]string{cmd}, args...), " "))
+		s.setAll(true)
+		s.extra = ""
+	case cmd != constants.IPTABLES:
+		return &bytes.Buffer{}, errors.New("unexpected command")
+	case args[2] == "-C":
+		if !s.rules[strings.Join(args[2:], " ")] {
+			return nil, errors.New("bad rule")
+		}
+	case args[2] == "-S" && args[3] == constants.ISTIOOUTPUT:
+		return bytes.NewBufferString(s.extra), nil
+	}
+	return &bytes.Buffer{}, nil
+}
+
+func TestReconcile(t *testing.T) {
+	cfg := NewIptablesConfigurator(constructTestConfig(), nil)
+	cfg.build()
+	state := newIptablesState(cfg)
+	cfg.ext = state
+
+	if cfg.Reconcile() {
+		t.Fatal("expected no drift when no rules are in place")
+	}
+	if !state.applied() {
+		t.Fatalf("expected the rules to be applied, executed %v", state.executed)
+	}
+
+	state.executed = nil
+	if cfg.Reconcile() {
+		t.Fatal("expected no drift when the rules are in place")
+	}
+	if len(state.executed) != 0 {
+		t.Fatalf("expected no command to be executed, executed %v", state.executed)
+	}
+
+	// A rule removed by another component.
+	for r := range state.rules {
+		state.rules[r] = false
+		break
+	}
+	state.executed = nil
+	if !cfg.Reconcile() {
+		t.Fatal("expected drift when a rule is missing")
+	}
+	// The rules are repaired in place, without removing them first.
+	if len(state.executed) != 1 || !strings.HasPrefix(state.executed[0], constants.IPTABLESRESTORE+" --noflush ") {
+		t.Fatalf("expected the rules to be repaired with a single iptables-restore, executed %v", state.executed)
+	}
+	for r, present := range state.rules {
+		if !present {
+			t.Fatalf("expected rule %s to be repaired", r)
+		}
+	}
+
+	// An unexpected rule added to an Istio chain.
+	state.executed = nil
+	state.extra = "-N ISTIO_OUTPUT\n-A ISTIO_OUTPUT -j ACCEPT\n" + strings.Repeat("-A ISTIO_OUTPUT -j RETURN\n", 20)
+	if !cfg.Reconcile() {
+		t.Fatal("expected drift when an unexpected rule is in place")
+	}
+	if state.extra != "" {
+		t.Fatal("expected the unexpected rules to be removed")
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	cfg := constructTestConfig()
+	cfg.DryRun = true
+	state := &iptablesState{}
+	iptConfigurator := NewIptablesConfigurator(cfg, state)
+	if iptConfigurator.Reconcile() {
+		t.Fatal("expected no drift in a dry run")
+	}
+	if !state.applied() {
+		t.Fatalf("expected the rules to be applied, executed %v", state.executed)
+	}
+}
+
+func TestReconcileLoop(t *testing.T) {
+	cfg := NewIptablesConfigurator(constructTestConfig(), nil)
+	cfg.build()
+	state := newIptablesState(cfg)
+	state.setAll(true)
+	cfg.ext = state
+	// A rule removed by another component, after the rules were applied.
+	cfg.applied = true
+	for r := range state.rules {
+		state.rules[r] = false
+		break
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		cfg.ReconcileLoop(time.Millisecond, stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(time.Millisecond) {
+		state.mu.Lock()
+		repaired := state.applied()
+		state.mu.Unlock()
+		if repaired {
+			return
+		}
+	}
+	t.Fatal("expected the missing rule to be repaired")
+}
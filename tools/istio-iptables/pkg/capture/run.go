@@ -18,11 +18,13 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/vishvananda/netlink"
 
+	istioconstants "istio.io/istio/pkg/config/constants"
 	"istio.io/istio/tools/istio-iptables/pkg/builder"
 	"istio.io/istio/tools/istio-iptables/pkg/config"
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
@@ -54,6 +56,10 @@ type IptablesConfigurator struct {
 	// TODO(abhide): Fix dep.Dependencies with better interface
 	ext dep.Dependencies
 	cfg *config.Config
+	// built is set once the rules are added to the builder.
+	built bool
+	// applied is set once the rules are applied, after which missing rules are reported as drift.
+	applied bool
 }
 
 func NewIptablesConfigurator(cfg *config.Config, ext dep.Dependencies) *IptablesConfigurator {
@@ -74,6 +80,12 @@ func split(s string) []string {
 	return config.Split(s)
 }
 
+// dport returns the --dport value of a port, or of an inclusive port range such as 10000-20000, which is
+// matched by a single rule.
+func dport(port string) string {
+	return strings.Replace(port, "-", ":", 1)
+}
+
 func (cfg *IptablesConfigurator) separateV4V6(cidrList string) (NetworkRange, NetworkRange, error) {
 	if cidrList == "*" {
 		return NetworkRange{IsWildcard: true}, NetworkRange{IsWildcard: true}, nil
@@ -159,7 +171,7 @@ func (cfg *IptablesConfigurator) handleInboundPortsInclude() {
 			if cfg.cfg.InboundPortsExclude != "" {
 				for _, port := range split(cfg.cfg.InboundPortsExclude) {
 					cfg.iptables.AppendRule(iptableslog.ExcludeInboundPort, constants.ISTIOINBOUND, table, "-p", constants.TCP,
-						"--dport", port, "-j", constants.RETURN)
+						"--dport", dport(port), "-j", constants.RETURN)
 				}
 			}
 			// Redirect remaining inbound traffic to Envoy.
@@ -178,6 +190,7 @@ func (cfg *IptablesConfigurator) handleInboundPortsInclude() {
 		} else {
 			// User has specified a non-empty list of ports to be redirected to Envoy.
 			for _, port := range split(cfg.cfg.InboundPortsInclude) {
+				port = dport(port)
 				if cfg.cfg.InboundInterceptionMode == constants.TPROXY {
 					cfg.iptables.AppendRule(iptableslog.IncludeInboundPort, constants.ISTIOINBOUND, constants.MANGLE, "-p", constants.TCP,
 						"--dport", port, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", constants.ISTIODIVERT)
@@ -309,13 +322,27 @@ func configureIPv6Addresses(cfg *config.Config) error {
 }
 
 func (cfg *IptablesConfigurator) Run() {
-	defer func() {
-		// Best effort since we don't know if the commands exist
-		_ = cfg.ext.Run(constants.IPTABLESSAVE)
-		if cfg.cfg.EnableInboundIPv6 {
-			_ = cfg.ext.Run(constants.IP6TABLESSAVE)
-		}
-	}()
+	defer cfg.saveRules()
+	cfg.build()
+	cfg.executeCommands()
+	cfg.applied = true
+}
+
+// saveRules logs the rules in place.
+func (cfg *IptablesConfigurator) saveRules() {
+	// Best effort since we don't know if the commands exist
+	_ = cfg.ext.Run(constants.IPTABLESSAVE)
+	if cfg.cfg.EnableInboundIPv6 {
+		_ = cfg.ext.Run(constants.IP6TABLESSAVE)
+	}
+}
+
+// build adds the rules to the builder. It only does so once, so that the rules can be reconciled repeatedly.
+func (cfg *IptablesConfigurator) build() {
+	if cfg.built {
+		return
+	}
+	cfg.built = true
 
 	// Since OUTBOUND_IP_RANGES_EXCLUDE could carry ipv4 and ipv6 ranges
 	// need to split them in different arrays one for ipv4 and one for ipv6
@@ -528,6 +555,11 @@ func (cfg *IptablesConfigurator) Run() {
 			ownerGroupsFilter)
 	}
 
+	if cfg.cfg.OutboundUDPPorts != "" {
+		HandleOutboundUDPPorts(AppendOps, cfg.iptables, cfg.ext, "", cfg.cfg.ProxyUID, cfg.cfg.ProxyGID, cfg.cfg.OutboundUDPPorts,
+			redirectDNS)
+	}
+
 	if cfg.cfg.InboundInterceptionMode == constants.TPROXY {
 		// save packet mark set by envoy.filters.listener.original_src as connection mark
 		cfg.iptables.AppendRule(iptableslog.UndefinedCommand, constants.PREROUTING, constants.MANGLE,
@@ -564,7 +596,6 @@ func (cfg *IptablesConfigurator) Run() {
 		cfg.iptables.InsertRule(iptableslog.UndefinedCommand, constants.ISTIOINBOUND, constants.MANGLE, 3,
 			"-p", constants.TCP, "-i", "lo", "-m", "mark", "!", "--mark", outboundMark, "-j", constants.RETURN)
 	}
-	cfg.executeCommands()
 }
 
 type UDPRuleApplier struct {
@@ -723,6 +754,42 @@ func addConntrackZoneDNSUDP(
 	}
 }
 
+// HandleOutboundUDPPorts is a helper function to tackle with the UDP ports proxied by Envoy.
+// Outbound UDP traffic to each port, except Envoy's own, is redirected to the port on localhost where Envoy binds a
+// UDP proxy listener, the same port or, for privileged ports, the port shifted by OutboundUDPPrivilegedPortOffset.
+// Port 53 is left to the DNS capture rules when DNS is redirected.
+// This helps the creation logic of the rules in sync with the deletion.
+func HandleOutboundUDPPorts(ops Ops, iptables *builder.IptablesBuilder, ext dep.Dependencies, cmd, proxyUID, proxyGID, ports string,
+	redirectDNS bool) {
+	f := UDPRuleApplier{
+		iptables: iptables,
+		ext:      ext,
+		ops:      ops,
+		table:    constants.NAT,
+		chain:    constants.OUTPUT,
+		cmd:      cmd,
+	}
+	for _, port := range split(ports) {
+		if port == "53" && redirectDNS {
+			if ops == AppendOps {
+				log.Warnf("not redirecting outbound UDP port 53, which is captured for DNS")
+			}
+			continue
+		}
+		toPort := port
+		if p, err := strconv.Atoi(port); err == nil && p < 1024 {
+			toPort = strconv.Itoa(p + istioconstants.OutboundUDPPrivilegedPortOffset)
+		}
+		for _, uid := range split(proxyUID) {
+			f.Run("-p", "udp", "--dport", port, "-m", "owner", "--uid-owner", uid, "-j", constants.RETURN)
+		}
+		for _, gid := range split(proxyGID) {
+			f.Run("-p", "udp", "--dport", port, "-m", "owner", "--gid-owner", gid, "-j", constants.RETURN)
+		}
+		f.Run("-p", "udp", "--dport", port, "-j", constants.REDIRECT, "--to-port", toPort)
+	}
+}
+
 func (cfg *IptablesConfigurator) handleOutboundPortsInclude() {
 	if cfg.cfg.OutboundPortsInclude != "" {
 		for _, port := range split(cfg.cfg.OutboundPortsInclude) {
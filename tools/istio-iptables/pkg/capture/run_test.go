@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	testutil "istio.io/istio/pilot/test/util"
+	"istio.io/istio/tools/istio-iptables/pkg/builder"
 	"istio.io/istio/tools/istio-iptables/pkg/config"
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
 	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
@@ -153,6 +154,27 @@ func TestIptables(t *testing.T) {
 				cfg.OutboundIPRangesInclude = "10.0.0.0/8"
 			},
 		},
+		{
+			"inbound-ports-range",
+			func(cfg *config.Config) {
+				cfg.InboundPortsInclude = "8080,10000-20000"
+			},
+		},
+		{
+			"outbound-udp-ports",
+			func(cfg *config.Config) {
+				cfg.OutboundUDPPorts = "514,5353"
+				cfg.ProxyGID = "1,2"
+				cfg.ProxyUID = "3,4"
+			},
+		},
+		{
+			"inbound-ports-wildcard-exclude-range",
+			func(cfg *config.Config) {
+				cfg.InboundPortsInclude = "*"
+				cfg.InboundPortsExclude = "10000-20000"
+			},
+		},
 		{
 			"inbound-ports-include",
 			func(cfg *config.Config) {
@@ -332,6 +354,24 @@ func TestSeparateV4V6(t *testing.T) {
 	}
 }
 
+func TestHandleOutboundUDPPorts(t *testing.T) {
+	iptables := builder.NewIptablesBuilder(nil)
+	HandleOutboundUDPPorts(AppendOps, iptables, &dep.StdoutStubDependencies{}, "", "1337", "", "53,514,5353", true)
+	got := make([]string, 0)
+	for _, rule := range iptables.BuildV4() {
+		got = append(got, strings.Join(rule, " "))
+	}
+	want := []string{
+		"iptables -t nat -A OUTPUT -p udp --dport 514 -m owner --uid-owner 1337 -j RETURN",
+		"iptables -t nat -A OUTPUT -p udp --dport 514 -j REDIRECT --to-port 16514",
+		"iptables -t nat -A OUTPUT -p udp --dport 5353 -m owner --uid-owner 1337 -j RETURN",
+		"iptables -t nat -A OUTPUT -p udp --dport 5353 -j REDIRECT --to-port 5353",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
 func compareToGolden(t *testing.T, name string, actual []string) {
 	t.Helper()
 	gotBytes := []byte(strings.Join(actual, "\n"))
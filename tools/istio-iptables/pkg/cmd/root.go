@@ -54,19 +54,15 @@ var rootCmd = &cobra.Command{
 		if err := cfg.Validate(); err != nil {
 			handleErrorWithCode(err, 1)
 		}
-		var ext dep.Dependencies
-		if cfg.DryRun {
-			ext = &dep.StdoutStubDependencies{}
-		} else {
-			ext = &dep.RealDependencies{
-				CNIMode:          cfg.CNIMode,
-				NetworkNamespace: cfg.NetworkNamespace,
-			}
-		}
+		ext := newDependencies(cfg)
 
 		iptConfigurator := capture.NewIptablesConfigurator(cfg, ext)
 		if !cfg.SkipRuleApply {
-			iptConfigurator.Run()
+			if cfg.Reconcile {
+				iptConfigurator.Reconcile()
+			} else {
+				iptConfigurator.Run()
+			}
 			if err := capture.ConfigureRoutes(cfg, ext); err != nil {
 				log.Errorf("failed to configure routes: ")
 				handleErrorWithCode(err, 1)
@@ -104,6 +100,30 @@ var configureRoutesCommand = &cobra.Command{
 	},
 }
 
+func newDependencies(cfg *config.Config) dep.Dependencies {
+	if cfg.DryRun {
+		return &dep.StdoutStubDependencies{}
+	}
+	return &dep.RealDependencies{
+		CNIMode:          cfg.CNIMode,
+		NetworkNamespace: cfg.NetworkNamespace,
+	}
+}
+
+// ConstructConfig returns the configuration set by the flags and environment variables of istio-iptables, without
+// applying it, for the components reconciling the rules it applies.
+func ConstructConfig() (*config.Config, error) {
+	bindFlags(rootCmd, nil)
+	cfg := constructConfig()
+	return cfg, cfg.Validate()
+}
+
+// NewReconciler returns the configurator reconciling the rules of cfg, see capture.IptablesConfigurator.ReconcileLoop.
+func NewReconciler(cfg *config.Config) *capture.IptablesConfigurator {
+	cfg.Reconcile = true
+	return capture.NewIptablesConfigurator(cfg, newDependencies(cfg))
+}
+
 func constructConfig() *config.Config {
 	cfg := &config.Config{
 		DryRun:                  viper.GetBool(constants.DryRun),
@@ -123,6 +143,7 @@ func constructConfig() *config.Config {
 		OwnerGroupsExclude:      viper.GetString(constants.OwnerGroupsExclude.Name),
 		OutboundPortsInclude:    viper.GetString(constants.OutboundPorts),
 		OutboundPortsExclude:    viper.GetString(constants.LocalOutboundPortsExclude),
+		OutboundUDPPorts:        viper.GetString(constants.OutboundUDPPorts),
 		OutboundIPRangesInclude: viper.GetString(constants.ServiceCidr),
 		OutboundIPRangesExclude: viper.GetString(constants.ServiceExcludeCidr),
 		KubeVirtInterfaces:      viper.GetString(constants.KubeVirtInterfaces),
@@ -137,6 +158,7 @@ func constructConfig() *config.Config {
 		OutputPath:              viper.GetString(constants.OutputPath),
 		NetworkNamespace:        viper.GetString(constants.NetworkNamespace),
 		CNIMode:                 viper.GetBool(constants.CNIMode),
+		Reconcile:               viper.GetBool(constants.Reconcile),
 	}
 
 	// TODO: Make this more configurable, maybe with an allowlist of users to be captured for output instead of a denylist.
@@ -288,6 +310,11 @@ func bindFlags(cmd *cobra.Command, args []string) {
 	}
 	viper.SetDefault(constants.LocalOutboundPortsExclude, "")
 
+	if err := viper.BindPFlag(constants.OutboundUDPPorts, cmd.Flags().Lookup(constants.OutboundUDPPorts)); err != nil {
+		handleError(err)
+	}
+	viper.SetDefault(constants.OutboundUDPPorts, "")
+
 	if err := viper.BindPFlag(constants.KubeVirtInterfaces, cmd.Flags().Lookup(constants.KubeVirtInterfaces)); err != nil {
 		handleError(err)
 	}
@@ -367,6 +394,11 @@ func bindFlags(cmd *cobra.Command, args []string) {
 		handleError(err)
 	}
 	viper.SetDefault(constants.CNIMode, false)
+
+	if err := viper.BindPFlag(constants.Reconcile, cmd.Flags().Lookup(constants.Reconcile)); err != nil {
+		handleError(err)
+	}
+	viper.SetDefault(constants.Reconcile, false)
 }
 
 // https://github.com/spf13/viper/issues/233.
@@ -396,11 +428,11 @@ func bindCmdlineFlags(rootCmd *cobra.Command) {
 		"The mode used to redirect inbound connections to Envoy, either \"REDIRECT\" or \"TPROXY\"")
 
 	rootCmd.Flags().StringP(constants.InboundPorts, "b", "",
-		"Comma separated list of inbound ports for which traffic is to be redirected to Envoy (optional). "+
+		"Comma separated list of inbound ports and port ranges, such as 10000-20000, for which traffic is to be redirected to Envoy (optional). "+
 			"The wildcard character \"*\" can be used to configure redirection for all ports. An empty list will disable")
 
 	rootCmd.Flags().StringP(constants.LocalExcludePorts, "d", "",
-		"Comma separated list of inbound ports to be excluded from redirection to Envoy (optional). "+
+		"Comma separated list of inbound ports and port ranges to be excluded from redirection to Envoy (optional). "+
 			"Only applies when all inbound traffic (i.e. \"*\") is being redirected (default to $ISTIO_LOCAL_EXCLUDE_PORTS)")
 
 	rootCmd.Flags().StringP(constants.ExcludeInterfaces, "", "",
@@ -420,6 +452,9 @@ func bindCmdlineFlags(rootCmd *cobra.Command) {
 	rootCmd.Flags().StringP(constants.LocalOutboundPortsExclude, "o", "",
 		"Comma separated list of outbound ports to be excluded from redirection to Envoy")
 
+	rootCmd.Flags().String(constants.OutboundUDPPorts, "",
+		"Comma separated list of outbound UDP ports to be redirected to the same port of Envoy on localhost")
+
 	rootCmd.Flags().StringP(constants.KubeVirtInterfaces, "k", "",
 		"Comma separated list of virtual interfaces whose inbound traffic (from VM) will be treated as outbound")
 
@@ -453,6 +488,9 @@ func bindCmdlineFlags(rootCmd *cobra.Command) {
 	rootCmd.Flags().String(constants.NetworkNamespace, "", "The network namespace that iptables rules should be applied to.")
 
 	rootCmd.Flags().Bool(constants.CNIMode, false, "Whether to run as CNI plugin.")
+
+	rootCmd.Flags().Bool(constants.Reconcile, false,
+		"Apply the rules idempotently: check the rules in place, and only apply them if they are missing or repair them if they drifted.")
 }
 
 func GetCommand() *cobra.Command {
@@ -88,6 +88,7 @@ const (
 	ServiceExcludeCidr        = "istio-service-exclude-cidr"
 	OutboundPorts             = "istio-outbound-ports"
 	LocalOutboundPortsExclude = "istio-local-outbound-ports-exclude"
+	OutboundUDPPorts          = "istio-outbound-udp-ports"
 	EnvoyPort                 = "envoy-port"
 	InboundCapturePort        = "inbound-capture-port"
 	InboundTunnelPort         = "inbound-tunnel-port"
@@ -108,6 +109,7 @@ const (
 	OutputPath                = "output-paths"
 	NetworkNamespace          = "network-namespace"
 	CNIMode                   = "cni-mode"
+	Reconcile                 = "reconcile"
 )
 
 // Environment variables that deliberately have no equivalent command-line flags.
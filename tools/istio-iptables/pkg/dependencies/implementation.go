@@ -70,13 +70,13 @@ type RealDependencies struct {
 	CNIMode          bool
 }
 
-func (r *RealDependencies) execute(cmd string, ignoreErrors bool, args ...string) error {
+func (r *RealDependencies) execute(cmd string, ignoreErrors bool, args ...string) (*bytes.Buffer, error) {
 	if r.CNIMode {
 		originalCmd := cmd
 		cmd = constants.NSENTER
 		args = append([]string{fmt.Sprintf("--net=%v", r.NetworkNamespace), "--", originalCmd}, args...)
 	}
-	log.Infof("Running command: %s %s", cmd, strings.Join(args, " "))
+	logCommand(cmd, ignoreErrors, args...)
 	externalCommand := exec.Command(cmd, args...)
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
@@ -95,7 +95,7 @@ func (r *RealDependencies) execute(cmd string, ignoreErrors bool, args ...string
 
 	err := externalCommand.Run()
 
-	if len(stdout.String()) != 0 {
+	if !ignoreErrors && len(stdout.String()) != 0 {
 		log.Infof("Command output: \n%v", stdout.String())
 	}
 
@@ -103,16 +103,26 @@ func (r *RealDependencies) execute(cmd string, ignoreErrors bool, args ...string
 		log.Errorf("Command error output: \n%v", stderr.String())
 	}
 
-	return err
+	return stdout, err
+}
+
+// logCommand logs a command about to be run. Commands run quietly, such as the periodic checks of the rules, are
+// only logged at debug level.
+func logCommand(cmd string, quietly bool, args ...string) {
+	if quietly {
+		log.Debugf("Running command: %s %s", cmd, strings.Join(args, " "))
+		return
+	}
+	log.Infof("Running command: %s %s", cmd, strings.Join(args, " "))
 }
 
-func (r *RealDependencies) executeXTables(cmd string, ignoreErrors bool, args ...string) error {
+func (r *RealDependencies) executeXTables(cmd string, ignoreErrors bool, args ...string) (*bytes.Buffer, error) {
 	if r.CNIMode {
 		originalCmd := cmd
 		cmd = constants.NSENTER
 		args = append([]string{fmt.Sprintf("--net=%v", r.NetworkNamespace), "--", originalCmd}, args...)
 	}
-	log.Infof("Running command: %s %s", cmd, strings.Join(args, " "))
+	logCommand(cmd, ignoreErrors, args...)
 
 	var stdout, stderr *bytes.Buffer
 
@@ -146,10 +156,10 @@ func (r *RealDependencies) executeXTables(cmd string, ignoreErrors bool, args ..
 		return err
 	}, b)
 	if backoffError != nil {
-		return fmt.Errorf("timed out trying to acquire XTables lock: %v", err)
+		return nil, fmt.Errorf("timed out trying to acquire XTables lock: %v", err)
 	}
 
-	if len(stdout.String()) != 0 {
+	if !ignoreErrors && len(stdout.String()) != 0 {
 		log.Infof("Command output: \n%v", stdout.String())
 	}
 
@@ -165,7 +175,7 @@ func (r *RealDependencies) executeXTables(cmd string, ignoreErrors bool, args ..
 		log.Errorf("Command error output: %v", stderrStr)
 	}
 
-	return err
+	return stdout, err
 }
 
 // transformToXTablesErrorMessage returns an updated error message with explicit xtables error hints, if applicable.
@@ -231,9 +241,9 @@ func exitCode(err error) (int, bool) {
 func (r *RealDependencies) RunOrFail(cmd string, args ...string) {
 	var err error
 	if XTablesCmds.Contains(cmd) {
-		err = r.executeXTables(cmd, false, args...)
+		_, err = r.executeXTables(cmd, false, args...)
 	} else {
-		err = r.execute(cmd, false, args...)
+		_, err = r.execute(cmd, false, args...)
 	}
 	if err != nil {
 		log.Errorf("Failed to execute: %s %s, %v", cmd, strings.Join(args, " "), err)
@@ -244,18 +254,26 @@ func (r *RealDependencies) RunOrFail(cmd string, args ...string) {
 // Run runs a command
 func (r *RealDependencies) Run(cmd string, args ...string) (err error) {
 	if XTablesCmds.Contains(cmd) {
-		err = r.executeXTables(cmd, false, args...)
+		_, err = r.executeXTables(cmd, false, args...)
 	} else {
-		err = r.execute(cmd, false, args...)
+		_, err = r.execute(cmd, false, args...)
 	}
 	return err
 }
 
+// RunWithOutput runs a command quietly and returns its output
+func (r *RealDependencies) RunWithOutput(cmd string, args ...string) (*bytes.Buffer, error) {
+	if XTablesCmds.Contains(cmd) {
+		return r.executeXTables(cmd, true, args...)
+	}
+	return r.execute(cmd, true, args...)
+}
+
 // RunQuietlyAndIgnore runs a command quietly and ignores errors
 func (r *RealDependencies) RunQuietlyAndIgnore(cmd string, args ...string) {
 	if XTablesCmds.Contains(cmd) {
-		_ = r.executeXTables(cmd, true, args...)
+		_, _ = r.executeXTables(cmd, true, args...)
 	} else {
-		_ = r.execute(cmd, true, args...)
+		_, _ = r.execute(cmd, true, args...)
 	}
 }
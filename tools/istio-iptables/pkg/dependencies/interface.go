@@ -14,6 +14,8 @@
 
 package dependencies
 
+import "bytes"
+
 // Dependencies is used as abstraction for the commands used from the operating system
 type Dependencies interface {
 	// RunOrFail runs a command and panics, if it fails
@@ -22,4 +24,6 @@ type Dependencies interface {
 	Run(cmd string, args ...string) error
 	// RunQuietlyAndIgnore runs a command quietly and ignores errors
 	RunQuietlyAndIgnore(cmd string, args ...string)
+	// RunWithOutput runs a command quietly and returns its output
+	RunWithOutput(cmd string, args ...string) (*bytes.Buffer, error)
 }